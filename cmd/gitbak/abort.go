@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunAbort discards the most recent gitbak session for the repository.
+// It returns to the branch that was checked out when the session started and
+// deletes the session branch, or deletes the checkpoint refs of a -refs-only
+// session, after asking for confirmation unless -yes is set.
+func (a *App) RunAbort(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to abort in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	// Holding the lock guarantees no gitbak process is still committing to the session branch
+	if err := a.acquireLock(ctx); err != nil {
+		if gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+			return gitbakErrors.Wrap(err, "stop the running session before aborting it")
+		}
+		return gitbakErrors.Wrap(gitbakErrors.ErrLockAcquisitionFailure, err.Error())
+	}
+
+	question := fmt.Sprintf("Delete branch '%s' with %d checkpoint commit(s) and return to '%s'?",
+		state.Branch, state.CommitsCount, state.OriginalBranch)
+	if state.Refs != "" {
+		question = fmt.Sprintf("Delete the %d checkpoint ref(s) under %s?", state.CommitsCount, state.Refs)
+	}
+	if !a.Config.AssumeYes && !a.interactor.PromptYesNo(question) {
+		_, _ = fmt.Fprintln(a.Stdout, "Abort cancelled, nothing was changed.")
+		return nil
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	if err := repo.AbortSession(ctx, state); err != nil {
+		return err
+	}
+
+	if err := session.Remove(a.Config.StateFile); err != nil {
+		a.Logger.Warning("Failed to remove session state: %v", err)
+	}
+
+	if state.Refs != "" {
+		a.Logger.Success("Session aborted: deleted the checkpoint refs under %s", state.Refs)
+		return nil
+	}
+	a.Logger.Success("Session aborted: deleted '%s' and returned to '%s'", state.Branch, state.OriginalBranch)
+	return nil
+}
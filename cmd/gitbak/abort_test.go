@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestSplitCommand tests that a leading subcommand is separated from its flags
+func TestSplitCommand(t *testing.T) {
+	tests := map[string]struct {
+		args         []string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		"NoArgs": {
+			args:         nil,
+			expectedArgs: nil,
+		},
+		"FlagsOnly": {
+			args:         []string{"-interval", "2"},
+			expectedArgs: []string{"-interval", "2"},
+		},
+		"AbortWithFlags": {
+			args:         []string{"abort", "-yes"},
+			expectedCmd:  "abort",
+			expectedArgs: []string{"-yes"},
+		},
+		"Squash": {
+			args:         []string{"squash"},
+			expectedCmd:  "squash",
+			expectedArgs: []string{},
+		},
+		"Start": {
+			args:         []string{"start", "-interval", "2"},
+			expectedCmd:  "start",
+			expectedArgs: []string{"-interval", "2"},
+		},
+		"Status": {
+			args:         []string{"status"},
+			expectedCmd:  "status",
+			expectedArgs: []string{},
+		},
+		"VerifyWithFlags": {
+			args:         []string{"verify", "-repo", "/tmp"},
+			expectedCmd:  "verify",
+			expectedArgs: []string{"-repo", "/tmp"},
+		},
+		"UnknownCommand": {
+			args:         []string{"frobnicate"},
+			expectedArgs: []string{"frobnicate"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd, args := splitCommand(test.args)
+
+			gotCmd := ""
+			if cmd != nil {
+				gotCmd = cmd.name
+			}
+			if gotCmd != test.expectedCmd {
+				t.Errorf("Expected command %q, got %q", test.expectedCmd, gotCmd)
+			}
+			if strings.Join(args, " ") != strings.Join(test.expectedArgs, " ") {
+				t.Errorf("Expected args %v, got %v", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+// TestRunAbort tests the abort command against a real repository
+func TestRunAbort(t *testing.T) {
+	tests := map[string]struct {
+		writeState     bool
+		assumeYes      bool
+		confirm        bool
+		errorContains  string
+		expectAborted  bool
+		outputContains string
+	}{
+		"NoSession": {
+			writeState:    false,
+			assumeYes:     true,
+			errorContains: "no gitbak session to abort",
+		},
+		"ConfirmationDeclined": {
+			writeState:     true,
+			confirm:        false,
+			outputContains: "Abort cancelled",
+		},
+		"ConfirmationAccepted": {
+			writeState:    true,
+			confirm:       true,
+			expectAborted: true,
+		},
+		"AssumeYes": {
+			writeState:    true,
+			assumeYes:     true,
+			expectAborted: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				out, err := exec.Command("git", "-C", repoPath, "branch", "--show-current").Output()
+				if err != nil {
+					t.Fatalf("Failed to get current branch: %v", err)
+				}
+				originalBranch := strings.TrimSpace(string(out))
+
+				if err := exec.Command("git", "-C", repoPath, "checkout", "-b", "gitbak-session").Run(); err != nil {
+					t.Fatalf("Failed to create session branch: %v", err)
+				}
+
+				stateFile := filepath.Join(t.TempDir(), "state.json")
+				if test.writeState {
+					state := &session.State{
+						RepoPath:       repoPath,
+						Branch:         "gitbak-session",
+						OriginalBranch: originalBranch,
+						CreatedBranch:  true,
+					}
+					if err := session.Save(stateFile, state); err != nil {
+						t.Fatalf("Failed to save state: %v", err)
+					}
+				}
+
+				var stdout, stderr bytes.Buffer
+				mockLocker := &MockLocker{}
+				app := NewTestApp()
+				app = WithMockLocker(app, mockLocker)
+				app = WithMockLogger(app, &MockLogger{})
+				app.Stdout = &stdout
+				app.Stderr = &stderr
+				app.interactor = git.NewMockInteractor(test.confirm)
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.AssumeYes = test.assumeYes
+
+				err = app.RunAbort(context.Background())
+
+				if test.errorContains != "" {
+					if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+						t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("RunAbort failed: %v", err)
+				}
+
+				if !mockLocker.AcquireCalled {
+					t.Error("Expected abort to acquire the repository lock")
+				}
+
+				if test.outputContains != "" && !strings.Contains(stdout.String(), test.outputContains) {
+					t.Errorf("Expected output to contain %q, got %q", test.outputContains, stdout.String())
+				}
+
+				branchErr := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "gitbak-session").Run()
+				_, stateErr := session.Load(stateFile)
+
+				if test.expectAborted {
+					if branchErr == nil {
+						t.Error("Expected session branch to be deleted")
+					}
+					if stateErr == nil {
+						t.Error("Expected session state to be removed")
+					}
+				} else {
+					if branchErr != nil {
+						t.Error("Expected session branch to be kept")
+					}
+					if stateErr != nil {
+						t.Errorf("Expected session state to be kept, got %v", stateErr)
+					}
+				}
+			})
+		})
+	}
+}
@@ -4,22 +4,39 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/bashhack/gitbak/pkg/config"
 	"github.com/bashhack/gitbak/pkg/constants"
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/hooks"
 	"github.com/bashhack/gitbak/pkg/lock"
 	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/metrics"
+	"github.com/bashhack/gitbak/pkg/mirror"
+	"github.com/bashhack/gitbak/pkg/notify"
+	"github.com/bashhack/gitbak/pkg/power"
+	"github.com/bashhack/gitbak/pkg/service"
+	"github.com/bashhack/gitbak/pkg/tracing"
+	"github.com/bashhack/gitbak/pkg/watch"
 )
 
+// startupCheckTimeout bounds the git checks made before monitoring starts
+const startupCheckTimeout = 10 * time.Second
+
 // Gitbaker performs Git operations
 type Gitbaker interface {
-	PrintSummary()
+	PrintSummary(ctx context.Context)
 	Run(ctx context.Context) error
+	FinalCheckpoint(ctx context.Context) error
+	PushPending(ctx context.Context) error
+	RecordHistory()
 }
 
 // Locker manages file locking
@@ -50,8 +67,16 @@ type AppOptions struct {
 	// Handles the core functionality of repository monitoring and automatic commits.
 	Gitbak Gitbaker
 
+	// Interactor asks for confirmation before destructive commands (optional, a default will be created if nil).
+	// Used by subcommands such as abort.
+	Interactor git.UserInteractor
+
 	// I/O dependencies
 
+	// Stdin is the reader for standard input (optional, defaults to os.Stdin).
+	// Used for the requests of -rpc.
+	Stdin io.Reader
+
 	// Stdout is the writer for standard output (optional, defaults to os.Stdout).
 	// Used for user-facing messages and normal operation output.
 	Stdout io.Writer
@@ -70,9 +95,10 @@ type AppOptions struct {
 	// Used to locate the git executable.
 	ExecLookPath func(file string) (string, error)
 
-	// IsRepository checks if a path is a valid Git repository (optional, defaults to git.IsRepository).
+	// IsRepository checks if a path is a valid Git repository (optional, defaults to asking the
+	// configured git backend, with -git-path, -git-args, -git-dir and -work-tree).
 	// Used during initialization to validate the repository path.
-	IsRepository func(string) (bool, error)
+	IsRepository func(context.Context, string) (bool, error)
 }
 
 // App is the main gitbak application.
@@ -93,6 +119,9 @@ type App struct {
 
 	// I/O streams
 
+	// Stdin is the reader for standard input, which carries the requests of -rpc.
+	Stdin io.Reader
+
 	// Stdout is the writer for standard output messages.
 	Stdout io.Writer
 
@@ -108,7 +137,89 @@ type App struct {
 	execLookPath func(file string) (string, error)
 
 	// isRepository checks if a path is a valid Git repository.
-	isRepository func(string) (bool, error)
+	isRepository func(context.Context, string) (bool, error)
+
+	// interactor asks the user for confirmation before destructive commands.
+	interactor git.UserInteractor
+
+	// mirrors pushes the session branch to the configured mirror profiles, if any.
+	mirrors *mirror.Scheduler
+
+	// hooks runs the -on-start, -on-commit, -on-error and -on-stop commands, if any.
+	hooks *hooks.Runner
+
+	// hookStarted, hookBranch and lastCheckpoint describe the session to the stop hook, and
+	// hookRepo is where hooks run, which follows the repository if it moves. They are set by
+	// gitbak's callbacks, which run on the goroutine calling Run.
+	hookStarted    bool
+	hookBranch     string
+	hookRepo       string
+	lastCheckpoint git.Checkpoint
+
+	// pprofServer serves profiling endpoints when -pprof is set.
+	pprofServer *http.Server
+
+	// nudges carries early check requests from the nudge endpoint to gitbak.
+	nudges chan struct{}
+
+	// nudgeServer serves the nudge endpoint when -nudge-addr is set.
+	nudgeServer *http.Server
+
+	// controlServer serves the JSON control endpoint when -listen is set.
+	controlServer *http.Server
+
+	// rpc exchanges JSON-RPC messages with the editor hosting gitbak when -rpc is set.
+	rpc *rpcConn
+
+	// metrics collects the session's metrics when -metrics-addr is set.
+	metrics *metrics.Collector
+
+	// metricsServer serves the metrics endpoint when -metrics-addr is set.
+	metricsServer *http.Server
+
+	// tracer exports spans of checks and git commands when -otlp-endpoint is set.
+	tracer *tracing.Tracer
+
+	// paused is set while checkpointing is paused through the control endpoint or by signal.
+	paused atomic.Bool
+
+	// lowBattery is set while the machine runs on battery below -battery-threshold.
+	lowBattery atomic.Bool
+
+	// readPower reads the machine's power source when -battery-threshold is set.
+	readPower func() (power.Status, error)
+
+	// requestStop, if set, stops the session as a shutdown signal would, for POST /stop
+	// on the control endpoint.
+	requestStop func()
+
+	// checkNow carries immediate check requests from the control endpoint and commitNowSignal to gitbak.
+	checkNow chan struct{}
+
+	// watcher reports working tree changes when -watch is set and watching is supported.
+	watcher *watch.Watcher
+
+	// lockHolder returns the PID of the running gitbak process holding a repository's lock, if any.
+	lockHolder func(repoPath string) (int, bool)
+
+	// executable returns the path of the running gitbak binary, for starting detached sessions.
+	executable func() (string, error)
+
+	// servicePlatform returns the service manager that the service command installs services with.
+	servicePlatform func() (*service.Platform, error)
+
+	// runServiceCommand runs a service manager command, such as systemctl or launchctl.
+	runServiceCommand func(ctx context.Context, args []string) error
+
+	// desktopNotifier returns the function showing desktop notifications when -notify is set.
+	desktopNotifier func() (logger.NotifyFunc, error)
+
+	// dashboard is the live status view shown in place of log lines when -tui is set.
+	dashboard *dashboard
+
+	// shutdownCtx bounds the final checkpoint and summary once the session is stopped.
+	// main cancels it when -shutdown-timeout expires; if nil, shutdown is unbounded.
+	shutdownCtx context.Context
 }
 
 // NewDefaultApp creates an App with standard dependencies.
@@ -132,7 +243,6 @@ func NewDefaultApp(versionInfo config.VersionInfo) *App {
 		Stderr:       os.Stderr,
 		Exit:         os.Exit,
 		ExecLookPath: exec.LookPath,
-		IsRepository: git.IsRepository,
 	}
 
 	return NewApp(opts)
@@ -161,14 +271,28 @@ func NewApp(opts AppOptions) *App {
 		Logger:       opts.Logger,
 		Locker:       opts.Locker,
 		Gitbak:       opts.Gitbak,
+		Stdin:        opts.Stdin,
 		Stdout:       opts.Stdout,
 		Stderr:       opts.Stderr,
 		exit:         opts.Exit,
 		execLookPath: opts.ExecLookPath,
 		isRepository: opts.IsRepository,
+		interactor:   opts.Interactor,
+		executable:   os.Executable,
+
+		desktopNotifier:   notify.Desktop,
+		readPower:         power.Read,
+		servicePlatform:   service.Current,
+		runServiceCommand: runServiceCommand,
+	}
+	app.lockHolder = func(key string) (int, bool) {
+		return lock.HolderWithStaleAfter(key, app.Config.LockStaleAfter)
 	}
 
 	// Set defaults for nil dependencies
+	if app.Stdin == nil {
+		app.Stdin = os.Stdin
+	}
 	if app.Stdout == nil {
 		app.Stdout = os.Stdout
 	}
@@ -182,7 +306,7 @@ func NewApp(opts AppOptions) *App {
 		app.execLookPath = exec.LookPath
 	}
 	if app.isRepository == nil {
-		app.isRepository = git.IsRepository
+		app.isRepository = app.isGitRepository
 	}
 
 	return app
@@ -200,30 +324,171 @@ func (a *App) Initialize() error {
 	}
 
 	if a.Logger == nil {
-		a.Logger = logger.New(a.Config.Debug, a.Config.LogFile, a.Config.Verbose)
+		// Prune before opening the log file, which may itself be among the stale ones
+		pruned, pruneErr := a.pruneLogs()
+		rotation := logger.Rotation{MaxBytes: int64(a.Config.LogMaxSizeMB) << 20, MaxFiles: a.Config.LogMaxFiles}
+		// With JSON output or -rpc, stdout is kept for the JSON document or messages alone
+		var stdout io.Writer = os.Stdout
+		if a.Config.Output == "json" || a.Config.RPC {
+			stdout = os.Stderr
+		}
+		log := logger.NewWithBackend(a.Config.Debug, logger.Backend(a.Config.LogBackend), a.Config.LogFile, a.Config.Verbosity(), rotation, stdout, os.Stderr)
+		if pruneErr != nil {
+			log.Warning("Failed to prune old log files: %v", pruneErr)
+		}
+		if len(pruned) > 0 {
+			log.Info("Removed %d log file(s) older than %d days", len(pruned), a.Config.LogMaxAgeDays)
+		}
+		log.SetColor(!a.Config.NoColor && logger.EnableVirtualTerminal(stdout))
+		a.Logger = log
+		if a.Config.Notify != notify.ModeOff {
+			a.addNotifications(log.Pipeline)
+		}
+		if a.Config.TUI {
+			a.addDashboard(log)
+		}
+	}
+
+	if a.interactor == nil {
+		if a.Config.NonInteractive {
+			a.interactor = git.NewNonInteractiveInteractor()
+		} else {
+			a.interactor = git.NewDefaultInteractor(a.Logger)
+		}
 	}
 
 	if a.Locker == nil {
-		locker, err := lock.New(a.Config.RepoPath)
+		locker, err := lock.NewWithStaleAfter(a.lockKey(a.Config.RepoPath), a.Config.LockStaleAfter)
 		if err != nil {
 			return gitbakErrors.Wrap(err, "failed to initialize lock")
 		}
 		a.Locker = locker
 	}
 
+	if a.mirrors == nil && len(a.Config.MirrorProfiles) > 0 {
+		a.mirrors = mirror.NewScheduler(a.Config.MirrorProfiles, git.NewRepository(a.Config.RepoPath, a.gitExecutor()), a.Logger)
+	}
+
+	if commands := a.Config.Hooks(); a.hooks == nil && len(commands) > 0 {
+		a.hooks = hooks.New(commands, func(event string, err error) {
+			a.Logger.WarningToUser("The %s hook failed: %v", event, err)
+		})
+	}
+
+	if a.nudges == nil && a.Config.NudgeAddr != "" {
+		a.nudges = make(chan struct{}, 1)
+	}
+
+	if a.checkNow == nil && (a.Config.ListenAddr != "" || a.Config.RPC || commitNowSignal != nil) {
+		a.checkNow = make(chan struct{}, 1)
+	}
+
+	if a.metrics == nil && a.Config.MetricsAddr != "" {
+		a.metrics = metrics.NewCollector()
+	}
+
+	if a.tracer == nil && a.Config.OTLPEndpoint != "" {
+		a.tracer = tracing.New(a.Config.OTLPEndpoint, a.Config.VersionInfo.Version, func(err error) {
+			a.Logger.Warning("Trace export failed: %v", err)
+		})
+	}
+
+	if a.watcher == nil && a.Config.Watch && a.Gitbak == nil {
+		watcher, err := watch.New(a.Config.RepoPath, a.Config.GitDir)
+		if err != nil {
+			a.Logger.WarningToUser("File watching unavailable, polling every %s instead: %v", a.Config.Interval, err)
+		} else {
+			a.watcher = watcher
+		}
+	}
+
 	if a.Gitbak == nil {
 		gitbakConfig := git.GitbakConfig{
-			RepoPath:        a.Config.RepoPath,
-			IntervalMinutes: a.Config.IntervalMinutes,
-			BranchName:      a.Config.BranchName,
-			CommitPrefix:    a.Config.CommitPrefix,
-			CreateBranch:    a.Config.CreateBranch,
-			Verbose:         a.Config.Verbose,
-			ShowNoChanges:   a.Config.ShowNoChanges,
-			ContinueSession: a.Config.ContinueSession,
-			NonInteractive:  a.Config.NonInteractive,
-			MaxRetries:      a.Config.MaxRetries,
+			RepoPath:         a.Config.RepoPath,
+			Interval:         a.Config.Interval,
+			MinInterval:      a.Config.MinInterval,
+			MaxInterval:      a.Config.MaxInterval,
+			IdleAfter:        a.Config.IdleAfter,
+			IdleInterval:     a.Config.IdleInterval,
+			AlignMarks:       a.Config.AlignMarks,
+			BranchName:       a.Config.BranchName,
+			CommitPrefix:     a.Config.CommitPrefix,
+			CommitAuthor:     a.Config.CommitAuthor,
+			CommitEmail:      a.Config.CommitEmail,
+			CoAuthors:        a.Config.CoAuthors,
+			DiffSummary:      a.Config.DiffSummary,
+			NoVerify:         a.Config.NoVerify,
+			MinChangedLines:  a.Config.MinChangedLines,
+			MinChangedFiles:  a.Config.MinChangedFiles,
+			MaxSkippedChecks: a.Config.MaxSkippedChecks,
+			MinQuietSeconds:  a.Config.MinQuietSeconds,
+			CheckCommand:     a.Config.CheckCommand,
+			OnCheckFail:      a.Config.OnCheckFail,
+			MaxFileSizeMB:    a.Config.MaxFileSizeMB,
+			UntrackedPolicy:  a.Config.UntrackedPolicy,
+			Paths:            a.Config.Paths,
+			Secrets:          a.Config.Secrets,
+			CreateBranch:     a.Config.CreateBranch,
+			Verbose:          a.Config.Verbose,
+			LogCommands:      a.Config.Verbosity() >= logger.VerbosityTrace,
+			ShowNoChanges:    a.Config.ShowNoChanges,
+			ContinueSession:  a.Config.ContinueSession,
+			EmptyRepo:        a.Config.EmptyRepo,
+			Mode:             a.Config.Mode,
+			JournalFile:      a.Config.JournalFile,
+			Backend:          a.Config.GitBackend,
+			GitPath:          a.Config.GitPath,
+			GitGlobalArgs:    a.Config.GitArgs(),
+			SeparateGitDir:   a.Config.GitDir != "",
+			Submodules:       a.Config.Submodules,
+			UntrackedFiles:   a.Config.UntrackedFiles,
+			FastStatus:       a.Config.FastStatus,
+			NonInteractive:   a.Config.NonInteractive,
+			MaxRetries:       a.Config.MaxRetries,
+			OpTimeout:        a.Config.OpTimeout,
+			CommandTimeout:   a.Config.CommandTimeout,
+			RetryBackoff:     a.Config.RetryBackoff,
+			RetryBackoffMax:  a.Config.RetryBackoffMax,
+			StateFile:        a.Config.StateFile,
+			SummaryFile:      a.Config.SummaryFile,
+			ChainTrailer:     a.Config.ChainTrailer,
+			Push:             a.Config.Push,
+			PushInterval:     a.Config.PushInterval,
+			IsDisabled:       config.IsDisabled,
+			Paused:           a.paused.Load,
+			OnDiverge:        a.Config.OnDiverge,
+			Pause:            func() { a.setPaused(true, "until the rewritten history is reviewed") },
+			Metrics:          a.metrics,
+			Tracer:           a.tracer,
+		}
+		if a.Config.Debug && logger.Backend(a.Config.LogBackend).UsesFile() {
+			gitbakConfig.LogFile = a.Config.LogFile
 		}
+		if a.Config.Output == "json" {
+			gitbakConfig.SummaryJSON = a.Stdout
+		}
+		if a.mirrors != nil || a.hooks != nil || a.Config.RPC {
+			gitbakConfig.OnCheckpoint = a.onCheckpoint
+		}
+		if a.hooks != nil || a.Config.RPC {
+			gitbakConfig.OnStart = a.onStart
+			gitbakConfig.OnCheckFailed = a.onCheckFailed
+			gitbakConfig.OnMoved = a.onMoved
+		}
+		if a.nudges != nil {
+			gitbakConfig.Nudges = a.nudges
+		}
+		if a.Config.BatteryThreshold > 0 {
+			gitbakConfig.LowPower = a.lowBattery.Load
+			gitbakConfig.LowPowerInterval = a.Config.BatteryInterval
+		}
+		if a.watcher != nil {
+			gitbakConfig.Changes = a.watcher.Changes()
+		}
+		if a.checkNow != nil {
+			gitbakConfig.CheckNow = a.checkNow
+		}
+		gitbakConfig.ControlAddr = a.Config.ListenAddr
 		gitbak, err := git.NewGitbak(gitbakConfig, a.Logger)
 		if err != nil {
 			return fmt.Errorf("failed to create gitbak instance: %w", err)
@@ -234,6 +499,47 @@ func (a *App) Initialize() error {
 	return nil
 }
 
+// pruneLogs removes the log files in the default log directory that -log-max-age considers
+// stale, such as those of repositories no longer monitored
+func (a *App) pruneLogs() ([]string, error) {
+	if a.Config.LogMaxAgeDays == 0 {
+		return nil, nil
+	}
+	return logger.PruneLogs(config.LogDir(), time.Duration(a.Config.LogMaxAgeDays)*24*time.Hour)
+}
+
+// addNotifications sends user-facing messages to the desktop as selected by -notify.
+// A missing notifier is reported once and otherwise ignored.
+func (a *App) addNotifications(pipeline *logger.Pipeline) {
+	send, err := a.desktopNotifier()
+	if err != nil {
+		a.Logger.WarningToUser("Desktop notifications unavailable: %v", err)
+		return
+	}
+	pipeline.AddSink(logger.NewNotificationSink(send), notify.MinLevel(a.Config.Notify))
+}
+
+// addDashboard sets up the -tui dashboard, which needs a terminal to redraw itself in.
+// Otherwise log lines are shown as usual.
+func (a *App) addDashboard(log *logger.DefaultLogger) {
+	if !logger.EnableVirtualTerminal(os.Stdout) {
+		a.Logger.WarningToUser("-tui needs a terminal, showing log lines instead")
+		return
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.backendExecutor())
+	a.dashboard = newDashboard(os.Stdout, func(muted bool) {
+		if muted {
+			log.SetStdout(io.Discard)
+			log.SetStderr(io.Discard)
+			return
+		}
+		log.SetStdout(os.Stdout)
+		log.SetStderr(os.Stderr)
+	}, repo.LastCommit)
+	log.Pipeline.AddSink(a.dashboard, logger.LevelInfo)
+}
+
 // Run executes the application with the given context
 // Handles special flags and runs the gitbak process
 func (a *App) Run(ctx context.Context) error {
@@ -259,6 +565,11 @@ func (a *App) Run(ctx context.Context) error {
 		return nil
 	}
 
+	// Honor the kill switch before touching the repository or the lock
+	if config.IsDisabled() {
+		return gitbakErrors.ErrDisabled
+	}
+
 	// Ensure we always clean up logger / lock, even on early error paths
 	defer func() {
 		if err := a.Close(); err != nil {
@@ -266,34 +577,180 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
-	// Verify prerequisites
-	if err := a.checkRequiredCommands(); err != nil {
-		_, _ = fmt.Fprintf(a.Stderr, "❌ Error: %v. Please install it and try again.\n", err)
-		return err
+	// Verify prerequisites; the gogit backend works without the git binary
+	if a.Config.GitBackend != git.BackendGoGit {
+		if err := a.checkRequiredCommands(); err != nil {
+			if !a.Config.ErrorsJSON {
+				_, _ = fmt.Fprintf(a.Stderr, "❌ Error: %v. Please install it and try again.\n", err)
+			}
+			return err
+		}
 	}
 
-	isRepo, err := a.isRepository(a.Config.RepoPath)
+	checkCtx, cancel := context.WithTimeout(ctx, startupCheckTimeout)
+	isRepo, err := a.isRepository(checkCtx, a.Config.RepoPath)
+	cancel()
 	if err != nil {
 		a.Logger.Warning("Failed to check if path is a git repository: %v", err)
 		return gitbakErrors.Wrap(gitbakErrors.ErrGitOperationFailed, err.Error())
 	}
 	if !isRepo {
+		if a.Config.GitDir != "" && a.Config.WorkTree == "" {
+			return gitbakErrors.Wrapf(gitbakErrors.ErrNotGitRepository,
+				"%s is not a work tree of %s; name the work tree with -work-tree (or GIT_WORK_TREE)", a.Config.RepoPath, a.Config.GitDir)
+		}
 		return gitbakErrors.ErrNotGitRepository
 	}
 	a.Logger.Info("Git repository verified")
 
 	// Acquire resource lock
-	if err := a.Locker.Acquire(); err != nil {
+	if err := a.acquireLock(ctx); err != nil {
 		// Since Locker.Acquire() already returns a properly wrapped error,
 		// we don't need to wrap it again
 		if gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+			if a.Config.Join {
+				return a.joinSession(ctx, err)
+			}
 			return err
 		}
 		return gitbakErrors.Wrap(gitbakErrors.ErrLockAcquisitionFailure, err.Error())
 	}
 
+	if a.Config.PprofAddr != "" {
+		if err := a.startPprofServer(a.Config.PprofAddr); err != nil {
+			a.Logger.WarningToUser("Failed to start profiling server on %s: %v", a.Config.PprofAddr, err)
+		}
+	}
+
+	if a.Config.NudgeAddr != "" {
+		if err := a.startNudgeServer(a.Config.NudgeAddr); err != nil {
+			a.Logger.WarningToUser("Failed to start nudge endpoint on %s: %v", a.Config.NudgeAddr, err)
+		}
+	}
+
+	if a.Config.ListenAddr != "" {
+		if err := a.startControlServer(a.Config.ListenAddr); err != nil {
+			a.Logger.WarningToUser("Failed to start control endpoint on %s: %v", a.Config.ListenAddr, err)
+		}
+	}
+
+	if a.Config.RPC {
+		a.startRPC(ctx)
+	}
+
+	if a.Config.MetricsAddr != "" {
+		if err := a.startMetricsServer(a.Config.MetricsAddr); err != nil {
+			a.Logger.WarningToUser("Failed to start metrics endpoint on %s: %v", a.Config.MetricsAddr, err)
+		}
+	}
+
+	stopMirrors := a.runMirrors(ctx)
+
+	a.watchPauseSignals(ctx)
+	a.watchCommitNowSignal(ctx)
+	a.watchBattery(ctx)
+	stopDashboard := a.startDashboard(ctx)
+
 	// Run main gitbak process
-	return a.Gitbak.Run(ctx)
+	err = a.runMonitoring(ctx)
+	stopDashboard()
+
+	// Stopped by a signal, so keep the work done since the last check before releasing the
+	// lock, and push the checkpoints a push interval would have left behind
+	if ctx.Err() != nil && gitbakErrors.Is(err, context.Canceled) {
+		if a.Config.CommitOnExit {
+			if err := a.Gitbak.FinalCheckpoint(a.shutdownContext()); err != nil {
+				a.Logger.WarningToUser("Failed to make a final checkpoint: %v", err)
+			}
+		}
+		if err := a.Gitbak.PushPending(a.shutdownContext()); err != nil {
+			a.Logger.WarningToUser("Failed to push at exit: %v", err)
+		}
+	}
+	a.Gitbak.RecordHistory()
+	stopMirrors()
+	a.onStop(err)
+	return err
+}
+
+// runMirrors starts pushing checkpoints to the mirror profiles, if any, and returns a
+// function that stops it. The mirrors outlive ctx, so that the final checkpoint is
+// mirrored too: stopping them pushes every profile one last time, and waits for that
+// within the shutdown timeout.
+func (a *App) runMirrors(ctx context.Context) func() {
+	if a.mirrors == nil {
+		return func() {}
+	}
+
+	mirrorCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.mirrors.Run(mirrorCtx)
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-a.shutdownContext().Done():
+		}
+	}
+}
+
+// onStart runs the start hook once gitbak has set up the session
+func (a *App) onStart(branch string) {
+	a.hookStarted = true
+	a.hookBranch = branch
+	a.hookRepo = a.Config.RepoPath
+	a.hooks.Run(hooks.Event{Name: hooks.EventStart, Repo: a.hookRepo, Branch: branch})
+	a.notifyRPC("gitbak/started", map[string]any{"repo_path": a.hookRepo, "branch": branch, "pid": os.Getpid()})
+}
+
+// onMoved runs later hooks in the repository's new location once gitbak has followed it there
+func (a *App) onMoved(repoPath string) {
+	a.hookRepo = repoPath
+}
+
+// onCheckpoint passes each checkpoint on to the mirrors, the commit hook and the RPC client
+func (a *App) onCheckpoint(checkpoint git.Checkpoint) {
+	a.lastCheckpoint = checkpoint
+	if a.mirrors != nil {
+		a.mirrors.Notify(checkpoint.Branch)
+	}
+	a.hooks.Run(hooks.Event{
+		Name:    hooks.EventCommit,
+		Repo:    a.hookRepo,
+		Branch:  checkpoint.Branch,
+		Commit:  checkpoint.Commit,
+		Counter: checkpoint.Number,
+	})
+	a.notifyRPC("gitbak/checkpoint", rpcCheckpointOf(checkpoint))
+}
+
+// onCheckFailed runs the error hook after a failed check and tells the RPC client
+func (a *App) onCheckFailed(err error, consecutive int) {
+	a.hooks.Run(hooks.Event{Name: hooks.EventError, Repo: a.hookRepo, Branch: a.hookBranch, Error: err.Error()})
+	a.notifyRPC("gitbak/checkFailed", map[string]any{"error": err.Error(), "consecutive_errors": consecutive})
+}
+
+// onStop runs the stop hook for a session that started, with the error it failed with, if any
+func (a *App) onStop(err error) {
+	if !a.hookStarted {
+		return
+	}
+	event := hooks.Event{
+		Name:    hooks.EventStop,
+		Repo:    a.hookRepo,
+		Branch:  a.hookBranch,
+		Commit:  a.lastCheckpoint.Commit,
+		Counter: a.lastCheckpoint.Number,
+	}
+	if err != nil && !gitbakErrors.Is(err, context.Canceled) {
+		event.Error = err.Error()
+	}
+	a.hooks.Run(event)
+	a.notifyRPC("gitbak/stopped", map[string]any{"error": event.Error, "checkpoints": event.Counter})
 }
 
 // ShowVersion displays version information
@@ -315,19 +772,79 @@ func (a *App) ShowLogo() {
 	_, _ = fmt.Fprintln(a.Stdout, centeredTagline)
 }
 
-// checkRequiredCommands verifies git is available in PATH
+// checkRequiredCommands verifies git is available in PATH, or at -git-path
 func (a *App) checkRequiredCommands() error {
-	_, err := a.execLookPath("git")
+	_, err := a.execLookPath(a.Config.GitExecutable())
 	if err != nil {
-		return fmt.Errorf("git is not found in PATH")
+		return gitbakErrors.ErrGitNotFound
 	}
 	return nil
 }
 
+// isGitRepository checks whether path is a git repository using the configured git backend
+func (a *App) isGitRepository(ctx context.Context, path string) (bool, error) {
+	return git.IsRepositoryWith(ctx, path, a.backendExecutor())
+}
+
+// gitExecutor returns an executor running the git binary given by -git-path with -git-args,
+// for the commands that always need git
+func (a *App) gitExecutor() git.CommandExecutor {
+	return git.NewExecExecutorWithOptions(git.ExecOptions{GitPath: a.Config.GitPath, GlobalArgs: a.Config.GitArgs()})
+}
+
+// backendExecutor returns the executor for the configured git backend
+func (a *App) backendExecutor() git.CommandExecutor {
+	if a.Config.GitBackend == "gogit" {
+		return git.NewExecutor(git.BackendGoGit)
+	}
+	return a.gitExecutor()
+}
+
 // Close releases resources held by the App
 func (a *App) Close() error {
 	var errs []error
 
+	if a.pprofServer != nil {
+		_ = a.pprofServer.Close()
+		a.pprofServer = nil
+	}
+
+	if a.nudgeServer != nil {
+		_ = a.nudgeServer.Close()
+		a.nudgeServer = nil
+	}
+
+	if a.controlServer != nil {
+		_ = a.controlServer.Close()
+		a.controlServer = nil
+	}
+
+	if a.metricsServer != nil {
+		_ = a.metricsServer.Close()
+		a.metricsServer = nil
+	}
+
+	// Send the spans of the last checks before the logger that reports failures closes
+	if a.tracer != nil {
+		if err := a.tracer.Shutdown(a.shutdownContext()); err != nil && a.Logger != nil {
+			a.Logger.Warning("Failed to export the last trace spans: %v", err)
+		}
+		a.tracer = nil
+	}
+
+	if a.watcher != nil {
+		_ = a.watcher.Close()
+		a.watcher = nil
+	}
+
+	// Let the stop hook finish, and report if it failed, before the logger closes
+	if a.hooks != nil {
+		if err := a.hooks.Wait(a.shutdownContext()); err != nil && a.Logger != nil {
+			a.Logger.Warning("Hooks were still running at exit: %v", err)
+		}
+		a.hooks = nil
+	}
+
 	// Release lock if it exists
 	if a.Locker != nil {
 		if err := a.Locker.Release(); err != nil {
@@ -353,8 +870,9 @@ func (a *App) Close() error {
 	return nil
 }
 
-// CleanupOnSignal releases locks and shows a summary on interruption
-func (a *App) CleanupOnSignal() {
+// CleanupOnSignal releases locks and shows a summary on interruption.
+// The summary's git queries are abandoned once ctx is canceled.
+func (a *App) CleanupOnSignal(ctx context.Context) {
 	// Close resources...
 	if err := a.Close(); err != nil {
 		_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
@@ -362,6 +880,6 @@ func (a *App) CleanupOnSignal() {
 
 	// Show summary only if we're not running in --logo or --version mode
 	if !a.Config.ShowLogo && !a.Config.Version && a.Gitbak != nil {
-		a.Gitbak.PrintSummary()
+		a.Gitbak.PrintSummary(ctx)
 	}
 }
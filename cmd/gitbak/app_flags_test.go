@@ -48,8 +48,8 @@ func TestAppRunWithVariousFlagCombinations(t *testing.T) {
 			args:          []string{"gitbak", "-interval", "15"},
 			expectSuccess: true,
 			checkConfig: func(t *testing.T, app *App) {
-				if app.Config.IntervalMinutes != 15 {
-					t.Errorf("Expected IntervalMinutes=15, got %.1f", app.Config.IntervalMinutes)
+				if app.Config.Interval != 15*time.Minute {
+					t.Errorf("Expected Interval=15m, got %s", app.Config.Interval)
 				}
 			},
 		},
@@ -175,8 +175,8 @@ func TestAppRunWithVariousFlagCombinations(t *testing.T) {
 			args:          []string{"gitbak", "-interval", "10", "-branch", "test-branch", "-prefix", "[test] ", "-quiet", "-show-no-changes", "-continue", "-debug"},
 			expectSuccess: true,
 			checkConfig: func(t *testing.T, app *App) {
-				if app.Config.IntervalMinutes != 10 {
-					t.Errorf("Expected IntervalMinutes=10, got %.1f", app.Config.IntervalMinutes)
+				if app.Config.Interval != 10*time.Minute {
+					t.Errorf("Expected Interval=10m, got %s", app.Config.Interval)
 				}
 				if app.Config.BranchName != "test-branch" {
 					t.Errorf("Expected BranchName=test-branch, got %s", app.Config.BranchName)
@@ -204,8 +204,8 @@ func TestAppRunWithVariousFlagCombinations(t *testing.T) {
 			expectSuccess: true,
 			checkConfig: func(t *testing.T, app *App) {
 				// Command line args should take precedence over env vars
-				if app.Config.IntervalMinutes != 10 {
-					t.Errorf("Expected IntervalMinutes=10 (from CLI), got %.1f", app.Config.IntervalMinutes)
+				if app.Config.Interval != 10*time.Minute {
+					t.Errorf("Expected Interval=10m (from CLI), got %s", app.Config.Interval)
 				}
 			},
 		},
@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bashhack/gitbak/pkg/config"
 	"github.com/bashhack/gitbak/pkg/lock"
@@ -177,7 +179,7 @@ func TestAppInitializeScenarios(t *testing.T) {
 				app := NewDefaultApp(config.VersionInfo{})
 				app.exit = func(int) {}
 				app.Config.RepoPath = repoPath
-				app.Config.IntervalMinutes = 10 // Custom interval
+				app.Config.Interval = 10 * time.Minute // Custom interval
 
 				logDir := t.TempDir()
 
@@ -198,8 +200,8 @@ func TestAppInitializeScenarios(t *testing.T) {
 				// Note: gitbak is initialized in Run(), not in Initialize()
 
 				// Verify that the configuration has the correct interval setting
-				if app.Config.IntervalMinutes != 10 {
-					t.Errorf("Expected Config.IntervalMinutes to be 10, got %.1f", app.Config.IntervalMinutes)
+				if app.Config.Interval != 10*time.Minute {
+					t.Errorf("Expected Config.Interval to be 10m, got %s", app.Config.Interval)
 				}
 			},
 		},
@@ -298,7 +300,7 @@ func TestAppInitializeScenarios(t *testing.T) {
 				app := &App{
 					Config: &config.Config{
 						// Set invalid config that will cause Finalize to fail
-						IntervalMinutes: -1, // Negative interval should cause error
+						Interval: -time.Minute, // Negative interval should cause error
 					},
 				}
 				return app, ""
@@ -349,7 +351,7 @@ func TestReleaseErrorHandling(t *testing.T) {
 	var stderrBuf bytes.Buffer
 	app.Stderr = &stderrBuf
 
-	app.CleanupOnSignal()
+	app.CleanupOnSignal(context.Background())
 
 	if !mockLocker.ReleaseCalled {
 		t.Error("Expected locker.Release to be called")
@@ -367,3 +369,74 @@ func TestReleaseErrorHandling(t *testing.T) {
 		}
 	}
 }
+
+// TestAppInitializeNotifications tests that -notify attaches desktop notifications to the logger
+func TestAppInitializeNotifications(t *testing.T) {
+	tests := map[string]struct {
+		mode        string
+		unavailable bool
+		expected    []string
+	}{
+		"Off": {
+			mode: "off",
+		},
+		"Errors": {
+			mode:     "errors",
+			expected: []string{"gitbak warning: Error occurred: boom"},
+		},
+		"All": {
+			mode:     "all",
+			expected: []string{"gitbak: Commit #1 created", "gitbak warning: Error occurred: boom"},
+		},
+		"Unavailable": {
+			mode:        "all",
+			unavailable: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var repoPath string
+			withGitRepo(t, func(path string) {
+				repoPath = path
+			})
+
+			cfg := config.New()
+			cfg.RepoPath = repoPath
+			cfg.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+			cfg.Notify = test.mode
+
+			var stdout, stderr bytes.Buffer
+			app := NewApp(AppOptions{
+				Config: cfg,
+				Stdout: &stdout,
+				Stderr: &stderr,
+			})
+
+			var sent []string
+			app.desktopNotifier = func() (logger.NotifyFunc, error) {
+				if test.unavailable {
+					return nil, &customError{msg: "notify-send not found"}
+				}
+				return func(title, message string) error {
+					sent = append(sent, title+": "+message)
+					return nil
+				}, nil
+			}
+
+			if err := app.Initialize(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer func() { _ = app.Close() }()
+
+			app.Logger.Info("internal message")
+			app.Logger.Success("Commit #%d created", 1)
+			app.Logger.WarningToUser("Error occurred: %s", "boom")
+
+			if strings.Join(sent, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("Expected notifications %q, got %q", test.expected, sent)
+			}
+		})
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/hooks"
 	"github.com/bashhack/gitbak/pkg/logger"
 )
 
@@ -36,7 +43,7 @@ func TestAppRunScenarios(t *testing.T) {
 				app.Stdout = &stdout
 				app.Stderr = &stderr
 
-				app.isRepository = func(path string) (bool, error) {
+				app.isRepository = func(_ context.Context, path string) (bool, error) {
 					return true, nil
 				}
 
@@ -63,7 +70,7 @@ func TestAppRunScenarios(t *testing.T) {
 				app.Stdout = &stdout
 				app.Stderr = &stderr
 
-				app.isRepository = func(path string) (bool, error) {
+				app.isRepository = func(_ context.Context, path string) (bool, error) {
 					return true, nil
 				}
 
@@ -95,7 +102,7 @@ func TestAppRunScenarios(t *testing.T) {
 				app.Stdout = &stdout
 				app.Stderr = &stderr
 
-				app.isRepository = func(path string) (bool, error) {
+				app.isRepository = func(_ context.Context, path string) (bool, error) {
 					return true, nil
 				}
 
@@ -129,7 +136,7 @@ func TestAppRunScenarios(t *testing.T) {
 					app.exit = func(int) {}
 					app.Config.RepoPath = tempDir
 
-					app.isRepository = func(path string) (bool, error) {
+					app.isRepository = func(_ context.Context, path string) (bool, error) {
 						return true, nil
 					}
 
@@ -171,7 +178,7 @@ func TestAppRunScenarios(t *testing.T) {
 					app.Gitbak = mockGitbaker
 					app.Config.RepoPath = tempDir
 
-					app.isRepository = func(path string) (bool, error) {
+					app.isRepository = func(_ context.Context, path string) (bool, error) {
 						return true, nil
 					}
 
@@ -225,7 +232,7 @@ func TestAppRunScenarios(t *testing.T) {
 					app.Gitbak = mockGitbaker
 					app.Config.RepoPath = tempDir
 
-					app.isRepository = func(path string) (bool, error) {
+					app.isRepository = func(_ context.Context, path string) (bool, error) {
 						return true, nil
 					}
 
@@ -264,7 +271,7 @@ func TestAppRunScenarios(t *testing.T) {
 					app.Gitbak = mockGitbaker
 					app.Config.RepoPath = tempDir
 
-					app.isRepository = func(path string) (bool, error) {
+					app.isRepository = func(_ context.Context, path string) (bool, error) {
 						return true, nil
 					}
 
@@ -300,7 +307,7 @@ func TestAppRunScenarios(t *testing.T) {
 					app = &App{
 						Config: &config.Config{
 							RepoPath:        tempDir,
-							IntervalMinutes: 5,
+							Interval:        5 * time.Minute,
 							BranchName:      "test-branch",
 							CommitPrefix:    "[test] ",
 							CreateBranch:    true,
@@ -316,7 +323,7 @@ func TestAppRunScenarios(t *testing.T) {
 						Stderr:       &stderr,
 						exit:         func(int) {},
 						Gitbak:       mockGitbaker,
-						isRepository: func(path string) (bool, error) {
+						isRepository: func(_ context.Context, path string) (bool, error) {
 							return true, nil
 						},
 					}
@@ -354,7 +361,7 @@ func TestAppRunScenarios(t *testing.T) {
 
 					app = NewTestApp()
 					app.Config.RepoPath = tempDir
-					app.Config.IntervalMinutes = 5
+					app.Config.Interval = 5 * time.Minute
 
 					app.Stdout = &bytes.Buffer{}
 					app.Stderr = &bytes.Buffer{}
@@ -397,7 +404,7 @@ func TestAppRunScenarios(t *testing.T) {
 
 					app = NewTestApp()
 					app.Config.RepoPath = tempDir
-					app.Config.IntervalMinutes = 5
+					app.Config.Interval = 5 * time.Minute
 					app.Config.ContinueSession = true
 					app.Config.BranchName = "test-branch"
 
@@ -469,12 +476,12 @@ func TestAppRunScenarios(t *testing.T) {
 				tmpDir := t.TempDir()
 
 				app := NewTestApp()
-				app.Config.IntervalMinutes = -1 // Invalid config will cause Initialize to fail
+				app.Config.Interval = -time.Minute // Invalid config will cause Initialize to fail
 				app.Config.RepoPath = tmpDir
 				app.Stdout = &stdout
 				app.Stderr = &stderr
 
-				app.isRepository = func(path string) (bool, error) {
+				app.isRepository = func(_ context.Context, path string) (bool, error) {
 					return true, nil
 				}
 
@@ -496,7 +503,7 @@ func TestAppRunScenarios(t *testing.T) {
 				app.Stdout = &stdout
 				app.Stderr = &stderr
 
-				app.isRepository = func(path string) (bool, error) {
+				app.isRepository = func(_ context.Context, path string) (bool, error) {
 					return true, nil
 				}
 
@@ -561,3 +568,143 @@ func TestAppRunScenarios(t *testing.T) {
 		})
 	}
 }
+
+// TestAppRunHonorsKillSwitch tests that Run refuses to start when GITBAK_DISABLE is set
+func TestAppRunHonorsKillSwitch(t *testing.T) {
+	if err := os.Setenv(config.DisableEnvVar, "1"); err != nil {
+		t.Fatalf("Failed to set environment variable: %v", err)
+	}
+	defer func() {
+		if err := os.Unsetenv(config.DisableEnvVar); err != nil {
+			t.Logf("Failed to unset environment variable: %v", err)
+		}
+	}()
+
+	var stdout, stderr bytes.Buffer
+	app := NewTestApp()
+	app.Config.RepoPath = t.TempDir()
+	app.Stdout = &stdout
+	app.Stderr = &stderr
+
+	mockLocker := &MockLocker{}
+	mockGitbak := &MockGitbaker{}
+	app = WithMockLocker(app, mockLocker)
+	app.Gitbak = mockGitbak
+
+	err := app.Run(context.Background())
+	if !gitbakErrors.Is(err, gitbakErrors.ErrDisabled) {
+		t.Fatalf("Expected ErrDisabled, got: %v", err)
+	}
+
+	if mockLocker.AcquireCalled {
+		t.Error("Expected lock not to be acquired when disabled")
+	}
+
+	if mockGitbak.RunCalled {
+		t.Error("Expected gitbak not to run when disabled")
+	}
+}
+
+// TestStartPprofServer tests that the profiling server starts and is shut down by Close
+func TestStartPprofServer(t *testing.T) {
+	app := NewTestApp()
+	app.Stdout = &bytes.Buffer{}
+	app.Stderr = &bytes.Buffer{}
+	app = WithMockLogger(app, &MockLogger{})
+
+	if err := app.startPprofServer("127.0.0.1:0"); err != nil {
+		t.Fatalf("Failed to start profiling server: %v", err)
+	}
+	if app.pprofServer == nil {
+		t.Fatal("Expected profiling server to be recorded on the app")
+	}
+
+	if err := app.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if app.pprofServer != nil {
+		t.Error("Expected profiling server to be cleared after Close")
+	}
+
+	if err := app.startPprofServer("not-an-address"); err == nil {
+		t.Error("Expected an error for an invalid listen address")
+	}
+}
+
+// TestStartNudgeServer tests that the nudge endpoint starts and is shut down by Close
+func TestStartNudgeServer(t *testing.T) {
+	app := NewTestApp()
+	app.Stdout = &bytes.Buffer{}
+	app.Stderr = &bytes.Buffer{}
+	app = WithMockLogger(app, &MockLogger{})
+
+	if err := app.startNudgeServer("127.0.0.1:0"); err != nil {
+		t.Fatalf("Failed to start nudge server: %v", err)
+	}
+	if app.nudgeServer == nil {
+		t.Fatal("Expected nudge server to be recorded on the app")
+	}
+
+	if err := app.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if app.nudgeServer != nil {
+		t.Error("Expected nudge server to be cleared after Close")
+	}
+
+	if err := app.startNudgeServer("not-an-address"); err == nil {
+		t.Error("Expected an error for an invalid listen address")
+	}
+}
+
+// TestAppStopHook tests that the stop hook describes the session's last checkpoint and why it ended
+func TestAppStopHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are written for sh")
+	}
+
+	tests := map[string]struct {
+		started     bool
+		runErr      error
+		expectEvent string
+	}{
+		"Stopped":    {started: true, runErr: context.Canceled, expectEvent: "stop gitbak-hooks 2 abc123 "},
+		"Failed":     {started: true, runErr: errors.New("disk full"), expectEvent: "stop gitbak-hooks 2 abc123 disk full"},
+		"NotStarted": {runErr: errors.New("not a repository")},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := NewTestApp()
+			app.Config.RepoPath = t.TempDir()
+			app.Config.OnStop = `echo "$GITBAK_EVENT $GITBAK_BRANCH $GITBAK_COUNTER $GITBAK_COMMIT $GITBAK_ERROR" > stop.txt`
+			app.hooks = hooks.New(app.Config.Hooks(), func(event string, err error) {
+				t.Errorf("Expected the %s hook to succeed, got %v", event, err)
+			})
+
+			if test.started {
+				app.onStart("gitbak-hooks")
+				app.onCheckpoint(git.Checkpoint{Number: 1, Branch: "gitbak-hooks", Commit: "fff000"})
+				app.onCheckpoint(git.Checkpoint{Number: 2, Branch: "gitbak-hooks", Commit: "abc123"})
+			}
+			app.onStop(test.runErr)
+			if err := app.hooks.Wait(context.Background()); err != nil {
+				t.Fatalf("Wait failed: %v", err)
+			}
+
+			out, err := os.ReadFile(filepath.Join(app.Config.RepoPath, "stop.txt"))
+			if test.expectEvent == "" {
+				if err == nil {
+					t.Errorf("Expected no stop hook for a session that never started, got %q", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected the stop hook to run: %v", err)
+			}
+			if got := strings.TrimRight(string(out), "\n"); got != test.expectEvent {
+				t.Errorf("Expected %q, got %q", test.expectEvent, got)
+			}
+		})
+	}
+}
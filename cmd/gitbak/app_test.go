@@ -410,7 +410,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Logger: nil,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 		// No assertions - we're just making sure it doesn't panic
 	})
 
@@ -428,7 +428,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Logger: nil,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 
 		if !mockGitbak.SummaryCalled {
 			t.Error("Expected PrintSummary to be called, but it wasn't")
@@ -449,7 +449,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Logger: nil,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 
 		if !mockLocker.Released {
 			t.Error("Expected Release to be called, but it wasn't")
@@ -472,7 +472,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Logger: testLogger,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 
 		if !mockLocker.Released {
 			t.Error("Expected Release to be called, but it wasn't")
@@ -504,7 +504,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Stderr: &buf,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 
 		if !mockLocker.Released {
 			t.Error("Expected Release to be called, but it wasn't")
@@ -532,7 +532,7 @@ func TestCleanupOnSignal(t *testing.T) {
 			Logger: testLogger,
 		})
 
-		app.CleanupOnSignal()
+		app.CleanupOnSignal(context.Background())
 
 		if !mockGitbak.SummaryCalled {
 			t.Error("Expected PrintSummary to be called, but it wasn't")
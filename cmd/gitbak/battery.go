@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/power"
+)
+
+// batteryPollInterval is how often the power source is read while -battery-threshold is set
+const batteryPollInterval = time.Minute
+
+// watchBattery keeps lowBattery up to date with the power source until ctx is done, so that
+// checks are spaced out while on battery below -battery-threshold. Where the power source
+// cannot be read, the user is warned and the threshold has no effect.
+func (a *App) watchBattery(ctx context.Context) {
+	if a.Config.BatteryThreshold == 0 {
+		return
+	}
+
+	status, err := a.readPower()
+	if err != nil {
+		a.Logger.WarningToUser("Battery status unavailable, -battery-threshold has no effect: %v", err)
+		return
+	}
+	a.updateBattery(status)
+
+	go func() {
+		ticker := time.NewTicker(batteryPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				status, err := a.readPower()
+				if err != nil {
+					// Keep the last known state; a single failed read is not worth changing the schedule
+					a.Logger.Warning("Failed to read battery status: %v", err)
+					continue
+				}
+				a.updateBattery(status)
+			}
+		}
+	}()
+}
+
+// updateBattery records whether the battery is low, telling the user when that changes
+func (a *App) updateBattery(status power.Status) {
+	low := status.Below(a.Config.BatteryThreshold)
+	if a.lowBattery.Swap(low) == low {
+		return
+	}
+
+	switch {
+	case !low:
+		a.Logger.InfoToUser("Battery no longer low, checking every %s again", a.Config.Interval)
+	case a.Config.BatteryInterval == 0:
+		a.Logger.InfoToUser("On battery at %d%%, checkpointing paused until plugged in", status.Percent)
+	default:
+		a.Logger.InfoToUser("On battery at %d%%, checking every %s until plugged in", status.Percent, a.Config.BatteryInterval)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/power"
+)
+
+func TestUpdateBattery(t *testing.T) {
+	tests := map[string]struct {
+		batteryInterval time.Duration
+		statuses        []power.Status
+		expectLow       bool
+		expectMessage   string
+	}{
+		"Charged": {
+			batteryInterval: 15 * time.Minute,
+			statuses:        []power.Status{{OnBattery: true, Percent: 80}},
+			expectLow:       false,
+		},
+		"Low": {
+			batteryInterval: 15 * time.Minute,
+			statuses:        []power.Status{{OnBattery: true, Percent: 15}},
+			expectLow:       true,
+			expectMessage:   "On battery at 15%, checking every 15m0s until plugged in",
+		},
+		"LowPaused": {
+			batteryInterval: 0,
+			statuses:        []power.Status{{OnBattery: true, Percent: 15}},
+			expectLow:       true,
+			expectMessage:   "On battery at 15%, checkpointing paused until plugged in",
+		},
+		"PluggedIn": {
+			batteryInterval: 15 * time.Minute,
+			statuses:        []power.Status{{OnBattery: true, Percent: 15}, {OnBattery: false, Percent: 15}},
+			expectLow:       false,
+			expectMessage:   "Battery no longer low, checking every 5m0s again",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.BatteryThreshold = 20
+			cfg.BatteryInterval = test.batteryInterval
+
+			mockLogger := &MockLogger{}
+			app := &App{Config: cfg, Logger: mockLogger}
+
+			for _, status := range test.statuses {
+				app.updateBattery(status)
+			}
+
+			if low := app.lowBattery.Load(); low != test.expectLow {
+				t.Errorf("Expected low battery to be %v, got %v", test.expectLow, low)
+			}
+			if test.expectMessage != "" && mockLogger.LastMessage != test.expectMessage {
+				t.Errorf("Expected message %q, got %q", test.expectMessage, mockLogger.LastMessage)
+			}
+			if test.expectMessage == "" && mockLogger.InfoToUserCalled {
+				t.Errorf("Expected no message, got %q", mockLogger.LastMessage)
+			}
+		})
+	}
+}
+
+func TestWatchBatteryUnavailable(t *testing.T) {
+	cfg := config.New()
+	cfg.BatteryThreshold = 20
+
+	mockLogger := &MockLogger{}
+	app := &App{
+		Config:    cfg,
+		Logger:    mockLogger,
+		readPower: func() (power.Status, error) { return power.Status{}, errors.New("not supported") },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.watchBattery(ctx)
+
+	if !mockLogger.WarningToUserCalled || !strings.Contains(mockLogger.LastMessage, "-battery-threshold has no effect") {
+		t.Errorf("Expected a warning that the threshold has no effect, got %q", mockLogger.LastMessage)
+	}
+	if app.lowBattery.Load() {
+		t.Error("Expected the battery not to be considered low")
+	}
+}
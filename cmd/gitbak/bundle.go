@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunExportBundle writes the branch of the most recent session to a git bundle, for
+// archiving the session or moving it to another machine without a shared remote. The
+// argument names the bundle file, or a directory to write it to under the branch's name;
+// without one it lands in the current directory. With -session-only only the commits made
+// since the session started are bundled. Overwriting a file needs confirmation unless -yes.
+func (a *App) RunExportBundle(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to export in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	target := "."
+	if len(a.Config.Args) > 0 {
+		target = a.Config.Args[0]
+	}
+	path, err := bundlePath(target, state.Branch)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil && !a.Config.AssumeYes {
+		if !a.interactor.PromptYesNo(fmt.Sprintf("Overwrite %s?", path)) {
+			_, _ = fmt.Fprintln(a.Stdout, "Export cancelled, nothing was written.")
+			return nil
+		}
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	commits, err := repo.ExportBundle(ctx, state, path, a.Config.BundleSessionOnly)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Success("Exported %d commit(s) of '%s' to %s", commits, state.Branch, path)
+	a.Logger.StatusMessage("Fetch them into another clone with: git fetch %s %s:%s", path, state.Branch, state.Branch)
+	return nil
+}
+
+// bundlePath returns the absolute path of the bundle to write for target: the file it
+// names, or a file named after branch in it if it is a directory
+func bundlePath(target, branch string) (string, error) {
+	path, err := filepath.Abs(target)
+	if err != nil {
+		return "", gitbakErrors.Wrapf(err, "failed to resolve %s", target)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, strings.ReplaceAll(branch, "/", "-")+".bundle")
+	}
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunExportBundle tests the export-bundle command against a real repository
+func TestRunExportBundle(t *testing.T) {
+	tests := map[string]struct {
+		target         string
+		existing       bool
+		assumeYes      bool
+		expectedFile   string
+		outputContains string
+		expectWritten  bool
+	}{
+		"File": {
+			target:        "session.bundle",
+			expectedFile:  "session.bundle",
+			expectWritten: true,
+		},
+		"Directory": {
+			target:        ".",
+			expectedFile:  "gitbak-user-session.bundle",
+			expectWritten: true,
+		},
+		"OverwriteDeclined": {
+			target:         "session.bundle",
+			existing:       true,
+			expectedFile:   "session.bundle",
+			outputContains: "Export cancelled",
+		},
+		"OverwriteAssumeYes": {
+			target:        "session.bundle",
+			existing:      true,
+			assumeYes:     true,
+			expectedFile:  "session.bundle",
+			expectWritten: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				run := func(args ...string) string {
+					out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+					if err != nil {
+						t.Fatalf("git %v failed: %v", args, err)
+					}
+					return strings.TrimSpace(string(out))
+				}
+
+				startCommit := run("rev-parse", "HEAD")
+				run("checkout", "-b", "gitbak/user/session")
+				if err := os.WriteFile(filepath.Join(repoPath, "notes.txt"), []byte("one\n"), 0644); err != nil {
+					t.Fatalf("Failed to write notes.txt: %v", err)
+				}
+				run("add", ".")
+				run("commit", "-m", "[gitbak] Automatic checkpoint #1 - 2026-01-01 10:00:00")
+
+				stateFile := filepath.Join(t.TempDir(), "state.json")
+				state := &session.State{RepoPath: repoPath, Branch: "gitbak/user/session", StartCommit: startCommit}
+				if err := session.Save(stateFile, state); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+
+				dir := t.TempDir()
+				expected := filepath.Join(dir, test.expectedFile)
+				if test.existing {
+					if err := os.WriteFile(expected, []byte("keep"), 0644); err != nil {
+						t.Fatalf("Failed to write %s: %v", expected, err)
+					}
+				}
+
+				var stdout bytes.Buffer
+				app := NewTestApp()
+				app = WithMockLocker(app, &MockLocker{})
+				app = WithMockLogger(app, &MockLogger{})
+				app.Stdout = &stdout
+				app.interactor = git.NewMockInteractor(false)
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.AssumeYes = test.assumeYes
+				app.Config.Args = []string{filepath.Join(dir, test.target)}
+
+				if err := app.RunExportBundle(context.Background()); err != nil {
+					t.Fatalf("RunExportBundle failed: %v", err)
+				}
+
+				if !strings.Contains(stdout.String(), test.outputContains) {
+					t.Errorf("Expected output to contain %q, got %q", test.outputContains, stdout.String())
+				}
+				content, err := os.ReadFile(expected)
+				if err != nil {
+					t.Fatalf("Expected %s to exist: %v", expected, err)
+				}
+				if written := string(content) != "keep"; written != test.expectWritten {
+					t.Errorf("Expected the bundle to be written: %v", test.expectWritten)
+				}
+				if test.expectWritten {
+					run("bundle", "verify", expected)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/control"
+)
+
+// command is a gitbak subcommand, selected by the first command-line argument.
+// Flags that follow the command name are parsed with the regular gitbak flag set.
+// A command without a run function starts a monitoring session.
+type command struct {
+	name    string
+	summary string
+	run     func(a *App, ctx context.Context) error
+
+	// args lists the values the command's arguments take, and pathArgs marks commands
+	// taking paths; shell completion offers these after the command name
+	args     []string
+	pathArgs bool
+}
+
+// commands lists the available subcommands by name.
+var commands = map[string]*command{
+	"abort": {
+		name:    "abort",
+		summary: "Discard the last session and return to the original branch",
+		run:     (*App).RunAbort,
+	},
+	"commit-now": {
+		name:    "commit-now",
+		summary: "Ask the running session to checkpoint changes right away, even while paused",
+		run:     (*App).RunCommitNow,
+	},
+	"control": {
+		name:    "control",
+		summary: "Query or steer the running session through its control endpoint (-listen), printing its status as JSON",
+		run:     (*App).RunControl,
+		args:    control.Actions,
+	},
+	"diff": {
+		name:    "diff",
+		summary: "Show the changes between two checkpoints of the last session, or of one named before them",
+		run:     (*App).RunDiff,
+	},
+	"export-bundle": {
+		name:     "export-bundle",
+		summary:  "Write the last session's branch to a git bundle file, for archiving it or moving it between machines",
+		run:      (*App).RunExportBundle,
+		pathArgs: true,
+	},
+	"ignores": {
+		name:     "ignores",
+		summary:  "Print the exclusion rules in effect and the changed files left out, or which rule excludes each given path",
+		run:      (*App).RunIgnores,
+		pathArgs: true,
+	},
+	"logs": {
+		name:    "logs",
+		summary: "Print the last records of the repository's log file, following it with -f, or its path",
+		run:     (*App).RunLogs,
+		args:    logsActions,
+	},
+	"materialize": {
+		name:    "materialize",
+		summary: "Create a branch from the checkpoints the last session wrote to refs with -refs-only",
+		run:     (*App).RunMaterialize,
+	},
+	"nudge": {
+		name:    "nudge",
+		summary: "Ask the running session to check for changes now, e.g. from an editor's save hook",
+		run:     (*App).RunNudge,
+	},
+	"pause": {
+		name:    "pause",
+		summary: "Pause checkpointing in the running session without stopping it",
+		run:     (*App).RunPause,
+	},
+	"restore": {
+		name:     "restore",
+		summary:  "List the last session's checkpoints, or restore the working tree or given paths to one",
+		run:      (*App).RunRestore,
+		pathArgs: true,
+	},
+	"resume": {
+		name:    "resume",
+		summary: "Resume checkpointing in a paused session",
+		run:     (*App).RunResume,
+	},
+	"service": {
+		name:    "service",
+		summary: "Install a user service (systemd or launchd) that runs gitbak for the repository at login",
+		run:     (*App).RunService,
+		args:    serviceActions,
+	},
+	"sessions": {
+		name:    "sessions",
+		summary: "List the recorded sessions of every repository",
+		run:     (*App).RunSessions,
+	},
+	"squash": {
+		name:    "squash",
+		summary: "Fold the last session into one commit on the original branch",
+		run:     (*App).RunSquash,
+	},
+	"start": {
+		name:    "start",
+		summary: "Start a monitoring session (the default without a command)",
+	},
+	"stats": {
+		name:    "stats",
+		summary: "Summarize the recorded sessions of every repository: checkpoints, time, busiest hours and per-repository totals",
+		run:     (*App).RunStats,
+	},
+	"status": {
+		name:    "status",
+		summary: "Show whether gitbak is running and when the next check is due",
+		run:     (*App).RunStatus,
+	},
+	"stop": {
+		name:    "stop",
+		summary: "Gracefully stop the gitbak process monitoring the repository",
+		run:     (*App).RunStop,
+	},
+	"verify": {
+		name:    "verify",
+		summary: "Check the last session's history against its integrity chain",
+		run:     (*App).RunVerify,
+	},
+}
+
+// splitCommand separates a leading subcommand from the remaining arguments.
+// If the first argument is not a known command, it returns nil and the arguments unchanged,
+// so that gitbak starts a monitoring session as usual.
+func splitCommand(args []string) (*command, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return nil, args
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return nil, args
+	}
+	return cmd, args[1:]
+}
+
+// completionCommands describes the subcommands to shell completion, sorted by name
+func completionCommands() []config.CompletionCommand {
+	completions := make([]config.CompletionCommand, 0, len(commands))
+	for _, cmd := range commands {
+		completions = append(completions, config.CompletionCommand{
+			Name:     cmd.name,
+			Summary:  cmd.summary,
+			Args:     cmd.args,
+			PathArgs: cmd.pathArgs,
+		})
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Name < completions[j].Name })
+	return completions
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// RunCommitNow asks the gitbak process monitoring the repository to check for changes
+// and checkpoint them immediately, e.g. before a risky refactor, even while it is paused.
+func (a *App) RunCommitNow(ctx context.Context) error {
+	return a.signalSession(commitNowSignal, "Requested a checkpoint from")
+}
+
+// watchCommitNowSignal requests an immediate check on commitNowSignal until ctx is done
+func (a *App) watchCommitNowSignal(ctx context.Context) {
+	if commitNowSignal == nil || a.checkNow == nil {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, commitNowSignal)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				a.Logger.Info("Immediate check requested by signal")
+				select {
+				case a.checkNow <- struct{}{}:
+				default:
+					// A check is already pending
+				}
+			}
+		}
+	}()
+}
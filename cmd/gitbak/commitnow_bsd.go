@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// commitNowSignal requests an immediate checkpoint in a running session. These systems
+// have no real-time signals, so SIGINFO is used, which Ctrl+T also sends from the terminal.
+var commitNowSignal os.Signal = syscall.SIGINFO
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// commitNowSignal requests an immediate checkpoint in a running session. It is
+// SIGRTMIN+1 as glibc numbers it (kill -RTMIN+1): the Go runtime reserves SIGRTMIN
+// itself, which musl uses internally.
+var commitNowSignal os.Signal = syscall.Signal(35)
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package main
+
+import "os"
+
+// commitNowSignal is unavailable on this platform; immediate checkpoints are requested
+// through the control endpoint instead.
+var commitNowSignal os.Signal
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCommitNow tests that commit-now requests an immediate check from the lock holder
+func TestCommitNow(t *testing.T) {
+	if commitNowSignal == nil {
+		t.Skip("No commit-now signal on this platform")
+	}
+
+	// The test process stands in for the monitoring gitbak instance
+	session := NewTestApp()
+	session = WithMockLogger(session, &MockLogger{})
+	session.checkNow = make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session.watchCommitNowSignal(ctx)
+
+	mockLogger := &MockLogger{}
+	app := NewTestApp()
+	app = WithMockLocker(app, &MockLocker{})
+	app = WithMockLogger(app, mockLogger)
+	app.Gitbak = &MockGitbaker{}
+	app.lockHolder = func(string) (int, bool) { return os.Getpid(), true }
+
+	if err := app.RunCommitNow(context.Background()); err != nil {
+		t.Fatalf("RunCommitNow failed: %v", err)
+	}
+	if !strings.Contains(mockLogger.LastMessage, "Requested a checkpoint from gitbak") {
+		t.Errorf("Expected a confirmation, got %q", mockLogger.LastMessage)
+	}
+
+	select {
+	case <-session.checkNow:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an immediate check to be requested")
+	}
+}
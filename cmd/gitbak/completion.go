@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// The completion command describes all commands, including itself, so it is added to
+// them at init rather than in their declaration, which would be an initialization cycle
+func init() {
+	commands["completion"] = &command{
+		name:    "completion",
+		summary: "Print a shell completion script for bash, zsh or fish",
+		run:     (*App).RunCompletion,
+		args:    config.CompletionShells,
+	}
+}
+
+// RunCompletion prints a completion script for the shell named by the command's argument
+func (a *App) RunCompletion(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if len(a.Config.Args) != 1 {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"completion takes the name of a shell: %s", strings.Join(config.CompletionShells, ", "))
+	}
+	return a.Config.PrintCompletion(a.Stdout, a.Config.Args[0], completionCommands())
+}
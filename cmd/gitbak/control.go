@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/control"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// startControlServer serves the JSON control endpoint on addr, so that status lines
+// and editor plugins can follow and steer the session without parsing log files:
+//
+//	GET  /status      the session's state
+//	POST /pause       skip checks until resumed
+//	POST /resume      resume checking
+//	POST /commit-now  check for changes immediately, even while paused
+//	POST /stop        stop the session, as gitbak stop does
+//
+// Every endpoint answers with the status document, control.Status; 'gitbak control' and
+// gitbakctl are its clients.
+func (a *App) startControlServer(addr string) error {
+	listener, err := localListener(addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", a.handleControlStatus)
+	mux.HandleFunc("/pause", a.handleControlPause)
+	mux.HandleFunc("/resume", a.handleControlResume)
+	mux.HandleFunc("/commit-now", a.handleControlCommitNow)
+	mux.HandleFunc("/stop", a.handleControlStop)
+
+	server := &http.Server{
+		Handler:           localOnly(mux, !strings.HasPrefix(addr, control.UnixAddrPrefix)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.controlServer = server
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.Logger.Warning("Control server stopped: %v", err)
+		}
+	}()
+
+	if strings.HasPrefix(addr, control.UnixAddrPrefix) {
+		a.Logger.InfoToUser("Control endpoint available on %s", addr)
+	} else {
+		a.Logger.InfoToUser("Control endpoint available at http://%s/status", listener.Addr())
+	}
+	return nil
+}
+
+// handleControlStatus reports the session's state
+func (a *App) handleControlStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a.writeControlStatus(w, http.StatusOK)
+}
+
+// handleControlPause suspends checkpointing
+func (a *App) handleControlPause(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	a.setPaused(true, "via the control endpoint")
+	a.writeControlStatus(w, http.StatusOK)
+}
+
+// handleControlResume resumes checkpointing
+func (a *App) handleControlResume(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	a.setPaused(false, "via the control endpoint")
+	a.writeControlStatus(w, http.StatusOK)
+}
+
+// handleControlCommitNow queues an immediate check. One already waiting absorbs further requests.
+func (a *App) handleControlCommitNow(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	select {
+	case a.checkNow <- struct{}{}:
+	default:
+		// A check is already pending
+	}
+	a.writeControlStatus(w, http.StatusAccepted)
+}
+
+// handleControlStop stops the session gracefully, with its final checkpoint and summary.
+// The status is written first, since the server closes once the session has stopped.
+func (a *App) handleControlStop(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	if a.requestStop == nil {
+		http.Error(w, "this session cannot be stopped through the control endpoint", http.StatusServiceUnavailable)
+		return
+	}
+	a.Logger.InfoToUser("Stop requested via the control endpoint")
+	a.writeControlStatus(w, http.StatusAccepted)
+	a.requestStop()
+}
+
+// localOnly rejects the requests a web page could have sent, so that a page open in the
+// user's browser can neither read nor steer the session: those with an Origin header that
+// is not this machine, which browsers send with every cross-site POST, and, when checkHost
+// is set, those whose Host header names another site, as when a page's DNS name is rebound
+// to 127.0.0.1. Neither curl nor 'gitbak control' sends an Origin. A Unix socket is only
+// reachable by the user, and its clients name any host, so its Host is not checked.
+func localOnly(next http.Handler, checkHost bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checkHost && !control.IsLoopbackHost(hostOnly(r.Host)) {
+			http.Error(w, "forbidden: the control endpoint only answers requests for this machine", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !control.IsLoopbackHost(u.Hostname()) {
+				http.Error(w, "forbidden: the control endpoint only answers requests from this machine", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostOnly returns the host of a Host header, without its port
+func hostOnly(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
+
+// requirePost rejects requests other than POST, reporting whether the request may proceed
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodPost)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// writeControlStatus writes the status document with the given HTTP status code
+func (a *App) writeControlStatus(w http.ResponseWriter, code int) {
+	status, err := a.controlStatus()
+	if err != nil {
+		a.Logger.Warning("Failed to read session state for the control endpoint: %v", err)
+		http.Error(w, "failed to read session state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// controlStatus builds the status document from the session state file, which the
+// session rewrites after every check; reading it avoids sharing the session's memory
+// with the server's goroutines.
+func (a *App) controlStatus() (*control.Status, error) {
+	status := &control.Status{
+		RepoPath: a.Config.RepoPath,
+		PID:      os.Getpid(),
+		Paused:   a.paused.Load(),
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if gitbakErrors.Is(err, session.ErrNoState) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status.Branch = state.Branch
+	status.Checkpoints = state.CommitsCount
+	status.StartTime = optionalTime(state.StartTime)
+	status.LastCommitTime = optionalTime(state.LastCommitTime)
+	status.LastCheckTime = optionalTime(state.LastCheckTime)
+	status.IntervalMinutes = state.IntervalMinutes
+	status.Watch = state.Watch
+	if !state.Watch && state.IntervalMinutes > 0 && !status.Paused {
+		status.NextCheckTime = optionalTime(state.NextCheck())
+	}
+	return status, nil
+}
+
+// optionalTime returns nil for the zero time, so that it is left out of the JSON document
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// RunControl calls the control endpoint of the running session and prints the status
+// document it answers with. The first argument selects the action: status reads the
+// session's state, and the others ask the session to act on it. The endpoint is the one
+// given with -listen or, failing that, the one the session recorded in its state, so
+// the client works on every platform, including Windows, where sessions cannot be
+// signalled.
+func (a *App) RunControl(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if len(a.Config.Args) != 1 || !slices.Contains(control.Actions, a.Config.Args[0]) {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"control takes one action: %s", strings.Join(control.Actions, ", "))
+	}
+	action := a.Config.Args[0]
+
+	addr := a.Config.ListenAddr
+	if addr == "" {
+		if state, err := session.Load(a.Config.StateFile); err == nil {
+			addr = state.ControlAddr
+		}
+	}
+	if addr == "" {
+		return gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"no control endpoint found; start the session with -listen, or pass its address here")
+	}
+
+	body, err := control.Call(ctx, addr, action)
+	if err != nil {
+		return err
+	}
+
+	_, _ = a.Stdout.Write(body)
+	return nil
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/control"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestControlEndpoint tests the status, pause, resume and commit-now handlers
+func TestControlEndpoint(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		method string
+		path   string
+	}
+
+	tests := map[string]struct {
+		requests      []request
+		recordState   bool
+		expectStatus  int
+		expectPaused  bool
+		expectPending bool
+		expectBranch  string
+		expectNext    bool
+	}{
+		"Status": {
+			requests:     []request{{http.MethodGet, "/status"}},
+			recordState:  true,
+			expectStatus: http.StatusOK,
+			expectBranch: "gitbak-20250101-120000",
+			expectNext:   true,
+		},
+		"StatusWithoutState": {
+			requests:     []request{{http.MethodGet, "/status"}},
+			expectStatus: http.StatusOK,
+		},
+		"Pause": {
+			requests:     []request{{http.MethodPost, "/pause"}},
+			recordState:  true,
+			expectStatus: http.StatusOK,
+			expectPaused: true,
+			expectBranch: "gitbak-20250101-120000",
+		},
+		"PauseThenResume": {
+			requests:     []request{{http.MethodPost, "/pause"}, {http.MethodPost, "/resume"}},
+			recordState:  true,
+			expectStatus: http.StatusOK,
+			expectBranch: "gitbak-20250101-120000",
+			expectNext:   true,
+		},
+		"CommitNow": {
+			requests:      []request{{http.MethodPost, "/commit-now"}, {http.MethodPost, "/commit-now"}},
+			expectStatus:  http.StatusAccepted,
+			expectPending: true,
+		},
+		"PauseRequiresPost": {
+			requests:     []request{{http.MethodGet, "/pause"}},
+			expectStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.New()
+			cfg.RepoPath = "/test/repo"
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+			if test.recordState {
+				now := time.Now()
+				state := &session.State{
+					RepoPath:        cfg.RepoPath,
+					Branch:          "gitbak-20250101-120000",
+					StartTime:       now.Add(-time.Hour),
+					LastCheckTime:   now,
+					CommitsCount:    4,
+					IntervalMinutes: 5,
+				}
+				if err := session.Save(cfg.StateFile, state); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+			}
+
+			app := &App{Config: cfg, Logger: &MockLogger{}, checkNow: make(chan struct{}, 1)}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/status", app.handleControlStatus)
+			mux.HandleFunc("/pause", app.handleControlPause)
+			mux.HandleFunc("/resume", app.handleControlResume)
+			mux.HandleFunc("/commit-now", app.handleControlCommitNow)
+
+			var rec *httptest.ResponseRecorder
+			for _, req := range test.requests {
+				rec = httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(req.method, req.path, nil))
+			}
+
+			if rec.Code != test.expectStatus {
+				t.Fatalf("Expected status %d, got %d", test.expectStatus, rec.Code)
+			}
+			if pending := len(app.checkNow) == 1; pending != test.expectPending {
+				t.Errorf("Expected pending check to be %v, got %v", test.expectPending, pending)
+			}
+			if rec.Code == http.StatusMethodNotAllowed {
+				return
+			}
+
+			var status control.Status
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to decode status %q: %v", rec.Body.String(), err)
+			}
+			if status.RepoPath != cfg.RepoPath {
+				t.Errorf("Expected repo path %s, got %s", cfg.RepoPath, status.RepoPath)
+			}
+			if status.Paused != test.expectPaused {
+				t.Errorf("Expected paused to be %v, got %v", test.expectPaused, status.Paused)
+			}
+			if status.Branch != test.expectBranch {
+				t.Errorf("Expected branch %q, got %q", test.expectBranch, status.Branch)
+			}
+			if (status.NextCheckTime != nil) != test.expectNext {
+				t.Errorf("Expected next check to be reported: %v, got %v", test.expectNext, status.NextCheckTime)
+			}
+		})
+	}
+}
+
+// TestControlStop tests that POST /stop stops the session, if it can be stopped
+func TestControlStop(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		method       string
+		stoppable    bool
+		expectStatus int
+		expectStop   bool
+	}{
+		"Stop": {
+			method:       http.MethodPost,
+			stoppable:    true,
+			expectStatus: http.StatusAccepted,
+			expectStop:   true,
+		},
+		"NotStoppable": {
+			method:       http.MethodPost,
+			expectStatus: http.StatusServiceUnavailable,
+		},
+		"RequiresPost": {
+			method:       http.MethodGet,
+			stoppable:    true,
+			expectStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.New()
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+			app := &App{Config: cfg, Logger: &MockLogger{}}
+
+			stopped := false
+			if test.stoppable {
+				app.requestStop = func() { stopped = true }
+			}
+
+			rec := httptest.NewRecorder()
+			app.handleControlStop(rec, httptest.NewRequest(test.method, "/stop", nil))
+
+			if rec.Code != test.expectStatus {
+				t.Errorf("Expected status %d, got %d", test.expectStatus, rec.Code)
+			}
+			if stopped != test.expectStop {
+				t.Errorf("Expected stop to be requested: %v, got %v", test.expectStop, stopped)
+			}
+		})
+	}
+}
+
+// TestControlLocalOnly tests that the control endpoint refuses requests a web page could
+// have sent, naming another site in their Host or Origin header
+func TestControlLocalOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		host         string
+		origin       string
+		unix         bool
+		expectStatus int
+	}{
+		"Loopback":          {host: "127.0.0.1:7373", expectStatus: http.StatusOK},
+		"IPv6Loopback":      {host: "[::1]:7373", expectStatus: http.StatusOK},
+		"Localhost":         {host: "localhost:7373", expectStatus: http.StatusOK},
+		"LocalOrigin":       {host: "127.0.0.1:7373", origin: "http://localhost:3000", expectStatus: http.StatusOK},
+		"ForeignOrigin":     {host: "127.0.0.1:7373", origin: "https://evil.example", expectStatus: http.StatusForbidden},
+		"OpaqueOrigin":      {host: "127.0.0.1:7373", origin: "null", expectStatus: http.StatusForbidden},
+		"DNSRebinding":      {host: "evil.example:7373", expectStatus: http.StatusForbidden},
+		"UnixSocket":        {host: "unix", unix: true, expectStatus: http.StatusOK},
+		"UnixForeignOrigin": {host: "unix", unix: true, origin: "https://evil.example", expectStatus: http.StatusForbidden},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			paused := false
+			handler := localOnly(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				paused = true
+				w.WriteHeader(http.StatusOK)
+			}), !test.unix)
+
+			req := httptest.NewRequest(http.MethodPost, "/pause", nil)
+			req.Host = test.host
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != test.expectStatus {
+				t.Errorf("Expected status %d, got %d", test.expectStatus, rec.Code)
+			}
+			if paused != (test.expectStatus == http.StatusOK) {
+				t.Errorf("Expected the request to reach the endpoint: %v, got %v", test.expectStatus == http.StatusOK, paused)
+			}
+		})
+	}
+}
+
+// TestRunControl tests the control command against a session listening on a Unix socket
+func TestRunControl(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, so avoid the long t.TempDir paths
+	socketDir, err := os.MkdirTemp("", "gitbak-control")
+	if err != nil {
+		t.Fatalf("Failed to create socket directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+
+	tests := map[string]struct {
+		args          []string
+		listenAddr    string
+		passAddr      bool
+		recordAddr    bool
+		errorIs       error
+		expectPaused  bool
+		expectPending bool
+	}{
+		"Status": {
+			args:       []string{"status"},
+			recordAddr: true,
+		},
+		"Pause": {
+			args:         []string{"pause"},
+			recordAddr:   true,
+			expectPaused: true,
+		},
+		"CommitNowWithAddress": {
+			args:          []string{"commit-now"},
+			passAddr:      true,
+			expectPending: true,
+		},
+		"NoEndpoint": {
+			args:    []string{"status"},
+			errorIs: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"NoSession": {
+			args:       []string{"status"},
+			listenAddr: "unix:" + filepath.Join(socketDir, "missing.sock"),
+			errorIs:    gitbakErrors.ErrNotRunning,
+		},
+		"UnknownAction": {
+			args:       []string{"restart"},
+			recordAddr: true,
+			errorIs:    gitbakErrors.ErrInvalidConfiguration,
+		},
+		"MissingAction": {
+			recordAddr: true,
+			errorIs:    gitbakErrors.ErrInvalidConfiguration,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := "unix:" + filepath.Join(socketDir, name+".sock")
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+
+			cfg := config.New()
+			cfg.RepoPath = "/test/repo"
+			cfg.StateFile = stateFile
+			session := &App{Config: cfg, Logger: &MockLogger{}, checkNow: make(chan struct{}, 1)}
+			if err := session.startControlServer(addr); err != nil {
+				t.Fatalf("Failed to start control server: %v", err)
+			}
+			t.Cleanup(func() { _ = session.controlServer.Close() })
+
+			if test.recordAddr {
+				saveControlState(t, stateFile, addr)
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.StateFile = stateFile
+			app.Config.Args = test.args
+			app.Config.ListenAddr = test.listenAddr
+			if test.passAddr {
+				app.Config.ListenAddr = addr
+			}
+
+			err := app.RunControl(context.Background())
+
+			if test.errorIs != nil {
+				if !gitbakErrors.Is(err, test.errorIs) {
+					t.Fatalf("Expected error %v, got %v", test.errorIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunControl failed: %v", err)
+			}
+
+			var status control.Status
+			if err := json.Unmarshal(stdout.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to decode status %q: %v", stdout.String(), err)
+			}
+			if status.RepoPath != cfg.RepoPath {
+				t.Errorf("Expected repo path %s, got %s", cfg.RepoPath, status.RepoPath)
+			}
+			if status.Paused != test.expectPaused {
+				t.Errorf("Expected paused to be %v, got %v", test.expectPaused, status.Paused)
+			}
+			if pending := len(session.checkNow) == 1; pending != test.expectPending {
+				t.Errorf("Expected pending check to be %v, got %v", test.expectPending, pending)
+			}
+		})
+	}
+}
+
+// saveControlState records a running session serving its control endpoint on addr
+func saveControlState(t *testing.T, stateFile, addr string) {
+	t.Helper()
+
+	state := &session.State{
+		RepoPath:    "/test/repo",
+		Branch:      "gitbak-20250101-120000",
+		StartTime:   time.Now(),
+		ControlAddr: addr,
+	}
+	if err := session.Save(stateFile, state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+}
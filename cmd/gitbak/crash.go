@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// crashReportPrefix starts the names of crash report files. It differs from the log
+// file names, so pruning old logs leaves crash reports alone.
+const crashReportPrefix = "gitbak-crash-"
+
+// recoverPanic turns a panic that reached main into a crash report and a failure exit,
+// after releasing the lock. It must be deferred directly.
+func (a *App) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := a.crashed(r, debug.Stack())
+	_ = a.Close()
+	a.fail(err)
+}
+
+// runMonitoring runs the main gitbak process, turning a panic in it into an
+// ErrCrashed failure so the session ends through the usual stop path.
+func (a *App) runMonitoring(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = a.crashed(r, debug.Stack())
+		}
+	}()
+	return a.Gitbak.Run(ctx)
+}
+
+// crashed writes a crash report for the panic r and returns the ErrCrashed failure
+// to end gitbak with, naming the report. If no report could be written, the error
+// carries the stack trace instead.
+func (a *App) crashed(r any, stack []byte) error {
+	path, err := a.writeCrashReport(r, stack, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v (failed to write a crash report: %v)\n%s", gitbakErrors.ErrCrashed, r, err, stack)
+	}
+	return fmt.Errorf("%w: %v (crash report written to %s)", gitbakErrors.ErrCrashed, r, path)
+}
+
+// writeCrashReport writes the panic r, its stack trace, the build and the configuration,
+// with secrets redacted, to a new file beside the log file, and returns its path
+func (a *App) writeCrashReport(r any, stack []byte, now time.Time) (string, error) {
+	dir := config.LogDir()
+	if a.Config.LogFile != "" {
+		dir = filepath.Dir(a.Config.LogFile)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", gitbakErrors.Wrap(err, "failed to create log directory")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "gitbak crash report\n\n")
+	fmt.Fprintf(&b, "Time:       %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:    %s (commit %s, built %s)\n", a.Config.VersionInfo.Version, a.Config.VersionInfo.Commit, a.Config.VersionInfo.Date)
+	fmt.Fprintf(&b, "Go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Repository: %s\n\n", a.Config.RepoPath)
+	fmt.Fprintf(&b, "Panic: %v\n\n%s\n", r, stack)
+	fmt.Fprintf(&b, "Configuration:\n%s", a.Config.Snapshot())
+
+	path := filepath.Join(dir, crashReportPrefix+now.Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", gitbakErrors.Wrap(err, "failed to write crash report")
+	}
+	return path, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestRunCrashReport tests that a panic while monitoring releases the lock and leaves a crash report
+func TestRunCrashReport(t *testing.T) {
+	logDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	mockLocker := &MockLocker{}
+	app := NewDefaultApp(config.VersionInfo{Version: "1.2.3", Commit: "abc123", Date: "2025-01-01"})
+	app.Stdout = &stdout
+	app.Stderr = &stderr
+	app.Locker = mockLocker
+	app.Logger = logger.New(false, "", true)
+	app.exit = func(int) {}
+	app.Gitbak = &MockGitbaker{RunPanic: "boom"}
+	app.Config.RepoPath = t.TempDir()
+	app.Config.LogFile = filepath.Join(logDir, "gitbak.log")
+	app.Config.OnStop = "notify-send --token s3cret"
+	app.isRepository = func(_ context.Context, path string) (bool, error) {
+		return true, nil
+	}
+	app.execLookPath = func(name string) (string, error) {
+		return "/usr/bin/" + name, nil
+	}
+
+	err := app.Run(context.Background())
+	if !gitbakErrors.Is(err, gitbakErrors.ErrCrashed) {
+		t.Fatalf("Expected ErrCrashed, got %v", err)
+	}
+	if code := gitbakErrors.ExitCode(err); code != gitbakErrors.ExitCodeCrashed {
+		t.Errorf("Expected exit code %d, got %d", gitbakErrors.ExitCodeCrashed, code)
+	}
+	if !mockLocker.ReleaseCalled {
+		t.Error("Expected the lock to be released")
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(logDir, crashReportPrefix+"*.txt"))
+	if len(reports) != 1 {
+		t.Fatalf("Expected one crash report, got %v", reports)
+	}
+	if !strings.Contains(err.Error(), reports[0]) {
+		t.Errorf("Expected the error to name the crash report %s, got %q", reports[0], err)
+	}
+	data, readErr := os.ReadFile(reports[0])
+	if readErr != nil {
+		t.Fatalf("Failed to read crash report: %v", readErr)
+	}
+	report := string(data)
+	for _, want := range []string{"Panic: boom", "1.2.3 (commit abc123", "runMonitoring", "interval = 5m0s", "on-stop = <redacted>"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the crash report to contain %q, got:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "s3cret") {
+		t.Errorf("Expected the crash report to leave out the stop hook, got:\n%s", report)
+	}
+}
+
+// TestCrashedWithoutReport tests that the stack trace is kept in the error when no report can be written
+func TestCrashedWithoutReport(t *testing.T) {
+	blocked := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocked, nil, 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.LogFile = filepath.Join(blocked, "gitbak.log")
+	app := &App{Config: cfg}
+
+	err := app.crashed(errors.New("boom"), []byte("goroutine 1 [running]:"))
+	if !gitbakErrors.Is(err, gitbakErrors.ErrCrashed) {
+		t.Fatalf("Expected ErrCrashed, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "goroutine 1 [running]:") {
+		t.Errorf("Expected the error to carry the stack trace, got %q", err)
+	}
+}
+
+// TestWriteCrashReportName tests that crash reports are named by time and survive log pruning
+func TestWriteCrashReportName(t *testing.T) {
+	logDir := t.TempDir()
+	cfg := config.New()
+	cfg.LogFile = filepath.Join(logDir, "gitbak.log")
+	app := &App{Config: cfg}
+
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	path, err := app.writeCrashReport("boom", nil, now)
+	if err != nil {
+		t.Fatalf("writeCrashReport failed: %v", err)
+	}
+	if expected := filepath.Join(logDir, "gitbak-crash-20250304-050607.txt"); path != expected {
+		t.Errorf("Expected the report at %s, got %s", expected, path)
+	}
+
+	old := now.Add(-365 * 24 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Failed to age crash report: %v", err)
+	}
+	if _, err := logger.PruneLogs(logDir, time.Hour); err != nil {
+		t.Fatalf("PruneLogs failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected log pruning to keep the crash report, got %v", err)
+	}
+}
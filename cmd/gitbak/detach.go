@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// detachedEnvVar is set in the environment of a detached session, so that it runs
+// in the foreground of its own process instead of detaching again
+const detachedEnvVar = "GITBAK_DETACHED"
+
+// detachTimeout is how long the launching process waits for a detached session
+// to take the repository lock. It exceeds startupCheckTimeout so that slow
+// startup checks are reported by the session itself.
+const detachTimeout = 2 * startupCheckTimeout
+
+// shouldDetach reports whether this process should start a background session
+// rather than monitor the repository itself
+func (a *App) shouldDetach() bool {
+	return a.Config.Detach && !a.Config.Version && !a.Config.ShowLogo && os.Getenv(detachedEnvVar) == ""
+}
+
+// RunDetached starts a copy of gitbak with the given arguments in a new session,
+// detached from the terminal, and returns once it holds the repository lock.
+// Prompts in the detached session are answered with their defaults, and its
+// output is appended to the log file.
+func (a *App) RunDetached(args []string) error {
+	if err := a.Config.Finalize(); err != nil {
+		if gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+			return err
+		}
+		return gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration, err.Error())
+	}
+
+	if config.IsDisabled() {
+		return gitbakErrors.ErrDisabled
+	}
+
+	// With -takeover the detached session stops the running one itself
+	if pid, running := a.lockHolder(a.lockKey(a.Config.RepoPath)); running && !a.Config.Takeover {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrAlreadyRunning, "PID %d is monitoring %s", pid, a.Config.RepoPath)
+	}
+
+	exe, err := a.executable()
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to locate the gitbak executable")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.Config.LogFile), 0o700); err != nil {
+		return gitbakErrors.Wrap(err, "failed to create log directory")
+	}
+	output, err := os.OpenFile(a.Config.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to open log file for the detached session")
+	}
+	defer func() { _ = output.Close() }()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Env = append(os.Environ(), detachedEnvVar+"=1", "NON_INTERACTIVE=true")
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return gitbakErrors.Wrap(err, "failed to start the detached session")
+	}
+	pid := cmd.Process.Pid
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	timeout := detachTimeout
+	if a.Config.Takeover {
+		timeout += a.takeoverTimeout() + killTimeout
+	}
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("detached gitbak exited during startup (%v); see %s", err, a.Config.LogFile)
+		case <-deadline:
+			return fmt.Errorf("detached gitbak (PID %d) did not start monitoring within %s; see %s",
+				pid, timeout, a.Config.LogFile)
+		case <-ticker.C:
+			if holder, running := a.lockHolder(a.lockKey(a.Config.RepoPath)); running && holder == pid {
+				_, _ = fmt.Fprintf(a.Stdout, "✅ gitbak is running in the background (PID %d) for %s\n", pid, a.Config.RepoPath)
+				_, _ = fmt.Fprintf(a.Stdout, "📄 Output: %s\n", a.Config.LogFile)
+				_, _ = fmt.Fprintf(a.Stdout, "Check on it with 'gitbak status' and end it with 'gitbak stop'.\n")
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/lock"
+)
+
+// TestDetachHelperProcess stands in for a detached gitbak session: it takes the
+// repository lock and holds it until killed. It does nothing in a normal test run.
+func TestDetachHelperProcess(t *testing.T) {
+	repoPath := os.Getenv("GITBAK_TEST_DETACH_REPO")
+	if os.Getenv(detachedEnvVar) != "1" || repoPath == "" {
+		return
+	}
+
+	locker, err := lock.New(repoPath)
+	if err != nil {
+		os.Exit(2)
+	}
+	if err := locker.Acquire(); err != nil {
+		os.Exit(2)
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+// TestRunDetached tests starting a session in the background
+func TestRunDetached(t *testing.T) {
+	tests := map[string]struct {
+		holdLock      bool
+		disabled      bool
+		exitEarly     bool
+		errorIs       error
+		errorContains string
+	}{
+		"Detached": {},
+		"AlreadyRunning": {
+			holdLock: true,
+			errorIs:  gitbakErrors.ErrAlreadyRunning,
+		},
+		"KillSwitchEngaged": {
+			disabled: true,
+			errorIs:  gitbakErrors.ErrDisabled,
+		},
+		"ExitsDuringStartup": {
+			exitEarly:     true,
+			errorContains: "exited during startup",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			t.Setenv("GITBAK_TEST_DETACH_REPO", repoPath)
+			t.Setenv(detachedEnvVar, "")
+			if test.disabled {
+				t.Setenv(config.DisableEnvVar, "true")
+			} else {
+				t.Setenv(config.DisableEnvVar, "")
+			}
+
+			if test.holdLock {
+				locker, err := lock.New(repoPath)
+				if err != nil {
+					t.Fatalf("Failed to create locker: %v", err)
+				}
+				if err := locker.Acquire(); err != nil {
+					t.Fatalf("Failed to acquire lock: %v", err)
+				}
+				t.Cleanup(func() { _ = locker.Release() })
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app.Stdout = &stdout
+			app.Config.RepoPath = repoPath
+			app.Config.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+			app.executable = func() (string, error) { return os.Args[0], nil }
+
+			args := []string{"-test.run=^TestDetachHelperProcess$"}
+			if test.exitEarly {
+				args = []string{"-test.run=^$"}
+			}
+
+			err := app.RunDetached(args)
+			t.Cleanup(func() {
+				if pid, running := lock.Holder(repoPath); running && pid != os.Getpid() {
+					if process, err := os.FindProcess(pid); err == nil {
+						_ = process.Kill()
+					}
+				}
+			})
+
+			if test.errorIs != nil {
+				if !gitbakErrors.Is(err, test.errorIs) {
+					t.Fatalf("Expected error %v, got %v", test.errorIs, err)
+				}
+				return
+			}
+			if test.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunDetached failed: %v", err)
+			}
+
+			pid, running := lock.Holder(repoPath)
+			if !running || pid == os.Getpid() {
+				t.Fatalf("Expected the detached session to hold the lock, got PID %d (running: %v)", pid, running)
+			}
+			if !strings.Contains(stdout.String(), "running in the background") {
+				t.Errorf("Expected a confirmation, got %q", stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunDiff shows the changes between two checkpoints of a session, given by number or
+// commit as restore takes them: gitbak diff <n> <m>. The session is the most recent one,
+// unless a third argument before the checkpoints names another of the repository's
+// sessions by its branch, its name under refs/gitbak or its session ID.
+func (a *App) RunDiff(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	args := a.Config.Args
+	name := ""
+	switch len(args) {
+	case 2:
+	case 3:
+		name, args = args[0], args[1:]
+	default:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"diff takes two checkpoints, optionally after the session they belong to: gitbak diff [session] <n> <m>")
+	}
+
+	state, err := a.findSession(name)
+	if err != nil {
+		return err
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	checkpoints, err := repo.ListCheckpoints(ctx, state)
+	if err != nil {
+		return err
+	}
+	from, err := git.FindCheckpoint(checkpoints, args[0])
+	if err != nil {
+		return err
+	}
+	to, err := git.FindCheckpoint(checkpoints, args[1])
+	if err != nil {
+		return err
+	}
+
+	changed, err := repo.DiffCheckpoints(ctx, a.Stdout, from, to)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		_, _ = fmt.Fprintf(a.Stdout, "No changes between checkpoints %s and %s.\n", checkpointName(from), checkpointName(to))
+	}
+	return nil
+}
+
+// findSession returns the repository's most recent session or, if name is given, its
+// latest session whose branch, name under refs/gitbak or session ID is name, looking
+// through the finished sessions in the history too
+func (a *App) findSession(name string) (*session.State, error) {
+	current, err := session.Load(a.Config.StateFile)
+	if err != nil && !gitbakErrors.Is(err, session.ErrNoState) {
+		return nil, err
+	}
+	if name == "" {
+		if current == nil {
+			return nil, gitbakErrors.Wrapf(err, "no gitbak session in %s", a.Config.RepoPath)
+		}
+		return current, nil
+	}
+
+	history, err := session.LoadHistory(filepath.Join(filepath.Dir(a.Config.StateFile), session.HistoryFileName))
+	if err != nil {
+		return nil, err
+	}
+	// The history is oldest first, and the current session the newest of all
+	candidates := slices.Clone(history)
+	slices.Reverse(candidates)
+	if current != nil {
+		candidates = append([]*session.State{current}, candidates...)
+	}
+
+	for _, state := range candidates {
+		if state.RepoPath != a.Config.RepoPath {
+			continue
+		}
+		if state.Branch == name || state.SessionID == name ||
+			(state.Refs != "" && strings.TrimPrefix(state.Refs, git.RefsPrefix) == name) {
+			return state, nil
+		}
+	}
+	return nil, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+		"no gitbak session named '%s' in %s; gitbak sessions lists them", name, a.Config.RepoPath)
+}
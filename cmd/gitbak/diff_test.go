@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunDiff tests the diff command against a real repository
+func TestRunDiff(t *testing.T) {
+	tests := map[string]struct {
+		args           []string
+		outputContains []string
+		errorContains  string
+	}{
+		"LastSession": {
+			args:           []string{"1", "3"},
+			outputContains: []string{"-one", "+three", "+other"},
+		},
+		"Reversed": {
+			args:           []string{"#3", "#2"},
+			outputContains: []string{"-three", "+two"},
+		},
+		"NamedSession": {
+			args:           []string{"gitbak-earlier", "1", "2"},
+			outputContains: []string{"-before", "+after"},
+		},
+		"NamedBySessionID": {
+			args:           []string{"01JEARLIER0000000000000000", "1", "2"},
+			outputContains: []string{"+after"},
+		},
+		"SameCheckpoint": {
+			args:           []string{"2", "2"},
+			outputContains: []string{"No changes between checkpoints #2 and #2"},
+		},
+		"UnknownSession": {
+			args:          []string{"gitbak-missing", "1", "2"},
+			errorContains: "no gitbak session named 'gitbak-missing'",
+		},
+		"UnknownCheckpoint": {
+			args:          []string{"1", "9"},
+			errorContains: "no checkpoint 9",
+		},
+		"OneArgument": {
+			args:          []string{"1"},
+			errorContains: "diff takes two checkpoints",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				run := func(args ...string) string {
+					out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+					if err != nil {
+						t.Fatalf("git %v failed: %v", args, err)
+					}
+					return strings.TrimSpace(string(out))
+				}
+				checkpoint := func(subject string, files map[string]string) {
+					for name, content := range files {
+						if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+							t.Fatalf("Failed to write %s: %v", name, err)
+						}
+					}
+					run("add", ".")
+					run("commit", "-m", subject)
+				}
+
+				// An earlier, finished session and the latest one
+				startCommit := run("rev-parse", "HEAD")
+				run("checkout", "-q", "-b", "gitbak-earlier")
+				checkpoint("[wip] Checkpoint #1 - 2026-01-01 09:00:00", map[string]string{"earlier.txt": "before\n"})
+				checkpoint("[wip] Checkpoint #2 - 2026-01-01 09:05:00", map[string]string{"earlier.txt": "after\n"})
+				run("checkout", "-q", startCommit)
+				run("checkout", "-q", "-b", "gitbak-latest")
+				checkpoint("[gitbak] Automatic checkpoint #1 - 2026-01-02 10:00:00", map[string]string{"notes.txt": "one\n"})
+				checkpoint("[gitbak] Automatic checkpoint #2 - 2026-01-02 10:05:00", map[string]string{"notes.txt": "two\n"})
+				checkpoint("[gitbak] Automatic checkpoint #3 - 2026-01-02 10:10:00", map[string]string{"notes.txt": "three\n", "other.txt": "other\n"})
+
+				stateDir := t.TempDir()
+				stateFile := filepath.Join(stateDir, "state.json")
+				latest := &session.State{RepoPath: repoPath, Branch: "gitbak-latest", CommitPrefix: "[gitbak] Automatic checkpoint", StartCommit: startCommit}
+				if err := session.Save(stateFile, latest); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+				earlier := &session.State{RepoPath: repoPath, Branch: "gitbak-earlier", CommitPrefix: "[wip] Checkpoint", StartCommit: startCommit, SessionID: "01JEARLIER0000000000000000"}
+				if err := session.AppendHistory(filepath.Join(stateDir, session.HistoryFileName), earlier); err != nil {
+					t.Fatalf("Failed to record history: %v", err)
+				}
+
+				var stdout bytes.Buffer
+				app := NewTestApp()
+				app = WithMockLocker(app, &MockLocker{})
+				app = WithMockLogger(app, &MockLogger{})
+				app.Stdout = &stdout
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.Args = test.args
+
+				err := app.RunDiff(context.Background())
+				if test.errorContains != "" {
+					if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+						t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("RunDiff failed: %v", err)
+				}
+				for _, want := range test.outputContains {
+					if !strings.Contains(stdout.String(), want) {
+						t.Errorf("Expected output to contain %q, got %q", want, stdout.String())
+					}
+				}
+			})
+		})
+	}
+}
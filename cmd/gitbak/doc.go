@@ -34,6 +34,28 @@
 //	gitbak -branch "my-branch" # Run with a custom branch name
 //	gitbak -continue           # Continue from an existing gitbak session
 //	gitbak -no-branch          # Use current branch instead of creating a new one
+//	gitbak start               # Same as running gitbak without a command
+//	gitbak start -detach       # Run the session in the background
+//	gitbak status              # Show whether gitbak is running and when the next check is due
+//	gitbak stop                # Gracefully stop the gitbak process for this repository
+//	gitbak pause               # Pause checkpointing in the running session
+//	gitbak resume              # Resume checkpointing in a paused session
+//	gitbak nudge               # Ask the running session to check for changes now
+//	gitbak commit-now          # Checkpoint changes in the running session right away
+//	gitbak control status      # Query or steer the session through its control endpoint
+//	gitbak sessions            # List the recorded sessions of every repository
+//	gitbak stats               # Summarize the recorded sessions: checkpoints, time, busiest hours
+//	gitbak service install     # Run gitbak for the repository at every login (systemd or launchd)
+//	gitbak logs -f             # Follow the repository's log file; 'logs path' prints where it is
+//	gitbak ignores [path...]   # Print the exclusion rules and left-out files, or which rule excludes each path
+//	gitbak abort               # Discard the last session and return to the original branch
+//	gitbak squash              # Fold the last session into one commit on the original branch
+//	gitbak restore [n [path]]  # List the last session's checkpoints, or restore files from one
+//	gitbak diff 3 7            # Show the changes between checkpoints #3 and #7 of the last session
+//	gitbak verify              # Check the last session's history against its integrity chain
+//	gitbak export-bundle [to]  # Write the last session's branch to a git bundle file
+//	gitbak materialize [name]  # Create a branch from the last -refs-only session's checkpoint refs
+//	gitbak completion bash     # Print a shell completion script (bash, zsh or fish)
 //
 // # Configuration Options
 //
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+)
+
+// RunIgnores prints the exclusion rules checkpoints are subject to, and the changed files
+// left out for their size or for holding secrets. Given paths, it instead explains for
+// each one whether it is excluded and by which rule, similar to git check-ignore -v.
+// Relative paths are resolved against the current directory.
+func (a *App) RunIgnores(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	opts := git.IgnoreOptions{
+		Paths:           a.Config.Paths,
+		UntrackedPolicy: a.Config.UntrackedPolicy,
+		MaxFileSizeMB:   a.Config.MaxFileSizeMB,
+		Secrets:         a.Config.Secrets,
+	}
+	leftOut, err := repo.LeftOutFiles(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Config.Args) == 0 {
+		sources, err := repo.IgnoreSources(ctx, opts)
+		if err != nil {
+			return err
+		}
+		a.printIgnoreSources(sources)
+		a.printLeftOutFiles(leftOut)
+		return nil
+	}
+
+	for _, arg := range a.Config.Args {
+		path, err := a.repoRelativePath(arg)
+		if err != nil {
+			return err
+		}
+
+		match, err := repo.ExplainIgnore(ctx, filepath.ToSlash(path), opts)
+		if err != nil {
+			return err
+		}
+		if file := leftOutFile(leftOut, match.Path); file != nil && !match.Excluded() {
+			_, _ = fmt.Fprintf(a.Stdout, "🚫 %s: left out by %s: %s\n", match.Path, file.Source, file.Reason)
+			continue
+		}
+		a.printIgnoreMatch(match)
+	}
+	return nil
+}
+
+// leftOutFile returns the file of leftOut at path, if any
+func leftOutFile(leftOut []git.LeftOutFile, path string) *git.LeftOutFile {
+	for i := range leftOut {
+		if leftOut[i].Path == path {
+			return &leftOut[i]
+		}
+	}
+	return nil
+}
+
+// repoRelativePath converts a command-line path to a path relative to the repository
+func (a *App) repoRelativePath(arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", gitbakErrors.Wrapf(err, "failed to resolve %s", arg)
+	}
+
+	rel, err := filepath.Rel(a.Config.RepoPath, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "%s is outside the repository %s", arg, a.Config.RepoPath)
+	}
+	return rel, nil
+}
+
+// printIgnoreSources lists each exclude file and its rules, in increasing order of precedence,
+// followed by the layers of the options that leave out more
+func (a *App) printIgnoreSources(sources []git.IgnoreSource) {
+	_, _ = fmt.Fprintf(a.Stdout, "Exclusion rules for %s (later rules take precedence)\n", a.Config.RepoPath)
+
+	for _, source := range sources {
+		switch {
+		case source.Description != "":
+			_, _ = fmt.Fprintf(a.Stdout, "  ⚙️  %s leaves out %s\n", source.Path, source.Description)
+			for _, rule := range source.Rules {
+				_, _ = fmt.Fprintf(a.Stdout, "       %s\n", rule.Pattern)
+			}
+			continue
+		case !source.Exists:
+			_, _ = fmt.Fprintf(a.Stdout, "  📄 %s (not present)\n", source.Path)
+			continue
+		case len(source.Rules) == 0:
+			_, _ = fmt.Fprintf(a.Stdout, "  📄 %s (no rules)\n", source.Path)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(a.Stdout, "  📄 %s\n", source.Path)
+		for _, rule := range source.Rules {
+			if rule.Line == 0 {
+				_, _ = fmt.Fprintf(a.Stdout, "       %s\n", rule.Pattern)
+			} else {
+				_, _ = fmt.Fprintf(a.Stdout, "  %4d %s\n", rule.Line, rule.Pattern)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "Tracked files are always checkpointed; the rules only apply to untracked files, except those in %s and %s.\n",
+		git.BakignoreFile, git.PathsIgnoreSource)
+}
+
+// printLeftOutFiles lists the changed files the next checkpoint leaves out for their contents
+func (a *App) printLeftOutFiles(leftOut []git.LeftOutFile) {
+	if len(leftOut) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(a.Stdout, "Changed files left out of the next checkpoint")
+	for _, file := range leftOut {
+		_, _ = fmt.Fprintf(a.Stdout, "  🚫 %s: %s (%s)\n", file.Path, file.Reason, file.Source)
+	}
+}
+
+// printIgnoreMatch explains the outcome for a single path
+func (a *App) printIgnoreMatch(match *git.IgnoreMatch) {
+	switch {
+	case match.Excluded():
+		_, _ = fmt.Fprintf(a.Stdout, "🚫 %s: excluded by %s\n", match.Path, formatIgnoreRule(match.Rule))
+	case match.Tracked:
+		_, _ = fmt.Fprintf(a.Stdout, "✅ %s: included (tracked files are always checkpointed)\n", match.Path)
+	case match.Rule == nil:
+		_, _ = fmt.Fprintf(a.Stdout, "✅ %s: included (no rule matches)\n", match.Path)
+	default:
+		_, _ = fmt.Fprintf(a.Stdout, "✅ %s: included, re-included by %s\n", match.Path, formatIgnoreRule(match.Rule))
+	}
+}
+
+// formatIgnoreRule renders a rule as source:line: pattern
+func formatIgnoreRule(rule *git.IgnoreRule) string {
+	if rule.Line == 0 {
+		return fmt.Sprintf("%s: %s", rule.Source, rule.Pattern)
+	}
+	return fmt.Sprintf("%s:%d: %s", rule.Source, rule.Line, rule.Pattern)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// joinSession shows the session of the gitbak process holding the lock, which Acquire
+// failed to take with lockErr, until that session ends or ctx is done. Nothing is
+// checkpointed, and interrupting the view leaves the session running.
+func (a *App) joinSession(ctx context.Context, lockErr error) error {
+	key := a.lockKey(a.Config.RepoPath)
+	pid, running := a.lockHolder(key)
+	if !running {
+		return lockErr
+	}
+	// The viewer makes no checkpoints of its own, so it has no session to summarize
+	a.Gitbak = nil
+	a.Logger.InfoToUser("Joined gitbak (PID %d) in %s as a viewer; Ctrl+C leaves its session running", pid, a.Config.RepoPath)
+
+	viewCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.viewSession(viewCtx, pid)
+	}()
+	stopView := func() {
+		cancel()
+		<-done
+	}
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			stopView()
+			return nil
+		case <-ticker.C:
+		}
+		holder, running := a.lockHolder(key)
+		if running && holder == pid {
+			continue
+		}
+
+		stopView()
+		end := "it exited without recording the end of the session"
+		if state, err := session.Load(a.Config.StateFile); err == nil && state.PID == pid {
+			end = describeEnd(state)
+		} else if running {
+			end = fmt.Sprintf("it was taken over by gitbak (PID %d)", holder)
+		}
+		_, _ = fmt.Fprintf(a.Stdout, "🏁 gitbak (PID %d) is no longer running: %s\n", pid, end)
+		return nil
+	}
+}
+
+// viewSession shows the session of the gitbak process pid until ctx is done: as the -tui
+// dashboard in a terminal, or otherwise as its status followed by a line per checkpoint
+func (a *App) viewSession(ctx context.Context, pid int) {
+	pauseFile, err := git.NewRepository(a.Config.RepoPath, a.gitExecutor()).PauseFilePath(ctx)
+	paused := func() bool {
+		if err != nil {
+			return false
+		}
+		_, statErr := os.Stat(pauseFile)
+		return statErr == nil
+	}
+
+	if a.Stdout == os.Stdout && logger.EnableVirtualTerminal(os.Stdout) {
+		repo := git.NewRepository(a.Config.RepoPath, a.backendExecutor())
+		view := newDashboard(os.Stdout, func(bool) {}, repo.LastCommit)
+		view.footer = "Press Ctrl+C to leave; the session keeps running"
+		view.run(ctx, a.Config.StateFile, pid, paused)
+		return
+	}
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	reported := -1
+	for {
+		if state, err := session.Load(a.Config.StateFile); err == nil && state.PID == pid {
+			switch {
+			case reported < 0:
+				a.printSessionStatus(state, true, time.Now())
+			case state.CommitsCount > reported:
+				_, _ = fmt.Fprintf(a.Stdout, "  📊 %d checkpoint(s), the latest at %s\n",
+					state.CommitsCount, state.LastCommitTime.Format(time.DateTime))
+			}
+			reported = state.CommitsCount
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
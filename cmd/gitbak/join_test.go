@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestJoinSession tests that -join follows the running session's checkpoints until it ends,
+// and leaves a session that is not running to be started as usual
+func TestJoinSession(t *testing.T) {
+	running := gitbakErrors.NewLockError("/tmp/gitbak.lock", 1234, gitbakErrors.ErrAlreadyRunning)
+
+	tests := map[string]struct {
+		running bool
+		expect  []string
+	}{
+		"Running": {
+			running: true,
+			expect:  []string{"1 checkpoint(s)", "2 checkpoint(s), the latest at", "is no longer running: Stopped at"},
+		},
+		"NotRunning": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.RepoPath = t.TempDir()
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+			var out bytes.Buffer
+			app := &App{Config: cfg, Logger: &MockLogger{}, Stdout: &out}
+
+			if !test.running {
+				app.lockHolder = func(string) (int, bool) { return 0, false }
+				if err := app.joinSession(context.Background(), running); !gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+					t.Fatalf("Expected the lock error, got %v", err)
+				}
+				return
+			}
+
+			pid, holder := startLockHolder(t, "echo ready; exec sleep 30")
+			app.lockHolder = holder
+			state := &session.State{RepoPath: cfg.RepoPath, Branch: "gitbak-join", PID: pid, StartTime: time.Now(), CommitsCount: 1}
+			if err := session.Save(cfg.StateFile, state); err != nil {
+				t.Fatalf("Failed to save state: %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- app.joinSession(context.Background(), running) }()
+
+			// The session makes a checkpoint, then stops
+			time.Sleep(dashboardRefresh / 2)
+			state.CommitsCount, state.LastCommitTime = 2, time.Now()
+			if err := session.Save(cfg.StateFile, state); err != nil {
+				t.Fatalf("Failed to save state: %v", err)
+			}
+			time.Sleep(3 * dashboardRefresh / 2)
+			state.EndTime = time.Now()
+			if err := session.Save(cfg.StateFile, state); err != nil {
+				t.Fatalf("Failed to save state: %v", err)
+			}
+			if process, err := os.FindProcess(pid); err == nil {
+				_ = process.Kill()
+			}
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("joinSession failed: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the view to end with the session")
+			}
+			for _, expected := range test.expect {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("Expected the view to show %q, got:\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+)
+
+// lockPollInterval is how often a lock held by another gitbak process is retried during -lock-wait
+const lockPollInterval = 250 * time.Millisecond
+
+// killTimeout is how long -takeover waits for a killed gitbak process to release the lock
+const killTimeout = 5 * time.Second
+
+// acquireLock takes the repository lock. If another gitbak process holds it, that process
+// is stopped with -takeover, or the lock is retried for up to -lock-wait before giving up
+// with the ErrAlreadyRunning error.
+func (a *App) acquireLock(ctx context.Context) error {
+	err := a.Locker.Acquire()
+	if err == nil || !gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+		return err
+	}
+	if a.Config.Takeover {
+		return a.takeOver(ctx, err)
+	}
+	if a.Config.LockWait <= 0 {
+		return err
+	}
+
+	a.Logger.InfoToUser("Another gitbak instance holds the lock, waiting up to %s for it to exit", a.Config.LockWait)
+
+	deadline := time.After(a.Config.LockWait)
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return gitbakErrors.Wrapf(err, "still locked after waiting %s", a.Config.LockWait)
+		case <-ticker.C:
+			err = a.Locker.Acquire()
+			if err == nil || !gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+				return err
+			}
+		}
+	}
+}
+
+// takeOver stops the gitbak process holding the lock, which Acquire failed to take with
+// lockErr, and takes the lock in its place. The process is asked to stop gracefully first,
+// and killed if it does not within twice -shutdown-timeout, as a hung one would not.
+func (a *App) takeOver(ctx context.Context, lockErr error) error {
+	pid, running := a.lockHolder(a.lockKey(a.Config.RepoPath))
+	if running {
+		a.Logger.InfoToUser("Asking gitbak (PID %d) to stop, to take over %s", pid, a.Config.RepoPath)
+
+		stopTimeout := a.takeoverTimeout()
+		stopped, err := a.stopProcess(ctx, pid, stopTimeout)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !stopped {
+			if err != nil {
+				a.Logger.WarningToUser("%v; killing gitbak (PID %d)", err, pid)
+			} else {
+				a.Logger.WarningToUser("gitbak (PID %d) did not stop within %s; killing it", pid, stopTimeout)
+			}
+			if err := a.killProcess(ctx, pid); err != nil {
+				return gitbakErrors.Wrapf(lockErr, "failed to take over from PID %d: %v", pid, err)
+			}
+		}
+		a.Logger.Info("Took over %s from gitbak (PID %d)", a.Config.RepoPath, pid)
+	}
+	return a.Locker.Acquire()
+}
+
+// takeoverTimeout is how long -takeover gives the running process to stop before killing it:
+// as long as gitbak stop waits, but never unlimited, so that a hung process is killed
+func (a *App) takeoverTimeout() time.Duration {
+	if a.Config.ShutdownTimeout == 0 {
+		return 2 * config.DefaultShutdownTimeout
+	}
+	return 2 * a.Config.ShutdownTimeout
+}
+
+// killProcess kills the gitbak process pid at once and waits for its lock to be released
+func (a *App) killProcess(ctx context.Context, pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return gitbakErrors.Wrapf(err, "failed to find gitbak process %d", pid)
+	}
+	if err := process.Kill(); err != nil {
+		return gitbakErrors.Wrapf(err, "failed to kill gitbak process %d", pid)
+	}
+	if released, err := a.waitForRelease(ctx, pid, killTimeout); err != nil || !released {
+		return fmt.Errorf("gitbak (PID %d) still holds the lock after being killed", pid)
+	}
+	return nil
+}
+
+// lockKey returns the path that the lock for the repository at repoPath is named after:
+// the worktree itself, or with -lock-scope repository the git directory its worktrees share.
+// If that cannot be found, e.g. outside a repository, the worktree is used.
+func (a *App) lockKey(repoPath string) string {
+	if a.Config.LockScope != "repository" {
+		return repoPath
+	}
+	if a.Config.GitDir != "" {
+		return a.Config.GitDir
+	}
+	commonDir, err := git.CommonDir(repoPath)
+	if err != nil {
+		return repoPath
+	}
+	return commonDir
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// sequenceLocker fails to acquire the lock with the errors in order, then succeeds
+type sequenceLocker struct {
+	errs     []error
+	attempts int
+}
+
+func (l *sequenceLocker) Acquire() error {
+	l.attempts++
+	if len(l.errs) == 0 {
+		return nil
+	}
+	err := l.errs[0]
+	l.errs = l.errs[1:]
+	return err
+}
+
+func (l *sequenceLocker) Release() error {
+	return nil
+}
+
+func TestAcquireLock(t *testing.T) {
+	running := gitbakErrors.NewLockError("/tmp/gitbak.lock", 1234, gitbakErrors.ErrAlreadyRunning)
+	broken := errors.New("permission denied")
+
+	tests := map[string]struct {
+		lockWait       time.Duration
+		errs           []error
+		expectErr      error
+		expectAttempts int
+	}{
+		"Free": {
+			lockWait:       time.Second,
+			expectAttempts: 1,
+		},
+		"NoWait": {
+			errs:           []error{running},
+			expectErr:      gitbakErrors.ErrAlreadyRunning,
+			expectAttempts: 1,
+		},
+		"Released": {
+			lockWait:       5 * time.Second,
+			errs:           []error{running, running},
+			expectAttempts: 3,
+		},
+		"TimedOut": {
+			lockWait:  3 * lockPollInterval / 2,
+			errs:      []error{running, running, running, running, running, running},
+			expectErr: gitbakErrors.ErrAlreadyRunning,
+		},
+		"OtherError": {
+			lockWait:       5 * time.Second,
+			errs:           []error{running, broken},
+			expectErr:      broken,
+			expectAttempts: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.LockWait = test.lockWait
+
+			locker := &sequenceLocker{errs: test.errs}
+			app := &App{Config: cfg, Logger: &MockLogger{}, Locker: locker}
+
+			err := app.acquireLock(context.Background())
+			if test.expectErr == nil && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if test.expectErr != nil && !gitbakErrors.Is(err, test.expectErr) {
+				t.Fatalf("Expected %v, got %v", test.expectErr, err)
+			}
+			if test.expectAttempts > 0 && locker.attempts != test.expectAttempts {
+				t.Errorf("Expected %d attempts, got %d", test.expectAttempts, locker.attempts)
+			}
+		})
+	}
+}
+
+func TestAcquireLockCanceled(t *testing.T) {
+	cfg := config.New()
+	cfg.LockWait = time.Minute
+
+	running := gitbakErrors.NewLockError("/tmp/gitbak.lock", 1234, gitbakErrors.ErrAlreadyRunning)
+	app := &App{Config: cfg, Logger: &MockLogger{}, Locker: &MockLocker{AcquireErr: running}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := app.acquireLock(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the wait to end when canceled, got %v", err)
+	}
+}
+
+// TestLockKey tests that -lock-scope repository names the lock after the git directory
+// shared by a repository's worktrees
+func TestLockKey(t *testing.T) {
+	repoPath := t.TempDir()
+	commonDir := filepath.Join(repoPath, ".git")
+	linkedDir := filepath.Join(commonDir, "worktrees", "feature")
+	if err := os.MkdirAll(linkedDir, 0755); err != nil {
+		t.Fatalf("Failed to create git directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(linkedDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatalf("Failed to write commondir: %v", err)
+	}
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+linkedDir+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .git file: %v", err)
+	}
+	outside := t.TempDir()
+
+	tests := map[string]struct {
+		scope    string
+		path     string
+		expected string
+	}{
+		"Worktree":           {scope: "worktree", path: worktree, expected: worktree},
+		"RepositoryMain":     {scope: "repository", path: repoPath, expected: commonDir},
+		"RepositoryLinked":   {scope: "repository", path: worktree, expected: commonDir},
+		"RepositoryFallback": {scope: "repository", path: outside, expected: outside},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := NewTestApp()
+			app.Config.LockScope = test.scope
+
+			if got := app.lockKey(test.path); got != test.expected {
+				t.Errorf("Expected lock key %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestTakeOver tests that -takeover stops the gitbak process holding the lock, killing
+// it if it does not stop, and takes the lock in its place
+func TestTakeOver(t *testing.T) {
+	running := gitbakErrors.NewLockError("/tmp/gitbak.lock", 1234, gitbakErrors.ErrAlreadyRunning)
+
+	tests := map[string]struct {
+		// script stands in for the running gitbak process
+		script       string
+		expectKilled bool
+	}{
+		"Stops": {
+			script: "echo ready; exec sleep 30",
+		},
+		"Hung": {
+			// Ignoring SIGTERM, as a hung process would
+			script:       "trap '' TERM; echo ready; exec sleep 30",
+			expectKilled: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pid, holder := startLockHolder(t, test.script)
+
+			cfg := config.New()
+			cfg.Takeover = true
+			cfg.ShutdownTimeout = 100 * time.Millisecond
+			mockLogger := &MockLogger{}
+			locker := &sequenceLocker{errs: []error{running}}
+			app := &App{Config: cfg, Logger: mockLogger, Locker: locker, lockHolder: holder}
+
+			if err := app.acquireLock(context.Background()); err != nil {
+				t.Fatalf("acquireLock failed: %v", err)
+			}
+			if locker.attempts != 2 {
+				t.Errorf("Expected the lock to be taken after the process stopped, got %d attempts", locker.attempts)
+			}
+			if _, stillRunning := holder(""); stillRunning {
+				t.Errorf("Expected PID %d to have exited", pid)
+			}
+			if killed := mockLogger.WarningToUserCalled; killed != test.expectKilled {
+				t.Errorf("Expected the process to be killed: %v, got %v (%q)", test.expectKilled, killed, mockLogger.LastMessage)
+			}
+		})
+	}
+}
+
+// startLockHolder runs script in a child process standing in for a gitbak process holding
+// the lock, and returns its PID and a lockHolder reporting it until it exits. It returns
+// once the script prints a line, to signal that any signal handling it sets up is in place.
+func startLockHolder(t *testing.T, script string) (int, func(string) (int, bool)) {
+	t.Helper()
+
+	child := exec.Command("sh", "-c", script)
+	stdout, err := child.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to connect to child process: %v", err)
+	}
+	if err := child.Start(); err != nil {
+		t.Fatalf("Failed to start child process: %v", err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("Child process did not start: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = child.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = child.Process.Kill()
+		<-exited
+	})
+
+	pid := child.Process.Pid
+	return pid, func(string) (int, bool) {
+		select {
+		case <-exited:
+			return 0, false
+		default:
+			return pid, true
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// logPollInterval is how often logs -follow looks for new records
+const logPollInterval = 250 * time.Millisecond
+
+// logsActions lists the actions of the logs command
+var logsActions = []string{"path"}
+
+// logRecord is a line of the log file, parsed from the text records the logger writes.
+// Lines that are not records, such as the output of a detached session, are kept whole
+// in Message at info level.
+type logRecord struct {
+	Time    string `json:"time,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"msg"`
+}
+
+// RunLogs prints the last -lines records of the repository's log file (see -debug and
+// -log-file) at -log-level and above, and with -follow keeps printing records as they
+// are written, across rotations, until interrupted. With -output json each record is
+// printed as a line of JSON. 'gitbak logs path' prints where the log file is instead.
+// Logs sent to syslog or the journal (-log-backend) are left to the system's tools.
+func (a *App) RunLogs(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	switch {
+	case len(a.Config.Args) == 0:
+	case len(a.Config.Args) == 1 && a.Config.Args[0] == "path":
+		_, _ = fmt.Fprintln(a.Stdout, a.Config.LogFile)
+		return nil
+	default:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "logs takes no arguments, or the action path")
+	}
+
+	switch logger.Backend(a.Config.LogBackend) {
+	case logger.BackendJournald:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"debug logs are sent to the systemd journal (-log-backend journald); read them with journalctl -t %s", logger.SyslogTag)
+	case logger.BackendSyslog:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"debug logs are sent to syslog (-log-backend syslog), tagged %s", logger.SyslogTag)
+	}
+
+	path := a.Config.LogFile
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && !a.Config.FollowLogs {
+			return gitbakErrors.Wrapf(err, "no log file at %s (gitbak writes one with -debug)", path)
+		}
+		if !os.IsNotExist(err) {
+			return gitbakErrors.Wrapf(err, "failed to open log file %s", path)
+		}
+		// Following a log file that is yet to be written, as a session started with -debug will
+		f = nil
+	}
+
+	verbosity := a.Config.Verbosity()
+	var offset int64
+	if f != nil {
+		offset, err = a.printLastRecords(f, verbosity)
+		if err != nil {
+			_ = f.Close()
+			return gitbakErrors.Wrapf(err, "failed to read log file %s", path)
+		}
+	}
+	if !a.Config.FollowLogs {
+		_ = f.Close()
+		return nil
+	}
+	return a.followLog(ctx, path, f, offset, verbosity)
+}
+
+// printLastRecords prints the last -lines records of f shown at verbosity, returning
+// the offset of the first line it has not read in full
+func (a *App) printLastRecords(f *os.File, verbosity logger.Verbosity) (int64, error) {
+	var records []logRecord
+	var offset int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// A partial line is still being written; following picks it up once it is done
+			return offset, a.printRecords(records)
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+
+		if record, ok := parseLogRecord(line); ok && shownAt(record, verbosity) {
+			records = append(records, record)
+			if lines := a.Config.LogLines; lines > 0 && len(records) > lines {
+				records = records[1:]
+			}
+		}
+	}
+}
+
+// followLog prints the records written to the log file at path from offset in f on, until
+// ctx is done. f may be nil until the file is created. When the logger rotates the file,
+// or it is truncated, reading continues from the start of the new file.
+func (a *App) followLog(ctx context.Context, path string, f *os.File, offset int64, verbosity logger.Verbosity) error {
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+
+	if f != nil {
+		// Reading the last records buffered data past the last complete line
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return gitbakErrors.Wrapf(err, "failed to read log file %s", path)
+		}
+	}
+
+	// drain prints the complete lines written to f since it was last read
+	var partial string
+	drain := func() error {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return gitbakErrors.Wrapf(err, "failed to read log file %s", path)
+		}
+		offset += int64(len(data))
+
+		lines := strings.SplitAfter(partial+string(data), "\n")
+		// The last element is what follows the last newline, a line yet to be finished
+		partial = lines[len(lines)-1]
+		var records []logRecord
+		for _, line := range lines[:len(lines)-1] {
+			if record, ok := parseLogRecord(line); ok && shownAt(record, verbosity) {
+				records = append(records, record)
+			}
+		}
+		return a.printRecords(records)
+	}
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		if f != nil {
+			if err := drain(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// Between a rotation's rename and the creation of the new file
+			continue
+		}
+		if f != nil {
+			current, err := f.Stat()
+			if err != nil {
+				return gitbakErrors.Wrapf(err, "failed to read log file %s", path)
+			}
+			if os.SameFile(info, current) {
+				if info.Size() < offset {
+					// Truncated
+					if _, err := f.Seek(0, io.SeekStart); err != nil {
+						return gitbakErrors.Wrapf(err, "failed to read log file %s", path)
+					}
+					offset, partial = 0, ""
+				}
+				continue
+			}
+
+			// Rotated: print what was written to the old file before it was renamed
+			if err := drain(); err != nil {
+				return err
+			}
+			_ = f.Close()
+			f = nil
+		}
+
+		if f, err = os.Open(path); err != nil {
+			// Removed again before it could be opened
+			continue
+		}
+		offset, partial = 0, ""
+	}
+}
+
+// printRecords prints records as they were written or, with -output json, as lines of JSON
+func (a *App) printRecords(records []logRecord) error {
+	encoder := json.NewEncoder(a.Stdout)
+	for _, record := range records {
+		if a.Config.Output == "json" {
+			if err := encoder.Encode(record); err != nil {
+				return gitbakErrors.Wrap(err, "failed to print log record")
+			}
+			continue
+		}
+		if record.Time == "" {
+			_, _ = fmt.Fprintln(a.Stdout, record.Message)
+			continue
+		}
+		_, _ = fmt.Fprintf(a.Stdout, "%s %-5s %s\n", record.Time, record.Level, record.Message)
+	}
+	return nil
+}
+
+// parseLogRecord parses a line of the log file, as written by slog's text handler or, with
+// -log-backend json, its JSON handler, reporting false for a blank line:
+//
+//	time=2025-03-04T05:06:07.890+01:00 level=WARN msg="Failed to push: exit status 128"
+//	{"time":"2025-03-04T05:06:07.890+01:00","level":"WARN","msg":"Failed to push: exit status 128"}
+func parseLogRecord(line string) (logRecord, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" {
+		return logRecord{}, false
+	}
+	record := logRecord{Level: "INFO", Message: line}
+
+	if strings.HasPrefix(line, "{") {
+		var parsed logRecord
+		if err := json.Unmarshal([]byte(line), &parsed); err != nil || parsed.Time == "" || parsed.Level == "" {
+			return record, true
+		}
+		return parsed, true
+	}
+
+	var parsed logRecord
+	rest := line
+	for rest != "" {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \"") {
+			return record, true
+		}
+		rest = value
+		if strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return record, true
+			}
+			rest = value[len(quoted):]
+			value, _ = strconv.Unquote(quoted)
+		} else {
+			value, rest, _ = strings.Cut(value, " ")
+		}
+		rest = strings.TrimPrefix(rest, " ")
+
+		switch key {
+		case "time":
+			parsed.Time = value
+		case "level":
+			parsed.Level = value
+		case "msg":
+			parsed.Message = value
+		}
+	}
+	if parsed.Time == "" || parsed.Level == "" {
+		return record, true
+	}
+	return parsed, true
+}
+
+// shownAt reports whether record is shown at verbosity: errors at every verbosity,
+// warnings from warn, informational records from info and trace records, which the
+// logger writes at debug level, only at trace
+func shownAt(record logRecord, verbosity logger.Verbosity) bool {
+	switch record.Level {
+	case "ERROR":
+		return true
+	case "WARN":
+		return verbosity >= logger.VerbosityWarn
+	case "DEBUG":
+		return verbosity >= logger.VerbosityTrace
+	default:
+		return verbosity >= logger.VerbosityInfo
+	}
+}
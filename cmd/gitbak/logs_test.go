@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// testLogFile is a log file as the logger writes it, with a line of a detached session's output
+const testLogFile = `time=2026-03-02T10:00:00.000+00:00 level=INFO msg="gitbak debug logging started"
+time=2026-03-02T10:00:01.000+00:00 level=DEBUG msg="git status --porcelain (12ms)"
+✅ Commit #1 created
+time=2026-03-02T10:05:00.000+00:00 level=WARN msg="Failed to push: exit status 128"
+time=2026-03-02T10:10:00.000+00:00 level=ERROR msg="Check failed: \"index.lock\" exists"
+`
+
+// lockedBuffer is a bytes.Buffer that may be written while it is being read
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestParseLogRecord tests parsing the records of the log file
+func TestParseLogRecord(t *testing.T) {
+	tests := map[string]struct {
+		line     string
+		expected logRecord
+		ok       bool
+	}{
+		"Record": {
+			line:     `time=2026-03-02T10:05:00.000+00:00 level=WARN msg="Failed to push: exit status 128"` + "\n",
+			expected: logRecord{Time: "2026-03-02T10:05:00.000+00:00", Level: "WARN", Message: "Failed to push: exit status 128"},
+			ok:       true,
+		},
+		"UnquotedMessage": {
+			line:     "time=2026-03-02T10:00:00.000+00:00 level=INFO msg=started",
+			expected: logRecord{Time: "2026-03-02T10:00:00.000+00:00", Level: "INFO", Message: "started"},
+			ok:       true,
+		},
+		"EscapedQuotes": {
+			line:     `time=2026-03-02T10:10:00.000+00:00 level=ERROR msg="\"index.lock\" exists"`,
+			expected: logRecord{Time: "2026-03-02T10:10:00.000+00:00", Level: "ERROR", Message: `"index.lock" exists`},
+			ok:       true,
+		},
+		"JSONRecord": {
+			line:     `{"time":"2026-03-02T10:05:00.000+00:00","level":"WARN","msg":"Failed to push: exit status 128"}` + "\n",
+			expected: logRecord{Time: "2026-03-02T10:05:00.000+00:00", Level: "WARN", Message: "Failed to push: exit status 128"},
+			ok:       true,
+		},
+		"NotAJSONRecord": {
+			line:     `{"status": "ok"}`,
+			expected: logRecord{Level: "INFO", Message: `{"status": "ok"}`},
+			ok:       true,
+		},
+		"NotARecord": {
+			line:     "✅ Commit #1 created: a=b\n",
+			expected: logRecord{Level: "INFO", Message: "✅ Commit #1 created: a=b"},
+			ok:       true,
+		},
+		"UnterminatedQuote": {
+			line:     `time=2026-03-02T10:00:00.000+00:00 level=INFO msg="cut off`,
+			expected: logRecord{Level: "INFO", Message: `time=2026-03-02T10:00:00.000+00:00 level=INFO msg="cut off`},
+			ok:       true,
+		},
+		"Blank": {
+			line: "\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			record, ok := parseLogRecord(test.line)
+			if ok != test.ok {
+				t.Fatalf("Expected ok to be %v, got %v", test.ok, ok)
+			}
+			if record != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, record)
+			}
+		})
+	}
+}
+
+// TestRunLogs tests printing the log file, its path, and its records by level
+func TestRunLogs(t *testing.T) {
+	tests := map[string]struct {
+		args              []string
+		noLogFile         bool
+		logLevel          string
+		lines             int
+		output            string
+		expectedOutput    string
+		expectErrContains string
+	}{
+		"Path": {
+			args: []string{"path"},
+		},
+		"LastRecords": {
+			lines: 2,
+			expectedOutput: "2026-03-02T10:05:00.000+00:00 WARN  Failed to push: exit status 128\n" +
+				"2026-03-02T10:10:00.000+00:00 ERROR Check failed: \"index.lock\" exists\n",
+		},
+		"AllRecordsAtInfo": {
+			lines: 0,
+			expectedOutput: "2026-03-02T10:00:00.000+00:00 INFO  gitbak debug logging started\n" +
+				"✅ Commit #1 created\n" +
+				"2026-03-02T10:05:00.000+00:00 WARN  Failed to push: exit status 128\n" +
+				"2026-03-02T10:10:00.000+00:00 ERROR Check failed: \"index.lock\" exists\n",
+		},
+		"TraceShowsGitCommands": {
+			logLevel: "trace",
+			lines:    4,
+			expectedOutput: "2026-03-02T10:00:01.000+00:00 DEBUG git status --porcelain (12ms)\n" +
+				"✅ Commit #1 created\n" +
+				"2026-03-02T10:05:00.000+00:00 WARN  Failed to push: exit status 128\n" +
+				"2026-03-02T10:10:00.000+00:00 ERROR Check failed: \"index.lock\" exists\n",
+		},
+		"ErrorsOnly": {
+			logLevel:       "error",
+			lines:          10,
+			expectedOutput: "2026-03-02T10:10:00.000+00:00 ERROR Check failed: \"index.lock\" exists\n",
+		},
+		"JSON": {
+			logLevel:       "warn",
+			lines:          1,
+			output:         "json",
+			expectedOutput: `{"time":"2026-03-02T10:10:00.000+00:00","level":"ERROR","msg":"Check failed: \"index.lock\" exists"}` + "\n",
+		},
+		"NoLogFile": {
+			noLogFile:         true,
+			expectErrContains: "gitbak writes one with -debug",
+		},
+		"UnknownAction": {
+			args:              []string{"clear"},
+			expectErrContains: "logs takes no arguments",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "gitbak-test.log")
+			if !test.noLogFile {
+				if err := os.WriteFile(logFile, []byte(testLogFile), 0o600); err != nil {
+					t.Fatalf("Failed to write log file: %v", err)
+				}
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.LogFile = logFile
+			app.Config.StateFile = filepath.Join(t.TempDir(), "gitbak-current.json")
+			app.Config.Args = test.args
+			app.Config.LogLevel = test.logLevel
+			app.Config.LogLines = test.lines
+			app.Config.Output = test.output
+
+			err := app.RunLogs(context.Background())
+			if test.expectErrContains != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectErrContains) {
+					t.Fatalf("Expected error containing %q, got %v", test.expectErrContains, err)
+				}
+				if test.noLogFile && !gitbakErrors.Is(err, os.ErrNotExist) {
+					t.Errorf("Expected the error to wrap os.ErrNotExist, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunLogs failed: %v", err)
+			}
+
+			expected := test.expectedOutput
+			if len(test.args) > 0 {
+				expected = logFile + "\n"
+			}
+			if stdout.String() != expected {
+				t.Errorf("Expected output %q, got %q", expected, stdout.String())
+			}
+		})
+	}
+}
+
+// TestRunLogsFollow tests following the log file as it is written and rotated
+func TestRunLogsFollow(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "gitbak-test.log")
+	if err := os.WriteFile(logFile, []byte("time=2026-03-02T10:00:00.000+00:00 level=INFO msg=before\ntime=2026-03-02T10:00:01.000+00:00 level=INFO msg=par"), 0o600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	var stdout lockedBuffer
+	app := NewTestApp()
+	app = WithMockLocker(app, &MockLocker{})
+	app = WithMockLogger(app, &MockLogger{})
+	app.Gitbak = &MockGitbaker{}
+	app.Stdout = &stdout
+	app.Config.LogFile = logFile
+	app.Config.StateFile = filepath.Join(t.TempDir(), "gitbak-current.json")
+	app.Config.FollowLogs = true
+	app.Config.Output = "json"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.RunLogs(ctx) }()
+
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(stdout.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected output to contain %q, got %q", want, stdout.String())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	appendLog := func(text string) {
+		t.Helper()
+		f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatalf("Failed to write log file: %v", err)
+		}
+	}
+
+	waitFor(`"msg":"before"`)
+	appendLog("tial\n")
+	waitFor(`"msg":"partial"`)
+
+	// Rotate as the logger does: rename the file away and start a new one
+	appendLog("time=2026-03-02T10:00:02.000+00:00 level=INFO msg=last-before-rotation\n")
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatalf("Failed to rotate log file: %v", err)
+	}
+	appendLog("time=2026-03-02T10:00:03.000+00:00 level=INFO msg=after-rotation\n")
+	waitFor(`"msg":"after-rotation"`)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("RunLogs failed: %v", err)
+	}
+
+	output := stdout.String()
+	for _, want := range []string{"before", "partial", "last-before-rotation", "after-rotation"} {
+		if count := strings.Count(output, `"msg":"`+want+`"`); count != 1 {
+			t.Errorf("Expected %q to be printed once, got %d times in %q", want, count, output)
+		}
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/mirror"
 )
 
 // Version information - injected at build time
@@ -19,6 +20,11 @@ var (
 )
 
 func main() {
+	// git runs gitbak as its ssh transport for bandwidth-limited mirror pushes
+	if mirror.IsSSHWrapper() {
+		os.Exit(mirror.RunSSHWrapper(os.Args[1:]))
+	}
+
 	versionInfo := config.VersionInfo{
 		Version: version,
 		Commit:  commit,
@@ -26,59 +32,94 @@ func main() {
 	}
 
 	app := NewDefaultApp(versionInfo)
+	defer app.recoverPanic()
 
-	if err := app.Config.ParseFlags(); err != nil {
-		// Error and help messages are already displayed in ParseFlags
-		app.exit(1)
+	var args []string
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+	cmd, args := splitCommand(args)
+
+	if err := app.Config.ParseArgs(args); err != nil {
+		// Error and help messages are already displayed in ParseArgs
+		if app.Config.ErrorsJSON {
+			_, _ = fmt.Fprintf(app.Stderr, "%s\n", gitbakErrors.JSON(err))
+		}
+		app.exit(gitbakErrors.ExitCode(err))
+	}
+
+	if (cmd == nil || cmd.run == nil) && app.shouldDetach() {
+		if err := app.RunDetached(os.Args[1:]); err != nil {
+			app.fail(err)
+		}
+		return
 	}
 
 	// Initialize the app (logger, lock, etc.)
 	if err := app.Initialize(); err != nil {
-		_, _ = fmt.Fprintf(app.Stderr, "❌ Error: %v\n", err)
-		app.exit(1)
+		app.fail(err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
-	go func() {
-		sig := <-c
-		fmt.Printf("\nReceived signal %v, stopping gitbak...\n", sig)
+	if cmd != nil && cmd.run != nil {
+		// Subcommands stop their git operations on the first interrupt
+		ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals...)
+		err := cmd.run(app, ctx)
+		stop()
+		if err != nil {
+			app.fail(err)
+		}
+		return
+	}
 
-		// Cancel the context to signal graceful shutdown
-		cancel()
+	ctx, cancel := context.WithCancel(context.Background())
 
-		// Wait a short time for the application to respond to context cancellation
-		// If it doesn't stop gracefully within a reasonable time, force termination
-		time.Sleep(5 * time.Second)
+	// shutdownCtx bounds the work done after monitoring stops: the final checkpoint and
+	// the session summary. It is canceled by a second signal or once -shutdown-timeout expires.
+	shutdownCtx, cancelShutdown := context.WithCancel(context.Background())
+	defer cancelShutdown()
+	app.shutdownCtx = shutdownCtx
 
-		// If we're still running after the timeout, force cleanup and exit
+	signals := make(chan os.Signal, 3)
+	signal.Notify(signals, shutdownSignals...)
+	listenForStop(signals)
+	app.requestStop = func() {
 		select {
-		case <-ctx.Done():
-			// Context was properly canceled, main function will handle cleanup
-			return
+		case signals <- syscall.SIGTERM:
 		default:
-			// Force cleanup and exit if context cancellation didn't work
-			app.CleanupOnSignal()
-			app.exit(0)
+			// Enough signals are queued to stop gitbak already
 		}
-	}()
+	}
+	go app.coordinateShutdown(signals, cancel, cancelShutdown)
 
 	// Run the application with the cancellable context
-	if err := app.Run(ctx); err != nil {
-		// Don't treat context cancellation as an error since that's our normal signal shutdown path
-		if err.Error() != "context canceled" {
-			_, _ = fmt.Fprintf(app.Stderr, "❌ Error: %v\n", err)
-			_ = app.Close()
-			app.exit(1)
+	// Don't treat context cancellation as an error since that's our normal signal shutdown path
+	if err := app.Run(ctx); err != nil && !gitbakErrors.Is(err, context.Canceled) {
+		if gitbakErrors.Is(err, gitbakErrors.ErrRepositoryMoved) && app.Gitbak != nil {
+			// The session ended for want of a repository, so account for what it did
+			app.Gitbak.PrintSummary(shutdownCtx)
 		}
+		_ = app.Close()
+		app.fail(err)
 	}
 
 	// Print summary only if we ran the main gitbak process (not for --logo or --version)
 	// and if the gitbak instance was initialized
 	if !app.Config.ShowLogo && !app.Config.Version && app.Gitbak != nil {
-		app.Gitbak.PrintSummary()
+		app.Gitbak.PrintSummary(shutdownCtx)
 	}
 	_ = app.Close()
 }
+
+// fail reports the error that ends gitbak and exits with the code for its failure type.
+// With -errors-json the error is printed as a single line of JSON instead.
+func (a *App) fail(err error) {
+	switch {
+	case a.Config.ErrorsJSON:
+		_, _ = fmt.Fprintf(a.Stderr, "%s\n", gitbakErrors.JSON(err))
+	case gitbakErrors.Is(err, gitbakErrors.ErrDisabled):
+		_, _ = fmt.Fprintf(a.Stderr, "⏸️  %v\n", err)
+	default:
+		_, _ = fmt.Fprintf(a.Stderr, "❌ Error: %v\n", err)
+	}
+	a.exit(gitbakErrors.ExitCode(err))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestFail tests that the final error is reported as text or JSON and sets the exit code
+func TestFail(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		errorsJSON   bool
+		expectedCode int
+		expectedText string
+	}{
+		"Text": {
+			err:          gitbakErrors.ErrNotGitRepository,
+			expectedCode: gitbakErrors.ExitCodeNotGitRepository,
+			expectedText: "❌ Error: not a git repository",
+		},
+		"Disabled": {
+			err:          gitbakErrors.ErrDisabled,
+			expectedCode: gitbakErrors.ExitCodeDisabled,
+			expectedText: "⏸️  gitbak is disabled",
+		},
+		"JSON": {
+			err:          gitbakErrors.NewLockError("/tmp/gitbak.lock", 4242, gitbakErrors.ErrAlreadyRunning),
+			errorsJSON:   true,
+			expectedCode: gitbakErrors.ExitCodeLockHeld,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			app := NewTestApp()
+			app.Stderr = &stderr
+			app.Config.ErrorsJSON = test.errorsJSON
+
+			exitCode := -1
+			app.exit = func(code int) { exitCode = code }
+
+			app.fail(test.err)
+
+			if exitCode != test.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", test.expectedCode, exitCode)
+			}
+			if !test.errorsJSON {
+				if !strings.HasPrefix(stderr.String(), test.expectedText) {
+					t.Errorf("Expected output to start with %q, got %q", test.expectedText, stderr.String())
+				}
+				return
+			}
+
+			var report gitbakErrors.Report
+			if err := json.Unmarshal(stderr.Bytes(), &report); err != nil {
+				t.Fatalf("Expected a JSON report, got %q: %v", stderr.String(), err)
+			}
+			if report.Code != "lock_held" || report.PID != 4242 {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunMaterialize turns the checkpoints the most recent session wrote with -refs-only into
+// a branch, created at the latest checkpoint ref so that its history holds them all. The
+// argument names the branch, which defaults to the session's name. The current branch
+// is not changed and the refs are kept, so materializing again under another name works.
+func (a *App) RunMaterialize(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to materialize in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	branch := strings.TrimPrefix(state.Refs, git.RefsPrefix)
+	switch len(a.Config.Args) {
+	case 0:
+	case 1:
+		branch = a.Config.Args[0]
+	default:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "materialize takes at most one argument, the branch to create")
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	ref, checkpoints, err := repo.MaterializeRefs(ctx, state, branch)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Success("Created branch '%s' at %s, with %d checkpoint(s)", branch, ref, checkpoints)
+	a.Logger.StatusMessage("Check it out with: git checkout %s", branch)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunMaterialize tests the materialize command against a real repository
+func TestRunMaterialize(t *testing.T) {
+	tests := map[string]struct {
+		args           []string
+		expectedBranch string
+		errorContains  string
+	}{
+		"SessionName": {
+			expectedBranch: "gitbak-20260101-100000",
+		},
+		"NamedBranch": {
+			args:           []string{"feature/recovered"},
+			expectedBranch: "feature/recovered",
+		},
+		"TooManyArguments": {
+			args:          []string{"one", "two"},
+			errorContains: "at most one argument",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				run := func(args ...string) string {
+					out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+					if err != nil {
+						t.Fatalf("git %v failed: %v", args, err)
+					}
+					return strings.TrimSpace(string(out))
+				}
+
+				head := run("rev-parse", "HEAD")
+				run("update-ref", "refs/gitbak/gitbak-20260101-100000/1", head)
+
+				stateFile := filepath.Join(t.TempDir(), "state.json")
+				state := &session.State{RepoPath: repoPath, Branch: "main", Refs: "refs/gitbak/gitbak-20260101-100000", StartCommit: head}
+				if err := session.Save(stateFile, state); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+
+				app := NewTestApp()
+				app = WithMockLocker(app, &MockLocker{})
+				app = WithMockLogger(app, &MockLogger{})
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.Args = test.args
+
+				err := app.RunMaterialize(context.Background())
+				if test.errorContains != "" {
+					if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+						t.Fatalf("Expected an error containing %q, got %v", test.errorContains, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("RunMaterialize failed: %v", err)
+				}
+				if tip := run("rev-parse", "refs/heads/"+test.expectedBranch); tip != head {
+					t.Errorf("Expected branch %s at %s, got %s", test.expectedBranch, head, tip)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// startMetricsServer serves the session's metrics on addr at /metrics, in the
+// Prometheus text format. The endpoint is read-only, so unlike the control
+// endpoint it may be bound to an address a central Prometheus server can reach.
+func (a *App) startMetricsServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", a.metrics.Handler())
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.metricsServer = server
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.Logger.Warning("Metrics server stopped: %v", err)
+		}
+	}()
+
+	a.Logger.InfoToUser("Metrics available at http://%s/metrics", listener.Addr())
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/control"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// nudgeRequestTimeout bounds how long the nudge command waits for the session to answer
+const nudgeRequestTimeout = control.RequestTimeout
+
+// startNudgeServer serves POST /nudge on addr, letting editor save hooks request
+// an early change check without running a shell command. Nudges are queued
+// without blocking; one already waiting absorbs the rest, and gitbak debounces
+// the check itself.
+func (a *App) startNudgeServer(addr string) error {
+	listener, err := localListener(addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nudge", a.handleNudge)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.nudgeServer = server
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.Logger.Warning("Nudge server stopped: %v", err)
+		}
+	}()
+
+	if strings.HasPrefix(addr, control.UnixAddrPrefix) {
+		a.Logger.InfoToUser("Nudge endpoint available on %s (POST /nudge, or run 'gitbak nudge')", addr)
+	} else {
+		a.Logger.InfoToUser("Nudge endpoint available at http://%s/nudge", listener.Addr())
+	}
+	return nil
+}
+
+// localListener listens on addr, either host:port or unix:<path>, for the nudge and
+// control endpoints. A socket file left behind by a session that did not shut down
+// cleanly is replaced; closing the listener removes the socket file again.
+func localListener(addr string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(addr, control.UnixAddrPrefix)
+	if !isUnix {
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		_ = os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Only the user running gitbak may nudge it
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// handleNudge queues a change check for POST requests
+func (a *App) handleNudge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	select {
+	case a.nudges <- struct{}{}:
+	default:
+		// A check is already pending
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RunNudge asks the session serving the configured nudge endpoint to check for
+// changes now. It is meant to be run from an editor's save hook, so that the
+// hook does not need curl or knowledge of the endpoint's URL.
+func (a *App) RunNudge(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	addr := a.Config.NudgeAddr
+	if addr == "" {
+		return gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"no nudge endpoint configured; start the session with -nudge-addr and pass the same address here")
+	}
+
+	client, url := control.NewClient(addr, "/nudge")
+
+	ctx, cancel := context.WithTimeout(ctx, nudgeRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return gitbakErrors.Wrapf(err, "invalid nudge address %s", addr)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrNotRunning, "no session answered on %s: %v", addr, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("nudge endpoint %s answered %s", addr, resp.Status)
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "👉 Nudged the gitbak session on %s\n", addr)
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/control"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestHandleNudge tests that POST /nudge queues a single pending check
+func TestHandleNudge(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		method        string
+		requests      int
+		expectStatus  int
+		expectPending bool
+	}{
+		"Post": {
+			method:        http.MethodPost,
+			requests:      1,
+			expectStatus:  http.StatusAccepted,
+			expectPending: true,
+		},
+		"RepeatedPostsCoalesce": {
+			method:        http.MethodPost,
+			requests:      3,
+			expectStatus:  http.StatusAccepted,
+			expectPending: true,
+		},
+		"GetRejected": {
+			method:        http.MethodGet,
+			requests:      1,
+			expectStatus:  http.StatusMethodNotAllowed,
+			expectPending: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			app := &App{nudges: make(chan struct{}, 1)}
+
+			var rec *httptest.ResponseRecorder
+			for i := 0; i < test.requests; i++ {
+				rec = httptest.NewRecorder()
+				app.handleNudge(rec, httptest.NewRequest(test.method, "/nudge", nil))
+			}
+
+			if rec.Code != test.expectStatus {
+				t.Errorf("Expected status %d, got %d", test.expectStatus, rec.Code)
+			}
+			if pending := len(app.nudges) == 1; pending != test.expectPending {
+				t.Errorf("Expected pending nudge to be %v, got %v", test.expectPending, pending)
+			}
+		})
+	}
+}
+
+// TestRunNudge tests posting a nudge to a session listening on a TCP or Unix socket address
+func TestRunNudge(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, so avoid the long t.TempDir paths
+	socketDir, err := os.MkdirTemp("", "gitbak-nudge")
+	if err != nil {
+		t.Fatalf("Failed to create socket directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+
+	tests := map[string]struct {
+		addr          string
+		listen        bool
+		errorIs       error
+		expectPending bool
+	}{
+		"TCP": {
+			addr:          freeTCPAddr(t),
+			listen:        true,
+			expectPending: true,
+		},
+		"UnixSocket": {
+			addr:          "unix:" + filepath.Join(socketDir, "listening.sock"),
+			listen:        true,
+			expectPending: true,
+		},
+		"NoSession": {
+			addr:    "unix:" + filepath.Join(socketDir, "missing.sock"),
+			errorIs: gitbakErrors.ErrNotRunning,
+		},
+		"NotConfigured": {
+			addr:    "",
+			errorIs: gitbakErrors.ErrInvalidConfiguration,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := test.addr
+
+			session := &App{nudges: make(chan struct{}, 1), Logger: &MockLogger{}}
+			if test.listen {
+				if err := session.startNudgeServer(addr); err != nil {
+					t.Fatalf("Failed to start nudge server: %v", err)
+				}
+				t.Cleanup(func() { _ = session.nudgeServer.Close() })
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.NudgeAddr = addr
+
+			err := app.RunNudge(context.Background())
+
+			if test.errorIs != nil {
+				if !gitbakErrors.Is(err, test.errorIs) {
+					t.Fatalf("Expected error %v, got %v", test.errorIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunNudge failed: %v", err)
+			}
+
+			if pending := len(session.nudges) == 1; pending != test.expectPending {
+				t.Errorf("Expected pending nudge to be %v, got %v", test.expectPending, pending)
+			}
+			if !strings.Contains(stdout.String(), "Nudged the gitbak session") {
+				t.Errorf("Expected confirmation, got %q", stdout.String())
+			}
+		})
+	}
+}
+
+// TestLocalListenerReplacesStaleSocket tests that a socket left behind by a crashed session is reused
+func TestLocalListenerReplacesStaleSocket(t *testing.T) {
+	socketDir, err := os.MkdirTemp("", "gitbak-nudge")
+	if err != nil {
+		t.Fatalf("Failed to create socket directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+
+	path := filepath.Join(socketDir, "stale.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	// Keep the socket file, as a process that was killed would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	listener, err := localListener(control.UnixAddrPrefix + path)
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced, got: %v", err)
+	}
+
+	if _, err := localListener(control.UnixAddrPrefix + path); err == nil {
+		t.Error("Expected an error while another listener owns the socket")
+	}
+
+	_ = listener.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket file to be removed on close, got: %v", err)
+	}
+}
+
+// freeTCPAddr returns a loopback address with a port that was free a moment ago
+func freeTCPAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().String()
+}
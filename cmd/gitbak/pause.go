@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// RunPause asks the gitbak process monitoring the repository to pause checkpointing,
+// e.g. for the duration of an interactive rebase, without ending the session.
+func (a *App) RunPause(ctx context.Context) error {
+	return a.signalSession(pauseSignal, "Paused")
+}
+
+// RunResume asks the gitbak process monitoring the repository to resume checkpointing
+func (a *App) RunResume(ctx context.Context) error {
+	return a.signalSession(resumeSignal, "Resumed")
+}
+
+// signalSession sends sig to the gitbak process holding the repository lock and
+// confirms it with "<done> gitbak (PID n) in <repo>"
+func (a *App) signalSession(sig os.Signal, done string) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if sig == nil {
+		return gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"signalling a session is not supported on this platform; use the control endpoint (-listen) instead")
+	}
+
+	pid, running := a.lockHolder(a.lockKey(a.Config.RepoPath))
+	if !running {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrNotRunning, "no gitbak session in %s", a.Config.RepoPath)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return gitbakErrors.Wrapf(err, "failed to find gitbak process %d", pid)
+	}
+	if err := process.Signal(sig); err != nil {
+		return gitbakErrors.Wrapf(err, "failed to signal gitbak process %d", pid)
+	}
+
+	a.Logger.Success("%s gitbak (PID %d) in %s", done, pid, a.Config.RepoPath)
+	return nil
+}
+
+// setPaused pauses or resumes checkpointing, telling the user how if that changes anything
+func (a *App) setPaused(paused bool, how string) {
+	if a.paused.Swap(paused) == paused {
+		return
+	}
+	if paused {
+		a.Logger.InfoToUser("Checkpointing paused %s", how)
+	} else {
+		a.Logger.InfoToUser("Checkpointing resumed %s", how)
+	}
+	a.notifyRPC("gitbak/paused", map[string]any{"paused": paused})
+}
+
+// watchPauseSignals pauses checkpointing on pauseSignal and resumes it on resumeSignal until ctx is done
+func (a *App) watchPauseSignals(ctx context.Context) {
+	if pauseSignal == nil {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, pauseSignal, resumeSignal)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				a.setPaused(sig == pauseSignal, "by signal")
+			}
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestPauseResume tests pausing and resuming a session by signalling the lock holder
+func TestPauseResume(t *testing.T) {
+	// The test process stands in for the monitoring gitbak instance
+	session := NewTestApp()
+	session = WithMockLogger(session, &MockLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session.watchPauseSignals(ctx)
+
+	waitForPaused := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for session.paused.Load() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected paused to become %t", want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		run     func(a *App, ctx context.Context) error
+		paused  bool
+		confirm string
+	}{
+		{name: "Pause", run: (*App).RunPause, paused: true, confirm: "Paused gitbak"},
+		{name: "Resume", run: (*App).RunResume, paused: false, confirm: "Resumed gitbak"},
+	}
+
+	// The steps depend on each other, so they run in order rather than in parallel
+	for _, test := range tests {
+		mockLogger := &MockLogger{}
+		app := NewTestApp()
+		app = WithMockLocker(app, &MockLocker{})
+		app = WithMockLogger(app, mockLogger)
+		app.Gitbak = &MockGitbaker{}
+		app.lockHolder = func(string) (int, bool) { return os.Getpid(), true }
+
+		if err := test.run(app, context.Background()); err != nil {
+			t.Fatalf("%s failed: %v", test.name, err)
+		}
+		if !strings.Contains(mockLogger.LastMessage, test.confirm) {
+			t.Errorf("%s: expected a confirmation, got %q", test.name, mockLogger.LastMessage)
+		}
+		waitForPaused(test.paused)
+	}
+}
+
+// TestPauseNotRunning tests that pausing without a running session is reported
+func TestPauseNotRunning(t *testing.T) {
+	app := NewTestApp()
+	app = WithMockLocker(app, &MockLocker{})
+	app = WithMockLogger(app, &MockLogger{})
+	app.Gitbak = &MockGitbaker{}
+	app.lockHolder = func(string) (int, bool) { return 0, false }
+
+	if err := app.RunPause(context.Background()); !gitbakErrors.Is(err, gitbakErrors.ErrNotRunning) {
+		t.Fatalf("Expected ErrNotRunning, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// pauseSignal and resumeSignal are unavailable on Windows, which has no user-defined
+// signals; sessions there are paused through the control endpoint instead.
+var (
+	pauseSignal  os.Signal
+	resumeSignal os.Signal
+)
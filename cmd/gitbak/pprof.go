@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// startPprofServer serves the net/http/pprof endpoints on addr for debugging
+// memory and CPU usage during long sessions. It uses a dedicated mux so the
+// profiling handlers are never exposed through http.DefaultServeMux.
+func (a *App) startPprofServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.pprofServer = server
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.Logger.Warning("Profiling server stopped: %v", err)
+		}
+	}()
+
+	a.Logger.InfoToUser("Profiling endpoints available at http://%s/debug/pprof/", listener.Addr())
+	return nil
+}
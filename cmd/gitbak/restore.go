@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunRestore recovers work from a checkpoint of the most recent session. Without
+// arguments it lists the session's checkpoints; given a checkpoint number or commit,
+// it restores the working tree to that checkpoint, or only the paths that follow it.
+// Relative paths are resolved against the current directory. Overwriting uncommitted
+// changes needs confirmation unless -yes is set.
+func (a *App) RunRestore(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to restore from in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	checkpoints, err := repo.ListCheckpoints(ctx, state)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Config.Args) == 0 {
+		a.printCheckpoints(state, checkpoints)
+		return nil
+	}
+
+	checkpoint, err := git.FindCheckpoint(checkpoints, a.Config.Args[0])
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, arg := range a.Config.Args[1:] {
+		path, err := a.repoRelativePath(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	modified, err := repo.ModifiedPaths(ctx, paths)
+	if err != nil {
+		return err
+	}
+	untracked, err := repo.OverwrittenUntrackedPaths(ctx, checkpoint, paths)
+	if err != nil {
+		return err
+	}
+	if len(modified)+len(untracked) > 0 && !a.Config.AssumeYes {
+		question := fmt.Sprintf("Overwrite uncommitted changes to %d file(s) with their content at checkpoint %s?",
+			len(modified)+len(untracked), checkpointName(checkpoint))
+		if !a.interactor.PromptYesNo(question) {
+			_, _ = fmt.Fprintln(a.Stdout, "Restore cancelled, nothing was changed.")
+			return nil
+		}
+	}
+
+	if err := repo.RestoreCheckpoint(ctx, checkpoint, paths); err != nil {
+		return err
+	}
+
+	restored := "the working tree"
+	if len(paths) > 0 {
+		restored = strings.Join(paths, ", ")
+	}
+	a.Logger.Success("Restored %s to checkpoint %s (%s)", restored, checkpointName(checkpoint), checkpoint.ShortCommit())
+	a.Logger.StatusMessage("The restored files show up as uncommitted changes; review them with: git diff")
+	return nil
+}
+
+// printCheckpoints lists the session's checkpoints, oldest first
+func (a *App) printCheckpoints(state *session.State, checkpoints []git.Checkpoint) {
+	where := fmt.Sprintf("on '%s'", state.Branch)
+	if state.Refs != "" {
+		where = "in " + state.Refs
+	}
+	if len(checkpoints) == 0 {
+		_, _ = fmt.Fprintf(a.Stdout, "No checkpoints found %s.\n", where)
+		return
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "Checkpoints %s:\n", where)
+	for _, checkpoint := range checkpoints {
+		_, _ = fmt.Fprintf(a.Stdout, "  %-5s %s  %s  %s\n", checkpointName(checkpoint),
+			checkpoint.Time.Local().Format(time.DateTime), checkpoint.ShortCommit(), checkpoint.Subject)
+	}
+	_, _ = fmt.Fprintln(a.Stdout, "Restore one with: gitbak restore <checkpoint> [path...]")
+}
+
+// checkpointName returns how the checkpoint is referred to: by number if it has one
+func checkpointName(checkpoint git.Checkpoint) string {
+	if checkpoint.Number > 0 {
+		return fmt.Sprintf("#%d", checkpoint.Number)
+	}
+	return checkpoint.ShortCommit()
+}
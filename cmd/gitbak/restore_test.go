@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunRestore tests the restore command against a real repository
+func TestRunRestore(t *testing.T) {
+	tests := map[string]struct {
+		args           []string
+		writeState     bool
+		dirty          bool
+		untracked      bool
+		assumeYes      bool
+		confirm        bool
+		expectedNotes  string
+		expectedOther  string
+		outputContains string
+		errorContains  string
+	}{
+		"NoSession": {
+			errorContains: "no gitbak session to restore from",
+		},
+		"List": {
+			writeState:     true,
+			outputContains: "#2",
+			expectedNotes:  "two\n",
+			expectedOther:  "other\n",
+		},
+		"WholeTree": {
+			args:          []string{"1"},
+			writeState:    true,
+			expectedNotes: "one\n",
+		},
+		"Paths": {
+			args:          []string{"#1", "notes.txt"},
+			writeState:    true,
+			expectedNotes: "one\n",
+			expectedOther: "other\n",
+		},
+		"DirtyDeclined": {
+			args:           []string{"1"},
+			writeState:     true,
+			dirty:          true,
+			outputContains: "Restore cancelled",
+			expectedNotes:  "unsaved\n",
+			expectedOther:  "other\n",
+		},
+		"DirtyConfirmed": {
+			args:          []string{"1"},
+			writeState:    true,
+			dirty:         true,
+			confirm:       true,
+			expectedNotes: "one\n",
+		},
+		"DirtyAssumeYes": {
+			args:          []string{"1", "notes.txt"},
+			writeState:    true,
+			dirty:         true,
+			assumeYes:     true,
+			expectedNotes: "one\n",
+			expectedOther: "other\n",
+		},
+		"UntrackedDeclined": {
+			args:           []string{"2"},
+			writeState:     true,
+			untracked:      true,
+			outputContains: "Restore cancelled",
+			expectedNotes:  "two\n",
+			expectedOther:  "untracked\n",
+		},
+		"UntrackedConfirmed": {
+			args:          []string{"2"},
+			writeState:    true,
+			untracked:     true,
+			confirm:       true,
+			expectedNotes: "two\n",
+			expectedOther: "other\n",
+		},
+		"UnknownCheckpoint": {
+			args:          []string{"9"},
+			writeState:    true,
+			errorContains: "no checkpoint 9",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				run := func(args ...string) string {
+					out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+					if err != nil {
+						t.Fatalf("git %v failed: %v", args, err)
+					}
+					return strings.TrimSpace(string(out))
+				}
+				write := func(name, content string) {
+					if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+						t.Fatalf("Failed to write %s: %v", name, err)
+					}
+				}
+
+				startCommit := run("rev-parse", "HEAD")
+				run("checkout", "-b", "gitbak-session")
+				write("notes.txt", "one\n")
+				run("add", ".")
+				run("commit", "-m", "[gitbak] Automatic checkpoint #1 - 2026-01-01 10:00:00")
+				write("notes.txt", "two\n")
+				write("other.txt", "other\n")
+				run("add", ".")
+				run("commit", "-m", "[gitbak] Automatic checkpoint #2 - 2026-01-01 10:05:00")
+				if test.dirty {
+					write("notes.txt", "unsaved\n")
+				}
+				if test.untracked {
+					// A commit made by hand stops tracking other.txt, so checkpoint #2 would overwrite it
+					run("rm", "--cached", "other.txt")
+					run("commit", "-m", "Stop tracking other.txt")
+					write("other.txt", "untracked\n")
+				}
+
+				stateFile := filepath.Join(t.TempDir(), "state.json")
+				if test.writeState {
+					state := &session.State{
+						RepoPath:     repoPath,
+						Branch:       "gitbak-session",
+						CommitPrefix: "[gitbak] Automatic checkpoint",
+						StartCommit:  startCommit,
+					}
+					if err := session.Save(stateFile, state); err != nil {
+						t.Fatalf("Failed to save state: %v", err)
+					}
+				}
+
+				var stdout bytes.Buffer
+				app := NewTestApp()
+				app = WithMockLocker(app, &MockLocker{})
+				app = WithMockLogger(app, &MockLogger{})
+				app.Stdout = &stdout
+				app.interactor = git.NewMockInteractor(test.confirm)
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.AssumeYes = test.assumeYes
+				app.Config.Args = test.args
+
+				err := app.RunRestore(context.Background())
+
+				if test.errorContains != "" {
+					if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+						t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("RunRestore failed: %v", err)
+				}
+
+				if !strings.Contains(stdout.String(), test.outputContains) {
+					t.Errorf("Expected output to contain %q, got %q", test.outputContains, stdout.String())
+				}
+				if notes, _ := os.ReadFile(filepath.Join(repoPath, "notes.txt")); string(notes) != test.expectedNotes {
+					t.Errorf("Expected notes.txt to contain %q, got %q", test.expectedNotes, notes)
+				}
+				if other, _ := os.ReadFile(filepath.Join(repoPath, "other.txt")); string(other) != test.expectedOther {
+					t.Errorf("Expected other.txt to contain %q, got %q", test.expectedOther, other)
+				}
+				if branch := run("branch", "--show-current"); branch != "gitbak-session" {
+					t.Errorf("Expected to stay on the session branch, got %q", branch)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/git"
+)
+
+// maxRPCMessageBytes bounds the size of a message read over -rpc; requests are tiny,
+// so anything larger is taken for a corrupted stream
+const maxRPCMessageBytes = 1 << 20
+
+// JSON-RPC 2.0 error codes used by the -rpc protocol
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInternalError  = -32603
+)
+
+// rpcRequest is a request or notification read from the editor
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+}
+
+// rpcResponse answers a request; exactly one of Result and Error is set
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError describes why a request failed
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcNotification tells the editor about the session without being asked
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// rpcCheckpoint is the payload of the gitbak/checkpoint notification
+type rpcCheckpoint struct {
+	Number int       `json:"number"`
+	Commit string    `json:"commit"`
+	Branch string    `json:"branch,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// rpcConn reads requests from the editor and writes responses and notifications to it,
+// framed as in the Language Server Protocol: a Content-Length header, a blank line and
+// the JSON body. Writes may come from any goroutine.
+type rpcConn struct {
+	in  *bufio.Reader
+	out io.Writer
+	mu  sync.Mutex
+}
+
+// startRPC serves the JSON-RPC protocol of -rpc on stdin and stdout, so that an editor
+// extension can host gitbak as a child process:
+//
+//	gitbak/status     the session's state, as the control endpoint reports it
+//	gitbak/pause      skip checks until resumed
+//	gitbak/resume     resume checking
+//	gitbak/commitNow  check for changes immediately, even while paused
+//	gitbak/stop       stop the session, as gitbak stop does
+//
+// Every request is answered with the status document. gitbak in turn sends the
+// notifications gitbak/started, gitbak/checkpoint, gitbak/checkFailed, gitbak/paused
+// and gitbak/stopped. Closing stdin stops the session, so it ends with the editor.
+func (a *App) startRPC(ctx context.Context) {
+	a.rpc = &rpcConn{in: bufio.NewReader(a.Stdin), out: a.Stdout}
+	a.Logger.Info("Serving JSON-RPC on stdin and stdout")
+
+	go func() {
+		for {
+			body, err := a.rpc.read()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if err == io.EOF {
+					a.Logger.Info("RPC client closed stdin, stopping")
+				} else {
+					a.Logger.WarningToUser("Stopping after an unreadable RPC message: %v", err)
+				}
+				if a.requestStop != nil {
+					a.requestStop()
+				}
+				return
+			}
+			a.handleRPC(body)
+		}
+	}()
+}
+
+// handleRPC carries out one message from the editor, answering it unless it is a notification
+func (a *App) handleRPC(body []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		a.rpc.respond(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		a.rpc.respond(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+		return
+	}
+
+	switch req.Method {
+	case "gitbak/status":
+	case "gitbak/pause":
+		a.setPaused(true, "via RPC")
+	case "gitbak/resume":
+		a.setPaused(false, "via RPC")
+	case "gitbak/commitNow":
+		select {
+		case a.checkNow <- struct{}{}:
+		default:
+			// A check is already pending
+		}
+	case "gitbak/stop":
+		if a.requestStop == nil {
+			a.rpc.respond(req.ID, nil, &rpcError{Code: rpcInternalError, Message: "this session cannot be stopped over RPC"})
+			return
+		}
+		a.Logger.InfoToUser("Stop requested via RPC")
+		defer a.requestStop()
+	default:
+		a.rpc.respond(req.ID, nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)})
+		return
+	}
+
+	status, err := a.controlStatus()
+	if err != nil {
+		a.Logger.Warning("Failed to read session state for RPC: %v", err)
+		a.rpc.respond(req.ID, nil, &rpcError{Code: rpcInternalError, Message: "failed to read session state"})
+		return
+	}
+	a.rpc.respond(req.ID, status, nil)
+}
+
+// notifyRPC sends a notification to the editor, if -rpc is serving one
+func (a *App) notifyRPC(method string, params any) {
+	if a.rpc == nil {
+		return
+	}
+	if err := a.rpc.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		a.Logger.Warning("Failed to send %s notification: %v", method, err)
+	}
+}
+
+// respond answers the request with id, unless it is a notification, which has none
+func (c *rpcConn) respond(id json.RawMessage, result any, rpcErr *rpcError) {
+	if id == nil && rpcErr == nil {
+		return
+	}
+	if id == nil {
+		// The request could not be read far enough to tell its id
+		id = json.RawMessage("null")
+	}
+	_ = c.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
+
+// read returns the body of the next message
+func (c *rpcConn) read() ([]byte, error) {
+	length := -1
+	for {
+		line, err := c.in.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without a Content-Length header")
+	}
+	if length > maxRPCMessageBytes {
+		return nil, fmt.Errorf("message of %d bytes exceeds the limit of %d", length, maxRPCMessageBytes)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// write sends message with its Content-Length header
+func (c *rpcConn) write(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.out.Write(body)
+	return err
+}
+
+// rpcCheckpointOf describes checkpoint for the gitbak/checkpoint notification
+func rpcCheckpointOf(checkpoint git.Checkpoint) rpcCheckpoint {
+	return rpcCheckpoint{Number: checkpoint.Number, Commit: checkpoint.Commit, Branch: checkpoint.Branch, Time: checkpoint.Time}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/control"
+)
+
+// rpcFrame frames body as a message of the -rpc protocol
+func rpcFrame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// TestRPC tests the requests and notifications of the -rpc protocol
+func TestRPC(t *testing.T) {
+	tests := map[string]struct {
+		input         string
+		expectMethods []string
+		expectError   int
+		expectPaused  bool
+		expectPending bool
+		expectStops   int
+	}{
+		"Status": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","id":1,"method":"gitbak/status"}`),
+			expectMethods: []string{""},
+			expectStops:   1,
+		},
+		"Pause": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","id":1,"method":"gitbak/pause"}`),
+			expectMethods: []string{"gitbak/paused", ""},
+			expectPaused:  true,
+			expectStops:   1,
+		},
+		"CommitNow": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","id":1,"method":"gitbak/commitNow"}`) + rpcFrame(`{"jsonrpc":"2.0","id":2,"method":"gitbak/commitNow"}`),
+			expectMethods: []string{"", ""},
+			expectPending: true,
+			expectStops:   1,
+		},
+		"Stop": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","id":"stop","method":"gitbak/stop"}`),
+			expectMethods: []string{""},
+			expectStops:   2,
+		},
+		"UnknownMethod": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","id":1,"method":"gitbak/rebase"}`),
+			expectMethods: []string{""},
+			expectError:   rpcMethodNotFound,
+			expectStops:   1,
+		},
+		"ParseError": {
+			input:         rpcFrame(`{"jsonrpc":`),
+			expectMethods: []string{""},
+			expectError:   rpcParseError,
+			expectStops:   1,
+		},
+		"NotificationIsNotAnswered": {
+			input:         rpcFrame(`{"jsonrpc":"2.0","method":"gitbak/pause"}`),
+			expectMethods: []string{"gitbak/paused"},
+			expectPaused:  true,
+			expectStops:   1,
+		},
+		"MissingContentLength": {
+			input:       "Content-Type: application/json\r\n\r\n{}",
+			expectStops: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.New()
+			cfg.RepoPath = "/test/repo"
+			cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+			var stdout bytes.Buffer
+			stops := make(chan struct{}, 2)
+			app := &App{
+				Config:      cfg,
+				Logger:      &MockLogger{},
+				Stdin:       strings.NewReader(test.input),
+				Stdout:      &stdout,
+				checkNow:    make(chan struct{}, 1),
+				requestStop: func() { stops <- struct{}{} },
+			}
+
+			app.startRPC(context.Background())
+			// The last stop is the one requested when stdin runs out
+			for i := 0; i < test.expectStops; i++ {
+				select {
+				case <-stops:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Expected %d stop request(s), got %d", test.expectStops, i)
+				}
+			}
+
+			conn := &rpcConn{in: bufio.NewReader(&stdout)}
+			var methods []string
+			for range test.expectMethods {
+				body, err := conn.read()
+				if err != nil {
+					t.Fatalf("Failed to read message %d of %d: %v", len(methods)+1, len(test.expectMethods), err)
+				}
+
+				var message struct {
+					ID     json.RawMessage `json:"id"`
+					Method string          `json:"method"`
+					Result *control.Status `json:"result"`
+					Error  *rpcError       `json:"error"`
+				}
+				if err := json.Unmarshal(body, &message); err != nil {
+					t.Fatalf("Failed to decode message %q: %v", body, err)
+				}
+				methods = append(methods, message.Method)
+				if message.Method != "" {
+					continue
+				}
+
+				if test.expectError != 0 {
+					if message.Error == nil || message.Error.Code != test.expectError {
+						t.Errorf("Expected error code %d, got %+v", test.expectError, message.Error)
+					}
+					continue
+				}
+				if message.Result == nil {
+					t.Fatalf("Expected a status result, got %q", body)
+				}
+				if message.Result.RepoPath != cfg.RepoPath {
+					t.Errorf("Expected repo path %s, got %s", cfg.RepoPath, message.Result.RepoPath)
+				}
+				if message.Result.Paused != test.expectPaused {
+					t.Errorf("Expected paused to be %v, got %v", test.expectPaused, message.Result.Paused)
+				}
+			}
+			if rest := stdout.String(); rest != "" {
+				t.Errorf("Expected messages %q, got %q and then %q", test.expectMethods, methods, rest)
+			}
+
+			if pending := len(app.checkNow) == 1; pending != test.expectPending {
+				t.Errorf("Expected pending check to be %v, got %v", test.expectPending, pending)
+			}
+		})
+	}
+}
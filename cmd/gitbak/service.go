@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/service"
+)
+
+// serviceActions lists the actions of the service command
+var serviceActions = []string{"install", "print", "uninstall"}
+
+// RunService manages a user service that runs gitbak for the repository at login: a
+// systemd unit on Linux or a launchd agent on macOS. The first argument selects the
+// action; the flags after install or print are those the service starts gitbak with.
+//
+//   - install writes the service definition and starts it, now and at every login
+//   - print writes the definition to stdout instead, to be installed by hand
+//   - uninstall stops the service and removes its definition
+func (a *App) RunService(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if len(a.Config.Args) == 0 || !slices.Contains(serviceActions, a.Config.Args[0]) {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"service takes an action: %s", strings.Join(serviceActions, ", "))
+	}
+	action, sessionArgs := a.Config.Args[0], a.Config.Args[1:]
+	if action == "uninstall" && len(sessionArgs) > 0 {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "service uninstall takes no arguments, got %q", sessionArgs)
+	}
+
+	platform, err := a.servicePlatform()
+	if err != nil {
+		return err
+	}
+	spec, err := a.serviceSpec(sessionArgs)
+	if err != nil {
+		return err
+	}
+	path := platform.Path(spec)
+
+	switch action {
+	case "print":
+		_, _ = fmt.Fprintf(a.Stderr, "📄 Install as %s\n", path)
+		_, _ = fmt.Fprint(a.Stdout, platform.Render(spec))
+		return nil
+	case "uninstall":
+		return a.uninstallService(ctx, platform, spec, path)
+	default:
+		return a.installService(ctx, platform, spec, path)
+	}
+}
+
+// serviceSpec describes the session the repository's service runs, started with sessionArgs
+func (a *App) serviceSpec(sessionArgs []string) (service.Spec, error) {
+	// Parse the session's flags now, so that mistakes are reported here rather than at login
+	sessionConfig := config.New()
+	if err := sessionConfig.ParseArgs(sessionArgs); err != nil {
+		return service.Spec{}, err
+	}
+	switch {
+	case len(sessionConfig.Args) > 0:
+		return service.Spec{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"unexpected argument %q: the service starts a monitoring session, with the flags given after the action", sessionConfig.Args[0])
+	case sessionConfig.RepoPath != "":
+		return service.Spec{}, gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"give -repo before the action, as in 'gitbak service -repo <path> install'")
+	case sessionConfig.Detach || sessionConfig.TUI:
+		return service.Spec{}, gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"-detach and -tui cannot be used for a service, which the service manager runs in the background")
+	}
+
+	exe, err := a.executable()
+	if err != nil {
+		return service.Spec{}, gitbakErrors.Wrap(err, "failed to locate the gitbak executable")
+	}
+
+	logFile := a.Config.LogFile
+	if sessionConfig.LogFile != "" {
+		if logFile, err = filepath.Abs(sessionConfig.LogFile); err != nil {
+			return service.Spec{}, gitbakErrors.Wrap(err, "failed to resolve the log file")
+		}
+	}
+
+	return service.Spec{
+		Name:        "gitbak-" + config.RepoID(a.Config.RepoPath),
+		Description: "gitbak checkpoints for " + a.Config.RepoPath,
+		Executable:  exe,
+		Args:        append([]string{"-repo", a.Config.RepoPath}, sessionArgs...),
+		WorkingDir:  a.Config.RepoPath,
+		// There is no terminal to answer prompts, and the service manager's PATH may lack git
+		Env:     []string{"NON_INTERACTIVE=true", "PATH=" + os.Getenv("PATH")},
+		LogFile: logFile,
+	}, nil
+}
+
+// installService writes the service definition to path and starts the service, replacing
+// any service already installed for the repository
+func (a *App) installService(ctx context.Context, platform *service.Platform, spec service.Spec, path string) error {
+	checkCtx, cancel := context.WithTimeout(ctx, startupCheckTimeout)
+	isRepo, err := a.isRepository(checkCtx, a.Config.RepoPath)
+	cancel()
+	if err != nil {
+		return gitbakErrors.Wrap(gitbakErrors.ErrGitOperationFailed, err.Error())
+	}
+	if !isRepo {
+		return gitbakErrors.ErrNotGitRepository
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		_, _ = fmt.Fprintf(a.Stdout, "🔄 Replacing the service installed at %s\n", path)
+		// Stop the old session first, or the new definition wouldn't take effect until the next login
+		for _, command := range platform.StopCommands(path, spec) {
+			if err := a.runServiceCommand(ctx, command); err != nil {
+				_, _ = fmt.Fprintf(a.Stderr, "⚠️  %v\n", err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return gitbakErrors.Wrap(err, "failed to create the service directory")
+	}
+	if err := os.WriteFile(path, []byte(platform.Render(spec)), 0o644); err != nil {
+		return gitbakErrors.Wrap(err, "failed to write the service definition")
+	}
+
+	for _, command := range platform.StartCommands(path, spec) {
+		if err := a.runServiceCommand(ctx, command); err != nil {
+			return gitbakErrors.Wrapf(err, "wrote %s, but failed to start it", path)
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "✅ Installed %s service %s\n", platform.Name, path)
+	_, _ = fmt.Fprintf(a.Stdout, "🚀 gitbak is now running for %s, and will start again at every login\n", a.Config.RepoPath)
+	_, _ = fmt.Fprintln(a.Stdout, "   Use 'gitbak status' to check on it and 'gitbak service uninstall' to remove it")
+	return nil
+}
+
+// uninstallService stops the repository's service and removes its definition from path
+func (a *App) uninstallService(ctx context.Context, platform *service.Platform, spec service.Spec, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "no gitbak service is installed for %s (looked for %s)", a.Config.RepoPath, path)
+	}
+
+	// A service that was never started, or was stopped by hand, can still be removed
+	for _, command := range platform.StopCommands(path, spec) {
+		if err := a.runServiceCommand(ctx, command); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "⚠️  %v\n", err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return gitbakErrors.Wrap(err, "failed to remove the service definition")
+	}
+	for _, command := range platform.ReloadCommands() {
+		if err := a.runServiceCommand(ctx, command); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "⚠️  %v\n", err)
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "🗑️  Removed %s service %s\n", platform.Name, path)
+	return nil
+}
+
+// serviceCommandTimeout bounds each systemctl or launchctl command
+const serviceCommandTimeout = 30 * time.Second
+
+// runServiceCommand runs a service manager command, reporting its output if it fails
+func runServiceCommand(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, serviceCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return gitbakErrors.Wrapf(err, "%s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
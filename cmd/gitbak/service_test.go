@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/service"
+)
+
+// TestRunService tests installing, printing and uninstalling the repository's service
+func TestRunService(t *testing.T) {
+	tests := map[string]struct {
+		args             []string
+		installed        bool
+		failCommand      string
+		expectedCommands []string
+		expectedOutput   string
+		expectInstalled  bool
+		errorIs          error
+		errorContains    string
+	}{
+		"Install": {
+			args: []string{"install", "-interval", "2", "-watch"},
+			expectedCommands: []string{
+				"systemctl --user daemon-reload",
+				"systemctl --user enable --now gitbak-",
+			},
+			expectedOutput:  "✅ Installed systemd service",
+			expectInstalled: true,
+		},
+		"Reinstall": {
+			args:      []string{"install"},
+			installed: true,
+			expectedCommands: []string{
+				"systemctl --user disable --now gitbak-",
+				"systemctl --user daemon-reload",
+				"systemctl --user enable --now gitbak-",
+			},
+			expectedOutput:  "🔄 Replacing the service",
+			expectInstalled: true,
+		},
+		"StartFails": {
+			args:            []string{"install"},
+			failCommand:     "enable",
+			errorContains:   "but failed to start it",
+			expectInstalled: true,
+		},
+		"Print": {
+			args:           []string{"print", "-interval", "2"},
+			expectedOutput: " -interval 2\n",
+		},
+		"Uninstall": {
+			args:      []string{"uninstall"},
+			installed: true,
+			expectedCommands: []string{
+				"systemctl --user disable --now gitbak-",
+				"systemctl --user daemon-reload",
+			},
+			expectedOutput: "🗑️  Removed systemd service",
+		},
+		"UninstallStopFails": {
+			args:        []string{"uninstall"},
+			installed:   true,
+			failCommand: "disable",
+			expectedCommands: []string{
+				"systemctl --user disable --now gitbak-",
+				"systemctl --user daemon-reload",
+			},
+			expectedOutput: "🗑️  Removed systemd service",
+		},
+		"UninstallNotInstalled": {
+			args:          []string{"uninstall"},
+			errorContains: "no gitbak service is installed",
+		},
+		"NoAction": {
+			errorIs: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"UnknownAction": {
+			args:    []string{"start"},
+			errorIs: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"RepoAfterAction": {
+			args:          []string{"install", "-repo", "elsewhere"},
+			errorContains: "give -repo before the action",
+		},
+		"Detach": {
+			args:          []string{"install", "-detach"},
+			errorContains: "cannot be used for a service",
+		},
+		"StrayArgument": {
+			args:          []string{"install", "now"},
+			errorContains: `unexpected argument "now"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			platform, err := service.For("linux", home, "")
+			if err != nil {
+				t.Fatalf("For failed: %v", err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			app := NewTestApp()
+			app.Stdout = &stdout
+			app.Stderr = &stderr
+			app.Config.RepoPath = t.TempDir()
+			app.Config.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+			app.Config.StateFile = filepath.Join(t.TempDir(), "state.json")
+			app.Config.Args = test.args
+			app.executable = func() (string, error) { return "/usr/local/bin/gitbak", nil }
+			app.servicePlatform = func() (*service.Platform, error) { return platform, nil }
+
+			var commands []string
+			app.runServiceCommand = func(_ context.Context, args []string) error {
+				command := strings.Join(args, " ")
+				commands = append(commands, command)
+				if test.failCommand != "" && slices.Contains(args, test.failCommand) {
+					return gitbakErrors.New(command + " failed")
+				}
+				return nil
+			}
+
+			spec, _ := app.serviceSpec(nil)
+			path := platform.Path(spec)
+			if test.installed {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("Failed to create service directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("[Unit]\n"), 0o644); err != nil {
+					t.Fatalf("Failed to write service: %v", err)
+				}
+			}
+
+			err = app.RunService(context.Background())
+			switch {
+			case test.errorIs != nil:
+				if !gitbakErrors.Is(err, test.errorIs) {
+					t.Fatalf("Expected error %v, got %v", test.errorIs, err)
+				}
+			case test.errorContains != "":
+				if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+					t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+				}
+			case err != nil:
+				t.Fatalf("RunService failed: %v", err)
+			}
+
+			if test.expectedCommands != nil {
+				if len(commands) != len(test.expectedCommands) {
+					t.Fatalf("Expected commands %q, got %q", test.expectedCommands, commands)
+				}
+				for i, want := range test.expectedCommands {
+					if !strings.HasPrefix(commands[i], want) {
+						t.Errorf("Expected command %d to start with %q, got %q", i, want, commands[i])
+					}
+				}
+			}
+			if !strings.Contains(stdout.String(), test.expectedOutput) {
+				t.Errorf("Expected output containing %q, got %q", test.expectedOutput, stdout.String())
+			}
+
+			content, readErr := os.ReadFile(path)
+			if installed := readErr == nil; installed != test.expectInstalled {
+				t.Fatalf("Expected the service to be installed: %v, got %v", test.expectInstalled, installed)
+			}
+			if test.expectInstalled && !strings.Contains(string(content), "ExecStart=/usr/local/bin/gitbak -repo "+app.Config.RepoPath) {
+				t.Errorf("Expected the unit to run gitbak for the repository, got:\n%s", content)
+			}
+		})
+	}
+}
+
+// TestServiceSpec tests the session that the service runs
+func TestServiceSpec(t *testing.T) {
+	app := NewTestApp()
+	app.Config.RepoPath = "/home/me/project"
+	app.Config.LogFile = "/home/me/.local/share/gitbak/logs/gitbak.log"
+	app.executable = func() (string, error) { return "/usr/local/bin/gitbak", nil }
+
+	spec, err := app.serviceSpec([]string{"-interval", "2", "-no-branch"})
+	if err != nil {
+		t.Fatalf("serviceSpec failed: %v", err)
+	}
+
+	expectedArgs := []string{"-repo", "/home/me/project", "-interval", "2", "-no-branch"}
+	if !reflect.DeepEqual(spec.Args, expectedArgs) {
+		t.Errorf("Expected args %q, got %q", expectedArgs, spec.Args)
+	}
+	if !strings.HasPrefix(spec.Name, "gitbak-") || spec.Name == "gitbak-" {
+		t.Errorf("Expected a name identifying the repository, got %q", spec.Name)
+	}
+	if spec.WorkingDir != "/home/me/project" || spec.LogFile != app.Config.LogFile {
+		t.Errorf("Expected the repository and log file, got %q and %q", spec.WorkingDir, spec.LogFile)
+	}
+	if !slices.Contains(spec.Env, "NON_INTERACTIVE=true") {
+		t.Errorf("Expected prompts to be disabled, got %q", spec.Env)
+	}
+
+	other := NewTestApp()
+	other.Config.RepoPath = "/home/me/other"
+	other.executable = app.executable
+	otherSpec, err := other.serviceSpec(nil)
+	if err != nil {
+		t.Fatalf("serviceSpec failed: %v", err)
+	}
+	if otherSpec.Name == spec.Name {
+		t.Errorf("Expected each repository to get its own service, both are %q", spec.Name)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunSessions lists the current and past sessions of every repository, most
+// recently updated first, marking those whose gitbak process is still running.
+func (a *App) RunSessions(_ context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	states, err := loadSessions(filepath.Dir(a.Config.StateFile))
+	if err != nil {
+		return err
+	}
+
+	if len(states) == 0 {
+		_, _ = fmt.Fprintln(a.Stdout, "No gitbak sessions recorded.")
+		return nil
+	}
+
+	for _, state := range states {
+		running := false
+		if state.EndState == "" {
+			_, running = a.lockHolder(a.lockKey(state.RepoPath))
+		}
+
+		marker := "⚪"
+		if running {
+			marker = "🟢"
+		}
+		outcome := state.Outcome(running)
+		if !state.EndTime.IsZero() {
+			outcome += " " + state.EndTime.Format(time.DateTime)
+		}
+
+		_, _ = fmt.Fprintf(a.Stdout, "%s %s\n", marker, state.RepoPath)
+		_, _ = fmt.Fprintf(a.Stdout, "   branch '%s', %d checkpoint(s), started %s, %s\n",
+			state.Branch, state.CommitsCount, state.StartTime.Format(time.DateTime), outcome)
+	}
+	return nil
+}
+
+// loadSessions reads every session state file in dir together with the session
+// history, newest first. A session found in both is listed once. Unreadable state
+// files are skipped; a missing directory means no sessions.
+func loadSessions(dir string) ([]*session.State, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, gitbakErrors.Wrap(err, "failed to read session directory")
+	}
+
+	var states []*session.State
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		state, err := session.Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		states = append(states, state)
+	}
+
+	history, err := session.LoadHistory(filepath.Join(dir, session.HistoryFileName))
+	if err != nil {
+		return nil, err
+	}
+	states = append(states, history...)
+
+	// The same session appears in both while its state file has not been replaced;
+	// keep whichever copy was written last
+	latest := make(map[string]*session.State)
+	for _, state := range states {
+		key := fmt.Sprintf("%s@%d", state.RepoPath, state.StartTime.UnixNano())
+		if prev, ok := latest[key]; !ok || state.UpdatedAt.After(prev.UpdatedAt) {
+			latest[key] = state
+		}
+	}
+	states = states[:0]
+	for _, state := range latest {
+		states = append(states, state)
+	}
+
+	slices.SortFunc(states, func(a, b *session.State) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	return states, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// coordinateShutdown stops the monitoring session on the first signal by calling stopSession.
+// The final checkpoint and the session summary then run until -shutdown-timeout expires or a
+// second signal arrives, when stopShutdown cancels the shutdown context, so that the session
+// shuts down deterministically rather than being cut off. main cancels the shutdown context
+// itself once it is done. A third signal exits at once; the lock left behind is recovered
+// as stale by the next gitbak.
+func (a *App) coordinateShutdown(signals <-chan os.Signal, stopSession, stopShutdown context.CancelFunc) {
+	sig := <-signals
+	_, _ = fmt.Fprintf(a.Stdout, "\nReceived signal %v, stopping gitbak...\n", sig)
+	stopSession()
+
+	var expired <-chan time.Time
+	if a.Config.ShutdownTimeout > 0 {
+		timer := time.NewTimer(a.Config.ShutdownTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-a.shutdownContext().Done():
+		// Shut down in time
+		return
+	case sig = <-signals:
+		_, _ = fmt.Fprintf(a.Stdout, "\nReceived signal %v again, skipping the rest of the shutdown...\n", sig)
+	case <-expired:
+		_, _ = fmt.Fprintf(a.Stderr, "⚠️  Shutdown took longer than %s (-shutdown-timeout), skipping the rest of it\n", a.Config.ShutdownTimeout)
+	}
+	stopShutdown()
+
+	sig = <-signals
+	_, _ = fmt.Fprintf(a.Stdout, "\nReceived signal %v again, exiting now...\n", sig)
+	a.exit(gitbakErrors.ExitCodeInterrupted)
+}
+
+// shutdownContext returns the context bounding the work done once the session is stopped
+func (a *App) shutdownContext() context.Context {
+	if a.shutdownCtx == nil {
+		return context.Background()
+	}
+	return a.shutdownCtx
+}
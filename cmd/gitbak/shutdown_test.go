@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestCoordinateShutdown tests how signals and -shutdown-timeout end the shutdown
+func TestCoordinateShutdown(t *testing.T) {
+	tests := map[string]struct {
+		timeout time.Duration
+		signals int
+
+		// expectStopped is whether the shutdown is cut short rather than left to finish
+		expectStopped bool
+		expectExit    bool
+	}{
+		"InTime": {
+			timeout: time.Minute,
+			signals: 1,
+		},
+		"TimedOut": {
+			timeout:       10 * time.Millisecond,
+			signals:       1,
+			expectStopped: true,
+		},
+		"SecondSignal": {
+			signals:       2,
+			expectStopped: true,
+		},
+		"ThirdSignal": {
+			signals:       3,
+			expectStopped: true,
+			expectExit:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			app := NewTestApp()
+			app.Stdout = &stdout
+			app.Stderr = &stderr
+			app.Config.ShutdownTimeout = test.timeout
+
+			exitCode := -1
+			app.exit = func(code int) { exitCode = code }
+
+			shutdownCtx, cancelShutdown := context.WithCancel(context.Background())
+			defer cancelShutdown()
+			app.shutdownCtx = shutdownCtx
+
+			sessionStopped := make(chan struct{})
+			shutdownStopped := make(chan struct{})
+			done := make(chan struct{})
+			signals := make(chan os.Signal, 3)
+			go func() {
+				defer close(done)
+				app.coordinateShutdown(signals,
+					func() { close(sessionStopped) },
+					func() { close(shutdownStopped); cancelShutdown() })
+			}()
+
+			for i := 0; i < test.signals; i++ {
+				signals <- syscall.SIGTERM
+			}
+			wait := func(ch <-chan struct{}, what string) {
+				t.Helper()
+				select {
+				case <-ch:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Timed out waiting for %s", what)
+				}
+			}
+
+			wait(sessionStopped, "the session to stop")
+			if !test.expectStopped {
+				// main cancels the shutdown context once the shutdown is done
+				cancelShutdown()
+				wait(done, "coordinateShutdown to return")
+				select {
+				case <-shutdownStopped:
+					t.Error("Expected the shutdown to be left to finish")
+				default:
+				}
+				return
+			}
+
+			wait(shutdownStopped, "the shutdown to be cut short")
+			if test.expectExit {
+				wait(done, "coordinateShutdown to return")
+				if exitCode != gitbakErrors.ExitCodeInterrupted {
+					t.Errorf("Expected exit code %d, got %d", gitbakErrors.ExitCodeInterrupted, exitCode)
+				}
+			}
+			if test.timeout > 0 && test.signals == 1 && !bytes.Contains(stderr.Bytes(), []byte("-shutdown-timeout")) {
+				t.Errorf("Expected a timeout warning, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+// TestRunMakesFinalCheckpoint tests that Run makes a final checkpoint only when stopped by a
+// signal with -commit-on-exit, pushes the pending checkpoints whenever stopped by one, and
+// records the session in the history however it ended
+func TestRunMakesFinalCheckpoint(t *testing.T) {
+	tests := map[string]struct {
+		runErr       error
+		canceled     bool
+		noCommitExit bool
+		expected     bool
+		expectPush   bool
+	}{
+		"Stopped":            {runErr: context.Canceled, canceled: true, expected: true, expectPush: true},
+		"StoppedWithoutFlag": {runErr: context.Canceled, canceled: true, noCommitExit: true, expectPush: true},
+		"Failed":             {runErr: errors.New("too many errors")},
+		"Finished":           {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := NewTestApp()
+			app.Config.RepoPath = t.TempDir()
+			app.Config.CommitOnExit = !test.noCommitExit
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			mockGitbak := &MockGitbaker{RunErr: test.runErr}
+			app.Gitbak = mockGitbak
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if test.canceled {
+				cancel()
+			}
+			defer cancel()
+
+			_ = app.Run(ctx)
+			if mockGitbak.FinalCheckpointCalled != test.expected {
+				t.Errorf("Expected a final checkpoint: %v, got %v", test.expected, mockGitbak.FinalCheckpointCalled)
+			}
+			if mockGitbak.PushPendingCalled != test.expectPush {
+				t.Errorf("Expected the pending checkpoints to be pushed: %v, got %v", test.expectPush, mockGitbak.PushPendingCalled)
+			}
+			if !mockGitbak.HistoryRecorded {
+				t.Error("Expected the session to be recorded in the history")
+			}
+		})
+	}
+}
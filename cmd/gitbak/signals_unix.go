@@ -0,0 +1,20 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals stop a session gracefully: Ctrl+C, gitbak stop, and the terminal
+// being closed
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// listenForStop does nothing here, where gitbak stop reaches the session as SIGTERM
+func listenForStop(chan<- os.Signal) {}
+
+// terminate asks a gitbak process to shut down gracefully
+func terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// shutdownSignals stop a session gracefully. Windows has no SIGHUP: Ctrl+C and Ctrl+Break
+// arrive as os.Interrupt, and closing the console window, logging off or shutting down
+// as SIGTERM.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// stopEventName names the event a session with the given PID waits on for gitbak stop
+func stopEventName(pid int) string {
+	return fmt.Sprintf(`Local\gitbak-stop-%d`, pid)
+}
+
+// listenForStop creates this process's stop event and delivers SIGTERM on signals once
+// gitbak stop sets it, since Windows cannot send a signal to another process
+func listenForStop(signals chan<- os.Signal) {
+	name, err := windows.UTF16PtrFromString(stopEventName(os.Getpid()))
+	if err != nil {
+		return
+	}
+	event, err := windows.CreateEvent(nil, 0, 0, name)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		defer func() { _ = windows.CloseHandle(event) }()
+		if result, err := windows.WaitForSingleObject(event, windows.INFINITE); err == nil && result == windows.WAIT_OBJECT_0 {
+			signals <- syscall.SIGTERM
+		}
+	}()
+}
+
+// terminate asks a gitbak process to shut down gracefully by setting its stop event
+func terminate(process *os.Process) error {
+	name, err := windows.UTF16PtrFromString(stopEventName(process.Pid))
+	if err != nil {
+		return err
+	}
+	event, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, name)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "the process does not accept stop requests")
+	}
+	defer func() { _ = windows.CloseHandle(event) }()
+	return windows.SetEvent(event)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunSquash folds the most recent gitbak session into a single commit on the original branch.
+// The commit message is generated from the session metadata and opened in the editor,
+// unless -message or -yes is set or prompts are disabled.
+func (a *App) RunSquash(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to squash in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	// Holding the lock guarantees no gitbak process is still committing to the session branch
+	if err := a.acquireLock(ctx); err != nil {
+		if gitbakErrors.Is(err, gitbakErrors.ErrAlreadyRunning) {
+			return gitbakErrors.Wrap(err, "stop the running session before squashing it")
+		}
+		return gitbakErrors.Wrap(gitbakErrors.ErrLockAcquisitionFailure, err.Error())
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	summary, err := repo.SummarizeSession(ctx, state)
+	if err != nil {
+		return err
+	}
+
+	summary.Subject = strings.TrimSpace(a.Config.SquashMessage)
+
+	edit := summary.Subject == "" && !a.Config.AssumeYes && !a.Config.NonInteractive
+	if err := repo.SquashSession(ctx, state, summary.Message(), edit); err != nil {
+		return err
+	}
+
+	if err := session.Remove(a.Config.StateFile); err != nil {
+		a.Logger.Warning("Failed to remove session state: %v", err)
+	}
+
+	a.Logger.Success("Squashed %d commit(s) from '%s' into one commit on '%s'", summary.Commits, state.Branch, state.OriginalBranch)
+	a.Logger.StatusMessage("The session branch was kept; delete it with: git branch -D %s", state.Branch)
+	return nil
+}
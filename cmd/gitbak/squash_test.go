@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunSquash tests the squash command against a real repository
+func TestRunSquash(t *testing.T) {
+	tests := map[string]struct {
+		writeState      bool
+		message         string
+		expectedSubject string
+		errorContains   string
+	}{
+		"NoSession": {
+			writeState:    false,
+			errorContains: "no gitbak session to squash",
+		},
+		"SquashesSession": {
+			writeState:      true,
+			expectedSubject: "Squash gitbak session gitbak-session",
+		},
+		"CustomMessage": {
+			writeState:      true,
+			message:         "Add work notes",
+			expectedSubject: "Add work notes",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			withGitRepo(t, func(repoPath string) {
+				out, err := exec.Command("git", "-C", repoPath, "branch", "--show-current").Output()
+				if err != nil {
+					t.Fatalf("Failed to get current branch: %v", err)
+				}
+				originalBranch := strings.TrimSpace(string(out))
+
+				if err := exec.Command("git", "-C", repoPath, "checkout", "-b", "gitbak-session").Run(); err != nil {
+					t.Fatalf("Failed to create session branch: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("work"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+				if err := exec.Command("git", "-C", repoPath, "add", ".").Run(); err != nil {
+					t.Fatalf("Failed to stage file: %v", err)
+				}
+				if err := exec.Command("git", "-C", repoPath, "commit", "-m", "[gitbak] Automatic checkpoint #1").Run(); err != nil {
+					t.Fatalf("Failed to commit: %v", err)
+				}
+
+				stateFile := filepath.Join(t.TempDir(), "state.json")
+				if test.writeState {
+					state := &session.State{
+						RepoPath:       repoPath,
+						Branch:         "gitbak-session",
+						OriginalBranch: originalBranch,
+						CreatedBranch:  true,
+						CommitPrefix:   "[gitbak]",
+					}
+					if err := session.Save(stateFile, state); err != nil {
+						t.Fatalf("Failed to save state: %v", err)
+					}
+				}
+
+				var stdout, stderr bytes.Buffer
+				app := NewTestApp()
+				app = WithMockLocker(app, &MockLocker{})
+				app = WithMockLogger(app, &MockLogger{})
+				app.Stdout = &stdout
+				app.Stderr = &stderr
+				app.Config.RepoPath = repoPath
+				app.Config.StateFile = stateFile
+				app.Config.AssumeYes = test.message == ""
+				app.Config.SquashMessage = test.message
+
+				err = app.RunSquash(context.Background())
+
+				if test.errorContains != "" {
+					if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+						t.Fatalf("Expected error containing %q, got %v", test.errorContains, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("RunSquash failed: %v", err)
+				}
+
+				subject, err := exec.Command("git", "-C", repoPath, "log", "-1", "--format=%s", originalBranch).Output()
+				if err != nil {
+					t.Fatalf("Failed to read squash commit: %v", err)
+				}
+				if strings.TrimSpace(string(subject)) != test.expectedSubject {
+					t.Errorf("Expected squash commit on %s, got subject %q", originalBranch, subject)
+				}
+
+				if _, err := session.Load(stateFile); err == nil {
+					t.Error("Expected session state to be removed after squashing")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// busiestHoursShown is how many of the busiest hours of the day RunStats reports
+const busiestHoursShown = 3
+
+// statsReport is what RunStats reports across the recorded sessions, and the
+// document it prints with -output json
+type statsReport struct {
+	Sessions        int     `json:"sessions"`
+	Checkpoints     int     `json:"checkpoints"`
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// IntervalUtilization is the share of interval checks that made a checkpoint, averaged
+	// over the sessions that checked on an interval, or nil if there were none
+	IntervalUtilization *float64 `json:"interval_utilization,omitempty"`
+
+	// BusiestHours are the hours of the day, in local time, most spent in sessions
+	BusiestHours []statsHour `json:"busiest_hours"`
+
+	// Repositories totals the sessions of each repository, the most recently active first
+	Repositories []statsRepository `json:"repositories"`
+}
+
+// statsHour is the time spent in sessions during one hour of the day
+type statsHour struct {
+	Hour    int     `json:"hour"`
+	Seconds float64 `json:"seconds"`
+}
+
+// statsRepository totals the sessions of one repository in a statsReport
+type statsRepository struct {
+	RepoPath        string    `json:"repo_path"`
+	Sessions        int       `json:"sessions"`
+	Checkpoints     int       `json:"checkpoints"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	LastSession     time.Time `json:"last_session"`
+}
+
+// RunStats summarizes the recorded sessions of every repository: how many there were,
+// the checkpoints they made and the time they ran, how often interval checks found
+// something to checkpoint, the hours of the day most spent in sessions, and totals for
+// each repository. With -output json it prints the summary as a JSON document.
+func (a *App) RunStats(_ context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	states, err := loadSessions(filepath.Dir(a.Config.StateFile))
+	if err != nil {
+		return err
+	}
+
+	report := sessionStats(states, func(state *session.State) bool {
+		if state.EndState != "" {
+			return false
+		}
+		_, running := a.lockHolder(a.lockKey(state.RepoPath))
+		return running
+	}, time.Now())
+
+	if a.Config.Output == "json" {
+		if err := json.NewEncoder(a.Stdout).Encode(report); err != nil {
+			return gitbakErrors.Wrap(err, "failed to print stats")
+		}
+		return nil
+	}
+
+	if report.Sessions == 0 {
+		_, _ = fmt.Fprintln(a.Stdout, "No gitbak sessions recorded.")
+		return nil
+	}
+	a.printStats(report)
+	return nil
+}
+
+// printStats prints report as a summary followed by a table of repositories
+func (a *App) printStats(report statsReport) {
+	_, _ = fmt.Fprintf(a.Stdout, "gitbak stats for %d session(s) in %d repositories\n", report.Sessions, len(report.Repositories))
+	_, _ = fmt.Fprintf(a.Stdout, "  📊 %d checkpoint(s) over %s\n", report.Checkpoints, statsDuration(report.DurationSeconds))
+	if report.IntervalUtilization != nil {
+		_, _ = fmt.Fprintf(a.Stdout, "  ⏱️  Interval utilization: %.0f%% of checks made a checkpoint\n", *report.IntervalUtilization*100)
+	}
+	if len(report.BusiestHours) > 0 {
+		hours := make([]string, 0, len(report.BusiestHours))
+		for _, hour := range report.BusiestHours {
+			hours = append(hours, fmt.Sprintf("%02d:00-%02d:00 (%s)", hour.Hour, (hour.Hour+1)%24, statsDuration(hour.Seconds)))
+		}
+		_, _ = fmt.Fprintf(a.Stdout, "  🕐 Busiest hours: %s\n", strings.Join(hours, ", "))
+	}
+
+	_, _ = fmt.Fprintln(a.Stdout)
+	table := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "REPOSITORY\tSESSIONS\tCHECKPOINTS\tTIME\tLAST SESSION")
+	for _, repo := range report.Repositories {
+		_, _ = fmt.Fprintf(table, "%s\t%d\t%d\t%s\t%s\n", repo.RepoPath, repo.Sessions, repo.Checkpoints,
+			statsDuration(repo.DurationSeconds), repo.LastSession.Format(time.DateTime))
+	}
+	_ = table.Flush()
+}
+
+// sessionStats summarizes states as of now; running tells whether a session's gitbak
+// process is still running, so that its time counts up to now
+func sessionStats(states []*session.State, running func(state *session.State) bool, now time.Time) statsReport {
+	report := statsReport{BusiestHours: []statsHour{}, Repositories: []statsRepository{}}
+	repos := make(map[string]*statsRepository)
+	var hours [24]time.Duration
+	var utilization float64
+	intervalSessions := 0
+
+	for _, state := range states {
+		end := sessionEnd(state, running(state), now)
+		duration := max(end.Sub(state.StartTime), 0)
+
+		report.Sessions++
+		report.Checkpoints += state.CommitsCount
+		report.DurationSeconds += duration.Seconds()
+
+		repo, ok := repos[state.RepoPath]
+		if !ok {
+			repo = &statsRepository{RepoPath: state.RepoPath}
+			repos[state.RepoPath] = repo
+		}
+		repo.Sessions++
+		repo.Checkpoints += state.CommitsCount
+		repo.DurationSeconds += duration.Seconds()
+		if state.StartTime.After(repo.LastSession) {
+			repo.LastSession = state.StartTime
+		}
+
+		// Watch sessions check on file changes, so they have no interval to make use of
+		interval := time.Duration(state.IntervalMinutes * float64(time.Minute))
+		if !state.Watch && interval > 0 && duration >= interval {
+			checks := float64(duration / interval)
+			utilization += min(float64(state.CommitsCount)/checks, 1)
+			intervalSessions++
+		}
+
+		start := state.StartTime.Local()
+		for hour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, time.Local); hour.Before(end); hour = hour.Add(time.Hour) {
+			from, to := maxTime(hour, state.StartTime), minTime(hour.Add(time.Hour), end)
+			hours[hour.Hour()] += to.Sub(from)
+		}
+	}
+
+	if intervalSessions > 0 {
+		average := utilization / float64(intervalSessions)
+		report.IntervalUtilization = &average
+	}
+
+	for hour, spent := range hours {
+		if spent > 0 {
+			report.BusiestHours = append(report.BusiestHours, statsHour{Hour: hour, Seconds: spent.Seconds()})
+		}
+	}
+	slices.SortStableFunc(report.BusiestHours, func(a, b statsHour) int {
+		return cmp.Compare(b.Seconds, a.Seconds)
+	})
+	if len(report.BusiestHours) > busiestHoursShown {
+		report.BusiestHours = report.BusiestHours[:busiestHoursShown]
+	}
+
+	for _, repo := range repos {
+		report.Repositories = append(report.Repositories, *repo)
+	}
+	slices.SortFunc(report.Repositories, func(a, b statsRepository) int {
+		return b.LastSession.Compare(a.LastSession)
+	})
+	return report
+}
+
+// statsDuration formats a number of seconds to the minute, e.g. "2h5m"
+func statsDuration(seconds float64) string {
+	duration := time.Duration(seconds * float64(time.Second)).Round(time.Minute)
+	if duration < time.Minute {
+		return "under a minute"
+	}
+	return strings.TrimSuffix(duration.String(), "0s")
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestSessionStats tests the figures summarized across recorded sessions
+func TestSessionStats(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	now := day.Add(24 * time.Hour)
+	states := []*session.State{
+		{
+			// 10:30-12:00, 6 of 9 interval checks made a checkpoint
+			RepoPath:        "/repos/api",
+			StartTime:       day.Add(10*time.Hour + 30*time.Minute),
+			EndTime:         day.Add(12 * time.Hour),
+			EndState:        session.EndStopped,
+			CommitsCount:    6,
+			IntervalMinutes: 10,
+		},
+		{
+			// 14:00-14:20 with -watch, so it has no interval to count against
+			RepoPath:        "/repos/api",
+			StartTime:       day.Add(14 * time.Hour),
+			EndTime:         day.Add(14*time.Hour + 20*time.Minute),
+			EndState:        session.EndStopped,
+			CommitsCount:    3,
+			IntervalMinutes: 5,
+			Watch:           true,
+		},
+		{
+			// Interrupted at 11:15, when it last wrote its state; every check made a checkpoint
+			RepoPath:        "/repos/web",
+			StartTime:       day.Add(11 * time.Hour),
+			UpdatedAt:       day.Add(11*time.Hour + 15*time.Minute),
+			CommitsCount:    20,
+			IntervalMinutes: 5,
+		},
+		{
+			// Still running since 23:30
+			RepoPath:        "/repos/web",
+			StartTime:       now.Add(-30 * time.Minute),
+			CommitsCount:    1,
+			IntervalMinutes: 5,
+		},
+	}
+
+	running := states[3]
+	report := sessionStats(states, func(state *session.State) bool { return state == running }, now)
+
+	if report.Sessions != 4 || report.Checkpoints != 30 {
+		t.Errorf("Expected 4 sessions and 30 checkpoints, got %d and %d", report.Sessions, report.Checkpoints)
+	}
+	if expected := (155 * time.Minute).Seconds(); report.DurationSeconds != expected {
+		t.Errorf("Expected %v seconds in sessions, got %v", expected, report.DurationSeconds)
+	}
+
+	// (6/9 + 1 + 1/6) / 3, the watch session left out
+	expectedUtilization := (6.0/9 + 1 + 1.0/6) / 3
+	if report.IntervalUtilization == nil || math.Abs(*report.IntervalUtilization-expectedUtilization) > 1e-9 {
+		t.Errorf("Expected an interval utilization of %v, got %v", expectedUtilization, report.IntervalUtilization)
+	}
+
+	expectedHours := []statsHour{{Hour: 11, Seconds: 75 * 60}, {Hour: 10, Seconds: 30 * 60}, {Hour: 23, Seconds: 30 * 60}}
+	if len(report.BusiestHours) != len(expectedHours) {
+		t.Fatalf("Expected busiest hours %v, got %v", expectedHours, report.BusiestHours)
+	}
+	for i, hour := range expectedHours {
+		if report.BusiestHours[i] != hour {
+			t.Errorf("Expected busiest hour #%d to be %v, got %v", i+1, hour, report.BusiestHours[i])
+		}
+	}
+
+	if len(report.Repositories) != 2 {
+		t.Fatalf("Expected 2 repositories, got %v", report.Repositories)
+	}
+	web, api := report.Repositories[0], report.Repositories[1]
+	if web.RepoPath != "/repos/web" || web.Sessions != 2 || web.Checkpoints != 21 || web.DurationSeconds != (45*time.Minute).Seconds() {
+		t.Errorf("Unexpected totals for the most recently active repository: %+v", web)
+	}
+	if api.RepoPath != "/repos/api" || api.Sessions != 2 || api.Checkpoints != 9 || !api.LastSession.Equal(day.Add(14*time.Hour)) {
+		t.Errorf("Unexpected totals for /repos/api: %+v", api)
+	}
+}
+
+// TestRunStats tests the stats command's table and JSON output
+func TestRunStats(t *testing.T) {
+	start := time.Now().Add(-3 * time.Hour)
+
+	tests := map[string]struct {
+		history        []*session.State
+		output         string
+		outputContains []string
+	}{
+		"NoSessions": {
+			outputContains: []string{"No gitbak sessions recorded."},
+		},
+		"Table": {
+			history: []*session.State{
+				{RepoPath: "/repos/api", StartTime: start, EndTime: start.Add(time.Hour), EndState: session.EndStopped, CommitsCount: 4, IntervalMinutes: 30, UpdatedAt: start.Add(time.Hour)},
+				{RepoPath: "/repos/web", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(150 * time.Minute), EndState: session.EndStopped, CommitsCount: 1, IntervalMinutes: 10, UpdatedAt: start.Add(150 * time.Minute)},
+			},
+			outputContains: []string{
+				"gitbak stats for 2 session(s) in 2 repositories",
+				"5 checkpoint(s) over 1h30m",
+				"Interval utilization: 67% of checks made a checkpoint",
+				"Busiest hours:",
+				"REPOSITORY",
+				"/repos/api  1         4            1h0m",
+			},
+		},
+		"JSON": {
+			history: []*session.State{
+				{RepoPath: "/repos/api", StartTime: start, EndTime: start.Add(time.Hour), EndState: session.EndStopped, CommitsCount: 4, UpdatedAt: start.Add(time.Hour)},
+			},
+			output:         "json",
+			outputContains: []string{`"sessions":1`, `"checkpoints":4`, `"duration_seconds":3600`, `"repo_path":"/repos/api"`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, state := range test.history {
+				if err := session.AppendHistory(filepath.Join(dir, session.HistoryFileName), state); err != nil {
+					t.Fatalf("Failed to append history: %v", err)
+				}
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.StateFile = filepath.Join(dir, "gitbak-current.json")
+			app.Config.Output = test.output
+			app.lockHolder = func(string) (int, bool) { return 0, false }
+
+			if err := app.RunStats(context.Background()); err != nil {
+				t.Fatalf("RunStats failed: %v", err)
+			}
+
+			output := stdout.String()
+			for _, want := range test.outputContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got %q", want, output)
+				}
+			}
+			if test.output == "json" && !json.Valid(stdout.Bytes()) {
+				t.Errorf("Expected valid JSON, got %q", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// statusReport is the document RunStatus prints with -output json
+type statusReport struct {
+	RepoPath string         `json:"repo_path"`
+	Running  bool           `json:"running"`
+	PID      int            `json:"pid,omitempty"`
+	Session  *statusSession `json:"session,omitempty"`
+
+	// Disabled and PauseFile report what keeps a running session from checkpointing:
+	// the kill switch, and the path of the pause file if it exists
+	Disabled  bool   `json:"disabled,omitempty"`
+	PauseFile string `json:"pause_file,omitempty"`
+}
+
+// statusSession describes the current or most recent session in a statusReport
+type statusSession struct {
+	Branch            string     `json:"branch"`
+	Outcome           string     `json:"outcome"`
+	StartTime         time.Time  `json:"start_time"`
+	EndTime           *time.Time `json:"end_time,omitempty"`
+	DurationSeconds   float64    `json:"duration_seconds"`
+	Checkpoints       int        `json:"checkpoints"`
+	LastCommitTime    *time.Time `json:"last_commit_time,omitempty"`
+	StorageAddedBytes int64      `json:"storage_added_bytes,omitempty"`
+	NextCheckTime     *time.Time `json:"next_check_time,omitempty"`
+	Watch             bool       `json:"watch,omitempty"`
+}
+
+// RunStatus reports whether gitbak is running for the repository, how many
+// checkpoints the current or most recent session has made, and when the next
+// check is due. It only reads the lock file and session state, so it is safe
+// to run alongside a monitoring session.
+func (a *App) RunStatus(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil && !gitbakErrors.Is(err, session.ErrNoState) {
+		return err
+	}
+
+	pid, running := a.lockHolder(a.lockKey(a.Config.RepoPath))
+	pauseFile := ""
+	if path, err := git.NewRepository(a.Config.RepoPath, a.gitExecutor()).PauseFilePath(ctx); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			pauseFile = path
+		}
+	}
+
+	if a.Config.Output == "json" {
+		report := statusReport{RepoPath: a.Config.RepoPath, Running: running, Disabled: config.IsDisabled(), PauseFile: pauseFile}
+		if running {
+			report.PID = pid
+		}
+		if state != nil {
+			report.Session = describeSession(state, running, time.Now())
+		}
+		if err := json.NewEncoder(a.Stdout).Encode(report); err != nil {
+			return gitbakErrors.Wrap(err, "failed to print status")
+		}
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(a.Stdout, "gitbak status for %s\n", a.Config.RepoPath)
+	if running {
+		_, _ = fmt.Fprintf(a.Stdout, "  🟢 Running (PID %d)\n", pid)
+	} else {
+		_, _ = fmt.Fprintf(a.Stdout, "  ⚪ Not running\n")
+	}
+
+	if state == nil {
+		_, _ = fmt.Fprintf(a.Stdout, "  No session recorded yet\n")
+	} else {
+		a.printSessionStatus(state, running, time.Now())
+	}
+
+	if config.IsDisabled() {
+		_, _ = fmt.Fprintf(a.Stdout, "  ⏸️  %s is set: checkpointing is paused\n", config.DisableEnvVar)
+	}
+	if pauseFile != "" {
+		_, _ = fmt.Fprintf(a.Stdout, "  ⏸️  %s exists: checkpointing is paused until it is removed\n", pauseFile)
+	}
+	return nil
+}
+
+// printSessionStatus describes a recorded session. The next check is only
+// reported for a session that is still running.
+func (a *App) printSessionStatus(state *session.State, running bool, now time.Time) {
+	label := "Last session"
+	if running {
+		label = "Session"
+	}
+	_, _ = fmt.Fprintf(a.Stdout, "  🌿 %s on '%s', started %s\n", label, state.Branch, state.StartTime.Format(time.DateTime))
+
+	if state.LastCommitTime.IsZero() {
+		_, _ = fmt.Fprintf(a.Stdout, "  📊 %d checkpoint(s)\n", state.CommitsCount)
+	} else {
+		_, _ = fmt.Fprintf(a.Stdout, "  📊 %d checkpoint(s), the latest at %s\n",
+			state.CommitsCount, state.LastCommitTime.Format(time.DateTime))
+	}
+
+	if len(state.StorageGrowth) > 0 {
+		_, _ = fmt.Fprintf(a.Stdout, "  💾 Object storage: %s\n", git.FormatSize(state.StorageAdded()))
+	}
+
+	if !running {
+		_, _ = fmt.Fprintf(a.Stdout, "  🏁 %s\n", describeEnd(state))
+		return
+	}
+
+	switch {
+	case state.Watch:
+		_, _ = fmt.Fprintf(a.Stdout, "  ⏱️  Next check: on the next file change\n")
+	case state.IntervalMinutes > 0:
+		next := state.NextCheck()
+		if wait := next.Sub(now); wait > 0 {
+			_, _ = fmt.Fprintf(a.Stdout, "  ⏱️  Next check due at %s (in %s)\n", next.Format(time.TimeOnly), wait.Round(time.Second))
+		} else {
+			_, _ = fmt.Fprintf(a.Stdout, "  ⏱️  Next check due now\n")
+		}
+	}
+}
+
+// describeSession summarizes a recorded session for the JSON status report. The next
+// check is only reported for a session that is still running.
+func describeSession(state *session.State, running bool, now time.Time) *statusSession {
+	end := sessionEnd(state, running, now)
+	described := &statusSession{
+		Branch:            state.Branch,
+		Outcome:           state.Outcome(running),
+		StartTime:         state.StartTime,
+		EndTime:           optionalTime(state.EndTime),
+		DurationSeconds:   end.Sub(state.StartTime).Seconds(),
+		Checkpoints:       state.CommitsCount,
+		LastCommitTime:    optionalTime(state.LastCommitTime),
+		StorageAddedBytes: state.StorageAdded(),
+		Watch:             state.Watch,
+	}
+	if running && !state.Watch && state.IntervalMinutes > 0 {
+		described.NextCheckTime = optionalTime(state.NextCheck())
+	}
+	return described
+}
+
+// sessionEnd returns when a recorded session ended, or now if it is still running
+func sessionEnd(state *session.State, running bool, now time.Time) time.Time {
+	switch {
+	case !state.EndTime.IsZero():
+		return state.EndTime
+	case !running && !state.UpdatedAt.IsZero():
+		// An interrupted session last showed signs of life when it wrote its state
+		return state.UpdatedAt
+	}
+	return now
+}
+
+// describeEnd reports how a session that is no longer running ended
+func describeEnd(state *session.State) string {
+	if state.EndTime.IsZero() {
+		return "Interrupted: gitbak exited without recording the end of the session"
+	}
+	if state.EndState == session.EndFailed {
+		return fmt.Sprintf("Failed at %s after repeated errors (see the log file)", state.EndTime.Format(time.DateTime))
+	}
+	return fmt.Sprintf("Stopped at %s", state.EndTime.Format(time.DateTime))
+}
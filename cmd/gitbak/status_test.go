@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRunStatus tests the status report built from the lock and session state
+func TestRunStatus(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		state          *session.State
+		running        bool
+		disabled       bool
+		pauseFile      bool
+		output         string
+		outputContains []string
+		outputExcludes []string
+	}{
+		"NoSession": {
+			outputContains: []string{"Not running", "No session recorded yet"},
+		},
+		"StoppedSession": {
+			state: &session.State{
+				Branch:            "gitbak-old",
+				StartTime:         now.Add(-time.Hour),
+				LastCommitTime:    now.Add(-30 * time.Minute),
+				CommitsCount:      4,
+				IntervalMinutes:   5,
+				StorageStartBytes: 4096,
+				StorageBytes:      4096 + 3072,
+				StorageGrowth:     []int64{1024, 1024, 1024},
+			},
+			outputContains: []string{"Not running", "Last session on 'gitbak-old'", "4 checkpoint(s), the latest at", "Object storage: +3.0 KiB", "Interrupted"},
+			outputExcludes: []string{"Next check"},
+		},
+		"FailedSession": {
+			state: &session.State{
+				Branch:    "gitbak-failed",
+				StartTime: now.Add(-time.Hour),
+				EndTime:   now.Add(-time.Minute),
+				EndState:  session.EndFailed,
+			},
+			outputContains: []string{"Not running", "Failed at", "after repeated errors"},
+			outputExcludes: []string{"Interrupted"},
+		},
+		"RunningSession": {
+			state: &session.State{
+				Branch:          "gitbak-live",
+				StartTime:       now.Add(-10 * time.Minute),
+				CommitsCount:    2,
+				IntervalMinutes: 5,
+				LastCheckTime:   now.Add(-time.Minute),
+			},
+			running:        true,
+			outputContains: []string{"Running (PID 4242)", "Session on 'gitbak-live'", "2 checkpoint(s)", "Next check due at"},
+		},
+		"WatchSession": {
+			state: &session.State{
+				Branch:          "gitbak-watch",
+				StartTime:       now.Add(-10 * time.Minute),
+				IntervalMinutes: 5,
+				Watch:           true,
+			},
+			running:        true,
+			outputContains: []string{"Next check: on the next file change"},
+		},
+		"KillSwitchEngaged": {
+			running:        true,
+			disabled:       true,
+			outputContains: []string{"Running (PID 4242)", "GITBAK_DISABLE is set"},
+		},
+		"JSON": {
+			state: &session.State{
+				Branch:          "gitbak-live",
+				StartTime:       now.Add(-10 * time.Minute),
+				CommitsCount:    2,
+				IntervalMinutes: 5,
+				LastCheckTime:   now.Add(-time.Minute),
+			},
+			running:        true,
+			output:         "json",
+			outputContains: []string{`"running":true`, `"pid":4242`, `"branch":"gitbak-live"`, `"outcome":"running"`, `"checkpoints":2`, `"next_check_time":`},
+			outputExcludes: []string{"Session on", `"end_time"`},
+		},
+		"JSONStoppedSession": {
+			state: &session.State{
+				Branch:    "gitbak-old",
+				StartTime: now.Add(-time.Hour),
+				EndTime:   now.Add(-30 * time.Minute),
+				EndState:  session.EndStopped,
+			},
+			output:         "json",
+			outputContains: []string{`"running":false`, `"outcome":"stopped"`, `"end_time":`, `"duration_seconds":1800`},
+			outputExcludes: []string{`"pid"`, `"next_check_time"`},
+		},
+		"PauseFile": {
+			running:        true,
+			pauseFile:      true,
+			outputContains: []string{"Running (PID 4242)", "gitbak-pause exists: checkpointing is paused"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.disabled {
+				t.Setenv(config.DisableEnvVar, "true")
+			} else {
+				t.Setenv(config.DisableEnvVar, "")
+			}
+
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+			if test.state != nil {
+				if err := session.Save(stateFile, test.state); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.StateFile = stateFile
+			app.Config.Output = test.output
+			app.lockHolder = func(string) (int, bool) { return 4242, test.running }
+			if test.pauseFile {
+				app.Config.RepoPath = t.TempDir()
+				if out, err := exec.Command("git", "init", "-q", app.Config.RepoPath).CombinedOutput(); err != nil {
+					t.Fatalf("Failed to initialize git repo: %v: %s", err, out)
+				}
+				if err := os.WriteFile(filepath.Join(app.Config.RepoPath, ".git", git.PauseFile), nil, 0644); err != nil {
+					t.Fatalf("Failed to create pause file: %v", err)
+				}
+			}
+
+			if err := app.RunStatus(context.Background()); err != nil {
+				t.Fatalf("RunStatus failed: %v", err)
+			}
+
+			output := stdout.String()
+			for _, want := range test.outputContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got %q", want, output)
+				}
+			}
+			for _, unwanted := range test.outputExcludes {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("Expected output not to contain %q, got %q", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+// TestRunSessions tests listing the recorded sessions of all repositories
+func TestRunSessions(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		states         []*session.State
+		history        []*session.State
+		writeGarbage   bool
+		outputContains []string
+		outputCount    map[string]int
+	}{
+		"NoSessions": {
+			outputContains: []string{"No gitbak sessions recorded."},
+		},
+		"SeveralSessions": {
+			states: []*session.State{
+				{RepoPath: "/repos/running", Branch: "gitbak-a", CommitsCount: 3},
+				{RepoPath: "/repos/stopped", Branch: "gitbak-b", CommitsCount: 1},
+			},
+			writeGarbage: true,
+			outputContains: []string{
+				"🟢 /repos/running", "branch 'gitbak-a', 3 checkpoint(s)",
+				"⚪ /repos/stopped", "branch 'gitbak-b', 1 checkpoint(s)",
+			},
+		},
+		"PastSessions": {
+			states: []*session.State{
+				{RepoPath: "/repos/running", Branch: "gitbak-c", StartTime: start.Add(time.Hour), CommitsCount: 2},
+			},
+			history: []*session.State{
+				{RepoPath: "/repos/running", Branch: "gitbak-a", StartTime: start, EndTime: start.Add(time.Minute), EndState: session.EndStopped},
+				{RepoPath: "/repos/other", Branch: "gitbak-b", StartTime: start, EndTime: start.Add(time.Minute), EndState: session.EndFailed},
+			},
+			outputContains: []string{
+				"🟢 /repos/running", "branch 'gitbak-c', 2 checkpoint(s)", "running",
+				"branch 'gitbak-a', 0 checkpoint(s)", "stopped " + start.Add(time.Minute).Format(time.DateTime),
+				"⚪ /repos/other", "failed",
+			},
+		},
+		"EndedSessionListedOnce": {
+			states: []*session.State{
+				{RepoPath: "/repos/stopped", Branch: "gitbak-d", StartTime: start, EndTime: start.Add(time.Minute), EndState: session.EndStopped},
+			},
+			history: []*session.State{
+				{RepoPath: "/repos/stopped", Branch: "gitbak-d", StartTime: start, EndTime: start.Add(time.Minute), EndState: session.EndStopped},
+			},
+			outputCount: map[string]int{"branch 'gitbak-d'": 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, state := range test.states {
+				if err := session.Save(filepath.Join(dir, fmt.Sprintf("gitbak-%d.json", i)), state); err != nil {
+					t.Fatalf("Failed to save state: %v", err)
+				}
+			}
+			for _, state := range test.history {
+				if err := session.AppendHistory(filepath.Join(dir, session.HistoryFileName), state); err != nil {
+					t.Fatalf("Failed to append history: %v", err)
+				}
+			}
+			if test.writeGarbage {
+				if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+					t.Fatalf("Failed to write broken state: %v", err)
+				}
+			}
+
+			var stdout bytes.Buffer
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, &MockLogger{})
+			app.Gitbak = &MockGitbaker{}
+			app.Stdout = &stdout
+			app.Config.StateFile = filepath.Join(dir, "gitbak-current.json")
+			app.lockHolder = func(repoPath string) (int, bool) { return 1, repoPath == "/repos/running" }
+
+			if err := app.RunSessions(context.Background()); err != nil {
+				t.Fatalf("RunSessions failed: %v", err)
+			}
+
+			output := stdout.String()
+			for _, want := range test.outputContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got %q", want, output)
+				}
+			}
+			for want, count := range test.outputCount {
+				if got := strings.Count(output, want); got != count {
+					t.Errorf("Expected %q %d time(s), got %d in %q", want, count, got, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// stopPollInterval is how often stop checks whether the monitoring process has exited
+const stopPollInterval = 100 * time.Millisecond
+
+// RunStop asks the gitbak process monitoring the repository to shut down gracefully,
+// as if it had been interrupted, and waits for it to release the repository lock.
+// The wait is twice -shutdown-timeout, leaving the process time for its final checkpoint
+// and summary, or unlimited if -shutdown-timeout is 0.
+func (a *App) RunStop(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	pid, running := a.lockHolder(a.lockKey(a.Config.RepoPath))
+	if !running {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrNotRunning, "nothing to stop in %s", a.Config.RepoPath)
+	}
+
+	stopTimeout := 2 * a.Config.ShutdownTimeout
+	stopped, err := a.stopProcess(ctx, pid, stopTimeout)
+	if err != nil {
+		return err
+	}
+	if !stopped {
+		return fmt.Errorf("gitbak (PID %d) did not stop within %s", pid, stopTimeout)
+	}
+	a.Logger.Success("Stopped gitbak (PID %d) in %s", pid, a.Config.RepoPath)
+	return nil
+}
+
+// stopProcess asks the gitbak process pid to shut down gracefully, as if it had been
+// interrupted, and reports whether it released the repository lock within timeout,
+// or at all if timeout is 0
+func (a *App) stopProcess(ctx context.Context, pid int, timeout time.Duration) (bool, error) {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false, gitbakErrors.Wrapf(err, "failed to find gitbak process %d", pid)
+	}
+	if err := terminate(process); err != nil {
+		return false, gitbakErrors.Wrapf(err, "failed to signal gitbak process %d", pid)
+	}
+	return a.waitForRelease(ctx, pid, timeout)
+}
+
+// waitForRelease reports whether the gitbak process pid released the repository lock
+// within timeout, or at all if timeout is 0
+func (a *App) waitForRelease(ctx context.Context, pid int, timeout time.Duration) (bool, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-deadline:
+			return false, nil
+		case <-ticker.C:
+			if holder, stillRunning := a.lockHolder(a.lockKey(a.Config.RepoPath)); !stillRunning || holder != pid {
+				return true, nil
+			}
+		}
+	}
+}
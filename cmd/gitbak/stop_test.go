@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestRunStop tests signalling the process that holds the repository lock
+func TestRunStop(t *testing.T) {
+	tests := map[string]struct {
+		running       bool
+		errorIs       error
+		expectStopped bool
+	}{
+		"NotRunning": {
+			running: false,
+			errorIs: gitbakErrors.ErrNotRunning,
+		},
+		"Running": {
+			running:       true,
+			expectStopped: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// A child process stands in for the monitoring gitbak instance
+			child := exec.Command("sleep", "30")
+			if err := child.Start(); err != nil {
+				t.Fatalf("Failed to start child process: %v", err)
+			}
+			exited := make(chan struct{})
+			go func() {
+				_ = child.Wait()
+				close(exited)
+			}()
+			t.Cleanup(func() {
+				_ = child.Process.Kill()
+				<-exited
+			})
+
+			mockLogger := &MockLogger{}
+			app := NewTestApp()
+			app = WithMockLocker(app, &MockLocker{})
+			app = WithMockLogger(app, mockLogger)
+			app.Gitbak = &MockGitbaker{}
+			app.lockHolder = func(string) (int, bool) {
+				if !test.running {
+					return 0, false
+				}
+				select {
+				case <-exited:
+					return 0, false
+				default:
+					return child.Process.Pid, true
+				}
+			}
+
+			err := app.RunStop(context.Background())
+
+			if test.errorIs != nil {
+				if !gitbakErrors.Is(err, test.errorIs) {
+					t.Fatalf("Expected error %v, got %v", test.errorIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunStop failed: %v", err)
+			}
+
+			select {
+			case <-exited:
+			default:
+				t.Error("Expected the child process to have exited")
+			}
+			if test.expectStopped && !strings.Contains(mockLogger.LastMessage, "Stopped gitbak") {
+				t.Errorf("Expected a stop confirmation, got %q", mockLogger.LastMessage)
+			}
+		})
+	}
+}
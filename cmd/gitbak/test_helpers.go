@@ -16,17 +16,43 @@ type MockGitbaker struct {
 	SummaryCalled bool
 	RunCalled     bool
 	RunErr        error
+	RunPanic      any
 	LastContext   context.Context
 	CommitsCount  int
+
+	FinalCheckpointCalled bool
+	FinalCheckpointErr    error
+
+	PushPendingCalled bool
+	PushPendingErr    error
+
+	HistoryRecorded bool
 }
 
-func (m *MockGitbaker) PrintSummary() {
+func (m *MockGitbaker) PrintSummary(ctx context.Context) {
 	m.SummaryCalled = true
 }
 
+func (m *MockGitbaker) FinalCheckpoint(ctx context.Context) error {
+	m.FinalCheckpointCalled = true
+	return m.FinalCheckpointErr
+}
+
+func (m *MockGitbaker) PushPending(ctx context.Context) error {
+	m.PushPendingCalled = true
+	return m.PushPendingErr
+}
+
+func (m *MockGitbaker) RecordHistory() {
+	m.HistoryRecorded = true
+}
+
 func (m *MockGitbaker) Run(ctx context.Context) error {
 	m.RunCalled = true
 	m.LastContext = ctx
+	if m.RunPanic != nil {
+		panic(m.RunPanic)
+	}
 	// Increment commit count on successful execution
 	if m.RunErr == nil {
 		m.CommitsCount++
@@ -65,6 +91,7 @@ type MockLogger struct {
 	WarningCalled       bool   // Set to true when Warning() is called
 	WarningToUserCalled bool   // Set to true when WarningToUser() is called
 	ErrorCalled         bool   // Set to true when Error() is called
+	TraceCalled         bool   // Set to true when Trace() is called
 	SuccessCalled       bool   // Set to true when Success() is called
 	StatusCalled        bool   // Set to true when StatusMessage() is called
 	LastMessage         string // Contains the most recent message passed to any log method
@@ -92,6 +119,12 @@ func (m *MockLogger) Error(format string, args ...interface{}) {
 	m.LastMessage = fmt.Sprintf(format, args...)
 }
 
+// Trace logs a trace message
+func (m *MockLogger) Trace(format string, args ...interface{}) {
+	m.TraceCalled = true
+	m.LastMessage = fmt.Sprintf(format, args...)
+}
+
 // Enhanced user-facing logging methods
 
 // InfoToUser logs an info message to the user
@@ -254,7 +287,9 @@ func WithMockLogger(app *App, mockLogger *MockLogger) *App {
 // requiring actual Git repositories. The provided function should accept a path string
 // and return a boolean indicating if it's a repository, along with any error.
 func WithIsRepository(app *App, fn func(string) (bool, error)) *App {
-	app.isRepository = fn
+	app.isRepository = func(_ context.Context, path string) (bool, error) {
+		return fn(path)
+	}
 	return app
 }
 
@@ -263,7 +298,7 @@ func WithIsRepository(app *App, fn func(string) (bool, error)) *App {
 // of repository detection without simulating error conditions. The provided function
 // simply returns true for valid repositories and false otherwise.
 func WithSimpleIsRepository(app *App, simpleFn func(string) bool) *App {
-	app.isRepository = func(path string) (bool, error) {
+	app.isRepository = func(_ context.Context, path string) (bool, error) {
 		return simpleFn(path), nil
 	}
 	return app
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// dashboardRefresh is how often the -tui dashboard is redrawn
+const dashboardRefresh = time.Second
+
+// dashboardMaxWidth bounds the length of dashboard lines, so that they don't wrap in a
+// narrow terminal and throw off redrawing them in place
+const dashboardMaxWidth = 100
+
+// dashboard is the live status view shown with -tui. Like the control endpoint, it reads
+// the session state file, which the session rewrites after every check. It is also a
+// logger.Sink, so that the latest message can be shown while console output is muted.
+type dashboard struct {
+	out io.Writer
+
+	// mute silences the logger's console output while the dashboard is drawn, or restores it
+	mute func(muted bool)
+
+	// lastCommit describes the checkpoint at a revision, for its file and line counts
+	lastCommit func(ctx context.Context, rev string) (*git.ReportCommit, error)
+
+	// footer is the last line of every frame
+	footer string
+
+	mu          sync.Mutex
+	lastMessage string
+
+	// lines is how many lines the previous frame took, to be redrawn over
+	lines int
+}
+
+// newDashboard creates a dashboard drawing to out
+func newDashboard(out io.Writer, mute func(muted bool), lastCommit func(ctx context.Context, rev string) (*git.ReportCommit, error)) *dashboard {
+	return &dashboard{out: out, mute: mute, lastCommit: lastCommit, footer: "Press Ctrl+C to stop"}
+}
+
+// Accepts implements logger.Sink. Status messages are banners and summaries, not news.
+func (d *dashboard) Accepts(kind logger.Kind, user bool) bool {
+	return user && kind != logger.KindStatus
+}
+
+// Write implements logger.Sink
+func (d *dashboard) Write(entry logger.Entry) error {
+	message, _, _ := strings.Cut(strings.TrimSpace(entry.Message), "\n")
+	d.mu.Lock()
+	d.lastMessage = message
+	d.mu.Unlock()
+	return nil
+}
+
+// Close implements logger.Sink
+func (d *dashboard) Close() error {
+	return nil
+}
+
+// startDashboard shows the -tui dashboard in place of scrolling log lines, once the session
+// has started, until ctx is done or the returned function is called. Stopping it restores
+// console output, so that the final checkpoint and the session summary are printed as usual.
+func (a *App) startDashboard(ctx context.Context) (stop func()) {
+	if a.dashboard == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.dashboard.run(ctx, a.Config.StateFile, os.Getpid(), a.paused.Load)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// run redraws the dashboard from the state file of the session of the gitbak process pid
+// until ctx is done
+func (d *dashboard) run(ctx context.Context, stateFile string, pid int, paused func() bool) {
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	active := false
+	defer func() {
+		if active {
+			d.mute(false)
+		}
+	}()
+
+	var lastCommitTime time.Time
+	var last *git.ReportCommit
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state, err := session.Load(stateFile)
+		if err != nil {
+			if !gitbakErrors.Is(err, session.ErrNoState) {
+				_ = d.Write(logger.Entry{Message: fmt.Sprintf("Failed to read session state: %v", err)})
+			}
+			continue
+		}
+
+		// Leave the console alone until the session is set up, as it may still prompt
+		if !active {
+			if state.PID != pid || !state.EndTime.IsZero() {
+				continue
+			}
+			active = true
+			d.mute(true)
+		}
+
+		if !state.Stash && !state.Observe && !state.LastCommitTime.Equal(lastCommitTime) {
+			lastCommitTime = state.LastCommitTime
+			rev := state.Branch
+			if state.Refs != "" {
+				// Checkpoints written to refs leave the branch where it is
+				rev = state.LastCheckpoint
+			}
+			if last, err = d.lastCommit(ctx, rev); err != nil {
+				last = nil
+			}
+		}
+		d.draw(d.render(state, last, paused(), time.Now()))
+	}
+}
+
+// render describes the session as the lines of a dashboard frame at now
+func (d *dashboard) render(state *session.State, last *git.ReportCommit, paused bool, now time.Time) []string {
+	lines := []string{
+		fmt.Sprintf("🌿 gitbak on '%s'", state.Branch),
+		fmt.Sprintf("⏱️  Elapsed:      %s", now.Sub(state.StartTime).Round(time.Second)),
+	}
+
+	if state.LastCommitTime.IsZero() {
+		lines = append(lines, fmt.Sprintf("📊 Checkpoints:  %d", state.CommitsCount))
+	} else {
+		lines = append(lines, fmt.Sprintf("📊 Checkpoints:  %d, the latest %s ago",
+			state.CommitsCount, now.Sub(state.LastCommitTime).Round(time.Second)))
+	}
+
+	switch {
+	case state.Stash, state.Observe:
+		// Snapshots record the whole working tree, and journaled changes are in the journal,
+		// so there is no diff worth showing
+	case last != nil && !state.LastCommitTime.IsZero():
+		lines = append(lines, fmt.Sprintf("📝 Last commit:  %d file(s), +%d -%d", last.Files, last.Insertions, last.Deletions))
+	default:
+		lines = append(lines, "📝 Last commit:  none yet")
+	}
+
+	switch {
+	case paused:
+		lines = append(lines, "⏸️  Next check:   paused")
+	case state.Watch:
+		lines = append(lines, "⏭️  Next check:   on the next file change")
+	case state.IntervalMinutes > 0:
+		if wait := state.NextCheck().Sub(now); wait > 0 {
+			lines = append(lines, fmt.Sprintf("⏭️  Next check:   in %s", wait.Round(time.Second)))
+		} else {
+			lines = append(lines, "⏭️  Next check:   due now")
+		}
+	}
+
+	if state.ConsecutiveErrors > 0 {
+		lines = append(lines, fmt.Sprintf("❌ Errors:       %d in a row: %s", state.ConsecutiveErrors, state.LastError))
+	} else {
+		lines = append(lines, "✅ Errors:       none")
+	}
+
+	d.mu.Lock()
+	if d.lastMessage != "" {
+		lines = append(lines, "💬 "+d.lastMessage)
+	}
+	d.mu.Unlock()
+
+	return append(lines, "", d.footer)
+}
+
+// draw writes a frame over the previous one
+func (d *dashboard) draw(lines []string) {
+	var b strings.Builder
+	if d.lines > 0 {
+		// Move back up to the first line of the previous frame
+		_, _ = fmt.Fprintf(&b, "\033[%dA", d.lines)
+	}
+	// Clear the previous frame, which may have been longer
+	b.WriteString("\r\033[J")
+	for _, line := range lines {
+		b.WriteString(truncateLine(line, dashboardMaxWidth))
+		b.WriteString("\n")
+	}
+	d.lines = len(lines)
+	_, _ = io.WriteString(d.out, b.String())
+}
+
+// truncateLine shortens line to at most width characters, marking the cut with an ellipsis
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
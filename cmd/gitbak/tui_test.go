@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestDashboardRender tests what the -tui dashboard shows about a session
+func TestDashboardRender(t *testing.T) {
+	now := time.Date(2025, 4, 14, 11, 0, 0, 0, time.UTC)
+	running := func() *session.State {
+		return &session.State{
+			Branch:          "gitbak-dash",
+			StartTime:       now.Add(-time.Hour),
+			CommitsCount:    3,
+			LastCommitTime:  now.Add(-2 * time.Minute),
+			LastCheckTime:   now.Add(-time.Minute),
+			IntervalMinutes: 5,
+		}
+	}
+
+	tests := map[string]struct {
+		state       func() *session.State
+		last        *git.ReportCommit
+		paused      bool
+		lastMessage string
+		expected    []string
+		unexpected  []string
+	}{
+		"Running": {
+			state:       running,
+			last:        &git.ReportCommit{Files: 2, Insertions: 10, Deletions: 4},
+			lastMessage: "Commit #3 created at 10:58:00",
+			expected: []string{
+				"gitbak on 'gitbak-dash'",
+				"Elapsed:      1h0m0s",
+				"Checkpoints:  3, the latest 2m0s ago",
+				"Last commit:  2 file(s), +10 -4",
+				"Next check:   in 4m0s",
+				"Errors:       none",
+				"Commit #3 created at 10:58:00",
+			},
+		},
+		"Failing": {
+			state: func() *session.State {
+				state := running()
+				state.ConsecutiveErrors = 2
+				state.LastError = "index.lock exists"
+				state.LastCheckTime = now.Add(-10 * time.Minute)
+				return state
+			},
+			expected: []string{"Errors:       2 in a row: index.lock exists", "Next check:   due now"},
+		},
+		"NoCommitsYet": {
+			state: func() *session.State {
+				state := running()
+				state.CommitsCount = 0
+				state.LastCommitTime = time.Time{}
+				return state
+			},
+			expected:   []string{"Checkpoints:  0\n", "Last commit:  none yet"},
+			unexpected: []string{"latest"},
+		},
+		"Paused": {
+			state:    running,
+			paused:   true,
+			expected: []string{"Next check:   paused"},
+		},
+		"Watch": {
+			state: func() *session.State {
+				state := running()
+				state.Watch = true
+				return state
+			},
+			expected: []string{"Next check:   on the next file change"},
+		},
+		"Stash": {
+			state: func() *session.State {
+				state := running()
+				state.Stash = true
+				return state
+			},
+			unexpected: []string{"Last commit"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := newDashboard(&bytes.Buffer{}, func(bool) {}, nil)
+			if test.lastMessage != "" {
+				_ = d.Write(logger.Entry{Kind: logger.KindSuccess, Message: test.lastMessage, User: true})
+			}
+
+			frame := strings.Join(d.render(test.state(), test.last, test.paused, now), "\n") + "\n"
+			for _, want := range test.expected {
+				if !strings.Contains(frame, want) {
+					t.Errorf("Expected dashboard to contain %q, got:\n%s", want, frame)
+				}
+			}
+			for _, unwanted := range test.unexpected {
+				if strings.Contains(frame, unwanted) {
+					t.Errorf("Expected dashboard not to contain %q, got:\n%s", unwanted, frame)
+				}
+			}
+		})
+	}
+}
+
+// TestDashboardDraw tests that each frame is drawn over the previous one
+func TestDashboardDraw(t *testing.T) {
+	var out bytes.Buffer
+	d := newDashboard(&out, func(bool) {}, nil)
+
+	d.draw([]string{"one", "two", strings.Repeat("x", dashboardMaxWidth+10)})
+	if strings.Contains(out.String(), "\033[3A") {
+		t.Errorf("Expected the first frame not to move the cursor up, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), strings.Repeat("x", dashboardMaxWidth-1)+"…\n") {
+		t.Errorf("Expected long lines to be truncated, got %q", out.String())
+	}
+
+	out.Reset()
+	d.draw([]string{"three"})
+	if out.String() != "\033[3A\r\033[Jthree\n" {
+		t.Errorf("Expected the second frame to replace the first, got %q", out.String())
+	}
+}
+
+// TestDashboardAccepts tests which messages the dashboard shows as the latest message
+func TestDashboardAccepts(t *testing.T) {
+	d := newDashboard(&bytes.Buffer{}, func(bool) {}, nil)
+
+	tests := map[string]struct {
+		kind     logger.Kind
+		user     bool
+		expected bool
+	}{
+		"Success":  {kind: logger.KindSuccess, user: true, expected: true},
+		"Warning":  {kind: logger.KindWarning, user: true, expected: true},
+		"Status":   {kind: logger.KindStatus, user: true},
+		"DebugLog": {kind: logger.KindInfo},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := d.Accepts(test.kind, test.user); got != test.expected {
+				t.Errorf("Expected Accepts to return %v, got %v", test.expected, got)
+			}
+		})
+	}
+
+	_ = d.Write(logger.Entry{Message: "  first line\nsecond line"})
+	if d.lastMessage != "first line" {
+		t.Errorf("Expected only the first line to be kept, got %q", d.lastMessage)
+	}
+}
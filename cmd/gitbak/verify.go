@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RunVerify checks that the history of the most recent session has not been rewritten
+// since its checkpoints were made, using the integrity chain kept in the session state.
+func (a *App) RunVerify(ctx context.Context) error {
+	if err := a.Initialize(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := a.Close(); err != nil {
+			_, _ = fmt.Fprintf(a.Stderr, "❌ Error during cleanup: %v\n", err)
+		}
+	}()
+
+	if err := a.checkRequiredCommands(); err != nil {
+		return err
+	}
+
+	state, err := session.Load(a.Config.StateFile)
+	if err != nil {
+		if gitbakErrors.Is(err, session.ErrNoState) {
+			return gitbakErrors.Wrapf(err, "no gitbak session to verify in %s", a.Config.RepoPath)
+		}
+		return err
+	}
+
+	repo := git.NewRepository(a.Config.RepoPath, a.gitExecutor())
+	verified, err := repo.VerifySession(ctx, state)
+	if err != nil {
+		if gitbakErrors.Is(err, gitbakErrors.ErrIntegrityViolation) {
+			a.Logger.WarningToUser("%d of %d checkpoint(s) verified before the first mismatch", verified, len(state.Chain))
+		}
+		return err
+	}
+
+	a.Logger.Success("All %d checkpoint(s) on '%s' match the session's integrity chain (head %s)",
+		verified, state.Branch, state.ChainHead())
+	return nil
+}
@@ -0,0 +1,31 @@
+// Package main implements gitbakctl, a small client for a running gitbak session
+//
+// gitbakctl talks to the control endpoint a session serves with gitbak -listen, to read
+// its status or to pause, resume, check right away or stop it. It does what
+// 'gitbak control' does, as a separate binary that leaves out the monitoring code, so
+// that it starts quickly and can be called freely from git hooks, tmux status lines,
+// editor plugins and CI scripts. It is built for the same platforms as gitbak, and
+// works on Windows, where sessions cannot be signalled.
+//
+// # Usage
+//
+//	gitbakctl status           # Print the session's status document
+//	gitbakctl pause            # Skip checks until resumed
+//	gitbakctl resume           # Resume checking
+//	gitbakctl commit-now       # Check for changes immediately, even while paused
+//	gitbakctl stop             # Stop the session, with its final checkpoint
+//
+// Every action prints the status document the session answers with, as JSON.
+//
+// # Finding the Session
+//
+// The session is the one monitoring the current directory, or the repository given with
+// -repo (or REPO_PATH). Its endpoint is read from the session's state, where gitbak
+// records the address it listens on; -listen (or LISTEN_ADDR) gives the address
+// directly instead, such as 127.0.0.1:7373 or unix:/tmp/gitbak.sock.
+//
+// # Exit Codes
+//
+// gitbakctl exits with the codes gitbak uses: 0 on success, and the "not running" code
+// when no session answers on the endpoint, so scripts can tell the two apart.
+package main
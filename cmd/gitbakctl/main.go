@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	"github.com/bashhack/gitbak/pkg/control"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// Version information - injected at build time
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run performs the action named in args on the running session and returns the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gitbakctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	repoPath := fs.String("repo", os.Getenv("REPO_PATH"), "Repository whose session to control (default: the current directory)")
+	addr := fs.String("listen", os.Getenv("LISTEN_ADDR"), "Control endpoint of the session, as given to gitbak -listen (default: the one the session recorded)")
+	showVersion := fs.Bool("version", false, "Print version information and exit")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: gitbakctl [flags] <%s>\n\n", strings.Join(control.Actions, "|"))
+		_, _ = fmt.Fprintf(stderr, "Controls the gitbak session running in a repository through its control endpoint (gitbak -listen).\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return gitbakErrors.ExitCodeInvalidConfiguration
+	}
+
+	if *showVersion {
+		_, _ = fmt.Fprintf(stdout, "gitbakctl %s (%s) built on %s\n", version, commit, date)
+		return 0
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return gitbakErrors.ExitCodeInvalidConfiguration
+	}
+
+	body, err := call(ctx, *repoPath, *addr, fs.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "❌ Error: %v\n", err)
+		return gitbakErrors.ExitCode(err)
+	}
+	_, _ = stdout.Write(body)
+	return 0
+}
+
+// call performs action on the session serving addr or, if addr is empty, on the one
+// whose state records the endpoint of the session monitoring repoPath
+func call(ctx context.Context, repoPath, addr, action string) ([]byte, error) {
+	if addr == "" {
+		var err error
+		if addr, err = recordedAddr(repoPath); err != nil {
+			return nil, err
+		}
+	}
+	return control.Call(ctx, addr, action)
+}
+
+// recordedAddr returns the control endpoint recorded in the state of the session
+// monitoring repoPath, the current directory if empty
+func recordedAddr(repoPath string) (string, error) {
+	if repoPath == "" {
+		var err error
+		if repoPath, err = os.Getwd(); err != nil {
+			return "", gitbakErrors.Wrap(err, "failed to get current directory")
+		}
+	}
+	absRepoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", gitbakErrors.Wrap(err, "failed to resolve absolute path")
+	}
+
+	state, err := session.Load(config.DefaultStateFile(absRepoPath))
+	if gitbakErrors.Is(err, session.ErrNoState) {
+		return "", gitbakErrors.Wrapf(gitbakErrors.ErrNotRunning, "no gitbak session recorded for %s", absRepoPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	if state.ControlAddr == "" {
+		return "", gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration,
+			"the session serves no control endpoint; start it with -listen, or pass its address with -listen here")
+	}
+	return state.ControlAddr, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRun tests that actions reach the session's endpoint, found from its flag or its state
+func TestRun(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"running":true}` + "\n"))
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	// A repository whose session recorded the server's endpoint, and one with no session
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("LOCALAPPDATA", dataHome)
+	t.Setenv("REPO_PATH", "")
+	t.Setenv("LISTEN_ADDR", "")
+	recorded := filepath.Join(t.TempDir(), "recorded")
+	if err := session.Save(config.DefaultStateFile(recorded), &session.State{RepoPath: recorded, ControlAddr: addr}); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	unrecorded := filepath.Join(t.TempDir(), "unrecorded")
+
+	tests := map[string]struct {
+		args           []string
+		expectCode     int
+		expectRequest  string
+		expectOutput   string
+		expectErrorOut string
+	}{
+		"StatusFromFlag": {
+			args:          []string{"-listen", addr, "status"},
+			expectRequest: "GET /status",
+			expectOutput:  `{"running":true}`,
+		},
+		"PauseFromState": {
+			args:          []string{"-repo", recorded, "pause"},
+			expectRequest: "POST /pause",
+			expectOutput:  `{"running":true}`,
+		},
+		"NoSession": {
+			args:           []string{"-repo", unrecorded, "status"},
+			expectCode:     gitbakErrors.ExitCode(gitbakErrors.ErrNotRunning),
+			expectErrorOut: "no gitbak session recorded",
+		},
+		"UnknownAction": {
+			args:       []string{"-listen", addr, "restart"},
+			expectCode: gitbakErrors.ExitCodeInvalidConfiguration,
+		},
+		"MissingAction": {
+			args:           []string{"-listen", addr},
+			expectCode:     gitbakErrors.ExitCodeInvalidConfiguration,
+			expectErrorOut: "Usage: gitbakctl",
+		},
+		"Version": {
+			args:         []string{"-version"},
+			expectOutput: "gitbakctl dev",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests = nil
+			var stdout, stderr bytes.Buffer
+
+			code := run(context.Background(), test.args, &stdout, &stderr)
+			if code != test.expectCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", test.expectCode, code, stderr.String())
+			}
+			if test.expectRequest != "" && (len(requests) != 1 || requests[0] != test.expectRequest) {
+				t.Errorf("Expected the request %q, got %v", test.expectRequest, requests)
+			}
+			if test.expectRequest == "" && len(requests) > 0 {
+				t.Errorf("Expected no request, got %v", requests)
+			}
+			if !strings.Contains(stdout.String(), test.expectOutput) {
+				t.Errorf("Expected output containing %q, got %q", test.expectOutput, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), test.expectErrorOut) {
+				t.Errorf("Expected error output containing %q, got %q", test.expectErrorOut, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// unsafeRefChars matches what git does not allow in branch names
+var unsafeRefChars = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]+|\.\.+|@\{`)
+
+// branchNameData holds the values a branch template can use
+type branchNameData struct {
+	// User is the login name of the user running gitbak
+	User string
+
+	// Date and Time are when the session started, as 20060102 and 150405
+	Date string
+	Time string
+
+	// Timestamp is Date and Time joined by a dash, as in gitbak's default branch names
+	Timestamp string
+
+	// Repo is the name of the repository's directory
+	Repo string
+
+	config *Config
+}
+
+// BaseBranch returns the branch checked out when the session started, or "detached"
+// if there is none. It is a method so that git is only asked when a template uses it.
+func (d branchNameData) BaseBranch() (string, error) {
+	branch, err := d.config.getCurrentBranchName()
+	if err != nil {
+		return "", err
+	}
+	if branch == "" {
+		return "detached", nil
+	}
+	return refSafe(branch), nil
+}
+
+// templateBranchName names the session branch from BranchTemplate for a session starting at now
+func (c *Config) templateBranchName(now time.Time) (string, error) {
+	text := c.BranchTemplate
+	if text == "" {
+		text = DefaultBranchTemplate
+	}
+	tmpl, err := template.New("branch").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := branchNameData{
+		User:      refSafe(strings.ReplaceAll(currentUser(), "/", "-")),
+		Date:      now.Format("20060102"),
+		Time:      now.Format("150405"),
+		Timestamp: now.Format("20060102-150405"),
+		Repo:      refSafe(filepath.Base(c.RepoPath)),
+		config:    c,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	name := buf.String()
+	if err := checkBranchName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// currentUser returns the login name of the user running gitbak, or "user" if it is unknown
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Windows names users as DOMAIN\name
+		return u.Username[strings.LastIndex(u.Username, `\`)+1:]
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "user"
+}
+
+// refSafe replaces what git does not allow in branch names with dashes,
+// so that template values such as user names always make a valid name
+func refSafe(value string) string {
+	return unsafeRefChars.ReplaceAllString(value, "-")
+}
+
+// checkBranchName returns an error if git does not allow name as a branch name,
+// following the rules of git check-ref-format
+func checkBranchName(name string) error {
+	invalid := name == "" || name == "@" || strings.HasPrefix(name, "-") ||
+		unsafeRefChars.MatchString(name) || strings.Contains(name, "//") ||
+		strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".")
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			invalid = true
+		}
+	}
+	if invalid {
+		return fmt.Errorf("%q is not a valid branch name", name)
+	}
+	return nil
+}
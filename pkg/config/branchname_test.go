@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTemplateBranchName tests the branch names templates make
+func TestTemplateBranchName(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := map[string]struct {
+		template    string
+		repoPath    string
+		expect      string
+		expectError bool
+	}{
+		"Default":           {expect: "gitbak-20250102-150405"},
+		"Namespaced":        {template: "backup/{{.Date}}/{{.Time}}", expect: "backup/20250102/150405"},
+		"Repo":              {template: "gitbak-{{.Repo}}-{{.Timestamp}}", repoPath: "/src/my project", expect: "gitbak-my-project-20250102-150405"},
+		"ParseError":        {template: "gitbak-{{.Date", expectError: true},
+		"UnknownValue":      {template: "gitbak-{{.Branch}}", expectError: true},
+		"Empty":             {template: "{{if false}}x{{end}}", expectError: true},
+		"DoubleDot":         {template: "gitbak..{{.Date}}", expectError: true},
+		"LeadingDash":       {template: "-{{.Date}}", expectError: true},
+		"TrailingSlash":     {template: "gitbak/{{.Date}}/", expectError: true},
+		"HiddenComponent":   {template: "gitbak/.{{.Date}}", expectError: true},
+		"LockComponent":     {template: "gitbak/{{.Date}}.lock/x", expectError: true},
+		"SpaceInTemplate":   {template: "gitbak {{.Date}}", expectError: true},
+		"ReflogSyntax":      {template: "gitbak@{{\"{\"}}1}", expectError: true},
+		"UnsafeCharsInText": {template: "gitbak:{{.Date}}", expectError: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New()
+			if test.template != "" {
+				c.BranchTemplate = test.template
+			}
+			c.RepoPath = test.repoPath
+
+			branch, err := c.templateBranchName(now)
+			if (err != nil) != test.expectError {
+				t.Fatalf("Expected error: %v, got %v (branch %q)", test.expectError, err, branch)
+			}
+			if branch != test.expect {
+				t.Errorf("Expected branch %q, got %q", test.expect, branch)
+			}
+		})
+	}
+}
+
+// TestRefSafe tests that template values are made safe for branch names
+func TestRefSafe(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		value  string
+		expect string
+	}{
+		"Plain":        {value: "alice", expect: "alice"},
+		"Spaces":       {value: "Alice  Example", expect: "Alice-Example"},
+		"Backslash":    {value: `CORP\alice`, expect: "CORP-alice"},
+		"DoubleDot":    {value: "a..b", expect: "a-b"},
+		"ReflogSyntax": {value: "main@{1}", expect: "main-1}"},
+		"Slash":        {value: "feature/login", expect: "feature/login"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if got := refSafe(test.value); got != test.expect {
+				t.Errorf("Expected %q for %q, got %q", test.expect, test.value, got)
+			}
+		})
+	}
+}
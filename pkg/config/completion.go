@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// CompletionShells lists the shells PrintCompletion generates scripts for
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// CompletionCommand describes a subcommand to shell completion
+type CompletionCommand struct {
+	Name    string
+	Summary string
+
+	// Args lists the values the command's arguments take, and PathArgs marks commands
+	// whose arguments are file or directory paths
+	Args     []string
+	PathArgs bool
+}
+
+// completionFlag is a documented flag as shell completion offers it
+type completionFlag struct {
+	name   string
+	usage  string
+	value  bool
+	values []string
+	path   bool
+}
+
+// PrintCompletion writes a completion script for shell to w, covering the given
+// subcommands and every documented flag. The script is generated from the flag
+// definitions, so it can't fall behind them.
+func (c *Config) PrintCompletion(w io.Writer, shell string, commands []CompletionCommand) error {
+	fs := flag.NewFlagSet("gitbak", flag.ContinueOnError)
+	c.SetupFlags(fs)
+
+	var flags []completionFlag
+	for _, d := range flagDocs {
+		f := fs.Lookup(d.name)
+		if f == nil {
+			continue
+		}
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:   d.name,
+			usage:  f.Usage,
+			value:  !ok || !boolFlag.IsBoolFlag(),
+			values: d.values,
+			path:   d.path,
+		})
+	}
+
+	var b bytes.Buffer
+	switch shell {
+	case "bash":
+		writeBashCompletion(&b, commands, flags)
+	case "zsh":
+		writeZshCompletion(&b, commands, flags)
+	case "fish":
+		writeFishCompletion(&b, commands, flags)
+	default:
+		err := fmt.Errorf("unsupported shell: %q (must be one of %s)", shell, strings.Join(CompletionShells, ", "))
+		return gitbakErrors.NewConfigError("shell", shell, err)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeBashCompletion writes a script for bash's programmable completion (complete -F)
+func writeBashCompletion(b *bytes.Buffer, commands []CompletionCommand, flags []completionFlag) {
+	var names, flagNames, pathFlags, valueFlags []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	for _, f := range flags {
+		flagNames = append(flagNames, "-"+f.name)
+		switch {
+		case f.path:
+			pathFlags = append(pathFlags, "-"+f.name)
+		case f.value && len(f.values) == 0:
+			valueFlags = append(valueFlags, "-"+f.name)
+		}
+	}
+
+	b.WriteString("# bash completion for gitbak.\n")
+	b.WriteString("# Generated by 'gitbak completion bash'; load it with: source <(gitbak completion bash)\n\n")
+	b.WriteString("_gitbak() {\n")
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\n")
+
+	b.WriteString("\tcase \"$prev\" in\n")
+	for _, f := range flags {
+		if len(f.values) > 0 {
+			fmt.Fprintf(b, "\t-%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\t\t;;\n", f.name, strings.Join(f.values, " "))
+		}
+	}
+	if len(pathFlags) > 0 {
+		fmt.Fprintf(b, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn\n\t\t;;\n", strings.Join(pathFlags, " | "))
+	}
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(b, "\t%s)\n\t\treturn\n\t\t;;\n", strings.Join(valueFlags, " | "))
+	}
+	b.WriteString("\tesac\n\n")
+
+	fmt.Fprintf(b, "\tif [[ \"$cur\" == -* ]]; then\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\tfi\n\n", strings.Join(flagNames, " "))
+	fmt.Fprintf(b, "\tif [[ $COMP_CWORD -eq 1 ]]; then\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\tfi\n\n", strings.Join(names, " "))
+
+	b.WriteString("\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, cmd := range commands {
+		switch {
+		case len(cmd.Args) > 0:
+			fmt.Fprintf(b, "\t%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\t;;\n", cmd.Name, strings.Join(cmd.Args, " "))
+		case cmd.PathArgs:
+			fmt.Fprintf(b, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\t;;\n", cmd.Name)
+		}
+	}
+	b.WriteString("\tesac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("complete -F _gitbak gitbak\n")
+}
+
+// writeZshCompletion writes a completion function for zsh's completion system (compinit)
+func writeZshCompletion(b *bytes.Buffer, commands []CompletionCommand, flags []completionFlag) {
+	b.WriteString("#compdef gitbak\n")
+	b.WriteString("# zsh completion for gitbak.\n")
+	b.WriteString("# Generated by 'gitbak completion zsh'; save it as _gitbak in a directory on $fpath,\n")
+	b.WriteString("# or load it with: source <(gitbak completion zsh)\n\n")
+	b.WriteString("_gitbak() {\n")
+
+	b.WriteString("\tlocal -a commands\n\tcommands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(b, "\t\t%s\n", zshQuote(cmd.Name+":"+cmd.Summary))
+	}
+	b.WriteString("\t)\n\n")
+
+	b.WriteString("\tlocal -a flags\n\tflags=(\n")
+	for _, f := range flags {
+		spec := "-" + f.name + "[" + zshBracketEscape(f.usage) + "]"
+		switch {
+		case len(f.values) > 0:
+			spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+		case f.path:
+			spec += ":" + f.name + ":_files"
+		case f.value:
+			spec += ":" + f.name + ": "
+		}
+		fmt.Fprintf(b, "\t\t%s\n", zshQuote(spec))
+	}
+	b.WriteString("\t)\n\n")
+
+	b.WriteString("\tif (( CURRENT == 2 )) && [[ $words[CURRENT] != -* ]]; then\n")
+	b.WriteString("\t\t_describe -t commands 'gitbak command' commands\n\t\treturn\n\tfi\n\n")
+
+	b.WriteString("\tcase $words[2] in\n")
+	for _, cmd := range commands {
+		switch {
+		case len(cmd.Args) > 0:
+			fmt.Fprintf(b, "\t%s)\n\t\t_arguments $flags %s\n\t\t;;\n", cmd.Name, zshQuote("*:argument:("+strings.Join(cmd.Args, " ")+")"))
+		case cmd.PathArgs:
+			fmt.Fprintf(b, "\t%s)\n\t\t_arguments $flags '*:path:_files'\n\t\t;;\n", cmd.Name)
+		}
+	}
+	b.WriteString("\t*)\n\t\t_arguments $flags\n\t\t;;\n")
+	b.WriteString("\tesac\n")
+	b.WriteString("}\n\n")
+
+	// Autoloaded from $fpath the function is called directly; sourced, it is registered
+	b.WriteString("if [[ \"$funcstack[1]\" == \"_gitbak\" ]]; then\n\t_gitbak \"$@\"\nelse\n\tcompdef _gitbak gitbak\nfi\n")
+}
+
+// writeFishCompletion writes completions for fish's complete builtin
+func writeFishCompletion(b *bytes.Buffer, commands []CompletionCommand, flags []completionFlag) {
+	b.WriteString("# fish completion for gitbak.\n")
+	b.WriteString("# Generated by 'gitbak completion fish'; save it as ~/.config/fish/completions/gitbak.fish,\n")
+	b.WriteString("# or load it with: gitbak completion fish | source\n\n")
+	b.WriteString("complete -c gitbak -f\n\n")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(b, "complete -c gitbak -n __fish_use_subcommand -a %s -d %s\n", cmd.Name, fishQuote(cmd.Summary))
+	}
+	for _, cmd := range commands {
+		condition := fishQuote("__fish_seen_subcommand_from " + cmd.Name)
+		switch {
+		case len(cmd.Args) > 0:
+			fmt.Fprintf(b, "complete -c gitbak -n %s -a %s\n", condition, fishQuote(strings.Join(cmd.Args, " ")))
+		case cmd.PathArgs:
+			fmt.Fprintf(b, "complete -c gitbak -n %s -F\n", condition)
+		}
+	}
+	b.WriteString("\n")
+
+	// Go flags take a single dash, which fish calls old-style options (-o)
+	for _, f := range flags {
+		line := "complete -c gitbak -o " + f.name
+		switch {
+		case len(f.values) > 0:
+			line += " -x -a " + fishQuote(strings.Join(f.values, " "))
+		case f.path:
+			line += " -r -F"
+		case f.value:
+			line += " -x"
+		}
+		fmt.Fprintf(b, "%s -d %s\n", line, fishQuote(f.usage))
+	}
+}
+
+// zshQuote quotes s as a single-quoted zsh word
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshBracketEscape escapes s for the [description] of an _arguments option spec
+func zshBracketEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// fishQuote quotes s as a single-quoted fish word
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
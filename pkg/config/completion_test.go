@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// testCompletionCommands stands in for the subcommands defined by the gitbak command
+var testCompletionCommands = []CompletionCommand{
+	{Name: "completion", Summary: "Print a shell completion script", Args: CompletionShells},
+	{Name: "ignores", Summary: "Explain which rule excludes a path", PathArgs: true},
+	{Name: "status", Summary: "Show the session's status"},
+}
+
+// TestPrintCompletion tests that every shell's script covers the commands and documented flags
+func TestPrintCompletion(t *testing.T) {
+	tests := map[string]struct {
+		// flagForm renders a flag as the script lists it
+		flagForm func(name string) string
+		expected []string
+	}{
+		"bash": {
+			flagForm: func(name string) string { return "-" + name },
+			expected: []string{"complete -F _gitbak gitbak", `"initial-commit root-checkpoint fail"`, "-repo | -git-dir | -work-tree | -journal | -log-file | -summary-file)"},
+		},
+		"zsh": {
+			flagForm: func(name string) string { return "'-" + name + "[" },
+			expected: []string{"#compdef gitbak", "'-mode[", ":mode:(branch stash refs observe)'", ":repo:_files'", "'*:argument:(bash zsh fish)'"},
+		},
+		"fish": {
+			flagForm: func(name string) string { return "-o " + name + " " },
+			expected: []string{"complete -c gitbak -o mode -x -a 'branch stash refs observe'", "complete -c gitbak -o repo -r -F", "-n '__fish_seen_subcommand_from ignores' -F"},
+		},
+	}
+
+	for shell, test := range tests {
+		t.Run(shell, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := New().PrintCompletion(&out, shell, testCompletionCommands); err != nil {
+				t.Fatalf("PrintCompletion failed: %v", err)
+			}
+			script := out.String()
+
+			for _, d := range flagDocs {
+				if !strings.Contains(script, test.flagForm(d.name)) {
+					t.Errorf("Expected the %s script to complete -%s", shell, d.name)
+				}
+			}
+			for _, cmd := range testCompletionCommands {
+				if !strings.Contains(script, cmd.Name) {
+					t.Errorf("Expected the %s script to complete the %s command", shell, cmd.Name)
+				}
+			}
+			for _, want := range test.expected {
+				if !strings.Contains(script, want) {
+					t.Errorf("Expected the %s script to contain %q", shell, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintCompletionUnsupportedShell(t *testing.T) {
+	var out bytes.Buffer
+	err := New().PrintCompletion(&out, "tcsh", testCompletionCommands)
+	var configErr *gitbakErrors.ConfigError
+	if !gitbakErrors.As(err, &configErr) || configErr.Parameter != "shell" {
+		t.Errorf("Expected a shell ConfigError, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+}
+
+// TestBashCompletion runs the generated bash script to check what it completes
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	var script bytes.Buffer
+	if err := New().PrintCompletion(&script, "bash", testCompletionCommands); err != nil {
+		t.Fatalf("PrintCompletion failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		words    []string
+		expected string
+	}{
+		"Commands":   {words: []string{"gitbak", "st"}, expected: "status"},
+		"Flags":      {words: []string{"gitbak", "status", "-no-"}, expected: "-no-verify -no-branch -no-color"},
+		"FlagValues": {words: []string{"gitbak", "-notify", "e"}, expected: "errors"},
+		"FreeValue":  {words: []string{"gitbak", "-interval", ""}, expected: ""},
+		"Arguments":  {words: []string{"gitbak", "completion", "z"}, expected: "zsh"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			words := make([]string, len(test.words))
+			for i, word := range test.words {
+				words[i] = "'" + word + "'"
+			}
+			program := script.String() + "\n" +
+				"COMP_WORDS=(" + strings.Join(words, " ") + ")\n" +
+				"COMP_CWORD=" + strconv.Itoa(len(test.words)-1) + "\n" +
+				"_gitbak\n" +
+				`echo "${COMPREPLY[*]}"` + "\n"
+
+			out, err := exec.Command("bash", "-c", program).CombinedOutput()
+			if err != nil {
+				t.Fatalf("bash failed: %v\n%s", err, out)
+			}
+			if got := strings.TrimSpace(string(out)); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -1,26 +1,37 @@
 package config
 
 import (
+	"context"
 	"crypto/sha256"
 	"flag"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bashhack/gitbak/pkg/control"
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/hooks"
+	"github.com/bashhack/gitbak/pkg/lock"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/mirror"
+	"github.com/bashhack/gitbak/pkg/notify"
 )
 
 const (
-	// DefaultIntervalMinutes is the default time (in minutes) between checking for changes.
+	// DefaultInterval is the default time between checking for changes.
 	// This interval determines how frequently gitbak looks for changes to commit.
 	// Lower values provide more frequent checkpoints but can increase resource usage.
-	// The value can be a fractional number (e.g., 0.5 for 30 seconds).
-	DefaultIntervalMinutes = 5.0
+	DefaultInterval = 5 * time.Minute
 
 	// DefaultCommitPrefix is the default prefix added to all commit messages.
 	// This prefix helps identify commits made by gitbak and is used to extract
@@ -32,6 +43,109 @@ const (
 	// allowed before gitbak exits. A value of 0 means retry indefinitely.
 	// The error counter resets when errors change or successful operations occur.
 	DefaultMaxRetries = 3
+
+	// DefaultOpTimeout is the default limit on a single check-and-commit cycle or push attempt.
+	// It is generous for large repositories, but keeps a hung git command (e.g. waiting on a
+	// credential helper) from stalling the session indefinitely.
+	DefaultOpTimeout = 2 * time.Minute
+
+	// DefaultCommandTimeout is the default limit on a single git command. A hung command, such
+	// as git add on an unresponsive network filesystem, is killed well before DefaultOpTimeout.
+	DefaultCommandTimeout = time.Minute
+
+	// DefaultShutdownTimeout is the default limit on the work done after a signal stops a
+	// session: the final checkpoint and the session summary. It leaves time for a checkpoint
+	// in a typical repository while keeping Ctrl+C responsive.
+	DefaultShutdownTimeout = 5 * time.Second
+
+	// DefaultRetryBackoff and DefaultRetryBackoffMax bound the wait after a failed check
+	// before the next attempt. The wait doubles with each repeat of the same error, so a
+	// momentarily locked index gets time to clear before -max-retries is used up.
+	DefaultRetryBackoff    = 5 * time.Second
+	DefaultRetryBackoffMax = 5 * time.Minute
+
+	// DefaultMaxSkippedChecks is how many checks in a row may hold back changes below
+	// -min-changed-lines or -min-changed-files before they are committed anyway, so that
+	// a small last edit isn't left out of checkpoints indefinitely.
+	DefaultMaxSkippedChecks = 5
+
+	// DefaultBatteryInterval is how long gitbak waits between checks while the
+	// battery is below -battery-threshold: a few checks an hour keep work safe, while
+	// sparing a laptop running low from constant git processes.
+	DefaultBatteryInterval = 15 * time.Minute
+
+	// DefaultIdleInterval is how long gitbak waits between checks once -idle-after
+	// checks in a row found no changes: often enough to pick up work again soon after a
+	// break, rarely enough to keep an overnight session quiet.
+	DefaultIdleInterval = 30 * time.Minute
+
+	// DefaultLogMaxSizeMB, DefaultLogMaxFiles and DefaultLogMaxAgeDays keep debug logs from
+	// filling the disk: a log file is rotated at 10MB with 5 rotations kept, and log files of
+	// repositories gitbak hasn't run in for a month are removed at startup.
+	DefaultLogMaxSizeMB  = 10
+	DefaultLogMaxFiles   = 5
+	DefaultLogMaxAgeDays = 30
+
+	// DefaultLogLines is how many of the last records of the log file the logs command
+	// prints, as tail does
+	DefaultLogLines = 10
+
+	// DisableEnvVar is the environment variable that acts as a global kill switch.
+	// When set to a truthy value (1, true, yes), gitbak refuses to start and
+	// running sessions stop at their next check. Wrapper tooling such as CI images
+	// or corporate policies can use it to disable checkpointing without uninstalling.
+	DisableEnvVar = "GITBAK_DISABLE"
+
+	// DefaultEmptyRepo is how a repository without commits is handled by default:
+	// an empty initial commit is created so the session has a branch to return to.
+	// See the EmptyRepo* modes in the git package for the alternatives.
+	DefaultEmptyRepo = "initial-commit"
+
+	// DefaultMode records checkpoints as commits on a branch. The alternatives, "stash",
+	// "refs" and "observe", store them as stash entries or refs, or only journal the changes;
+	// see the Mode* constants in the git package.
+	DefaultMode = "branch"
+
+	// DefaultGitBackend runs the git binary for every git command. The alternative, "gogit",
+	// works without git installed; see the Backend* constants in the git package.
+	DefaultGitBackend = "exec"
+
+	// DefaultSubmodules checkpoints submodules as git add does, recording a submodule's
+	// new commit when its HEAD moves; see the Submodules* constants in the git package.
+	DefaultSubmodules = "include"
+
+	// DefaultUntrackedFiles has change checks report new files as git status does by
+	// default, collapsing new directories; see the Untracked* constants in the git package.
+	DefaultUntrackedFiles = "normal"
+
+	// DefaultUntrackedPolicy takes new files into checkpoints like changes to tracked files;
+	// see the UntrackedPolicy* constants in the git package.
+	DefaultUntrackedPolicy = "all"
+
+	// DefaultOnDiverge warns when the session branch's history is rewritten under the session
+	// and checkpoints on top of the new history; see the Diverge* constants in the git package.
+	DefaultOnDiverge = "warn"
+
+	// DefaultOnCheckFail makes checkpoints whose -check-cmd failed anyway, marked as failing;
+	// see the CheckFail* constants in the git package.
+	DefaultOnCheckFail = "mark"
+
+	// DefaultOutput prints the session summary and status for people to read. The
+	// alternative, "json", prints them as JSON on stdout for scripts and dashboards.
+	DefaultOutput = "text"
+
+	// DefaultLogBackend writes debug logs to the log file as text records; see logger.Backend
+	// for the alternatives.
+	DefaultLogBackend = "text"
+
+	// DefaultBranchTemplate names session branches after when they started, such as
+	// gitbak-20250101-120000. See BranchTemplate for the values a template can use.
+	DefaultBranchTemplate = "gitbak-{{.Timestamp}}"
+
+	// DefaultLockScope locks each worktree on its own, so that sessions in separate
+	// worktrees of a repository can run side by side. The alternative, "repository",
+	// allows a single session across all of a repository's worktrees.
+	DefaultLockScope = "worktree"
 )
 
 // Config holds all gitbak application settings.
@@ -41,21 +155,116 @@ type Config struct {
 	// Repository configuration
 
 	// RepoPath is the path to the Git repository to monitor.
-	// If empty, the current working directory is used.
+	// If empty, the current working directory is used, or WorkTree if that is set.
 	RepoPath string
 
-	// IntervalMinutes is how often (in minutes) to check for changes.
-	// Uses float64 to support fractional minutes (e.g., 0.5 for 30 seconds).
-	IntervalMinutes float64
+	// GitDir is the repository's git directory, for work trees that are not co-located with
+	// it, such as a bare repository of dotfiles checked out into the home directory. It is
+	// passed to every git command as --git-dir, and taken from GIT_DIR as git itself does.
+	GitDir string
+
+	// WorkTree is the top-level directory of the work tree that goes with GitDir, passed to
+	// every git command as --work-tree and taken from GIT_WORK_TREE as git itself does. If
+	// empty, git decides, as it does when only GIT_DIR is set.
+	WorkTree string
+
+	// Interval is how often to check for changes.
+	// It is given as fractional minutes (e.g., 0.5 for 30 seconds) or a Go duration (e.g., 90s).
+	Interval time.Duration
+
+	// MinInterval and MaxInterval bound an interval that adapts to activity: it shrinks
+	// while checkpoints are made on check after check and grows while checks find nothing
+	// to commit. Zero keeps the interval fixed in that direction. Like Interval, they are
+	// given as fractional minutes or a Go duration.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+
+	// IdleAfter, if set, is how many checks in a row must find no changes before gitbak
+	// goes idle, checking every IdleInterval and logging once instead of at each check,
+	// until changes reappear. Zero never goes idle.
+	IdleAfter    int
+	IdleInterval time.Duration
+
+	// Align lists the minutes past the hour that scheduled checks are moved on to, as
+	// comma-separated :MM or :MM:SS marks. AlignMarks holds them parsed, after Finalize.
+	Align      string
+	AlignMarks []time.Duration
+
+	// BatteryThreshold, if set, is the battery percentage below which checks are spaced
+	// BatteryInterval apart while running on battery. Zero BatteryInterval pauses
+	// checkpointing instead. Only supported on Linux and macOS.
+	BatteryThreshold int
+	BatteryInterval  time.Duration
+
+	// Watch checks for changes when the file system reports them instead of on every interval.
+	// Where watching is unsupported, gitbak falls back to polling.
+	Watch bool
+
+	// Detach runs the monitoring session in the background, detached from the terminal.
+	// Its output is appended to LogFile; use the stop and status commands to control it.
+	Detach bool
 
 	// BranchName is the Git branch to use for checkpoint commits.
-	// If empty and CreateBranch is true, a timestamp-based name is generated.
+	// If empty and CreateBranch is true, a name is generated from BranchTemplate.
 	BranchName string
 
+	// BranchTemplate is the text/template BranchName is generated from. It can use
+	// {{.User}}, {{.Date}}, {{.Time}}, {{.Timestamp}}, {{.BaseBranch}} and {{.Repo}}.
+	// Empty selects DefaultBranchTemplate.
+	BranchTemplate string
+
 	// CommitPrefix is prepended to all commit messages.
 	// Used to identify gitbak commits and extract commit numbers.
 	CommitPrefix string
 
+	// CommitAuthor and CommitEmail, if set, attribute checkpoint commits to this identity as
+	// both author and committer instead of the configured git user.
+	CommitAuthor string
+	CommitEmail  string
+
+	// CoAuthors holds the identities ("Name <email>") credited with a Co-authored-by
+	// trailer on every checkpoint, such as the other half of a pair.
+	CoAuthors []string
+
+	// MinChangedLines and MinChangedFiles, if set, hold back checkpoints until the changes
+	// add or remove that many lines, or touch that many files. MaxSkippedChecks bounds how
+	// many checks in a row may hold changes back (0 = no limit).
+	MinChangedLines  int
+	MinChangedFiles  int
+	MaxSkippedChecks int
+
+	// MinQuietSeconds, if set, holds back checkpoints until the working tree has been
+	// stable for that many seconds, so that a burst of changes makes a single checkpoint.
+	MinQuietSeconds float64
+
+	// CheckCommand, if set, is a shell command run before each checkpoint, such as a build,
+	// whose outcome is recorded in the checkpoint's message. OnCheckFail selects whether a
+	// failure still makes the checkpoint ("mark") or holds it back until it passes ("skip").
+	CheckCommand string
+	OnCheckFail  string
+
+	// MaxFileSizeMB, if set, leaves changed files larger than this many megabytes out of
+	// checkpoints, asking about each one first unless NonInteractive is set (0 = no limit).
+	MaxFileSizeMB int
+
+	// Paths, if set, scopes checkpoints to these files and directories of the repository,
+	// relative to RepoPath, so that other changes are neither detected nor staged. Finalize
+	// cleans them into slash-separated form.
+	Paths []string
+
+	// Secrets selects what happens to changes that look like they hold credentials:
+	// "skip" leaves those files out of checkpoints, "abort" holds checkpoints back until
+	// they are gone and "off" disables scanning. Empty picks skip, or off with gogit.
+	Secrets string
+
+	// NoVerify passes --no-verify to the commits gitbak creates, bypassing pre-commit and
+	// commit-msg hooks.
+	NoVerify bool
+
+	// DiffSummary lists the files each checkpoint changes, with line counts,
+	// in the body of its commit message.
+	DiffSummary bool
+
 	// CreateBranch determines whether to create a new branch or use existing one.
 	// If true, a new branch named BranchName will be created.
 	CreateBranch bool
@@ -64,12 +273,97 @@ type Config struct {
 	// When true, gitbak finds the last commit number and continues numbering from there.
 	ContinueSession bool
 
+	// EmptyRepo selects how a repository without any commits is handled:
+	// "initial-commit", "root-checkpoint" or "fail".
+	EmptyRepo string
+
+	// Mode selects where checkpoints are recorded: "branch" commits them,
+	// "stash" stores them as stash entries without committing on any branch,
+	// "refs" writes them to refs/gitbak/<session>/<n>, also leaving every branch alone,
+	// and "observe" records none, journaling the changes to JournalFile instead.
+	Mode string
+
+	// RefsOnly selects the "refs" Mode (-refs-only).
+	RefsOnly bool
+
+	// JournalFile is where the "observe" mode appends the changes it detects, as JSON lines.
+	// If empty, a default location under the XDG data directory is derived from the repository path.
+	JournalFile string
+
+	// GitBackend selects how git commands are carried out: "exec" runs the git binary,
+	// "gogit" uses a built-in implementation for machines without git installed.
+	GitBackend string
+
+	// GitPath is the git binary to run, such as /opt/homebrew/bin/git or a wrapper
+	// script. If empty, git is found in PATH.
+	GitPath string
+
+	// GitGlobalArgs holds arguments passed to git before every command's own, separated
+	// by whitespace, such as "-c core.untrackedCache=true". See GitArgs.
+	GitGlobalArgs string
+
+	// Submodules selects how checkpoints treat submodules: "include" records their
+	// new commits, "ignore" leaves them out, and "recursive" also checkpoints inside them.
+	Submodules string
+
+	// UntrackedFiles selects how change checks look for new files: "normal", "all"
+	// (every file inside new directories) or "no" (quickest in very large trees).
+	UntrackedFiles string
+
+	// UntrackedPolicy selects whether new files are taken into checkpoints: "all" takes
+	// them in, "none" leaves them out until added by hand, and "prompt" asks about each one.
+	UntrackedPolicy string
+
+	// FastStatus speeds up change checks in large repositories with git's untracked
+	// cache and, where available, its built-in file system monitor.
+	FastStatus bool
+
+	// OnDiverge selects what happens when the session branch's history is rewritten under the
+	// session, e.g. by a rebase or reset: "warn" checkpoints on top of the new history, "pause"
+	// pauses checkpointing until it is resumed.
+	OnDiverge string
+
 	// User experience options
 
 	// Verbose controls the amount of informational output.
-	// When true, gitbak provides detailed status updates.
+	// When true, gitbak provides detailed status updates. Finalize sets it from LogLevel,
+	// so it holds for info verbosity and above.
 	Verbose bool
 
+	// LogLevel selects which messages are shown on the console: "error", "warn", "info",
+	// "debug" or "trace" (see logger.Verbosity). Empty selects info, or warn if Verbose
+	// is false, as with -quiet.
+	LogLevel string
+
+	// VerboseLevels is how many levels the -v and -vv flags raise LogLevel by.
+	// Finalize applies it to LogLevel and resets it.
+	VerboseLevels int
+
+	// Notify selects which messages are also shown as desktop notifications:
+	// "off", "errors" or "all". See the notify package for the modes.
+	Notify string
+
+	// NoColor strips colors from console output. Colors are also left out when
+	// stdout is not a terminal.
+	NoColor bool
+
+	// ErrorsJSON prints the error that ends gitbak as a single line of JSON on stderr,
+	// for wrapper scripts. See the errors package for the exit codes and fields.
+	ErrorsJSON bool
+
+	// Output selects how the session summary and the status command's report are printed:
+	// "text", or "json" for a single line of JSON on stdout, with every other message moved
+	// to stderr so that stdout holds nothing else.
+	Output string
+
+	// TUI shows a live dashboard of the session in place of scrolling log lines, when
+	// stdout is a terminal.
+	TUI bool
+
+	// RPC serves a JSON-RPC protocol on stdin and stdout for an editor hosting gitbak as a
+	// child process, moving every other message to stderr and disabling prompts.
+	RPC bool
+
 	// ShowNoChanges determines whether to report when no changes are detected.
 	// When true, gitbak logs a message at each interval even if nothing changed.
 	ShowNoChanges bool
@@ -85,6 +379,45 @@ type Config struct {
 	// Errors of different types or successful operations reset this counter.
 	MaxRetries int
 
+	// OpTimeout bounds each check-and-commit cycle and push attempt.
+	// A value of 0 disables the limit.
+	OpTimeout time.Duration
+
+	// CommandTimeout bounds each git command; a command exceeding it is killed along with
+	// the processes it started. A value of 0 disables the limit.
+	CommandTimeout time.Duration
+
+	// CommitOnExit makes a final checkpoint of any changes since the last check when a
+	// signal stops the session, so work done in the last interval is not left uncommitted.
+	CommitOnExit bool
+
+	// ShutdownTimeout bounds the final checkpoint and the session summary once a signal
+	// stops the session. A value of 0 disables the limit.
+	ShutdownTimeout time.Duration
+
+	// RetryBackoff is the wait after a failed check before the next attempt, doubling with
+	// each repeat of the same error up to RetryBackoffMax. A value of 0 disables backoff.
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+
+	// LockWait is how long to wait for another gitbak process to release the repository
+	// lock before giving up. A value of 0 gives up immediately.
+	LockWait time.Duration
+
+	// Takeover asks another gitbak process holding the repository lock to stop, killing it if
+	// it does not, and takes its place. Join instead shows that process's session as a live
+	// status view, without checkpointing, until it ends.
+	Takeover bool
+	Join     bool
+
+	// LockScope selects what the lock guards: "worktree" allows one session per worktree,
+	// "repository" one session across all worktrees of the repository.
+	LockScope string
+
+	// LockStaleAfter is how long a lock may go without being refreshed by its holder before
+	// it is reclaimed, even if its PID is in use. A value of 0 trusts the PID alone.
+	LockStaleAfter time.Duration
+
 	// Debugging options
 
 	// Debug enables detailed logging.
@@ -95,6 +428,92 @@ type Config struct {
 	// If empty, logs are written to a default location based on repository path.
 	LogFile string
 
+	// LogBackend selects where debug logs are recorded: "text" or "json" records in LogFile,
+	// or "syslog" or "journald" for the system's logs. Those two enable Debug, since the
+	// system rotates what it keeps rather than gitbak.
+	LogBackend string
+
+	// LogMaxSizeMB is the size at which the log file is rotated (0 = never), and LogMaxFiles how
+	// many rotations are kept. LogMaxAgeDays removes log files in the default log directory that
+	// haven't been written to for that many days when gitbak starts (0 = keep them).
+	LogMaxSizeMB  int
+	LogMaxFiles   int
+	LogMaxAgeDays int
+
+	// SummaryFile, if set, is where a report of the session is written when it ends:
+	// as JSON if the name ends in .json, as Markdown otherwise.
+	SummaryFile string
+
+	// StateFile is where session state is persisted between gitbak invocations.
+	// If empty, a default location under the XDG data directory is derived from the repository path.
+	StateFile string
+
+	// ChainTrailer records the previous checkpoint's integrity hash as a commit trailer,
+	// so the chain can be audited from the history itself.
+	ChainTrailer bool
+
+	// Push is the remote the session branch is pushed to after checkpoints.
+	// If empty, checkpoints are not pushed.
+	Push string
+
+	// PushInterval is the minimum time between pushes.
+	// A value of 0 pushes after every checkpoint.
+	PushInterval time.Duration
+
+	// Mirrors holds the mirror profile specs ([name=]remote[,every=<duration>][,limit=<rate>])
+	// the session branch is pushed to, under refs/gitbak/ on each remote. MirrorProfiles holds them parsed, after Finalize.
+	Mirrors        []string
+	MirrorProfiles []mirror.Profile
+
+	// AssumeYes answers "yes" to confirmation prompts of destructive commands such as abort,
+	// and accepts generated commit messages (e.g. for squash) without opening an editor.
+	AssumeYes bool
+
+	// SquashMessage is the subject line of the commit created by squash.
+	// If set, the session details are kept below it and no editor is opened.
+	SquashMessage string
+
+	// BundleSessionOnly makes export-bundle bundle only the commits made since the session
+	// started, rather than the whole history of the session branch.
+	BundleSessionOnly bool
+
+	// FollowLogs makes the logs command keep printing records as they are written to the
+	// log file, across rotations, until interrupted.
+	FollowLogs bool
+
+	// LogLines is how many of the last records of the log file the logs command prints
+	// before following it, or 0 for all of them.
+	LogLines int
+
+	// PprofAddr is the address (e.g. 127.0.0.1:6060) on which to serve runtime
+	// profiling endpoints. If empty, profiling is disabled.
+	PprofAddr string
+
+	// NudgeAddr is the address (e.g. 127.0.0.1:7091, or unix:<path> for a Unix socket)
+	// on which to accept POST /nudge requests for an early change check, and which the
+	// nudge command posts to. If empty, the endpoint is disabled.
+	NudgeAddr string
+
+	// ListenAddr is the address (host:port, or unix:<path> for a Unix socket) of the local
+	// control endpoint serving /status, /pause, /resume and /commit-now. If empty, it is disabled.
+	ListenAddr string
+
+	// MetricsAddr is the address (host:port) on which to serve Prometheus metrics at /metrics.
+	// If empty, metrics are not collected.
+	MetricsAddr string
+
+	// OTLPEndpoint is the URL of an OTLP/HTTP collector (e.g. http://localhost:4318) to export
+	// OpenTelemetry spans of each check and git command to. If empty, nothing is traced.
+	OTLPEndpoint string
+
+	// OnStart, OnCommit, OnError and OnStop are shell commands run, in the repository and
+	// described by GITBAK_* environment variables, when the session starts, after each
+	// checkpoint, after each failed check and when the session ends. Empty ones are not run.
+	OnStart  string
+	OnCommit string
+	OnError  string
+	OnStop   string
+
 	// Special flags
 
 	// Version indicates whether to show version information and exit.
@@ -124,6 +543,10 @@ type Config struct {
 	// ParsedQuiet tracks the state of the -quiet flag.
 	// Used during flag parsing to handle flag inversion.
 	ParsedQuiet *bool
+
+	// Args holds the arguments left after the flags,
+	// such as the paths given to the ignores command.
+	Args []string
 }
 
 // VersionInfo contains build-time version metadata.
@@ -143,19 +566,46 @@ type VersionInfo struct {
 // New creates a new Config with default values
 func New() *Config {
 	return &Config{
-		IntervalMinutes: DefaultIntervalMinutes,
+		Interval:        DefaultInterval,
 		CommitPrefix:    DefaultCommitPrefix,
 		CreateBranch:    true,
 		Verbose:         true,
+		BranchTemplate:  DefaultBranchTemplate,
 		ShowNoChanges:   false,
 		RepoPath:        "",
 		ContinueSession: false,
 		Debug:           false,
 		LogFile:         "",
+		LogBackend:      DefaultLogBackend,
+		LogMaxSizeMB:    DefaultLogMaxSizeMB,
+		LogMaxFiles:     DefaultLogMaxFiles,
+		LogMaxAgeDays:   DefaultLogMaxAgeDays,
+		LogLines:        DefaultLogLines,
 		Version:         false,
 		ShowLogo:        false,
 		ShowHelp:        false,
 		MaxRetries:      DefaultMaxRetries,
+		OpTimeout:       DefaultOpTimeout,
+		CommandTimeout:  DefaultCommandTimeout,
+		CommitOnExit:    true,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		RetryBackoff:    DefaultRetryBackoff,
+		RetryBackoffMax: DefaultRetryBackoffMax,
+		EmptyRepo:       DefaultEmptyRepo,
+		Mode:            DefaultMode,
+		GitBackend:      DefaultGitBackend,
+		Submodules:      DefaultSubmodules,
+		UntrackedFiles:  DefaultUntrackedFiles,
+		UntrackedPolicy: DefaultUntrackedPolicy,
+		OnDiverge:       DefaultOnDiverge,
+		OnCheckFail:     DefaultOnCheckFail,
+		LockScope:       DefaultLockScope,
+		Output:          DefaultOutput,
+		Notify:          notify.ModeOff,
+
+		MaxSkippedChecks: DefaultMaxSkippedChecks,
+		BatteryInterval:  DefaultBatteryInterval,
+		IdleInterval:     DefaultIdleInterval,
 
 		// Default version info, will be overridden if provided
 		VersionInfo: VersionInfo{
@@ -168,18 +618,90 @@ func New() *Config {
 
 // LoadFromEnvironment updates config from environment variables
 func (c *Config) LoadFromEnvironment() {
-	c.IntervalMinutes = getEnvFloat("INTERVAL_MINUTES", c.IntervalMinutes)
+	c.Interval = getEnvInterval("INTERVAL_MINUTES", c.Interval)
+	c.MinInterval = getEnvInterval("MIN_INTERVAL_MINUTES", c.MinInterval)
+	c.MaxInterval = getEnvInterval("MAX_INTERVAL_MINUTES", c.MaxInterval)
+	c.IdleAfter = getEnvInt("IDLE_AFTER", c.IdleAfter)
+	c.IdleInterval = getEnvInterval("IDLE_INTERVAL_MINUTES", c.IdleInterval)
+	c.Align = getEnvString("ALIGN", c.Align)
+	c.BatteryThreshold = getEnvInt("BATTERY_THRESHOLD", c.BatteryThreshold)
+	c.BatteryInterval = getEnvInterval("BATTERY_INTERVAL_MINUTES", c.BatteryInterval)
+	c.Watch = getEnvBool("WATCH", c.Watch)
 	c.BranchName = getEnvString("BRANCH_NAME", c.BranchName)
+	c.BranchTemplate = getEnvString("BRANCH_TEMPLATE", c.BranchTemplate)
 	c.CommitPrefix = getEnvString("COMMIT_PREFIX", c.CommitPrefix)
+	c.CommitAuthor = getEnvString("COMMIT_AUTHOR", c.CommitAuthor)
+	c.CommitEmail = getEnvString("COMMIT_EMAIL", c.CommitEmail)
+	c.CoAuthors = getEnvList("COAUTHORS", ";", c.CoAuthors)
+	c.DiffSummary = getEnvBool("DIFF_SUMMARY", c.DiffSummary)
+	c.NoVerify = getEnvBool("NO_VERIFY", c.NoVerify)
+	c.MinChangedLines = getEnvInt("MIN_CHANGED_LINES", c.MinChangedLines)
+	c.MinChangedFiles = getEnvInt("MIN_CHANGED_FILES", c.MinChangedFiles)
+	c.MaxSkippedChecks = getEnvInt("MAX_SKIPPED_CHECKS", c.MaxSkippedChecks)
+	c.MinQuietSeconds = getEnvFloat("MIN_QUIET_SECONDS", c.MinQuietSeconds)
+	c.CheckCommand = getEnvString("CHECK_CMD", c.CheckCommand)
+	c.OnCheckFail = getEnvString("ON_CHECK_FAIL", c.OnCheckFail)
+	c.MaxFileSizeMB = getEnvInt("MAX_FILE_SIZE_MB", c.MaxFileSizeMB)
+	c.Paths = getEnvList("PATHS", ";", c.Paths)
+	c.Secrets = getEnvString("SECRETS", c.Secrets)
 	c.CreateBranch = getEnvBool("CREATE_BRANCH", c.CreateBranch)
 	c.Verbose = getEnvBool("VERBOSE", c.Verbose)
+	c.LogLevel = getEnvString("LOG_LEVEL", c.LogLevel)
+	// By the NO_COLOR convention (https://no-color.org), any non-empty value disables color
+	if os.Getenv("NO_COLOR") != "" {
+		c.NoColor = true
+	}
+	c.ErrorsJSON = getEnvBool("ERRORS_JSON", c.ErrorsJSON)
+	c.TUI = getEnvBool("TUI", c.TUI)
+	c.Output = getEnvString("OUTPUT_FORMAT", c.Output)
 	c.NonInteractive = getEnvBool("NON_INTERACTIVE", c.NonInteractive)
 	c.ShowNoChanges = getEnvBool("SHOW_NO_CHANGES", c.ShowNoChanges)
+	c.Notify = getEnvString("NOTIFY", c.Notify)
 	c.RepoPath = getEnvString("REPO_PATH", c.RepoPath)
+	c.GitDir = getEnvString("GIT_DIR", c.GitDir)
+	c.WorkTree = getEnvString("GIT_WORK_TREE", c.WorkTree)
 	c.ContinueSession = getEnvBool("CONTINUE_SESSION", c.ContinueSession)
+	c.EmptyRepo = getEnvString("EMPTY_REPO", c.EmptyRepo)
+	c.Mode = getEnvString("CHECKPOINT_MODE", c.Mode)
+	c.RefsOnly = getEnvBool("REFS_ONLY", c.RefsOnly)
+	c.JournalFile = getEnvString("JOURNAL_FILE", c.JournalFile)
+	c.GitBackend = getEnvString("GIT_BACKEND", c.GitBackend)
+	c.GitPath = getEnvString("GIT_PATH", c.GitPath)
+	c.GitGlobalArgs = getEnvString("GIT_GLOBAL_ARGS", c.GitGlobalArgs)
+	c.Submodules = getEnvString("SUBMODULES", c.Submodules)
+	c.UntrackedFiles = getEnvString("UNTRACKED_FILES", c.UntrackedFiles)
+	c.UntrackedPolicy = getEnvString("UNTRACKED_POLICY", c.UntrackedPolicy)
+	c.FastStatus = getEnvBool("FAST_STATUS", c.FastStatus)
+	c.OnDiverge = getEnvString("ON_DIVERGE", c.OnDiverge)
 	c.Debug = getEnvBool("DEBUG", c.Debug)
 	c.LogFile = getEnvString("LOG_FILE", c.LogFile)
+	c.LogBackend = getEnvString("LOG_BACKEND", c.LogBackend)
+	c.LogMaxSizeMB = getEnvInt("LOG_MAX_SIZE_MB", c.LogMaxSizeMB)
+	c.LogMaxFiles = getEnvInt("LOG_MAX_FILES", c.LogMaxFiles)
+	c.LogMaxAgeDays = getEnvInt("LOG_MAX_AGE_DAYS", c.LogMaxAgeDays)
+	c.SummaryFile = getEnvString("SUMMARY_FILE", c.SummaryFile)
 	c.MaxRetries = getEnvInt("MAX_RETRIES", c.MaxRetries)
+	c.OpTimeout = getEnvDuration("OP_TIMEOUT", c.OpTimeout)
+	c.CommandTimeout = getEnvDuration("COMMAND_TIMEOUT", c.CommandTimeout)
+	c.CommitOnExit = getEnvBool("COMMIT_ON_EXIT", c.CommitOnExit)
+	c.ShutdownTimeout = getEnvDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	c.LockWait = getEnvDuration("LOCK_WAIT", c.LockWait)
+	c.LockScope = getEnvString("LOCK_SCOPE", c.LockScope)
+	c.LockStaleAfter = getEnvDuration("LOCK_STALE_AFTER", c.LockStaleAfter)
+	c.RetryBackoff = getEnvDuration("RETRY_BACKOFF", c.RetryBackoff)
+	c.RetryBackoffMax = getEnvDuration("RETRY_BACKOFF_MAX", c.RetryBackoffMax)
+	c.ChainTrailer = getEnvBool("CHAIN_TRAILER", c.ChainTrailer)
+	c.Push = getEnvString("PUSH_REMOTE", c.Push)
+	c.PushInterval = getEnvInterval("PUSH_INTERVAL_MINUTES", c.PushInterval)
+	c.NudgeAddr = getEnvString("NUDGE_ADDR", c.NudgeAddr)
+	c.ListenAddr = getEnvString("LISTEN_ADDR", c.ListenAddr)
+	c.MetricsAddr = getEnvString("METRICS_ADDR", c.MetricsAddr)
+	c.OTLPEndpoint = getEnvString("OTLP_ENDPOINT", c.OTLPEndpoint)
+	c.Mirrors = getEnvList("MIRRORS", ";", c.Mirrors)
+	c.OnStart = getEnvString("ON_START", c.OnStart)
+	c.OnCommit = getEnvString("ON_COMMIT", c.OnCommit)
+	c.OnError = getEnvString("ON_ERROR", c.OnError)
+	c.OnStop = getEnvString("ON_STOP", c.OnStop)
 }
 
 // SetupFlags sets up command-line flags to override config values
@@ -189,20 +711,102 @@ func (c *Config) SetupFlags(fs *flag.FlagSet) {
 	var quiet bool
 
 	// Define command-line flags
-	fs.Float64Var(&c.IntervalMinutes, "interval", c.IntervalMinutes, "Minutes between commits (supports decimal values like 0.1 for 6 seconds)")
-	fs.StringVar(&c.BranchName, "branch", c.BranchName, "Custom branch name (default: gitbak-{timestamp})")
+	fs.Var(intervalValue{&c.Interval}, "interval", "Time between commits, in minutes (0.5) or as a Go duration (90s, 2m30s)")
+	fs.Var(intervalValue{&c.MinInterval}, "min-interval", "Shortest interval while checkpoints are made on every check, in minutes or as a Go duration (0 = fixed interval)")
+	fs.Var(intervalValue{&c.MaxInterval}, "max-interval", "Longest interval while checks find no changes, in minutes or as a Go duration (0 = fixed interval)")
+	fs.IntVar(&c.IdleAfter, "idle-after", c.IdleAfter, "Go idle after this many checks in a row without changes (0 = never)")
+	fs.Var(intervalValue{&c.IdleInterval}, "idle-interval", "Time between checks while idle, in minutes or as a Go duration")
+	fs.StringVar(&c.Align, "align", c.Align, "Move checks on to these minutes past the hour, e.g. :00,:15,:30,:45")
+	fs.IntVar(&c.BatteryThreshold, "battery-threshold", c.BatteryThreshold, "Check less often while on battery below this percentage (0 = disabled)")
+	fs.Var(intervalValue{&c.BatteryInterval}, "battery-interval", "Time between checks while the battery is low, in minutes or as a Go duration (0 = pause)")
+	fs.BoolVar(&c.Watch, "watch", c.Watch, "Check shortly after files change instead of on every interval")
+	fs.BoolVar(&c.Detach, "detach", c.Detach, "Run the session in the background (control it with stop and status)")
+	fs.StringVar(&c.BranchName, "branch", c.BranchName, "Custom branch name (default: generated from -branch-template)")
+	fs.StringVar(&c.BranchTemplate, "branch-template", c.BranchTemplate, "Template for generated branch names, using {{.User}}, {{.Date}}, {{.Time}}, {{.Timestamp}}, {{.BaseBranch}} and {{.Repo}}")
 	fs.StringVar(&c.CommitPrefix, "prefix", c.CommitPrefix, "Custom commit message prefix")
+	fs.StringVar(&c.CommitAuthor, "author", c.CommitAuthor, "Name checkpoint commits are authored and committed by (requires -author-email)")
+	fs.StringVar(&c.CommitEmail, "author-email", c.CommitEmail, "Email checkpoint commits are authored and committed by (requires -author)")
+	fs.Var(&stringList{values: &c.CoAuthors}, "coauthor", "Credit this co-author, as 'Name <email>', with a Co-authored-by trailer on every checkpoint (repeatable)")
+	fs.IntVar(&c.MinChangedLines, "min-changed-lines", c.MinChangedLines, "Hold back checkpoints until this many lines changed (0 = disabled)")
+	fs.IntVar(&c.MinChangedFiles, "min-changed-files", c.MinChangedFiles, "Hold back checkpoints until this many files changed (0 = disabled)")
+	fs.IntVar(&c.MaxSkippedChecks, "max-skipped-checks", c.MaxSkippedChecks, "Commit held back changes after this many checks in a row (0 = no limit)")
+	fs.Float64Var(&c.MinQuietSeconds, "min-quiet", c.MinQuietSeconds, "Seconds the working tree must be left alone before a checkpoint (0 = disabled)")
+	fs.StringVar(&c.CheckCommand, "check-cmd", c.CheckCommand, "Run this shell command before each checkpoint, e.g. 'go build ./...', and mark the checkpoint with whether it passed")
+	fs.StringVar(&c.OnCheckFail, "on-check-fail", c.OnCheckFail, "When -check-cmd fails: mark the checkpoint as failing, or skip it until the command passes")
+	fs.IntVar(&c.MaxFileSizeMB, "max-file-size", c.MaxFileSizeMB, "Leave changed files larger than this many megabytes out of checkpoints (0 = no limit)")
+	fs.Var(&stringList{values: &c.Paths}, "path", "Only checkpoint changes under this file or directory, relative to the repository (repeatable)")
+	fs.StringVar(&c.Secrets, "secrets", c.Secrets, "Changes that look like they hold secrets: skip their files, abort the checkpoint, or off (default skip)")
+	fs.BoolVar(&c.DiffSummary, "diff-summary", c.DiffSummary, "List the changed files and line counts in each checkpoint's commit message")
+	fs.BoolVar(&c.NoVerify, "no-verify", c.NoVerify, "Skip pre-commit and commit-msg hooks when creating checkpoints")
 	fs.BoolVar(&noBranch, "no-branch", !c.CreateBranch, "Use current branch instead of creating a new one")
-	fs.BoolVar(&quiet, "quiet", !c.Verbose, "Hide informational messages")
+	fs.BoolVar(&quiet, "quiet", !c.Verbose, "Hide informational messages (the same as -log-level warn)")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Show messages at this level and above: error, warn, info, debug or trace (default info)")
+	fs.Var(&levelCount{count: &c.VerboseLevels, step: 1}, "v", "Show more messages: raise -log-level by one level (repeatable)")
+	fs.Var(&levelCount{count: &c.VerboseLevels, step: 2}, "vv", "Show even more messages: raise -log-level by two levels")
 	fs.BoolVar(&c.ShowNoChanges, "show-no-changes", c.ShowNoChanges, "Show messages when no changes detected")
+	fs.BoolVar(&c.NoColor, "no-color", c.NoColor, "Disable colored output")
+	fs.BoolVar(&c.ErrorsJSON, "errors-json", c.ErrorsJSON, "Print the error that ends gitbak as JSON on stderr")
+	fs.BoolVar(&c.TUI, "tui", c.TUI, "Show a live dashboard instead of scrolling log lines")
+	fs.BoolVar(&c.RPC, "rpc", c.RPC, "Speak JSON-RPC on stdin and stdout, for editor extensions hosting gitbak")
+	fs.StringVar(&c.Output, "output", c.Output, "Print the session summary and status as text or json (on stdout, other messages go to stderr)")
+	fs.StringVar(&c.Notify, "notify", c.Notify, "Show desktop notifications: off, errors or all (also every checkpoint)")
 	fs.StringVar(&c.RepoPath, "repo", c.RepoPath, "Path to repository (default: current directory)")
+	fs.StringVar(&c.GitDir, "git-dir", c.GitDir, "Repository's git directory, when it is not the work tree's .git (e.g. a bare repository)")
+	fs.StringVar(&c.WorkTree, "work-tree", c.WorkTree, "Top-level directory of the work tree that goes with -git-dir")
 	fs.BoolVar(&c.ContinueSession, "continue", c.ContinueSession, "Continue from existing branch")
+	fs.StringVar(&c.Mode, "mode", c.Mode, "Where to record checkpoints: branch (commits), stash (stash entries, no commits on any branch), refs (commits under refs/gitbak, on no branch) or observe (no checkpoints, only a journal of changes)")
+	fs.BoolVar(&c.RefsOnly, "refs-only", c.RefsOnly, "Write checkpoints to refs/gitbak/<session>/<n> instead of a branch (same as -mode refs; see gitbak materialize)")
+	fs.StringVar(&c.JournalFile, "journal", c.JournalFile, "Where -mode observe journals changes (default: ~/.local/share/gitbak/journals/gitbak-{repo-hash}.jsonl)")
+	fs.StringVar(&c.GitBackend, "git-backend", c.GitBackend, "How to run git commands: exec (the git binary) or gogit (built in, git need not be installed)")
+	fs.StringVar(&c.GitPath, "git-path", c.GitPath, "Run this git binary instead of the git in PATH")
+	fs.StringVar(&c.GitGlobalArgs, "git-args", c.GitGlobalArgs, "Pass these arguments to git before every command, e.g. '-c core.untrackedCache=true'")
+	fs.StringVar(&c.Submodules, "submodules", c.Submodules, "How to checkpoint submodules: include (their new commits), ignore or recursive (also commit inside them)")
+	fs.StringVar(&c.UntrackedFiles, "untracked-files", c.UntrackedFiles, "How change checks look for new files: normal, all or no (quickest, new files wait for other changes)")
+	fs.StringVar(&c.UntrackedPolicy, "untracked-policy", c.UntrackedPolicy, "Whether new files are checkpointed: all, none (only once added by hand) or prompt (ask about each)")
+	fs.BoolVar(&c.FastStatus, "fast-status", c.FastStatus, "Speed up change checks in large repositories with git's untracked cache and file system monitor")
+	fs.StringVar(&c.EmptyRepo, "empty-repo", c.EmptyRepo, "How to start in a repository without commits: initial-commit, root-checkpoint or fail")
 	fs.BoolVar(&c.Debug, "debug", c.Debug, "Enable debug logging")
 	fs.StringVar(&c.LogFile, "log-file", c.LogFile, "Path to log file (default: ~/.local/share/gitbak/logs/gitbak-{repo-hash}.log)")
+	fs.StringVar(&c.LogBackend, "log-backend", c.LogBackend, "Record debug logs as text or json in the log file, or send them to syslog or journald")
+	fs.IntVar(&c.LogMaxSizeMB, "log-max-size", c.LogMaxSizeMB, "Rotate the log file once it reaches this many megabytes (0 = never)")
+	fs.IntVar(&c.LogMaxFiles, "log-max-files", c.LogMaxFiles, "Number of rotated log files to keep")
+	fs.IntVar(&c.LogMaxAgeDays, "log-max-age", c.LogMaxAgeDays, "Remove log files not written to for this many days at startup (0 = keep them)")
+	fs.StringVar(&c.SummaryFile, "summary-file", c.SummaryFile, "Write a session report to this file on exit (.json for JSON, Markdown otherwise)")
+	fs.StringVar(&c.PprofAddr, "pprof", c.PprofAddr, "Serve runtime profiling (net/http/pprof) on this address, e.g. 127.0.0.1:6060")
+	fs.BoolVar(&c.ChainTrailer, "chain-trailer", c.ChainTrailer, "Add a Gitbak-Chain integrity trailer to checkpoint commits")
+	fs.StringVar(&c.Push, "push", c.Push, "Push the session branch to this remote after checkpoints")
+	fs.Var(intervalValue{&c.PushInterval}, "push-interval", "Minimum time between pushes, in minutes or as a Go duration (0 = after every checkpoint)")
+	fs.StringVar(&c.NudgeAddr, "nudge-addr", c.NudgeAddr, "Accept POST /nudge requests for an early check on this address, e.g. 127.0.0.1:7091 or unix:/tmp/gitbak.sock")
+	fs.StringVar(&c.ListenAddr, "listen", c.ListenAddr, "Serve the JSON control endpoint (/status, /pause, /resume, /commit-now) on this address, e.g. 127.0.0.1:7373")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "Serve Prometheus metrics at /metrics on this address, e.g. :9473")
+	fs.StringVar(&c.OTLPEndpoint, "otlp-endpoint", c.OTLPEndpoint, "Export OpenTelemetry spans of checks and git commands to this OTLP/HTTP collector, e.g. http://localhost:4318")
+	fs.Var(&stringList{values: &c.Mirrors}, "mirror", "Push the session branch to refs/gitbak/ on a mirror, as [name=]remote[,every=1h][,limit=512k] (repeatable)")
+	fs.StringVar(&c.OnStart, "on-start", c.OnStart, "Run this shell command when the session starts")
+	fs.StringVar(&c.OnCommit, "on-commit", c.OnCommit, "Run this shell command after each checkpoint, with GITBAK_COMMIT and GITBAK_COUNTER set")
+	fs.StringVar(&c.OnError, "on-error", c.OnError, "Run this shell command after each failed check, with GITBAK_ERROR set")
+	fs.StringVar(&c.OnStop, "on-stop", c.OnStop, "Run this shell command when the session ends")
+	fs.BoolVar(&c.AssumeYes, "yes", c.AssumeYes, "Answer yes to confirmation prompts and accept generated commit messages (e.g. for abort, squash)")
+	fs.StringVar(&c.SquashMessage, "message", c.SquashMessage, "Subject line of the squash commit, skipping the editor")
+	fs.BoolVar(&c.BundleSessionOnly, "session-only", c.BundleSessionOnly, "Bundle only the commits made since the session started (export-bundle)")
+	fs.BoolVar(&c.FollowLogs, "follow", c.FollowLogs, "Keep printing log records as they are written (logs)")
+	fs.BoolVar(&c.FollowLogs, "f", c.FollowLogs, "Short for -follow")
+	fs.IntVar(&c.LogLines, "lines", c.LogLines, "Print this many of the last log records (logs, 0 = all)")
 	fs.BoolVar(&c.Version, "version", c.Version, "Print version information and exit")
 	fs.BoolVar(&c.ShowLogo, "logo", c.ShowLogo, "Display ASCII logo and exit")
 	fs.BoolVar(&c.ShowHelp, "help", c.ShowHelp, "Display help message and exit")
 	fs.IntVar(&c.MaxRetries, "max-retries", c.MaxRetries, "Maximum consecutive identical errors before quitting (0 = unlimited)")
+	fs.DurationVar(&c.RetryBackoff, "retry-backoff", c.RetryBackoff, "Wait after a failed check before retrying, doubled for each repeat of the error (0 = retry at the next check)")
+	fs.DurationVar(&c.RetryBackoffMax, "retry-backoff-max", c.RetryBackoffMax, "Longest wait between retries of a failing check")
+	fs.DurationVar(&c.OpTimeout, "op-timeout", c.OpTimeout, "Time limit for each checkpoint or push before it is canceled and retried (0 = unlimited)")
+	fs.DurationVar(&c.CommandTimeout, "command-timeout", c.CommandTimeout, "Time limit for each git command before it is killed (0 = unlimited)")
+	fs.StringVar(&c.OnDiverge, "on-diverge", c.OnDiverge, "When the branch's history is rewritten under the session: warn, or pause checkpointing until resumed")
+	fs.BoolVar(&c.CommitOnExit, "commit-on-exit", c.CommitOnExit, "Make a final checkpoint of pending changes after Ctrl+C or gitbak stop (-commit-on-exit=false to skip it)")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "Time limit for the final checkpoint and summary after Ctrl+C or gitbak stop (0 = unlimited)")
+	fs.DurationVar(&c.LockWait, "lock-wait", c.LockWait, "How long to wait for another gitbak instance to release the lock (0 = fail immediately)")
+	fs.BoolVar(&c.Takeover, "takeover", c.Takeover, "Stop another gitbak instance monitoring the repository and take its place")
+	fs.BoolVar(&c.Join, "join", c.Join, "Watch the session of another gitbak instance monitoring the repository, without checkpointing")
+	fs.StringVar(&c.LockScope, "lock-scope", c.LockScope, "What the lock guards: worktree (one session per worktree) or repository (one across all worktrees)")
+	fs.DurationVar(&c.LockStaleAfter, "lock-stale-after", c.LockStaleAfter, "Reclaim a lock not refreshed for this long, even if its PID is in use (0 = trust the PID)")
 
 	// Add test-specific flags if we're in a test build
 	// This calls the appropriate function based on build tags
@@ -214,12 +818,32 @@ func (c *Config) SetupFlags(fs *flag.FlagSet) {
 	c.ParsedQuiet = &quiet
 }
 
-// PrintUsage prints a formatted help message with command descriptions, examples, and grouped flags
+// PrintUsage prints a formatted help message with command descriptions, examples and the core flags.
+// The remaining flag groups are only listed; PrintHelpTopic shows them in detail.
 func (c *Config) PrintUsage(fs *flag.FlagSet, w io.Writer) {
+	c.printUsageHeader(w)
+
+	printGroup(w, fs, helpGroups[0])
+
+	_, _ = fmt.Fprintf(w, "More Options:\n")
+	for _, g := range helpGroups[1:] {
+		if len(flagsInGroup(g.name)) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %-13s %s\n", g.name, g.title)
+	}
+	_, _ = fmt.Fprintf(w, "\n")
+	_, _ = fmt.Fprintf(w, "Run '-help <group>' or '-help <flag>' for details, or '-help all' for every option.\n\n")
+
+	printEnvironment(w, fs)
+}
+
+// printUsageHeader prints the description, examples and commands shown at the top of the help
+func (c *Config) printUsageHeader(w io.Writer) {
 	programName := filepath.Base(os.Args[0])
 
 	_, _ = fmt.Fprintf(w, "gitbak: An automatic commit safety net\n\n")
-	_, _ = fmt.Fprintf(w, "Usage: %s [options]\n\n", programName)
+	_, _ = fmt.Fprintf(w, "Usage: %s [command] [options]\n\n", programName)
 	_, _ = fmt.Fprintf(w, "gitbak automatically creates checkpoint commits at regular intervals,\n")
 	_, _ = fmt.Fprintf(w, "providing protection against accidental code loss during programming sessions.\n\n")
 
@@ -228,47 +852,29 @@ func (c *Config) PrintUsage(fs *flag.FlagSet, w io.Writer) {
 	_, _ = fmt.Fprintf(w, "  %s -interval 1                        # Commit every minute\n", programName)
 	_, _ = fmt.Fprintf(w, "  %s -interval 0.1 -prefix \"[pair]\"   # Commit every 6 seconds with custom prefix\n", programName)
 	_, _ = fmt.Fprintf(w, "  %s -branch feature-backup -no-branch  # Use existing branch instead of creating\n", programName)
-	_, _ = fmt.Fprintf(w, "  %s -continue                          # Continue numbering from previous session\n\n", programName)
-
-	// Group flags by category
-	_, _ = fmt.Fprintf(w, "Core Options:\n")
-	printFlagIfExists(w, fs, "interval")
-	printFlagIfExists(w, fs, "branch")
-	printFlagIfExists(w, fs, "prefix")
-	printFlagIfExists(w, fs, "no-branch")
-	printFlagIfExists(w, fs, "repo")
-	printFlagIfExists(w, fs, "continue")
-	_, _ = fmt.Fprintf(w, "\n")
-
-	_, _ = fmt.Fprintf(w, "Output Options:\n")
-	printFlagIfExists(w, fs, "quiet")
-	printFlagIfExists(w, fs, "show-no-changes")
-	printFlagIfExists(w, fs, "debug")
-	printFlagIfExists(w, fs, "log-file")
+	_, _ = fmt.Fprintf(w, "  %s -continue                          # Continue numbering from previous session\n", programName)
+	_, _ = fmt.Fprintf(w, "  %s start -detach                      # Run in the background; see 'status' and 'stop'\n\n", programName)
+
+	_, _ = fmt.Fprintf(w, "Commands:\n")
+	_, _ = fmt.Fprintf(w, "  start: Start a monitoring session (the default when no command is given)\n")
+	_, _ = fmt.Fprintf(w, "  stop: Gracefully stop the gitbak process monitoring the repository\n")
+	_, _ = fmt.Fprintf(w, "  status: Show whether gitbak is running, its checkpoint count and when the next check is due\n")
+	_, _ = fmt.Fprintf(w, "  pause: Pause checkpointing in the running session, e.g. during an interactive rebase\n")
+	_, _ = fmt.Fprintf(w, "  resume: Resume checkpointing in a paused session\n")
+	_, _ = fmt.Fprintf(w, "  nudge: Ask the running session to check for changes now (needs -nudge-addr)\n")
+	_, _ = fmt.Fprintf(w, "  commit-now: Ask the running session to checkpoint changes right away, even while paused\n")
+	_, _ = fmt.Fprintf(w, "  sessions: List the recorded sessions of every repository\n")
+	_, _ = fmt.Fprintf(w, "  stats: Summarize the recorded sessions: checkpoints, time, busiest hours and per-repository totals\n")
+	_, _ = fmt.Fprintf(w, "  logs [path]: Print the repository's log file, or its path; -f follows it\n")
+	_, _ = fmt.Fprintf(w, "  ignores [path...]: Print the exclusion rules in effect, or which rule excludes each path\n")
+	_, _ = fmt.Fprintf(w, "  abort: Discard the last session: return to the original branch and delete the gitbak branch\n")
+	_, _ = fmt.Fprintf(w, "  squash: Fold the last session into one commit on the original branch, with a generated message\n")
+	_, _ = fmt.Fprintf(w, "  verify: Check that the last session's checkpoints have not been rewritten\n")
+	_, _ = fmt.Fprintf(w, "  diff [session] <n> <m>: Show the changes between two checkpoints of the last session, or of the named one\n")
+	_, _ = fmt.Fprintf(w, "  export-bundle [file|dir]: Write the last session's branch to a git bundle, e.g. to move it to another machine\n")
+	_, _ = fmt.Fprintf(w, "  materialize [branch]: Create a branch from the checkpoints the last session wrote with -refs-only\n")
+	_, _ = fmt.Fprintf(w, "  completion <bash|zsh|fish>: Print a shell completion script\n")
 	_, _ = fmt.Fprintf(w, "\n")
-
-	_, _ = fmt.Fprintf(w, "Error Handling:\n")
-	printFlagIfExists(w, fs, "max-retries")
-	_, _ = fmt.Fprintf(w, "\n")
-
-	_, _ = fmt.Fprintf(w, "Information:\n")
-	printFlagIfExists(w, fs, "version")
-	printFlagIfExists(w, fs, "logo")
-	printFlagIfExists(w, fs, "help")
-	_, _ = fmt.Fprintf(w, "\n")
-
-	_, _ = fmt.Fprintf(w, "Environment variables:\n")
-	_, _ = fmt.Fprintf(w, "  INTERVAL_MINUTES          Minutes between commits (supports decimal values)\n")
-	_, _ = fmt.Fprintf(w, "  BRANCH_NAME               Branch name to use\n")
-	_, _ = fmt.Fprintf(w, "  COMMIT_PREFIX             Custom prefix for commit messages\n")
-	_, _ = fmt.Fprintf(w, "  CREATE_BRANCH             Whether to create a new branch (true/false)\n")
-	_, _ = fmt.Fprintf(w, "  VERBOSE                   Whether to show informational messages (true/false)\n")
-	_, _ = fmt.Fprintf(w, "  SHOW_NO_CHANGES           Whether to show 'no changes' messages (true/false)\n")
-	_, _ = fmt.Fprintf(w, "  REPO_PATH                 Path to repository\n")
-	_, _ = fmt.Fprintf(w, "  CONTINUE_SESSION          Whether to continue from existing branch (true/false)\n")
-	_, _ = fmt.Fprintf(w, "  DEBUG                     Enable debug logging (true/false)\n")
-	_, _ = fmt.Fprintf(w, "  LOG_FILE                  Path to log file\n")
-	_, _ = fmt.Fprintf(w, "  MAX_RETRIES               Maximum consecutive identical errors before quitting\n")
 }
 
 // printFlagIfExists prints a flag's usage if it exists in the FlagSet
@@ -289,15 +895,32 @@ func printFlagIfExists(w io.Writer, fs *flag.FlagSet, name string) {
 
 // ParseFlags parses the command-line arguments and updates the config
 func (c *Config) ParseFlags() error {
-	for _, arg := range os.Args[1:] {
-		if arg == "--help" || arg == "-help" || arg == "-h" || arg == "--h" {
-			// Create a fake FlagSet to set up flags for help display
-			fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
-			c.SetupFlags(fs)
+	var args []string
+	// Skip the program name (os.Args[0])
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+	return c.ParseArgs(args)
+}
+
+// ParseArgs parses the given arguments (excluding the program name) and updates the config.
+// Subcommands use this to parse the flags that follow the command name.
+func (c *Config) ParseArgs(args []string) error {
+	if topic, ok := helpTopic(args); ok {
+		// Create a fake FlagSet to set up flags for help display
+		fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+		c.SetupFlags(fs)
 
+		if topic == "" {
 			c.PrintUsage(fs, os.Stdout)
 			os.Exit(0)
 		}
+
+		if err := c.PrintHelpTopic(fs, os.Stdout, topic); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return gitbakErrors.NewConfigError("help", topic, err)
+		}
+		os.Exit(0)
 	}
 
 	// Create a flag set with custom error handling to suppress
@@ -320,15 +943,7 @@ func (c *Config) ParseFlags() error {
 
 	c.SetupFlags(fs)
 
-	// For simplicity, parse whatever arguments were passed to the program.
-	// If running under 'go test', the test binary will handle test flags separately.
-	var appArgs []string
-	// Skip the program name (os.Args[0])
-	if len(os.Args) > 1 {
-		appArgs = os.Args[1:]
-	}
-
-	if err := fs.Parse(appArgs); err != nil {
+	if err := fs.Parse(args); err != nil {
 		helpFS := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 		c.SetupFlags(helpFS)
 
@@ -338,6 +953,13 @@ func (c *Config) ParseFlags() error {
 
 		return gitbakErrors.NewConfigError("flags", nil, gitbakErrors.Wrap(gitbakErrors.ErrInvalidFlag, err.Error()))
 	}
+	c.Args = fs.Args()
+
+	// Configuration files fill in whatever the environment and flags left unset
+	if err := c.applyConfigFiles(fs); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return err
+	}
 
 	// Apply inverted flags only after successful parsing
 	if c.ParsedNoBranch != nil {
@@ -350,11 +972,410 @@ func (c *Config) ParseFlags() error {
 	return nil
 }
 
+// helpTopic reports whether args request help and returns the requested topic, if any.
+// Both "-help <topic>" and "-help=<topic>" are accepted.
+func helpTopic(args []string) (string, bool) {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "help" && name != "h") {
+			continue
+		}
+		if hasValue {
+			return value, true
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			return args[i+1], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
 // Finalize validates and finalizes the configuration
 func (c *Config) Finalize() error {
-	if c.IntervalMinutes <= 0 {
-		err := fmt.Errorf("invalid interval: %.2f (must be greater than 0)", c.IntervalMinutes)
-		return gitbakErrors.NewConfigError("interval", c.IntervalMinutes, gitbakErrors.Wrap(err, "invalid interval"))
+	if c.Interval <= 0 {
+		err := fmt.Errorf("invalid interval: %s (must be greater than 0)", c.Interval)
+		return gitbakErrors.NewConfigError("interval", c.Interval, gitbakErrors.Wrap(err, "invalid interval"))
+	}
+
+	if c.MinInterval < 0 || c.MinInterval > c.Interval {
+		err := fmt.Errorf("invalid minimum interval: %s (must be between 0 and the interval, %s)", c.MinInterval, c.Interval)
+		return gitbakErrors.NewConfigError("minInterval", c.MinInterval, gitbakErrors.Wrap(err, "invalid minimum interval"))
+	}
+
+	if c.MaxInterval < 0 || (c.MaxInterval > 0 && c.MaxInterval < c.Interval) {
+		err := fmt.Errorf("invalid maximum interval: %s (must be 0 or at least the interval, %s)", c.MaxInterval, c.Interval)
+		return gitbakErrors.NewConfigError("maxInterval", c.MaxInterval, gitbakErrors.Wrap(err, "invalid maximum interval"))
+	}
+
+	if c.IdleAfter < 0 {
+		err := fmt.Errorf("invalid idle after: %d (must not be negative)", c.IdleAfter)
+		return gitbakErrors.NewConfigError("idleAfter", c.IdleAfter, gitbakErrors.Wrap(err, "invalid idle after"))
+	}
+	if c.IdleAfter > 0 && c.IdleInterval < c.Interval {
+		err := fmt.Errorf("invalid idle interval: %s (must be at least the interval, %s)", c.IdleInterval, c.Interval)
+		return gitbakErrors.NewConfigError("idleInterval", c.IdleInterval, gitbakErrors.Wrap(err, "invalid idle interval"))
+	}
+
+	marks, err := parseAlign(c.Align)
+	if err != nil {
+		return gitbakErrors.NewConfigError("align", c.Align, gitbakErrors.Wrap(err, "invalid align"))
+	}
+	c.AlignMarks = marks
+
+	if c.LogMaxSizeMB < 0 || c.LogMaxFiles < 0 || c.LogMaxAgeDays < 0 {
+		err := fmt.Errorf("invalid log retention: %dMB, %d files, %d days (must not be negative)",
+			c.LogMaxSizeMB, c.LogMaxFiles, c.LogMaxAgeDays)
+		return gitbakErrors.NewConfigError("logMaxSizeMB", c.LogMaxSizeMB, gitbakErrors.Wrap(err, "invalid log retention"))
+	}
+	if c.LogLines < 0 {
+		err := fmt.Errorf("invalid lines: %d (must not be negative)", c.LogLines)
+		return gitbakErrors.NewConfigError("logLines", c.LogLines, gitbakErrors.Wrap(err, "invalid lines"))
+	}
+
+	// The control endpoint is unauthenticated, so it is only served to this machine
+	if c.ListenAddr != "" && !strings.HasPrefix(c.ListenAddr, control.UnixAddrPrefix) {
+		host, _, err := net.SplitHostPort(c.ListenAddr)
+		if err != nil || !control.IsLoopbackHost(host) {
+			err := fmt.Errorf("invalid listen address: %s (must be unix:<path> or host:port on a loopback address, e.g. 127.0.0.1:7373)", c.ListenAddr)
+			return gitbakErrors.NewConfigError("listen", c.ListenAddr, gitbakErrors.Wrap(err, "invalid listen address"))
+		}
+	}
+
+	if c.MinChangedLines < 0 || c.MinChangedFiles < 0 || c.MaxSkippedChecks < 0 {
+		err := fmt.Errorf("invalid change threshold: %d lines or %d files, up to %d skipped checks (must not be negative)",
+			c.MinChangedLines, c.MinChangedFiles, c.MaxSkippedChecks)
+		return gitbakErrors.NewConfigError("minChangedLines", c.MinChangedLines, gitbakErrors.Wrap(err, "invalid change threshold"))
+	}
+
+	// -refs-only is a name for -mode refs, so it conflicts with the other modes
+	if c.RefsOnly {
+		if c.Mode != "" && c.Mode != DefaultMode && c.Mode != "refs" {
+			err := fmt.Errorf("invalid refs-only: cannot be combined with -mode %s", c.Mode)
+			return gitbakErrors.NewConfigError("refsOnly", c.RefsOnly, gitbakErrors.Wrap(err, "invalid refs-only"))
+		}
+		c.Mode = "refs"
+	}
+
+	// Thresholds apply to checkpoint commits and are measured in a scratch index, which gogit cannot use
+	if (c.MinChangedLines > 0 || c.MinChangedFiles > 0) && (c.Mode == "stash" || c.Mode == "refs" || c.GitBackend == "gogit") {
+		err := fmt.Errorf("invalid change threshold: cannot be combined with -mode stash or refs, or -git-backend gogit")
+		return gitbakErrors.NewConfigError("minChangedLines", c.MinChangedLines, gitbakErrors.Wrap(err, "invalid change threshold"))
+	}
+
+	if c.MinQuietSeconds < 0 {
+		err := fmt.Errorf("invalid quiet period: %.2f seconds (must not be negative)", c.MinQuietSeconds)
+		return gitbakErrors.NewConfigError("minQuietSeconds", c.MinQuietSeconds, gitbakErrors.Wrap(err, "invalid quiet period"))
+	}
+	// Stash snapshots, checkpoint refs and journal entries record the tree as it is, whatever is being written
+	if c.MinQuietSeconds > 0 && (c.Mode == "stash" || c.Mode == "refs" || c.Mode == "observe") {
+		err := fmt.Errorf("invalid quiet period: cannot be combined with -mode stash, refs or observe")
+		return gitbakErrors.NewConfigError("minQuietSeconds", c.MinQuietSeconds, gitbakErrors.Wrap(err, "invalid quiet period"))
+	}
+
+	if c.OnCheckFail == "" {
+		c.OnCheckFail = DefaultOnCheckFail
+	}
+	if !slices.Contains([]string{"mark", "skip"}, c.OnCheckFail) {
+		err := fmt.Errorf("invalid check failure mode: %q (must be mark or skip)", c.OnCheckFail)
+		return gitbakErrors.NewConfigError("onCheckFail", c.OnCheckFail, gitbakErrors.Wrap(err, "invalid check failure mode"))
+	}
+	// Checks run before checkpoint commits on a branch; the other modes have none to hold back or mark
+	if c.CheckCommand != "" && (c.Mode == "stash" || c.Mode == "refs" || c.Mode == "observe") {
+		err := fmt.Errorf("invalid check command: cannot be combined with -mode stash, refs or observe")
+		return gitbakErrors.NewConfigError("checkCmd", c.CheckCommand, gitbakErrors.Wrap(err, "invalid check command"))
+	}
+
+	if c.MaxFileSizeMB < 0 {
+		err := fmt.Errorf("invalid max file size: %dMB (must not be negative)", c.MaxFileSizeMB)
+		return gitbakErrors.NewConfigError("maxFileSizeMB", c.MaxFileSizeMB, gitbakErrors.Wrap(err, "invalid max file size"))
+	}
+	// Leaving files out takes pathspec exclusions, which gogit's staging cannot apply
+	if c.MaxFileSizeMB > 0 && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid max file size: cannot be combined with -git-backend gogit")
+		return gitbakErrors.NewConfigError("maxFileSizeMB", c.MaxFileSizeMB, gitbakErrors.Wrap(err, "invalid max file size"))
+	}
+
+	for i, path := range c.Paths {
+		if !filepath.IsLocal(path) {
+			err := fmt.Errorf("invalid path: %q (must be relative to the repository, and inside it)", path)
+			return gitbakErrors.NewConfigError("path", path, gitbakErrors.Wrap(err, "invalid path"))
+		}
+		c.Paths[i] = filepath.ToSlash(filepath.Clean(path))
+	}
+	// Scoping checkpoints takes pathspecs, which gogit's staging cannot apply
+	if len(c.Paths) > 0 && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid path: cannot be combined with -git-backend gogit")
+		return gitbakErrors.NewConfigError("path", strings.Join(c.Paths, ";"), gitbakErrors.Wrap(err, "invalid path"))
+	}
+
+	if c.BatteryThreshold < 0 || c.BatteryThreshold > 100 {
+		err := fmt.Errorf("invalid battery threshold: %d (must be a percentage between 0 and 100)", c.BatteryThreshold)
+		return gitbakErrors.NewConfigError("batteryThreshold", c.BatteryThreshold, gitbakErrors.Wrap(err, "invalid battery threshold"))
+	}
+
+	if c.BatteryInterval < 0 {
+		err := fmt.Errorf("invalid battery interval: %s (must not be negative)", c.BatteryInterval)
+		return gitbakErrors.NewConfigError("batteryInterval", c.BatteryInterval, gitbakErrors.Wrap(err, "invalid battery interval"))
+	}
+
+	if c.PushInterval < 0 {
+		err := fmt.Errorf("invalid push interval: %s (must not be negative)", c.PushInterval)
+		return gitbakErrors.NewConfigError("pushInterval", c.PushInterval, gitbakErrors.Wrap(err, "invalid push interval"))
+	}
+
+	if c.OpTimeout < 0 {
+		err := fmt.Errorf("invalid operation timeout: %s (must not be negative)", c.OpTimeout)
+		return gitbakErrors.NewConfigError("opTimeout", c.OpTimeout, gitbakErrors.Wrap(err, "invalid operation timeout"))
+	}
+
+	if c.CommandTimeout < 0 {
+		err := fmt.Errorf("invalid command timeout: %s (must not be negative)", c.CommandTimeout)
+		return gitbakErrors.NewConfigError("commandTimeout", c.CommandTimeout, gitbakErrors.Wrap(err, "invalid command timeout"))
+	}
+
+	if c.ShutdownTimeout < 0 {
+		err := fmt.Errorf("invalid shutdown timeout: %s (must not be negative)", c.ShutdownTimeout)
+		return gitbakErrors.NewConfigError("shutdownTimeout", c.ShutdownTimeout, gitbakErrors.Wrap(err, "invalid shutdown timeout"))
+	}
+
+	if c.LockWait < 0 {
+		err := fmt.Errorf("invalid lock wait: %s (must not be negative)", c.LockWait)
+		return gitbakErrors.NewConfigError("lockWait", c.LockWait, gitbakErrors.Wrap(err, "invalid lock wait"))
+	}
+
+	// Each of them picks what happens when another instance holds the lock
+	if (c.Takeover && c.Join) || ((c.Takeover || c.Join) && c.LockWait > 0) {
+		err := fmt.Errorf("invalid lock handling: only one of -takeover, -join and -lock-wait can be given")
+		return gitbakErrors.NewConfigError("takeover", c.Takeover, gitbakErrors.Wrap(err, "invalid lock handling"))
+	}
+	// A detached session has no terminal to show the view in
+	if c.Join && c.Detach {
+		err := fmt.Errorf("invalid join: cannot be combined with -detach")
+		return gitbakErrors.NewConfigError("join", c.Join, gitbakErrors.Wrap(err, "invalid join"))
+	}
+
+	if c.LockScope == "" {
+		c.LockScope = DefaultLockScope
+	}
+	if !slices.Contains([]string{"worktree", "repository"}, c.LockScope) {
+		err := fmt.Errorf("invalid lock scope: %q (must be worktree or repository)", c.LockScope)
+		return gitbakErrors.NewConfigError("lockScope", c.LockScope, gitbakErrors.Wrap(err, "invalid lock scope"))
+	}
+
+	// A shorter threshold would reclaim the lock of a session that is merely busy
+	if c.LockStaleAfter < 0 || (c.LockStaleAfter > 0 && c.LockStaleAfter < lock.MinStaleAfter) {
+		err := fmt.Errorf("invalid lock stale after: %s (must be 0 or at least %s)", c.LockStaleAfter, lock.MinStaleAfter)
+		return gitbakErrors.NewConfigError("lockStaleAfter", c.LockStaleAfter, gitbakErrors.Wrap(err, "invalid lock stale after"))
+	}
+
+	if c.Notify == "" {
+		c.Notify = notify.ModeOff
+	}
+	if !slices.Contains(notify.Modes, c.Notify) {
+		err := fmt.Errorf("invalid notify mode: %q (must be one of %s)", c.Notify, strings.Join(notify.Modes, ", "))
+		return gitbakErrors.NewConfigError("notify", c.Notify, gitbakErrors.Wrap(err, "invalid notify mode"))
+	}
+
+	// The other options act on checkpoint commits on a branch, which stash, refs and observe modes do not make
+	if (c.Mode == "stash" || c.Mode == "refs" || c.Mode == "observe") && (c.ContinueSession || c.ChainTrailer || c.Push != "" || len(c.Mirrors) > 0) {
+		err := fmt.Errorf("invalid checkpoint mode: %s (cannot be combined with -continue, -chain-trailer, -push or -mirror)", c.Mode)
+		return gitbakErrors.NewConfigError("mode", c.Mode, gitbakErrors.Wrap(err, "invalid checkpoint mode"))
+	}
+
+	if c.GitBackend == "" {
+		c.GitBackend = DefaultGitBackend
+	}
+	if c.GitBackend != "exec" && c.GitBackend != "gogit" {
+		err := fmt.Errorf("invalid git backend: %q (must be exec or gogit)", c.GitBackend)
+		return gitbakErrors.NewConfigError("gitBackend", c.GitBackend, gitbakErrors.Wrap(err, "invalid git backend"))
+	}
+
+	// Pushing, stashing, writing refs, journaling and diff summaries are left to the git binary
+	if c.GitBackend == "gogit" && (c.Push != "" || len(c.Mirrors) > 0 || c.Mode == "stash" || c.Mode == "refs" || c.Mode == "observe" || c.DiffSummary) {
+		err := fmt.Errorf("invalid git backend: gogit (cannot be combined with -push, -mirror, -mode stash, refs or observe, or -diff-summary)")
+		return gitbakErrors.NewConfigError("gitBackend", c.GitBackend, gitbakErrors.Wrap(err, "invalid git backend"))
+	}
+
+	if c.Submodules == "" {
+		c.Submodules = DefaultSubmodules
+	}
+	if !slices.Contains([]string{"include", "ignore", "recursive"}, c.Submodules) {
+		err := fmt.Errorf("invalid submodule mode: %q (must be include, ignore or recursive)", c.Submodules)
+		return gitbakErrors.NewConfigError("submodules", c.Submodules, gitbakErrors.Wrap(err, "invalid submodule mode"))
+	}
+	// Only recording new submodule commits works without the git binary, and stash and refs modes commit on no branch
+	if (c.Submodules != "include" && c.GitBackend == "gogit") || (c.Submodules == "recursive" && (c.Mode == "stash" || c.Mode == "refs")) {
+		err := fmt.Errorf("invalid submodule mode: %s (cannot be combined with -git-backend gogit, nor recursive with -mode stash or refs)", c.Submodules)
+		return gitbakErrors.NewConfigError("submodules", c.Submodules, gitbakErrors.Wrap(err, "invalid submodule mode"))
+	}
+
+	if c.UntrackedFiles == "" {
+		c.UntrackedFiles = DefaultUntrackedFiles
+	}
+	if !slices.Contains([]string{"normal", "all", "no"}, c.UntrackedFiles) {
+		err := fmt.Errorf("invalid untracked files mode: %q (must be normal, all or no)", c.UntrackedFiles)
+		return gitbakErrors.NewConfigError("untrackedFiles", c.UntrackedFiles, gitbakErrors.Wrap(err, "invalid untracked files mode"))
+	}
+	// gogit's status always reports new files as git's default does
+	if c.UntrackedFiles != DefaultUntrackedFiles && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid untracked files mode: %s (cannot be combined with -git-backend gogit)", c.UntrackedFiles)
+		return gitbakErrors.NewConfigError("untrackedFiles", c.UntrackedFiles, gitbakErrors.Wrap(err, "invalid untracked files mode"))
+	}
+
+	if c.UntrackedPolicy == "" {
+		c.UntrackedPolicy = DefaultUntrackedPolicy
+	}
+	if !slices.Contains([]string{"all", "none", "prompt"}, c.UntrackedPolicy) {
+		err := fmt.Errorf("invalid untracked policy: %q (must be all, none or prompt)", c.UntrackedPolicy)
+		return gitbakErrors.NewConfigError("untrackedPolicy", c.UntrackedPolicy, gitbakErrors.Wrap(err, "invalid untracked policy"))
+	}
+	// Leaving new files out relies on git ls-files, which gogit cannot run
+	if c.UntrackedPolicy != DefaultUntrackedPolicy && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid untracked policy: %s (cannot be combined with -git-backend gogit)", c.UntrackedPolicy)
+		return gitbakErrors.NewConfigError("untrackedPolicy", c.UntrackedPolicy, gitbakErrors.Wrap(err, "invalid untracked policy"))
+	}
+
+	if c.OnDiverge == "" {
+		c.OnDiverge = DefaultOnDiverge
+	}
+	if !slices.Contains([]string{"warn", "pause"}, c.OnDiverge) {
+		err := fmt.Errorf("invalid divergence mode: %q (must be warn or pause)", c.OnDiverge)
+		return gitbakErrors.NewConfigError("onDiverge", c.OnDiverge, gitbakErrors.Wrap(err, "invalid divergence mode"))
+	}
+
+	if (c.CommitAuthor == "") != (c.CommitEmail == "") || strings.ContainsAny(c.CommitAuthor+c.CommitEmail, "<>\n") {
+		err := fmt.Errorf("invalid commit author: %q <%s> (-author and -author-email must be given together, without angle brackets)", c.CommitAuthor, c.CommitEmail)
+		return gitbakErrors.NewConfigError("author", c.CommitAuthor, gitbakErrors.Wrap(err, "invalid commit author"))
+	}
+	// The identity is passed to git through its environment, which gogit does not read
+	if c.CommitAuthor != "" && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid commit author: cannot be combined with -git-backend gogit")
+		return gitbakErrors.NewConfigError("author", c.CommitAuthor, gitbakErrors.Wrap(err, "invalid commit author"))
+	}
+
+	for _, coAuthor := range c.CoAuthors {
+		if !coAuthorPattern.MatchString(coAuthor) {
+			err := fmt.Errorf("invalid co-author: %q (must be given as 'Name <email>')", coAuthor)
+			return gitbakErrors.NewConfigError("coauthor", coAuthor, gitbakErrors.Wrap(err, "invalid co-author"))
+		}
+	}
+
+	// Scanning reads the changes with git diff, which gogit cannot run, so it is off there by default
+	if c.Secrets == "" {
+		c.Secrets = "skip"
+		if c.GitBackend == "gogit" {
+			c.Secrets = "off"
+		}
+	}
+	if !slices.Contains([]string{"skip", "abort", "off"}, c.Secrets) {
+		err := fmt.Errorf("invalid secrets mode: %q (must be skip, abort or off)", c.Secrets)
+		return gitbakErrors.NewConfigError("secrets", c.Secrets, gitbakErrors.Wrap(err, "invalid secrets mode"))
+	}
+	if c.Secrets != "off" && c.GitBackend == "gogit" {
+		err := fmt.Errorf("invalid secrets mode: %s cannot be combined with -git-backend gogit", c.Secrets)
+		return gitbakErrors.NewConfigError("secrets", c.Secrets, gitbakErrors.Wrap(err, "invalid secrets mode"))
+	}
+
+	// The log level defaults to info, or warn with -quiet, and each -v raises it
+	if c.LogLevel == "" {
+		c.LogLevel = c.Verbosity().String()
+	}
+	verbosity, err := logger.ParseVerbosity(c.LogLevel)
+	if err != nil {
+		return gitbakErrors.NewConfigError("logLevel", c.LogLevel, gitbakErrors.Wrap(err, "invalid log level"))
+	}
+	verbosity = min(verbosity+logger.Verbosity(c.VerboseLevels), logger.VerbosityTrace)
+	c.LogLevel, c.VerboseLevels = verbosity.String(), 0
+	c.Verbose = verbosity >= logger.VerbosityInfo
+
+	if c.Output == "" {
+		c.Output = DefaultOutput
+	}
+	if c.Output != "text" && c.Output != "json" {
+		err := fmt.Errorf("invalid output format: %q (must be text or json)", c.Output)
+		return gitbakErrors.NewConfigError("output", c.Output, gitbakErrors.Wrap(err, "invalid output format"))
+	}
+	if c.LogBackend == "" {
+		c.LogBackend = DefaultLogBackend
+	}
+	if !slices.Contains(logger.Backends, logger.Backend(c.LogBackend)) {
+		err := fmt.Errorf("invalid log backend: %q (must be text, json, syslog or journald)", c.LogBackend)
+		return gitbakErrors.NewConfigError("logBackend", c.LogBackend, gitbakErrors.Wrap(err, "invalid log backend"))
+	}
+	if !logger.Backend(c.LogBackend).UsesFile() {
+		c.Debug = true
+	}
+
+	// JSON output keeps stdout for the summary, which the dashboard would draw over
+	if c.Output == "json" && c.TUI {
+		err := fmt.Errorf("invalid output format: json cannot be combined with -tui")
+		return gitbakErrors.NewConfigError("output", c.Output, gitbakErrors.Wrap(err, "invalid output format"))
+	}
+
+	// A detached session has no terminal to draw in
+	if c.TUI && c.Detach {
+		err := fmt.Errorf("invalid tui: cannot be combined with -detach")
+		return gitbakErrors.NewConfigError("tui", c.TUI, gitbakErrors.Wrap(err, "invalid tui"))
+	}
+
+	// The protocol needs stdin and stdout to itself, and the editor hosting the session
+	if c.RPC && (c.TUI || c.Output == "json" || c.Detach || c.Join) {
+		err := fmt.Errorf("invalid rpc: cannot be combined with -tui, -output json, -detach or -join")
+		return gitbakErrors.NewConfigError("rpc", c.RPC, gitbakErrors.Wrap(err, "invalid rpc"))
+	}
+	if c.RPC {
+		// stdin carries requests, so there is nobody to answer a prompt
+		c.NonInteractive = true
+	}
+
+	if c.RetryBackoff < 0 || c.RetryBackoffMax < 0 {
+		err := fmt.Errorf("invalid retry backoff: %s up to %s (must not be negative)", c.RetryBackoff, c.RetryBackoffMax)
+		return gitbakErrors.NewConfigError("retryBackoff", c.RetryBackoff, gitbakErrors.Wrap(err, "invalid retry backoff"))
+	}
+
+	if c.OTLPEndpoint != "" {
+		if u, err := url.Parse(c.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			err := fmt.Errorf("invalid OTLP endpoint: %q (must be an http:// or https:// URL, e.g. http://localhost:4318)", c.OTLPEndpoint)
+			return gitbakErrors.NewConfigError("otlpEndpoint", c.OTLPEndpoint, gitbakErrors.Wrap(err, "invalid OTLP endpoint"))
+		}
+	}
+
+	// Relative paths are taken from the current directory, as git takes GIT_DIR and GIT_WORK_TREE
+	if c.GitDir != "" {
+		absGitDir, err := filepath.Abs(c.GitDir)
+		if err != nil {
+			return gitbakErrors.NewConfigError("gitDir", c.GitDir, gitbakErrors.Wrap(err, "failed to resolve absolute path"))
+		}
+		if info, err := os.Stat(absGitDir); err != nil || !info.IsDir() {
+			err := fmt.Errorf("invalid git dir: %q (must be an existing directory)", c.GitDir)
+			return gitbakErrors.NewConfigError("gitDir", c.GitDir, gitbakErrors.Wrap(err, "invalid git dir"))
+		}
+		c.GitDir = absGitDir
+	}
+	if c.WorkTree != "" {
+		if c.GitDir == "" {
+			err := fmt.Errorf("invalid work tree: %q (requires -git-dir)", c.WorkTree)
+			return gitbakErrors.NewConfigError("workTree", c.WorkTree, gitbakErrors.Wrap(err, "invalid work tree"))
+		}
+		absWorkTree, err := filepath.Abs(c.WorkTree)
+		if err != nil {
+			return gitbakErrors.NewConfigError("workTree", c.WorkTree, gitbakErrors.Wrap(err, "failed to resolve absolute path"))
+		}
+		if info, err := os.Stat(absWorkTree); err != nil || !info.IsDir() {
+			err := fmt.Errorf("invalid work tree: %q (must be an existing directory)", c.WorkTree)
+			return gitbakErrors.NewConfigError("workTree", c.WorkTree, gitbakErrors.Wrap(err, "invalid work tree"))
+		}
+		c.WorkTree = absWorkTree
+		if c.RepoPath == "" {
+			c.RepoPath = c.WorkTree
+		}
+	}
+	// gogit opens the repository around the work tree itself, and a submodule's git commands
+	// would be sent to the superproject's git directory
+	if c.GitDir != "" && (c.GitBackend == "gogit" || c.Submodules == "recursive") {
+		err := fmt.Errorf("invalid git dir: cannot be combined with -git-backend gogit or -submodules recursive")
+		return gitbakErrors.NewConfigError("gitDir", c.GitDir, gitbakErrors.Wrap(err, "invalid git dir"))
 	}
 
 	if c.RepoPath == "" {
@@ -370,47 +1391,117 @@ func (c *Config) Finalize() error {
 		return gitbakErrors.NewConfigError("repoPath", c.RepoPath, gitbakErrors.Wrap(err, "failed to resolve absolute path"))
 	}
 	c.RepoPath = absRepoPath
-
-	if c.LogFile == "" {
-		// Follow XDG Base Directory Specification
-		logDir := os.Getenv("XDG_DATA_HOME")
-		if logDir == "" {
-			// Default XDG data home if not set
-			homeDir, err := os.UserHomeDir()
-			if err == nil {
-				logDir = filepath.Join(homeDir, ".local", "share")
-			} else {
-				logDir = os.TempDir()
-			}
+	if c.WorkTree != "" {
+		if rel, err := filepath.Rel(c.WorkTree, c.RepoPath); err != nil || !filepath.IsLocal(rel) {
+			err := fmt.Errorf("invalid repo: %q (must be inside the work tree %s)", c.RepoPath, c.WorkTree)
+			return gitbakErrors.NewConfigError("repoPath", c.RepoPath, gitbakErrors.Wrap(err, "invalid repo"))
 		}
+	}
 
-		repoHash := fmt.Sprintf("%x", sha256OfString(c.RepoPath)[:8])
+	repoHash := RepoID(c.RepoPath)
 
-		gitbakLogDir := filepath.Join(logDir, "gitbak", "logs")
-		c.LogFile = filepath.Join(gitbakLogDir, fmt.Sprintf("gitbak-%s.log", repoHash))
+	if c.LogFile == "" {
+		c.LogFile = DefaultLogFile(c.RepoPath)
 
 		if err := os.MkdirAll(filepath.Dir(c.LogFile), 0o700); err != nil {
 			return gitbakErrors.NewConfigError("logFile", c.LogFile, gitbakErrors.Wrap(err, "cannot create log directory"))
 		}
 	}
 
+	// A detached session runs from another directory, so resolve the report's path up front
+	if c.SummaryFile != "" {
+		absSummaryFile, err := filepath.Abs(c.SummaryFile)
+		if err != nil {
+			return gitbakErrors.NewConfigError("summaryFile", c.SummaryFile, gitbakErrors.Wrap(err, "failed to resolve absolute path"))
+		}
+		c.SummaryFile = absSummaryFile
+	}
+
+	profiles, err := mirror.ParseProfiles(c.Mirrors)
+	if err != nil {
+		return gitbakErrors.NewConfigError("mirror", strings.Join(c.Mirrors, ";"), err)
+	}
+	c.MirrorProfiles = profiles
+
+	if c.StateFile == "" {
+		c.StateFile = DefaultStateFile(c.RepoPath)
+	}
+
+	if c.Mode == "observe" {
+		if c.JournalFile == "" {
+			c.JournalFile = filepath.Join(dataHome(), "gitbak", "journals", fmt.Sprintf("gitbak-%s.jsonl", repoHash))
+		}
+		absJournalFile, err := filepath.Abs(c.JournalFile)
+		if err != nil {
+			return gitbakErrors.NewConfigError("journalFile", c.JournalFile, gitbakErrors.Wrap(err, "failed to resolve absolute path"))
+		}
+		c.JournalFile = absJournalFile
+	}
+
 	if c.BranchName == "" {
 		if c.ContinueSession {
-			currentBranch, err := getCurrentBranchName(c.RepoPath)
+			currentBranch, err := c.getCurrentBranchName()
 			if err != nil {
 				return gitbakErrors.NewConfigError("branchName", "",
 					gitbakErrors.Wrap(err, "failed to get current branch name in continue mode"))
 			}
 			c.BranchName = currentBranch
 		} else {
-			timestamp := time.Now().Format("20060102-150405")
-			c.BranchName = fmt.Sprintf("gitbak-%s", timestamp)
+			name, err := c.templateBranchName(time.Now())
+			if err != nil {
+				return gitbakErrors.NewConfigError("branchTemplate", c.BranchTemplate, gitbakErrors.Wrap(err, "invalid branch template"))
+			}
+			c.BranchName = name
 		}
 	}
 
 	return nil
 }
 
+// GitExecutable returns the git binary to run: GitPath, or git from PATH if it is empty
+func (c *Config) GitExecutable() string {
+	if c.GitPath == "" {
+		return "git"
+	}
+	return c.GitPath
+}
+
+// GitArgs returns the arguments passed to git before every command's own: the git directory
+// and work tree, if set, then GitGlobalArgs split into arguments
+func (c *Config) GitArgs() []string {
+	var args []string
+	if c.GitDir != "" {
+		args = append(args, "--git-dir="+c.GitDir)
+	}
+	if c.WorkTree != "" {
+		args = append(args, "--work-tree="+c.WorkTree)
+	}
+	return append(args, strings.Fields(c.GitGlobalArgs)...)
+}
+
+// Hooks returns the configured hook commands keyed by event, as hooks.New takes them
+func (c *Config) Hooks() map[string]string {
+	commands := make(map[string]string)
+	for event, command := range map[string]string{
+		hooks.EventStart:  c.OnStart,
+		hooks.EventCommit: c.OnCommit,
+		hooks.EventError:  c.OnError,
+		hooks.EventStop:   c.OnStop,
+	} {
+		if command != "" {
+			commands[event] = command
+		}
+	}
+	return commands
+}
+
+// IsDisabled reports whether the GITBAK_DISABLE kill switch is engaged.
+// The environment is consulted on every call so that the check can be
+// repeated on each monitoring tick.
+func IsDisabled() bool {
+	return getEnvBool(DisableEnvVar, false)
+}
+
 // getEnvString returns an environment variable string or a default value
 func getEnvString(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -439,6 +1530,26 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
+// getEnvDuration returns an environment variable as a time.Duration or a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if valueStr, exists := os.LookupEnv(key); exists {
+		if value, err := time.ParseDuration(valueStr); err == nil {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInterval returns an environment variable as an interval (see ParseInterval) or a default value
+func getEnvInterval(key string, defaultValue time.Duration) time.Duration {
+	if valueStr, exists := os.LookupEnv(key); exists {
+		if value, err := ParseInterval(valueStr); err == nil {
+			return value
+		}
+	}
+	return defaultValue
+}
+
 // getEnvBool returns an environment variable as bool or a default value
 func getEnvBool(key string, defaultValue bool) bool {
 	if valueStr, exists := os.LookupEnv(key); exists {
@@ -454,15 +1565,237 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvList gets a list of values from an environment variable, split on sep
+func getEnvList(key, sep string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// coAuthorPattern matches an identity as Co-authored-by trailers take it: "Name <email>"
+var coAuthorPattern = regexp.MustCompile(`^[^<>\n]*[^<>\s] <[^<>\s]+>$`)
+
+// Verbosity returns the console verbosity LogLevel selects. Before Finalize has
+// resolved LogLevel, an empty one selects info or, if Verbose is false, warn.
+func (c *Config) Verbosity() logger.Verbosity {
+	if c.LogLevel == "" {
+		if !c.Verbose {
+			return logger.VerbosityWarn
+		}
+		return logger.VerbosityInfo
+	}
+	verbosity, err := logger.ParseVerbosity(c.LogLevel)
+	if err != nil {
+		return logger.VerbosityInfo
+	}
+	return verbosity
+}
+
+// levelCount is a repeatable boolean flag, such as -v, that adds step to count each time it is given
+type levelCount struct {
+	count *int
+	step  int
+}
+
+// String implements flag.Value
+func (l *levelCount) String() string {
+	if l.count == nil {
+		return "0"
+	}
+	return strconv.Itoa(*l.count)
+}
+
+// Set implements flag.Value
+func (l *levelCount) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*l.count += l.step
+	}
+	return nil
+}
+
+// IsBoolFlag lets the flag be given without a value, as -v
+func (l *levelCount) IsBoolFlag() bool {
+	return true
+}
+
+// stringList is a repeatable string flag.
+// The first value given on the command line replaces any value from the environment.
+type stringList struct {
+	values *[]string
+	set    bool
+}
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ";")
+}
+
+// Set implements flag.Value
+func (l *stringList) Set(value string) error {
+	if !l.set {
+		*l.values = nil
+		l.set = true
+	}
+	*l.values = append(*l.values, value)
+	return nil
+}
+
+// ParseInterval parses an interval given as fractional minutes, such as 5 or 0.5,
+// or as a Go duration, such as 90s, 2m30s or 1h
+func ParseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if minutes, err := strconv.ParseFloat(s, 64); err == nil {
+		return minutesToDuration(minutes), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q: use minutes (5, 0.5) or a duration (90s, 2m30s, 1h)", s)
+	}
+	return d, nil
+}
+
+// minutesToDuration converts fractional minutes to a duration with millisecond precision
+func minutesToDuration(minutes float64) time.Duration {
+	return time.Duration(minutes*60*1000) * time.Millisecond
+}
+
+// parseAlign parses comma-separated :MM or :MM:SS marks into offsets into the hour, sorted
+// and without repeats. An empty value gives no marks.
+func parseAlign(value string) ([]time.Duration, error) {
+	var marks []time.Duration
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimPrefix(spec, ":"), ":")
+		if !strings.HasPrefix(spec, ":") || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid align mark %q: use minutes past the hour such as :00 or :30, or :MM:SS", spec)
+		}
+		var mark time.Duration
+		for i, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 0 || n > 59 {
+				return nil, fmt.Errorf("invalid align mark %q: minutes and seconds must be between 00 and 59", spec)
+			}
+			if i == 0 {
+				mark += time.Duration(n) * time.Minute
+			} else {
+				mark += time.Duration(n) * time.Second
+			}
+		}
+		if !slices.Contains(marks, mark) {
+			marks = append(marks, mark)
+		}
+	}
+	slices.Sort(marks)
+	return marks, nil
+}
+
+// intervalValue is an interval flag taking minutes or a Go duration
+type intervalValue struct {
+	interval *time.Duration
+}
+
+// String implements flag.Value
+func (v intervalValue) String() string {
+	if v.interval == nil {
+		return ""
+	}
+	return v.interval.String()
+}
+
+// Set implements flag.Value
+func (v intervalValue) Set(value string) error {
+	d, err := ParseInterval(value)
+	if err != nil {
+		return err
+	}
+	*v.interval = d
+	return nil
+}
+
+// LogDir returns the directory gitbak keeps its log files in by default, one per repository
+func LogDir() string {
+	return filepath.Join(dataHome(), "gitbak", "logs")
+}
+
+// DefaultLogFile returns the log file gitbak writes for the repository at the absolute
+// path repoPath unless -log-file says otherwise
+func DefaultLogFile(repoPath string) string {
+	return filepath.Join(LogDir(), fmt.Sprintf("gitbak-%s.log", RepoID(repoPath)))
+}
+
+// dataHome returns the base directory for gitbak's data files,
+// following the XDG Base Directory Specification, or %LOCALAPPDATA% on Windows.
+func dataHome() string {
+	return userDir(runtime.GOOS, "XDG_DATA_HOME", "LOCALAPPDATA", filepath.Join(".local", "share"))
+}
+
+// userDir returns the base directory named by the XDG variable xdgVar or, on Windows,
+// by windowsVar. If neither is set, it is homeDefault in the user's home directory.
+func userDir(goos, xdgVar, windowsVar, homeDefault string) string {
+	if dir := os.Getenv(xdgVar); dir != "" {
+		return dir
+	}
+	if goos == "windows" {
+		if dir := os.Getenv(windowsVar); dir != "" {
+			return dir
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	return filepath.Join(homeDir, homeDefault)
+}
+
+// RepoID returns the short hash of an absolute repository path that names the repository's
+// log, session and service files
+func RepoID(repoPath string) string {
+	return fmt.Sprintf("%x", sha256OfString(repoPath)[:8])
+}
+
+// DefaultStateFile returns where the session state of the repository at the absolute path
+// repoPath is kept, unless StateFile says otherwise
+func DefaultStateFile(repoPath string) string {
+	return filepath.Join(dataHome(), "gitbak", "sessions", fmt.Sprintf("gitbak-%s.json", RepoID(repoPath)))
+}
+
 // sha256OfString returns the SHA256 hash of a string
 func sha256OfString(input string) []byte {
 	hash := sha256.Sum256([]byte(input))
 	return hash[:]
 }
 
-// getCurrentBranchName gets the current git branch name for a repository
-func getCurrentBranchName(repoPath string) (string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "branch", "--show-current")
+// gitQueryTimeout bounds the git queries made while finalizing the configuration
+const gitQueryTimeout = 10 * time.Second
+
+// getCurrentBranchName gets the current git branch name of the repository
+func (c *Config) getCurrentBranchName() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitQueryTimeout)
+	defer cancel()
+
+	args := append(c.GitArgs(), "-C", c.RepoPath, "branch", "--show-current")
+	cmd := exec.CommandContext(ctx, c.GitExecutable(), args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
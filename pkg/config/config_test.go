@@ -6,8 +6,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 )
@@ -23,8 +26,8 @@ func TestNewConfig(t *testing.T) {
 	t.Parallel()
 	c := New()
 
-	if c.IntervalMinutes != DefaultIntervalMinutes {
-		t.Errorf("Expected IntervalMinutes=%.1f, got %.1f", DefaultIntervalMinutes, c.IntervalMinutes)
+	if c.Interval != DefaultInterval {
+		t.Errorf("Expected Interval=%s, got %s", DefaultInterval, c.Interval)
 	}
 	if c.CommitPrefix != DefaultCommitPrefix {
 		t.Errorf("Expected CommitPrefix=%s, got %s", DefaultCommitPrefix, c.CommitPrefix)
@@ -32,6 +35,9 @@ func TestNewConfig(t *testing.T) {
 	if !c.CreateBranch {
 		t.Errorf("Expected CreateBranch=true, got false")
 	}
+	if !c.CommitOnExit {
+		t.Errorf("Expected CommitOnExit=true, got false")
+	}
 	if !c.Verbose {
 		t.Errorf("Expected Verbose=true, got false")
 	}
@@ -57,6 +63,7 @@ func TestLoadFromEnvironment(t *testing.T) {
 	checkEnvErr(t, os.Setenv("CONTINUE_SESSION", "true"))
 	checkEnvErr(t, os.Setenv("DEBUG", "true"))
 	checkEnvErr(t, os.Setenv("LOG_FILE", "/tmp/test.log"))
+	checkEnvErr(t, os.Setenv("NO_COLOR", "anything"))
 
 	defer func() {
 		checkEnvErr(t, os.Unsetenv("INTERVAL_MINUTES"))
@@ -69,13 +76,14 @@ func TestLoadFromEnvironment(t *testing.T) {
 		checkEnvErr(t, os.Unsetenv("CONTINUE_SESSION"))
 		checkEnvErr(t, os.Unsetenv("DEBUG"))
 		checkEnvErr(t, os.Unsetenv("LOG_FILE"))
+		checkEnvErr(t, os.Unsetenv("NO_COLOR"))
 	}()
 
 	c := New()
 	c.LoadFromEnvironment()
 
-	if c.IntervalMinutes != 10 {
-		t.Errorf("Expected IntervalMinutes=10, got %.1f", c.IntervalMinutes)
+	if c.Interval != 10*time.Minute {
+		t.Errorf("Expected Interval=10m, got %s", c.Interval)
 	}
 	if c.BranchName != "test-branch" {
 		t.Errorf("Expected BranchName=test-branch, got %s", c.BranchName)
@@ -104,6 +112,9 @@ func TestLoadFromEnvironment(t *testing.T) {
 	if c.LogFile != "/tmp/test.log" {
 		t.Errorf("Expected LogFile=/tmp/test.log, got %s", c.LogFile)
 	}
+	if !c.NoColor {
+		t.Errorf("Expected NoColor=true for any NO_COLOR value, got false")
+	}
 }
 
 func TestSetupFlags(t *testing.T) {
@@ -125,8 +136,8 @@ func TestSetupFlags(t *testing.T) {
 		t.Fatalf("Failed to parse flags: %v", err)
 	}
 
-	if c.IntervalMinutes != 15 {
-		t.Errorf("Expected IntervalMinutes=15, got %.1f", c.IntervalMinutes)
+	if c.Interval != 15*time.Minute {
+		t.Errorf("Expected Interval=15m, got %s", c.Interval)
 	}
 	if c.BranchName != "flag-branch" {
 		t.Errorf("Expected BranchName=flag-branch, got %s", c.BranchName)
@@ -153,8 +164,8 @@ func TestParseFlags(t *testing.T) {
 			return
 		}
 
-		if c.IntervalMinutes != 30 {
-			t.Errorf("Expected IntervalMinutes=30, got %.1f", c.IntervalMinutes)
+		if c.Interval != 30*time.Minute {
+			t.Errorf("Expected Interval=30m, got %s", c.Interval)
 		}
 		if c.BranchName != "test-branch" {
 			t.Errorf("Expected BranchName=test-branch, got %s", c.BranchName)
@@ -193,6 +204,58 @@ func TestParseFlags(t *testing.T) {
 		}
 	})
 
+	t.Run("Positional arguments", func(t *testing.T) {
+		c := New()
+
+		if err := c.ParseArgs([]string{"-quiet", "build/out.log", "notes.txt"}); err != nil {
+			t.Errorf("ParseArgs() error = %v, expected no error", err)
+			return
+		}
+
+		if len(c.Args) != 2 || c.Args[0] != "build/out.log" || c.Args[1] != "notes.txt" {
+			t.Errorf("Expected Args=[build/out.log notes.txt], got %v", c.Args)
+		}
+	})
+
+	t.Run("Log level", func(t *testing.T) {
+		tests := map[string]struct {
+			args          []string
+			expectLevel   string
+			expectVerbose bool
+		}{
+			"Default":             {expectLevel: "info", expectVerbose: true},
+			"Quiet":               {args: []string{"-quiet"}, expectLevel: "warn"},
+			"Verbose":             {args: []string{"-v"}, expectLevel: "debug", expectVerbose: true},
+			"RepeatedVerbose":     {args: []string{"-v", "-v"}, expectLevel: "trace", expectVerbose: true},
+			"VeryVerbose":         {args: []string{"-vv"}, expectLevel: "trace", expectVerbose: true},
+			"BeyondTrace":         {args: []string{"-vv", "-v"}, expectLevel: "trace", expectVerbose: true},
+			"QuietVerbose":        {args: []string{"-quiet", "-v"}, expectLevel: "info", expectVerbose: true},
+			"LogLevel":            {args: []string{"-log-level", "error"}, expectLevel: "error"},
+			"LogLevelOverQuiet":   {args: []string{"-quiet", "-log-level", "debug"}, expectLevel: "debug", expectVerbose: true},
+			"LogLevelWithVerbose": {args: []string{"-log-level", "WARN", "-v"}, expectLevel: "info", expectVerbose: true},
+		}
+
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				c := New()
+				if err := c.ParseArgs(test.args); err != nil {
+					t.Fatalf("ParseArgs() error = %v, expected no error", err)
+				}
+				if err := c.Finalize(); err != nil {
+					t.Fatalf("Finalize() error = %v, expected no error", err)
+				}
+				if c.LogLevel != test.expectLevel || c.Verbose != test.expectVerbose {
+					t.Errorf("Expected LogLevel=%s, Verbose=%v, got LogLevel=%s, Verbose=%v", test.expectLevel, test.expectVerbose, c.LogLevel, c.Verbose)
+				}
+				// Finalizing again does not raise the level further
+				if err := c.Finalize(); err != nil || c.LogLevel != test.expectLevel {
+					t.Errorf("Expected LogLevel=%s after finalizing again, got %s (err %v)", test.expectLevel, c.LogLevel, err)
+				}
+			})
+		}
+	})
+
 	t.Run("Version flag", func(t *testing.T) {
 		originalArgs := os.Args
 		defer func() { os.Args = originalArgs }()
@@ -233,6 +296,44 @@ func TestParseFlags(t *testing.T) {
 		}
 	})
 
+	t.Run("Duration interval", func(t *testing.T) {
+		c := New()
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		c.SetupFlags(fs)
+
+		if err := fs.Parse([]string{"-interval", "90s"}); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if c.Interval != 90*time.Second {
+			t.Errorf("Expected Interval=1m30s, got %s", c.Interval)
+		}
+	})
+
+	t.Run("Duration intervals", func(t *testing.T) {
+		c := New()
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		c.SetupFlags(fs)
+
+		args := []string{"-min-interval", "45s", "-max-interval", "0.5", "-idle-interval", "1h",
+			"-battery-interval", "20m", "-push-interval", "2m30s"}
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		for flagName, values := range map[string][2]time.Duration{
+			"min-interval":     {c.MinInterval, 45 * time.Second},
+			"max-interval":     {c.MaxInterval, 30 * time.Second},
+			"idle-interval":    {c.IdleInterval, time.Hour},
+			"battery-interval": {c.BatteryInterval, 20 * time.Minute},
+			"push-interval":    {c.PushInterval, 150 * time.Second},
+		} {
+			if got, expected := values[0], values[1]; got != expected {
+				t.Errorf("Expected -%s to set %s, got %s", flagName, expected, got)
+			}
+		}
+	})
+
 	t.Run("Invalid interval", func(t *testing.T) {
 		invalidArgs := []string{"gitbak", "-interval", "invalid"}
 
@@ -316,7 +417,7 @@ func TestParseFlags(t *testing.T) {
 func TestFinalize(t *testing.T) {
 	t.Parallel()
 	c := New()
-	c.IntervalMinutes = 0 // Invalid value
+	c.Interval = 0 // Invalid value
 
 	err := c.Finalize()
 	if err == nil {
@@ -326,10 +427,418 @@ func TestFinalize(t *testing.T) {
 		t.Errorf("Expected 'invalid interval' error, got: %v", err)
 	}
 
+	c.Interval = 5 * time.Minute
+	c.MaxInterval = 2 * time.Minute // Invalid value, below the interval
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid maximum interval") {
+		t.Errorf("Expected 'invalid maximum interval' error, got: %v", err)
+	}
+
+	c.MaxInterval = 0
+	c.IdleAfter = -1 // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid idle after") {
+		t.Errorf("Expected 'invalid idle after' error, got: %v", err)
+	}
+
+	c.IdleAfter = 3
+	c.IdleInterval = 2 * time.Minute // Invalid value, below the interval
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid idle interval") {
+		t.Errorf("Expected 'invalid idle interval' error, got: %v", err)
+	}
+
+	c.IdleInterval = 30 * time.Minute
+	c.Align = ":00,:75" // Invalid value, past the hour
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid align") {
+		t.Errorf("Expected 'invalid align' error, got: %v", err)
+	}
+
+	c.Align = ":00,:30"
+	c.MinInterval = 10 * time.Minute // Invalid value, above the interval
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid minimum interval") {
+		t.Errorf("Expected 'invalid minimum interval' error, got: %v", err)
+	}
+
+	c.MinInterval = 0
+	c.PushInterval = -time.Minute // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid push interval") {
+		t.Errorf("Expected 'invalid push interval' error, got: %v", err)
+	}
+
+	c.PushInterval = 0
+	c.Notify = "sometimes" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid notify mode") {
+		t.Errorf("Expected 'invalid notify mode' error, got: %v", err)
+	}
+
+	c.Notify = "errors"
+	c.BatteryThreshold = 120 // Not a percentage
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid battery threshold") {
+		t.Errorf("Expected 'invalid battery threshold' error, got: %v", err)
+	}
+
+	c.BatteryThreshold = 20
+	c.BatteryInterval = -time.Minute // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid battery interval") {
+		t.Errorf("Expected 'invalid battery interval' error, got: %v", err)
+	}
+
+	c.BatteryInterval = 0
+	c.LockWait = -time.Second // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lock wait") {
+		t.Errorf("Expected 'invalid lock wait' error, got: %v", err)
+	}
+
+	c.LockWait = time.Second
+	c.Takeover = true // Waiting and taking over exclude each other
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lock handling") {
+		t.Errorf("Expected 'invalid lock handling' error, got: %v", err)
+	}
+
+	c.LockWait = 0
+	c.Join = true // Taking over and joining exclude each other
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lock handling") {
+		t.Errorf("Expected 'invalid lock handling' error, got: %v", err)
+	}
+
+	c.Takeover = false
+	c.Join = false
+	c.LockScope = "branch" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lock scope") {
+		t.Errorf("Expected 'invalid lock scope' error, got: %v", err)
+	}
+
+	c.LockScope = "repository"
+	c.LockStaleAfter = time.Minute // Shorter than a few missed refreshes
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lock stale after") {
+		t.Errorf("Expected 'invalid lock stale after' error, got: %v", err)
+	}
+
+	c.LockStaleAfter = 10 * time.Minute
+	c.ShutdownTimeout = -time.Second // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid shutdown timeout") {
+		t.Errorf("Expected 'invalid shutdown timeout' error, got: %v", err)
+	}
+
+	c.ShutdownTimeout = 0
+	c.CommandTimeout = -time.Second // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid command timeout") {
+		t.Errorf("Expected 'invalid command timeout' error, got: %v", err)
+	}
+
+	c.CommandTimeout = 0
+	c.LogMaxFiles = -1 // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid log retention") {
+		t.Errorf("Expected 'invalid log retention' error, got: %v", err)
+	}
+
+	c.LogMaxFiles = 5
+	c.LogLines = -1 // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid lines") {
+		t.Errorf("Expected 'invalid lines' error, got: %v", err)
+	}
+
+	c.LogLines = 10
+	c.MinChangedLines = 5
+	c.GitBackend = "gogit" // The gogit backend cannot measure changes
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid change threshold") {
+		t.Errorf("Expected 'invalid change threshold' error, got: %v", err)
+	}
+
+	c.MinChangedLines = 0
+	c.MaxFileSizeMB = 50 // The gogit backend cannot leave files out
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid max file size") {
+		t.Errorf("Expected 'invalid max file size' error, got: %v", err)
+	}
+
+	c.MaxFileSizeMB = 0
+	c.Paths = []string{"services/billing"} // The gogit backend cannot scope checkpoints
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid path") {
+		t.Errorf("Expected 'invalid path' error, got: %v", err)
+	}
+
+	c.GitBackend = "exec"
+	c.Paths = []string{"services/billing", "../elsewhere"} // Outside the repository
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid path") {
+		t.Errorf("Expected 'invalid path' error, got: %v", err)
+	}
+
+	c.Paths = nil
+	c.Mode = "stash"
+	c.Push = "origin" // Stash mode makes no commits to push
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid checkpoint mode") {
+		t.Errorf("Expected 'invalid checkpoint mode' error, got: %v", err)
+	}
+
+	c.Push = ""
+	c.GitBackend = "gogit" // The gogit backend cannot stash
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid git backend") {
+		t.Errorf("Expected 'invalid git backend' error, got: %v", err)
+	}
+
+	c.GitBackend = "libgit2" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid git backend") {
+		t.Errorf("Expected 'invalid git backend' error, got: %v", err)
+	}
+
+	c.GitBackend = "exec"
+	c.Submodules = "recursive" // Stash mode commits nowhere, not even in submodules
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid submodule mode") {
+		t.Errorf("Expected 'invalid submodule mode' error, got: %v", err)
+	}
+
+	c.Submodules = "all" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid submodule mode") {
+		t.Errorf("Expected 'invalid submodule mode' error, got: %v", err)
+	}
+
+	c.Submodules = "include"
+	c.UntrackedFiles = "some" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid untracked files mode") {
+		t.Errorf("Expected 'invalid untracked files mode' error, got: %v", err)
+	}
+
+	c.UntrackedFiles = "normal"
+	c.UntrackedPolicy = "some" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid untracked policy") {
+		t.Errorf("Expected 'invalid untracked policy' error, got: %v", err)
+	}
+
+	c.UntrackedPolicy = "all"
+	c.MinQuietSeconds = -5 // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid quiet period") {
+		t.Errorf("Expected 'invalid quiet period' error, got: %v", err)
+	}
+
+	c.MinQuietSeconds = 10
+	c.Mode = "observe" // Journal entries do not wait for the tree to settle
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid quiet period") {
+		t.Errorf("Expected 'invalid quiet period' error, got: %v", err)
+	}
+
+	c.MinQuietSeconds = 0
+	c.CheckCommand = "go build ./..." // Journal entries have no message to mark
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid check command") {
+		t.Errorf("Expected 'invalid check command' error, got: %v", err)
+	}
+
+	c.CheckCommand = ""
+	c.OnCheckFail = "retry" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid check failure mode") {
+		t.Errorf("Expected 'invalid check failure mode' error, got: %v", err)
+	}
+
+	c.OnCheckFail = "mark"
+	c.Mode = "stash"
+	c.RefsOnly = true // -refs-only is -mode refs
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid refs-only") {
+		t.Errorf("Expected 'invalid refs-only' error, got: %v", err)
+	}
+
+	c.Mode = "branch"
+	c.ContinueSession = true // Checkpoint refs are on no branch to continue
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid checkpoint mode") {
+		t.Errorf("Expected 'invalid checkpoint mode' error, got: %v", err)
+	}
+
+	c.ContinueSession = false
+	c.RefsOnly = false
+	c.Mode = "branch"
+	c.Output = "yaml" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid output format") {
+		t.Errorf("Expected 'invalid output format' error, got: %v", err)
+	}
+
+	c.Output = "json"
+	c.TUI = true // The dashboard would draw over the JSON
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid output format") {
+		t.Errorf("Expected 'invalid output format' error, got: %v", err)
+	}
+
+	c.Output = "text"
+	c.TUI = false
+	c.RPC = true
+	c.Detach = true // The editor hosting the session would lose it
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid rpc") {
+		t.Errorf("Expected 'invalid rpc' error, got: %v", err)
+	}
+
+	c.Detach = false
+	c.NonInteractive = false
+
+	if err = c.Finalize(); err != nil {
+		t.Errorf("Expected -rpc alone to be valid, got: %v", err)
+	}
+	if !c.NonInteractive {
+		t.Errorf("Expected -rpc to imply -non-interactive")
+	}
+
+	c.RPC = false
+	c.NonInteractive = false
+	c.LogLevel = "loud" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid log level") {
+		t.Errorf("Expected 'invalid log level' error, got: %v", err)
+	}
+
+	c.LogLevel = "info"
+	c.CoAuthors = []string{"Alice Example <alice@example.com>", "bob@example.com"} // Missing name
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid co-author") {
+		t.Errorf("Expected 'invalid co-author' error, got: %v", err)
+	}
+
+	c.CoAuthors = nil
+	c.CommitAuthor = "gitbak bot" // Missing email
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid commit author") {
+		t.Errorf("Expected 'invalid commit author' error, got: %v", err)
+	}
+
+	c.CommitEmail = "<bot@example.com>" // Angle brackets are added by gitbak
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid commit author") {
+		t.Errorf("Expected 'invalid commit author' error, got: %v", err)
+	}
+
+	c.CommitEmail = "bot@example.com"
+	c.Mode = "branch"
+	c.GitBackend = "gogit" // gogit cannot be given the identity
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid commit author") {
+		t.Errorf("Expected 'invalid commit author' error, got: %v", err)
+	}
+
+	c.GitBackend = "exec"
+	c.TUI = true
+	c.Detach = true // A detached session has no terminal
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid tui") {
+		t.Errorf("Expected 'invalid tui' error, got: %v", err)
+	}
+
+	c.Detach = false
+	c.OnDiverge = "ignore" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid divergence mode") {
+		t.Errorf("Expected 'invalid divergence mode' error, got: %v", err)
+	}
+
+	c.OnDiverge = "pause"
+	c.Secrets = "redact" // Invalid value
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid secrets mode") {
+		t.Errorf("Expected 'invalid secrets mode' error, got: %v", err)
+	}
+
+	c.Secrets = "abort"
+	c.CommitAuthor, c.CommitEmail = "", ""
+	c.GitBackend = "gogit" // gogit cannot scan changes
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid secrets mode") {
+		t.Errorf("Expected 'invalid secrets mode' error, got: %v", err)
+	}
+
+	c.Secrets = ""
+	c.GitBackend = "exec"
+	c.OTLPEndpoint = "localhost:4318" // Missing the http:// scheme
+
+	err = c.Finalize()
+	if err == nil || !strings.Contains(err.Error(), "invalid OTLP endpoint") {
+		t.Errorf("Expected 'invalid OTLP endpoint' error, got: %v", err)
+	}
+
 	// Set valid values
-	c.IntervalMinutes = 5
+	c.OTLPEndpoint = "http://localhost:4318"
+	c.Submodules = "ignore"
+	c.Mode = "stash"
+	c.GitBackend = "exec"
 	c.RepoPath = "" // Should use the current directory
 	c.LogFile = ""  // Should use XDG base directory
+	c.SummaryFile = "session.md"
 
 	err = c.Finalize()
 	if err != nil {
@@ -344,6 +853,10 @@ func TestFinalize(t *testing.T) {
 		t.Errorf("Expected LogFile to be set, got empty string")
 	}
 
+	if !filepath.IsAbs(c.SummaryFile) || filepath.Base(c.SummaryFile) != "session.md" {
+		t.Errorf("Expected SummaryFile to be made absolute, got %s", c.SummaryFile)
+	}
+
 	if strings.Contains(c.LogFile, c.RepoPath) {
 		t.Errorf("Expected LogFile to be outside the repository, got %s", c.LogFile)
 	}
@@ -358,6 +871,228 @@ func TestFinalize(t *testing.T) {
 	}
 }
 
+// TestGitDirAndWorkTree tests how a separate git directory and work tree are resolved and
+// passed to git
+func TestGitDirAndWorkTree(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	gitDir := filepath.Join(root, "dotfiles.git")
+	workTree := filepath.Join(root, "home")
+	for _, dir := range []string{gitDir, filepath.Join(workTree, "config")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	tests := map[string]struct {
+		gitDir         string
+		workTree       string
+		repoPath       string
+		submodules     string
+		expectRepoPath string
+		expectArgs     []string
+		errorContains  string
+	}{
+		"WorkTreeIsMonitored": {
+			gitDir:         gitDir,
+			workTree:       workTree,
+			expectRepoPath: workTree,
+			expectArgs:     []string{"--git-dir=" + gitDir, "--work-tree=" + workTree, "-c", "core.quotePath=false"},
+		},
+		"RepoInsideWorkTree": {
+			gitDir:         gitDir,
+			workTree:       workTree,
+			repoPath:       filepath.Join(workTree, "config"),
+			expectRepoPath: filepath.Join(workTree, "config"),
+			expectArgs:     []string{"--git-dir=" + gitDir, "--work-tree=" + workTree, "-c", "core.quotePath=false"},
+		},
+		"GitDirOnly": {
+			gitDir:         gitDir,
+			repoPath:       workTree,
+			expectRepoPath: workTree,
+			expectArgs:     []string{"--git-dir=" + gitDir, "-c", "core.quotePath=false"},
+		},
+		"RepoOutsideWorkTree": {
+			gitDir:        gitDir,
+			workTree:      filepath.Join(workTree, "config"),
+			repoPath:      workTree,
+			errorContains: "invalid repo",
+		},
+		"MissingGitDir": {
+			gitDir:        filepath.Join(root, "missing.git"),
+			errorContains: "invalid git dir",
+		},
+		"WorkTreeWithoutGitDir": {
+			workTree:      workTree,
+			errorContains: "invalid work tree",
+		},
+		"RecursiveSubmodules": {
+			gitDir:        gitDir,
+			workTree:      workTree,
+			submodules:    "recursive",
+			errorContains: "invalid git dir",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New()
+			c.GitDir = test.gitDir
+			c.WorkTree = test.workTree
+			c.RepoPath = test.repoPath
+			c.Submodules = test.submodules
+			c.GitGlobalArgs = "-c core.quotePath=false"
+			c.StateFile = filepath.Join(t.TempDir(), "state.json")
+			c.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+
+			err := c.Finalize()
+			if test.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+					t.Fatalf("Expected an error containing %q, got %v", test.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if c.RepoPath != test.expectRepoPath {
+				t.Errorf("Expected RepoPath %s, got %s", test.expectRepoPath, c.RepoPath)
+			}
+			if args := c.GitArgs(); !slices.Equal(args, test.expectArgs) {
+				t.Errorf("Expected git arguments %q, got %q", test.expectArgs, args)
+			}
+		})
+	}
+}
+
+// TestLogBackend tests that the log backend is validated, and that those without a log
+// file turn on debug logging
+func TestLogBackend(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		backend       string
+		debug         bool
+		expectBackend string
+		expectDebug   bool
+		errorContains string
+	}{
+		"Default":  {expectBackend: "text"},
+		"JSON":     {backend: "json", debug: true, expectBackend: "json", expectDebug: true},
+		"Journald": {backend: "journald", expectBackend: "journald", expectDebug: true},
+		"Syslog":   {backend: "syslog", expectBackend: "syslog", expectDebug: true},
+		"Unknown":  {backend: "carrier-pigeon", errorContains: "invalid log backend"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New()
+			c.LogBackend = test.backend
+			c.Debug = test.debug
+			c.RepoPath = t.TempDir()
+			c.StateFile = filepath.Join(t.TempDir(), "state.json")
+			c.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+
+			err := c.Finalize()
+			if test.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errorContains) {
+					t.Fatalf("Expected an error containing %q, got %v", test.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if c.LogBackend != test.expectBackend || c.Debug != test.expectDebug {
+				t.Errorf("Expected backend %s with debug %v, got %s with %v", test.expectBackend, test.expectDebug, c.LogBackend, c.Debug)
+			}
+		})
+	}
+}
+
+// TestListenAddr tests that the control endpoint is only served on loopback addresses
+func TestListenAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		addr        string
+		expectError bool
+	}{
+		"Unset":           {},
+		"IPv4Loopback":    {addr: "127.0.0.1:7373"},
+		"IPv6Loopback":    {addr: "[::1]:7373"},
+		"Localhost":       {addr: "localhost:7373"},
+		"UnixSocket":      {addr: "unix:/tmp/gitbak.sock"},
+		"AllInterfaces":   {addr: "0.0.0.0:7373", expectError: true},
+		"EmptyHost":       {addr: ":7373", expectError: true},
+		"LANAddress":      {addr: "192.168.1.20:7373", expectError: true},
+		"Hostname":        {addr: "example.com:7373", expectError: true},
+		"MissingPort":     {addr: "127.0.0.1", expectError: true},
+		"IPv6Unspecified": {addr: "[::]:7373", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New()
+			c.ListenAddr = test.addr
+			c.RepoPath = t.TempDir()
+			c.StateFile = filepath.Join(t.TempDir(), "state.json")
+			c.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+
+			err := c.Finalize()
+			if test.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid listen address") {
+					t.Fatalf("Expected an invalid listen address error for %q, got %v", test.addr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestUserDir tests where gitbak keeps its files when the XDG variables are unset
+func TestUserDir(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("No home directory: %v", err)
+	}
+
+	tests := map[string]struct {
+		goos         string
+		xdg          string
+		localAppData string
+		expect       string
+	}{
+		"XDG":              {goos: "linux", xdg: "/xdg/data", localAppData: `C:\Users\me\AppData\Local`, expect: "/xdg/data"},
+		"Home":             {goos: "linux", localAppData: `C:\Users\me\AppData\Local`, expect: filepath.Join(homeDir, ".local", "share")},
+		"Windows":          {goos: "windows", localAppData: `C:\Users\me\AppData\Local`, expect: `C:\Users\me\AppData\Local`},
+		"WindowsXDG":       {goos: "windows", xdg: "/xdg/data", localAppData: `C:\Users\me\AppData\Local`, expect: "/xdg/data"},
+		"WindowsNoAppData": {goos: "windows", expect: filepath.Join(homeDir, ".local", "share")},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", test.xdg)
+			t.Setenv("LOCALAPPDATA", test.localAppData)
+
+			if dir := userDir(test.goos, "XDG_DATA_HOME", "LOCALAPPDATA", filepath.Join(".local", "share")); dir != test.expect {
+				t.Errorf("Expected %s, got %s", test.expect, dir)
+			}
+		})
+	}
+}
+
 // TestBranchHandling tests branch name handling in different modes
 func TestBranchHandling(t *testing.T) {
 	tempDir := t.TempDir()
@@ -366,6 +1101,7 @@ func TestBranchHandling(t *testing.T) {
 	tests := map[string]struct {
 		continueSession bool
 		initialBranch   string
+		branchTemplate  string
 		expectBranch    string
 		expectError     bool
 	}{
@@ -393,6 +1129,23 @@ func TestBranchHandling(t *testing.T) {
 			expectBranch:    "custom-branch", // Should keep specified branch
 			expectError:     false,
 		},
+		"normal mode with branch template": {
+			branchTemplate: "backup/{{.BaseBranch}}",
+			expectBranch:   "backup/main",
+		},
+		"branch template ignored for specified branch": {
+			initialBranch:  "custom-branch",
+			branchTemplate: "{{.Unknown}}",
+			expectBranch:   "custom-branch",
+		},
+		"branch template with unknown value": {
+			branchTemplate: "backup/{{.Unknown}}",
+			expectError:    true,
+		},
+		"branch template with invalid name": {
+			branchTemplate: "backup/{{.BaseBranch}}.lock",
+			expectError:    true,
+		},
 	}
 
 	for name, test := range tests {
@@ -402,11 +1155,17 @@ func TestBranchHandling(t *testing.T) {
 			cfg.ContinueSession = test.continueSession
 			cfg.RepoPath = tempDir
 			cfg.BranchName = test.initialBranch
+			if test.branchTemplate != "" {
+				cfg.BranchTemplate = test.branchTemplate
+			}
 
 			err := cfg.Finalize()
 
-			if test.expectError && err == nil {
-				t.Errorf("Expected an error but got none")
+			if test.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid branch template") {
+					t.Errorf("Expected 'invalid branch template' error, got: %v", err)
+				}
+				return
 			} else if !test.expectError && err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -434,6 +1193,9 @@ func TestBranchHandling(t *testing.T) {
 			if !test.continueSession && test.initialBranch == "" && cfg.BranchName == "" {
 				t.Errorf("Expected a generated branch name, got empty string")
 			}
+			if name == "normal mode with empty branch" && !regexp.MustCompile(`^gitbak-\d{8}-\d{6}$`).MatchString(cfg.BranchName) {
+				t.Errorf("Expected a timestamp-based branch name, got %s", cfg.BranchName)
+			}
 		})
 	}
 }
@@ -544,6 +1306,76 @@ func TestGetEnvInt(t *testing.T) {
 	}
 }
 
+func TestParseInterval(t *testing.T) {
+	tests := map[string]struct {
+		value       string
+		expected    time.Duration
+		expectError bool
+	}{
+		"Minutes":           {value: "5", expected: 5 * time.Minute},
+		"FractionalMinutes": {value: "0.5", expected: 30 * time.Second},
+		"Seconds":           {value: "90s", expected: 90 * time.Second},
+		"Compound":          {value: "2m30s", expected: 150 * time.Second},
+		"Hours":             {value: "1h", expected: time.Hour},
+		"Spaces":            {value: " 2m ", expected: 2 * time.Minute},
+		"NoUnit":            {value: "2x", expectError: true},
+		"Empty":             {value: "", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseInterval(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %s", test.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInterval failed: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseAlign(t *testing.T) {
+	tests := map[string]struct {
+		value       string
+		expected    []time.Duration
+		expectError bool
+	}{
+		"Empty":         {value: ""},
+		"Quarters":      {value: ":00,:15,:30,:45", expected: []time.Duration{0, 15 * time.Minute, 30 * time.Minute, 45 * time.Minute}},
+		"SortedDeduped": {value: ":30, :00,:30", expected: []time.Duration{0, 30 * time.Minute}},
+		"Seconds":       {value: ":05:30", expected: []time.Duration{5*time.Minute + 30*time.Second}},
+		"NoColon":       {value: "15", expectError: true},
+		"OutOfRange":    {value: ":60", expectError: true},
+		"NotANumber":    {value: ":xx", expectError: true},
+		"TooManyFields": {value: ":01:02:03", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseAlign(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %v", test.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAlign failed: %v", err)
+			}
+			if !slices.Equal(got, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
 func TestSetupTestFlags(t *testing.T) {
 	oldEnv := os.Getenv("GITBAK_TESTING")
 	if err := os.Setenv("GITBAK_TESTING", "1"); err != nil {
@@ -579,3 +1411,97 @@ func TestSetupTestFlags(t *testing.T) {
 		t.Error("Expected non-interactive flag not to be set")
 	}
 }
+
+func TestIsDisabled(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		set      bool
+		expected bool
+	}{
+		"Unset":   {set: false, expected: false},
+		"One":     {value: "1", set: true, expected: true},
+		"True":    {value: "true", set: true, expected: true},
+		"Yes":     {value: "YES", set: true, expected: true},
+		"Zero":    {value: "0", set: true, expected: false},
+		"Garbage": {value: "maybe", set: true, expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.set {
+				checkEnvErr(t, os.Setenv(DisableEnvVar, test.value))
+			} else {
+				checkEnvErr(t, os.Unsetenv(DisableEnvVar))
+			}
+			defer func() {
+				checkEnvErr(t, os.Unsetenv(DisableEnvVar))
+			}()
+
+			if got := IsDisabled(); got != test.expected {
+				t.Errorf("Expected IsDisabled()=%t for %q, got %t", test.expected, test.value, got)
+			}
+		})
+	}
+}
+
+func TestMirrorConfiguration(t *testing.T) {
+	tests := map[string]struct {
+		env           string
+		args          []string
+		expectedNames []string
+		expectError   bool
+	}{
+		"FromEnvironment": {
+			env:           "nas=ssh://nas/backup.git; cloud=origin,every=1h",
+			expectedNames: []string{"nas", "cloud"},
+		},
+		"FlagsReplaceEnvironment": {
+			env:           "nas=ssh://nas/backup.git",
+			args:          []string{"-mirror", "usb=/mnt/usb/project.git", "-mirror", "cloud=origin,limit=256k"},
+			expectedNames: []string{"usb", "cloud"},
+		},
+		"InvalidProfile": {
+			args:        []string{"-mirror", "cloud=origin,every=sometimes"},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("MIRRORS", test.env)
+			}
+
+			c := New()
+			c.LoadFromEnvironment()
+			c.RepoPath = t.TempDir()
+			c.LogFile = filepath.Join(t.TempDir(), "gitbak.log")
+			c.BranchName = "gitbak-test"
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			c.SetupFlags(fs)
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatalf("Failed to parse flags: %v", err)
+			}
+
+			err := c.Finalize()
+			if test.expectError {
+				if !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+					t.Errorf("Expected ErrInvalidConfiguration, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, p := range c.MirrorProfiles {
+				names = append(names, p.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(test.expectedNames, ",") {
+				t.Errorf("Expected mirrors %v, got %v", test.expectedNames, names)
+			}
+		})
+	}
+}
@@ -16,39 +16,193 @@
 //
 //  1. Command-line flags (highest priority)
 //  2. Environment variables
-//  3. Default values (lowest priority)
+//  3. The repository's .gitbak.toml
+//  4. The global $XDG_CONFIG_HOME/gitbak/config.toml
+//  5. Default values (lowest priority)
+//
+// Configuration files are TOML documents keyed by flag name (e.g. interval = 2).
+// They are applied by ParseArgs to whatever the environment and flags left unset.
 //
 // # Environment Variables
 //
 // The following environment variables are supported:
 //
 //	INTERVAL_MINUTES   Minutes between commit checks (default: 5)
-//	BRANCH_NAME        Branch name to use (default: gitbak-<timestamp>)
+//	MIN_INTERVAL_MINUTES Shortest adaptive interval (default: 0, fixed interval)
+//	MAX_INTERVAL_MINUTES Longest adaptive interval (default: 0, fixed interval)
+//	IDLE_AFTER         Go idle after this many checks without changes (default: 0, never)
+//	IDLE_INTERVAL_MINUTES Minutes between checks while idle (default: 30)
+//	ALIGN              Minutes past the hour to move checks on to, e.g. :00,:30 (default: none)
+//	BATTERY_THRESHOLD  Check less often on battery below this percentage (default: 0, disabled)
+//	BATTERY_INTERVAL_MINUTES Minutes between checks on low battery, 0 pauses (default: 15)
+//	WATCH              Check when files change instead of polling (default: false)
+//	BRANCH_NAME        Branch name to use (default: from BRANCH_TEMPLATE)
+//	BRANCH_TEMPLATE    Template for generated branch names (default: gitbak-{{.Timestamp}})
 //	COMMIT_PREFIX      Commit message prefix (default: "[gitbak]")
+//	COMMIT_AUTHOR      Name checkpoint commits are attributed to (default: git user)
+//	COMMIT_EMAIL       Email checkpoint commits are attributed to (default: git user)
+//	COAUTHORS          Co-authors credited on checkpoints, separated by ';' (default: none)
+//	MIN_CHANGED_LINES  Lines that must change before a checkpoint (default: 0, disabled)
+//	MIN_CHANGED_FILES  Files that must change before a checkpoint (default: 0, disabled)
+//	MAX_SKIPPED_CHECKS Checks that may hold back small changes (default: 5)
+//	MIN_QUIET_SECONDS  Seconds the tree must be left alone before a checkpoint (default: 0, disabled)
+//	CHECK_CMD          Shell command whose outcome each checkpoint records (default: none)
+//	ON_CHECK_FAIL      When CHECK_CMD fails: mark or skip the checkpoint (default: mark)
+//	MAX_FILE_SIZE_MB   Largest file a checkpoint takes in (default: 0, no limit)
+//	PATHS              Paths checkpoints are scoped to, separated by ';' (default: all)
+//	SECRETS            Changes that look like secrets: skip, abort or off (default: skip)
+//	DIFF_SUMMARY       List changed files in checkpoint commit bodies (default: false)
+//	NO_VERIFY          Skip commit hooks for checkpoints (default: false)
 //	CREATE_BRANCH      Whether to create a new branch (default: true)
 //	CONTINUE_SESSION   Continue an existing gitbak session (default: false)
+//	EMPTY_REPO         Handling of repositories without commits (default: initial-commit)
+//	CHECKPOINT_MODE    Where checkpoints are recorded: branch, stash, refs or observe (default: branch)
+//	REFS_ONLY          Write checkpoints to refs/gitbak instead of a branch (default: false)
+//	JOURNAL_FILE       Where -mode observe journals changes (default: derived from repo path)
+//	GIT_BACKEND        How git commands are run: exec or gogit (default: exec)
+//	GIT_PATH           git binary to run (default: git from PATH)
+//	GIT_GLOBAL_ARGS    Arguments passed to git before every command (default: none)
+//	SUBMODULES         Submodule handling: include, ignore or recursive (default: include)
+//	UNTRACKED_FILES    How new files are looked for: normal, all or no (default: normal)
+//	UNTRACKED_POLICY   Whether new files are checkpointed: all, none or prompt (default: all)
+//	FAST_STATUS        Use git's untracked cache and file system monitor (default: false)
 //	VERBOSE            Whether to show informational messages (default: true)
+//	LOG_LEVEL          Messages shown: error, warn, info, debug or trace (default: info)
 //	SHOW_NO_CHANGES    Show messages when no changes detected (default: false)
+//	NO_COLOR           Disable colored output if set to any value (default: unset)
+//	ERRORS_JSON        Print the final error as JSON (default: false)
+//	TUI                Show a live dashboard instead of log lines (default: false)
+//	OUTPUT_FORMAT      Print the summary and status as text or json (default: text)
+//	NOTIFY             Desktop notifications: off, errors or all (default: off)
 //	DEBUG              Enable debug logging (default: false)
 //	REPO_PATH          Path to repository (default: current directory)
+//	GIT_DIR            Git directory, when not the work tree's .git (default: none)
+//	GIT_WORK_TREE      Work tree that goes with GIT_DIR (default: none)
 //	MAX_RETRIES        Max consecutive identical errors before exiting (default: 3)
+//	OP_TIMEOUT         Time limit for each checkpoint or push (default: 2m)
+//	COMMAND_TIMEOUT    Time limit for each git command (default: 1m)
+//	COMMIT_ON_EXIT     Make a final checkpoint when stopped (default: true)
+//	ON_DIVERGE         When the branch's history is rewritten: warn or pause (default: warn)
+//	SHUTDOWN_TIMEOUT   Time limit for the final checkpoint and summary (default: 5s)
+//	LOCK_WAIT          Wait for another instance to release the lock (default: 0, fail immediately)
+//	LOCK_SCOPE         Lock per worktree or per repository (default: worktree)
+//	LOCK_STALE_AFTER   Reclaim a lock not refreshed for this long (default: 0, trust the PID)
+//	RETRY_BACKOFF      Wait after a failed check before retrying (default: 5s)
+//	RETRY_BACKOFF_MAX  Longest wait between retries (default: 5m)
 //	LOG_FILE           Path to log file (default: ~/.local/share/gitbak/logs/gitbak-<hash>.log)
+//	LOG_BACKEND        Where debug logs are recorded: text, json, syslog or journald (default: text)
+//	LOG_MAX_SIZE_MB    Rotate the log file at this size (default: 10, 0 = never)
+//	LOG_MAX_FILES      Rotated log files to keep (default: 5)
+//	LOG_MAX_AGE_DAYS   Remove log files older than this at startup (default: 30, 0 = keep)
+//	SUMMARY_FILE       Write a session report on exit, JSON or Markdown (default: none)
+//	CHAIN_TRAILER      Add Gitbak-Chain integrity trailers to checkpoints (default: false)
+//	PUSH_REMOTE        Remote to push the session branch to (default: none)
+//	PUSH_INTERVAL_MINUTES Minimum minutes between pushes (default: 0, every checkpoint)
+//	MIRRORS            Mirror profiles separated by ';' (default: none)
+//	NUDGE_ADDR         Address of the POST /nudge endpoint (default: disabled)
+//	LISTEN_ADDR        Address of the JSON control endpoint (default: disabled)
+//	METRICS_ADDR       Address of the Prometheus metrics endpoint (default: disabled)
+//	OTLP_ENDPOINT      OTLP/HTTP collector to export trace spans to (default: disabled)
+//	ON_START           Shell command run when the session starts (default: none)
+//	ON_COMMIT          Shell command run after each checkpoint (default: none)
+//	ON_ERROR           Shell command run after each failed check (default: none)
+//	ON_STOP            Shell command run when the session ends (default: none)
+//	GITBAK_DISABLE     Kill switch that disables checkpointing (default: false)
 //
 // # Command-line Flags
 //
 // The following command-line flags are supported:
 //
-//	-interval        Minutes between commit checks
+//	-interval        Time between commit checks (minutes or a duration)
+//	-min-interval    Shortest interval while busy
+//	-max-interval    Longest interval while idle
+//	-idle-after      Go idle after this many checks without changes
+//	-idle-interval   Time between checks while idle
+//	-align           Minutes past the hour to move checks on to
+//	-battery-threshold Check less often on battery below this percentage
+//	-battery-interval Time between checks on low battery (0 = pause)
+//	-watch           Check when files change instead of polling
+//	-detach          Run the session in the background
 //	-branch          Branch name to use
+//	-branch-template Template for generated branch names
 //	-prefix          Commit message prefix
+//	-author          Name checkpoint commits are attributed to
+//	-author-email    Email checkpoint commits are attributed to
+//	-coauthor        Credit a co-author on every checkpoint (repeatable)
+//	-min-changed-lines Lines that must change before a checkpoint
+//	-min-changed-files Files that must change before a checkpoint
+//	-max-skipped-checks Checks that may hold back small changes
+//	-min-quiet       Seconds the tree must be left alone before a checkpoint
+//	-check-cmd       Shell command whose outcome each checkpoint records
+//	-on-check-fail   When -check-cmd fails: mark or skip the checkpoint
+//	-max-file-size   Largest file a checkpoint takes in, in MB
+//	-path            Only checkpoint changes under this path (repeatable)
+//	-secrets         Changes that look like secrets: skip, abort or off
+//	-diff-summary    List changed files in checkpoint commit bodies
+//	-no-verify       Skip commit hooks for checkpoints
 //	-no-branch       Stay on current branch instead of creating a new one
 //	-continue        Continue existing session
+//	-empty-repo      Handling of repositories without commits
+//	-mode            Where checkpoints are recorded: branch, stash, refs or observe
+//	-refs-only       Write checkpoints to refs/gitbak instead of a branch
+//	-journal         Where -mode observe journals changes
+//	-git-backend     How git commands are run: exec or gogit
+//	-git-path        git binary to run instead of the git in PATH
+//	-git-args        Arguments passed to git before every command
+//	-submodules      Submodule handling: include, ignore or recursive
+//	-untracked-files How new files are looked for: normal, all or no
+//	-untracked-policy Whether new files are checkpointed: all, none or prompt
+//	-fast-status     Use git's untracked cache and file system monitor
 //	-show-no-changes Show messages when no changes detected
+//	-no-color        Disable colored output
+//	-errors-json     Print the final error as JSON
+//	-tui             Show a live dashboard instead of log lines
+//	-output          Print the summary and status as text or json
+//	-notify          Show desktop notifications (off, errors, all)
 //	-quiet           Hide informational messages
+//	-log-level       Messages shown: error, warn, info, debug or trace
+//	-v, -vv          Raise the log level by one or two levels
 //	-repo            Path to repository
+//	-git-dir         Git directory, when not the work tree's .git
+//	-work-tree       Work tree that goes with -git-dir
 //	-max-retries     Max consecutive identical errors before exiting
+//	-op-timeout      Time limit for each checkpoint or push
+//	-command-timeout Time limit for each git command
+//	-commit-on-exit   Make a final checkpoint when stopped
+//	-on-diverge      When the branch's history is rewritten: warn or pause
+//	-shutdown-timeout Time limit for the final checkpoint and summary
+//	-lock-wait       Wait for another instance to release the lock
+//	-takeover        Stop the running instance and take its place
+//	-join            Watch the running instance's session
+//	-lock-scope      Lock per worktree or per repository
+//	-lock-stale-after Reclaim a lock not refreshed for this long
+//	-retry-backoff   Wait after a failed check before retrying
+//	-retry-backoff-max Longest wait between retries
 //	-debug           Enable debug logging
 //	-log-file        Path to log file
+//	-log-backend     Record debug logs as text or json, or in syslog or journald
+//	-log-max-size    Rotate the log file at this many megabytes
+//	-log-max-files   Rotated log files to keep
+//	-log-max-age     Remove log files older than this many days at startup
+//	-summary-file    Write a session report on exit, JSON or Markdown
+//	-chain-trailer   Add Gitbak-Chain integrity trailers to checkpoints
+//	-push            Push the session branch to a remote after checkpoints
+//	-push-interval   Minimum time between pushes
+//	-mirror          Push the session branch to refs/gitbak/ on a mirror (repeatable)
+//	-nudge-addr      Accept POST /nudge requests for an early check
+//	-listen          Serve the JSON control endpoint
+//	-rpc             Speak JSON-RPC on stdin and stdout, for editor extensions
+//	-metrics-addr    Serve Prometheus metrics at /metrics
+//	-otlp-endpoint   Export OpenTelemetry spans to an OTLP/HTTP collector
+//	-on-start        Run a shell command when the session starts
+//	-on-commit       Run a shell command after each checkpoint
+//	-on-error        Run a shell command after each failed check
+//	-on-stop         Run a shell command when the session ends
+//	-yes             Answer yes to prompts and accept generated messages
+//	-message         Subject line of the squash commit
+//	-session-only    Bundle only the session's own commits (export-bundle)
+//	-follow/-f       Keep printing log records as they are written (logs)
+//	-lines           Print this many of the last log records (logs)
 //	-version         Print version information and exit
 //	-logo            Display ASCII logo and exit
 //
@@ -64,7 +218,7 @@
 //	}
 //
 //	// Configuration is now ready to use
-//	fmt.Printf("Interval: %.2f minutes\n", cfg.IntervalMinutes)
+//	fmt.Printf("Interval: %s\n", cfg.Interval)
 //	fmt.Printf("Branch: %s\n", cfg.BranchName)
 //
 // # Thread Safety
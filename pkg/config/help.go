@@ -0,0 +1,1014 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/notify"
+)
+
+// helpGroup is a section of the help output that related flags are listed under
+type helpGroup struct {
+	name  string
+	title string
+}
+
+// helpGroups lists the help sections in display order.
+// The first group is shown in full by a plain -help; the others are only listed.
+var helpGroups = []helpGroup{
+	{name: "core", title: "Core Options"},
+	{name: "output", title: "Output Options"},
+	{name: "safety", title: "Safety Options"},
+	{name: "integration", title: "Integration Options"},
+	{name: "info", title: "Information"},
+}
+
+// flagDoc holds the help metadata for a command-line flag.
+// The short usage text and default value come from the flag definition in SetupFlags;
+// flagDoc adds what is needed for grouping and for the detailed -help <flag> page.
+type flagDoc struct {
+	name     string
+	group    string
+	env      string
+	details  string
+	examples []string
+
+	// values lists what a flag taking one of a fixed set of values accepts, and path marks
+	// flags taking a file or directory; shell completion offers these for the flag's value
+	values []string
+	path   bool
+}
+
+// flagDocs describes every documented flag, in display order within each group
+var flagDocs = []flagDoc{
+	{
+		name:    "interval",
+		group:   "core",
+		env:     "INTERVAL_MINUTES",
+		details: "How often gitbak checks for changes. A plain number is minutes, and decimal values are allowed, so 0.5 checks every 30 seconds. A Go duration such as 90s, 2m30s or 1h is accepted too, here and in INTERVAL_MINUTES.",
+		examples: []string{
+			"gitbak -interval 2",
+			"gitbak -interval 0.5",
+			"gitbak -interval 90s",
+		},
+	},
+	{
+		name:    "min-interval",
+		group:   "core",
+		env:     "MIN_INTERVAL_MINUTES",
+		details: "Let the interval shrink while you are busy. After several checks in a row that each create a checkpoint, the interval is halved, down to this value, given like -interval as minutes or a Go duration. Must not exceed -interval.",
+		examples: []string{
+			"gitbak -interval 5 -min-interval 1",
+		},
+	},
+	{
+		name:    "max-interval",
+		group:   "core",
+		env:     "MAX_INTERVAL_MINUTES",
+		details: "Let the interval grow while the repository is idle, so fewer git processes are run. After several checks in a row that find nothing to commit, the interval is doubled, up to this value, given like -interval as minutes or a Go duration; the next checkpoint restores -interval. Must be at least -interval.",
+		examples: []string{
+			"gitbak -interval 5 -max-interval 30",
+			"gitbak -interval 2 -min-interval 0.5 -max-interval 20",
+		},
+	},
+	{
+		name:    "idle-after",
+		group:   "core",
+		env:     "IDLE_AFTER",
+		details: "Go idle once this many checks in a row have found no changes at all, e.g. overnight: gitbak then checks only every -idle-interval and says so once, instead of reporting each quiet check. The first check that finds changes ends the idle period and restores the usual interval; with -watch that happens as soon as a file changes. Changes held back by -min-changed-lines or -min-changed-files count as changes. 0 never goes idle.",
+		examples: []string{
+			"gitbak -idle-after 6",
+			"gitbak -watch -idle-after 3 -idle-interval 60",
+		},
+	},
+	{
+		name:    "idle-interval",
+		group:   "core",
+		env:     "IDLE_INTERVAL_MINUTES",
+		details: "Time between checks while idle after -idle-after checks without changes, in minutes or as a Go duration such as 1h. Must be at least -interval.",
+		examples: []string{
+			"gitbak -idle-after 6 -idle-interval 60",
+			"gitbak -idle-after 6 -idle-interval 1h",
+		},
+	},
+	{
+		name:    "align",
+		group:   "core",
+		env:     "ALIGN",
+		details: "Line scheduled checks up with the wall clock: each is moved on to the first of these marks past the hour that is at least -interval after the previous check was due. Marks are comma-separated :MM or :MM:SS in local time. Without it, checks are due every -interval from the start of the session. Checks are scheduled from when the previous one was due, not when it finished, so slow git operations don't make them drift; one that overruns the interval is logged, and the checks it ran past are skipped.",
+		examples: []string{
+			"gitbak -interval 5 -align :00,:05,:10,:15,:20,:25,:30,:35,:40,:45,:50,:55",
+			"gitbak -interval 30 -align :00,:30",
+		},
+	},
+	{
+		name:    "battery-threshold",
+		group:   "core",
+		env:     "BATTERY_THRESHOLD",
+		details: "On laptops, check less often while running on battery with less than this percentage left, so a session doesn't keep spawning git processes on a nearly empty battery. Checks are then spaced -battery-interval apart; plugging in, or charging above the threshold, restores the usual schedule. 0 disables it. Supported on Linux and macOS.",
+		examples: []string{
+			"gitbak -battery-threshold 20",
+		},
+	},
+	{
+		name:    "battery-interval",
+		group:   "core",
+		env:     "BATTERY_INTERVAL_MINUTES",
+		details: "Time between checks while the battery is below -battery-threshold, in minutes or as a Go duration such as 30m. 0 pauses checkpointing until the machine is plugged in; POST /commit-now on the -listen endpoint still checkpoints on demand.",
+		examples: []string{
+			"gitbak -battery-threshold 20 -battery-interval 30",
+			"gitbak -battery-threshold 10 -battery-interval 0",
+		},
+	},
+	{
+		name:    "watch",
+		group:   "core",
+		env:     "WATCH",
+		details: "Use file system notifications to check for changes a couple of seconds after files are saved, instead of running git status on every interval. The interval then only serves as a safety net and idle repositories are not polled. Where watching is unsupported, or the system's watch limit is reached, gitbak warns and falls back to polling.",
+		examples: []string{
+			"gitbak -watch",
+			"gitbak -watch -interval 15",
+		},
+	},
+	{
+		name:     "branch",
+		group:    "core",
+		env:      "BRANCH_NAME",
+		details:  "Name of the branch that receives checkpoint commits. Without it, the name is generated from -branch-template. With -no-branch or -continue, the current branch is used instead.",
+		examples: []string{"gitbak -branch feature-backup"},
+	},
+	{
+		name:    "branch-template",
+		group:   "core",
+		env:     "BRANCH_TEMPLATE",
+		details: "Go template the session branch is named from when -branch is not given, e.g. to meet branch protection rules that require a prefix. It can use {{.User}} (your login name), {{.Date}} (20060102), {{.Time}} (150405), {{.Timestamp}} (the two joined by a dash), {{.BaseBranch}} (the branch checked out when gitbak started) and {{.Repo}} (the repository's directory name). Characters git does not allow in branch names are replaced with dashes in the values; gitbak refuses to start if the name is still not a valid branch name.",
+		examples: []string{
+			"gitbak -branch-template 'gitbak/{{.User}}/{{.Date}}-{{.BaseBranch}}'",
+			"BRANCH_TEMPLATE='backup/{{.Repo}}-{{.Timestamp}}' gitbak",
+		},
+	},
+	{
+		name:     "prefix",
+		group:    "core",
+		env:      "COMMIT_PREFIX",
+		details:  "Prefix for checkpoint commit messages. gitbak also uses it to find its own commits when continuing a session.",
+		examples: []string{"gitbak -prefix \"[pair]\""},
+	},
+	{
+		name:    "author",
+		group:   "core",
+		env:     "COMMIT_AUTHOR",
+		details: "Attribute checkpoint commits to a separate identity, such as a bot, so they are easy to tell apart from your own commits when pairing. The name and -author-email are used as both author and committer; your git user is left untouched and still makes every other commit. Cannot be combined with -git-backend gogit.",
+		examples: []string{
+			"gitbak -author \"gitbak bot\" -author-email gitbak@example.com",
+		},
+	},
+	{
+		name:     "author-email",
+		group:    "core",
+		env:      "COMMIT_EMAIL",
+		details:  "Email of the identity checkpoint commits are attributed to. Must be given together with -author.",
+		examples: []string{"gitbak -author \"gitbak bot\" -author-email gitbak@example.com"},
+	},
+	{
+		name:    "coauthor",
+		group:   "core",
+		env:     "COAUTHORS",
+		details: "Credit someone else with every checkpoint, such as the other half of a pair, by ending its message with a Co-authored-by trailer, which hosts like GitHub show as a co-author. Give the identity as 'Name <email>'. Repeat the flag for several co-authors; in COAUTHORS, separate them with ';'. The trailers are also carried over into the commit 'gitbak squash' makes.",
+		examples: []string{
+			"gitbak -coauthor \"Alice Example <alice@example.com>\"",
+			"COAUTHORS=\"Alice <alice@example.com>;Bob <bob@example.com>\" gitbak",
+		},
+	},
+	{
+		name:    "min-changed-lines",
+		group:   "core",
+		env:     "MIN_CHANGED_LINES",
+		details: "Keep history meaningful by not checkpointing trivially small changes, such as a single whitespace fix. Changes are left to accumulate until they add or remove at least this many lines (untracked files included, binary files counting as one line), or until -min-changed-files is met. 0 disables it. Cannot be combined with -mode stash, -refs-only or -git-backend gogit.",
+		examples: []string{
+			"gitbak -min-changed-lines 5",
+			"gitbak -min-changed-lines 10 -min-changed-files 3",
+		},
+	},
+	{
+		name:     "min-changed-files",
+		group:    "core",
+		env:      "MIN_CHANGED_FILES",
+		details:  "Like -min-changed-lines, but counts the files changed. When both are set, meeting either one is enough for a checkpoint. 0 disables it.",
+		examples: []string{"gitbak -min-changed-files 2"},
+	},
+	{
+		name:    "max-skipped-checks",
+		group:   "core",
+		env:     "MAX_SKIPPED_CHECKS",
+		details: "How many checks in a row may hold back changes below -min-changed-lines or -min-changed-files. The next check commits them regardless, so a small final edit still ends up in a checkpoint. 0 holds them back until the threshold is met.",
+		examples: []string{
+			"gitbak -min-changed-lines 5 -max-skipped-checks 3",
+		},
+	},
+	{
+		name:    "min-quiet",
+		group:   "core",
+		env:     "MIN_QUIET_SECONDS",
+		details: "Keep checkpoints consistent while something writes many files at once, such as a build or a code generator: a checkpoint is only made once no changed file has been modified, and with -watch no change reported, for this many seconds. A check that finds changes still being made checks again as soon as the period is up, instead of waiting for the next interval. The checkpoint made when gitbak stops does not wait. A file written continuously, such as a log, holds checkpoints back for as long as it is; leave it out with .gitbakignore. 0 disables it. Cannot be combined with -mode stash or observe, or -refs-only.",
+		examples: []string{
+			"gitbak -watch -min-quiet 10",
+			"MIN_QUIET_SECONDS=5 gitbak",
+		},
+	},
+	{
+		name:    "check-cmd",
+		group:   "core",
+		env:     "CHECK_CMD",
+		details: "Run this shell command in the repository before each checkpoint, such as a build or a quick test run, and record whether it exited successfully: the checkpoint's subject ends in ✅ or ❌, and a Gitbak-Check trailer says passed or failed, so 'git log --grep \"Gitbak-Check: passed\"' finds the last known good checkpoint. -on-check-fail chooses whether a failing command still makes the checkpoint. The command counts towards -op-timeout, so raise it for slow builds. Cannot be combined with -mode stash or observe, or -refs-only. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -check-cmd 'go build ./...'",
+			"CHECK_CMD='npm test -- --bail' gitbak",
+		},
+	},
+	{
+		name:    "on-check-fail",
+		group:   "core",
+		env:     "ON_CHECK_FAIL",
+		values:  []string{"mark", "skip"},
+		details: "What a failing -check-cmd does to the checkpoint: 'mark' (the default) makes it anyway, marked ❌, so no work goes unsaved; 'skip' holds the changes back until the command passes, so every checkpoint is good. Held changes are not checked again until they change. The checkpoint made when gitbak stops is made without running the command, as there is no time for a build, and carries no marker.",
+		examples: []string{
+			"gitbak -check-cmd 'go vet ./...' -on-check-fail skip",
+		},
+	},
+	{
+		name:    "max-file-size",
+		group:   "core",
+		env:     "MAX_FILE_SIZE_MB",
+		details: "Guard history against large files, such as a database dump or a build artifact, that would bloat the repository for good once committed. Changed files over this many megabytes are left out of checkpoints: interactive sessions ask about each one the first time it is found, and sessions with -non-interactive skip it with a warning. Either way the file is not mentioned again. 0 disables the limit. Cannot be combined with -git-backend gogit.",
+		examples: []string{
+			"gitbak -max-file-size 50",
+			"MAX_FILE_SIZE_MB=100 gitbak -non-interactive",
+		},
+	},
+	{
+		name:    "path",
+		group:   "core",
+		env:     "PATHS",
+		details: "Scope checkpoints to part of a repository, such as a team's area of a monorepo. Only changes under the given file or directory, relative to the repository, are detected and staged: changes elsewhere neither trigger a checkpoint nor end up in one, and stay in the working tree as they are. Repeat the flag to checkpoint several paths; in PATHS, separate them with ';'. Paths are taken literally, without glob patterns. Cannot be combined with -git-backend gogit.",
+		examples: []string{
+			"gitbak -path services/billing",
+			"gitbak -path services/billing -path libs/shared",
+			"PATHS='services/billing;libs/shared' gitbak",
+		},
+	},
+	{
+		name:    "secrets",
+		group:   "core",
+		env:     "SECRETS",
+		values:  []string{"skip", "abort", "off"},
+		details: "Scan every change before it is checkpointed for things that look like credentials: private keys, AWS, GitHub, GitLab, Slack, Stripe and Google keys, random-looking values assigned to names like password or api_key, and files such as .env, *.pem and id_rsa. Once committed, a secret stays in the repository's history for good. With skip, files with a hit are left out of checkpoints: interactive sessions ask about each one the first time it is found, and sessions with -non-interactive skip it with a warning. With abort, no checkpoint is made until the secret is gone. A line containing gitbak:allow is never reported. Defaults to skip, or off with -git-backend gogit, which cannot scan changes; sessions then warn at startup that secret scanning is disabled.",
+		examples: []string{
+			"gitbak -secrets abort",
+			"SECRETS=off gitbak",
+		},
+	},
+	{
+		name:    "diff-summary",
+		group:   "core",
+		env:     "DIFF_SUMMARY",
+		details: "Give every checkpoint commit a body listing the files it changes with their line counts, as 'git diff --stat' shows them, followed by any files created, deleted or renamed. Reviewing a session then shows what each checkpoint contains without opening it. Long lists are cut off after 50 files.",
+		examples: []string{
+			"gitbak -diff-summary",
+			"git log gitbak-1700000000",
+		},
+	},
+	{
+		name:    "no-verify",
+		group:   "core",
+		env:     "NO_VERIFY",
+		details: "Create checkpoints with 'git commit --no-verify', skipping the repository's pre-commit and commit-msg hooks. Formatters, linters and other checks meant for your own commits then can't slow checkpoints down, change what they contain, or make every one of them fail. Hooks still run for your commits and for squashing a session. Set NO_VERIFY=true, or no-verify in a config file, to make it the default.",
+		examples: []string{
+			"gitbak -no-verify",
+		},
+	},
+	{
+		name:     "no-branch",
+		group:    "core",
+		env:      "CREATE_BRANCH=false",
+		details:  "Commit checkpoints onto the current branch instead of creating a new one. Useful when mixing manual milestone commits with checkpoints.",
+		examples: []string{"gitbak -no-branch"},
+	},
+	{
+		name:     "repo",
+		group:    "core",
+		env:      "REPO_PATH",
+		path:     true,
+		details:  "Repository to monitor. Relative paths are resolved against the current directory.",
+		examples: []string{"gitbak -repo ~/src/project"},
+	},
+	{
+		name:    "git-dir",
+		group:   "core",
+		env:     "GIT_DIR",
+		path:    true,
+		details: "The repository's git directory, for work trees that are not co-located with their .git, such as a bare repository of dotfiles checked out into the home directory or a deployment checkout. It is passed to every git command gitbak runs, and read from GIT_DIR as git itself does. Give the work tree with -work-tree unless the repository records one (core.worktree). It cannot be combined with -git-backend gogit or -submodules recursive.",
+		examples: []string{
+			"gitbak -git-dir ~/.dotfiles -work-tree ~ -untracked-policy none",
+			"GIT_DIR=/srv/app.git GIT_WORK_TREE=/srv/app gitbak",
+		},
+	},
+	{
+		name:     "work-tree",
+		group:    "core",
+		env:      "GIT_WORK_TREE",
+		path:     true,
+		details:  "The top-level directory of the work tree that goes with -git-dir, passed to every git command and read from GIT_WORK_TREE as git itself does. gitbak monitors it unless -repo names a directory inside it.",
+		examples: []string{"gitbak -git-dir ~/.dotfiles -work-tree ~"},
+	},
+	{
+		name:     "continue",
+		group:    "core",
+		env:      "CONTINUE_SESSION",
+		details:  "Resume a previous session on the current branch, continuing the checkpoint numbering where it left off. The numbering is read from the notes gitbak records under refs/notes/gitbak, which survive renaming the branch or changing -prefix, or else from checkpoint subjects.",
+		examples: []string{"git checkout gitbak-1700000000 && gitbak -continue"},
+	},
+	{
+		name:    "mode",
+		group:   "core",
+		env:     "CHECKPOINT_MODE",
+		values:  []string{"branch", "stash", "refs", "observe"},
+		details: "Where checkpoints are recorded. 'branch' commits them on the session branch (or the current one with -no-branch). 'stash' stores each snapshot as a stash entry on top of HEAD instead, for workflows that forbid extra commits: no branch is created or moved, and the index and working tree are left as they are. Restore a snapshot with 'git stash apply'. 'refs' is the same as -refs-only. 'observe' records no checkpoints at all and writes nothing to the repository: every check that finds files changed since the previous one appends the files, the time and their line counts to the -journal file, for auditing how a session evolved where commits aren't allowed. Stash, refs and observe modes cannot be combined with -continue, -chain-trailer, -push or -mirror.",
+		examples: []string{
+			"gitbak -mode stash",
+			"git stash list",
+			"gitbak -mode observe -journal session.jsonl",
+		},
+	},
+	{
+		name:    "refs-only",
+		group:   "core",
+		env:     "REFS_ONLY",
+		details: "Write each checkpoint to a ref of its own, refs/gitbak/<session>/<n>, instead of committing it on a branch, so 'git branch' stays clean. The session is named like the branch it would otherwise have created, or by -branch. Each checkpoint's parent is the one before it, so the latest ref holds the whole session and 'gitbak materialize' turns it into a branch whenever it is needed, while 'gitbak abort' deletes the refs. No branch is created or moved, and the index and working tree are left as they are. The same as -mode refs, with the same restrictions as -mode stash.",
+		examples: []string{
+			"gitbak -refs-only",
+			"git for-each-ref refs/gitbak",
+			"gitbak materialize",
+		},
+	},
+	{
+		name:     "journal",
+		group:    "core",
+		env:      "JOURNAL_FILE",
+		path:     true,
+		details:  "The file -mode observe appends its journal to, one line of JSON per change detected, listing the files changed since the previous line with their status and how many lines they differ from HEAD by. By default, each repository has its own journal in ~/.local/share/gitbak/journals. Ignored in the other modes. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{"gitbak -mode observe -journal ~/audit/session.jsonl"},
+	},
+	{
+		name:    "git-backend",
+		group:   "core",
+		env:     "GIT_BACKEND",
+		values:  []string{"exec", "gogit"},
+		details: "How git commands are carried out. 'exec' runs the git binary. 'gogit' uses a built-in implementation instead, for minimal containers and CI images without git installed; it covers monitoring sessions only, so it cannot be combined with -push, -mirror, -mode stash, -refs-only or -diff-summary, and the other commands still need git. With -continue, also name the branch with -branch.",
+		examples: []string{
+			"gitbak -git-backend gogit",
+			"GIT_BACKEND=gogit gitbak -no-branch",
+		},
+	},
+	{
+		name:    "git-path",
+		group:   "core",
+		env:     "GIT_PATH",
+		details: "Run this git binary, such as a newer git from Homebrew or a wrapper script, instead of the first git in PATH. It is used for every git command gitbak runs, including those of abort, squash and the other commands.",
+		examples: []string{
+			"gitbak -git-path /opt/homebrew/bin/git",
+			"GIT_PATH=~/bin/git-wrapper gitbak",
+		},
+	},
+	{
+		name:    "git-args",
+		group:   "core",
+		env:     "GIT_GLOBAL_ARGS",
+		details: "Pass these arguments to git before every command's own, separated by whitespace, such as -c options that speed up large repositories. They apply to every git command gitbak runs, so they should not change what a command does.",
+		examples: []string{
+			"gitbak -git-args '-c core.untrackedCache=true -c core.fsmonitor=true'",
+		},
+	},
+	{
+		name:    "submodules",
+		group:   "core",
+		env:     "SUBMODULES",
+		values:  []string{"include", "ignore", "recursive"},
+		details: "How checkpoints treat submodules. 'include' records a submodule's new commit when its HEAD moves, as 'git add .' does; uncommitted changes inside a submodule are left alone and don't count as changes. 'ignore' leaves submodules out of checkpoints entirely. 'recursive' first commits the uncommitted changes inside each submodule, nested ones first, on the submodule's current HEAD, so the checkpoint records them too. Only 'include' works with -git-backend gogit, and 'recursive' cannot be combined with -mode stash or -refs-only.",
+		examples: []string{
+			"gitbak -submodules ignore",
+			"gitbak -submodules recursive",
+		},
+	},
+	{
+		name:    "untracked-files",
+		group:   "core",
+		env:     "UNTRACKED_FILES",
+		values:  []string{"normal", "all", "no"},
+		details: "How change checks look for new files, as git status --untracked-files does. 'normal' reports new files, and new directories as a whole. 'all' looks inside new directories too. 'no' skips the search for new files, which is quickest in very large trees: new files alone don't trigger a checkpoint, but are included in the next one that other changes trigger. Only 'normal' works with -git-backend gogit.",
+		examples: []string{
+			"gitbak -untracked-files no",
+			"UNTRACKED_FILES=no gitbak -fast-status",
+		},
+	},
+	{
+		name:    "untracked-policy",
+		group:   "core",
+		env:     "UNTRACKED_POLICY",
+		values:  []string{"all", "none", "prompt"},
+		details: "Whether new files are taken into checkpoints. 'all' checkpoints them like changes to tracked files. 'none' checkpoints only tracked files; a new file is taken in once you add it with git add. 'prompt' asks about each new file, or new directory, the first time it shows up, and leaves it out with -non-interactive. Files left out stay in the working tree untouched. Only 'all' works with -git-backend gogit.",
+		examples: []string{
+			"gitbak -untracked-policy none",
+			"gitbak -untracked-policy prompt",
+		},
+	},
+	{
+		name:    "fast-status",
+		group:   "core",
+		env:     "FAST_STATUS",
+		details: "Speed up change checks in large repositories. Checks use git's untracked cache, which remembers which directories hold no new files, and, where git supports it (macOS and Windows), git's built-in file system monitor, which tells git which files changed. With a monitor, a check of a clean tree returns without working out what to exclude from checkpoints. The monitor daemon keeps running after gitbak exits, until 'git fsmonitor--daemon stop'. Settings the repository already configures, such as a Watchman hook in core.fsmonitor, are used as they are. Has no effect with -git-backend gogit.",
+		examples: []string{
+			"gitbak -fast-status",
+		},
+	},
+	{
+		name:    "empty-repo",
+		group:   "core",
+		env:     "EMPTY_REPO",
+		values:  []string{"initial-commit", "root-checkpoint", "fail"},
+		details: "What to do when the repository has no commits yet, as after 'git init'. 'initial-commit' records an empty commit on the current branch first, so abort and squash have a branch to return to; 'root-checkpoint' makes the first checkpoint the root commit; 'fail' refuses to start.",
+		examples: []string{
+			"gitbak -empty-repo root-checkpoint",
+			"gitbak -empty-repo fail",
+		},
+	},
+	{
+		name:     "quiet",
+		group:    "output",
+		env:      "VERBOSE=false",
+		details:  "Hide informational messages, the same as -log-level warn. Commit confirmations, warnings and errors are still shown. An explicit -log-level takes precedence.",
+		examples: []string{"gitbak -quiet"},
+	},
+	{
+		name:    "log-level",
+		group:   "output",
+		env:     "LOG_LEVEL",
+		values:  []string{"error", "warn", "info", "debug", "trace"},
+		details: "Which messages are shown, each level adding to the ones before it: 'error' shows only errors, 'warn' adds warnings and commit confirmations, 'info' (the default) adds informational messages, 'debug' adds gitbak's internal messages and 'trace' adds every git command run, with how long it took. Banners, summaries and prompts are always shown. With -debug, the log file records the same trace messages, and 'gitbak logs' shows those of the level given and above.",
+		examples: []string{
+			"gitbak -log-level warn",
+			"LOG_LEVEL=debug gitbak",
+		},
+	},
+	{
+		name:     "v",
+		group:    "output",
+		details:  "Raise -log-level by one level, from info to debug; give it twice, or use -vv, for trace. Levels beyond trace are ignored.",
+		examples: []string{"gitbak -v", "gitbak -v -v"},
+	},
+	{
+		name:     "vv",
+		group:    "output",
+		details:  "Raise -log-level by two levels, from info to trace, to see every git command gitbak runs.",
+		examples: []string{"gitbak -vv", "gitbak -quiet -vv"},
+	},
+	{
+		name:     "show-no-changes",
+		group:    "output",
+		env:      "SHOW_NO_CHANGES",
+		details:  "Print a message on every check that finds nothing to commit.",
+		examples: []string{"gitbak -show-no-changes"},
+	},
+	{
+		name:     "no-color",
+		group:    "output",
+		env:      "NO_COLOR",
+		details:  "Print output without colors, such as those in the branch visualization at the end of a session. Colors are also left out when the output is not a terminal, e.g. when it is piped into a file. Following the NO_COLOR convention, setting NO_COLOR to any non-empty value has the same effect.",
+		examples: []string{"gitbak -no-color", "NO_COLOR=1 gitbak"},
+	},
+	{
+		name:    "errors-json",
+		group:   "output",
+		env:     "ERRORS_JSON",
+		details: "When gitbak fails, print the error as a single line of JSON on stderr instead of the usual message, for scripts that wrap gitbak. The object has a 'code' naming the failure (lock_held, not_a_repository, invalid_configuration, max_retries_exceeded, git_not_found, crashed, repository_moved, disabled; timeout, index_locked, push_rejected or hook_failed, which exit with 1; or error for anything else), the 'exit_code' gitbak exits with, the 'message', and details such as the 'parameter' of an invalid setting, the 'pid' holding the lock or the 'hook' that failed.",
+		examples: []string{
+			"gitbak -errors-json 2>errors.log",
+		},
+	},
+	{
+		name:    "tui",
+		group:   "output",
+		env:     "TUI",
+		details: "Show a dashboard that updates in place instead of scrolling log lines: the elapsed session time, the number of checkpoints, the files and lines changed by the latest one, the time until the next check, and any errors, along with the latest message. The dashboard appears once the session has started, after any prompts, and is replaced by the usual summary when gitbak stops. When stdout is not a terminal, gitbak warns and shows log lines instead.",
+		examples: []string{
+			"gitbak -tui",
+			"gitbak -tui -interval 2",
+		},
+	},
+	{
+		name:    "output",
+		group:   "output",
+		env:     "OUTPUT_FORMAT",
+		values:  []string{"text", "json"},
+		details: "How the session summary, 'gitbak status' and 'gitbak stats' are printed. With 'json', each is printed as a single line of JSON on stdout, and every other message goes to stderr, so stdout can be piped straight into a script or dashboard. The summary holds the session report that -summary-file writes (branch, checkpoint counts, duration and commits); the status holds whether gitbak is running and the recorded session; the stats hold the totals across recorded sessions. 'gitbak logs' prints each log record it shows as a line of JSON, with its time, level and message. Cannot be combined with -tui.",
+		examples: []string{
+			"gitbak -output json > session.json",
+			"gitbak status -output json | jq .session.checkpoints",
+		},
+	},
+	{
+		name:    "notify",
+		group:   "output",
+		env:     "NOTIFY",
+		values:  notify.Modes,
+		details: "Also show messages as desktop notifications, using osascript on macOS and notify-send on Linux. 'errors' notifies about failed checkpoints and pushes and when gitbak stops after too many consecutive errors; 'all' also notifies about every checkpoint. If no notifier is available, gitbak warns once and carries on without notifications.",
+		examples: []string{
+			"gitbak -notify errors",
+			"gitbak -notify all",
+		},
+	},
+	{
+		name:     "debug",
+		group:    "output",
+		env:      "DEBUG",
+		details:  "Write detailed logs to the log file. The location is printed at startup.",
+		examples: []string{"gitbak -debug"},
+	},
+	{
+		name:     "log-file",
+		group:    "output",
+		env:      "LOG_FILE",
+		path:     true,
+		details:  "Where debug logs are written. Only used together with -debug, and by gitbak logs to find them. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{"gitbak -debug -log-file /tmp/gitbak.log"},
+	},
+	{
+		name:     "log-backend",
+		group:    "output",
+		env:      "LOG_BACKEND",
+		values:   []string{"text", "json", "syslog", "journald"},
+		details:  "Where debug logs are recorded. 'text' (the default) and 'json' write the log file, as logfmt-style records or one JSON object per line for log shippers. 'syslog' and 'journald' send them to the system's logs instead, tagged gitbak, and turn on -debug; -log-file and the rotation options then do not apply, and 'gitbak logs' points at journalctl or the syslog files. journald is only available on Linux, and syslog everywhere but Windows.",
+		examples: []string{"gitbak -debug -log-backend json", "LOG_BACKEND=journald gitbak"},
+	},
+	{
+		name:     "log-max-size",
+		group:    "output",
+		env:      "LOG_MAX_SIZE_MB",
+		details:  "Rotate the log file once it reaches this many megabytes: it is renamed to <file>.1, earlier rotations move up to <file>.2 and so on, and only -log-max-files of them are kept. 0 lets the file grow without bound.",
+		examples: []string{"gitbak -debug -log-max-size 50"},
+	},
+	{
+		name:     "log-max-files",
+		group:    "output",
+		env:      "LOG_MAX_FILES",
+		details:  "How many rotated log files to keep next to the log file. 0 discards the log each time it is rotated.",
+		examples: []string{"gitbak -debug -log-max-size 5 -log-max-files 2"},
+	},
+	{
+		name:     "log-max-age",
+		group:    "output",
+		env:      "LOG_MAX_AGE_DAYS",
+		details:  "At startup, remove log files and rotations in ~/.local/share/gitbak/logs that haven't been written to for this many days, such as those of repositories gitbak no longer runs in. Other files in the directory, and a -log-file elsewhere, are left alone. 0 keeps them all.",
+		examples: []string{"gitbak -log-max-age 7"},
+	},
+	{
+		name:     "follow",
+		group:    "output",
+		details:  "Used by logs to keep printing records as they are written to the log file, like tail -f, until interrupted. Rotations are followed to the new file.",
+		examples: []string{"gitbak logs -follow", "gitbak logs -f -log-level warn"},
+	},
+	{
+		name:     "f",
+		group:    "output",
+		details:  "Short for -follow.",
+		examples: []string{"gitbak logs -f"},
+	},
+	{
+		name:     "lines",
+		group:    "output",
+		details:  "Used by logs as the number of the last records to print, after -log-level has filtered them, before following the file. 0 prints them all.",
+		examples: []string{"gitbak logs -lines 50", "gitbak logs -lines 0 -output json"},
+	},
+	{
+		name:    "summary-file",
+		group:   "output",
+		env:     "SUMMARY_FILE",
+		path:    true,
+		details: "When the session ends, also write a report of it to this file: the branch, start and end times, every commit made with its time and the files and lines it changed, and the commands for merging the session. The report is JSON if the name ends in .json and Markdown otherwise. An existing file is overwritten, so this can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -summary-file session.md",
+			"gitbak -summary-file ~/notes/pairing-session.json",
+		},
+	},
+	{
+		name:    "detach",
+		group:   "core",
+		details: "Start the session in the background, detached from the terminal, so a tab does not have to stay open for every repository. Prompts are answered with their defaults and output is appended to the log file (-log-file). 'gitbak status' reports on the session and 'gitbak stop' ends it.",
+		examples: []string{
+			"gitbak start -detach",
+			"gitbak status",
+			"gitbak stop",
+		},
+	},
+	{
+		name:     "pprof",
+		group:    "output",
+		details:  "Serve Go runtime profiles for diagnosing CPU or memory issues. Bind to a loopback address; the endpoints are unauthenticated. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{"gitbak -pprof 127.0.0.1:6060"},
+	},
+	{
+		name:     "max-retries",
+		group:    "safety",
+		env:      "MAX_RETRIES",
+		details:  "Stop after this many consecutive identical errors. A different error or a successful check resets the count. A check that finds the git index locked by another git process is retried a few times within moments, and if the index stays locked it is skipped without counting.",
+		examples: []string{"gitbak -max-retries 10", "gitbak -max-retries 0"},
+	},
+	{
+		name:     "op-timeout",
+		group:    "safety",
+		env:      "OP_TIMEOUT",
+		details:  "Each check-and-commit cycle, and each push attempt, is canceled if it takes longer than this, so a git command that hangs (for example on a credential helper prompt) cannot stall the session. The check fails and is retried at the next interval; repeated timeouts count toward -max-retries like other errors. Takes a Go duration such as 90s or 5m.",
+		examples: []string{"gitbak -op-timeout 5m", "gitbak -op-timeout 0"},
+	},
+	{
+		name:     "command-timeout",
+		group:    "safety",
+		env:      "COMMAND_TIMEOUT",
+		details:  "A single git command still running after this long is killed, together with the hooks, filters and helpers it started, and the check fails with a timeout to be retried at the next interval. It catches a git add stuck on an unresponsive network filesystem sooner than -op-timeout, which bounds the whole check. An index lock left behind by a killed git add, commit or stash is removed; after other commands, such as a push, the lock belongs to another git process and is kept. Applies to the exec backend. Takes a Go duration such as 30s or 2m; 0 disables the limit.",
+		examples: []string{"gitbak -command-timeout 3m", "gitbak -command-timeout 0"},
+	},
+	{
+		name:    "on-diverge",
+		group:   "safety",
+		env:     "ON_DIVERGE",
+		values:  []string{"warn", "pause"},
+		details: "What to do when the session branch's history is rewritten while gitbak runs, for example by an interactive rebase, a reset or an amended commit. Before each checkpoint gitbak checks that the branch still contains its tip from the last check; commits you add on top are fine. 'warn' tells you once and checkpoints on top of the new history. 'pause' tells you and pauses checkpointing, so nothing is committed onto history you are still working on; 'gitbak resume' continues from the new history.",
+		examples: []string{
+			"gitbak -on-diverge pause",
+			"gitbak resume",
+		},
+	},
+	{
+		name:     "commit-on-exit",
+		group:    "safety",
+		env:      "COMMIT_ON_EXIT",
+		details:  "When Ctrl+C or 'gitbak stop' ends a session, gitbak checks for changes one last time and checkpoints them before printing the summary, so work done since the last interval is not left uncommitted. Nothing is committed while checkpointing is paused. Pass -commit-on-exit=false to leave those changes in the working tree instead.",
+		examples: []string{"gitbak -commit-on-exit=false"},
+	},
+	{
+		name:     "shutdown-timeout",
+		group:    "safety",
+		env:      "SHUTDOWN_TIMEOUT",
+		details:  "When Ctrl+C or 'gitbak stop' ends a session, gitbak makes a final checkpoint of any changes since the last check and prints the session summary. Both are canceled if they take longer than this in total, and a second Ctrl+C cancels them at once. Takes a Go duration such as 30s; 0 waits as long as they take.",
+		examples: []string{"gitbak -shutdown-timeout 30s", "gitbak -shutdown-timeout 0"},
+	},
+	{
+		name:    "lock-wait",
+		group:   "safety",
+		env:     "LOCK_WAIT",
+		details: "Only one gitbak process can monitor a repository at a time. By default a second one exits straight away; with -lock-wait it waits up to this long for the running one to exit and then takes over, which helps scripts that restart gitbak right after stopping it. Takes a Go duration such as 10s or 1m.",
+		examples: []string{
+			"gitbak stop; gitbak -lock-wait 30s",
+		},
+	},
+	{
+		name:    "takeover",
+		group:   "safety",
+		details: "Take over from another gitbak process monitoring the repository instead of exiting. The running process is asked to stop, as with gitbak stop, and gets twice -shutdown-timeout (or twice its default, if it is 0) to make its final checkpoint; a process that does not stop in time, such as a hung one, is killed. The new session then starts as usual, so add -continue to carry on the old session's branch.",
+		examples: []string{
+			"gitbak -takeover",
+			"gitbak -takeover -continue",
+		},
+	},
+	{
+		name:    "join",
+		group:   "safety",
+		details: "When another gitbak process is monitoring the repository, show its session as a live status view instead of exiting: the dashboard of -tui in a terminal, or a line per checkpoint otherwise. Nothing is checkpointed by the viewer, and Ctrl+C leaves the session running. The view ends when the session does. Starts a session as usual if none is running.",
+		examples: []string{
+			"gitbak -join",
+		},
+	},
+	{
+		name:    "lock-scope",
+		group:   "safety",
+		env:     "LOCK_SCOPE",
+		values:  []string{"worktree", "repository"},
+		details: "What the lock keeps to a single gitbak process. 'worktree' locks each working tree on its own, so sessions in separate worktrees of one repository (see 'git worktree add') can checkpoint different branches side by side. 'repository' allows only one session across all worktrees of the repository, for setups where parallel sessions would get in each other's way. The subcommands that find the running session, such as stop and status, use the same scope.",
+		examples: []string{
+			"gitbak -lock-scope repository",
+		},
+	},
+	{
+		name:    "lock-stale-after",
+		group:   "safety",
+		env:     "LOCK_STALE_AFTER",
+		details: "A running gitbak process refreshes the timestamp in its lock file once a minute. A lock is reclaimed when its PID no longer runs or it was taken before the machine last rebooted. With -lock-stale-after, so is one that has not been refreshed for this long, as when a crashed gitbak's PID has been reused by another program. Must be at least 5m; 0 trusts the PID alone.",
+		examples: []string{
+			"gitbak -lock-stale-after 10m",
+		},
+	},
+	{
+		name:     "retry-backoff",
+		group:    "safety",
+		env:      "RETRY_BACKOFF",
+		details:  "After a failed check, wait at least this long before the next attempt; checks that fall due sooner are skipped. The wait doubles each time the same error repeats, up to -retry-backoff-max, and varies randomly by up to 20%. This keeps short intervals, nudges and file changes from using up -max-retries within seconds on a transient problem such as a locked index. 0 retries at the next check.",
+		examples: []string{"gitbak -retry-backoff 10s", "gitbak -retry-backoff 0"},
+	},
+	{
+		name:     "retry-backoff-max",
+		group:    "safety",
+		env:      "RETRY_BACKOFF_MAX",
+		details:  "The longest wait between attempts while the same error repeats (see -retry-backoff).",
+		examples: []string{"gitbak -retry-backoff 5s -retry-backoff-max 1m"},
+	},
+	{
+		name:     "chain-trailer",
+		group:    "safety",
+		env:      "CHAIN_TRAILER",
+		details:  "Every checkpoint is recorded in an integrity hash chain that 'gitbak verify' checks. With this flag, each checkpoint commit also carries the previous link's hash as a Gitbak-Chain trailer, so rewrites can be detected from the history alone.",
+		examples: []string{"gitbak -chain-trailer", "gitbak verify"},
+	},
+	{
+		name:     "yes",
+		group:    "safety",
+		details:  "Skip the confirmation prompt of destructive commands such as abort, and commit generated messages (e.g. from squash) without opening an editor.",
+		examples: []string{"gitbak abort -yes", "gitbak squash -yes"},
+	},
+	{
+		name:     "message",
+		group:    "safety",
+		details:  "Used by squash as the first line of the commit message, in place of the generated subject. The session details (start time, duration, checkpoint count, fork point, notes and co-authors) are kept below it, and the commit is created without opening an editor.",
+		examples: []string{"gitbak squash -message \"Add CSV export\""},
+	},
+	{
+		name:     "session-only",
+		group:    "safety",
+		details:  "Used by export-bundle to bundle only the commits made since the session started, instead of the whole history of its branch. The bundle is smaller, but can only be fetched into a clone that already has the commit the session started from.",
+		examples: []string{"gitbak export-bundle -session-only ~/backups"},
+	},
+	{
+		name:    "push",
+		group:   "integration",
+		env:     "PUSH_REMOTE",
+		details: "Push the session branch to a remote so the checkpoints survive losing the machine. Failed pushes are retried a few times with backoff, then again at the next check; they never stop checkpointing. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -push origin",
+			"gitbak -push backup -push-interval 30",
+		},
+	},
+	{
+		name:     "push-interval",
+		group:    "integration",
+		env:      "PUSH_INTERVAL_MINUTES",
+		details:  "Minimum time between pushes made by -push, in minutes or as a Go duration such as 15m. Checkpoints made in between are pushed together once the interval has passed, or when the session stops, whichever comes first. 0 pushes after every checkpoint.",
+		examples: []string{"gitbak -push origin -push-interval 15", "gitbak -push origin -push-interval 1h"},
+	},
+	{
+		name:    "nudge-addr",
+		group:   "integration",
+		env:     "NUDGE_ADDR",
+		details: "Serve a POST /nudge endpoint that editor save hooks can call to request a check before the next interval. Nudges arriving within a couple of seconds are folded into one check. Useful in containers or on remote machines where file events don't propagate. Use unix:<path> to listen on a Unix socket only your user can connect to; a TCP endpoint is unauthenticated, so bind it to a loopback address unless the caller is elsewhere. 'gitbak nudge' with the same address (or NUDGE_ADDR, or the global config file) sends a nudge. A repository's .gitbak.toml cannot set it.",
+		examples: []string{
+			"gitbak -nudge-addr 127.0.0.1:7091",
+			"curl -X POST http://127.0.0.1:7091/nudge",
+			"gitbak -nudge-addr unix:/tmp/gitbak-project.sock",
+			"gitbak nudge -nudge-addr unix:/tmp/gitbak-project.sock",
+		},
+	},
+	{
+		name:    "metrics-addr",
+		group:   "integration",
+		env:     "METRICS_ADDR",
+		details: "Serve the session's metrics at /metrics in the Prometheus text format, for monitoring gitbak on many machines centrally: checks, checkpoints and errors so far, the current run of consecutive errors, the time of the latest checkpoint, and histograms of the files changed per checkpoint and of how long checks take. The endpoint only reads, so it may listen on an address the Prometheus server can reach. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -metrics-addr :9473",
+			"curl -s http://127.0.0.1:9473/metrics",
+		},
+	},
+	{
+		name:    "otlp-endpoint",
+		group:   "integration",
+		env:     "OTLP_ENDPOINT",
+		details: "Export OpenTelemetry spans to the OTLP/HTTP collector at this URL, to find out which git calls make checks slow on a large repository. Every check gets a gitbak.check span, with a child span for each git command it runs that records the command, its arguments, how long it took and its exit code. Spans are sent as JSON every few seconds; an unreachable collector is reported in the log and its spans are dropped, without affecting the session. Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -otlp-endpoint http://localhost:4318",
+			"OTLP_ENDPOINT=https://otel.example.com/v1/traces gitbak",
+		},
+	},
+	{
+		name:    "listen",
+		group:   "integration",
+		env:     "LISTEN_ADDR",
+		details: "Serve a local JSON endpoint for status lines and editor plugins. GET /status reports the session (branch, checkpoints, last and next check, whether it is paused); POST /pause and /resume suspend and resume checkpointing, POST /commit-now checks for changes immediately, even while paused, and POST /stop stops the session. Accepts unix:<path>, or host:port on a loopback address: the endpoint is unauthenticated, so gitbak refuses other addresses, and requests whose Host or Origin header names another site, such as a web page's. Set it on the command line, in the environment or in the global config file; a repository's .gitbak.toml cannot open it. 'gitbak control <action>' calls it, finding the address in the running session's state when -listen is not given.",
+		examples: []string{
+			"gitbak -listen 127.0.0.1:7373",
+			"curl -s http://127.0.0.1:7373/status",
+			"curl -s -X POST http://127.0.0.1:7373/pause",
+			"gitbak -listen unix:/tmp/gitbak-project.sock",
+			"gitbak control status",
+		},
+	},
+	{
+		name:     "rpc",
+		group:    "integration",
+		details:  "Let an editor extension host gitbak as a child process, speaking JSON-RPC 2.0 on stdin and stdout with the Content-Length framing of the Language Server Protocol. The requests gitbak/status, gitbak/pause, gitbak/resume, gitbak/commitNow and gitbak/stop act as the control endpoint's do and answer with its status document; gitbak sends the notifications gitbak/started, gitbak/checkpoint, gitbak/checkFailed, gitbak/paused and gitbak/stopped. Every other message goes to stderr, prompts are disabled, and closing stdin stops the session. Cannot be combined with -tui, -output json, -detach or -join.",
+		examples: []string{"gitbak -rpc -interval 2"},
+	},
+	{
+		name:    "mirror",
+		group:   "integration",
+		env:     "MIRRORS",
+		details: "Push the session branch to a destination so the safety net survives losing the machine. Mirrors receive it as refs/gitbak/<branch>, outside the remote's branches, so a mirror never overwrites a real branch. The first push of a session only fast-forwards that ref; later ones overwrite it only while it still holds the commit gitbak pushed last (--force-with-lease), so a mirror updated by anyone else is left alone. A profile without a name is named after its remote. Repeat the flag for several mirrors; in MIRRORS, separate profiles with ';'. 'every' sets the minimum time between pushes (default: after every checkpoint), though every mirror is brought up to date when the session stops, and 'limit' caps upload bandwidth in bytes per second (k and m suffixes allowed, ssh remotes only). Can be set in the global configuration file, but not a repository's .gitbak.toml.",
+		examples: []string{
+			"gitbak -mirror backup",
+			"gitbak -mirror nas=ssh://nas.local/backup/project.git",
+			"gitbak -mirror nas=nas-remote -mirror cloud=origin,every=1h,limit=256k",
+		},
+	},
+	{
+		name:    "on-start",
+		group:   "integration",
+		env:     "ON_START",
+		details: "Run a shell command (sh -c, or cmd /C on Windows) in the repository once the session is set up. Hooks run in the background with GITBAK_EVENT, GITBAK_REPO and GITBAK_BRANCH set, and are killed after a minute; one that fails is reported as a warning. Hooks can be set in the global configuration file but not a repository's .gitbak.toml, so that a cloned repository never runs commands of its own.",
+		examples: []string{
+			"gitbak -on-start 'echo \"gitbak started on $GITBAK_BRANCH\" >> ~/gitbak.log'",
+		},
+	},
+	{
+		name:    "on-commit",
+		group:   "integration",
+		env:     "ON_COMMIT",
+		details: "Run a shell command after each checkpoint, with GITBAK_COMMIT set to its hash and GITBAK_COUNTER to its number, as well as the variables -on-start sets. A slow hook never delays the next check.",
+		examples: []string{
+			"gitbak -on-commit 'curl -s -d \"checkpoint $GITBAK_COUNTER\" ntfy.sh/my-gitbak'",
+		},
+	},
+	{
+		name:    "on-error",
+		group:   "integration",
+		env:     "ON_ERROR",
+		details: "Run a shell command after each failed check, with GITBAK_ERROR describing what went wrong.",
+		examples: []string{
+			"gitbak -on-error 'notify-send gitbak \"$GITBAK_ERROR\"'",
+		},
+	},
+	{
+		name:    "on-stop",
+		group:   "integration",
+		env:     "ON_STOP",
+		details: "Run a shell command when the session ends, after its final checkpoint. GITBAK_COMMIT and GITBAK_COUNTER describe the session's last checkpoint, if it made any, and GITBAK_ERROR is set if the session failed. gitbak waits for the hook, up to the -shutdown-timeout, before exiting.",
+		examples: []string{
+			"gitbak -on-stop './scripts/summarize-session.sh'",
+		},
+	},
+	{
+		name:     "version",
+		group:    "info",
+		details:  "Print the version, commit and build date, then exit.",
+		examples: []string{"gitbak -version"},
+	},
+	{
+		name:     "logo",
+		group:    "info",
+		details:  "Print the gitbak logo, then exit.",
+		examples: []string{"gitbak -logo"},
+	},
+	{
+		name:    "help",
+		group:   "info",
+		details: "Show help. Pass a group name, a flag name or 'all' for more detail.",
+		examples: []string{
+			"gitbak -help all",
+			"gitbak -help output",
+			"gitbak -help interval",
+		},
+	},
+}
+
+// envOnlyDocs describes environment variables that have no flag equivalent
+var envOnlyDocs = []struct {
+	name  string
+	usage string
+}{
+	{name: DisableEnvVar, usage: "Kill switch: refuse to start or stop at next check (true/false)"},
+}
+
+// helpTopics returns the names accepted by -help <topic>
+func helpTopics() []string {
+	topics := []string{"all"}
+	for _, g := range helpGroups {
+		if len(flagsInGroup(g.name)) > 0 {
+			topics = append(topics, g.name)
+		}
+	}
+	return topics
+}
+
+// flagsInGroup returns the documented flags of a help group
+func flagsInGroup(group string) []flagDoc {
+	var docs []flagDoc
+	for _, d := range flagDocs {
+		if d.group == group {
+			docs = append(docs, d)
+		}
+	}
+	return docs
+}
+
+// lookupFlagDoc returns the help metadata for a flag, if it is documented
+func lookupFlagDoc(name string) (flagDoc, bool) {
+	for _, d := range flagDocs {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return flagDoc{}, false
+}
+
+// printGroup prints a help group heading followed by its flags
+func printGroup(w io.Writer, fs *flag.FlagSet, g helpGroup) {
+	docs := flagsInGroup(g.name)
+	if len(docs) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "%s:\n", g.title)
+	for _, d := range docs {
+		printFlagIfExists(w, fs, d.name)
+	}
+	_, _ = fmt.Fprintf(w, "\n")
+}
+
+// printEnvironment prints the environment variables understood by gitbak
+func printEnvironment(w io.Writer, fs *flag.FlagSet) {
+	_, _ = fmt.Fprintf(w, "Environment variables:\n")
+	for _, d := range flagDocs {
+		if d.env == "" {
+			continue
+		}
+		usage := ""
+		if f := fs.Lookup(d.name); f != nil {
+			usage = f.Usage
+		}
+		_, _ = fmt.Fprintf(w, "  %-25s %s\n", d.env, usage)
+	}
+	for _, e := range envOnlyDocs {
+		_, _ = fmt.Fprintf(w, "  %-25s %s\n", e.name, e.usage)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nConfiguration files (keys are flag names, e.g. interval = 2):\n")
+	_, _ = fmt.Fprintf(w, "  %-25s %s\n", GlobalConfigFile(), "Personal defaults for every repository")
+	_, _ = fmt.Fprintf(w, "  %-25s %s\n", RepoConfigFile, "Shared defaults committed to the repository")
+}
+
+// PrintHelpTopic prints the help for a single topic: "all", a group name, or a flag name.
+// It returns an ErrInvalidFlag error if the topic is unknown.
+func (c *Config) PrintHelpTopic(fs *flag.FlagSet, w io.Writer, topic string) error {
+	topic = strings.TrimLeft(topic, "-")
+
+	if topic == "all" {
+		c.printUsageHeader(w)
+		for _, g := range helpGroups {
+			printGroup(w, fs, g)
+		}
+		printEnvironment(w, fs)
+		return nil
+	}
+
+	for _, g := range helpGroups {
+		if g.name == topic && len(flagsInGroup(g.name)) > 0 {
+			printGroup(w, fs, g)
+			return nil
+		}
+	}
+
+	f := fs.Lookup(topic)
+	if f == nil {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidFlag,
+			"unknown help topic %q (expected a flag name or one of: %s)", topic, strings.Join(helpTopics(), ", "))
+	}
+
+	_, _ = fmt.Fprintf(w, "-%s: %s\n\n", f.Name, f.Usage)
+
+	d, documented := lookupFlagDoc(f.Name)
+	if documented && d.details != "" {
+		_, _ = fmt.Fprintf(w, "%s\n\n", d.details)
+	}
+	if f.DefValue != "" {
+		_, _ = fmt.Fprintf(w, "Default:     %s\n", f.DefValue)
+	}
+	if documented && d.env != "" {
+		_, _ = fmt.Fprintf(w, "Environment: %s\n", d.env)
+	}
+	if documented && len(d.examples) > 0 {
+		_, _ = fmt.Fprintf(w, "\nExamples:\n")
+		for _, example := range d.examples {
+			_, _ = fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+
+	return nil
+}
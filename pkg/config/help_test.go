@@ -0,0 +1,154 @@
+package config
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+func TestHelpTopic(t *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		expectedTopic string
+		expectedHelp  bool
+	}{
+		"NoHelp": {
+			args:         []string{"-interval", "2"},
+			expectedHelp: false,
+		},
+		"PlainHelp": {
+			args:         []string{"-help"},
+			expectedHelp: true,
+		},
+		"ShortHelp": {
+			args:         []string{"-h"},
+			expectedHelp: true,
+		},
+		"HelpWithTopic": {
+			args:          []string{"-help", "output"},
+			expectedTopic: "output",
+			expectedHelp:  true,
+		},
+		"HelpWithEquals": {
+			args:          []string{"--help=interval"},
+			expectedTopic: "interval",
+			expectedHelp:  true,
+		},
+		"HelpFollowedByFlag": {
+			args:         []string{"-help", "-debug"},
+			expectedHelp: true,
+		},
+		"HelpAsValue": {
+			args:         []string{"-prefix", "help"},
+			expectedHelp: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			topic, ok := helpTopic(test.args)
+			if ok != test.expectedHelp {
+				t.Errorf("Expected help=%v, got %v", test.expectedHelp, ok)
+			}
+			if topic != test.expectedTopic {
+				t.Errorf("Expected topic %q, got %q", test.expectedTopic, topic)
+			}
+		})
+	}
+}
+
+func TestPrintHelpTopic(t *testing.T) {
+	tests := map[string]struct {
+		topic       string
+		contains    []string
+		notContains []string
+		expectError bool
+	}{
+		"All": {
+			topic:    "all",
+			contains: []string{"Core Options:", "Output Options:", "Safety Options:", "Information:", "Environment variables:"},
+		},
+		"Group": {
+			topic:       "output",
+			contains:    []string{"Output Options:", "-debug", "-log-file"},
+			notContains: []string{"Core Options:", "-interval"},
+		},
+		"Flag": {
+			topic:    "interval",
+			contains: []string{"-interval:", "Default:     5", "Environment: INTERVAL_MINUTES", "Examples:", "gitbak -interval 0.5"},
+		},
+		"FlagWithDash": {
+			topic:    "-max-retries",
+			contains: []string{"-max-retries:", "Environment: MAX_RETRIES"},
+		},
+		"Unknown": {
+			topic:       "bogus",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := New()
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			c.SetupFlags(fs)
+
+			var buf strings.Builder
+			err := c.PrintHelpTopic(fs, &buf, test.topic)
+
+			if test.expectError {
+				if !gitbakErrors.Is(err, gitbakErrors.ErrInvalidFlag) {
+					t.Errorf("Expected ErrInvalidFlag, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			for _, want := range test.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got: %s", want, output)
+				}
+			}
+			for _, unwanted := range test.notContains {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("Expected output not to contain %q, got: %s", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+// TestFlagDocsMatchFlags ensures help metadata stays in sync with the flag definitions
+func TestFlagDocsMatchFlags(t *testing.T) {
+	// Test-only flags are intentionally undocumented
+	t.Setenv("GITBAK_TESTING", "")
+
+	c := New()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.SetupFlags(fs)
+
+	groups := make(map[string]bool)
+	for _, g := range helpGroups {
+		groups[g.name] = true
+	}
+
+	for _, d := range flagDocs {
+		if fs.Lookup(d.name) == nil {
+			t.Errorf("Help metadata documents unknown flag -%s", d.name)
+		}
+		if !groups[d.group] {
+			t.Errorf("Flag -%s is in unknown help group %q", d.name, d.group)
+		}
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := lookupFlagDoc(f.Name); !ok {
+			t.Errorf("Flag -%s has no help metadata", f.Name)
+		}
+	})
+}
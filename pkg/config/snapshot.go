@@ -0,0 +1,44 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedFlags lists the flags whose values are left out of snapshots altogether,
+// since they can carry credentials in forms that cannot be recognized
+var redactedFlags = map[string]bool{
+	"on-start":  true,
+	"on-commit": true,
+	"on-error":  true,
+	"on-stop":   true,
+	"git-args":  true,
+	"check-cmd": true,
+}
+
+// urlUserinfo matches the user name and password in a URL, such as those of an OTLP endpoint
+var urlUserinfo = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// Snapshot returns every setting as a "name = value" line, sorted by name, for
+// diagnostics such as crash reports. Settings that can hold credentials are redacted.
+func (c *Config) Snapshot() string {
+	// The flags are bound to a copy, as SetupFlags records state for parsing
+	snapshot := *c
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	snapshot.SetupFlags(fs)
+
+	var b strings.Builder
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch {
+		case value != "" && redactedFlags[f.Name]:
+			value = "<redacted>"
+		default:
+			value = urlUserinfo.ReplaceAllString(value, "${1}<redacted>@")
+		}
+		fmt.Fprintf(&b, "%s = %s\n", f.Name, value)
+	})
+	return b.String()
+}
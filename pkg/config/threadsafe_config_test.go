@@ -72,9 +72,9 @@ func TestThreadSafeConfigConcurrency(t *testing.T) {
 			for j := 0; j < iterations; j++ {
 				cfg := ts.Config()
 
-				if cfg.IntervalMinutes <= 0 {
-					t.Errorf("Goroutine %d: Invalid IntervalMinutes value: %f",
-						id, cfg.IntervalMinutes)
+				if cfg.Interval <= 0 {
+					t.Errorf("Goroutine %d: Invalid Interval value: %s",
+						id, cfg.Interval)
 				}
 
 				// Small random delay to increase the chance of race conditions
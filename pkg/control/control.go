@@ -0,0 +1,106 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// UnixAddrPrefix marks a nudge or control address as the path of a Unix domain socket
+const UnixAddrPrefix = "unix:"
+
+// RequestTimeout bounds how long a client waits for the session to answer
+const RequestTimeout = 5 * time.Second
+
+// Actions lists the actions of the control endpoint, each served at an endpoint of the
+// same name. status reads the session's state, and the others ask the session to act.
+var Actions = []string{"status", "pause", "resume", "commit-now", "stop"}
+
+// Status is the JSON document served by the control endpoint
+type Status struct {
+	RepoPath        string     `json:"repo_path"`
+	PID             int        `json:"pid"`
+	Paused          bool       `json:"paused"`
+	Branch          string     `json:"branch,omitempty"`
+	Checkpoints     int        `json:"checkpoints"`
+	StartTime       *time.Time `json:"start_time,omitempty"`
+	LastCommitTime  *time.Time `json:"last_commit_time,omitempty"`
+	LastCheckTime   *time.Time `json:"last_check_time,omitempty"`
+	NextCheckTime   *time.Time `json:"next_check_time,omitempty"`
+	IntervalMinutes float64    `json:"interval_minutes,omitempty"`
+	Watch           bool       `json:"watch,omitempty"`
+}
+
+// IsLoopbackHost reports whether host, a name or an IP address without a port, can only
+// be reached from this machine: localhost, or a loopback address such as 127.0.0.1 or ::1
+func IsLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// NewClient returns an HTTP client and the URL of endpoint, such as /nudge, on the
+// nudge or control address addr
+func NewClient(addr, endpoint string) (*http.Client, string) {
+	path, isUnix := strings.CutPrefix(addr, UnixAddrPrefix)
+	if !isUnix {
+		return http.DefaultClient, "http://" + addr + endpoint
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	// The host is never resolved; the transport always dials the socket
+	return &http.Client{Transport: transport}, "http://unix" + endpoint
+}
+
+// Call performs action on the session serving the control endpoint at addr and returns
+// the status document it answers with. It fails with ErrNotRunning if nothing answers,
+// and with ErrInvalidConfiguration for an action the endpoint does not serve.
+func Call(ctx context.Context, addr, action string) ([]byte, error) {
+	if !slices.Contains(Actions, action) {
+		return nil, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"unknown control action %q: use one of %s", action, strings.Join(Actions, ", "))
+	}
+
+	method := http.MethodPost
+	if action == "status" {
+		method = http.MethodGet
+	}
+	client, url := NewClient(addr, "/"+action)
+
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, gitbakErrors.Wrapf(err, "invalid control address %s", addr)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, gitbakErrors.Wrapf(gitbakErrors.ErrNotRunning, "no session answered on %s: %v", addr, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, gitbakErrors.Wrapf(err, "failed to read the answer from %s", addr)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("control endpoint %s answered %s: %s", addr, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
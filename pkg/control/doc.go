@@ -0,0 +1,26 @@
+// Package control is the client side of the control endpoint a gitbak session serves
+// with -listen.
+//
+// The endpoint is a small JSON API over HTTP, on a TCP address such as 127.0.0.1:7373
+// or a Unix domain socket given as unix:<path>. This package holds what a client needs
+// to talk to it: the status document, the actions, and Call, which performs one. It
+// depends on nothing but the standard library and the errors package, so that clients
+// such as gitbakctl stay small and never load the monitoring code.
+//
+// # Core Components
+//
+//   - Status: The JSON document every endpoint answers with
+//   - Actions: The actions a session accepts, each an endpoint of the same name
+//   - Call: Performs an action and returns the session's answer
+//   - NewClient: An HTTP client for a TCP or Unix socket address
+//
+// # Usage
+//
+//	body, err := control.Call(ctx, "unix:/tmp/gitbak.sock", "pause")
+//	if errors.Is(err, gitbakErrors.ErrNotRunning) {
+//	    // No session is listening on the address
+//	}
+//
+//	var status control.Status
+//	_ = json.Unmarshal(body, &status)
+package control
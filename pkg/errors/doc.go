@@ -9,6 +9,7 @@
 //
 //   - Error wrapping with context
 //   - Standardized error formatting
+//   - Process exit codes and machine-readable reports for each failure type
 //
 // # Usage
 //
@@ -38,6 +39,27 @@
 //   - The underlying error message
 //   - Optional formatting with variable values
 //
+// # Failure Classes
+//
+// Each kind of failure has a sentinel to match with errors.Is, such as ErrIndexLocked or
+// ErrPushRejected. NewGitError classifies a git failure from the output git printed, and
+// a HookError names the hook that failed:
+//
+//	var hookErr *errors.HookError
+//	if errors.As(err, &hookErr) {
+//	    fmt.Printf("the %s hook failed\n", hookErr.Hook)
+//	}
+//
+// # Exit Codes
+//
+// ExitCode maps an error to the code gitbak exits with, such as ExitCodeLockHeld when
+// ErrAlreadyRunning is in its chain, and NewReport and JSON describe it for the
+// -errors-json flag:
+//
+//	if err := app.Run(ctx); err != nil {
+//	    os.Exit(errors.ExitCode(err))
+//	}
+//
 // # Compatibility
 //
 // The package is fully compatible with the standard library errors package
@@ -3,6 +3,8 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // Sentinel errors that can be used with errors.Is() for error type checking
@@ -24,6 +26,46 @@ var (
 
 	// ErrInvalidFlag indicates an invalid command-line flag was provided
 	ErrInvalidFlag = errors.New("invalid flag")
+
+	// ErrDisabled indicates checkpointing was disabled via the GITBAK_DISABLE kill switch
+	ErrDisabled = errors.New("gitbak is disabled via GITBAK_DISABLE")
+
+	// ErrOperationTimeout indicates a git operation was canceled for exceeding its time limit
+	ErrOperationTimeout = errors.New("git operation timed out")
+
+	// ErrNotRunning indicates no gitbak instance is running for the repository
+	ErrNotRunning = errors.New("gitbak is not running for this repository")
+
+	// ErrIntegrityViolation indicates the session history no longer matches its recorded integrity chain
+	ErrIntegrityViolation = errors.New("session history does not match its integrity chain")
+
+	// ErrGitNotFound indicates the git executable is not in PATH
+	ErrGitNotFound = errors.New("git is not found in PATH")
+
+	// ErrMaxRetriesExceeded indicates gitbak stopped after too many consecutive identical errors
+	ErrMaxRetriesExceeded = errors.New("maximum retries exceeded")
+
+	// ErrCrashed indicates gitbak recovered from a panic and stopped
+	ErrCrashed = errors.New("gitbak crashed")
+
+	// ErrIndexLocked indicates a git command failed because another git process holds the
+	// repository's index lock, or one that crashed left it behind
+	ErrIndexLocked = errors.New("the git index is locked")
+
+	// ErrDetachedHead indicates HEAD is detached where gitbak needs it on a branch
+	ErrDetachedHead = errors.New("HEAD is detached")
+
+	// ErrPushRejected indicates the remote refused pushed checkpoints, e.g. because the
+	// branch there has diverged, rather than the push failing to reach it
+	ErrPushRejected = errors.New("push rejected by the remote")
+
+	// ErrHookFailed indicates a hook failed: one of gitbak's -on-* commands, or a git
+	// commit hook rejecting a checkpoint
+	ErrHookFailed = errors.New("hook failed")
+
+	// ErrRepositoryMoved indicates the repository's directory disappeared during the session
+	// and could not be found again
+	ErrRepositoryMoved = errors.New("repository was moved or deleted")
 )
 
 // New creates a new error with the given message.
@@ -73,6 +115,10 @@ type GitError struct {
 	Args      []string
 	Err       error
 	Output    string
+
+	// Class is the sentinel naming the kind of failure, such as ErrIndexLocked or
+	// ErrPushRejected, when git's output identifies it, and nil otherwise
+	Class error
 }
 
 // Error implements the error interface with a detailed, user-friendly error message.
@@ -92,13 +138,71 @@ func (e *GitError) Unwrap() error {
 	return e.Err
 }
 
-// NewGitError creates a new GitError with the given parameters.
+// Is reports whether target is the sentinel classifying the failure, such as ErrIndexLocked,
+// so that errors.Is matches it as well as the errors in Err's chain.
+func (e *GitError) Is(target error) bool {
+	return e.Class != nil && e.Class == target
+}
+
+// NewGitError creates a new GitError with the given parameters, classifying the failure
+// from the output git printed.
 func NewGitError(operation string, args []string, err error, output string) *GitError {
 	return &GitError{
 		Operation: operation,
 		Args:      args,
 		Err:       err,
 		Output:    output,
+		Class:     classifyGitOutput(output),
+	}
+}
+
+// gitFailures maps text that git prints for a failure to the sentinel classifying it.
+// Apart from the index.lock path, the text is git's untranslated messages, so under other
+// locales such failures go unclassified.
+var gitFailures = []struct {
+	text  string
+	class error
+}{
+	{"index.lock", ErrIndexLocked},
+	{"not a git repository", ErrNotGitRepository},
+	{"[rejected]", ErrPushRejected},
+	{"[remote rejected]", ErrPushRejected},
+}
+
+// classifyGitOutput returns the sentinel for the failure git described in output, or nil
+func classifyGitOutput(output string) error {
+	for _, failure := range gitFailures {
+		if strings.Contains(output, failure.text) {
+			return failure.class
+		}
+	}
+	return nil
+}
+
+// CommandTimeoutError represents a command that was killed for running longer than its time limit.
+// It matches ErrOperationTimeout, so it is retried like any other timed-out operation.
+type CommandTimeoutError struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+// Error implements the error interface with the time limit the command exceeded.
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("%s killed after running for %s: %v", e.Command, e.Timeout, ErrOperationTimeout)
+}
+
+// Unwrap returns ErrOperationTimeout for use with errors.Is.
+func (e *CommandTimeoutError) Unwrap() error {
+	return ErrOperationTimeout
+}
+
+// NewCommandTimeoutError creates a new CommandTimeoutError with the given parameters.
+func NewCommandTimeoutError(command string, args []string, timeout time.Duration) *CommandTimeoutError {
+	return &CommandTimeoutError{
+		Command: command,
+		Args:    args,
+		Timeout: timeout,
 	}
 }
 
@@ -132,6 +236,36 @@ func NewLockError(lockFile string, pid int, err error) *LockError {
 	}
 }
 
+// HookError represents a hook that failed: one of gitbak's -on-* commands, named by its
+// event, or a git commit hook, such as pre-commit. It matches ErrHookFailed.
+type HookError struct {
+	Hook string
+	Err  error
+}
+
+// Error implements the error interface with the underlying error, which describes the command.
+func (e *HookError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error for use with errors.Is and errors.As.
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrHookFailed.
+func (e *HookError) Is(target error) bool {
+	return target == ErrHookFailed
+}
+
+// NewHookError creates a new HookError with the given parameters.
+func NewHookError(hook string, err error) *HookError {
+	return &HookError{
+		Hook: hook,
+		Err:  err,
+	}
+}
+
 // ConfigError represents an error in the application configuration.
 // It includes the parameter name, its value if available, and the underlying error.
 type ConfigError struct {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestWrap(t *testing.T) {
@@ -48,6 +49,65 @@ func TestGitError(t *testing.T) {
 	}
 }
 
+// TestGitErrorClass tests that git errors are classified by the output git printed
+func TestGitErrorClass(t *testing.T) {
+	tests := map[string]struct {
+		output string
+		class  error
+	}{
+		"IndexLocked": {
+			output: "fatal: Unable to create '/repo/.git/index.lock': File exists.",
+			class:  ErrIndexLocked,
+		},
+		"NotARepository": {
+			output: "fatal: not a git repository (or any of the parent directories): .git",
+			class:  ErrNotGitRepository,
+		},
+		"PushRejected": {
+			output: " ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs",
+			class:  ErrPushRejected,
+		},
+		"RemoteRejected": {
+			output: " ! [remote rejected] main -> main (pre-receive hook declined)",
+			class:  ErrPushRejected,
+		},
+		"Unclassified": {
+			output: "fatal: unable to access 'https://example.com/repo.git/'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Wrap(NewGitError("push", nil, ErrGitOperationFailed, test.output), "check failed")
+
+			if !Is(err, ErrGitOperationFailed) {
+				t.Error("Expected the underlying error to match as well")
+			}
+			for _, class := range []error{ErrIndexLocked, ErrNotGitRepository, ErrPushRejected} {
+				if Is(err, class) != (class == test.class) {
+					t.Errorf("Expected errors.Is(err, %v) to be %v", class, class == test.class)
+				}
+			}
+		})
+	}
+}
+
+// TestHookError tests that hook failures match ErrHookFailed and keep their message
+func TestHookError(t *testing.T) {
+	err := Wrap(NewHookError("commit", Wrapf(ErrGitOperationFailed, "%q failed", "make lint")), "hook")
+
+	if !Is(err, ErrHookFailed) || !Is(err, ErrGitOperationFailed) {
+		t.Errorf("Expected the error to match ErrHookFailed and the underlying error, got %v", err)
+	}
+	var hookErr *HookError
+	if !As(err, &hookErr) || hookErr.Hook != "commit" {
+		t.Errorf("Expected a HookError for the commit hook, got %v", err)
+	}
+	if expected := `hook: "make lint" failed: git operation failed`; err.Error() != expected {
+		t.Errorf("Expected message %q, got %q", expected, err.Error())
+	}
+}
+
 func TestLockError(t *testing.T) {
 	err := errors.New("file not found")
 	lockErr := NewLockError("/tmp/lock.file", 1234, err)
@@ -89,6 +149,25 @@ func TestConfigError(t *testing.T) {
 	}
 }
 
+func TestCommandTimeoutError(t *testing.T) {
+	timeoutErr := NewCommandTimeoutError("git", []string{"add", "-A"}, time.Minute)
+
+	expectedMsg := "git killed after running for 1m0s: git operation timed out"
+	if timeoutErr.Error() != expectedMsg {
+		t.Errorf("Expected message %q, got %q", expectedMsg, timeoutErr.Error())
+	}
+
+	gitErr := NewGitError("git", []string{"add", "-A"}, timeoutErr, "")
+	if !Is(gitErr, ErrOperationTimeout) {
+		t.Errorf("Expected CommandTimeoutError to match ErrOperationTimeout")
+	}
+
+	var te *CommandTimeoutError
+	if !As(gitErr, &te) || te.Timeout != time.Minute {
+		t.Errorf("Expected gitErr to match CommandTimeoutError type")
+	}
+}
+
 func TestErrorMatching(t *testing.T) {
 	gitErr := NewGitError("status", nil, ErrNotGitRepository, "")
 
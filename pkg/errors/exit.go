@@ -0,0 +1,146 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Process exit codes, letting wrapper scripts tell failures apart without parsing messages
+const (
+	// ExitCodeFailure is returned for any failure without a more specific code
+	ExitCodeFailure = 1
+
+	// ExitCodeInvalidConfiguration is returned for invalid flags, environment variables or
+	// configuration files
+	ExitCodeInvalidConfiguration = 2
+
+	// ExitCodeDisabled is returned when the GITBAK_DISABLE kill switch is engaged,
+	// allowing wrapper tooling to distinguish a deliberate no-op from a failure
+	ExitCodeDisabled = 3
+
+	// ExitCodeLockHeld is returned when another gitbak instance holds the repository lock
+	ExitCodeLockHeld = 4
+
+	// ExitCodeNotGitRepository is returned when the target path is not a git repository
+	ExitCodeNotGitRepository = 5
+
+	// ExitCodeMaxRetriesExceeded is returned when gitbak stops after too many consecutive
+	// identical errors
+	ExitCodeMaxRetriesExceeded = 6
+
+	// ExitCodeGitNotFound is returned when the git executable is not in PATH
+	ExitCodeGitNotFound = 7
+
+	// ExitCodeCrashed is returned when gitbak stops because of an internal error (a panic),
+	// after writing a crash report
+	ExitCodeCrashed = 8
+
+	// ExitCodeRepositoryMoved is returned when the repository's directory was moved or
+	// deleted during the session and gitbak could not follow it
+	ExitCodeRepositoryMoved = 9
+
+	// ExitCodeInterrupted is returned when repeated signals end gitbak before it could shut down
+	ExitCodeInterrupted = 130
+)
+
+// exitClass ties a failure type to its exit code and the code name reported by -errors-json
+type exitClass struct {
+	name     string
+	exitCode int
+	matches  func(err error) bool
+}
+
+// exitClasses lists the failure types in the order they are matched
+var exitClasses = []exitClass{
+	{name: "crashed", exitCode: ExitCodeCrashed, matches: isTarget(ErrCrashed)},
+	{name: "repository_moved", exitCode: ExitCodeRepositoryMoved, matches: isTarget(ErrRepositoryMoved)},
+	{name: "disabled", exitCode: ExitCodeDisabled, matches: isTarget(ErrDisabled)},
+	{name: "lock_held", exitCode: ExitCodeLockHeld, matches: isTarget(ErrAlreadyRunning)},
+	{name: "not_a_repository", exitCode: ExitCodeNotGitRepository, matches: isTarget(ErrNotGitRepository)},
+	{name: "git_not_found", exitCode: ExitCodeGitNotFound, matches: isTarget(ErrGitNotFound)},
+	{name: "max_retries_exceeded", exitCode: ExitCodeMaxRetriesExceeded, matches: isTarget(ErrMaxRetriesExceeded)},
+	{name: "invalid_configuration", exitCode: ExitCodeInvalidConfiguration, matches: func(err error) bool {
+		var configErr *ConfigError
+		return errors.As(err, &configErr) || errors.Is(err, ErrInvalidConfiguration) || errors.Is(err, ErrInvalidFlag)
+	}},
+
+	// Failures without an exit code of their own, named for -errors-json
+	{name: "timeout", exitCode: ExitCodeFailure, matches: isTarget(ErrOperationTimeout)},
+	{name: "index_locked", exitCode: ExitCodeFailure, matches: isTarget(ErrIndexLocked)},
+	{name: "push_rejected", exitCode: ExitCodeFailure, matches: isTarget(ErrPushRejected)},
+	{name: "hook_failed", exitCode: ExitCodeFailure, matches: isTarget(ErrHookFailed)},
+}
+
+// isTarget returns a matcher reporting whether target is in an error's chain
+func isTarget(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// classify returns the code name and exit code for err
+func classify(err error) (string, int) {
+	for _, class := range exitClasses {
+		if class.matches(err) {
+			return class.name, class.exitCode
+		}
+	}
+	return "error", ExitCodeFailure
+}
+
+// ExitCode returns the process exit code for err: 0 for nil, one of the specific
+// ExitCode constants for a recognized failure, and ExitCodeFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	_, code := classify(err)
+	return code
+}
+
+// Report is the machine-readable form of a final error, as printed by -errors-json.
+// Detail fields are only set when the error carries them.
+type Report struct {
+	// Code names the failure type, e.g. "lock_held"; "error" for unrecognized failures
+	Code     string `json:"code"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
+
+	Parameter string `json:"parameter,omitempty"`
+	LockFile  string `json:"lock_file,omitempty"`
+	PID       int    `json:"pid,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Hook      string `json:"hook,omitempty"`
+}
+
+// NewReport describes err for machine consumption
+func NewReport(err error) Report {
+	code, exitCode := classify(err)
+	report := Report{Code: code, ExitCode: exitCode, Message: err.Error()}
+
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		report.Parameter = configErr.Parameter
+	}
+	var lockErr *LockError
+	if errors.As(err, &lockErr) {
+		report.LockFile = lockErr.LockFile
+		report.PID = lockErr.PID
+	}
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		report.Operation = gitErr.Operation
+	}
+	var hookErr *HookError
+	if errors.As(err, &hookErr) {
+		report.Hook = hookErr.Hook
+	}
+	return report
+}
+
+// JSON encodes the Report for err as a single line of JSON
+func JSON(err error) []byte {
+	// A Report holds only strings and ints, so encoding cannot fail
+	data, _ := json.Marshal(NewReport(err))
+	return data
+}
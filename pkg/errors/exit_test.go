@@ -0,0 +1,133 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		expectedCode int
+		expectedName string
+	}{
+		"LockHeld": {
+			err:          NewLockError("/tmp/gitbak.lock", 4242, ErrAlreadyRunning),
+			expectedCode: ExitCodeLockHeld,
+			expectedName: "lock_held",
+		},
+		"NotGitRepository": {
+			err:          ErrNotGitRepository,
+			expectedCode: ExitCodeNotGitRepository,
+			expectedName: "not_a_repository",
+		},
+		"ConfigError": {
+			err:          NewConfigError("interval", -1, New("must be positive")),
+			expectedCode: ExitCodeInvalidConfiguration,
+			expectedName: "invalid_configuration",
+		},
+		"InvalidFlag": {
+			err:          Wrap(ErrInvalidFlag, "flag provided but not defined: -nope"),
+			expectedCode: ExitCodeInvalidConfiguration,
+			expectedName: "invalid_configuration",
+		},
+		"MaxRetriesExceeded": {
+			err:          fmt.Errorf("%w (3) with error: boom: %w", ErrMaxRetriesExceeded, ErrGitOperationFailed),
+			expectedCode: ExitCodeMaxRetriesExceeded,
+			expectedName: "max_retries_exceeded",
+		},
+		"GitNotFound": {
+			err:          ErrGitNotFound,
+			expectedCode: ExitCodeGitNotFound,
+			expectedName: "git_not_found",
+		},
+		"Disabled": {
+			err:          ErrDisabled,
+			expectedCode: ExitCodeDisabled,
+			expectedName: "disabled",
+		},
+		"Crashed": {
+			err:          fmt.Errorf("%w: runtime error: index out of range", ErrCrashed),
+			expectedCode: ExitCodeCrashed,
+			expectedName: "crashed",
+		},
+		"RepositoryMoved": {
+			err:          fmt.Errorf("/work/project no longer exists: %w", ErrRepositoryMoved),
+			expectedCode: ExitCodeRepositoryMoved,
+			expectedName: "repository_moved",
+		},
+		"IndexLocked": {
+			err:          NewGitError("commit", nil, ErrGitOperationFailed, "fatal: Unable to create '/repo/.git/index.lock': File exists."),
+			expectedCode: ExitCodeFailure,
+			expectedName: "index_locked",
+		},
+		"HookFailed": {
+			err:          NewHookError("pre-commit", NewGitError("commit", nil, ErrGitOperationFailed, "")),
+			expectedCode: ExitCodeFailure,
+			expectedName: "hook_failed",
+		},
+		"Timeout": {
+			err:          NewGitError("status", nil, NewCommandTimeoutError("git", nil, time.Minute), ""),
+			expectedCode: ExitCodeFailure,
+			expectedName: "timeout",
+		},
+		"Other": {
+			err:          NewGitError("status", nil, ErrGitOperationFailed, ""),
+			expectedCode: ExitCodeFailure,
+			expectedName: "error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if code := ExitCode(test.err); code != test.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", test.expectedCode, code)
+			}
+			if report := NewReport(test.err); report.Code != test.expectedName {
+				t.Errorf("Expected code %q, got %q", test.expectedName, report.Code)
+			}
+		})
+	}
+
+	if code := ExitCode(nil); code != 0 {
+		t.Errorf("Expected exit code 0 for nil, got %d", code)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	err := Wrap(NewLockError("/tmp/gitbak.lock", 4242, ErrAlreadyRunning), "failed to start")
+
+	var report map[string]any
+	if jsonErr := json.Unmarshal(JSON(err), &report); jsonErr != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", jsonErr)
+	}
+
+	expected := map[string]any{
+		"code":      "lock_held",
+		"exit_code": float64(ExitCodeLockHeld),
+		"message":   err.Error(),
+		"lock_file": "/tmp/gitbak.lock",
+		"pid":       float64(4242),
+	}
+	if len(report) != len(expected) {
+		t.Errorf("Expected fields %v, got %v", expected, report)
+	}
+	for key, value := range expected {
+		if report[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, report[key])
+		}
+	}
+
+	configReport := NewReport(NewConfigError("interval", -1, errors.New("must be positive")))
+	if configReport.Parameter != "interval" {
+		t.Errorf("Expected parameter interval, got %q", configReport.Parameter)
+	}
+
+	hookReport := NewReport(NewHookError("stop", errors.New(`"notify-send done" failed`)))
+	if hookReport.Hook != "stop" {
+		t.Errorf("Expected hook stop, got %q", hookReport.Hook)
+	}
+}
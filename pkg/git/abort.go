@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// AbortSession undoes a gitbak session as if it never happened: it checks out the
+// branch that was active when the session started and deletes the session branch
+// along with all of its checkpoint commits and the notes recorded on them. If gitbak
+// committed the changes left uncommitted when the session started, that commit is
+// undone too, leaving the changes uncommitted again, as long as it is still the tip
+// of the original branch. A session run with -refs-only has its checkpoint refs
+// deleted instead.
+//
+// Only sessions that created their own branch can be aborted this way. Sessions run
+// with -no-branch or -continue committed onto an existing branch, so deleting it
+// would destroy work that predates the session.
+func (r *Repository) AbortSession(ctx context.Context, state *session.State) error {
+	if state.Stash {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' stored its snapshots in the stash and changed no branch; drop them with git stash drop if unwanted", state.Branch)
+	}
+	if state.Refs != "" {
+		return r.abortRefsSession(ctx, state)
+	}
+	if state.Observe {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' only observed changes and made no checkpoints, so there is nothing to abort", state.Branch)
+	}
+	if !state.CreatedBranch {
+		hint := "use git reset to drop the checkpoint commits"
+		if state.StartCommit != "" {
+			hint = fmt.Sprintf("to drop the checkpoint commits run: git reset --soft %s", state.StartCommit)
+		}
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' did not create its own branch, so it cannot be aborted automatically; %s", state.Branch, hint)
+	}
+
+	if state.OriginalBranch == "" || state.OriginalBranch == state.Branch {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session state does not record an original branch to return to")
+	}
+
+	current, err := r.CurrentBranch(ctx)
+	if err != nil {
+		return gitbakErrors.NewGitError("branch", []string{"--show-current"},
+			gitbakErrors.Wrap(err, "failed to determine current branch"), "")
+	}
+
+	if current != state.OriginalBranch {
+		if err := r.run(ctx, "checkout", state.OriginalBranch); err != nil {
+			return gitbakErrors.NewGitError("checkout", []string{state.OriginalBranch},
+				gitbakErrors.Wrap(err, "failed to check out original branch"), "")
+		}
+	}
+
+	// The notes are found through the checkpoints, so they go before the branch does
+	args := []string{"rev-list", state.Branch, "--not", state.OriginalBranch}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return gitbakErrors.NewGitError("rev-list", args[1:], gitbakErrors.Wrap(err, "failed to list checkpoint commits"), "")
+	}
+	if err := r.removeCheckpointNotes(ctx, strings.Fields(out)); err != nil {
+		return err
+	}
+
+	if err := r.run(ctx, "branch", "-D", state.Branch); err != nil {
+		return gitbakErrors.NewGitError("branch", []string{"-D", state.Branch},
+			gitbakErrors.Wrap(err, "failed to delete session branch"), "")
+	}
+
+	return r.undoStartupCommit(ctx, state)
+}
+
+// abortRefsSession deletes the checkpoint refs of a -refs-only session, and the notes
+// recorded on them. Refs in its namespace that gitbak did not write are left alone.
+func (r *Repository) abortRefsSession(ctx context.Context, state *session.State) error {
+	refs, err := r.checkpointRefs(ctx, state.Refs)
+	if err != nil {
+		return err
+	}
+
+	commits := make([]string, 0, len(refs))
+	var deletes strings.Builder
+	for _, ref := range refs {
+		commits = append(commits, ref.Commit)
+		// The old value makes update-ref leave alone a ref moved since it was listed
+		_, _ = fmt.Fprintf(&deletes, "delete %s %s\n", ref.Name, ref.Commit)
+	}
+	if err := r.removeCheckpointNotes(ctx, commits); err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if err := r.runWithInput(ctx, deletes.String(), "update-ref", "--stdin"); err != nil {
+		return gitbakErrors.NewGitError("update-ref", []string{"--stdin"},
+			gitbakErrors.Wrap(err, "failed to delete checkpoint refs"), "")
+	}
+	return nil
+}
+
+// removeCheckpointNotes removes the notes recorded on commits under NotesRef, and the
+// notes ref itself once no notes are left on it
+func (r *Repository) removeCheckpointNotes(ctx context.Context, commits []string) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", NotesRef); err != nil {
+		// No notes were ever recorded
+		return nil
+	}
+
+	args := []string{"notes", "--ref=" + NotesRef, "remove", "--ignore-missing", "--stdin"}
+	if err := r.runWithInput(ctx, strings.Join(commits, "\n")+"\n", args...); err != nil {
+		return gitbakErrors.NewGitError("notes", args[1:], gitbakErrors.Wrap(err, "failed to remove checkpoint notes"), "")
+	}
+
+	remaining, err := r.output(ctx, "notes", "--ref="+NotesRef, "list")
+	if err != nil || remaining != "" {
+		return nil
+	}
+	if err := r.run(ctx, "update-ref", "-d", NotesRef); err != nil {
+		return gitbakErrors.NewGitError("update-ref", []string{"-d", NotesRef},
+			gitbakErrors.Wrap(err, "failed to delete the empty notes ref"), "")
+	}
+	return nil
+}
+
+// undoStartupCommit undoes the commit gitbak made of the changes left uncommitted when
+// the session started, keeping them in the working tree, unless the original branch
+// has moved on since
+func (r *Repository) undoStartupCommit(ctx context.Context, state *session.State) error {
+	if state.StartupCommit == "" {
+		return nil
+	}
+	head, err := r.output(ctx, "rev-parse", "HEAD")
+	if err != nil || head != state.StartupCommit {
+		return nil
+	}
+
+	args := []string{"reset", "--quiet", state.StartupCommit + "^"}
+	if err := r.run(ctx, args...); err != nil {
+		return gitbakErrors.NewGitError("reset", args[1:],
+			gitbakErrors.Wrap(err, "failed to undo the commit made when the session started"), "")
+	}
+	return nil
+}
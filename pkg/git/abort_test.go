@@ -0,0 +1,351 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestSessionStateIsSaved tests that a session records enough state to be aborted later
+func TestSessionStateIsSaved(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	startCommit, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+
+	originalBranch, err := exec.Command("git", "-C", repoPath, "branch", "--show-current").Output()
+	if err != nil {
+		t.Fatalf("Failed to get current branch: %v", err)
+	}
+
+	log := logger.New(false, "", false)
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-state-test",
+		CommitPrefix:   "[gitbak]",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      stateFile,
+	}, log)
+
+	if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("work"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := gb.RunSingleIteration(context.Background()); err != nil {
+		t.Fatalf("RunSingleIteration failed: %v", err)
+	}
+
+	state, err := session.Load(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to load session state: %v", err)
+	}
+
+	if state.Branch != "gitbak-state-test" {
+		t.Errorf("Expected branch 'gitbak-state-test', got %q", state.Branch)
+	}
+	if state.OriginalBranch != strings.TrimSpace(string(originalBranch)) {
+		t.Errorf("Expected original branch %q, got %q", strings.TrimSpace(string(originalBranch)), state.OriginalBranch)
+	}
+	if !state.CreatedBranch {
+		t.Error("Expected CreatedBranch to be true")
+	}
+	if state.StartCommit != strings.TrimSpace(string(startCommit)) {
+		t.Errorf("Expected start commit %q, got %q", strings.TrimSpace(string(startCommit)), state.StartCommit)
+	}
+	if state.CommitsCount != 1 {
+		t.Errorf("Expected 1 commit recorded, got %d", state.CommitsCount)
+	}
+	if state.LastCommitTime.IsZero() {
+		t.Error("Expected LastCommitTime to be set after a commit")
+	}
+}
+
+// TestStartupCommitIsSaved tests that a session records the commit it made of the changes
+// left uncommitted at startup, so that aborting it can undo the commit
+func TestStartupCommitIsSaved(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	originalBranch := gitOutput(t, repoPath, "branch", "--show-current")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:     repoPath,
+		Interval:     time.Minute,
+		BranchName:   "gitbak-startup-state-test",
+		CommitPrefix: "[gitbak]",
+		CreateBranch: true,
+		StateFile:    stateFile,
+	}, logger.New(false, "", false))
+	gb.interactor = NewMockInteractor(true)
+
+	if err := os.WriteFile(filepath.Join(repoPath, "draft.txt"), []byte("draft"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := gb.RunSingleIteration(context.Background()); err != nil {
+		t.Fatalf("RunSingleIteration failed: %v", err)
+	}
+
+	state, err := session.Load(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to load session state: %v", err)
+	}
+	if tip := gitOutput(t, repoPath, "rev-parse", originalBranch); state.StartupCommit != tip {
+		t.Errorf("Expected the startup commit %s to be recorded, got %q", tip, state.StartupCommit)
+	}
+}
+
+// TestAbortSession tests discarding a session in a real repository
+func TestAbortSession(t *testing.T) {
+	tests := map[string]struct {
+		createdBranch bool
+		useOriginal   bool
+		errorContains string
+	}{
+		"SessionBranchIsDeleted": {
+			createdBranch: true,
+			useOriginal:   true,
+		},
+		"NoBranchSessionIsRefused": {
+			createdBranch: false,
+			useOriginal:   true,
+			errorContains: "git reset --soft",
+		},
+		"MissingOriginalBranchIsRefused": {
+			createdBranch: true,
+			useOriginal:   false,
+			errorContains: "original branch",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath := setupTestRepo(t)
+
+			out, err := exec.Command("git", "-C", repoPath, "branch", "--show-current").Output()
+			if err != nil {
+				t.Fatalf("Failed to get current branch: %v", err)
+			}
+			originalBranch := strings.TrimSpace(string(out))
+
+			head, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+			if err != nil {
+				t.Fatalf("Failed to get HEAD: %v", err)
+			}
+
+			if err := exec.Command("git", "-C", repoPath, "checkout", "-b", "gitbak-abort-test").Run(); err != nil {
+				t.Fatalf("Failed to create session branch: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("work"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			if err := exec.Command("git", "-C", repoPath, "add", ".").Run(); err != nil {
+				t.Fatalf("Failed to stage file: %v", err)
+			}
+			if err := exec.Command("git", "-C", repoPath, "commit", "-m", "[gitbak] Automatic checkpoint #1").Run(); err != nil {
+				t.Fatalf("Failed to commit: %v", err)
+			}
+
+			state := &session.State{
+				RepoPath:      repoPath,
+				Branch:        "gitbak-abort-test",
+				CreatedBranch: test.createdBranch,
+				StartCommit:   strings.TrimSpace(string(head)),
+			}
+			if test.useOriginal {
+				state.OriginalBranch = originalBranch
+			}
+
+			repo := NewRepository(repoPath, nil)
+			err = repo.AbortSession(context.Background(), state)
+
+			if test.errorContains != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, got nil", test.errorContains)
+				}
+				if !strings.Contains(err.Error(), test.errorContains) {
+					t.Errorf("Expected error containing %q, got %q", test.errorContains, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("AbortSession failed: %v", err)
+			}
+
+			current, err := repo.CurrentBranch(context.Background())
+			if err != nil {
+				t.Fatalf("Failed to get current branch: %v", err)
+			}
+			if current != originalBranch {
+				t.Errorf("Expected to be back on %q, got %q", originalBranch, current)
+			}
+
+			if err := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "gitbak-abort-test").Run(); err == nil {
+				t.Error("Expected session branch to be deleted")
+			}
+
+			if _, err := os.Stat(filepath.Join(repoPath, "work.txt")); !os.IsNotExist(err) {
+				t.Error("Expected session work to be discarded from the working tree")
+			}
+		})
+	}
+}
+
+// commitFile writes a file and commits it with the given message, returning the commit
+func commitFile(t *testing.T, repoPath, name, message string) string {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(message), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	gitOutput(t, repoPath, "add", name)
+	gitOutput(t, repoPath, "commit", "-m", message)
+	return gitOutput(t, repoPath, "rev-parse", "HEAD")
+}
+
+// TestAbortSessionRemovesNotes tests that aborting a session removes the notes recorded on
+// its checkpoints, and the notes ref too once it has no notes left
+func TestAbortSessionRemovesNotes(t *testing.T) {
+	tests := map[string]struct {
+		foreignNote bool
+		expectRef   bool
+	}{
+		"OnlySessionNotes": {},
+		"OtherNotesKept":   {foreignNote: true, expectRef: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath := setupTestRepo(t)
+			originalBranch := gitOutput(t, repoPath, "branch", "--show-current")
+			start := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			if test.foreignNote {
+				gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "add", "-m", "gitbak-session: earlier", start)
+			}
+
+			gitOutput(t, repoPath, "checkout", "-q", "-b", "gitbak-notes-test")
+			checkpoint := commitFile(t, repoPath, "work.txt", "[gitbak] Automatic checkpoint #1")
+			gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "add", "-m", "gitbak-session: abc", checkpoint)
+
+			state := &session.State{
+				RepoPath:       repoPath,
+				Branch:         "gitbak-notes-test",
+				OriginalBranch: originalBranch,
+				CreatedBranch:  true,
+				StartCommit:    start,
+			}
+			if err := NewRepository(repoPath, nil).AbortSession(context.Background(), state); err != nil {
+				t.Fatalf("AbortSession failed: %v", err)
+			}
+
+			if err := exec.Command("git", "-C", repoPath, "notes", "--ref="+NotesRef, "show", checkpoint).Run(); err == nil {
+				t.Error("Expected the checkpoint's note to be removed")
+			}
+			hasRef := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", NotesRef).Run() == nil
+			if hasRef != test.expectRef {
+				t.Errorf("Expected the notes ref to remain: %v, got %v", test.expectRef, hasRef)
+			}
+			if test.foreignNote {
+				if note := gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "show", start); note != "gitbak-session: earlier" {
+					t.Errorf("Expected the note of an earlier commit to be kept, got %q", note)
+				}
+			}
+		})
+	}
+}
+
+// TestAbortSessionUndoesStartupCommit tests that aborting a session undoes the commit made
+// of the changes left uncommitted at startup, unless the original branch has moved on
+func TestAbortSessionUndoesStartupCommit(t *testing.T) {
+	tests := map[string]struct {
+		movedOn      bool
+		expectUndone bool
+	}{
+		"Undone":        {expectUndone: true},
+		"BranchMovedOn": {movedOn: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath := setupTestRepo(t)
+			originalBranch := gitOutput(t, repoPath, "branch", "--show-current")
+			start := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			startup := commitFile(t, repoPath, "draft.txt", "Manual commit before starting gitbak session")
+
+			gitOutput(t, repoPath, "checkout", "-q", "-b", "gitbak-startup-test")
+			commitFile(t, repoPath, "work.txt", "[gitbak] Automatic checkpoint #1")
+			if test.movedOn {
+				gitOutput(t, repoPath, "checkout", "-q", originalBranch)
+				commitFile(t, repoPath, "later.txt", "Later work")
+				gitOutput(t, repoPath, "checkout", "-q", "gitbak-startup-test")
+			}
+
+			state := &session.State{
+				RepoPath:       repoPath,
+				Branch:         "gitbak-startup-test",
+				OriginalBranch: originalBranch,
+				CreatedBranch:  true,
+				StartCommit:    start,
+				StartupCommit:  startup,
+			}
+			if err := NewRepository(repoPath, nil).AbortSession(context.Background(), state); err != nil {
+				t.Fatalf("AbortSession failed: %v", err)
+			}
+
+			head := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			if undone := head == start; undone != test.expectUndone {
+				t.Errorf("Expected the startup commit to be undone: %v, got HEAD %s", test.expectUndone, head)
+			}
+			if content, err := os.ReadFile(filepath.Join(repoPath, "draft.txt")); err != nil || string(content) != "Manual commit before starting gitbak session" {
+				t.Errorf("Expected the changes of the startup commit to stay in the working tree, got %q (%v)", content, err)
+			}
+			if test.expectUndone {
+				if status := gitOutput(t, repoPath, "status", "--porcelain", "--", "draft.txt"); status != "?? draft.txt" {
+					t.Errorf("Expected draft.txt to be uncommitted again, got %q", status)
+				}
+			}
+		})
+	}
+}
+
+// TestAbortRefsSession tests that aborting a -refs-only session deletes its checkpoint refs
+// and their notes, leaving alone refs in its namespace that gitbak did not write
+func TestAbortRefsSession(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	start := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	namespace := RefsPrefix + "gitbak-refs-test"
+
+	gitOutput(t, repoPath, "update-ref", namespace+"/1", start)
+	gitOutput(t, repoPath, "update-ref", namespace+"/2", start)
+	gitOutput(t, repoPath, "update-ref", namespace+"/keep", start)
+	gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "add", "-m", "gitbak-session: abc", start)
+
+	state := &session.State{
+		RepoPath:     repoPath,
+		Branch:       "gitbak-refs-test",
+		Refs:         namespace,
+		StartCommit:  start,
+		CommitsCount: 2,
+	}
+	if err := NewRepository(repoPath, nil).AbortSession(context.Background(), state); err != nil {
+		t.Fatalf("AbortSession failed: %v", err)
+	}
+
+	if refs := gitOutput(t, repoPath, "for-each-ref", "--format=%(refname)", namespace); refs != namespace+"/keep" {
+		t.Errorf("Expected only %s/keep to remain, got %q", namespace, refs)
+	}
+	if err := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", NotesRef).Run(); err == nil {
+		t.Error("Expected the checkpoints' notes to be removed")
+	}
+}
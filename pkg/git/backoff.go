@@ -0,0 +1,55 @@
+package git
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// retryJitter is the fraction by which a retry delay is randomly lengthened or shortened,
+// so that sessions hitting the same shared problem don't retry in lockstep
+const retryJitter = 0.2
+
+// maxRetryDelay caps retry delays when RetryBackoffMax is not set
+const maxRetryDelay = time.Hour
+
+// retryDelay returns how long to wait before retrying after consecutive identical errors:
+// RetryBackoff doubled for every repeat, capped at RetryBackoffMax, with jitter applied.
+// It returns 0 if backoff is disabled.
+func (g *Gitbak) retryDelay(consecutive int) time.Duration {
+	base := g.config.RetryBackoff
+	if base <= 0 || consecutive < 1 {
+		return 0
+	}
+
+	limit := g.config.RetryBackoffMax
+	if limit <= 0 {
+		limit = maxRetryDelay
+	}
+
+	delay := base
+	for i := 1; i < consecutive && delay < limit; i++ {
+		delay *= 2
+	}
+	delay = min(delay, limit)
+
+	random := rand.Float64
+	if g.random != nil {
+		random = g.random
+	}
+	factor := 1 - retryJitter + 2*retryJitter*random()
+	return time.Duration(float64(delay) * factor)
+}
+
+// backingOff reports whether a failed check was too recent for another attempt.
+// Scheduled, nudged and watch-triggered checks wait until the backoff has passed;
+// requested checks do not.
+func (g *Gitbak) backingOff() bool {
+	if g.retryAt.IsZero() {
+		return false
+	}
+	if time.Now().Before(g.retryAt) {
+		return true
+	}
+	g.retryAt = time.Time{}
+	return false
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config      GitbakConfig
+		consecutive int
+		random      float64
+		expected    time.Duration
+	}{
+		"Disabled": {
+			config:      GitbakConfig{},
+			consecutive: 2,
+			random:      0.5,
+			expected:    0,
+		},
+		"FirstError": {
+			config:      GitbakConfig{RetryBackoff: 5 * time.Second, RetryBackoffMax: time.Minute},
+			consecutive: 1,
+			random:      0.5,
+			expected:    5 * time.Second,
+		},
+		"Doubles": {
+			config:      GitbakConfig{RetryBackoff: 5 * time.Second, RetryBackoffMax: time.Minute},
+			consecutive: 3,
+			random:      0.5,
+			expected:    20 * time.Second,
+		},
+		"Capped": {
+			config:      GitbakConfig{RetryBackoff: 5 * time.Second, RetryBackoffMax: time.Minute},
+			consecutive: 10,
+			random:      0.5,
+			expected:    time.Minute,
+		},
+		"UncappedStopsAtAnHour": {
+			config:      GitbakConfig{RetryBackoff: 5 * time.Second},
+			consecutive: 500,
+			random:      0.5,
+			expected:    maxRetryDelay,
+		},
+		"JitterShortens": {
+			config:      GitbakConfig{RetryBackoff: 10 * time.Second, RetryBackoffMax: time.Minute},
+			consecutive: 1,
+			random:      0,
+			expected:    8 * time.Second,
+		},
+		"JitterLengthens": {
+			config:      GitbakConfig{RetryBackoff: 10 * time.Second, RetryBackoffMax: time.Minute},
+			consecutive: 1,
+			random:      0.75,
+			expected:    11 * time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := &Gitbak{config: test.config, random: func() float64 { return test.random }}
+			if delay := g.retryDelay(test.consecutive); delay != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, delay)
+			}
+		})
+	}
+}
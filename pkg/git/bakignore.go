@@ -0,0 +1,132 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// BakignoreFile is the per-repository file, in the repository root and in .gitignore
+// syntax, listing paths left out of checkpoints even when git tracks them. It lets
+// checkpoints keep the semantics of `git add .` while skipping noisy directories.
+const BakignoreFile = ".gitbakignore"
+
+// hasBakignore reports whether the repository has a BakignoreFile
+func (g *Gitbak) hasBakignore() bool {
+	_, err := os.Stat(filepath.Join(g.config.RepoPath, BakignoreFile))
+	return err == nil
+}
+
+// bakignoredPaths lists the tracked and untracked paths matched by BakignoreFile.
+// Untracked directories matched as a whole are listed once, with a trailing slash.
+// It returns nothing when the repository has no BakignoreFile.
+func (g *Gitbak) bakignoredPaths(ctx context.Context) ([]string, error) {
+	if g.config.Backend == BackendGoGit || !g.hasBakignore() {
+		return nil, nil
+	}
+
+	excludeFrom := "--exclude-from=" + filepath.Join(g.config.RepoPath, BakignoreFile)
+	out, err := g.runGitCommandWithOutput(ctx, "ls-files", "-z", "--cached", "--others", "--ignored", "--directory", excludeFrom)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{"--ignored", excludeFrom},
+			gitbakErrors.Wrapf(err, "failed to apply %s", BakignoreFile), "")
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, path := range strings.Split(out, "\x00") {
+		// A tracked file with unmerged changes is listed once per stage
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// changePathspec returns the pathspec covering every change a checkpoint takes in:
+// the whole working tree or the Paths it is scoped to, less the paths matched by
+// BakignoreFile, any submodules left out by SubmodulesIgnore, any new files left out by
+// UntrackedPolicy, any files over MaxFileSizeMB and any files that look like they hold
+// secrets under SecretsSkip.
+func (g *Gitbak) changePathspec(ctx context.Context) ([]string, error) {
+	paths, err := g.excludedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := g.secretPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, secrets...)
+	if len(paths) == 0 && len(g.config.Paths) == 0 {
+		return []string{"."}, nil
+	}
+
+	pathspec := append([]string{"--"}, g.scopePathspec()...)
+	for _, path := range paths {
+		pathspec = append(pathspec, ":(exclude,literal)"+strings.TrimSuffix(path, "/"))
+	}
+	return pathspec, nil
+}
+
+// excludedPaths lists the paths left out of checkpoints before any secret scanning: those
+// matched by BakignoreFile, submodules left out by SubmodulesIgnore, new files left out by
+// UntrackedPolicy and files over MaxFileSizeMB
+func (g *Gitbak) excludedPaths(ctx context.Context) ([]string, error) {
+	paths, err := g.bakignoredPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	submodules, err := g.ignoredSubmodulePaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, submodules...)
+	// New files left out are not worth a warning about their size
+	untracked, err := g.untrackedPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, untracked...)
+	oversized, err := g.oversizedPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	return append(paths, oversized...), nil
+}
+
+// stageArgs returns the arguments of a git add staging the changes a checkpoint takes in
+func (g *Gitbak) stageArgs(ctx context.Context, flags ...string) ([]string, error) {
+	pathspec, err := g.changePathspec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) > 0 && len(pathspec) == 1 {
+		// Without exclusions or Paths the flags alone already cover the whole tree
+		return append([]string{"add"}, flags...), nil
+	}
+	return append(append([]string{"add"}, flags...), pathspec...), nil
+}
+
+// bakignoreRule returns the last rule of BakignoreFile matching path, if any
+func (r *Repository) bakignoreRule(path string, isDir bool) (*IgnoreRule, error) {
+	source, err := r.readIgnoreSource(BakignoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	var match *IgnoreRule
+	for i, rule := range source.Rules {
+		if gitignore.ParsePattern(rule.Pattern, nil).Match(parts, isDir) != gitignore.NoMatch {
+			match = &source.Rules[i]
+		}
+	}
+	return match, nil
+}
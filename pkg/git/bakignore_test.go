@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestBakignore tests that paths in .gitbakignore are left out of checkpoints, tracked or not
+func TestBakignore(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	for name, content := range map[string]string{
+		BakignoreFile:     "tmp/\n*.out\n",
+		"tmp/tracked.txt": "v1\n",
+	} {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	gitOutput(t, repoPath, "add", "-A")
+	gitOutput(t, repoPath, "commit", "-m", "Track tmp/tracked.txt")
+	gitOutput(t, repoPath, "checkout", "-b", "gitbak-bakignore")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-bakignore",
+		CommitPrefix:   "[gitbak-bakignore] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+	}, logger.New(false, "", false))
+	ctx := context.Background()
+
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// Changes to excluded paths alone are not worth a checkpoint
+	write("tmp/tracked.txt", "v2\n")
+	write("tmp/cache/blob", "x")
+	write("build.out", "x")
+	hasChanges, err := gb.hasUncommittedChanges(ctx)
+	if err != nil {
+		t.Fatalf("hasUncommittedChanges failed: %v", err)
+	}
+	if hasChanges {
+		t.Error("Expected changes to excluded paths to be ignored")
+	}
+
+	write("initial.txt", "changed\n")
+	if _, err := gb.createCommit(ctx, 1); err != nil {
+		t.Fatalf("createCommit failed: %v", err)
+	}
+
+	if files := gitOutput(t, repoPath, "show", "--name-only", "--format=", "HEAD"); files != "initial.txt" {
+		t.Errorf("Expected only initial.txt in the checkpoint, got %q", files)
+	}
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status != "M tmp/tracked.txt\n?? build.out\n?? tmp/cache/" {
+		t.Errorf("Expected the excluded changes to be left in the working tree, got %q", status)
+	}
+}
+
+func TestChangePathspec(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	gb := setupTestGitbak(GitbakConfig{RepoPath: repoPath, Interval: time.Minute, BranchName: "gitbak-pathspec", CommitPrefix: "[gitbak]"}, logger.New(false, "", false))
+	ctx := context.Background()
+
+	pathspec, err := gb.changePathspec(ctx)
+	if err != nil {
+		t.Fatalf("changePathspec failed: %v", err)
+	}
+	if len(pathspec) != 1 || pathspec[0] != "." {
+		t.Errorf("Expected the whole tree without a %s, got %q", BakignoreFile, pathspec)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, BakignoreFile), []byte("initial.txt\n"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", BakignoreFile, err)
+	}
+	pathspec, err = gb.changePathspec(ctx)
+	if err != nil {
+		t.Fatalf("changePathspec failed: %v", err)
+	}
+	expected := []string{"--", ".", ":(exclude,literal)initial.txt"}
+	if len(pathspec) != len(expected) || pathspec[2] != expected[2] {
+		t.Errorf("Expected %q, got %q", expected, pathspec)
+	}
+}
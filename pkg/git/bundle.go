@@ -0,0 +1,53 @@
+package git
+
+import (
+	"context"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// ExportBundle writes the session branch to a git bundle at path, which can be fetched
+// from like a remote, and returns the number of commits the bundle holds. With sessionOnly
+// only the commits made since the session started are bundled, so the repository the
+// bundle is fetched into must already have the commit the session started from.
+func (r *Repository) ExportBundle(ctx context.Context, state *session.State, path string, sessionOnly bool) (int, error) {
+	if state.Stash || state.Observe {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' made no checkpoint commits to bundle", state.Branch)
+	}
+	if state.Refs != "" {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' wrote its checkpoints to %s; turn them into a branch with gitbak materialize first", state.Branch, state.Refs)
+	}
+	if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+state.Branch); err != nil {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"branch '%s' of the session no longer exists", state.Branch)
+	}
+
+	// Naming the branch records it in the bundle for fetching
+	revs := []string{"refs/heads/" + state.Branch}
+	if sessionOnly {
+		if state.StartCommit == "" {
+			return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+				"session state does not record the commit the session started from")
+		}
+		revs = append(revs, "^"+state.StartCommit)
+	}
+
+	commits, err := r.countCommits(ctx, revs...)
+	if err != nil {
+		return 0, err
+	}
+	if commits == 0 {
+		// git refuses to create an empty bundle
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' has made no commits since it started", state.Branch)
+	}
+
+	args := append([]string{"bundle", "create", path}, revs...)
+	if err := r.run(ctx, args...); err != nil {
+		return 0, gitbakErrors.NewGitError("bundle", args[1:], gitbakErrors.Wrap(err, "failed to create bundle"), "")
+	}
+	return commits, nil
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestExportBundle tests bundling a session branch, whole or from the session's start
+func TestExportBundle(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		sessionOnly   bool
+		state         func(state *session.State)
+		expectCommits int
+		expectErr     error
+	}{
+		"WholeBranch": {
+			expectCommits: 3,
+		},
+		"SessionOnly": {
+			sessionOnly:   true,
+			expectCommits: 2,
+		},
+		"StashSession": {
+			state:     func(state *session.State) { state.Stash = true },
+			expectErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"BranchGone": {
+			state:     func(state *session.State) { state.Branch = "gitbak-gone" },
+			expectErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"NoCommitsSinceStart": {
+			sessionOnly: true,
+			state: func(state *session.State) {
+				state.StartCommit = "gitbak-bundle"
+			},
+			expectErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			gitOutput(t, repoPath, "checkout", "-b", "gitbak-bundle")
+			for _, file := range []string{"one.txt", "two.txt"} {
+				writeRepoFile(t, repoPath, file, file)
+				gitOutput(t, repoPath, "add", ".")
+				gitOutput(t, repoPath, "commit", "-m", "[gitbak] Automatic checkpoint "+file)
+			}
+
+			state := &session.State{Branch: "gitbak-bundle", StartCommit: startCommit}
+			if test.state != nil {
+				test.state(state)
+			}
+			path := filepath.Join(t.TempDir(), "session.bundle")
+			commits, err := NewRepository(repoPath, nil).ExportBundle(context.Background(), state, path, test.sessionOnly)
+			if test.expectErr != nil {
+				if !gitbakErrors.Is(err, test.expectErr) {
+					t.Fatalf("Expected %v, got %v", test.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExportBundle failed: %v", err)
+			}
+			if commits != test.expectCommits {
+				t.Errorf("Expected %d commits, got %d", test.expectCommits, commits)
+			}
+
+			if heads := gitOutput(t, repoPath, "bundle", "list-heads", path); !strings.HasSuffix(heads, " refs/heads/gitbak-bundle") {
+				t.Errorf("Expected the bundle to hold the session branch, got %q", heads)
+			}
+
+			// A fresh repository can fetch the whole branch, and a clone the session's commits
+			target := t.TempDir()
+			if test.sessionOnly {
+				target = filepath.Join(target, "clone")
+				gitOutput(t, repoPath, "branch", "gitbak-start", startCommit)
+				if out, err := exec.Command("git", "clone", "--quiet", "--no-local", "--single-branch", "--branch", "gitbak-start", repoPath, target).CombinedOutput(); err != nil {
+					t.Fatalf("Failed to clone: %v: %s", err, out)
+				}
+			} else {
+				gitOutput(t, target, "init", "--quiet")
+			}
+			gitOutput(t, target, "fetch", "--quiet", path, "gitbak-bundle:gitbak-bundle")
+			if head, fetched := gitOutput(t, repoPath, "rev-parse", "gitbak-bundle"), gitOutput(t, target, "rev-parse", "gitbak-bundle"); head != fetched {
+				t.Errorf("Expected the fetched branch at %s, got %s", head, fetched)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// caseRename is a tracked file whose name on disk differs from the index only in case,
+// as left behind by renaming Readme.md to README.md on a case-insensitive filesystem
+type caseRename struct {
+	from string
+	to   string
+}
+
+// detectIgnoreCase reports whether git treats the repository's filesystem as case-insensitive.
+// git sets core.ignorecase when the repository is created on such a filesystem (the default on
+// macOS and Windows), and then hides case-only renames from git status.
+func (g *Gitbak) detectIgnoreCase(ctx context.Context) bool {
+	out, err := g.runGitCommandWithOutput(ctx, "config", "--bool", "core.ignorecase")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// findCaseRenames compares the tracked paths with the names actually found on disk.
+// It is only consulted on case-insensitive filesystems, where git status reports
+// case-only renames confusingly or not at all, and `git add` keeps the old name.
+func (g *Gitbak) findCaseRenames(ctx context.Context) ([]caseRename, error) {
+	if !g.ignoreCase {
+		return nil, nil
+	}
+
+	out, err := g.runGitCommandWithOutput(ctx, append([]string{"ls-files", "-z"}, g.scopeArgs()...)...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{"-z"}, gitbakErrors.Wrap(err, "failed to list tracked files"), "")
+	}
+
+	return caseRenames(g.config.RepoPath, strings.Split(out, "\x00"), readDirNames), nil
+}
+
+// readDirNames returns the names of the entries in dir
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return f.Readdirnames(-1)
+}
+
+// caseRenames returns the tracked paths (slash-separated, relative to root) whose
+// on-disk spelling differs only in case. Each directory is listed at most once.
+func caseRenames(root string, tracked []string, listDir func(string) ([]string, error)) []caseRename {
+	listings := make(map[string][]string)
+	list := func(dir string) []string {
+		names, ok := listings[dir]
+		if !ok {
+			names, _ = listDir(filepath.Join(root, filepath.FromSlash(dir)))
+			listings[dir] = names
+		}
+		return names
+	}
+
+	var renames []caseRename
+	for _, p := range tracked {
+		if p == "" {
+			continue
+		}
+
+		// Resolve each component against its parent's listing, so that directory renames are found too
+		actual := ""
+		for _, component := range strings.Split(p, "/") {
+			match := ""
+			for _, name := range list(actual) {
+				if name == component {
+					match = name
+					break
+				}
+				if match == "" && strings.EqualFold(name, component) {
+					match = name
+				}
+			}
+			if match == "" {
+				// Deleted rather than renamed; git status reports it as usual
+				actual = ""
+				break
+			}
+			actual = path.Join(actual, match)
+		}
+
+		if actual != "" && actual != p {
+			renames = append(renames, caseRename{from: p, to: actual})
+		}
+	}
+	return renames
+}
+
+// stageCaseRenames drops the old spelling of case-renamed files from the index,
+// so that the following `git add` records them under their new names
+func (g *Gitbak) stageCaseRenames(ctx context.Context) error {
+	renames, err := g.findCaseRenames(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range renames {
+		args := []string{"rm", "--cached", "--quiet", "--", r.from}
+		if err := g.runGitCommand(ctx, args...); err != nil {
+			return gitbakErrors.NewGitError("rm", args[1:], gitbakErrors.Wrap(err, "failed to stage case-only rename"), "")
+		}
+		g.logger.Info("Staged case-only rename %s -> %s", r.from, r.to)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestCaseRenames tests matching tracked paths against a case-insensitive directory listing
+func TestCaseRenames(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tracked  []string
+		disk     map[string][]string
+		expected []caseRename
+	}{
+		"Unchanged": {
+			tracked:  []string{"README.md", "src/main.go"},
+			disk:     map[string][]string{"": {"README.md", "src"}, "src": {"main.go"}},
+			expected: nil,
+		},
+		"FileRenamed": {
+			tracked:  []string{"Readme.md"},
+			disk:     map[string][]string{"": {"README.md"}},
+			expected: []caseRename{{from: "Readme.md", to: "README.md"}},
+		},
+		"DirectoryRenamed": {
+			tracked:  []string{"Src/main.go", "Src/util.go"},
+			disk:     map[string][]string{"": {"src"}, "src": {"main.go", "util.go"}},
+			expected: []caseRename{{from: "Src/main.go", to: "src/main.go"}, {from: "Src/util.go", to: "src/util.go"}},
+		},
+		"ExactMatchPreferred": {
+			tracked:  []string{"a.txt", "A.txt"},
+			disk:     map[string][]string{"": {"A.txt", "a.txt"}},
+			expected: nil,
+		},
+		"DuplicateIndexEntries": {
+			tracked:  []string{"Foo.go", "foo.go"},
+			disk:     map[string][]string{"": {"foo.go"}},
+			expected: []caseRename{{from: "Foo.go", to: "foo.go"}},
+		},
+		"Deleted": {
+			tracked:  []string{"gone.txt", ""},
+			disk:     map[string][]string{"": {"other.txt"}},
+			expected: nil,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			listed := make(map[string]int)
+			listDir := func(dir string) ([]string, error) {
+				rel, _ := filepath.Rel("/repo", dir)
+				if rel == "." {
+					rel = ""
+				}
+				listed[rel]++
+				for d, names := range test.disk {
+					if strings.EqualFold(d, rel) {
+						return names, nil
+					}
+				}
+				return nil, os.ErrNotExist
+			}
+
+			got := caseRenames("/repo", test.tracked, listDir)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+			for dir, n := range listed {
+				if n > 1 {
+					t.Errorf("Expected directory %q to be listed once, listed %d times", dir, n)
+				}
+			}
+		})
+	}
+}
+
+// TestCaseOnlyRenameCheckpoint tests that a case-only rename is checkpointed under the new name.
+// core.ignorecase makes git behave as it does on a case-insensitive volume.
+func TestCaseOnlyRenameCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	runGit := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "Readme.md"), []byte("docs"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	runGit("add", "Readme.md")
+	runGit("commit", "-m", "Add readme")
+	runGit("config", "core.ignorecase", "true")
+
+	if err := os.Rename(filepath.Join(repoPath, "Readme.md"), filepath.Join(repoPath, "README.md")); err != nil {
+		t.Fatalf("Failed to rename file: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-case",
+		CommitPrefix:   "[gitbak-case] Commit",
+		CreateBranch:   false,
+		NonInteractive: true,
+	}, logger.New(false, "", false))
+
+	if err := gb.RunSingleIteration(context.Background()); err != nil {
+		t.Fatalf("RunSingleIteration failed: %v", err)
+	}
+
+	if gb.commitsCount != 1 {
+		t.Fatalf("Expected the rename to be checkpointed, got %d commits", gb.commitsCount)
+	}
+	tracked := strings.Fields(runGit("ls-files"))
+	if !reflect.DeepEqual(tracked, []string{"README.md", "initial.txt"}) {
+		t.Errorf("Expected only the new spelling to be tracked, got %v", tracked)
+	}
+	if status := strings.TrimSpace(runGit("status", "--porcelain")); status != "" {
+		t.Errorf("Expected a clean working tree after the checkpoint, got %q", status)
+	}
+}
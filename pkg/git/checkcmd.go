@@ -0,0 +1,136 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// What happens to a checkpoint when the check command fails
+const (
+	// CheckFailMark makes the checkpoint anyway, marked as failing the check.
+	CheckFailMark = "mark"
+
+	// CheckFailSkip holds the changes back until the check passes, so that every
+	// checkpoint passed it.
+	CheckFailSkip = "skip"
+)
+
+// CheckFailModes lists the accepted values of GitbakConfig.OnCheckFail
+var CheckFailModes = []string{CheckFailMark, CheckFailSkip}
+
+// CheckTrailer is the commit trailer recording whether a checkpoint passed the check
+// command, as passed or failed, so that the last known good checkpoint can be found with
+// git log --grep
+const CheckTrailer = "Gitbak-Check"
+
+// maxCheckOutput bounds how much of a failing check command's output is logged; the end
+// is kept, as that is where build tools and test runners report what failed
+const maxCheckOutput = 2000
+
+// checkResult is the outcome of running the check command before a checkpoint
+type checkResult int
+
+const (
+	// checkNotRun means no check command is configured
+	checkNotRun checkResult = iota
+	checkPassed
+	checkFailed
+)
+
+// marker returns what the checkpoint's subject is suffixed with for the result
+func (r checkResult) marker() string {
+	switch r {
+	case checkPassed:
+		return " ✅"
+	case checkFailed:
+		return " ❌"
+	default:
+		return ""
+	}
+}
+
+// trailer returns the CheckTrailer line recording the result, or "" if no check ran
+func (r checkResult) trailer() string {
+	switch r {
+	case checkPassed:
+		return CheckTrailer + ": passed"
+	case checkFailed:
+		return CheckTrailer + ": failed"
+	default:
+		return ""
+	}
+}
+
+// runCheckCommand runs CheckCommand with the shell in the repository, for the checkpoint
+// about to be made, and reports whether it exited successfully. It fails only if ctx ends
+// first, as the check must then be retried; a command that cannot be run at all fails the
+// check like one exiting with an error.
+func (g *Gitbak) runCheckCommand(ctx context.Context) (checkResult, error) {
+	if g.config.CheckCommand == "" {
+		return checkNotRun, nil
+	}
+
+	name, args := "sh", []string{"-c", g.config.CheckCommand}
+	if runtime.GOOS == "windows" {
+		name, args = "cmd", []string{"/C", g.config.CheckCommand}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = g.config.RepoPath
+	cmd.WaitDelay = commandWaitDelay
+	// A timed-out build must not leave its compilers running
+	killProcessGroupOnCancel(cmd)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	started := time.Now()
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return checkNotRun, ctx.Err()
+	}
+	if err == nil {
+		g.logger.Info("Check command passed in %v: %s", time.Since(started).Round(time.Millisecond), g.config.CheckCommand)
+		return checkPassed, nil
+	}
+
+	out := strings.TrimSpace(output.String())
+	if len(out) > maxCheckOutput {
+		out = "..." + out[len(out)-maxCheckOutput:]
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		g.logger.WarningToUser("Failed to run the check command %q: %v", g.config.CheckCommand, err)
+	}
+	g.logger.Warning("Check command failed in %v: %s: %v\n%s", time.Since(started).Round(time.Millisecond), g.config.CheckCommand, err, out)
+	return checkFailed, nil
+}
+
+// checkHoldsCheckpoint runs the check command and reports whether the checkpoint must wait
+// for it to pass, recording the result for the checkpoint's message otherwise. The final
+// checkpoint has only the shutdown timeout to be made in, too short for a build, so it is
+// made unchecked.
+func (g *Gitbak) checkHoldsCheckpoint(ctx context.Context) (bool, error) {
+	g.checkResult = checkNotRun
+	if g.finalCheck {
+		return false, nil
+	}
+
+	result, err := g.runCheckCommand(ctx)
+	if err != nil {
+		return false, err
+	}
+	g.checkResult = result
+	if result != checkFailed || g.config.OnCheckFail != CheckFailSkip {
+		return false, nil
+	}
+
+	g.logger.WarningToUser("Not checkpointing: %q failed; checkpoints resume once it passes", g.config.CheckCommand)
+	g.logger.Info("Checkpoint held back: the check command failed")
+	return true, nil
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestCheckCommand tests that checkpoints record the outcome of CheckCommand, and wait
+// for it to pass with CheckFailSkip
+func TestCheckCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		command       string
+		onCheckFail   string
+		finalCheck    bool
+		expectCommit  bool
+		expectSubject string
+		expectTrailer string
+	}{
+		"NoCommand": {
+			expectCommit:  true,
+			expectSubject: "#1 - ",
+		},
+		"Passed": {
+			command:       "exit 0",
+			expectCommit:  true,
+			expectSubject: " ✅",
+			expectTrailer: "Gitbak-Check: passed",
+		},
+		"FailedMarked": {
+			command:       "exit 3",
+			expectCommit:  true,
+			expectSubject: " ❌",
+			expectTrailer: "Gitbak-Check: failed",
+		},
+		"FailedSkipped": {
+			command:     "exit 3",
+			onCheckFail: CheckFailSkip,
+		},
+		"FinalCheckpointUnchecked": {
+			command:       "exit 3",
+			onCheckFail:   CheckFailSkip,
+			finalCheck:    true,
+			expectCommit:  true,
+			expectSubject: "#1 - ",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			writeRepoFile(t, repoPath, "main.go", "package main\n")
+			// Old enough for the changes to be told apart from further edits
+			modified := time.Now().Add(-time.Minute)
+			if err := os.Chtimes(filepath.Join(repoPath, "main.go"), modified, modified); err != nil {
+				t.Fatalf("Failed to age main.go: %v", err)
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:     repoPath,
+				Interval:     time.Minute,
+				BranchName:   "gitbak-check",
+				CommitPrefix: "[gitbak-check] Commit",
+				CheckCommand: test.command,
+				OnCheckFail:  test.onCheckFail,
+			}, logger.New(false, "", false))
+			gb.finalCheck = test.finalCheck
+
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(context.Background(), 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if commitWasCreated != test.expectCommit {
+				t.Fatalf("Expected a checkpoint: %v, got %v", test.expectCommit, commitWasCreated)
+			}
+			if !test.expectCommit {
+				if gb.heldChanges == "" {
+					t.Errorf("Expected the changes to be held until they change")
+				}
+				return
+			}
+
+			subject := gitOutput(t, repoPath, "log", "-1", "--format=%s")
+			if !strings.Contains(subject, test.expectSubject) {
+				t.Errorf("Expected subject %q to contain %q", subject, test.expectSubject)
+			}
+			if test.expectTrailer == "" && (strings.Contains(subject, "✅") || strings.Contains(subject, "❌")) {
+				t.Errorf("Expected no check marker, got %q", subject)
+			}
+			trailers := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key=Gitbak-Check)")
+			if strings.TrimSpace(trailers) != test.expectTrailer {
+				t.Errorf("Expected trailer %q, got %q", test.expectTrailer, trailers)
+			}
+		})
+	}
+}
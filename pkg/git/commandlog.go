@@ -0,0 +1,65 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// commandLogger is a CommandExecutor that traces every command the executor it wraps
+// runs, with how long it took, so that -log-level trace shows what gitbak is doing
+type commandLogger struct {
+	executor CommandExecutor
+	logger   logger.Logger
+}
+
+// newCommandLogger wraps executor so that its commands are traced to log
+func newCommandLogger(executor CommandExecutor, log logger.Logger) *commandLogger {
+	return &commandLogger{executor: executor, logger: log}
+}
+
+// Execute implements CommandExecutor.Execute
+func (e *commandLogger) Execute(ctx context.Context, cmd *exec.Cmd) error {
+	start := time.Now()
+	err := e.executor.Execute(ctx, cmd)
+	e.trace(cmd.Args, start, err)
+	return err
+}
+
+// ExecuteWithOutput implements CommandExecutor.ExecuteWithOutput
+func (e *commandLogger) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	start := time.Now()
+	out, err := e.executor.ExecuteWithOutput(ctx, cmd)
+	e.trace(cmd.Args, start, err)
+	return out, err
+}
+
+// ExecuteWithContext implements CommandExecutor.ExecuteWithContext
+func (e *commandLogger) ExecuteWithContext(ctx context.Context, name string, args ...string) error {
+	start := time.Now()
+	err := e.executor.ExecuteWithContext(ctx, name, args...)
+	e.trace(append([]string{name}, args...), start, err)
+	return err
+}
+
+// ExecuteWithContextAndOutput implements CommandExecutor.ExecuteWithContextAndOutput
+func (e *commandLogger) ExecuteWithContextAndOutput(ctx context.Context, name string, args ...string) (string, error) {
+	start := time.Now()
+	out, err := e.executor.ExecuteWithContextAndOutput(ctx, name, args...)
+	e.trace(append([]string{name}, args...), start, err)
+	return out, err
+}
+
+// trace logs a finished command, given as its name followed by its arguments
+func (e *commandLogger) trace(argv []string, start time.Time, err error) {
+	took := time.Since(start).Round(time.Microsecond)
+	command := strings.Join(argv, " ")
+	if err != nil {
+		e.logger.Trace("%s failed after %s (exit code %d)", command, took, exitCode(err))
+		return
+	}
+	e.logger.Trace("%s took %s", command, took)
+}
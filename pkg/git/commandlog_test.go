@@ -0,0 +1,66 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestCommandLogging tests that git commands are traced only when LogCommands is set
+func TestCommandLogging(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		logCommands bool
+		expectTrace bool
+	}{
+		"Enabled":  {logCommands: true, expectTrace: true},
+		"Disabled": {},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			if err := os.WriteFile(filepath.Join(repoPath, "traced.txt"), []byte("changed"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			var buf bytes.Buffer
+			log := logger.NewWithRotation(false, "", logger.VerbosityTrace, logger.Rotation{}, &buf, &buf)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-logged",
+				CommitPrefix:   "[gitbak-logged]",
+				CreateBranch:   false,
+				NonInteractive: true,
+				LogCommands:    test.logCommands,
+			}, log)
+
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(context.Background(), 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if !commitWasCreated {
+				t.Fatal("Expected a checkpoint to be created")
+			}
+
+			traced := strings.Contains(buf.String(), "🔬 git ")
+			if traced != test.expectTrace {
+				t.Fatalf("Expected git commands to be traced: %v, got output %q", test.expectTrace, buf.String())
+			}
+			if test.expectTrace && !strings.Contains(buf.String(), "commit ") {
+				t.Errorf("Expected the commit command to be traced, got %q", buf.String())
+			}
+		})
+	}
+}
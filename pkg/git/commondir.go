@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// CommonDir returns the git directory shared by all worktrees of the repository containing
+// path, which identifies the repository whichever of its worktrees path is in. It reads the
+// .git file and commondir link that git leaves in a linked worktree rather than running git,
+// so that it works with every backend.
+func CommonDir(path string) (string, error) {
+	_, _, commonDir, err := findGitDirs(path)
+	return commonDir, err
+}
+
+// findGitDirs returns the top-level directory of the worktree containing path, the worktree's
+// own git directory and the repository's shared one. The two git directories are the same
+// except in a linked worktree.
+func findGitDirs(path string) (top, gitDir, commonDir string, err error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", "", gitbakErrors.Wrap(err, "failed to resolve repository path")
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		switch {
+		case err == nil && info.IsDir():
+			return dir, dotGit, dotGit, nil
+		case err == nil:
+			gitDir, commonDir, err := linkedGitDirs(dotGit)
+			return dir, gitDir, commonDir, err
+		case !os.IsNotExist(err):
+			return "", "", "", gitbakErrors.Wrap(err, "failed to inspect .git")
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", "", gitbakErrors.Wrapf(gitbakErrors.ErrNotGitRepository, "no .git found above %s", path)
+		}
+		dir = parent
+	}
+}
+
+// linkedGitDirs follows the .git file of a linked worktree (or submodule) to its git
+// directory, and from there its commondir link, if any, to the repository's shared one
+func linkedGitDirs(dotGit string) (gitDir, commonDir string, err error) {
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", "", gitbakErrors.Wrap(err, "failed to read .git file")
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", "", gitbakErrors.Errorf("unrecognized .git file %s", dotGit)
+	}
+	gitDir = resolveFrom(filepath.Dir(dotGit), strings.TrimSpace(gitDir))
+
+	link, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if os.IsNotExist(err) {
+		// A submodule has a git directory of its own
+		return gitDir, gitDir, nil
+	}
+	if err != nil {
+		return "", "", gitbakErrors.Wrap(err, "failed to read commondir")
+	}
+	return gitDir, resolveFrom(gitDir, strings.TrimSpace(string(link))), nil
+}
+
+// resolveFrom resolves path relative to dir, unless it is absolute
+func resolveFrom(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestCommonDir tests that every worktree of a repository, and every directory in them,
+// resolves to the repository's shared git directory
+func TestCommonDir(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	subdir := filepath.Join(repoPath, "sub", "dir")
+	if err := os.MkdirAll(subdir, 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	worktree := filepath.Join(t.TempDir(), "feature")
+	gitOutput(t, repoPath, "worktree", "add", "-q", "-b", "feature", worktree)
+
+	expected, err := filepath.EvalSymlinks(filepath.Join(repoPath, ".git"))
+	if err != nil {
+		t.Fatalf("Failed to resolve git directory: %v", err)
+	}
+
+	tests := map[string]struct {
+		path string
+	}{
+		"MainWorktree":   {path: repoPath},
+		"Subdirectory":   {path: subdir},
+		"LinkedWorktree": {path: worktree},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			commonDir, err := CommonDir(test.path)
+			if err != nil {
+				t.Fatalf("Failed to find common dir: %v", err)
+			}
+			if resolved, _ := filepath.EvalSymlinks(commonDir); resolved != expected {
+				t.Errorf("Expected common dir %s, got %s", expected, commonDir)
+			}
+		})
+	}
+
+	if _, err := CommonDir(t.TempDir()); !gitbakErrors.Is(err, gitbakErrors.ErrNotGitRepository) {
+		t.Errorf("Expected ErrNotGitRepository outside a repository, got %v", err)
+	}
+}
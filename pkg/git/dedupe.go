@@ -0,0 +1,32 @@
+package git
+
+import (
+	"context"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// stagedTreeIsHead reports whether the index, once a checkpoint's changes are staged,
+// records the same tree as HEAD, so that committing it would only repeat HEAD's content.
+// git status can list changes that staging finds nothing of, such as files rewritten with
+// the content they had, on a session restarted straight after the last checkpoint, or line
+// endings that are normalized on the way into the index. Not supported by BackendGoGit,
+// which reports false.
+func (g *Gitbak) stagedTreeIsHead(ctx context.Context) (bool, error) {
+	if g.config.Backend == BackendGoGit {
+		return false, nil
+	}
+
+	head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--verify", "--quiet", "HEAD^{tree}")
+	if err != nil {
+		// An unborn HEAD has no tree, so whatever is staged is new
+		return false, nil
+	}
+
+	staged, err := g.runGitCommandWithOutput(ctx, "write-tree")
+	if err != nil {
+		return false, gitbakErrors.NewGitError("write-tree", nil, gitbakErrors.Wrap(err, "failed to record index"), "")
+	}
+	return strings.TrimSpace(staged) == strings.TrimSpace(head), nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestCheckpointRepeatingHead tests that changes git status lists are not checkpointed when
+// staging them leaves the tree as it is at HEAD
+func TestCheckpointRepeatingHead(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-dedupe",
+		CommitPrefix:   "[gitbak] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	checkpoint := func(counter int) bool {
+		t.Helper()
+		var created bool
+		if err := gb.checkAndCommitChanges(ctx, counter, &created); err != nil {
+			t.Fatalf("checkAndCommitChanges failed: %v", err)
+		}
+		return created
+	}
+
+	// A staged edit since undone in the working tree: listed by git status, but staging
+	// everything brings back HEAD's content
+	original, err := os.ReadFile(filepath.Join(repoPath, "initial.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	writeRepoFile(t, repoPath, "initial.txt", "edited")
+	gitOutput(t, repoPath, "add", "initial.txt")
+	writeRepoFile(t, repoPath, "initial.txt", string(original))
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status == "" {
+		t.Fatal("Expected git status to list the undone edit")
+	}
+
+	head := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	if checkpoint(1) {
+		t.Error("Expected no checkpoint repeating HEAD's tree")
+	}
+	if checkpoint(1) {
+		t.Error("Expected no checkpoint at the next check either")
+	}
+	if now := gitOutput(t, repoPath, "rev-parse", "HEAD"); now != head || gb.commitsCount != 0 {
+		t.Errorf("Expected HEAD to stay at %s with no checkpoints, got %s with %d", head, now, gb.commitsCount)
+	}
+
+	// Staging without a commit is all a caller that commits directly gets
+	if created, err := gb.createCommit(ctx, 1); err != nil || created {
+		t.Errorf("Expected createCommit to skip the unchanged tree, got %v, %v", created, err)
+	}
+
+	writeRepoFile(t, repoPath, "initial.txt", "changed")
+	if !checkpoint(1) {
+		t.Fatal("Expected a checkpoint of a real change")
+	}
+	if now := gitOutput(t, repoPath, "rev-parse", "HEAD^"); now != head {
+		t.Errorf("Expected the checkpoint on top of %s, got a parent of %s", head, now)
+	}
+}
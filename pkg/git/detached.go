@@ -0,0 +1,54 @@
+package git
+
+import (
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// shortCommitLength is how many hex digits identify a commit in messages
+const shortCommitLength = 7
+
+// shortCommit abbreviates a commit hash to shortCommitLength digits
+func shortCommit(commit string) string {
+	if len(commit) > shortCommitLength {
+		return commit[:shortCommitLength]
+	}
+	return commit
+}
+
+// handleDetachedHead prepares a session started with HEAD detached, which has no branch
+// for checkpoints to extend. A new gitbak branch starts from the current commit, and stash
+// snapshots don't need a branch at all, but checkpoints committed to the detached HEAD itself
+// would belong to no branch and be easily lost, so -no-branch and -continue are refused.
+//
+// The commit HEAD is detached at takes the place of the original branch, which is what
+// the session summary suggests returning to.
+func (g *Gitbak) handleDetachedHead() error {
+	if g.originalBranch != "" {
+		return nil
+	}
+
+	commit := shortCommit(g.startCommit)
+	if commit == "" {
+		return gitbakErrors.Wrap(gitbakErrors.ErrGitOperationFailed, "HEAD is neither on a branch nor at a commit")
+	}
+	g.originalBranch = commit
+
+	switch {
+	case g.stashMode():
+		g.logger.InfoToUser("HEAD is detached at %s - stash snapshots will be based on it", commit)
+	case g.refsMode():
+		g.logger.InfoToUser("HEAD is detached at %s - checkpoint refs will be based on it", commit)
+	case g.observeMode():
+		g.logger.InfoToUser("HEAD is detached at %s - changes are journaled against it", commit)
+	case g.config.ContinueSession:
+		return gitbakErrors.Errorf("%w at %s; check out the gitbak branch of the session to continue first: %w",
+			gitbakErrors.ErrDetachedHead, commit, gitbakErrors.ErrInvalidConfiguration)
+	case !g.config.CreateBranch:
+		return gitbakErrors.Errorf("%w at %s, so checkpoints made with -no-branch would belong to no branch; "+
+			"check out a branch first, or omit -no-branch to start a gitbak branch from this commit: %w",
+			gitbakErrors.ErrDetachedHead, commit, gitbakErrors.ErrInvalidConfiguration)
+	default:
+		g.logger.InfoToUser("HEAD is detached at %s - the gitbak branch will start from this commit", commit)
+	}
+	return nil
+}
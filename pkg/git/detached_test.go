@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestDetachedHeadScenarios tests starting a session with HEAD detached
+func TestDetachedHeadScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config       GitbakConfig
+		expectErr    error
+		validateFunc func(t *testing.T, gb *Gitbak, repoPath, detachedAt string)
+	}{
+		"NewBranch": {
+			config: GitbakConfig{CreateBranch: true},
+			validateFunc: func(t *testing.T, gb *Gitbak, repoPath, detachedAt string) {
+				if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != "gitbak-detached" {
+					t.Errorf("Expected to be on the gitbak branch, got %q", branch)
+				}
+				if parent := gitOutput(t, repoPath, "rev-parse", "HEAD~1"); parent != detachedAt {
+					t.Errorf("Expected the gitbak branch to start from %s, got %s", detachedAt, parent)
+				}
+				if gb.originalBranch != detachedAt[:shortCommitLength] {
+					t.Errorf("Expected the detached commit as the original branch, got %q", gb.originalBranch)
+				}
+			},
+		},
+		"NoBranch": {
+			config:    GitbakConfig{CreateBranch: false},
+			expectErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"Continue": {
+			config:    GitbakConfig{ContinueSession: true},
+			expectErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+		"Stash": {
+			config: GitbakConfig{Mode: ModeStash},
+			validateFunc: func(t *testing.T, gb *Gitbak, repoPath, detachedAt string) {
+				if head := gitOutput(t, repoPath, "rev-parse", "HEAD"); head != detachedAt {
+					t.Errorf("Expected HEAD to stay at %s, got %s", detachedAt, head)
+				}
+				if stashes := gitOutput(t, repoPath, "stash", "list"); stashes == "" {
+					t.Error("Expected a stash snapshot")
+				}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gitOutput(t, repoPath, "checkout", "--detach")
+			detachedAt := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("work"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			config := test.config
+			config.RepoPath = repoPath
+			config.Interval = time.Minute
+			config.BranchName = "gitbak-detached"
+			config.CommitPrefix = "[gitbak-detached] Commit"
+			config.NonInteractive = true
+			gb := setupTestGitbak(config, logger.New(false, "", false))
+
+			ctx := context.Background()
+			err := gb.initialize(ctx)
+			if test.expectErr != nil {
+				if !gitbakErrors.Is(err, test.expectErr) {
+					t.Fatalf("Expected %v, got %v", test.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if !commitWasCreated {
+				t.Fatal("Expected a checkpoint to be created")
+			}
+
+			test.validateFunc(t, gb, repoPath, detachedAt)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// diffSummaryMaxFiles bounds how many files a checkpoint's diff summary lists,
+// so that a checkpoint touching thousands of files doesn't get an equally long message
+const diffSummaryMaxFiles = 50
+
+// diffSummary describes the staged changes for the body of a checkpoint commit: the lines
+// changed per file as reported by git diff --stat, followed by the files created, deleted
+// or renamed. It returns an empty string when nothing is staged.
+func (g *Gitbak) diffSummary(ctx context.Context) (string, error) {
+	args := []string{"diff", "--cached", "--no-color", "--stat", fmt.Sprintf("--stat-count=%d", diffSummaryMaxFiles), "--summary"}
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return "", gitbakErrors.NewGitError("diff", args[1:], gitbakErrors.Wrap(err, "failed to summarize staged changes"), "")
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestDiffSummary tests that checkpoint commits describe their changes when DiffSummary is set
+func TestDiffSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		diffSummary  bool
+		chainTrailer bool
+		wantBody     []string
+	}{
+		"Disabled": {
+			diffSummary: false,
+		},
+		"Enabled": {
+			diffSummary: true,
+			wantBody: []string{
+				"initial.txt | 2 +-",
+				"new.txt     | 1 +",
+				"2 files changed, 2 insertions(+), 1 deletion(-)",
+				"create mode 100644 new.txt",
+			},
+		},
+		"EnabledWithChainTrailer": {
+			diffSummary:  true,
+			chainTrailer: true,
+			wantBody:     []string{"2 files changed"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify file: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("new\n"), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+
+			config := GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-summary",
+				CommitPrefix:   "[gitbak-summary] Checkpoint",
+				CreateBranch:   false,
+				NonInteractive: true,
+				DiffSummary:    test.diffSummary,
+				ChainTrailer:   test.chainTrailer,
+			}
+			if test.chainTrailer {
+				config.StateFile = filepath.Join(t.TempDir(), "state.json")
+			}
+			gb := setupTestGitbak(config, logger.New(false, "", false))
+
+			if _, err := gb.createCommit(context.Background(), 1); err != nil {
+				t.Fatalf("createCommit failed: %v", err)
+			}
+
+			subject := gitOutput(t, repoPath, "log", "-1", "--format=%s")
+			if !strings.HasPrefix(subject, "[gitbak-summary] Checkpoint #1 - ") {
+				t.Errorf("Expected the checkpoint subject, got %q", subject)
+			}
+
+			body := gitOutput(t, repoPath, "log", "-1", "--format=%b")
+			if len(test.wantBody) == 0 && body != "" {
+				t.Errorf("Expected no body, got %q", body)
+			}
+			for _, want := range test.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected body to contain %q, got %q", want, body)
+				}
+			}
+
+			if test.chainTrailer {
+				trailer := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key=Gitbak-Chain)")
+				if trailer == "" {
+					t.Errorf("Expected the chain trailer to follow the summary, got body %q", body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// Divergence modes, selecting what happens when the session branch's history is rewritten
+// under the session, e.g. by an interactive rebase or a reset
+const (
+	// DivergeWarn warns once and makes the next checkpoint on top of the rewritten history.
+	DivergeWarn = "warn"
+
+	// DivergePause warns and pauses checkpointing through GitbakConfig.Pause, so that the
+	// user can look at the new history before checkpoints continue on top of it.
+	DivergePause = "pause"
+)
+
+// DivergeModes lists the accepted values of GitbakConfig.OnDiverge
+var DivergeModes = []string{DivergeWarn, DivergePause}
+
+// recordHead remembers the branch tip after a checkpoint, as the history later checkpoints must extend
+func (g *Gitbak) recordHead(ctx context.Context) {
+	if out, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD"); err == nil {
+		g.knownHead = strings.TrimSpace(out)
+	}
+}
+
+// historyIntact reports whether a checkpoint may be made on the branch's current history.
+// The branch diverged if its tip no longer descends from the tip seen at the previous
+// check, which means commits the session knew about were rewritten or dropped; commits
+// made on top of it, such as manual ones, are fine. A divergence is reported once, and
+// its new history accepted, so it returns false only when DivergePause paused the session.
+func (g *Gitbak) historyIntact(ctx context.Context) bool {
+	out, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		// No commits yet, so there is no history to rewrite
+		return true
+	}
+	head := strings.TrimSpace(out)
+
+	known := g.knownHead
+	if known == "" {
+		known = g.startCommit
+	}
+	g.knownHead = head
+	if known == "" || known == head {
+		return true
+	}
+
+	err = g.runGitCommand(ctx, "merge-base", "--is-ancestor", known, head)
+	if err == nil {
+		return true
+	}
+	if exitCode(err) != 1 {
+		// Unable to tell, e.g. the old tip was pruned; err on the side of keeping the work
+		g.logger.Warning("Failed to check whether %s still contains %s: %v", g.sessionBranch(), known, err)
+		return true
+	}
+
+	branch := g.sessionBranch()
+	g.logger.Warning("Branch %s diverged: %s is no longer part of its history, which now ends at %s", branch, known, head)
+	if g.config.OnDiverge == DivergePause && g.config.Pause != nil {
+		g.logger.WarningToUser("⚠️  The history of '%s' was rewritten: %s, its tip at the last check, is no longer part of it. "+
+			"Checkpointing is paused so nothing is committed on top by surprise; run 'gitbak resume' to continue from the new history.",
+			branch, shortCommit(known))
+		g.config.Pause()
+		return false
+	}
+
+	g.logger.WarningToUser("⚠️  The history of '%s' was rewritten: %s, its tip at the last check, is no longer part of it. "+
+		"Checkpoints continue on top of the new history.", branch, shortCommit(known))
+	return true
+}
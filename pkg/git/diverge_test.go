@@ -0,0 +1,114 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestHistoryDivergence tests that checkpoints notice a session branch rewritten under them
+func TestHistoryDivergence(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		rewrite      func(t *testing.T, repoPath string)
+		onDiverge    string
+		expectWarn   bool
+		expectPause  bool
+		expectCommit bool
+	}{
+		"ManualCommitOnTop": {
+			rewrite: func(t *testing.T, repoPath string) {
+				gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "Manual milestone")
+			},
+			onDiverge:    DivergePause,
+			expectCommit: true,
+		},
+		"ResetWarns": {
+			rewrite: func(t *testing.T, repoPath string) {
+				gitOutput(t, repoPath, "reset", "--hard", "HEAD~1")
+			},
+			onDiverge:    DivergeWarn,
+			expectWarn:   true,
+			expectCommit: true,
+		},
+		"AmendPauses": {
+			rewrite: func(t *testing.T, repoPath string) {
+				gitOutput(t, repoPath, "commit", "--amend", "-m", "Reworded checkpoint")
+			},
+			onDiverge:   DivergePause,
+			expectWarn:  true,
+			expectPause: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			write := func(content string) {
+				if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			}
+
+			var buf bytes.Buffer
+			paused := false
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-diverge",
+				CommitPrefix:   "[gitbak-diverge]",
+				CreateBranch:   false,
+				NonInteractive: true,
+				OnDiverge:      test.onDiverge,
+				Pause:          func() { paused = true },
+			}, logger.NewWithOutput(false, "", true, &buf, &buf))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			write("first")
+			var created bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil || !created {
+				t.Fatalf("Expected the first checkpoint, got created=%v, err=%v", created, err)
+			}
+
+			test.rewrite(t, repoPath)
+			write("second")
+			if err := gb.checkAndCommitChanges(ctx, 2, &created); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+
+			if created != test.expectCommit {
+				t.Errorf("Expected a checkpoint on the new history: %v, got %v", test.expectCommit, created)
+			}
+			if paused != test.expectPause {
+				t.Errorf("Expected the session to be paused: %v, got %v", test.expectPause, paused)
+			}
+			if warned := strings.Contains(buf.String(), "was rewritten"); warned != test.expectWarn {
+				t.Errorf("Expected a warning: %v, got output %q", test.expectWarn, buf.String())
+			}
+
+			// Once reported, the new history is accepted
+			buf.Reset()
+			write("third")
+			if err := gb.checkAndCommitChanges(ctx, 3, &created); err != nil || !created {
+				t.Fatalf("Expected a checkpoint after the divergence was reported, got created=%v, err=%v", created, err)
+			}
+			if strings.Contains(buf.String(), "was rewritten") {
+				t.Errorf("Expected the divergence to be reported once, got %q", buf.String())
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@
 //   - Gitbak: Main type that manages a Git repository and performs automatic commits
 //   - CommandExecutor: Interface for executing Git commands
 //   - UserInteractor: Interface for user interaction during Git operations
+//   - Repository: Git operations on a finished session, such as AbortSession, SquashSession and VerifySession
 //
 // # Features
 //
@@ -25,7 +26,7 @@
 //
 //	config := git.GitbakConfig{
 //	    RepoPath:        "/path/to/repo",
-//	    IntervalMinutes: 5,
+//	    Interval: 5 * time.Minute,
 //	    BranchName:      "gitbak-session",
 //	    CommitPrefix:    "[gitbak]",
 //	    CreateBranch:    true,
@@ -52,8 +53,13 @@
 //	    // Handle error
 //	}
 //
+//	// Checkpoint the latest changes if stopped, then record the finished session
+//	_ = gitbak.FinalCheckpoint(shutdownCtx)
+//	_ = gitbak.PushPending(shutdownCtx)
+//	gitbak.RecordHistory()
+//
 //	// Show session summary
-//	gitbak.PrintSummary()
+//	gitbak.PrintSummary(ctx)
 //
 // # Error Handling
 //
@@ -4,10 +4,46 @@ import (
 	"bytes"
 	"context"
 	"os/exec"
+	"slices"
+	"time"
 
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 )
 
+// maxStderrBytes bounds how much of a command's stderr is retained for error reporting.
+// Git can emit very large diagnostics (e.g. on a corrupt index); keeping all of it in every
+// GitError would let a long session's memory grow with each failure.
+const maxStderrBytes = 64 * 1024
+
+// commandWaitDelay is how long a canceled command's output pipes may stay open after it is killed.
+// Without it, a hung grandchild (such as a credential helper) that inherited the pipes would block
+// the kill from ever returning.
+const commandWaitDelay = 5 * time.Second
+
+// boundedBuffer is a bytes.Buffer that silently discards writes beyond its limit.
+// It always reports the full write length so the command never sees a short write.
+type boundedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String returns the retained contents of the buffer
+func (b *boundedBuffer) String() string {
+	return b.buf.String()
+}
+
 // CommandExecutor defines an interface for executing commands
 type CommandExecutor interface {
 	// Execute runs a command and returns its exit code
@@ -23,21 +59,119 @@ type CommandExecutor interface {
 	ExecuteWithContextAndOutput(ctx context.Context, name string, args ...string) (string, error)
 }
 
+// Git backends, selecting how git commands are carried out
+const (
+	// BackendExec runs the git binary for every command.
+	BackendExec = "exec"
+
+	// BackendGoGit carries out commands in-process with go-git, so git need not be installed.
+	BackendGoGit = "gogit"
+)
+
+// Backends lists the accepted values of GitbakConfig.Backend
+var Backends = []string{BackendExec, BackendGoGit}
+
+// NewExecutor creates the CommandExecutor for a backend, which must be one of Backends
+// or empty for BackendExec
+func NewExecutor(backend string) CommandExecutor {
+	if backend == BackendGoGit {
+		return NewGoGitExecutor()
+	}
+	return NewExecExecutor()
+}
+
+// exitCode returns the exit code of a failed command, or -1 if err does not carry one.
+// It understands both *exec.ExitError and the exit statuses reported by GoGitExecutor.
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if gitbakErrors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // ExecExecutor is the default implementation of CommandExecutor
-// that delegates to the os/exec package
-type ExecExecutor struct{}
+// that delegates to the os/exec package. It keeps no state between commands,
+// so a single ExecExecutor can be shared by concurrent callers.
+type ExecExecutor struct {
+	// timeout is the longest a command may run before it is killed; zero means no limit
+	timeout time.Duration
+
+	// gitPath is the git binary that commands named "git" run; empty means git from PATH
+	gitPath string
+
+	// globalArgs are inserted before the arguments of every git command
+	globalArgs []string
+}
+
+// ExecOptions configures an ExecExecutor
+type ExecOptions struct {
+	// Timeout is the longest a command may run before it is killed, along with the
+	// processes it started; zero means no limit
+	Timeout time.Duration
+
+	// GitPath is the git binary to run instead of the git found in PATH, such as
+	// /opt/homebrew/bin/git or a wrapper script
+	GitPath string
+
+	// GlobalArgs are passed to git before every command's own arguments,
+	// such as -c core.untrackedCache=true
+	GlobalArgs []string
+}
 
 // NewExecExecutor creates a new ExecExecutor
 func NewExecExecutor() *ExecExecutor {
 	return &ExecExecutor{}
 }
 
-// handleExecutionError creates a standardized GitError from a command execution error
-func (e *ExecExecutor) handleExecutionError(operation string, args []string, err error, stderr string) error {
+// NewExecExecutorWithOptions creates an ExecExecutor configured by opts
+func NewExecExecutorWithOptions(opts ExecOptions) *ExecExecutor {
+	return &ExecExecutor{timeout: opts.Timeout, gitPath: opts.GitPath, globalArgs: opts.GlobalArgs}
+}
+
+// NewExecExecutorWithTimeout creates an ExecExecutor that kills any command running longer
+// than timeout, along with the processes it started, and fails it with a CommandTimeoutError.
+// A zero timeout means no limit. Commands attached to the terminal, such as an editor, should
+// not be run through it.
+func NewExecExecutorWithTimeout(timeout time.Duration) *ExecExecutor {
+	return &ExecExecutor{timeout: timeout}
+}
+
+// handleExecutionError creates a standardized GitError from a command execution error.
+// A command killed because cmdCtx ran out of time, rather than because ctx was canceled,
+// fails with a CommandTimeoutError instead of the signal that killed it.
+func (e *ExecExecutor) handleExecutionError(ctx, cmdCtx context.Context, operation string, args []string, err error, stderr string) error {
+	if ctx.Err() == nil && cmdCtx.Err() == context.DeadlineExceeded {
+		return gitbakErrors.NewGitError(operation, args, gitbakErrors.NewCommandTimeoutError(operation, args, e.timeout), stderr)
+	}
 	wrappedErr := gitbakErrors.Wrap(err, "git operation failed")
 	return gitbakErrors.NewGitError(operation, args, wrappedErr, stderr)
 }
 
+// command creates the command for name and args, bounded by ctx and by the executor's timeout.
+// Commands named "git" run the configured git binary with the global arguments.
+// It returns the context the command runs under, and a CancelFunc to call once it has finished.
+func (e *ExecExecutor) command(ctx context.Context, name string, args ...string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	if name == "git" {
+		if e.gitPath != "" {
+			name = e.gitPath
+		}
+		args = append(slices.Clone(e.globalArgs), args...)
+	}
+
+	cmdCtx, cancel := ctx, context.CancelFunc(func() {})
+	if e.timeout > 0 {
+		cmdCtx, cancel = context.WithTimeout(ctx, e.timeout)
+	}
+
+	cmd := exec.CommandContext(cmdCtx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	if e.timeout > 0 {
+		killProcessGroupOnCancel(cmd)
+	}
+	return cmd, cmdCtx, cancel
+}
+
 // extractCommandInfo extracts the operation name and arguments from a command
 func (e *ExecExecutor) extractCommandInfo(cmd *exec.Cmd) (string, []string) {
 	// Extract the executable
@@ -56,34 +190,42 @@ func (e *ExecExecutor) extractCommandInfo(cmd *exec.Cmd) (string, []string) {
 }
 
 // prepareCommandWithContext creates a new command with context and copies properties from the original command
-func (e *ExecExecutor) prepareCommandWithContext(ctx context.Context, cmd *exec.Cmd) *exec.Cmd {
-	cmdWithContext := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+func (e *ExecExecutor) prepareCommandWithContext(ctx context.Context, cmd *exec.Cmd) (*exec.Cmd, context.Context, context.CancelFunc) {
+	name := cmd.Path
+	if cmd.Args[0] == "git" {
+		// Resolved from PATH by exec.Command, but the executor decides which git runs
+		name = "git"
+	}
+	cmdWithContext, cmdCtx, cancel := e.command(ctx, name, cmd.Args[1:]...)
 	cmdWithContext.Stdin = cmd.Stdin
 	cmdWithContext.Env = cmd.Env
 	cmdWithContext.Dir = cmd.Dir
-	return cmdWithContext
+	return cmdWithContext, cmdCtx, cancel
 }
 
 // Execute implements CommandExecutor.Execute
 func (e *ExecExecutor) Execute(ctx context.Context, cmd *exec.Cmd) error {
-	cmdWithContext := e.prepareCommandWithContext(ctx, cmd)
+	cmdWithContext, cmdCtx, cancel := e.prepareCommandWithContext(ctx, cmd)
+	defer cancel()
 	cmdWithContext.Stdout = cmd.Stdout
 	cmdWithContext.Stderr = cmd.Stderr
 
 	err := cmdWithContext.Run()
 	if err != nil {
 		operation, args := e.extractCommandInfo(cmdWithContext)
-		return e.handleExecutionError(operation, args, err, "")
+		return e.handleExecutionError(ctx, cmdCtx, operation, args, err, "")
 	}
 	return nil
 }
 
 // ExecuteWithOutput implements CommandExecutor.ExecuteWithOutput
 func (e *ExecExecutor) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (string, error) {
-	cmdWithContext := e.prepareCommandWithContext(ctx, cmd)
+	cmdWithContext, cmdCtx, cancel := e.prepareCommandWithContext(ctx, cmd)
+	defer cancel()
 
 	// Copy existing stdout/stderr if set, otherwise create new buffers
-	var stdout, stderr bytes.Buffer
+	var stdout bytes.Buffer
+	stderr := boundedBuffer{limit: maxStderrBytes}
 	if cmd.Stdout != nil {
 		cmdWithContext.Stdout = cmd.Stdout
 	} else {
@@ -99,7 +241,7 @@ func (e *ExecExecutor) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (st
 	err := cmdWithContext.Run()
 	if err != nil {
 		operation, args := e.extractCommandInfo(cmdWithContext)
-		return "", e.handleExecutionError(operation, args, err, stderr.String())
+		return "", e.handleExecutionError(ctx, cmdCtx, operation, args, err, stderr.String())
 	}
 
 	return stdout.String(), nil
@@ -107,26 +249,33 @@ func (e *ExecExecutor) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (st
 
 // ExecuteWithContext implements CommandExecutor.ExecuteWithContext
 func (e *ExecExecutor) ExecuteWithContext(ctx context.Context, name string, args ...string) error {
-	cmd := exec.CommandContext(ctx, name, args...)
+	cmd, cmdCtx, cancel := e.command(ctx, name, args...)
+	defer cancel()
+
+	// Kept for the error, which is classified by what git printed
+	stderr := boundedBuffer{limit: maxStderrBytes}
+	cmd.Stderr = &stderr
 
 	err := cmd.Run()
 	if err != nil {
-		return e.handleExecutionError(name, args, err, "")
+		return e.handleExecutionError(ctx, cmdCtx, name, args, err, stderr.String())
 	}
 	return nil
 }
 
 // ExecuteWithContextAndOutput implements CommandExecutor.ExecuteWithContextAndOutput
 func (e *ExecExecutor) ExecuteWithContextAndOutput(ctx context.Context, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
+	cmd, cmdCtx, cancel := e.command(ctx, name, args...)
+	defer cancel()
 
-	var stdout, stderr bytes.Buffer
+	var stdout bytes.Buffer
+	stderr := boundedBuffer{limit: maxStderrBytes}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	err := cmd.Run()
 	if err != nil {
-		return "", e.handleExecutionError(name, args, err, stderr.String())
+		return "", e.handleExecutionError(ctx, cmdCtx, name, args, err, stderr.String())
 	}
 
 	return stdout.String(), nil
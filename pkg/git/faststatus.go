@@ -0,0 +1,76 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// Untracked file modes, selecting how git status looks for new files (--untracked-files)
+const (
+	// UntrackedNormal reports new files, and directories holding only new files as a whole.
+	UntrackedNormal = "normal"
+
+	// UntrackedAll reports every new file, even inside new directories.
+	UntrackedAll = "all"
+
+	// UntrackedNo does not look for new files, which is quickest in large trees. New files
+	// alone do not trigger a checkpoint, but they are included once other changes do.
+	UntrackedNo = "no"
+)
+
+// UntrackedModes lists the accepted values of GitbakConfig.UntrackedFiles
+var UntrackedModes = []string{UntrackedNormal, UntrackedAll, UntrackedNo}
+
+// setupFastStatus works out the git settings that speed up change checks when FastStatus
+// is set: the untracked cache, and the built-in file system monitor where git supports
+// it. Settings the repository or the user already configured are left as they are.
+func (g *Gitbak) setupFastStatus(ctx context.Context) {
+	if !g.config.FastStatus || g.config.Backend == BackendGoGit {
+		return
+	}
+
+	var enabled []string
+	if _, set := g.gitConfigValue(ctx, "core.untrackedCache"); !set {
+		g.statusConfig = append(g.statusConfig, "-c", "core.untrackedCache=true")
+		enabled = append(enabled, "untracked cache")
+	}
+
+	if value, set := g.gitConfigValue(ctx, "core.fsmonitor"); set {
+		// A hook such as Watchman's, or the daemon, as configured for the repository
+		g.fsmonitor = value != "false"
+	} else if g.fsmonitorSupported(ctx) {
+		g.statusConfig = append(g.statusConfig, "-c", "core.fsmonitor=true")
+		g.fsmonitor = true
+		enabled = append(enabled, "file system monitor")
+	}
+
+	if len(enabled) > 0 {
+		g.logger.Info("Checking for changes with git's %s", strings.Join(enabled, " and "))
+	}
+}
+
+// gitConfigValue returns the value of a git configuration key, and whether it is set
+func (g *Gitbak) gitConfigValue(ctx context.Context, key string) (string, bool) {
+	out, err := g.runGitCommandWithOutput(ctx, "config", "--get", key)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// fsmonitorSupported reports whether git has a built-in file system monitor for the
+// repository. The daemon reports whether it is watching the repository where it is
+// supported, and fails with "not supported" on other platforms and file systems.
+func (g *Gitbak) fsmonitorSupported(ctx context.Context) bool {
+	out, _ := g.runGitCommandWithOutput(ctx, "fsmonitor--daemon", "status")
+	return strings.Contains(out, "watching")
+}
+
+// statusArgs returns the arguments of git status --porcelain, as used to look for changes
+func (g *Gitbak) statusArgs() []string {
+	args := append(append([]string{}, g.statusConfig...), "status", "--porcelain")
+	if g.config.UntrackedFiles != "" && g.config.Backend != BackendGoGit {
+		args = append(args, "--untracked-files="+g.config.UntrackedFiles)
+	}
+	return append(args, g.submoduleStatusArgs()...)
+}
@@ -0,0 +1,143 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestSetupFastStatus tests which git settings FastStatus adds to change checks
+func TestSetupFastStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fastStatus      bool
+		repoConfig      map[string]string
+		expectCache     bool
+		expectFSMonitor bool
+	}{
+		"Disabled": {},
+		"Enabled": {
+			fastStatus:  true,
+			expectCache: true,
+		},
+		"RepoUntrackedCache": {
+			fastStatus: true,
+			repoConfig: map[string]string{"core.untrackedCache": "false"},
+		},
+		"RepoFSMonitorHook": {
+			fastStatus:      true,
+			repoConfig:      map[string]string{"core.fsmonitor": ".git/hooks/fsmonitor-watchman"},
+			expectCache:     true,
+			expectFSMonitor: true,
+		},
+		"RepoFSMonitorOff": {
+			fastStatus:  true,
+			repoConfig:  map[string]string{"core.fsmonitor": "false"},
+			expectCache: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			for key, value := range test.repoConfig {
+				gitOutput(t, repoPath, "config", key, value)
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:     repoPath,
+				Interval:     time.Minute,
+				BranchName:   "gitbak-fast-status",
+				CommitPrefix: "[gitbak] Commit",
+				FastStatus:   test.fastStatus,
+			}, logger.New(false, "", false))
+			gb.setupFastStatus(context.Background())
+
+			settings := strings.Join(gb.statusConfig, " ")
+			if cache := strings.Contains(settings, "core.untrackedCache=true"); cache != test.expectCache {
+				t.Errorf("Expected the untracked cache to be enabled: %v, got settings %q", test.expectCache, settings)
+			}
+			if _, set := test.repoConfig["core.fsmonitor"]; set && strings.Contains(settings, "core.fsmonitor") {
+				t.Errorf("Expected the repository's file system monitor to be left alone, got settings %q", settings)
+			}
+			if set := test.repoConfig["core.fsmonitor"] != ""; set && gb.fsmonitor != test.expectFSMonitor {
+				t.Errorf("Expected a file system monitor: %v, got %v", test.expectFSMonitor, gb.fsmonitor)
+			}
+			if !test.fastStatus && (gb.fsmonitor || settings != "") {
+				t.Errorf("Expected no settings without FastStatus, got %q (fsmonitor %v)", settings, gb.fsmonitor)
+			}
+		})
+	}
+}
+
+// TestHasChangesStatusOptions tests that change checks honor UntrackedFiles, and that the
+// quick check made with a file system monitor still leaves excluded paths out
+func TestHasChangesStatusOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		untracked string
+		fsmonitor bool
+		newFile   string
+		expected  bool
+	}{
+		"NewFile":                   {newFile: "notes.txt", expected: true},
+		"NewFileUntrackedNo":        {untracked: UntrackedNo, newFile: "notes.txt"},
+		"NewFileUntrackedAll":       {untracked: UntrackedAll, newFile: "docs/notes.txt", expected: true},
+		"FSMonitorClean":            {fsmonitor: true},
+		"FSMonitorChange":           {fsmonitor: true, newFile: "notes.txt", expected: true},
+		"FSMonitorExcludedChange":   {fsmonitor: true, newFile: "build.log"},
+		"NoFSMonitorExcludedChange": {newFile: "build.log"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			if err := os.WriteFile(filepath.Join(repoPath, BakignoreFile), []byte("*.log\n"), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", BakignoreFile, err)
+			}
+			gitOutput(t, repoPath, "add", BakignoreFile)
+			gitOutput(t, repoPath, "commit", "-m", "Add "+BakignoreFile)
+
+			if test.newFile != "" {
+				path := filepath.Join(repoPath, filepath.FromSlash(test.newFile))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("new\n"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-status",
+				CommitPrefix:   "[gitbak] Commit",
+				UntrackedFiles: test.untracked,
+			}, logger.New(false, "", false))
+			// The monitor itself is not available everywhere; the quick check is what matters
+			gb.fsmonitor = test.fsmonitor
+
+			changed, err := gb.hasUncommittedChanges(context.Background())
+			if err != nil {
+				t.Fatalf("hasUncommittedChanges failed: %v", err)
+			}
+			if changed != test.expected {
+				t.Errorf("Expected changes: %v, got %v", test.expected, changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// bytesPerMB is the size of the megabytes MaxFileSizeMB is given in
+const bytesPerMB = 1 << 20
+
+// oversizedPaths lists the changed files, tracked or untracked, that are larger than
+// MaxFileSizeMB and so are left out of checkpoints. Paths already covered by excluded
+// are not considered. It returns nothing when MaxFileSizeMB is not set.
+func (g *Gitbak) oversizedPaths(ctx context.Context, excluded []string) ([]string, error) {
+	files, err := g.oversizedFiles(ctx, excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, file := range files {
+		if g.skipOversized(file.path, file.size) {
+			paths = append(paths, file.path)
+		}
+	}
+	return paths, nil
+}
+
+// oversizedFile is a changed file over MaxFileSizeMB
+type oversizedFile struct {
+	path string
+	size int64
+}
+
+// oversizedFiles lists the changed files, tracked or untracked, that are larger than
+// MaxFileSizeMB, whether or not they are to be left out. Paths already covered by
+// excluded are not considered. It returns nothing when MaxFileSizeMB is not set.
+func (g *Gitbak) oversizedFiles(ctx context.Context, excluded []string) ([]oversizedFile, error) {
+	if g.config.MaxFileSizeMB <= 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"ls-files", "-z", "--modified", "--others", "--exclude-standard"}, g.scopeArgs()...)
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{"--modified", "--others"},
+			gitbakErrors.Wrap(err, "failed to list changed files"), "")
+	}
+
+	limit := int64(g.config.MaxFileSizeMB) * bytesPerMB
+	seen := make(map[string]bool)
+	var files []oversizedFile
+	for _, path := range strings.Split(out, "\x00") {
+		if path == "" || seen[path] || isExcluded(path, excluded) {
+			continue
+		}
+		seen[path] = true
+
+		// Deleted files, symlinks and submodules have nothing large to commit
+		info, err := os.Lstat(filepath.Join(g.config.RepoPath, path))
+		if err != nil || !info.Mode().IsRegular() || info.Size() <= limit {
+			continue
+		}
+		files = append(files, oversizedFile{path: path, size: info.Size()})
+	}
+	return files, nil
+}
+
+// skipOversized decides whether the file at path, which is over MaxFileSizeMB, is left out
+// of checkpoints. Interactive sessions ask the user; others always leave it out. Either way
+// the decision is made once per path, so neither the question nor the warning repeats.
+func (g *Gitbak) skipOversized(path string, size int64) bool {
+	if skip, decided := g.oversized[path]; decided {
+		return skip
+	}
+
+	sizeMB := float64(size) / bytesPerMB
+	skip := true
+	if !g.config.NonInteractive {
+		skip = !g.promptYesNo(fmt.Sprintf("%s is %.1f MB, over the %d MB limit. Include it in checkpoints anyway?",
+			path, sizeMB, g.config.MaxFileSizeMB))
+	}
+	if skip {
+		g.logger.WarningToUser("Leaving %s (%.1f MB) out of checkpoints: it is over the %d MB limit of -max-file-size. "+
+			"Add it to .gitignore or %s to silence this.", path, sizeMB, g.config.MaxFileSizeMB, BakignoreFile)
+	}
+
+	if g.oversized == nil {
+		g.oversized = make(map[string]bool)
+	}
+	g.oversized[path] = skip
+	return skip
+}
+
+// isExcluded reports whether path is one of excluded, or inside an excluded directory,
+// which is listed with a trailing slash
+func isExcluded(path string, excluded []string) bool {
+	for _, e := range excluded {
+		if path == e || (strings.HasSuffix(e, "/") && strings.HasPrefix(path, e)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestMaxFileSize tests that changed files over MaxFileSizeMB are left out of checkpoints
+func TestMaxFileSize(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		nonInteractive bool
+		response       bool
+		tracked        bool
+		withoutSmall   bool
+		expectPrompt   bool
+		expectIncluded bool
+	}{
+		"NonInteractive": {
+			nonInteractive: true,
+		},
+		"InteractiveDeclined": {
+			expectPrompt: true,
+		},
+		"InteractiveAccepted": {
+			response:       true,
+			expectPrompt:   true,
+			expectIncluded: true,
+		},
+		"TrackedFileGrows": {
+			nonInteractive: true,
+			tracked:        true,
+		},
+		"OnlyLargeChanges": {
+			nonInteractive: true,
+			withoutSmall:   true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			large := filepath.Join(repoPath, "dump.sql")
+			if test.tracked {
+				if err := os.WriteFile(large, []byte("small for now\n"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+				gitOutput(t, repoPath, "add", "dump.sql")
+				gitOutput(t, repoPath, "commit", "-m", "Add dump")
+			}
+			if err := os.WriteFile(large, bytes.Repeat([]byte("x"), 2*bytesPerMB), 0644); err != nil {
+				t.Fatalf("Failed to write large file: %v", err)
+			}
+			if !test.withoutSmall {
+				if err := os.WriteFile(filepath.Join(repoPath, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			}
+
+			base := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+			var buf bytes.Buffer
+			log := logger.NewWithOutput(false, "", true, &buf, &buf)
+			interactor := NewMockInteractor(test.response)
+			gb, err := NewGitbakWithDeps(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-size",
+				CommitPrefix:   "[gitbak-size] Commit",
+				CreateBranch:   false,
+				NonInteractive: test.nonInteractive,
+				MaxFileSizeMB:  1,
+			}, log, NewExecutor(""), interactor)
+			if err != nil {
+				t.Fatalf("NewGitbakWithDeps failed: %v", err)
+			}
+
+			ctx := context.Background()
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if expectCommit := !test.withoutSmall || test.expectIncluded; commitWasCreated != expectCommit {
+				t.Fatalf("Expected a checkpoint to be created: %v, got %v", expectCommit, commitWasCreated)
+			}
+			if interactor.PromptYesNoCalled != test.expectPrompt {
+				t.Errorf("Expected a prompt: %v, got %v", test.expectPrompt, interactor.PromptYesNoCalled)
+			}
+
+			// Later checks neither ask again nor repeat the warning
+			interactor.PromptYesNoCalled = false
+			if err := os.WriteFile(filepath.Join(repoPath, "more.txt"), []byte("more\n"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			if err := gb.checkAndCommitChanges(ctx, 2, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if interactor.PromptYesNoCalled {
+				t.Error("Expected no second prompt about the same file")
+			}
+			expectedWarnings := 1
+			if test.expectIncluded {
+				expectedWarnings = 0
+			}
+			if warnings := strings.Count(buf.String(), "Leaving dump.sql"); warnings != expectedWarnings {
+				t.Errorf("Expected the warning %d times, got %d", expectedWarnings, warnings)
+			}
+
+			changed := gitOutput(t, repoPath, "diff", "--name-only", base, "HEAD")
+			if included := strings.Contains(changed, "dump.sql"); included != test.expectIncluded {
+				t.Errorf("Expected the checkpoints to include the large file: %v, got %v (they changed %q)", test.expectIncluded, included, changed)
+			}
+			if _, err := os.Stat(large); err != nil {
+				t.Errorf("Expected the large file to stay in the working tree: %v", err)
+			}
+		})
+	}
+}
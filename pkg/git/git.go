@@ -3,14 +3,21 @@ package git
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/metrics"
+	"github.com/bashhack/gitbak/pkg/session"
+	"github.com/bashhack/gitbak/pkg/tracing"
 )
 
 // GitbakConfig contains configuration for a gitbak instance.
@@ -22,10 +29,28 @@ type GitbakConfig struct {
 	// Can be absolute or relative path. If empty, validation will fail.
 	RepoPath string
 
-	// IntervalMinutes defines how often (in minutes) gitbak checks for changes.
-	// This can be a fractional value (e.g. 0.5 for 30 seconds).
+	// Interval defines how often gitbak checks for changes.
 	// Must be greater than 0.
-	IntervalMinutes float64
+	Interval time.Duration
+
+	// MinInterval and MaxInterval let the interval adapt to activity: it shrinks towards
+	// MinInterval while checkpoints are made on check after check, and grows towards
+	// MaxInterval while checks find nothing to commit. Zero keeps the interval fixed in
+	// that direction. If set, MinInterval must not exceed Interval, and MaxInterval must
+	// not be below it.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+
+	// IdleAfter, if set, is how many checks in a row must find no changes before gitbak goes
+	// idle: it then checks only every IdleInterval, and says so once rather than at every
+	// check, until a check finds changes. IdleInterval must be at least Interval.
+	IdleAfter    int
+	IdleInterval time.Duration
+
+	// AlignMarks, if set, moves each scheduled check on to the next of these offsets into the
+	// hour, e.g. 0 and 30 minutes for checks on the hour and half hour, so that checkpoints
+	// line up with the wall clock. Each must be within the hour.
+	AlignMarks []time.Duration
 
 	// BranchName specifies the Git branch to use for checkpoint commits.
 	// If CreateBranch is true, this branch will be created.
@@ -37,6 +62,72 @@ type GitbakConfig struct {
 	// Used to identify gitbak commits and extract commit numbers.
 	CommitPrefix string
 
+	// CommitAuthor and CommitEmail, if set, attribute checkpoints to this identity as both
+	// author and committer instead of the configured git user. They must be set together,
+	// must not contain angle brackets or newlines, and cannot be combined with BackendGoGit.
+	CommitAuthor string
+	CommitEmail  string
+
+	// CoAuthors credits each of these identities, given as "Name <email>", with a
+	// Co-authored-by trailer on every checkpoint, e.g. for the other half of a pair.
+	CoAuthors []string
+
+	// DiffSummary adds the files each checkpoint commit changes, with their line counts
+	// as reported by git diff --stat, to its commit message body.
+	DiffSummary bool
+
+	// NoVerify makes the commits gitbak creates bypass the repository's pre-commit and
+	// commit-msg hooks, so that a slow or failing hook can't block or alter checkpoints.
+	NoVerify bool
+
+	// MinChangedLines and MinChangedFiles hold back checkpoints of trivially small changes:
+	// changes are left to accumulate until they add or remove at least MinChangedLines lines,
+	// or touch at least MinChangedFiles files. Zero disables that threshold. After
+	// MaxSkippedChecks checks in a row that held changes back, they are committed anyway;
+	// zero holds them back indefinitely. None may be negative, and the thresholds cannot be
+	// combined with ModeStash, ModeRefs or BackendGoGit.
+	MinChangedLines  int
+	MinChangedFiles  int
+	MaxSkippedChecks int
+
+	// MinQuietSeconds, if positive, holds back a checkpoint until the working tree has been
+	// stable for that many seconds: until no changed file has been modified, nor a change
+	// reported on Changes, for that long. A burst of changes, such as a build writing its
+	// output, then makes one consistent checkpoint instead of capturing it half-written.
+	// It must not be negative and cannot be combined with ModeStash, ModeRefs or ModeObserve.
+	MinQuietSeconds float64
+
+	// CheckCommand, if set, is a shell command run in RepoPath before each checkpoint, such
+	// as a build or a quick test run. Checkpoints record whether it passed with a marker on
+	// their subject and a CheckTrailer, and OnCheckFail chooses what a failure does. It
+	// cannot be combined with ModeStash, ModeRefs or ModeObserve.
+	CheckCommand string
+
+	// OnCheckFail selects what happens to a checkpoint when CheckCommand fails: one of
+	// CheckFailModes, or CheckFailMark if empty.
+	OnCheckFail string
+
+	// MaxFileSizeMB, if set, leaves changed files larger than this many megabytes out of
+	// checkpoints, warning about each once, or asking about each once unless NonInteractive
+	// is set. It must not be negative and cannot be combined with BackendGoGit.
+	MaxFileSizeMB int
+
+	// UntrackedPolicy selects whether new files are taken into checkpoints: one of
+	// UntrackedPolicies, or empty for UntrackedPolicyAll. It cannot be combined with
+	// BackendGoGit unless it is UntrackedPolicyAll.
+	UntrackedPolicy string
+
+	// Paths, if set, scopes checkpoints to these files and directories, slash-separated and
+	// relative to RepoPath: only changes under them are detected and staged. It cannot be
+	// combined with BackendGoGit.
+	Paths []string
+
+	// Secrets selects what happens to changes that look like they hold credentials, such as
+	// private keys, access tokens or .env files: SecretsSkip leaves those files out, asking
+	// about each once unless NonInteractive is set, and SecretsAbort holds back checkpoints
+	// until they are gone. Empty or SecretsOff commits them unscanned. Scanning excludes BackendGoGit.
+	Secrets string
+
 	// CreateBranch determines whether to create a new branch or use existing one.
 	// If true, a new branch named BranchName will be created.
 	// If false, gitbak will use the existing branch specified by BranchName.
@@ -66,6 +157,172 @@ type GitbakConfig struct {
 	// If zero, gitbak will retry indefinitely.
 	// Errors of different types or successful operations reset this counter.
 	MaxRetries int
+
+	// OpTimeout bounds each check-and-commit cycle and each push attempt. When it is
+	// exceeded, the git command is killed and the check fails with ErrOperationTimeout,
+	// to be retried at the next tick. If zero, operations are not bounded. Must not be negative.
+	OpTimeout time.Duration
+
+	// CommandTimeout bounds each git command run by the exec backend. A command still running
+	// when it expires is killed together with the processes it started, such as hooks, and
+	// fails with a CommandTimeoutError. If zero, commands are only bounded by OpTimeout.
+	// Must not be negative.
+	CommandTimeout time.Duration
+
+	// RetryBackoff is the minimum wait after a failed check before the next attempt. It
+	// doubles with every repeat of the same error, up to RetryBackoffMax, and is randomly
+	// varied by up to 20%. Checks due before then are skipped, so that short intervals,
+	// nudges or file changes cannot exhaust MaxRetries within seconds. If zero, failed
+	// checks are retried at the next opportunity. Neither may be negative.
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+
+	// Mode selects where checkpoints are recorded: ModeBranch (the default if empty)
+	// commits them, ModeStash stores them as stash entries without touching any branch,
+	// ModeRefs writes them to refs under RefsPrefix, also leaving every branch alone, and
+	// ModeObserve only journals the changes to JournalFile. ModeStash, ModeRefs and
+	// ModeObserve cannot be combined with ContinueSession, ChainTrailer or Push, which all
+	// act on checkpoint commits in a branch.
+	Mode string
+
+	// JournalFile is where ModeObserve appends a JournalEntry, as a line of JSON, for every
+	// check that finds files changed since the previous one. ModeObserve requires it.
+	JournalFile string
+
+	// Backend selects how git commands are carried out: BackendExec (the default if empty)
+	// runs the git binary, BackendGoGit uses go-git. BackendGoGit cannot be combined with
+	// Push, ModeStash, ModeRefs or DiffSummary, which rely on git commands go-git does not provide.
+	Backend string
+
+	// UntrackedFiles selects how change checks look for new files: one of UntrackedModes,
+	// or empty for git's own setting. BackendGoGit only supports UntrackedNormal.
+	UntrackedFiles string
+
+	// FastStatus speeds up change checks in large repositories by having git status use
+	// the untracked cache and, where git supports it, the built-in file system monitor,
+	// unless the repository configures them itself. Ignored by BackendGoGit.
+	FastStatus bool
+
+	// GitPath is the git binary the exec backend runs; if empty, git is found in PATH.
+	GitPath string
+
+	// GitGlobalArgs are passed to git by the exec backend before the arguments of every
+	// command, such as []string{"-c", "core.untrackedCache=true"}.
+	GitGlobalArgs []string
+
+	// SeparateGitDir reports that GitGlobalArgs name the git directory (--git-dir), which
+	// cannot then be found from RepoPath, so the session cannot follow the repository if
+	// it moves.
+	SeparateGitDir bool
+
+	// EmptyRepo selects how a repository without commits is handled:
+	// EmptyRepoInitialCommit (the default if empty), EmptyRepoRootCheckpoint or EmptyRepoFail.
+	EmptyRepo string
+
+	// Submodules selects how checkpoints treat submodules: SubmodulesInclude (the default
+	// if empty), SubmodulesIgnore or SubmodulesRecursive. Anything but SubmodulesInclude
+	// is left to the git binary, and SubmodulesRecursive commits inside submodules, so it
+	// cannot be combined with ModeStash or ModeRefs.
+	Submodules string
+
+	// StateFile is where session state is persisted so that follow-up commands
+	// (such as abort) can act on the session after gitbak exits.
+	// If empty, no session state is written.
+	StateFile string
+
+	// SummaryFile is where PrintSummary also writes a session report, as JSON if the
+	// name ends in .json and as Markdown otherwise. If empty, no report is written.
+	SummaryFile string
+
+	// SummaryJSON, if set, receives the session report as a single line of JSON in place
+	// of the summary PrintSummary otherwise prints, for scripts and dashboards to read.
+	SummaryJSON io.Writer
+
+	// ChainTrailer adds a Gitbak-Chain trailer with the previous checkpoint's
+	// integrity hash to every checkpoint commit. Requires StateFile.
+	ChainTrailer bool
+
+	// Push is the remote that the session branch is pushed to after checkpoints,
+	// so that they survive losing the machine. If empty, nothing is pushed.
+	Push string
+
+	// PushInterval is the minimum time between pushes.
+	// If zero, the branch is pushed after every checkpoint. Must not be negative.
+	PushInterval time.Duration
+
+	// Nudges, if set, requests early change checks between intervals (e.g. from editor save hooks).
+	// Nudges arriving in quick succession are debounced into a single check.
+	Nudges <-chan struct{}
+
+	// Changes, if set, switches gitbak to watch mode: it receives a value when files
+	// in the working tree change, and checks happen shortly after changes (debounced
+	// like Nudges) instead of on every interval. Interval ticks then only check
+	// when changes were reported since the last check.
+	Changes <-chan struct{}
+
+	// CheckNow, if set, requests an immediate check, without the debounce applied to
+	// Nudges. Such checks run even while checkpointing is paused.
+	CheckNow <-chan struct{}
+
+	// OnMoved, if set, is called with the repository's new path after the session has
+	// followed the repository to where its directory was moved or renamed.
+	OnMoved func(repoPath string)
+
+	// ControlAddr is the address of the control endpoint serving CheckNow and Paused, if any.
+	// It is recorded in the session state for clients to find.
+	ControlAddr string
+
+	// Paused, if set, reports whether checkpointing is paused. Scheduled, nudged and
+	// watch-triggered checks are skipped while it returns true, as they are while the
+	// repository's PauseFile exists.
+	Paused func() bool
+
+	// OnDiverge selects what happens when the session branch's history is rewritten under
+	// the session, such as by a rebase or reset: one of DivergeModes, or DivergeWarn if empty.
+	// DivergePause needs Pause.
+	OnDiverge string
+
+	// Pause, if set, pauses checkpointing until the user resumes it, so that Paused reports true.
+	Pause func()
+
+	// LowPower, if set, reports whether the machine is short on power, such as on a laptop
+	// running on battery. While it returns true, scheduled, nudged and watch-triggered checks
+	// are spaced at least LowPowerInterval apart, or skipped if that is zero.
+	// LowPowerInterval must not be negative.
+	LowPower         func() bool
+	LowPowerInterval time.Duration
+
+	// OnCheckpoint, if set, is called after each checkpoint, on the goroutine running Run.
+	// It must not block; mirroring uses it to schedule pushes.
+	OnCheckpoint func(Checkpoint)
+
+	// OnStart, if set, is called once Run has set up the session, with the branch that
+	// checkpoints are committed to, or an empty string in ModeStash, ModeRefs and ModeObserve.
+	// It must not block.
+	OnStart func(branch string)
+
+	// OnCheckFailed, if set, is called after every failed check with its error and the
+	// number of checks in a row that have failed. It must not block.
+	OnCheckFailed func(err error, consecutiveErrors int)
+
+	// LogFile is the path of the debug log file, if debug logging is enabled.
+	// It is only used to point users at the log in the session summary.
+	LogFile string
+
+	// Metrics, if set, records every check and checkpoint for the metrics endpoint
+	Metrics *metrics.Collector
+
+	// Tracer, if set, records a span for every check and every git command it runs
+	Tracer *tracing.Tracer
+
+	// LogCommands traces every git command run, with how long it took, through
+	// the logger's Trace method
+	LogCommands bool
+
+	// IsDisabled reports whether checkpointing has been disabled externally
+	// (e.g. via the GITBAK_DISABLE kill switch). It is consulted before each check.
+	// If nil, the kill switch is not consulted.
+	IsDisabled func() bool
 }
 
 // Validate sanity-checks the config and returns an error if something is wrong.
@@ -74,18 +331,61 @@ type GitbakConfig struct {
 //
 // The following validations are performed:
 //   - RepoPath must not be empty
-//   - IntervalMinutes must be greater than 0
+//   - Interval must be greater than 0
+//   - MinInterval and MaxInterval must not be negative and, if set, must bound Interval
+//   - IdleAfter must not be negative and, if set, IdleInterval must be at least Interval
+//   - AlignMarks must lie within the hour
 //   - BranchName must not be empty
 //   - CommitPrefix must not be empty
 //   - MaxRetries must not be negative
+//   - PushInterval must not be negative
+//   - LowPowerInterval must not be negative
+//   - MinChangedLines, MinChangedFiles and MaxSkippedChecks must not be negative
+//   - MinQuietSeconds must not be negative, and cannot be combined with ModeStash, ModeRefs or ModeObserve
+//   - CheckCommand cannot be combined with ModeStash, ModeRefs or ModeObserve
+//   - OnCheckFail must be empty or one of CheckFailModes
+//   - MaxFileSizeMB must not be negative, and excludes BackendGoGit
+//   - Paths must be relative paths inside RepoPath, and excludes BackendGoGit
+//   - Secrets must be empty or one of SecretModes, and scanning excludes BackendGoGit
+//   - UntrackedFiles must be empty or one of UntrackedModes, and only UntrackedNormal works with BackendGoGit
+//   - UntrackedPolicy must be empty or one of UntrackedPolicies, and only UntrackedPolicyAll works with BackendGoGit
+//   - OpTimeout and CommandTimeout must not be negative
+//   - RetryBackoff and RetryBackoffMax must not be negative
+//   - EmptyRepo must be empty or one of EmptyRepoModes
+//   - Mode must be empty or one of Modes, and ModeStash, ModeRefs and ModeObserve exclude the branch-only options
+//   - ModeObserve requires JournalFile
+//   - Backend must be empty or one of Backends, and BackendGoGit excludes Push, ModeStash, ModeRefs, ModeObserve and DiffSummary
+//   - The change thresholds exclude ModeStash, ModeRefs and BackendGoGit
+//   - OnDiverge must be empty or one of DivergeModes, and DivergePause requires Pause
+//   - Submodules must be empty or one of SubmoduleModes, and only SubmodulesInclude suits BackendGoGit
+//   - SubmodulesRecursive excludes ModeStash and ModeRefs
+//   - CommitAuthor and CommitEmail must be set together, as a valid identity, and exclude BackendGoGit
+//   - CoAuthors must each be a "Name <email>" identity
 //
 // Returns nil if the configuration is valid, or an error describing the issue.
 func (c *GitbakConfig) Validate() error {
 	if c.RepoPath == "" {
 		return fmt.Errorf("RepoPath must not be empty")
 	}
-	if c.IntervalMinutes <= 0 {
-		return fmt.Errorf("IntervalMinutes must be > 0 (got %.2f)", c.IntervalMinutes)
+	if c.Interval <= 0 {
+		return fmt.Errorf("Interval must be > 0 (got %s)", c.Interval)
+	}
+	if c.MinInterval < 0 || c.MinInterval > c.Interval {
+		return fmt.Errorf("MinInterval must be between 0 and Interval (got %s)", c.MinInterval)
+	}
+	if c.MaxInterval < 0 || (c.MaxInterval > 0 && c.MaxInterval < c.Interval) {
+		return fmt.Errorf("MaxInterval must be 0 or at least Interval (got %s)", c.MaxInterval)
+	}
+	if c.IdleAfter < 0 {
+		return fmt.Errorf("IdleAfter cannot be negative (got %d)", c.IdleAfter)
+	}
+	if c.IdleAfter > 0 && c.IdleInterval < c.Interval {
+		return fmt.Errorf("IdleInterval must be at least Interval when IdleAfter is set (got %s)", c.IdleInterval)
+	}
+	for _, mark := range c.AlignMarks {
+		if mark < 0 || mark >= time.Hour {
+			return fmt.Errorf("AlignMarks must lie within the hour (got %s)", mark)
+		}
 	}
 	if c.BranchName == "" {
 		return fmt.Errorf("BranchName must not be empty")
@@ -96,6 +396,119 @@ func (c *GitbakConfig) Validate() error {
 	if c.MaxRetries < 0 {
 		return fmt.Errorf("MaxRetries cannot be negative (got %d)", c.MaxRetries)
 	}
+	if c.PushInterval < 0 {
+		return fmt.Errorf("PushInterval cannot be negative (got %s)", c.PushInterval)
+	}
+	if c.MinChangedLines < 0 || c.MinChangedFiles < 0 || c.MaxSkippedChecks < 0 {
+		return fmt.Errorf("MinChangedLines, MinChangedFiles and MaxSkippedChecks cannot be negative (got %d, %d and %d)",
+			c.MinChangedLines, c.MinChangedFiles, c.MaxSkippedChecks)
+	}
+	if c.LowPowerInterval < 0 {
+		return fmt.Errorf("LowPowerInterval cannot be negative (got %s)", c.LowPowerInterval)
+	}
+	if c.OpTimeout < 0 {
+		return fmt.Errorf("OpTimeout cannot be negative (got %s)", c.OpTimeout)
+	}
+	if c.CommandTimeout < 0 {
+		return fmt.Errorf("CommandTimeout cannot be negative (got %s)", c.CommandTimeout)
+	}
+	if c.RetryBackoff < 0 || c.RetryBackoffMax < 0 {
+		return fmt.Errorf("RetryBackoff and RetryBackoffMax cannot be negative (got %s and %s)", c.RetryBackoff, c.RetryBackoffMax)
+	}
+	if c.EmptyRepo != "" && !slices.Contains(EmptyRepoModes, c.EmptyRepo) {
+		return fmt.Errorf("EmptyRepo must be one of %s (got %q)", strings.Join(EmptyRepoModes, ", "), c.EmptyRepo)
+	}
+	if c.Mode != "" && !slices.Contains(Modes, c.Mode) {
+		return fmt.Errorf("Mode must be one of %s (got %q)", strings.Join(Modes, ", "), c.Mode)
+	}
+	if (c.Mode == ModeStash || c.Mode == ModeRefs || c.Mode == ModeObserve) && (c.ContinueSession || c.ChainTrailer || c.Push != "") {
+		return fmt.Errorf("Mode %q cannot be combined with ContinueSession, ChainTrailer or Push", c.Mode)
+	}
+	if c.Mode == ModeObserve && c.JournalFile == "" {
+		return fmt.Errorf("Mode %q requires JournalFile", ModeObserve)
+	}
+	if c.Backend != "" && !slices.Contains(Backends, c.Backend) {
+		return fmt.Errorf("Backend must be one of %s (got %q)", strings.Join(Backends, ", "), c.Backend)
+	}
+	if c.Backend == BackendGoGit && (c.Push != "" || c.Mode == ModeStash || c.Mode == ModeRefs || c.Mode == ModeObserve || c.DiffSummary) {
+		return fmt.Errorf("Backend %q cannot be combined with Push, Mode %q, %q or %q, or DiffSummary", BackendGoGit, ModeStash, ModeRefs, ModeObserve)
+	}
+	if (c.MinChangedLines > 0 || c.MinChangedFiles > 0) && (c.Mode == ModeStash || c.Mode == ModeRefs || c.Backend == BackendGoGit) {
+		return fmt.Errorf("MinChangedLines and MinChangedFiles cannot be combined with Mode %q or %q, or Backend %q", ModeStash, ModeRefs, BackendGoGit)
+	}
+	if c.MinQuietSeconds < 0 {
+		return fmt.Errorf("MinQuietSeconds cannot be negative (got %.2f)", c.MinQuietSeconds)
+	}
+	if c.MinQuietSeconds > 0 && (c.Mode == ModeStash || c.Mode == ModeRefs || c.Mode == ModeObserve) {
+		return fmt.Errorf("MinQuietSeconds cannot be combined with Mode %q, %q or %q", ModeStash, ModeRefs, ModeObserve)
+	}
+	if c.CheckCommand != "" && (c.Mode == ModeStash || c.Mode == ModeRefs || c.Mode == ModeObserve) {
+		return fmt.Errorf("CheckCommand cannot be combined with Mode %q, %q or %q", ModeStash, ModeRefs, ModeObserve)
+	}
+	if c.OnCheckFail != "" && !slices.Contains(CheckFailModes, c.OnCheckFail) {
+		return fmt.Errorf("OnCheckFail must be one of %s (got %q)", strings.Join(CheckFailModes, ", "), c.OnCheckFail)
+	}
+	if c.MaxFileSizeMB < 0 {
+		return fmt.Errorf("MaxFileSizeMB cannot be negative (got %d)", c.MaxFileSizeMB)
+	}
+	if c.MaxFileSizeMB > 0 && c.Backend == BackendGoGit {
+		return fmt.Errorf("MaxFileSizeMB cannot be combined with Backend %q", BackendGoGit)
+	}
+	for _, path := range c.Paths {
+		if !filepath.IsLocal(filepath.FromSlash(path)) {
+			return fmt.Errorf("Paths must be relative paths inside RepoPath (got %q)", path)
+		}
+	}
+	if len(c.Paths) > 0 && c.Backend == BackendGoGit {
+		return fmt.Errorf("Paths cannot be combined with Backend %q", BackendGoGit)
+	}
+	if c.Secrets != "" && !slices.Contains(SecretModes, c.Secrets) {
+		return fmt.Errorf("Secrets must be one of %s (got %q)", strings.Join(SecretModes, ", "), c.Secrets)
+	}
+	if c.scanSecrets() && c.Backend == BackendGoGit {
+		return fmt.Errorf("Secrets %q cannot be combined with Backend %q", c.Secrets, BackendGoGit)
+	}
+	if c.UntrackedFiles != "" && !slices.Contains(UntrackedModes, c.UntrackedFiles) {
+		return fmt.Errorf("UntrackedFiles must be one of %s (got %q)", strings.Join(UntrackedModes, ", "), c.UntrackedFiles)
+	}
+	if c.UntrackedFiles != "" && c.UntrackedFiles != UntrackedNormal && c.Backend == BackendGoGit {
+		return fmt.Errorf("UntrackedFiles %q cannot be combined with Backend %q", c.UntrackedFiles, BackendGoGit)
+	}
+	if c.UntrackedPolicy != "" && !slices.Contains(UntrackedPolicies, c.UntrackedPolicy) {
+		return fmt.Errorf("UntrackedPolicy must be one of %s (got %q)", strings.Join(UntrackedPolicies, ", "), c.UntrackedPolicy)
+	}
+	if c.UntrackedPolicy != "" && c.UntrackedPolicy != UntrackedPolicyAll && c.Backend == BackendGoGit {
+		return fmt.Errorf("UntrackedPolicy %q cannot be combined with Backend %q", c.UntrackedPolicy, BackendGoGit)
+	}
+	if c.OnDiverge != "" && !slices.Contains(DivergeModes, c.OnDiverge) {
+		return fmt.Errorf("OnDiverge must be one of %s (got %q)", strings.Join(DivergeModes, ", "), c.OnDiverge)
+	}
+	if c.OnDiverge == DivergePause && c.Pause == nil {
+		return fmt.Errorf("OnDiverge %s requires Pause", DivergePause)
+	}
+	if c.Submodules != "" && !slices.Contains(SubmoduleModes, c.Submodules) {
+		return fmt.Errorf("Submodules must be one of %s (got %q)", strings.Join(SubmoduleModes, ", "), c.Submodules)
+	}
+	if c.Submodules != "" && c.Submodules != SubmodulesInclude && c.Backend == BackendGoGit {
+		return fmt.Errorf("Submodules %q cannot be combined with Backend %q", c.Submodules, BackendGoGit)
+	}
+	if c.Submodules == SubmodulesRecursive && (c.Mode == ModeStash || c.Mode == ModeRefs) {
+		return fmt.Errorf("Submodules %q cannot be combined with Mode %q or %q", SubmodulesRecursive, ModeStash, ModeRefs)
+	}
+	if (c.CommitAuthor == "") != (c.CommitEmail == "") {
+		return fmt.Errorf("CommitAuthor and CommitEmail must be set together (got %q and %q)", c.CommitAuthor, c.CommitEmail)
+	}
+	if strings.ContainsAny(c.CommitAuthor+c.CommitEmail, "<>\n") {
+		return fmt.Errorf("CommitAuthor and CommitEmail cannot contain angle brackets or newlines (got %q and %q)", c.CommitAuthor, c.CommitEmail)
+	}
+	if c.CommitAuthor != "" && c.Backend == BackendGoGit {
+		return fmt.Errorf("CommitAuthor cannot be combined with Backend %q", BackendGoGit)
+	}
+	for _, coAuthor := range c.CoAuthors {
+		if !coAuthorPattern.MatchString(coAuthor) {
+			return fmt.Errorf("CoAuthors must each be a \"Name <email>\" identity (got %q)", coAuthor)
+		}
+	}
 	return nil
 }
 
@@ -119,13 +532,176 @@ type Gitbak struct {
 	// commitsCount tracks the total number of commits made in this session
 	commitsCount int
 
+	// lastCheckpoint is the commit of the checkpoint numbered commitsCount, if known
+	lastCheckpoint string
+
 	// startTime records when this gitbak instance began running
 	startTime time.Time
 
-	// originalBranch stores the branch name that was active when gitbak started
+	// originalBranch stores the branch name that was active when gitbak started,
+	// or the abbreviated commit HEAD was detached at
 	originalBranch string
+
+	// lastCommitTime records when the most recent checkpoint was created
+	lastCommitTime time.Time
+
+	// ignoreCase is set when git treats the filesystem as case-insensitive (core.ignorecase)
+	ignoreCase bool
+
+	// statusConfig holds the -c settings that FastStatus adds to git status
+	statusConfig []string
+
+	// fsmonitor is set when git status consults a file system monitor, which makes
+	// checking a clean tree cheap
+	fsmonitor bool
+
+	// pauseFile is where the repository's PauseFile lives, or empty if it could not be found
+	pauseFile string
+
+	// pausedByFile records whether the pause file existed when last looked for
+	pausedByFile bool
+
+	// oversized records, for each changed file found over MaxFileSizeMB, whether it is left out of checkpoints
+	oversized map[string]bool
+
+	// untracked records, for each new file or directory asked about under UntrackedPolicyPrompt,
+	// whether it is left out of checkpoints
+	untracked map[string]bool
+
+	// secrets records, for each changed file found to look like it holds secrets, whether it is
+	// left out of checkpoints, and so that it is reported only once
+	secrets map[string]bool
+
+	// lastCheckTime records when gitbak last checked for changes
+	lastCheckTime time.Time
+
+	// consecutiveErrors and lastError describe the failing streak at the last check, if any
+	consecutiveErrors int
+	lastError         string
+
+	// endTime and endState record when and how the session ended, once it has
+	endTime  time.Time
+	endState string
+	// historyRecorded is set once RecordHistory has appended the session to the history
+	historyRecorded bool
+
+	// startCommit is the HEAD commit when the session started
+	startCommit string
+	// startupCommit is the commit made of the changes left uncommitted at startup, if any
+	startupCommit string
+
+	// chain is the integrity hash chain of this session's checkpoints
+	chain []session.ChainLink
+
+	// sessionID identifies the session in checkpoint notes, across -continue
+	sessionID string
+
+	// checksCount tracks how many change checks were attempted in this session
+	checksCount int
+
+	// errorsCount tracks how many of those checks ended in an error
+	errorsCount int
+
+	// pushPending is set when checkpoints have been made that are not pushed yet
+	pushPending bool
+
+	// lastPushTime records when the session branch was last pushed successfully
+	lastPushTime time.Time
+
+	// pushesCount and pushFailures track the outcome of pushes in this session
+	pushesCount  int
+	pushFailures int
+
+	// storageTracked is set once the object database size has been measured at startup
+	storageTracked bool
+
+	// storageStart and storageSize are the object database sizes in bytes at session start
+	// and after the most recent checkpoint
+	storageStart int64
+	storageSize  int64
+
+	// storageGrowth records how many bytes each checkpoint added to the object database
+	storageGrowth []int64
+
+	// pushRetryDelay is the wait before the first push retry
+	pushRetryDelay time.Duration
+
+	// nudgeDebounce is how long to wait after a nudge or file change for further ones before checking
+	nudgeDebounce time.Duration
+
+	// schedule adapts the interval between checks to activity; it is set once monitoring starts
+	schedule *intervalSchedule
+
+	// ticks times the scheduled checks; it is set once monitoring starts
+	ticks *tickScheduler
+
+	// retryAt is the earliest time for the next attempt after a failed check, or zero
+	retryAt time.Time
+
+	// random returns a number in [0, 1) for jittering retry delays; rand.Float64 if nil
+	random func() float64
+
+	// lastSnapshotTree is the working tree recorded by the most recent stash snapshot
+	lastSnapshotTree string
+
+	// journaled holds the fingerprints of the changed files as of the latest journal entry
+	// in ModeObserve, keyed by path
+	journaled map[string]string
+
+	// skippedChecks counts the checks in a row that held back changes below the change threshold
+	skippedChecks int
+
+	// checkedStatus is the git status output of the latest change check
+	checkedStatus string
+
+	// heldChanges fingerprints the changes a check left uncommitted without failing, held
+	// back or rejected by a hook, which later checks skip while they stay the same
+	heldChanges string
+
+	// heldBelowThreshold is set when heldChanges were held back below the change threshold
+	heldBelowThreshold bool
+
+	// lastChangeReported is when Changes last reported a change in the working tree
+	lastChangeReported time.Time
+
+	// quietWait is set by a check that held changes back under MinQuietSeconds, to how long
+	// to wait before checking again; the monitoring loop clears it once it has scheduled that
+	quietWait time.Duration
+
+	// finalCheck is set during FinalCheckpoint, which has no later check to wait for
+	finalCheck bool
+
+	// checkResult is the outcome of CheckCommand for the checkpoint being made
+	checkResult checkResult
+
+	// location records where the repository was found, to follow it if it moves
+	location *repoLocation
+
+	// knownHead is the branch tip after the latest checkpoint, or as last seen before one,
+	// against which rewritten history is detected; startCommit until then
+	knownHead string
 }
 
+// maxErrorFingerprintLen bounds the error text retained between retries.
+// Only enough of the message to tell errors apart is needed, and keeping the
+// whole thing (which may embed large git output) would grow memory over long sessions.
+const maxErrorFingerprintLen = 512
+
+// defaultNudgeDebounce is how long gitbak waits after a nudge before checking,
+// so that a burst of saves results in a single check
+const defaultNudgeDebounce = 2 * time.Second
+
+// summaryTimeout bounds the git queries made while printing the session summary
+const summaryTimeout = 5 * time.Second
+
+// commitNumberScanLimit bounds how many commits are read when continuing a session
+// without usable state, so that it stays quick on histories of any length
+const commitNumberScanLimit = 1000
+
+// protectedBranches lists branch names that commonly have protection rules.
+// Checkpointing directly onto one of these (via -no-branch) earns a warning in the summary.
+var protectedBranches = []string{"main", "master", "develop", "trunk", "production", "release"}
+
 // NewGitbak creates a new gitbak instance with default dependencies.
 // This is the primary constructor for creating a gitbak instance with standard
 // components. It validates the configuration and sets up all required dependencies.
@@ -142,7 +718,7 @@ type Gitbak struct {
 //
 //	cfg := GitbakConfig{
 //	    RepoPath: "/path/to/repo",
-//	    IntervalMinutes: 5,
+//	    Interval: 5 * time.Minute,
 //	    BranchName: "gitbak-session",
 //	    CommitPrefix: "[gitbak]",
 //	    CreateBranch: true,
@@ -153,7 +729,14 @@ func NewGitbak(config GitbakConfig, logger logger.Logger) (*Gitbak, error) {
 		return nil, fmt.Errorf("invalid gitbak configuration: %w", err)
 	}
 
-	executor := NewExecExecutor()
+	executor := NewExecutor(config.Backend)
+	if config.Backend != BackendGoGit {
+		executor = NewExecExecutorWithOptions(ExecOptions{
+			Timeout:    config.CommandTimeout,
+			GitPath:    config.GitPath,
+			GlobalArgs: config.GitGlobalArgs,
+		})
+	}
 
 	var interactor UserInteractor
 	if config.NonInteractive {
@@ -176,25 +759,36 @@ func NewGitbakWithDeps(
 		return nil, fmt.Errorf("invalid gitbak configuration: %w", err)
 	}
 
+	if config.Tracer != nil {
+		executor = newTracingExecutor(executor, config.Tracer)
+	}
+	if config.LogCommands {
+		executor = newCommandLogger(executor, logger)
+	}
+
 	return &Gitbak{
-		config:       config,
-		logger:       logger,
-		executor:     executor,
-		interactor:   interactor,
-		commitsCount: 0,
-		startTime:    time.Now(),
+		config:         config,
+		logger:         logger,
+		executor:       executor,
+		interactor:     interactor,
+		commitsCount:   0,
+		startTime:      time.Now(),
+		pushRetryDelay: defaultPushRetryDelay,
+		nudgeDebounce:  defaultNudgeDebounce,
 	}, nil
 }
 
 // IsRepository checks if the given path is a git repository
 // Returns true if it is a repository, false otherwise.
 // If path is not a repository due to git exit code 128, returns (false, nil).
-// For other errors (git not found, permission issues, etc), returns (false, err).
-func IsRepository(path string) (bool, error) {
+// For other errors (git not found, permission issues, cancellation, etc), returns (false, err).
+func IsRepository(ctx context.Context, path string) (bool, error) {
+	return IsRepositoryWith(ctx, path, NewExecExecutor())
+}
+
+// IsRepositoryWith is IsRepository, running git through executor
+func IsRepositoryWith(ctx context.Context, path string, executor CommandExecutor) (bool, error) {
 	cmd := exec.Command("git", "-C", path, "rev-parse", "--is-inside-work-tree")
-	executor := NewExecExecutor()
-	// Use background context since this is a utility function
-	ctx := context.Background()
 	if err := executor.Execute(ctx, cmd); err != nil {
 		// Exit code 128 is git's generic fatal error code - for this command,
 		// it typically means the directory is not part of a git repository,
@@ -204,9 +798,7 @@ func IsRepository(path string) (bool, error) {
 		// grouping together what could be a number of different issues. For the purposes
 		// of this function, I think it's reasonable to treat them all the same -
 		// as almost any issue with the repository will be fatal to gitbak.
-
-		var exitErr *exec.ExitError
-		if gitbakErrors.As(err, &exitErr) && exitErr.ExitCode() == 128 {
+		if exitCode(err) == 128 {
 			return false, nil
 		}
 
@@ -223,8 +815,17 @@ func (g *Gitbak) Run(ctx context.Context) error {
 	if err := g.initialize(ctx); err != nil {
 		return err
 	}
+	if g.config.OnStart != nil {
+		branch := g.sessionBranch()
+		if g.stashMode() || g.refsMode() || g.observeMode() {
+			branch = ""
+		}
+		g.config.OnStart(branch)
+	}
 
-	return g.monitoringLoop(ctx)
+	err := g.monitoringLoop(ctx)
+	g.recordEnd(err)
+	return err
 }
 
 // initialize prepares the gitbak session by detecting the original branch
@@ -241,9 +842,42 @@ func (g *Gitbak) initialize(ctx context.Context) error {
 		}
 		return gitbakErrors.Wrap(err, "failed to get current branch")
 	}
+	g.ignoreCase = g.detectIgnoreCase(ctx)
+	g.trackLocation()
+	g.setupScope()
+	g.setupFastStatus(ctx)
+	g.setupPauseFile(ctx)
+	if g.config.Backend == BackendGoGit && g.hasBakignore() {
+		g.logger.WarningToUser("%s is not supported by the %s backend, so none of its paths are excluded", BakignoreFile, BackendGoGit)
+	}
+	if g.config.Backend == BackendGoGit {
+		g.logger.WarningToUser("Secret scanning is disabled: the %s backend cannot scan changes, so any credentials in them are checkpointed; use -git-backend exec to scan them", BackendGoGit)
+	}
+
+	if head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD"); err == nil {
+		g.startCommit = strings.TrimSpace(head)
+	}
+
+	if err := g.handleDetachedHead(); err != nil {
+		return err
+	}
 	g.logger.Info("Starting gitbak on branch: %s", g.originalBranch)
 
-	if g.config.ContinueSession {
+	if err := g.handleEmptyRepository(ctx); err != nil {
+		return err
+	}
+
+	if g.stashMode() {
+		g.setupStashSession()
+	} else if g.refsMode() {
+		if err := g.setupRefsSession(ctx); err != nil {
+			return err
+		}
+	} else if g.observeMode() {
+		if err := g.setupObserveSession(ctx); err != nil {
+			return err
+		}
+	} else if g.config.ContinueSession {
 		if err := g.setupContinueSession(ctx); err != nil {
 			return err
 		}
@@ -255,15 +889,228 @@ func (g *Gitbak) initialize(ctx context.Context) error {
 		g.setupCurrentBranchSession(ctx)
 	}
 
+	if g.sessionID == "" {
+		g.sessionID = newSessionID()
+	}
+
+	g.startStorageTracking(ctx)
+	if g.config.ContinueSession {
+		g.restoreChain()
+	}
+
+	g.saveState()
 	g.displayStartupInfo()
 	return nil
 }
 
+// sessionBranch returns the branch checkpoints are committed to
+func (g *Gitbak) sessionBranch() string {
+	if !g.config.CreateBranch {
+		return g.originalBranch
+	}
+	return g.config.BranchName
+}
+
+// restoreChain picks up the integrity chain and storage accounting of the session being
+// continued, so that it extends them instead of starting anew. The previous session is
+// recognized by its session ID, even if its branch has been renamed since, or else by its branch.
+func (g *Gitbak) restoreChain() {
+	if g.config.StateFile == "" {
+		return
+	}
+
+	prev, err := session.Load(g.config.StateFile)
+	if err != nil || (prev.SessionID != g.sessionID && prev.Branch != g.originalBranch) {
+		return
+	}
+
+	g.chain = prev.Chain
+	if prev.StartCommit != "" {
+		g.startCommit = prev.StartCommit
+	}
+	if g.storageTracked && len(prev.StorageGrowth) > 0 {
+		g.storageStart = prev.StorageStartBytes
+		g.storageGrowth = prev.StorageGrowth
+	}
+}
+
+// chainState returns the session state fields that make up the integrity chain
+func (g *Gitbak) chainState() *session.State {
+	return &session.State{StartCommit: g.startCommit, Chain: g.chain}
+}
+
+// extendChain records the checkpoint at HEAD in the integrity chain
+func (g *Gitbak) extendChain(ctx context.Context) {
+	if g.config.StateFile == "" {
+		return
+	}
+
+	out, err := g.runGitCommandWithOutput(ctx, "log", "-1", "--format=%H %T")
+	if err != nil {
+		g.logger.Warning("Failed to read checkpoint for integrity chain: %v", err)
+		return
+	}
+
+	commit, tree, ok := strings.Cut(strings.TrimSpace(out), " ")
+	if !ok {
+		g.logger.Warning("Unexpected checkpoint description for integrity chain: %q", out)
+		return
+	}
+
+	state := g.chainState()
+	state.AppendChain(commit, tree)
+	g.chain = state.Chain
+}
+
+// saveState persists the session state, if a state file is configured.
+// Failing to write state never interrupts checkpointing.
+func (g *Gitbak) saveState() {
+	if g.config.StateFile == "" {
+		return
+	}
+	if err := session.Save(g.config.StateFile, g.sessionState()); err != nil {
+		g.logger.Warning("Failed to save session state: %v", err)
+	}
+}
+
+// sessionState returns the session's state as recorded in the state file and the history
+func (g *Gitbak) sessionState() *session.State {
+	state := &session.State{
+		RepoPath:          g.config.RepoPath,
+		Branch:            g.sessionBranch(),
+		OriginalBranch:    g.originalBranch,
+		CreatedBranch:     g.config.CreateBranch,
+		Stash:             g.stashMode(),
+		Observe:           g.observeMode(),
+		Refs:              g.stateRefs(),
+		StartCommit:       g.startCommit,
+		StartupCommit:     g.startupCommit,
+		CommitPrefix:      g.config.CommitPrefix,
+		CommitEmail:       g.config.CommitEmail,
+		SessionID:         g.sessionID,
+		Chain:             g.chain,
+		PID:               os.Getpid(),
+		StartTime:         g.startTime,
+		LastCommitTime:    g.lastCommitTime,
+		CommitsCount:      g.commitsCount,
+		LastCheckpoint:    g.lastCheckpoint,
+		IntervalMinutes:   g.currentIntervalMinutes(),
+		Watch:             g.config.Changes != nil,
+		ControlAddr:       g.config.ControlAddr,
+		LastCheckTime:     g.lastCheckTime,
+		NextCheckTime:     g.nextCheckTime(),
+		ConsecutiveErrors: g.consecutiveErrors,
+		LastError:         g.lastError,
+		EndTime:           g.endTime,
+		EndState:          g.endState,
+	}
+	if g.storageTracked {
+		state.StorageStartBytes = g.storageStart
+		state.StorageBytes = g.storageSize
+		state.StorageGrowth = g.storageGrowth
+	}
+	return state
+}
+
+// currentIntervalMinutes returns the interval until the next check, which differs from
+// the configured one while the interval adapts to activity
+func (g *Gitbak) currentIntervalMinutes() float64 {
+	if g.schedule == nil {
+		return g.config.Interval.Minutes()
+	}
+	return g.schedule.current.Minutes()
+}
+
+// nextCheckTime returns when the next scheduled check is due under the current interval,
+// or zero before monitoring starts
+func (g *Gitbak) nextCheckTime() time.Time {
+	if g.ticks == nil || g.schedule == nil {
+		return time.Time{}
+	}
+	due, _ := g.ticks.following(g.schedule.current)
+	return due
+}
+
+// recordEnd saves how the session ended, given the error that stopped monitoring.
+// Stopping on a signal or the kill switch is a normal end; anything else is a failure.
+// The session joins the history later, in RecordHistory, once the final checkpoint
+// that may follow has been made.
+func (g *Gitbak) recordEnd(err error) {
+	g.endTime = time.Now()
+	g.endState = session.EndStopped
+	if err != nil && !gitbakErrors.Is(err, context.Canceled) && !gitbakErrors.Is(err, gitbakErrors.ErrDisabled) {
+		g.endState = session.EndFailed
+	}
+	g.saveState()
+}
+
+// RecordHistory appends the session to the history of finished sessions, next to the
+// state file. It is called once the session is over, after FinalCheckpoint and
+// PushPending, so that the entry counts every checkpoint; nothing is recorded before
+// Run has returned, nor a second time.
+func (g *Gitbak) RecordHistory() {
+	if g.config.StateFile == "" || g.endTime.IsZero() || g.historyRecorded {
+		return
+	}
+	g.historyRecorded = true
+
+	state := g.sessionState()
+	state.UpdatedAt = time.Now()
+	historyFile := filepath.Join(filepath.Dir(g.config.StateFile), session.HistoryFileName)
+	if err := session.AppendHistory(historyFile, state); err != nil {
+		g.logger.Warning("Failed to record session history: %v", err)
+	}
+}
+
 // setupContinueSession configures gitbak for continuing a previous session
 func (g *Gitbak) setupContinueSession(ctx context.Context) error {
 	g.config.CreateBranch = false
 	g.logger.StatusMessage("🔄 Continuing gitbak session on branch: %s", g.originalBranch)
 
+	// The previous session's state knows its last checkpoint, sparing a search of the history
+	if prev, ok := g.continuedState(ctx); ok {
+		g.sessionID = prev.SessionID
+		g.commitsCount = prev.CommitsCount
+		g.lastCheckpoint = prev.LastCheckpoint
+		if prev.LastCheckpoint != g.startCommit {
+			// Only the commits since can hold later checkpoints, e.g. of a -no-branch session
+			highestNum, err := g.highestCommitNumber(ctx, prev.LastCheckpoint+"..HEAD")
+			if err != nil {
+				g.logger.Warning("Failed to find highest commit number since %s: %v", prev.LastCheckpoint, err)
+			} else if highestNum > g.commitsCount {
+				g.commitsCount = highestNum
+				g.lastCheckpoint = ""
+			}
+		}
+		g.logger.InfoToUser("Found previous commits - starting from commit #%d", g.commitsCount+1)
+		return nil
+	}
+
+	// Checkpoint notes survive renaming the branch and changing the prefix, unlike subjects
+	note, found, err := g.latestCheckpointNote(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to read checkpoint notes: %v", err)
+	} else if found {
+		g.sessionID = note.Session
+		g.commitsCount = note.Counter
+		if note.Prefix != "" && note.Prefix != strings.TrimSpace(g.config.CommitPrefix) {
+			g.logger.InfoToUser("Previous checkpoints used the prefix '%s', continuing with '%s'", note.Prefix, g.config.CommitPrefix)
+		}
+		g.logger.InfoToUser("Found previous commits - starting from commit #%d", note.Counter+1)
+		return nil
+	}
+
+	// Session trailers travel with the commits, unlike notes, which aren't pushed by default
+	id, counter, found, err := g.latestSessionCheckpoint(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to read session trailers: %v", err)
+	} else if found {
+		g.sessionID = id
+		g.commitsCount = counter
+		g.logger.InfoToUser("Found previous commits - starting from commit #%d", counter+1)
+		return nil
+	}
+
 	highestNum, err := g.findHighestCommitNumber(ctx)
 	if err != nil {
 		g.logger.Warning("Failed to find highest commit number: %v", err)
@@ -325,9 +1172,12 @@ func (g *Gitbak) handleUncommittedChanges(ctx context.Context) error {
 	shouldCommit := g.promptForCommit()
 
 	if shouldCommit {
-		addArgs := []string{"."}
-		if err := g.runGitCommand(ctx, "add", "."); err != nil {
-			return gitbakErrors.NewGitError("add", addArgs, err, "failed to stage changes")
+		addArgs, err := g.stageArgs(ctx)
+		if err != nil {
+			return err
+		}
+		if err := g.runGitCommand(ctx, addArgs...); err != nil {
+			return gitbakErrors.NewGitError("add", addArgs[1:], err, "failed to stage changes")
 		}
 
 		commitMsg := "Manual commit before starting gitbak session"
@@ -336,6 +1186,9 @@ func (g *Gitbak) handleUncommittedChanges(ctx context.Context) error {
 			return gitbakErrors.NewGitError("commit", commitArgs, err, "failed to create initial commit")
 		}
 
+		if head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD"); err == nil {
+			g.startupCommit = strings.TrimSpace(head)
+		}
 		g.logger.Success("Created initial commit")
 	}
 
@@ -391,10 +1244,32 @@ func (g *Gitbak) displayStartupInfo() {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	g.logger.StatusMessage("🔄 gitbak started at %s", timestamp)
 	g.logger.StatusMessage("📂 Repository: %s", g.config.RepoPath)
-	g.logger.StatusMessage("⏱️ Interval: %.2f minutes", g.config.IntervalMinutes)
+	if len(g.config.Paths) > 0 {
+		g.logger.StatusMessage("🗂️  Paths: %s", strings.Join(g.config.Paths, ", "))
+	}
+	schedule := newIntervalSchedule(g.config)
+	if schedule.adaptive() {
+		g.logger.StatusMessage("⏱️ Interval: %s, adapting between %s and %s to activity",
+			g.config.Interval, schedule.min, schedule.max)
+	} else {
+		g.logger.StatusMessage("⏱️ Interval: %s", g.config.Interval)
+	}
+	if len(g.config.AlignMarks) > 0 {
+		g.logger.StatusMessage("🕐 Aligned to %s past the hour", formatMarks(g.config.AlignMarks))
+	}
+	if g.config.IdleAfter > 0 {
+		g.logger.StatusMessage("💤 Idle: after %d checks without changes, checking every %.2f minutes until files change",
+			g.config.IdleAfter, schedule.idleInterval.Minutes())
+	}
+	if g.config.Changes != nil {
+		g.logger.StatusMessage("👀 Watch mode: checking shortly after files change")
+	}
 	g.logger.StatusMessage("📝 Commit prefix: %s", g.config.CommitPrefix)
 	g.logger.StatusMessage("🔊 Verbose mode: %t", g.config.Verbose)
 	g.logger.StatusMessage("🔔 Show no-changes messages: %t", g.config.ShowNoChanges)
+	if g.config.Push != "" {
+		g.logger.StatusMessage("☁️  Pushing to: %s", g.config.Push)
+	}
 	g.logger.StatusMessage("❓ Press Ctrl+C to stop and view session summary")
 }
 
@@ -409,12 +1284,22 @@ func (g *Gitbak) tryOperation(
 	},
 	operation func() error,
 ) error {
-	err := operation()
+	g.checksCount++
+
+	err := g.retryIndexLocked(ctx, operation)
+	if err != nil && gitbakErrors.Is(err, gitbakErrors.ErrIndexLocked) {
+		// Another git process holding the index is contention rather than a fault, so it
+		// neither counts towards MaxRetries nor ends a streak of another error
+		g.logger.Warning("Check skipped, the git index is still locked: %v", err)
+		g.logger.WarningToUser("The git index is locked by another git process, trying again at the next check")
+		return err
+	}
 	if err != nil {
+		g.errorsCount++
 		g.logger.Error("Error in operation: %v", err)
 		g.logger.WarningToUser("Error occurred: %v", err)
 
-		currentErrorMsg := err.Error()
+		currentErrorMsg := errorFingerprint(err)
 		if currentErrorMsg == errorState.lastErrorMsg {
 			errorState.consecutiveErrors++
 		} else {
@@ -426,18 +1311,32 @@ func (g *Gitbak) tryOperation(
 		if g.config.MaxRetries > 0 && errorState.consecutiveErrors > g.config.MaxRetries {
 			g.logger.Error("Reached maximum number of consecutive errors (%d). Stopping gitbak.", g.config.MaxRetries)
 			g.logger.WarningToUser("Too many consecutive errors (same error %d times in a row). Stopping gitbak.", errorState.consecutiveErrors)
-			return gitbakErrors.Wrap(gitbakErrors.ErrGitOperationFailed,
-				fmt.Sprintf("maximum retries (%d) exceeded with error: %v", g.config.MaxRetries, err))
+			return fmt.Errorf("%w (%d) with error: %v: %w", gitbakErrors.ErrMaxRetriesExceeded,
+				g.config.MaxRetries, err, gitbakErrors.ErrGitOperationFailed)
 		}
 		return err
 	}
 
-	// Reset consecutive errors on success
+	// Reset consecutive errors on success, saying so, which also ends the console's
+	// summary of the errors it held back
+	if errorState.consecutiveErrors > 0 {
+		g.logger.InfoToUser("Checks are succeeding again")
+	}
 	errorState.consecutiveErrors = 0
 	errorState.lastErrorMsg = ""
 	return nil
 }
 
+// errorFingerprint returns a bounded prefix of the error message used to detect repeated errors.
+func errorFingerprint(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrorFingerprintLen {
+		// Clone so the retained fingerprint doesn't pin the full message in memory
+		return strings.Clone(msg[:maxErrorFingerprintLen])
+	}
+	return msg
+}
+
 // monitoringLoop periodically checks for changes and creates commits.
 // It runs until the context is canceled or an unrecoverable error occurs.
 func (g *Gitbak) monitoringLoop(ctx context.Context) error {
@@ -445,10 +1344,30 @@ func (g *Gitbak) monitoringLoop(ctx context.Context) error {
 	// If we're in continue mode, g.commitsCount was already set in setupContinueSession
 	commitCounter := g.commitsCount + 1
 
-	// Convert interval minutes (float) to duration for more precise control
-	interval := time.Duration(g.config.IntervalMinutes*60*1000) * time.Millisecond
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	g.schedule = newIntervalSchedule(g.config)
+	interval := g.schedule.current
+	ticks := newTickScheduler(interval, g.config.AlignMarks)
+	defer ticks.stop()
+	g.ticks = ticks
+	// Record when the first check is due, which is not an interval away when aligned
+	g.saveState()
+
+	// debounce fires once nudges or changes have settled; it is nil while none is pending
+	var debounce <-chan time.Time
+
+	// adapt moves the ticks to the schedule's interval after a check, if it changed, and
+	// schedules the recheck of changes the check held back until the tree is quiet
+	adapt := func() {
+		if next := g.schedule.current; next != interval {
+			g.logger.Info("Check interval changed from %v to %v", interval, next)
+			interval = next
+			ticks.setInterval(interval)
+		}
+		if g.quietWait > 0 {
+			debounce = time.After(g.quietWait)
+			g.quietWait = 0
+		}
+	}
 
 	// Track consecutive errors for potential bail-out
 	errorState := struct {
@@ -456,37 +1375,269 @@ func (g *Gitbak) monitoringLoop(ctx context.Context) error {
 		lastErrorMsg      string
 	}{}
 
+	// changed records whether the watcher reported changes since the last check (watch mode only)
+	changed := false
+
 	for {
 		select {
 		case <-ctx.Done():
 			g.logger.Info("Received cancellation signal, shutting down gracefully...")
 			return ctx.Err()
 
-		case <-ticker.C:
-			opErr := g.tryOperation(ctx, &errorState, func() error {
-				commitWasCreated := false
+		case <-g.config.Nudges:
+			if debounce == nil {
+				g.logger.Info("Nudge received, checking for changes in %v", g.nudgeDebounce)
+				debounce = time.After(g.nudgeDebounce)
+			}
+
+		case _, ok := <-g.config.Changes:
+			if !ok {
+				// The watcher stopped; keep polling on the interval
+				g.logger.Warning("File watcher stopped, falling back to polling")
+				g.config.Changes = nil
+				changed = true
+				continue
+			}
+			changed = true
+			g.lastChangeReported = time.Now()
+			if debounce == nil {
+				debounce = time.After(g.nudgeDebounce)
+			}
 
-				if err := g.checkAndCommitChanges(ctx, commitCounter, &commitWasCreated); err != nil {
+		case <-g.config.CheckNow:
+			changed = false
+			debounce = nil
+			g.logger.Info("Immediate check requested")
+			if err := g.runCheck(ctx, &commitCounter, &errorState); err != nil {
+				return err
+			}
+			adapt()
+
+		case <-debounce:
+			debounce = nil
+			if g.isPaused() || g.backingOff() || g.lowPowerDeferred() {
+				// Leave changed set, so the first tick after resuming checks
+				g.logger.Info("Checkpointing paused, backing off after an error or low on power, skipping check")
+				continue
+			}
+			changed = false
+			if err := g.runCheck(ctx, &commitCounter, &errorState); err != nil {
+				return err
+			}
+			adapt()
+
+		case <-ticks.C():
+			if late, missed := ticks.advance(); late > overrunSlack {
+				g.logger.Warning("Check due at %s started %v late, as an earlier check overran the %v interval; %d later check(s) skipped",
+					ticks.last.Format(time.TimeOnly), late.Round(time.Second), interval, missed)
+			}
+			if g.isPaused() {
+				// The kill switch still applies while paused
+				if err := g.checkKillSwitch(); err != nil {
 					return err
 				}
-
-				if commitWasCreated {
-					commitCounter++
+				g.logger.Info("Checkpointing paused, skipping check")
+				continue
+			}
+			if g.backingOff() {
+				if err := g.checkKillSwitch(); err != nil {
+					return err
+				}
+				g.logger.Info("Backing off after an error, next attempt at %s", g.retryAt.Format(time.TimeOnly))
+				continue
+			}
+			if g.lowPowerDeferred() {
+				if err := g.checkKillSwitch(); err != nil {
+					return err
 				}
+				g.logger.Info("Low on power, deferring check")
+				continue
+			}
+			if g.config.Changes != nil && !changed {
+				// Nothing changed since the last check, so skip git status
+				if err := g.checkKillSwitch(); err != nil {
+					return err
+				}
+				g.schedule.observe(false)
+				g.observeIdle(false)
+				adapt()
+				g.pushIfDue(ctx)
+				continue
+			}
+			// This check also covers any pending nudge or change
+			changed = false
+			debounce = nil
+			if err := g.runCheck(ctx, &commitCounter, &errorState); err != nil {
+				return err
+			}
+			adapt()
+		}
+	}
+}
 
-				return nil
-			})
+// FinalCheckpoint checkpoints the changes made since the last check, once Run has
+// returned because its context was canceled, so that stopping a session keeps the
+// latest work. ctx bounds how long it may take. Nothing is done if monitoring never
+// started, checkpointing is paused or the kill switch is engaged.
+func (g *Gitbak) FinalCheckpoint(ctx context.Context) error {
+	if g.schedule == nil || g.isPaused() || (g.config.IsDisabled != nil && g.config.IsDisabled()) {
+		return nil
+	}
+	if err := g.followRepository(ctx); err != nil {
+		return err
+	}
 
-			// If the operation hit max retries, bubble up the fatal error
-			if opErr != nil && errorState.consecutiveErrors > g.config.MaxRetries {
-				return opErr
-			}
+	// Changes still being made are better checkpointed half-done than not at all
+	g.finalCheck = true
+	g.checksCount++
+	created := false
+	if err := g.checkAndCommitChanges(ctx, g.commitsCount+1, &created); err != nil {
+		g.errorsCount++
+		g.logger.Warning("Failed to make a final checkpoint: %v", err)
+		return err
+	}
+	if created {
+		g.logger.Info("Made final checkpoint #%d", g.commitsCount)
+	}
+	return nil
+}
+
+// isPaused reports whether checkpointing has been paused, e.g. through the control endpoint
+// or by creating the PauseFile
+func (g *Gitbak) isPaused() bool {
+	if g.config.Paused != nil && g.config.Paused() {
+		return true
+	}
+	return g.pauseFilePresent()
+}
+
+// checkKillSwitch returns ErrDisabled if checkpointing has been disabled externally
+func (g *Gitbak) checkKillSwitch() error {
+	if g.config.IsDisabled != nil && g.config.IsDisabled() {
+		g.logger.WarningToUser("Kill switch engaged, stopping gitbak.")
+		g.logger.Info("Kill switch engaged during monitoring, stopping")
+		return gitbakErrors.ErrDisabled
+	}
+	return nil
+}
+
+// runCheck performs one scheduled or nudged check: it honors the kill switch,
+// commits any changes and pushes checkpoints when due.
+// It returns an error only when monitoring must stop.
+func (g *Gitbak) runCheck(
+	ctx context.Context,
+	commitCounter *int,
+	errorState *struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	},
+) error {
+	if err := g.checkKillSwitch(); err != nil {
+		return err
+	}
+	if err := g.followRepository(ctx); err != nil {
+		return err
+	}
+
+	// The check's span also covers the push that may follow it
+	var opErr error
+	ctx, span := g.startCheckSpan(ctx, *commitCounter)
+	defer func() { span.End(opErr) }()
+
+	committed := false
+	skippedBefore := g.skippedChecks
+	started := time.Now()
+	opErr = g.tryOperation(ctx, errorState, func() error {
+		commitWasCreated := false
+
+		opCtx, cancel := g.withOpTimeout(ctx)
+		defer cancel()
+
+		if err := g.checkAndCommitChanges(opCtx, *commitCounter, &commitWasCreated); err != nil {
+			return g.classifyTimeout(ctx, opCtx, err)
 		}
+
+		if commitWasCreated {
+			*commitCounter++
+			committed = true
+		}
+
+		return nil
+	})
+	g.observeCheck(time.Since(started), opErr, errorState.consecutiveErrors)
+	span.SetAttributes(tracing.Bool("gitbak.checkpoint", committed))
+
+	if opErr != nil && g.config.OnCheckFailed != nil {
+		g.config.OnCheckFailed(opErr, errorState.consecutiveErrors)
+	}
+
+	// Space out retries of a failing check
+	g.retryAt = time.Time{}
+	if opErr != nil {
+		if delay := g.retryDelay(errorState.consecutiveErrors); delay > 0 {
+			g.retryAt = time.Now().Add(delay)
+			g.logger.Info("Retrying no sooner than in %v", delay.Round(time.Millisecond))
+		}
+	}
+
+	// Failed checks say nothing about activity, so they leave the interval alone
+	if g.schedule != nil && opErr == nil {
+		g.schedule.observe(committed)
+		// Changes held back below the change threshold, or until the tree is quiet, keep
+		// the session awake as well
+		g.observeIdle(committed || g.skippedChecks > skippedBefore || g.quietWait > 0)
+	}
+
+	// Record the check so that 'gitbak status' can tell when the next one is due
+	g.lastCheckTime = time.Now()
+	g.consecutiveErrors = errorState.consecutiveErrors
+	g.lastError = ""
+	if opErr != nil {
+		g.lastError = opErr.Error()
+	}
+	g.saveState()
+
+	// If the operation hit max retries, bubble up the fatal error
+	if opErr != nil && errorState.consecutiveErrors > g.config.MaxRetries {
+		return opErr
 	}
+
+	g.pushIfDue(ctx)
+	return nil
+}
+
+// observeIdle moves the schedule in or out of its idle state after a check that found
+// changes or not, telling the user once instead of at every quiet check
+func (g *Gitbak) observeIdle(changed bool) {
+	if !g.schedule.observeChanges(changed) {
+		return
+	}
+	if g.schedule.idling {
+		g.logger.Info("No changes for %d checks, idling", g.schedule.idleAfter)
+		g.logger.InfoToUser("💤 No changes for %d checks, checking every %v until files change", g.schedule.idleAfter, g.schedule.current)
+		return
+	}
+	g.logger.Info("Changes detected, no longer idle")
+	g.logger.InfoToUser("⏰ Changes detected, checking every %v again", g.schedule.current)
+}
+
+// idling reports whether the session is idle after a run of checks without changes
+func (g *Gitbak) idling() bool {
+	return g.schedule != nil && g.schedule.idling
 }
 
 // checkAndCommitChanges checks for uncommitted changes and creates a commit if found.
 func (g *Gitbak) checkAndCommitChanges(ctx context.Context, commitCounter int, commitWasCreated *bool) error {
+	if g.stashMode() {
+		return g.checkAndSnapshotChanges(ctx, commitCounter, commitWasCreated)
+	}
+	if g.refsMode() {
+		return g.checkAndRecordRefs(ctx, commitCounter, commitWasCreated)
+	}
+	if g.observeMode() {
+		return g.checkAndJournalChanges(ctx, commitCounter, commitWasCreated)
+	}
+
 	hasChanges, err := g.hasUncommittedChanges(ctx)
 	if err != nil {
 		// If it's already a GitError or already has ErrGitOperationFailed, just return it
@@ -498,11 +1649,71 @@ func (g *Gitbak) checkAndCommitChanges(ctx context.Context, commitCounter int, c
 	}
 
 	if hasChanges {
+		if g.changesStillHeld() {
+			// Staging the same changes again would only touch the index for the same outcome
+			g.logger.Info("Changes are the same as at the last check, which left them uncommitted")
+			*commitWasCreated = false
+			return nil
+		}
+
+		if g.holdUntilQuiet() {
+			*commitWasCreated = false
+			return nil
+		}
+
+		held, err := g.secretsHoldCheckpoint(ctx)
+		if err != nil {
+			return err
+		}
+		if held {
+			g.holdChanges(false)
+			*commitWasCreated = false
+			return nil
+		}
+
+		below, err := g.belowChangeThreshold(ctx)
+		if err != nil {
+			// Measuring is only an optimization; err on the side of keeping the work
+			g.logger.Warning("Failed to measure changes, committing them regardless: %v", err)
+		} else if below {
+			g.holdChanges(true)
+			*commitWasCreated = false
+			return nil
+		}
+
+		if !g.historyIntact(ctx) {
+			*commitWasCreated = false
+			return nil
+		}
+
+		held, err = g.checkHoldsCheckpoint(ctx)
+		if err != nil {
+			return err
+		}
+		if held {
+			// The check would fail the same way until the changes change
+			g.holdChanges(false)
+			*commitWasCreated = false
+			return nil
+		}
+
 		*commitWasCreated = true
-		return g.createCommit(ctx, commitCounter)
+		created, err := g.createCommit(ctx, commitCounter)
+		if err != nil {
+			return err
+		}
+		if !created {
+			// Staging the same changes again would find the same tree
+			g.holdChanges(false)
+			*commitWasCreated = false
+			return nil
+		}
+		g.skippedChecks = 0
+		return nil
 	} else {
 		*commitWasCreated = false
-		if g.config.ShowNoChanges && g.config.Verbose {
+		g.heldChanges = ""
+		if g.config.ShowNoChanges && g.config.Verbose && !g.idling() {
 			g.logger.InfoToUser("No changes to commit at %s", time.Now().Format("15:04:05"))
 			g.logger.Info("No changes to commit detected")
 		}
@@ -512,46 +1723,137 @@ func (g *Gitbak) checkAndCommitChanges(ctx context.Context, commitCounter int, c
 }
 
 // createCommit stages all changes and creates a commit with the configured prefix.
-func (g *Gitbak) createCommit(ctx context.Context, commitCounter int) error {
+// It reports false, committing nothing, when the staged tree is the same as HEAD's.
+func (g *Gitbak) createCommit(ctx context.Context, commitCounter int) (bool, error) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 
-	addArgs := []string{"."}
-	err := g.runGitCommand(ctx, "add", ".")
+	if err := g.stageCaseRenames(ctx); err != nil {
+		g.logger.Warning("Failed to stage case-only renames: %v", err)
+		return false, err
+	}
+
+	commitMsg := fmt.Sprintf("%s #%d - %s%s", g.config.CommitPrefix, commitCounter, timestamp, g.checkResult.marker())
+	if g.config.Submodules == SubmodulesRecursive {
+		if err := g.checkpointSubmodules(ctx, g.config.RepoPath, commitMsg); err != nil {
+			g.logger.Warning("Failed to checkpoint submodules: %v", err)
+			g.logger.WarningToUser("Failed to checkpoint submodules: %v", err)
+			return false, err
+		}
+	}
+
+	addArgs, err := g.stageArgs(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to stage changes: %v", err)
+		g.logger.WarningToUser("Failed to stage changes: %v", err)
+		return false, err
+	}
+	err = g.runGitCommand(ctx, addArgs...)
 	if err != nil {
 		g.logger.Warning("Failed to stage changes: %v", err)
 		g.logger.WarningToUser("Failed to stage changes: %v", err)
 		// If it's already a GitError or already has ErrGitOperationFailed, just return it
 		if gitbakErrors.Is(err, gitbakErrors.ErrGitOperationFailed) {
-			return err
+			return false, err
 		}
-		return gitbakErrors.NewGitError("add", addArgs,
+		return false, gitbakErrors.NewGitError("add", addArgs[1:],
 			gitbakErrors.Wrap(err, "failed to stage changes"), "")
 	}
 
-	commitMsg := fmt.Sprintf("%s #%d - %s", g.config.CommitPrefix, commitCounter, timestamp)
+	unchanged, err := g.stagedTreeIsHead(ctx)
+	if err != nil {
+		// Comparing is only a safeguard; err on the side of keeping the work
+		g.logger.Warning("Failed to compare the staged changes with HEAD, committing them regardless: %v", err)
+	} else if unchanged {
+		g.logger.Info("Staged changes leave the tree as it is at HEAD, so checkpoint #%d would repeat it; skipping", commitCounter)
+		if g.config.ShowNoChanges && g.config.Verbose && !g.idling() {
+			g.logger.InfoToUser("No changes to commit at %s", time.Now().Format("15:04:05"))
+		}
+		return false, nil
+	}
+
 	commitArgs := []string{"-m", commitMsg}
-	err = g.runGitCommand(ctx, "commit", "-m", commitMsg)
+	if g.config.DiffSummary {
+		// The summary is a convenience; a checkpoint without one beats no checkpoint
+		if summary, err := g.diffSummary(ctx); err != nil {
+			g.logger.Warning("Failed to summarize changes, committing without a summary: %v", err)
+		} else if summary != "" {
+			commitArgs = append(commitArgs, "-m", summary)
+		}
+	}
+	// Trailers must share the last paragraph for git to recognize them
+	trailers := g.coAuthorTrailers()
+	if g.sessionID != "" {
+		trailers = append(trailers, sessionTrailer(g.sessionID))
+	}
+	if trailer := g.checkResult.trailer(); trailer != "" {
+		trailers = append(trailers, trailer)
+	}
+	if g.config.ChainTrailer && g.config.StateFile != "" {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", session.ChainTrailer, g.chainState().ChainHead()))
+	}
+	if len(trailers) > 0 {
+		commitArgs = append(commitArgs, "-m", strings.Join(trailers, "\n"))
+	}
+	err = g.runCheckpointCommit(ctx, g.config.RepoPath, commitArgs...)
 	if err != nil {
 		g.logger.Warning("Failed to create commit: %v", err)
 		g.logger.WarningToUser("Failed to create commit: %v", err)
+		var hookErr *gitbakErrors.HookError
+		if gitbakErrors.As(err, &hookErr) {
+			g.logger.WarningToUser("The repository's %s hook rejected the checkpoint; -no-verify makes checkpoints skip commit hooks", hookErr.Hook)
+			// The hook would reject the same changes again, so wait for them to change
+			g.holdChanges(false)
+		}
 		// If it's already a GitError or already has ErrGitOperationFailed, just return it
 		if gitbakErrors.Is(err, gitbakErrors.ErrGitOperationFailed) {
-			return err
+			return false, err
 		}
-		return gitbakErrors.NewGitError("commit", commitArgs,
+		return false, gitbakErrors.NewGitError("commit", commitArgs,
 			gitbakErrors.Wrap(err, "failed to create commit"), "")
 	}
 
-	g.logger.Success("Commit #%d created at %s", commitCounter, timestamp)
+	g.logger.Success("Commit #%d created at %s%s", commitCounter, timestamp, g.checkResult.marker())
 	g.logger.Info("Successfully created commit #%d", commitCounter)
 
 	g.commitsCount = commitCounter
+	g.lastCommitTime = time.Now()
+	g.recordHead(ctx)
+	g.lastCheckpoint = g.knownHead
+	g.recordCheckpointNote(ctx, commitCounter)
+	g.extendChain(ctx)
+	g.recordCheckpointStorage(ctx)
+	g.observeCheckpoint(ctx, "--root", "HEAD")
+	g.saveState()
+	g.pushPending = true
+
+	if g.config.OnCheckpoint != nil {
+		g.config.OnCheckpoint(Checkpoint{Number: commitCounter, Branch: g.sessionBranch(), Commit: g.knownHead, Time: g.lastCommitTime, Subject: commitMsg})
+	}
 
-	return nil
+	return true, nil
+}
+
+// PrintSummary prints a summary of the gitbak session.
+// The git queries it makes are bounded by summaryTimeout and abandoned if ctx is canceled,
+// so a slow repository never holds up exiting.
+func (g *Gitbak) PrintSummary(ctx context.Context) {
+	if g.config.SummaryJSON != nil {
+		g.printReportJSON(ctx)
+	} else {
+		g.printSummaryText(ctx)
+	}
+
+	if g.config.SummaryFile != "" {
+		if err := g.writeReport(ctx, g.config.SummaryFile); err != nil {
+			g.logger.WarningToUser("Failed to write the session report: %v", err)
+		} else {
+			g.logger.InfoToUser("📝 Session report written to %s", g.config.SummaryFile)
+		}
+	}
 }
 
-// PrintSummary prints a summary of the gitbak session
-func (g *Gitbak) PrintSummary() {
+// printSummaryText prints the summary for people to read
+func (g *Gitbak) printSummaryText(ctx context.Context) {
 	duration := time.Since(g.startTime)
 	hours := int(duration.Hours())
 	minutes := int(duration.Minutes()) % 60
@@ -561,35 +1863,173 @@ func (g *Gitbak) PrintSummary() {
 	g.logger.StatusMessage("---------------------------------------------")
 	g.logger.StatusMessage("📊 gitbak Session Summary")
 	g.logger.StatusMessage("---------------------------------------------")
-	g.logger.StatusMessage("✅ Total commits made: %d", g.commitsCount)
+	if g.stashMode() {
+		g.logger.StatusMessage("✅ Total snapshots stashed: %d", g.commitsCount)
+	} else if g.refsMode() {
+		g.logger.StatusMessage("✅ Total checkpoints written to %s: %d", g.sessionRefs(), g.commitsCount)
+	} else if g.observeMode() {
+		g.logger.StatusMessage("✅ Total changes journaled: %d", g.commitsCount)
+	} else {
+		g.logger.StatusMessage("✅ Total commits made: %d", g.commitsCount)
+	}
 	g.logger.StatusMessage("⏱️  Session duration: %dh %dm %ds", hours, minutes, seconds)
 
-	if g.config.CreateBranch {
+	if g.stashMode() || g.refsMode() || !g.config.CreateBranch {
+		g.logger.StatusMessage("🌿 Working branch: %s (unchanged)", g.originalBranch)
+	} else {
 		g.logger.StatusMessage("🌿 Working branch: %s", g.config.BranchName)
+	}
+	if g.observeMode() {
+		g.logger.StatusMessage("📓 Journal: %s", g.config.JournalFile)
+	}
+	if steps := g.nextSteps(); len(steps) > 0 {
 		g.logger.StatusMessage("")
-		g.logger.StatusMessage("To merge these changes to your original branch:")
-		g.logger.StatusMessage("  git checkout %s", g.originalBranch)
-		g.logger.StatusMessage("  git merge %s", g.config.BranchName)
-		g.logger.StatusMessage("")
-		g.logger.StatusMessage("To squash all commits into one:")
-		g.logger.StatusMessage("  git checkout %s", g.originalBranch)
-		g.logger.StatusMessage("  git merge --squash %s", g.config.BranchName)
-		g.logger.StatusMessage("  git commit -m \"Merged gitbak session\"")
-	} else {
-		g.logger.StatusMessage("🌿 Working branch: %s (unchanged)", g.originalBranch)
+		for _, step := range steps {
+			g.logger.StatusMessage("%s:", step.Description)
+			for _, command := range step.Commands {
+				g.logger.StatusMessage("  %s", command)
+			}
+		}
 	}
 
-	g.showBranchVisualization()
+	if g.config.Push != "" {
+		g.logger.StatusMessage("☁️  Pushes to %s: %d", g.config.Push, g.pushesCount)
+	}
+
+	if g.storageTracked {
+		g.logger.StatusMessage("💾 Object storage: %s", g.storageSummary())
+	}
+
+	g.showBranchVisualization(ctx)
+
+	if suggestions := g.summarySuggestions(); len(suggestions) > 0 {
+		g.logger.StatusMessage("")
+		g.logger.StatusMessage("💡 Suggestions:")
+		for _, suggestion := range suggestions {
+			g.logger.StatusMessage("  - %s", suggestion)
+		}
+	}
 
 	g.logger.StatusMessage("---------------------------------------------")
 	g.logger.StatusMessage("🛑 gitbak terminated at %s", time.Now().Format("2006-01-02 15:04:05"))
 }
 
-// showBranchVisualization displays a visual representation of the branch structure
-func (g *Gitbak) showBranchVisualization() {
-	// Using a background context since this is just for display and not tied to the main app lifecycle
-	ctx := context.Background()
-	output, err := g.runGitCommandWithOutput(ctx, "log", "--graph", "--oneline", "--decorate", "--all", "--color=always", "-n", "10")
+// nextSteps returns the commands for bringing the session's checkpoints into the original
+// branch, or for recovering stashed snapshots and checkpoint refs. Sessions with nothing to
+// act on return none.
+func (g *Gitbak) nextSteps() []ReportStep {
+	switch {
+	case g.stashMode():
+		if g.commitsCount == 0 {
+			return nil
+		}
+		return []ReportStep{
+			{
+				Description: "To list the snapshots (the newest is stash@{0})",
+				Commands:    []string{fmt.Sprintf("git stash list --fixed-strings --grep '%s'", g.config.CommitPrefix)},
+			},
+			{
+				Description: "To restore a snapshot's changes onto the working tree",
+				Commands:    []string{"git stash apply stash@{0}"},
+			},
+		}
+	case g.refsMode():
+		if g.commitsCount == 0 {
+			return nil
+		}
+		return []ReportStep{
+			{
+				Description: "To list the checkpoints",
+				Commands:    []string{"git for-each-ref " + g.sessionRefs()},
+			},
+			{
+				Description: "To turn them into a branch",
+				Commands:    []string{"gitbak materialize"},
+			},
+		}
+	case g.config.CreateBranch:
+		return []ReportStep{
+			{
+				Description: "To merge these changes to your original branch",
+				Commands:    []string{"git checkout " + g.originalBranch, "git merge " + g.config.BranchName},
+			},
+			{
+				Description: "To squash all commits into one",
+				Commands: []string{
+					"git checkout " + g.originalBranch,
+					"git merge --squash " + g.config.BranchName,
+					`git commit -m "Merged gitbak session"`,
+				},
+			},
+			{
+				Description: "Or let gitbak write the message from the session metadata",
+				Commands:    []string{"gitbak squash"},
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+// summarySuggestions returns targeted hints based on how the session went.
+// An uneventful session returns no suggestions so the summary stays compact.
+func (g *Gitbak) summarySuggestions() []string {
+	var suggestions []string
+
+	if g.commitsCount == 0 && g.errorsCount == 0 {
+		suggestions = append(suggestions,
+			fmt.Sprintf("No checkpoints were made. If you expected some, check that your changes aren't excluded by .gitignore or "+BakignoreFile+" "+
+				"and that the interval (%s) suits the session length.", g.config.Interval))
+	}
+
+	// Errors "dominate" when at least half of the checks failed
+	if g.errorsCount > 0 && g.errorsCount*2 >= g.checksCount {
+		if g.config.LogFile != "" {
+			suggestions = append(suggestions,
+				fmt.Sprintf("%d of %d checks failed. See the log for details: %s", g.errorsCount, g.checksCount, g.config.LogFile))
+		} else {
+			suggestions = append(suggestions,
+				fmt.Sprintf("%d of %d checks failed. Re-run with -debug to capture a detailed log.", g.errorsCount, g.checksCount))
+		}
+	}
+
+	if g.pushPending && g.pushFailures > 0 {
+		suggestions = append(suggestions,
+			fmt.Sprintf("The latest checkpoints were not pushed to '%s'. Push them manually: git push %s %s",
+				g.config.Push, g.config.Push, g.sessionBranch()))
+	}
+
+	if g.commitsCount > 0 && !g.config.CreateBranch && !g.stashMode() && !g.refsMode() && !g.observeMode() && isProtectedBranch(g.originalBranch) {
+		suggestions = append(suggestions,
+			fmt.Sprintf("⚠️  Checkpoints were committed directly to '%s', which is commonly protected. "+
+				"Squash or drop them (e.g. git rebase -i) before pushing, or omit -no-branch next time.", g.originalBranch))
+	}
+
+	return suggestions
+}
+
+// isProtectedBranch reports whether the branch name is one that is commonly protected.
+func isProtectedBranch(branch string) bool {
+	for _, protected := range protectedBranches {
+		if branch == protected {
+			return true
+		}
+	}
+	return false
+}
+
+// showBranchVisualization displays a visual representation of the branch structure. Only
+// branches, tags and remotes are drawn, and a -refs-only session's refs: --all would also draw
+// the commits of refs/notes/gitbak and of the stash, which are not part of any history.
+func (g *Gitbak) showBranchVisualization(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+
+	args := []string{"log", "--graph", "--oneline", "--decorate", "--branches", "--tags", "--remotes", "--color=always", "-n", "10"}
+	if g.refsMode() {
+		args = append(args, "--glob="+g.sessionRefs()+"/*")
+	}
+	output, err := g.runGitCommandWithOutput(ctx, args...)
 	if err == nil && output != "" {
 		g.logger.StatusMessage("")
 		g.logger.StatusMessage("🔍 Branch visualization (last 10 commits):")
@@ -600,7 +2040,7 @@ func (g *Gitbak) showBranchVisualization() {
 
 // Git operations
 
-// getCurrentBranch returns the name of the current git branch.
+// getCurrentBranch returns the name of the current git branch, or an empty string when HEAD is detached.
 func (g *Gitbak) getCurrentBranch(ctx context.Context) (string, error) {
 	output, err := g.runGitCommandWithOutput(ctx, "branch", "--show-current")
 	if err != nil {
@@ -610,9 +2050,45 @@ func (g *Gitbak) getCurrentBranch(ctx context.Context) (string, error) {
 }
 
 // hasUncommittedChanges returns true if the repository contains changes
-// that have not been committed yet.
+// that have not been committed yet, including case-only renames that git
+// status does not report on case-insensitive filesystems. Changes a checkpoint
+// would leave out, such as those to paths in BakignoreFile, are not counted.
 func (g *Gitbak) hasUncommittedChanges(ctx context.Context) (bool, error) {
-	output, err := g.runGitCommandWithOutput(ctx, "status", "--porcelain")
+	changed, err := g.hasChanges(ctx)
+	if err != nil || changed {
+		return changed, err
+	}
+
+	renames, err := g.findCaseRenames(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(renames) > 0, nil
+}
+
+// hasChanges reports whether git status shows changes within Paths, outside the excluded paths
+func (g *Gitbak) hasChanges(ctx context.Context) (bool, error) {
+	if g.fsmonitor {
+		// With a file system monitor a clean tree is quick to confirm, and has nothing
+		// to exclude, so there is no need to work out the exclusions first
+		output, err := g.runGitCommandWithOutput(ctx, g.statusArgs()...)
+		if err != nil || strings.TrimSpace(output) == "" {
+			g.checkedStatus = output
+			return false, err
+		}
+	}
+
+	pathspec, err := g.changePathspec(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(pathspec) == 1 {
+		// Without exclusions or Paths there is no need to restrict status to a pathspec
+		pathspec = nil
+	}
+
+	output, err := g.runGitCommandWithOutput(ctx, append(g.statusArgs(), pathspec...)...)
+	g.checkedStatus = output
 	if err != nil {
 		return false, err
 	}
@@ -626,8 +2102,7 @@ func (g *Gitbak) branchExists(ctx context.Context, branchName string) (bool, err
 		return true, nil
 	}
 
-	var exitErr *exec.ExitError
-	if gitbakErrors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+	if exitCode(err) == 1 {
 		// Exit code 1 is the expected "branch not found" case
 		return false, nil
 	}
@@ -638,9 +2113,18 @@ func (g *Gitbak) branchExists(ctx context.Context, branchName string) (bool, err
 // findHighestCommitNumber parses git log to find the highest sequential commit
 // number used with the configured commit prefix.
 func (g *Gitbak) findHighestCommitNumber(ctx context.Context) (int, error) {
+	return g.highestCommitNumber(ctx)
+}
+
+// highestCommitNumber finds the highest commit number used with the configured commit
+// prefix among the latest commitNumberScanLimit checkpoints reachable from HEAD, or
+// in the given revision range
+func (g *Gitbak) highestCommitNumber(ctx context.Context, revs ...string) (int, error) {
 	escapedPrefix := regexp.QuoteMeta(g.config.CommitPrefix)
 
-	output, err := g.runGitCommandWithOutput(ctx, "log", "--pretty=format:%s")
+	// Let git pick out the checkpoints rather than reading every subject in the history
+	args := []string{"log", "-n", strconv.Itoa(commitNumberScanLimit), "--fixed-strings", "--grep=" + g.config.CommitPrefix + " #", "--pretty=format:%s"}
+	output, err := g.runGitCommandWithOutput(ctx, append(args, revs...)...)
 	if err != nil {
 		return 0, err
 	}
@@ -662,6 +2146,32 @@ func (g *Gitbak) findHighestCommitNumber(ctx context.Context) (int, error) {
 	return highestNum, nil
 }
 
+// continuedState returns the state of the session being continued if it describes the
+// current branch's checkpoints: same branch and prefix, and a last checkpoint that is
+// still in the branch's history
+func (g *Gitbak) continuedState(ctx context.Context) (*session.State, bool) {
+	if g.config.StateFile == "" {
+		return nil, false
+	}
+
+	prev, err := session.Load(g.config.StateFile)
+	if err != nil || prev.Stash || prev.Refs != "" || prev.Branch != g.originalBranch || prev.CommitPrefix != g.config.CommitPrefix ||
+		prev.LastCheckpoint == "" || prev.CommitsCount == 0 {
+		return nil, false
+	}
+	if prev.LastCheckpoint == g.startCommit {
+		return prev, true
+	}
+	if g.config.Backend == BackendGoGit {
+		// Without merge-base, only a checkpoint that is still the tip can be trusted
+		return nil, false
+	}
+	if err := g.runGitCommand(ctx, "merge-base", "--is-ancestor", prev.LastCheckpoint, "HEAD"); err != nil {
+		return nil, false
+	}
+	return prev, true
+}
+
 // runGitCommand executes a git command in the repository directory with context.
 func (g *Gitbak) runGitCommand(ctx context.Context, args ...string) error {
 	allArgs := append([]string{"-C", g.config.RepoPath}, args...)
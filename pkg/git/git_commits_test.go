@@ -6,6 +6,7 @@ import (
 	"fmt"
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
 	"os"
 	"path/filepath"
 	"strings"
@@ -31,7 +32,7 @@ func TestCommitChangesScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -82,7 +83,7 @@ func TestCommitChangesScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -158,7 +159,7 @@ func TestCommitChangesScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				setupCtx := context.Background()
@@ -233,7 +234,7 @@ func TestCommitChangesScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -278,7 +279,7 @@ func TestCommitChangesScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				ctx := context.Background()
@@ -348,7 +349,7 @@ func TestCommitNumberScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				for i := 1; i <= 3; i++ {
@@ -393,7 +394,7 @@ func TestCommitNumberScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				return context.Background()
@@ -437,7 +438,7 @@ func TestCommitNumberScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: true,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				nonContinueCtx := context.Background()
@@ -544,7 +545,7 @@ func TestFileChangeScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -623,7 +624,7 @@ func TestFileChangeScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -683,7 +684,7 @@ func TestFileChangeScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -754,7 +755,7 @@ func TestFileChangeScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -814,7 +815,7 @@ func TestFileChangeScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				initCtx := context.Background()
@@ -972,7 +973,7 @@ func TestCommitCounterIncrementationScenarios(t *testing.T) {
 	gb := setupTestGitbak(
 		GitbakConfig{
 			RepoPath:        repoPath,
-			IntervalMinutes: 1,
+			Interval:        time.Minute,
 			BranchName:      "counter-test-branch",
 			CommitPrefix:    "[counter-test] Commit",
 			CreateBranch:    true,
@@ -1103,11 +1104,11 @@ func TestHandleUncommittedChangesScenarios(t *testing.T) {
 			}()
 
 			config := GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
-				BranchName:      "test-branch",
-				CommitPrefix:    "[test]",
-				NonInteractive:  false,
+				RepoPath:       "/test/repo",
+				Interval:       5 * time.Minute,
+				BranchName:     "test-branch",
+				CommitPrefix:   "[test]",
+				NonInteractive: false,
 			}
 
 			gb := setupTestGitbak(config, log)
@@ -1169,7 +1170,7 @@ func TestSummaryScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) bytes.Buffer {
 				gb.originalBranch = "main"
@@ -1181,7 +1182,7 @@ func TestSummaryScenarios(t *testing.T) {
 				testLogger := logger.NewWithOutput(true, "", true, &stdoutBuf, &stdoutBuf)
 				gb.logger = testLogger
 
-				gb.PrintSummary()
+				gb.PrintSummary(context.Background())
 
 				gb.logger = originalLogger
 
@@ -1212,7 +1213,7 @@ func TestSummaryScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) bytes.Buffer {
 				gb.originalBranch = "main"
@@ -1224,7 +1225,7 @@ func TestSummaryScenarios(t *testing.T) {
 				testLogger := logger.NewWithOutput(true, "", true, &stdoutBuf, &stdoutBuf)
 				gb.logger = testLogger
 
-				gb.PrintSummary()
+				gb.PrintSummary(context.Background())
 
 				gb.logger = originalLogger
 
@@ -1254,7 +1255,7 @@ func TestSummaryScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) bytes.Buffer {
 				gb.originalBranch = "main"
@@ -1266,7 +1267,7 @@ func TestSummaryScenarios(t *testing.T) {
 				testLogger := logger.NewWithOutput(true, "", true, &stdoutBuf, &stdoutBuf)
 				gb.logger = testLogger
 
-				gb.PrintSummary()
+				gb.PrintSummary(context.Background())
 
 				gb.logger = originalLogger
 
@@ -1316,3 +1317,264 @@ func TestSummaryScenarios(t *testing.T) {
 		})
 	}
 }
+
+// TestSummarySuggestionsScenarios tests that the summary hints reflect how the session went
+func TestSummarySuggestionsScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config         GitbakConfig
+		originalBranch string
+		commitsCount   int
+		checksCount    int
+		errorsCount    int
+		expected       []string
+		unexpected     []string
+	}{
+		"UneventfulSession": {
+			config:         GitbakConfig{CreateBranch: true, Interval: 5 * time.Minute},
+			originalBranch: "main",
+			commitsCount:   4,
+			checksCount:    10,
+			unexpected:     []string{"No checkpoints", "checks failed", "commonly protected"},
+		},
+		"ZeroCommits": {
+			config:         GitbakConfig{CreateBranch: true, Interval: 5 * time.Minute},
+			originalBranch: "feature",
+			checksCount:    3,
+			expected:       []string{"No checkpoints were made", ".gitignore", "interval (5m0s)"},
+		},
+		"ErrorsDominatedWithLogFile": {
+			config:         GitbakConfig{CreateBranch: true, Interval: time.Minute, LogFile: "/tmp/gitbak-test.log"},
+			originalBranch: "feature",
+			commitsCount:   1,
+			checksCount:    4,
+			errorsCount:    3,
+			expected:       []string{"3 of 4 checks failed", "/tmp/gitbak-test.log"},
+			unexpected:     []string{"No checkpoints"},
+		},
+		"ErrorsDominatedWithoutLogFile": {
+			config:         GitbakConfig{CreateBranch: true, Interval: time.Minute},
+			originalBranch: "feature",
+			checksCount:    2,
+			errorsCount:    2,
+			expected:       []string{"2 of 2 checks failed", "-debug"},
+		},
+		"NoBranchOnProtectedBranch": {
+			config:         GitbakConfig{CreateBranch: false, Interval: 5 * time.Minute},
+			originalBranch: "main",
+			commitsCount:   2,
+			checksCount:    2,
+			expected:       []string{"directly to 'main'", "-no-branch"},
+		},
+		"NoBranchOnProtectedBranchWithoutCommits": {
+			config:         GitbakConfig{CreateBranch: false, Interval: 5 * time.Minute},
+			originalBranch: "main",
+			checksCount:    2,
+			expected:       []string{"No checkpoints were made"},
+			unexpected:     []string{"commonly protected"},
+		},
+		"NoBranchOnFeatureBranch": {
+			config:         GitbakConfig{CreateBranch: false, Interval: 5 * time.Minute},
+			originalBranch: "feature/login",
+			commitsCount:   2,
+			checksCount:    2,
+			unexpected:     []string{"commonly protected"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := &Gitbak{
+				config:         test.config,
+				originalBranch: test.originalBranch,
+				commitsCount:   test.commitsCount,
+				checksCount:    test.checksCount,
+				errorsCount:    test.errorsCount,
+			}
+
+			joined := strings.Join(gb.summarySuggestions(), "\n")
+
+			for _, want := range test.expected {
+				if !strings.Contains(joined, want) {
+					t.Errorf("Expected suggestions to contain %q, got: %s", want, joined)
+				}
+			}
+			for _, unwanted := range test.unexpected {
+				if strings.Contains(joined, unwanted) {
+					t.Errorf("Expected suggestions not to contain %q, got: %s", unwanted, joined)
+				}
+			}
+		})
+	}
+}
+
+// TestFinalCheckpoint tests that the final checkpoint commits pending changes only once monitoring
+// started, and counts as a check in the summary
+func TestFinalCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		started  bool
+		paused   bool
+		locked   bool
+		expected bool
+		checks   int
+		errors   int
+		summary  string
+	}{
+		"Started":    {started: true, expected: true, checks: 1},
+		"NotStarted": {},
+		"Paused":     {started: true, paused: true},
+		"Failed":     {started: true, locked: true, checks: 1, errors: 1, summary: "1 of 1 checks failed"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "final-test-branch",
+				CommitPrefix:   "[final-test] Commit",
+				CreateBranch:   true,
+				NonInteractive: true,
+				Paused:         func() bool { return test.paused },
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("Failed to initialize gitbak: %v", err)
+			}
+			if test.started {
+				gb.schedule = newIntervalSchedule(gb.config)
+			}
+
+			if err := os.WriteFile(filepath.Join(repoPath, "pending.txt"), []byte("pending\n"), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			if test.locked {
+				if err := os.WriteFile(filepath.Join(repoPath, ".git", "index.lock"), nil, 0644); err != nil {
+					t.Fatalf("Failed to lock the index: %v", err)
+				}
+			}
+			if err := gb.FinalCheckpoint(ctx); (err != nil) != test.locked {
+				t.Fatalf("Expected FinalCheckpoint to fail: %v, got %v", test.locked, err)
+			}
+
+			committed := strings.HasPrefix(gitOutput(t, repoPath, "log", "-1", "--format=%s"), "[final-test] Commit #1")
+			if committed != test.expected {
+				t.Errorf("Expected a final checkpoint: %v, got %v", test.expected, committed)
+			}
+			if gb.checksCount != test.checks || gb.errorsCount != test.errors {
+				t.Errorf("Expected %d check(s) and %d error(s), got %d and %d", test.checks, test.errors, gb.checksCount, gb.errorsCount)
+			}
+			if suggestions := strings.Join(gb.summarySuggestions(), "\n"); !strings.Contains(suggestions, test.summary) {
+				t.Errorf("Expected the summary to contain %q, got: %s", test.summary, suggestions)
+			}
+		})
+	}
+}
+
+// TestContinueFromState tests that continuing a session counts on from the number in its
+// state, reading only the commits made since its last checkpoint, while the state matches
+func TestContinueFromState(t *testing.T) {
+	t.Parallel()
+
+	const prefix = "[gitbak-state] Commit"
+
+	tests := map[string]struct {
+		statePrefix string
+		rewrite     bool
+		later       []string
+		expected    int
+		fromState   bool
+	}{
+		"StateAtHead": {
+			expected:  7,
+			fromState: true,
+		},
+		"CheckpointsSince": {
+			later:     []string{prefix + " #9 - 2023-01-01 12:00:00"},
+			expected:  9,
+			fromState: true,
+		},
+		"ManualCommitSince": {
+			later:     []string{"Fix the build"},
+			expected:  7,
+			fromState: true,
+		},
+		"OtherPrefix": {
+			statePrefix: "[other] Commit",
+			expected:    2,
+		},
+		"Rewritten": {
+			rewrite:  true,
+			expected: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			for i := 1; i <= 2; i++ {
+				gitOutput(t, repoPath, "commit", "--allow-empty", "-m", fmt.Sprintf("%s #%d - 2023-01-01 12:00:00", prefix, i))
+			}
+
+			// The state's count differs from the history's, to tell which one was used
+			statePrefix := prefix
+			if test.statePrefix != "" {
+				statePrefix = test.statePrefix
+			}
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+			if err := session.Save(stateFile, &session.State{
+				RepoPath:       repoPath,
+				Branch:         gitOutput(t, repoPath, "branch", "--show-current"),
+				CommitPrefix:   statePrefix,
+				SessionID:      "0123abcd",
+				CommitsCount:   7,
+				LastCheckpoint: gitOutput(t, repoPath, "rev-parse", "HEAD"),
+			}); err != nil {
+				t.Fatalf("Failed to save state: %v", err)
+			}
+
+			if test.rewrite {
+				gitOutput(t, repoPath, "reset", "--hard", "HEAD~1")
+			}
+			for _, subject := range test.later {
+				gitOutput(t, repoPath, "commit", "--allow-empty", "-m", subject)
+			}
+
+			var out bytes.Buffer
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:        repoPath,
+				Interval:        time.Minute,
+				BranchName:      gitOutput(t, repoPath, "branch", "--show-current"),
+				CommitPrefix:    prefix,
+				ContinueSession: true,
+				NonInteractive:  true,
+				StateFile:       stateFile,
+				MaxRetries:      3,
+			}, logger.NewWithOutput(false, "", true, &out, &out))
+			if err := gb.initialize(context.Background()); err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			if gb.commitsCount != test.expected {
+				t.Errorf("Expected to continue from checkpoint %d, got %d", test.expected, gb.commitsCount)
+			}
+			if fromState := gb.sessionID == "0123abcd"; fromState != test.fromState {
+				t.Errorf("Expected the session to be continued from its state: %v, got session ID %q", test.fromState, gb.sessionID)
+			}
+		})
+	}
+}
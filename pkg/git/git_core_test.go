@@ -3,12 +3,16 @@ package git
 import (
 	"context"
 	"fmt"
-	"github.com/bashhack/gitbak/pkg/logger"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
 )
 
 func TestIsRepository(t *testing.T) {
@@ -16,6 +20,7 @@ func TestIsRepository(t *testing.T) {
 
 	tests := map[string]struct {
 		setupPath    func(t *testing.T) string
+		canceled     bool
 		expectedRepo bool
 		expectError  bool
 	}{
@@ -40,6 +45,14 @@ func TestIsRepository(t *testing.T) {
 			expectedRepo: false,
 			expectError:  false, // IsRepository should handle non-existent paths gracefully
 		},
+		"Canceled Context": {
+			setupPath: func(t *testing.T) string {
+				return setupTestRepo(t)
+			},
+			canceled:     true,
+			expectedRepo: false,
+			expectError:  true,
+		},
 	}
 
 	for name, test := range tests {
@@ -48,7 +61,13 @@ func TestIsRepository(t *testing.T) {
 			t.Parallel()
 			path := test.setupPath(t)
 
-			isRepo, err := IsRepository(path)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.canceled {
+				cancel()
+			}
+
+			isRepo, err := IsRepository(ctx, path)
 
 			if test.expectError {
 				if err == nil {
@@ -85,7 +104,7 @@ func TestGitMethodScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "test-branch",
 						CommitPrefix:    "[test] Commit",
 						CreateBranch:    true,
@@ -119,7 +138,7 @@ func TestGitMethodScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "test-branch",
 						CommitPrefix:    "[test] Commit",
 						CreateBranch:    true,
@@ -181,7 +200,7 @@ func TestGitMethodScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "test-branch",
 						CommitPrefix:    "[test] Commit",
 						CreateBranch:    true,
@@ -259,7 +278,7 @@ func TestInitializationScenarios(t *testing.T) {
 		"NonInteractiveMode": {
 			config: GitbakConfig{
 				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 				BranchName:      "test-branch",
 				CommitPrefix:    "[test] ",
 				CreateBranch:    true,
@@ -274,7 +293,7 @@ func TestInitializationScenarios(t *testing.T) {
 				}
 
 				if gb.config.RepoPath != "/test/repo" ||
-					gb.config.IntervalMinutes != 5 ||
+					gb.config.Interval != 5*time.Minute ||
 					gb.config.BranchName != "test-branch" ||
 					gb.config.CommitPrefix != "[test] " ||
 					!gb.config.CreateBranch ||
@@ -302,7 +321,7 @@ func TestInitializationScenarios(t *testing.T) {
 		"InteractiveMode": {
 			config: GitbakConfig{
 				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 				BranchName:      "test-branch",
 				CommitPrefix:    "[test] ",
 				CreateBranch:    true,
@@ -335,7 +354,7 @@ func TestInitializationScenarios(t *testing.T) {
 		"CustomRetryConfiguration": {
 			config: GitbakConfig{
 				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 				BranchName:      "test-branch",
 				CommitPrefix:    "[test] ",
 				CreateBranch:    true,
@@ -398,7 +417,7 @@ func TestBranchSessionScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) context.Context {
 				ctx := context.Background()
@@ -432,7 +451,7 @@ func TestBranchSessionScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) context.Context {
 				gb.originalBranch = "test-original-branch"
@@ -455,7 +474,7 @@ func TestBranchSessionScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) context.Context {
 				ctx := context.Background()
@@ -531,7 +550,7 @@ func TestBranchInitializationScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) context.Context {
 				initCtx := context.Background()
@@ -565,7 +584,7 @@ func TestBranchInitializationScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 5,
+				Interval:        5 * time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak) context.Context {
 				ctx := context.Background()
@@ -633,7 +652,7 @@ func TestBranchEdgeCasesScenarios(t *testing.T) {
 				gb1 := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      existingBranchName,
 						CommitPrefix:    "[gitbak-existing] Commit",
 						CreateBranch:    true,
@@ -654,7 +673,7 @@ func TestBranchEdgeCasesScenarios(t *testing.T) {
 				gb2 := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      existingBranchName,
 						CommitPrefix:    "[gitbak-existing] Commit",
 						CreateBranch:    true,
@@ -700,7 +719,7 @@ func TestBranchEdgeCasesScenarios(t *testing.T) {
 				gitCmd := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "temp-command-branch",
 						CommitPrefix:    "[temp] ",
 						CreateBranch:    false,
@@ -728,7 +747,7 @@ func TestBranchEdgeCasesScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      deletedBranchName,
 						CommitPrefix:    "[gitbak-recovery] Commit",
 						CreateBranch:    true,
@@ -775,7 +794,7 @@ func TestBranchEdgeCasesScenarios(t *testing.T) {
 				gb2 := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      deletedBranchName,
 						CommitPrefix:    "[gitbak-recovery] Commit",
 						CreateBranch:    true,
@@ -862,7 +881,7 @@ func TestSessionInitializationScenarios(t *testing.T) {
 				gb1 := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      continueBranch,
 						CommitPrefix:    continuePrefix,
 						CreateBranch:    true,
@@ -903,7 +922,7 @@ func TestSessionInitializationScenarios(t *testing.T) {
 			config: func(repoPath, branchName, commitPrefix string) GitbakConfig {
 				return GitbakConfig{
 					RepoPath:        repoPath,
-					IntervalMinutes: 1,
+					Interval:        time.Minute,
 					BranchName:      branchName,
 					CommitPrefix:    commitPrefix,
 					CreateBranch:    false,
@@ -964,7 +983,7 @@ func TestSessionInitializationScenarios(t *testing.T) {
 				setupGb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      continueBranch,
 						CommitPrefix:    continuePrefix,
 						CreateBranch:    true,
@@ -995,7 +1014,7 @@ func TestSessionInitializationScenarios(t *testing.T) {
 			config: func(repoPath, branchName, commitPrefix string) GitbakConfig {
 				return GitbakConfig{
 					RepoPath:        repoPath,
-					IntervalMinutes: 5,
+					Interval:        5 * time.Minute,
 					BranchName:      branchName,
 					CommitPrefix:    commitPrefix,
 					CreateBranch:    false,
@@ -1058,3 +1077,121 @@ func TestSessionInitializationScenarios(t *testing.T) {
 		})
 	}
 }
+
+// TestRecordEnd tests that the end of a session is saved to its state and, by RecordHistory,
+// appended to the session history
+func TestRecordEnd(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err           error
+		expectedState string
+	}{
+		"Canceled": {
+			err:           context.Canceled,
+			expectedState: session.EndStopped,
+		},
+		"KillSwitch": {
+			err:           gitbakErrors.ErrDisabled,
+			expectedState: session.EndStopped,
+		},
+		"TooManyErrors": {
+			err:           gitbakErrors.ErrGitOperationFailed,
+			expectedState: session.EndFailed,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stateDir := t.TempDir()
+			stateFile := filepath.Join(stateDir, "state.json")
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       setupTestRepo(t),
+				Interval:       time.Minute,
+				BranchName:     "gitbak-end",
+				CommitPrefix:   "[gitbak]",
+				CreateBranch:   true,
+				NonInteractive: true,
+				StateFile:      stateFile,
+			}, logger.New(false, "", false))
+			gb.startTime = time.Now()
+
+			gb.recordEnd(test.err)
+			gb.RecordHistory()
+
+			state, err := session.Load(stateFile)
+			if err != nil {
+				t.Fatalf("Failed to load state: %v", err)
+			}
+			if state.EndState != test.expectedState || state.EndTime.IsZero() {
+				t.Errorf("Expected the session to end as %q, got %q at %v", test.expectedState, state.EndState, state.EndTime)
+			}
+
+			history, err := session.LoadHistory(filepath.Join(stateDir, session.HistoryFileName))
+			if err != nil {
+				t.Fatalf("Failed to load history: %v", err)
+			}
+			if len(history) != 1 {
+				t.Fatalf("Expected one history entry for the session, got %d", len(history))
+			}
+			if history[0].EndState != test.expectedState || history[0].Branch != "gitbak-end" {
+				t.Errorf("Expected the history entry to match the session, got %+v", history[0])
+			}
+		})
+	}
+}
+
+// TestRecordHistoryOnce tests that a session stopped with a final checkpoint is appended to
+// the history once, counting the final checkpoint
+func TestRecordHistoryOnce(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	repoPath := setupTestRepo(t)
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-history",
+		CommitPrefix:   "[gitbak-history] Commit",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      filepath.Join(stateDir, "state.json"),
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("Failed to initialize gitbak: %v", err)
+	}
+	gb.schedule = newIntervalSchedule(gb.config)
+	gb.startTime = time.Now()
+
+	// Run records the end as it returns, before the final checkpoint is made
+	gb.RecordHistory()
+	gb.recordEnd(context.Canceled)
+	if err := os.WriteFile(filepath.Join(repoPath, "pending.txt"), []byte("pending\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := gb.FinalCheckpoint(ctx); err != nil {
+		t.Fatalf("FinalCheckpoint failed: %v", err)
+	}
+	gb.RecordHistory()
+	gb.RecordHistory()
+
+	content, err := os.ReadFile(filepath.Join(stateDir, session.HistoryFileName))
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 1 {
+		t.Fatalf("Expected one history line for the session, got %d:\n%s", lines, content)
+	}
+	history, err := session.LoadHistory(filepath.Join(stateDir, session.HistoryFileName))
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(history) != 1 || history[0].CommitsCount != 1 {
+		t.Errorf("Expected the history entry to count the final checkpoint, got %+v", history)
+	}
+}
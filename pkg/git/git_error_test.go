@@ -7,6 +7,7 @@ import (
 	"github.com/bashhack/gitbak/pkg/logger"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -59,7 +60,7 @@ func TestGitErrorHandlingScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "gitbak-test-branch",
 						CommitPrefix:    "[gitbak-test] Commit",
 						CreateBranch:    true,
@@ -113,7 +114,7 @@ func TestGitErrorHandlingScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "gitbak-test-branch",
 						CommitPrefix:    "[gitbak-test] Commit",
 						CreateBranch:    true,
@@ -175,7 +176,7 @@ func TestGitErrorHandlingScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        readOnlyDir,
-						IntervalMinutes: 5,
+						Interval:        5 * time.Minute,
 						BranchName:      "gitbak-test-branch",
 						CommitPrefix:    "[gitbak-test] Commit",
 						CreateBranch:    true,
@@ -227,79 +228,503 @@ func TestGitbakConfigValidationScenarios(t *testing.T) {
 	}{
 		"valid config": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
-				BranchName:      "test-branch",
-				CommitPrefix:    "[test] ",
-				MaxRetries:      3,
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				MaxRetries:   3,
 			},
 			expectError: false,
 		},
 		"empty repo path": {
 			config: GitbakConfig{
-				RepoPath:        "",
-				IntervalMinutes: 5,
-				BranchName:      "test-branch",
-				CommitPrefix:    "[test] ",
-				MaxRetries:      3,
+				RepoPath:     "",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				MaxRetries:   3,
 			},
 			expectError: true,
 			errorMsg:    "RepoPath must not be empty",
 		},
 		"zero interval minutes": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 0,
-				BranchName:      "test-branch",
-				CommitPrefix:    "[test] ",
-				MaxRetries:      3,
+				RepoPath:     "/test/repo",
+				Interval:     0,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				MaxRetries:   3,
 			},
 			expectError: true,
-			errorMsg:    "IntervalMinutes must be > 0 (got 0.00)",
+			errorMsg:    "Interval must be > 0 (got 0s)",
 		},
 		"negative interval minutes": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: -5,
-				BranchName:      "test-branch",
-				CommitPrefix:    "[test] ",
-				MaxRetries:      3,
+				RepoPath:     "/test/repo",
+				Interval:     -5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				MaxRetries:   3,
 			},
 			expectError: true,
-			errorMsg:    "IntervalMinutes must be > 0 (got -5.00)",
+			errorMsg:    "Interval must be > 0 (got -5m0s)",
 		},
 		"empty branch name": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
-				BranchName:      "",
-				CommitPrefix:    "[test] ",
-				MaxRetries:      3,
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "",
+				CommitPrefix: "[test] ",
+				MaxRetries:   3,
 			},
 			expectError: true,
 			errorMsg:    "BranchName must not be empty",
 		},
 		"empty commit prefix": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
-				BranchName:      "test-branch",
-				CommitPrefix:    "",
-				MaxRetries:      3,
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "",
+				MaxRetries:   3,
 			},
 			expectError: true,
 			errorMsg:    "CommitPrefix must not be empty",
 		},
 		"negative max retries": {
 			config: GitbakConfig{
-				RepoPath:        "/test/repo",
-				IntervalMinutes: 5,
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				MaxRetries:   -1,
+			},
+			expectError: true,
+			errorMsg:    "MaxRetries cannot be negative (got -1)",
+		},
+		"negative command timeout": {
+			config: GitbakConfig{
+				RepoPath:       "/test/repo",
+				Interval:       5 * time.Minute,
+				BranchName:     "test-branch",
+				CommitPrefix:   "[test] ",
+				CommandTimeout: -time.Second,
+			},
+			expectError: true,
+			errorMsg:    "CommandTimeout cannot be negative (got -1s)",
+		},
+		"invalid on diverge": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				OnDiverge:    "ignore",
+			},
+			expectError: true,
+			errorMsg:    "OnDiverge must be one of warn, pause",
+		},
+		"pause on diverge without pause": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				OnDiverge:    DivergePause,
+			},
+			expectError: true,
+			errorMsg:    "OnDiverge pause requires Pause",
+		},
+		"min interval above interval": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				MinInterval:  10 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: true,
+			errorMsg:    "MinInterval must be between 0 and Interval ",
+		},
+		"max interval below interval": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				MaxInterval:  2 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: true,
+			errorMsg:    "MaxInterval must be 0 or at least Interval ",
+		},
+		"negative idle after": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				IdleAfter:    -1,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: true,
+			errorMsg:    "IdleAfter cannot be negative",
+		},
+		"idle interval below interval": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				IdleAfter:    3,
+				IdleInterval: 2 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: true,
+			errorMsg:    "IdleInterval must be at least Interval ",
+		},
+		"align mark past the hour": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				AlignMarks:   []time.Duration{0, time.Hour},
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: true,
+			errorMsg:    "AlignMarks must lie within the hour",
+		},
+		"adaptive interval": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				MinInterval:  time.Minute,
+				MaxInterval:  30 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+			},
+			expectError: false,
+		},
+		"unknown empty repo mode": {
+			config: GitbakConfig{
+				RepoPath:     "/test/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				EmptyRepo:    "ignore",
+			},
+			expectError: true,
+			errorMsg:    "EmptyRepo must be one of",
+		},
+		"InvalidMode": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Mode:         "worktree",
+			},
+			expectError: true,
+			errorMsg:    "Mode must be one of",
+		},
+		"StashModeWithPush": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Mode:         ModeStash,
+				Push:         "origin",
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"ObserveModeWithoutJournal": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Mode:         ModeObserve,
+			},
+			expectError: true,
+			errorMsg:    "requires JournalFile",
+		},
+		"NegativeMinChangedLines": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
 				BranchName:      "test-branch",
 				CommitPrefix:    "[test] ",
-				MaxRetries:      -1,
+				MinChangedLines: -1,
 			},
 			expectError: true,
-			errorMsg:    "MaxRetries cannot be negative (got -1)",
+			errorMsg:    "cannot be negative",
+		},
+		"ChangeThresholdWithStashMode": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
+				BranchName:      "test-branch",
+				CommitPrefix:    "[test] ",
+				MinChangedFiles: 2,
+				Mode:            ModeStash,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"NegativeMaxFileSize": {
+			config: GitbakConfig{
+				RepoPath:      "/path/to/repo",
+				Interval:      5 * time.Minute,
+				BranchName:    "test-branch",
+				CommitPrefix:  "[test] ",
+				MaxFileSizeMB: -1,
+			},
+			expectError: true,
+			errorMsg:    "MaxFileSizeMB cannot be negative",
+		},
+		"MaxFileSizeWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:      "/path/to/repo",
+				Interval:      5 * time.Minute,
+				BranchName:    "test-branch",
+				CommitPrefix:  "[test] ",
+				MaxFileSizeMB: 50,
+				Backend:       BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"PathOutsideRepo": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Paths:        []string{"../elsewhere"},
+			},
+			expectError: true,
+			errorMsg:    "Paths must be relative paths inside RepoPath",
+		},
+		"PathsWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Paths:        []string{"services/billing"},
+				Backend:      BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"InvalidSecrets": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Secrets:      "redact",
+			},
+			expectError: true,
+			errorMsg:    "Secrets must be one of",
+		},
+		"SecretsWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Secrets:      SecretsSkip,
+				Backend:      BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"InvalidUntrackedFiles": {
+			config: GitbakConfig{
+				RepoPath:       "/path/to/repo",
+				Interval:       5 * time.Minute,
+				BranchName:     "test-branch",
+				CommitPrefix:   "[test] ",
+				UntrackedFiles: "some",
+			},
+			expectError: true,
+			errorMsg:    "UntrackedFiles must be one of",
+		},
+		"UntrackedFilesWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:       "/path/to/repo",
+				Interval:       5 * time.Minute,
+				BranchName:     "test-branch",
+				CommitPrefix:   "[test] ",
+				UntrackedFiles: UntrackedNo,
+				Backend:        BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"NegativeMinQuiet": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
+				BranchName:      "test-branch",
+				CommitPrefix:    "[test] ",
+				MinQuietSeconds: -1,
+			},
+			expectError: true,
+			errorMsg:    "MinQuietSeconds cannot be negative",
+		},
+		"MinQuietWithStash": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
+				BranchName:      "test-branch",
+				CommitPrefix:    "[test] ",
+				MinQuietSeconds: 10,
+				Mode:            ModeStash,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"CheckCommandWithObserve": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CheckCommand: "go build ./...",
+				Mode:         ModeObserve,
+				JournalFile:  "/tmp/journal.jsonl",
+			},
+			expectError: true,
+			errorMsg:    "CheckCommand cannot be combined with",
+		},
+		"InvalidOnCheckFail": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CheckCommand: "go build ./...",
+				OnCheckFail:  "retry",
+			},
+			expectError: true,
+			errorMsg:    "OnCheckFail must be one of",
+		},
+		"InvalidUntrackedPolicy": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
+				BranchName:      "test-branch",
+				CommitPrefix:    "[test] ",
+				UntrackedPolicy: "some",
+			},
+			expectError: true,
+			errorMsg:    "UntrackedPolicy must be one of",
+		},
+		"UntrackedPolicyWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:        "/path/to/repo",
+				Interval:        5 * time.Minute,
+				BranchName:      "test-branch",
+				CommitPrefix:    "[test] ",
+				UntrackedPolicy: UntrackedPolicyNone,
+				Backend:         BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"InvalidBackend": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Backend:      "libgit2",
+			},
+			expectError: true,
+			errorMsg:    "Backend must be one of",
+		},
+		"GoGitBackendWithPush": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Backend:      BackendGoGit,
+				Push:         "origin",
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"InvalidSubmodules": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Submodules:   "all",
+			},
+			expectError: true,
+			errorMsg:    "Submodules must be one of",
+		},
+		"RecursiveSubmodulesWithStash": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				Submodules:   SubmodulesRecursive,
+				Mode:         ModeStash,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"CommitAuthorWithoutEmail": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CommitAuthor: "gitbak bot",
+			},
+			expectError: true,
+			errorMsg:    "must be set together",
+		},
+		"CommitEmailWithBrackets": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CommitAuthor: "gitbak bot",
+				CommitEmail:  "<bot@example.com>",
+			},
+			expectError: true,
+			errorMsg:    "cannot contain angle brackets",
+		},
+		"CommitAuthorWithGoGit": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CommitAuthor: "gitbak bot",
+				CommitEmail:  "bot@example.com",
+				Backend:      BackendGoGit,
+			},
+			expectError: true,
+			errorMsg:    "cannot be combined with",
+		},
+		"InvalidCoAuthor": {
+			config: GitbakConfig{
+				RepoPath:     "/path/to/repo",
+				Interval:     5 * time.Minute,
+				BranchName:   "test-branch",
+				CommitPrefix: "[test] ",
+				CoAuthors:    []string{"Alice <alice@example.com>", "bob@example.com"},
+			},
+			expectError: true,
+			errorMsg:    "CoAuthors must each be",
 		},
 	}
 
@@ -341,11 +766,11 @@ func TestRetryLogicExitsAfterMaxRetries(t *testing.T) {
 
 	gb := &Gitbak{
 		config: GitbakConfig{
-			RepoPath:        "/mock/repo/path",
-			IntervalMinutes: 1,
-			BranchName:      "test-branch",
-			CommitPrefix:    "[test]",
-			MaxRetries:      config.DefaultMaxRetries,
+			RepoPath:     "/mock/repo/path",
+			Interval:     time.Minute,
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			MaxRetries:   config.DefaultMaxRetries,
 		},
 		logger:   log,
 		executor: mockExecutor,
@@ -364,6 +789,10 @@ func TestRetryLogicExitsAfterMaxRetries(t *testing.T) {
 		t.Errorf("Expected error to be ErrGitOperationFailed, got: %v", loopErr)
 	}
 
+	if !gitbakErrors.Is(loopErr, gitbakErrors.ErrMaxRetriesExceeded) {
+		t.Errorf("Expected error to be ErrMaxRetriesExceeded, got: %v", loopErr)
+	}
+
 	if !strings.Contains(loopErr.Error(), "maximum retries") {
 		t.Errorf("Expected error to mention maximum retries, got: %v", loopErr)
 	}
@@ -392,11 +821,11 @@ func TestRetryLogicResetOnDifferentError(t *testing.T) {
 
 	gb := &Gitbak{
 		config: GitbakConfig{
-			RepoPath:        "/mock/repo/path",
-			IntervalMinutes: 1,
-			BranchName:      "test-branch",
-			CommitPrefix:    "[test]",
-			MaxRetries:      config.DefaultMaxRetries, // With default of 3, we should get 6+ calls
+			RepoPath:     "/mock/repo/path",
+			Interval:     time.Minute,
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			MaxRetries:   config.DefaultMaxRetries, // With default of 3, we should get 6+ calls
 		},
 		logger:   log,
 		executor: mockExecutor,
@@ -439,11 +868,11 @@ func TestRetryLogicResetOnSuccess(t *testing.T) {
 
 	gb := &Gitbak{
 		config: GitbakConfig{
-			RepoPath:        "/mock/repo/path",
-			IntervalMinutes: 1,
-			BranchName:      "test-branch",
-			CommitPrefix:    "[test]",
-			MaxRetries:      3,
+			RepoPath:     "/mock/repo/path",
+			Interval:     time.Minute,
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			MaxRetries:   3,
 		},
 		logger:   log,
 		executor: mockExecutor,
@@ -463,3 +892,161 @@ func TestRetryLogicResetOnSuccess(t *testing.T) {
 			mockExecutor.CallCount)
 	}
 }
+
+// TestFailureClasses tests that failures are reported with the sentinel for their class
+func TestFailureClasses(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		setup    func(t *testing.T, repoPath string)
+		noBranch bool
+		noVerify bool
+
+		// atStart sets the repository up before the session starts rather than before a check
+		atStart   bool
+		skipOn    string
+		expectErr []error
+		hook      string
+	}{
+		"IndexLocked": {
+			setup: func(t *testing.T, repoPath string) {
+				writeTestFile(t, filepath.Join(repoPath, ".git", "index.lock"), "")
+			},
+			expectErr: []error{gitbakErrors.ErrIndexLocked},
+		},
+		"PreCommitHook": {
+			setup: func(t *testing.T, repoPath string) {
+				writeHook(t, repoPath, "pre-commit", "echo 'lint failed' >&2\nexit 1\n")
+			},
+			// git needs no execute permission to run hooks on Windows, where this one would still run
+			skipOn:    "windows",
+			expectErr: []error{gitbakErrors.ErrHookFailed},
+			hook:      "pre-commit",
+		},
+		"CommitMsgHook": {
+			setup: func(t *testing.T, repoPath string) {
+				writeHook(t, repoPath, "commit-msg", "exit 1\n")
+			},
+			skipOn:    "windows",
+			expectErr: []error{gitbakErrors.ErrHookFailed},
+			hook:      "commit-msg",
+		},
+		"HookSkippedWithNoVerify": {
+			setup: func(t *testing.T, repoPath string) {
+				writeHook(t, repoPath, "pre-commit", "exit 1\n")
+			},
+			noVerify: true,
+		},
+		"DetachedHead": {
+			setup: func(t *testing.T, repoPath string) {
+				gitOutput(t, repoPath, "checkout", "-q", "--detach")
+			},
+			noBranch:  true,
+			atStart:   true,
+			expectErr: []error{gitbakErrors.ErrDetachedHead, gitbakErrors.ErrInvalidConfiguration},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if runtime.GOOS == test.skipOn {
+				t.Skipf("Not supported on %s", test.skipOn)
+			}
+
+			repoPath := setupTestRepo(t)
+			writeTestFile(t, filepath.Join(repoPath, "work.txt"), "work")
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-classes",
+				CommitPrefix:   "[gitbak] Checkpoint",
+				CreateBranch:   !test.noBranch,
+				NoVerify:       test.noVerify,
+				NonInteractive: true,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if test.atStart {
+				test.setup(t, repoPath)
+			}
+			err := gb.initialize(ctx)
+			if !test.atStart {
+				if err != nil {
+					t.Fatalf("initialize failed: %v", err)
+				}
+				test.setup(t, repoPath)
+				created := false
+				err = gb.checkAndCommitChanges(ctx, 1, &created)
+			}
+
+			if len(test.expectErr) == 0 && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for _, expected := range test.expectErr {
+				if !gitbakErrors.Is(err, expected) {
+					t.Errorf("Expected the error to match %v, got %v", expected, err)
+				}
+			}
+			var hookErr *gitbakErrors.HookError
+			if gitbakErrors.As(err, &hookErr) != (test.hook != "") || (hookErr != nil && hookErr.Hook != test.hook) {
+				t.Errorf("Expected a failure of hook %q, got %v", test.hook, err)
+			}
+		})
+	}
+}
+
+// writeTestFile writes content to path
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// writeHook installs an executable git hook running script in the repository
+func writeHook(t *testing.T, repoPath, hook, script string) {
+	t.Helper()
+
+	path := filepath.Join(repoPath, ".git", "hooks", hook)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Failed to write %s hook: %v", hook, err)
+	}
+}
+
+// TestTryOperationRecovery tests that a check failing the same way is shown once, and that
+// its repeats are summarized once checks succeed again
+func TestTryOperationRecovery(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr strings.Builder
+	gb := &Gitbak{
+		config: GitbakConfig{MaxRetries: 5},
+		logger: logger.NewWithOutput(false, "", true, &stdout, &stderr),
+	}
+
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	failure := gitbakErrors.New("index.lock exists")
+	for range 3 {
+		_ = gb.tryOperation(context.Background(), &errorState, func() error { return failure })
+	}
+	if err := gb.tryOperation(context.Background(), &errorState, func() error { return nil }); err != nil {
+		t.Fatalf("Expected the check to succeed, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 ||
+		lines[0] != "⚠️  Error occurred: index.lock exists" ||
+		!strings.HasPrefix(lines[1], "⚠️  Same warning repeated 2×, last at ") ||
+		lines[2] != "ℹ️  Checks are succeeding again" {
+		t.Errorf("Expected the warning once, a summary of its repeats and the recovery, got %q", stdout.String())
+	}
+	if count := strings.Count(stderr.String(), "❌ Error in operation: index.lock exists"); count != 1 {
+		t.Errorf("Expected the error to be shown once, got %d times in %q", count, stderr.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -85,6 +86,122 @@ func TestExecutorWithContextMethods(t *testing.T) {
 	}
 }
 
+// TestExecutorCommandTimeout tests that a command outliving the timeout is killed with the processes it started
+func TestExecutorCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Process groups are not killed on Windows")
+	}
+	t.Parallel()
+
+	tests := map[string]struct {
+		timeout       time.Duration
+		cancelSession bool
+		expectTimeout bool
+	}{
+		"TimedOut": {
+			timeout:       100 * time.Millisecond,
+			expectTimeout: true,
+		},
+		"SessionCanceled": {
+			timeout:       time.Hour,
+			cancelSession: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := NewExecExecutorWithTimeout(test.timeout)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancelSession {
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+
+			// The background sleep holds the output pipe open, so the command only returns
+			// early if the whole process group is killed
+			started := time.Now()
+			_, err := executor.ExecuteWithContextAndOutput(ctx, "sh", "-c", "sleep 30 & sleep 30")
+			if elapsed := time.Since(started); elapsed > 3*time.Second {
+				t.Errorf("Expected the command to be killed promptly, took %v", elapsed)
+			}
+
+			var timeoutErr *gitbakErrors.CommandTimeoutError
+			if gitbakErrors.As(err, &timeoutErr) != test.expectTimeout {
+				t.Fatalf("Expected a CommandTimeoutError: %v, got %v", test.expectTimeout, err)
+			}
+			if test.expectTimeout && (!gitbakErrors.Is(err, gitbakErrors.ErrOperationTimeout) || timeoutErr.Timeout != test.timeout) {
+				t.Errorf("Expected the timeout to match ErrOperationTimeout and record its limit, got %v", err)
+			}
+			if err == nil {
+				t.Error("Expected the killed command to fail")
+			}
+		})
+	}
+}
+
+// TestExecutorGitOptions tests that git commands run the configured git binary with the global arguments
+func TestExecutorGitOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The wrapper is a shell script")
+	}
+	t.Parallel()
+
+	realGit, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found")
+	}
+	repoPath := setupTestRepo(t)
+
+	// The wrapper records its arguments before handing them to git
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	wrapper := filepath.Join(dir, "git-wrapper")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$*\" >> %q\nexec %q \"$@\"\n", argsFile, realGit)
+	if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write wrapper: %v", err)
+	}
+
+	tests := map[string]struct {
+		run func(ctx context.Context, executor *ExecExecutor) (string, error)
+	}{
+		"ByName": {
+			run: func(ctx context.Context, executor *ExecExecutor) (string, error) {
+				return executor.ExecuteWithContextAndOutput(ctx, "git", "-C", repoPath, "config", "--get", "gitbak.test")
+			},
+		},
+		"PreparedCommand": {
+			run: func(ctx context.Context, executor *ExecExecutor) (string, error) {
+				return executor.ExecuteWithOutput(ctx, exec.Command("git", "-C", repoPath, "config", "--get", "gitbak.test"))
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			executor := NewExecExecutorWithOptions(ExecOptions{GitPath: wrapper, GlobalArgs: []string{"-c", "gitbak.test=" + name}})
+			out, err := test.run(context.Background(), executor)
+			if err != nil {
+				t.Fatalf("Command failed: %v", err)
+			}
+			if strings.TrimSpace(out) != name {
+				t.Errorf("Expected git to see the global config gitbak.test=%s, got %q", name, out)
+			}
+
+			recorded, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatalf("Expected the wrapper to run: %v", err)
+			}
+			if !strings.Contains(string(recorded), "-c gitbak.test="+name+" -C "+repoPath+" config") {
+				t.Errorf("Expected the global arguments before the command's, got %q", recorded)
+			}
+		})
+	}
+}
+
 // TestContextCancellationScenarios tests different gitbak methods with context cancellation
 func TestContextCancellationScenarios(t *testing.T) {
 	t.Parallel()
@@ -106,7 +223,7 @@ func TestContextCancellationScenarios(t *testing.T) {
 				return setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      "gitbak-context-branch",
 						CommitPrefix:    "[gitbak-context] Commit",
 						CreateBranch:    true,
@@ -173,7 +290,7 @@ func TestContextCancellationScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      "gitbak-monitor-branch",
 						CommitPrefix:    "[gitbak-monitor] Commit",
 						CreateBranch:    true,
@@ -268,7 +385,7 @@ func TestRunBehaviorScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				testFile := filepath.Join(repoPath, "test-run.txt")
@@ -317,7 +434,7 @@ func TestRunBehaviorScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: true,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				setupCtx := context.Background()
@@ -383,7 +500,7 @@ func TestRunBehaviorScenarios(t *testing.T) {
 				ShowNoChanges:   true,
 				ContinueSession: false,
 				NonInteractive:  true,
-				IntervalMinutes: 1,
+				Interval:        time.Minute,
 			},
 			setupFunc: func(t *testing.T, gb *Gitbak, repoPath string) context.Context {
 				setupCtx := context.Background()
@@ -454,3 +571,244 @@ func TestRunBehaviorScenarios(t *testing.T) {
 		})
 	}
 }
+
+// TestMonitoringLoopHonorsKillSwitch tests that the loop stops at the next tick once the kill switch is engaged
+func TestMonitoringLoopHonorsKillSwitch(t *testing.T) {
+	t.Parallel()
+
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-killswitch-test.log")
+	log := logger.New(true, tempLogFile, true)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	mockExecutor := NewMockCommandExecutor()
+
+	gb := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:     "/mock/repo/path",
+			Interval:     60 * time.Millisecond, // 60ms
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			IsDisabled:   func() bool { return true },
+		},
+		logger:   log,
+		executor: mockExecutor,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := gb.monitoringLoop(ctx)
+	if !gitbakErrors.Is(err, gitbakErrors.ErrDisabled) {
+		t.Fatalf("Expected ErrDisabled, got: %v", err)
+	}
+
+	if mockExecutor.CallCount != 0 {
+		t.Errorf("Expected no git commands to run once disabled, got %d", mockExecutor.CallCount)
+	}
+}
+
+// TestMonitoringLoopNudge tests that a burst of nudges is debounced into a single early check
+func TestMonitoringLoopNudge(t *testing.T) {
+	t.Parallel()
+
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-nudge-test.log")
+	log := logger.New(true, tempLogFile, true)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	mockExecutor := NewMockCommandExecutor()
+	nudges := make(chan struct{}, 1)
+
+	gb := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:     "/mock/repo/path",
+			Interval:     60 * time.Minute, // Long enough that only nudges trigger checks
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			Nudges:       nudges,
+		},
+		logger:        log,
+		executor:      mockExecutor,
+		nudgeDebounce: 100 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gb.monitoringLoop(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		nudges <- struct{}{}
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	cancel()
+
+	if err := <-errChan; !gitbakErrors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	if gb.checksCount != 1 {
+		t.Errorf("Expected the nudges to result in 1 check, got %d", gb.checksCount)
+	}
+}
+
+// TestMonitoringLoopWatchMode tests that in watch mode idle ticks skip git status and changes trigger a check
+func TestMonitoringLoopWatchMode(t *testing.T) {
+	t.Parallel()
+
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-watch-test.log")
+	log := logger.New(true, tempLogFile, true)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	mockExecutor := NewMockCommandExecutor()
+	changes := make(chan struct{}, 1)
+
+	gb := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:     "/mock/repo/path",
+			Interval:     60 * time.Millisecond, // 60ms
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			Changes:      changes,
+		},
+		logger:        log,
+		executor:      mockExecutor,
+		nudgeDebounce: 50 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gb.monitoringLoop(ctx)
+	}()
+
+	// Several idle ticks pass, then a change arrives
+	time.Sleep(300 * time.Millisecond)
+	changes <- struct{}{}
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	if err := <-errChan; !gitbakErrors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	// The change may be picked up by the debounce or by a tick before it fires, but only once
+	if gb.checksCount != 1 {
+		t.Errorf("Expected exactly 1 check in watch mode, got %d", gb.checksCount)
+	}
+}
+
+// TestMonitoringLoopPausedCheckNow tests that ticks are skipped while paused and that
+// an immediate check request is honored regardless
+func TestMonitoringLoopPausedCheckNow(t *testing.T) {
+	t.Parallel()
+
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-pause-test.log")
+	log := logger.New(true, tempLogFile, true)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	mockExecutor := NewMockCommandExecutor()
+	checkNow := make(chan struct{}, 1)
+
+	gb := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:     "/mock/repo/path",
+			Interval:     60 * time.Millisecond, // 60ms
+			BranchName:   "test-branch",
+			CommitPrefix: "[test]",
+			CheckNow:     checkNow,
+			Paused:       func() bool { return true },
+		},
+		logger:   log,
+		executor: mockExecutor,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gb.monitoringLoop(ctx)
+	}()
+
+	// Several ticks pass while paused, then an immediate check is requested
+	time.Sleep(300 * time.Millisecond)
+	checkNow <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	if err := <-errChan; !gitbakErrors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	if gb.checksCount != 1 {
+		t.Errorf("Expected only the requested check while paused, got %d checks", gb.checksCount)
+	}
+}
+
+// TestMonitoringLoopRetryBackoff tests that ticks are skipped while backing off after an error
+func TestMonitoringLoopRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-backoff-test.log")
+	log := logger.New(true, tempLogFile, true)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	mockExecutor := NewMockCommandExecutor()
+	mockExecutor.ExecuteWithOutputFn = func(ctx context.Context, cmd *exec.Cmd) (string, error) {
+		return "", fmt.Errorf("index.lock exists")
+	}
+
+	gb := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:        "/mock/repo/path",
+			Interval:        30 * time.Millisecond, // 30ms
+			BranchName:      "test-branch",
+			CommitPrefix:    "[test]",
+			MaxRetries:      10,
+			RetryBackoff:    200 * time.Millisecond,
+			RetryBackoffMax: time.Second,
+		},
+		logger:   log,
+		executor: mockExecutor,
+		random:   func() float64 { return 0.5 },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gb.monitoringLoop(ctx)
+	}()
+
+	// Without backoff, about 15 ticks would each retry; with it, attempts follow
+	// the first failure after about 200ms and then a further 400ms
+	time.Sleep(450 * time.Millisecond)
+	cancel()
+
+	if err := <-errChan; !gitbakErrors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	if gb.checksCount != 2 {
+		t.Errorf("Expected 2 checks while backing off, got %d", gb.checksCount)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // TestInteractionScenarios tests various interaction methods and behaviors
@@ -73,7 +74,7 @@ func TestInteractionScenarios(t *testing.T) {
 				gb := setupTestGitbak(
 					GitbakConfig{
 						RepoPath:        repoPath,
-						IntervalMinutes: 1,
+						Interval:        time.Minute,
 						BranchName:      "gitbak-prompt-branch",
 						CommitPrefix:    "[gitbak-prompt] Commit",
 						CreateBranch:    true,
@@ -156,7 +157,7 @@ func TestGitbakPromptYesNoScenarios(t *testing.T) {
 	gb := setupTestGitbak(
 		GitbakConfig{
 			RepoPath:        repoPath,
-			IntervalMinutes: 1,
+			Interval:        time.Minute,
 			BranchName:      "gitbak-direct-prompt-branch",
 			CommitPrefix:    "[gitbak-direct-prompt] Commit",
 			CreateBranch:    true,
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestTryOperationAllocations guards against per-tick allocations creeping into the retry bookkeeping
+func TestTryOperationAllocations(t *testing.T) {
+	gb := &Gitbak{
+		config: GitbakConfig{MaxRetries: 0},
+		logger: logger.NewWithOutput(false, "", false, io.Discard, io.Discard),
+	}
+
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	ctx := context.Background()
+	success := func() error { return nil }
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		_ = gb.tryOperation(ctx, &errorState, success)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected successful tryOperation to allocate nothing, got %.1f allocs per run", allocs)
+	}
+}
+
+// TestRepeatedErrorStateIsBounded tests that retry state doesn't retain huge error messages
+func TestRepeatedErrorStateIsBounded(t *testing.T) {
+	tempLogFile := filepath.Join(t.TempDir(), "gitbak-memory-test.log")
+	log := logger.NewWithOutput(true, tempLogFile, false, io.Discard, io.Discard)
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Logf("Failed to close log: %v", err)
+		}
+	}()
+
+	gb := &Gitbak{
+		config: GitbakConfig{MaxRetries: 0},
+		logger: log,
+	}
+
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	hugeErr := errors.New(strings.Repeat("x", 64*1024))
+
+	for i := 0; i < 20; i++ {
+		_ = gb.tryOperation(context.Background(), &errorState, func() error { return hugeErr })
+	}
+
+	if len(errorState.lastErrorMsg) > maxErrorFingerprintLen {
+		t.Errorf("Expected retained error message to be at most %d bytes, got %d",
+			maxErrorFingerprintLen, len(errorState.lastErrorMsg))
+	}
+	if errorState.consecutiveErrors != 20 {
+		t.Errorf("Expected truncated fingerprints to still match, got %d consecutive errors", errorState.consecutiveErrors)
+	}
+}
+
+// TestBoundedBuffer tests that captured stderr is capped without short writes
+func TestBoundedBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := boundedBuffer{limit: 8}
+	n, err := buf.Write([]byte("0123456789"))
+	if err != nil || n != 10 {
+		t.Fatalf("Expected full write to be reported (10, nil), got (%d, %v)", n, err)
+	}
+	n, err = buf.Write([]byte("abc"))
+	if err != nil || n != 3 {
+		t.Fatalf("Expected write past the limit to be reported (3, nil), got (%d, %v)", n, err)
+	}
+	if buf.String() != "01234567" {
+		t.Errorf("Expected retained contents %q, got %q", "01234567", buf.String())
+	}
+}
@@ -0,0 +1,624 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// errUnsupportedCommand is reported for git commands the go-git backend does not implement
+var errUnsupportedCommand = gitbakErrors.New("not supported by the gogit backend")
+
+// exitStatusError is a failed command with the exit code git itself would have returned,
+// so that callers can tell "not found" (exit code 1) apart from real failures
+type exitStatusError struct {
+	code int
+	err  error
+}
+
+// Error implements error
+func (e *exitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d: %v", e.code, e.err)
+}
+
+// Unwrap returns the underlying error
+func (e *exitStatusError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code git would have returned
+func (e *exitStatusError) ExitCode() int {
+	return e.code
+}
+
+// GoGitExecutor is a CommandExecutor that carries out git commands in-process with go-git
+// instead of running the git binary, for machines where git isn't installed, such as
+// minimal container and CI images.
+//
+// It understands the commands a monitoring session issues, in the forms gitbak uses them:
+// detecting the branch and HEAD, checking the status, staging and committing every change,
+// creating the session branch, and reading back checkpoint subjects. Anything else, such as
+// pushing or the stash and ignore commands, fails with an error naming the command.
+type GoGitExecutor struct {
+	mu    sync.Mutex
+	repos map[string]*gogit.Repository
+}
+
+// NewGoGitExecutor creates a new GoGitExecutor
+func NewGoGitExecutor() *GoGitExecutor {
+	return &GoGitExecutor{repos: make(map[string]*gogit.Repository)}
+}
+
+// Execute implements CommandExecutor.Execute
+func (e *GoGitExecutor) Execute(ctx context.Context, cmd *exec.Cmd) error {
+	_, err := e.ExecuteWithOutput(ctx, cmd)
+	return err
+}
+
+// ExecuteWithOutput implements CommandExecutor.ExecuteWithOutput. Commands that depend on
+// their environment (such as an alternative index file) cannot be honored and are rejected.
+func (e *GoGitExecutor) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	var args []string
+	if len(cmd.Args) > 1 {
+		args = cmd.Args[1:]
+	}
+	if cmd.Env != nil {
+		return "", gitbakErrors.NewGitError("git", args,
+			gitbakErrors.Wrap(errUnsupportedCommand, "custom environments are"), "")
+	}
+	return e.ExecuteWithContextAndOutput(ctx, filepath.Base(cmd.Path), args...)
+}
+
+// ExecuteWithContext implements CommandExecutor.ExecuteWithContext
+func (e *GoGitExecutor) ExecuteWithContext(ctx context.Context, name string, args ...string) error {
+	_, err := e.ExecuteWithContextAndOutput(ctx, name, args...)
+	return err
+}
+
+// ExecuteWithContextAndOutput implements CommandExecutor.ExecuteWithContextAndOutput.
+// go-git operations cannot be interrupted, so ctx is only checked before each command.
+func (e *GoGitExecutor) ExecuteWithContextAndOutput(ctx context.Context, name string, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", gitbakErrors.NewGitError(name, args, gitbakErrors.Wrap(err, "git operation failed"), "")
+	}
+
+	out, err := e.dispatch(name, args)
+	if err != nil {
+		return "", gitbakErrors.NewGitError(name, args, gitbakErrors.Wrap(err, "git operation failed"), "")
+	}
+	return out, nil
+}
+
+// dispatch runs "git -C <path> <command> <args...>"
+func (e *GoGitExecutor) dispatch(name string, args []string) (string, error) {
+	if name != "git" {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "running %s is", name)
+	}
+
+	path := "."
+	if len(args) >= 2 && args[0] == "-C" {
+		path, args = args[1], args[2:]
+	}
+	if len(args) == 0 {
+		return "", gitbakErrors.Wrap(errUnsupportedCommand, "git without a command is")
+	}
+
+	command, rest := args[0], args[1:]
+	if command == "rev-parse" && argsAre(rest, "--is-inside-work-tree") {
+		return e.isInsideWorkTree(path)
+	}
+
+	repo, err := e.open(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch command {
+	case "branch":
+		if argsAre(rest, "--show-current") {
+			return goGitCurrentBranch(repo)
+		}
+	case "config":
+		if len(rest) == 2 && rest[0] == "--bool" {
+			return goGitConfigBool(repo, rest[1])
+		}
+	case "rev-parse":
+		return goGitRevParse(repo, rest)
+	case "status":
+		if argsAre(rest, "--porcelain") {
+			return goGitStatus(repo)
+		}
+	case "add":
+		if argsAre(rest, ".") || argsAre(rest, "-A") {
+			return "", goGitAddAll(repo)
+		}
+	case "commit":
+		return goGitCommit(repo, rest)
+	case "show-ref":
+		if len(rest) == 3 && rest[0] == "--verify" && rest[1] == "--quiet" {
+			return goGitShowRef(repo, rest[2])
+		}
+	case "checkout":
+		if len(rest) == 2 && rest[0] == "-b" {
+			return "", goGitCheckoutNewBranch(repo, rest[1])
+		}
+	case "log":
+		return goGitLog(repo, rest)
+	case "ls-files":
+		if argsAre(rest, "-z") {
+			return goGitLsFiles(repo)
+		}
+	case "diff-tree":
+		return goGitDiffTree(repo, rest)
+	case "count-objects":
+		if argsAre(rest, "-v") {
+			return goGitCountObjects(repo)
+		}
+	}
+	return "", gitbakErrors.Wrapf(errUnsupportedCommand, "git %s is", strings.Join(args, " "))
+}
+
+// argsAre reports whether args consist of exactly want
+func argsAre(args []string, want ...string) bool {
+	return slices.Equal(args, want)
+}
+
+// open returns the repository containing path, opening it on first use
+func (e *GoGitExecutor) open(path string) (*gogit.Repository, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if repo, ok := e.repos[path]; ok {
+		return repo, nil
+	}
+
+	repo, err := gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if err == gogit.ErrRepositoryNotExists {
+			// git's exit code for "not a git repository"
+			return nil, &exitStatusError{code: 128, err: err}
+		}
+		return nil, err
+	}
+	e.repos[path] = repo
+	return repo, nil
+}
+
+// isInsideWorkTree implements rev-parse --is-inside-work-tree
+func (e *GoGitExecutor) isInsideWorkTree(path string) (string, error) {
+	if _, err := e.open(path); err != nil {
+		return "", err
+	}
+	return "true\n", nil
+}
+
+// goGitCurrentBranch implements branch --show-current, printing nothing when HEAD is detached
+func goGitCurrentBranch(repo *gogit.Repository) (string, error) {
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+	return head.Target().Short() + "\n", nil
+}
+
+// goGitConfigBool implements config --bool <section>.<key>, failing with exit code 1 when unset
+func goGitConfigBool(repo *gogit.Repository, key string) (string, error) {
+	section, option, ok := strings.Cut(key, ".")
+	if !ok {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "config key %q is", key)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	s := cfg.Raw.Section(section)
+	if !s.HasOption(option) {
+		return "", &exitStatusError{code: 1, err: fmt.Errorf("%s is not set", key)}
+	}
+
+	switch strings.ToLower(s.Option(option)) {
+	case "true", "yes", "on", "1", "":
+		return "true\n", nil
+	default:
+		return "false\n", nil
+	}
+}
+
+// goGitRevParse implements rev-parse [--verify --quiet] <rev> for HEAD and HEAD^{tree},
+// and rev-parse --git-path <path>
+func goGitRevParse(repo *gogit.Repository, args []string) (string, error) {
+	if len(args) == 2 && args[0] == "--git-path" {
+		storage, ok := repo.Storer.(*filesystem.Storage)
+		if !ok {
+			return "", gitbakErrors.Wrap(errUnsupportedCommand, "rev-parse --git-path without a git directory is")
+		}
+		return filepath.Join(storage.Filesystem().Root(), args[1]) + "\n", nil
+	}
+
+	verify := len(args) == 3 && args[0] == "--verify" && args[1] == "--quiet"
+	if verify {
+		args = args[2:]
+	}
+	if len(args) != 1 {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "rev-parse %s is", strings.Join(args, " "))
+	}
+
+	rev, wantTree := strings.CutSuffix(args[0], "^{tree}")
+	if rev != "HEAD" {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "rev-parse %s is", args[0])
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound && verify {
+			// --verify --quiet exits with 1 when the revision does not resolve
+			return "", &exitStatusError{code: 1, err: err}
+		}
+		return "", &exitStatusError{code: 128, err: err}
+	}
+
+	if !wantTree {
+		return head.Hash().String() + "\n", nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", err
+	}
+	return commit.TreeHash.String() + "\n", nil
+}
+
+// goGitStatus implements status --porcelain. Entries are sorted by path, as git reports them.
+func goGitStatus(repo *gogit.Repository) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	st, err := worktree.Status()
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(st))
+	for path, file := range st {
+		if file.Staging == gogit.Unmodified && file.Worktree == gogit.Unmodified {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		file := st[path]
+		_, _ = fmt.Fprintf(&b, "%c%c %s\n", goGitStatusCode(file.Staging), goGitStatusCode(file.Worktree), path)
+	}
+	return b.String(), nil
+}
+
+// goGitStatusCode converts a go-git status code to its porcelain letter
+func goGitStatusCode(code gogit.StatusCode) byte {
+	if code == gogit.Unmodified {
+		return ' '
+	}
+	return byte(code)
+}
+
+// goGitAddAll implements add . (and add -A): stages every change, including deletions
+func goGitAddAll(repo *gogit.Repository) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.AddWithOptions(&gogit.AddOptions{All: true})
+}
+
+// goGitCommit implements commit [--allow-empty] [--only] -m <message> [-m <paragraph>...].
+// --only without paths commits none of the staged changes, as git does.
+func goGitCommit(repo *gogit.Repository, args []string) (string, error) {
+	var paragraphs []string
+	allowEmpty, only := false, false
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--allow-empty":
+			allowEmpty = true
+		case "--only":
+			only = true
+		case "--no-verify":
+			// go-git never runs hooks
+		case "-m":
+			if i+1 == len(args) {
+				return "", gitbakErrors.Wrap(errUnsupportedCommand, "commit -m without a message is")
+			}
+			i++
+			paragraphs = append(paragraphs, args[i])
+		default:
+			return "", gitbakErrors.Wrapf(errUnsupportedCommand, "commit %s is", args[i])
+		}
+	}
+	message := strings.Join(paragraphs, "\n\n") + "\n"
+
+	if only {
+		return "", goGitCommitHeadTree(repo, message)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if _, err := worktree.Commit(message, &gogit.CommitOptions{AllowEmptyCommits: allowEmpty}); err != nil {
+		if err == gogit.ErrEmptyCommit {
+			return "", &exitStatusError{code: 1, err: err}
+		}
+		return "", err
+	}
+	return "", nil
+}
+
+// goGitCommitHeadTree commits HEAD's tree again (an empty tree on an unborn branch),
+// leaving the index alone, and advances the current branch to the new commit
+func goGitCommitHeadTree(repo *gogit.Repository, message string) error {
+	opts := &gogit.CommitOptions{}
+	if err := opts.Validate(repo); err != nil {
+		return err
+	}
+
+	commit := &object.Commit{
+		Author:       *opts.Author,
+		Committer:    *opts.Committer,
+		Message:      message,
+		ParentHashes: opts.Parents,
+	}
+
+	if len(opts.Parents) > 0 {
+		parent, err := repo.CommitObject(opts.Parents[0])
+		if err != nil {
+			return err
+		}
+		commit.TreeHash = parent.TreeHash
+	} else {
+		tree := repo.Storer.NewEncodedObject()
+		if err := (&object.Tree{}).Encode(tree); err != nil {
+			return err
+		}
+		hash, err := repo.Storer.SetEncodedObject(tree)
+		if err != nil {
+			return err
+		}
+		commit.TreeHash = hash
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return err
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return err
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return err
+	}
+	name := plumbing.HEAD
+	if head.Type() == plumbing.SymbolicReference {
+		name = head.Target()
+	}
+	return repo.Storer.SetReference(plumbing.NewHashReference(name, hash))
+}
+
+// goGitShowRef implements show-ref --verify --quiet <ref>, failing with exit code 1 when it does not exist
+func goGitShowRef(repo *gogit.Repository, ref string) (string, error) {
+	if _, err := repo.Reference(plumbing.ReferenceName(ref), false); err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return "", &exitStatusError{code: 1, err: err}
+		}
+		return "", err
+	}
+	return "", nil
+}
+
+// goGitCheckoutNewBranch implements checkout -b <branch>: the branch starts at HEAD and
+// the index and working tree, including uncommitted changes, are kept as they are
+func goGitCheckoutNewBranch(repo *gogit.Repository, branch string) error {
+	name := plumbing.NewBranchReferenceName(branch)
+
+	if _, err := repo.Head(); err == plumbing.ErrReferenceNotFound {
+		// On an unborn branch there is nothing to start from; switch to the new unborn branch
+		return repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, name))
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(&gogit.CheckoutOptions{Branch: name, Create: true, Keep: true})
+}
+
+// goGitLog implements the log forms gitbak reads checkpoints with: -1 --format=%H %T, and
+// [-n <limit>] [--fixed-strings --grep=<text>] --pretty=format:%s (subjects, newest first)
+func goGitLog(repo *gogit.Repository, args []string) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", &exitStatusError{code: 128, err: err}
+	}
+
+	if argsAre(args, "-1", "--format=%H %T") {
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s\n", commit.Hash, commit.TreeHash), nil
+	}
+
+	limit, grep, ok := goGitSubjectsArgs(args)
+	if !ok {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "log %s is", strings.Join(args, " "))
+	}
+
+	commits, err := repo.Log(&gogit.LogOptions{From: head.Hash(), Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return "", err
+	}
+	defer commits.Close()
+
+	var subjects []string
+	err = commits.ForEach(func(c *object.Commit) error {
+		if limit >= 0 && len(subjects) == limit {
+			return storer.ErrStop
+		}
+		if !strings.Contains(c.Message, grep) {
+			return nil
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		subjects = append(subjects, subject)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(subjects, "\n"), nil
+}
+
+// goGitSubjectsArgs parses the arguments of log --pretty=format:%s, returning the limit
+// on commits (-1 for none) and the text messages must contain
+func goGitSubjectsArgs(args []string) (limit int, grep string, ok bool) {
+	limit = -1
+	fixed := false
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-n" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return 0, "", false
+			}
+			limit = n
+			i++
+		case arg == "--fixed-strings":
+			fixed = true
+		case strings.HasPrefix(arg, "--grep="):
+			grep = strings.TrimPrefix(arg, "--grep=")
+		case arg == "--pretty=format:%s" && i == len(args)-1:
+			// Matching patterns as regular expressions is not supported
+			return limit, grep, fixed || grep == ""
+		default:
+			return 0, "", false
+		}
+	}
+	return 0, "", false
+}
+
+// goGitLsFiles implements ls-files -z
+func goGitLsFiles(repo *gogit.Repository) (string, error) {
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, entry := range index.Entries {
+		b.WriteString(entry.Name)
+		b.WriteByte(0)
+	}
+	return b.String(), nil
+}
+
+// goGitDiffTree implements diff-tree -r --no-commit-id --name-only --root HEAD,
+// listing the files the latest commit changed
+func goGitDiffTree(repo *gogit.Repository, args []string) (string, error) {
+	if !argsAre(args, "-r", "--no-commit-id", "--name-only", "--root", "HEAD") {
+		return "", gitbakErrors.Wrapf(errUnsupportedCommand, "diff-tree %s is", strings.Join(args, " "))
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", &exitStatusError{code: 128, err: err}
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return "", err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return "", err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, change := range changes {
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		b.WriteString(name)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// goGitCountObjects implements the size and size-pack lines of count-objects -v,
+// in KiB, by adding up the files in the object database
+func goGitCountObjects(repo *gogit.Repository) (string, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", gitbakErrors.Wrap(errUnsupportedCommand, "count-objects without a git directory is")
+	}
+
+	var loose, packed int64
+	objects := filepath.Join(storage.Filesystem().Root(), "objects")
+	err := filepath.WalkDir(objects, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if filepath.Base(filepath.Dir(path)) == "pack" {
+			packed += info.Size()
+		} else if len(filepath.Base(filepath.Dir(path))) == 2 {
+			loose += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("size: %d\nsize-pack: %d\n", loose/1024, packed/1024), nil
+}
@@ -0,0 +1,264 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestGoGitExecutorMatchesGit tests that the gogit backend answers the session's queries like git does
+func TestGoGitExecutorMatchesGit(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("modified"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("untracked"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := map[string]struct {
+		args []string
+	}{
+		"CurrentBranch":   {args: []string{"branch", "--show-current"}},
+		"Head":            {args: []string{"rev-parse", "HEAD"}},
+		"VerifyHead":      {args: []string{"rev-parse", "--verify", "--quiet", "HEAD"}},
+		"HeadTree":        {args: []string{"rev-parse", "HEAD^{tree}"}},
+		"InsideWorkTree":  {args: []string{"rev-parse", "--is-inside-work-tree"}},
+		"Status":          {args: []string{"status", "--porcelain"}},
+		"TrackedFiles":    {args: []string{"ls-files", "-z"}},
+		"Subjects":        {args: []string{"log", "--pretty=format:%s"}},
+		"MatchedSubjects": {args: []string{"log", "-n", "5", "--fixed-strings", "--grep=Initial", "--pretty=format:%s"}},
+		"NoSubjects":      {args: []string{"log", "-n", "5", "--fixed-strings", "--grep=[gitbak] #", "--pretty=format:%s"}},
+		"LatestCommit":    {args: []string{"log", "-1", "--format=%H %T"}},
+		"ChangedFiles":    {args: []string{"diff-tree", "-r", "--no-commit-id", "--name-only", "--root", "HEAD"}},
+		"ExistingBranch":  {args: []string{"show-ref", "--verify", "--quiet", "refs/heads/" + gitOutput(t, repoPath, "branch", "--show-current")}},
+		"MissingBranch":   {args: []string{"show-ref", "--verify", "--quiet", "refs/heads/no-such-branch"}},
+		"UnsetConfigBool": {args: []string{"config", "--bool", "gitbak.unset"}},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			args := append([]string{"-C", repoPath}, test.args...)
+
+			want, wantErr := NewExecExecutor().ExecuteWithContextAndOutput(ctx, "git", args...)
+			got, err := NewGoGitExecutor().ExecuteWithContextAndOutput(ctx, "git", args...)
+
+			if exitCode(err) != exitCode(wantErr) {
+				t.Fatalf("Expected exit code %d (%v), got %d (%v)", exitCode(wantErr), wantErr, exitCode(err), err)
+			}
+			if got != want {
+				t.Errorf("Expected output %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+// TestGoGitExecutorUnsupportedCommand tests that commands outside the session's needs are refused
+func TestGoGitExecutorUnsupportedCommand(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+
+	err := NewGoGitExecutor().ExecuteWithContext(context.Background(), "git", "-C", repoPath, "push", "origin", "HEAD")
+	if err == nil || !strings.Contains(err.Error(), "not supported by the gogit backend") {
+		t.Errorf("Expected an unsupported command error, got: %v", err)
+	}
+
+	cmd := exec.Command("git", "-C", repoPath, "write-tree")
+	cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(t.TempDir(), "index"))
+	if _, err := NewGoGitExecutor().ExecuteWithOutput(context.Background(), cmd); err == nil {
+		t.Error("Expected an error for a command with a custom environment")
+	}
+}
+
+// TestGoGitIsRepository tests repository detection with the gogit backend
+func TestGoGitIsRepository(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	isRepo, err := IsRepositoryWith(ctx, setupTestRepo(t), NewGoGitExecutor())
+	if err != nil || !isRepo {
+		t.Errorf("Expected a repository, got %v (error: %v)", isRepo, err)
+	}
+
+	isRepo, err = IsRepositoryWith(ctx, t.TempDir(), NewGoGitExecutor())
+	if err != nil || isRepo {
+		t.Errorf("Expected no repository, got %v (error: %v)", isRepo, err)
+	}
+}
+
+// TestGoGitSession tests a checkpoint session carried out entirely by the gogit backend
+func TestGoGitSession(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	startBranch := gitOutput(t, repoPath, "branch", "--show-current")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-gogit",
+		CommitPrefix:   "[gitbak-gogit] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+		Backend:        BackendGoGit,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	checkpoint := func(counter int) bool {
+		t.Helper()
+		var created bool
+		if err := gb.checkAndCommitChanges(ctx, counter, &created); err != nil {
+			t.Fatalf("checkAndCommitChanges failed: %v", err)
+		}
+		return created
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if !checkpoint(1) {
+		t.Fatal("Expected a checkpoint of the changes")
+	}
+	if checkpoint(2) {
+		t.Error("Expected no checkpoint when nothing changed")
+	}
+
+	if err := os.Remove(filepath.Join(repoPath, "new.txt")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if !checkpoint(2) {
+		t.Fatal("Expected a checkpoint of the deletion")
+	}
+
+	// The result must be indistinguishable from a session run with git
+	if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != "gitbak-gogit" {
+		t.Errorf("Expected to be on gitbak-gogit, got %s", branch)
+	}
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status != "" {
+		t.Errorf("Expected a clean working tree, got %q", status)
+	}
+	if files := gitOutput(t, repoPath, "ls-files"); files != "initial.txt" {
+		t.Errorf("Expected only initial.txt to be tracked, got %q", files)
+	}
+	if author := gitOutput(t, repoPath, "log", "-1", "--format=%an <%ae>"); author != "Test User <test@example.com>" {
+		t.Errorf("Expected the configured author, got %q", author)
+	}
+	if base := gitOutput(t, repoPath, "merge-base", "--is-ancestor", startBranch, "HEAD"); base != "" {
+		t.Errorf("Expected the session branch to start from %s", startBranch)
+	}
+
+	highest, err := gb.findHighestCommitNumber(ctx)
+	if err != nil {
+		t.Fatalf("findHighestCommitNumber failed: %v", err)
+	}
+	if highest != 2 {
+		t.Errorf("Expected the highest checkpoint to be #2, got #%d", highest)
+	}
+}
+
+// TestGoGitWarnsSecretScanningOff tests that starting a session with the gogit backend warns
+// that its changes are not scanned for secrets
+func TestGoGitWarnsSecretScanningOff(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		backend     string
+		expectWarns bool
+	}{
+		"GoGit": {backend: BackendGoGit, expectWarns: true},
+		"Exec":  {backend: BackendExec},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       setupTestRepo(t),
+				Interval:       time.Minute,
+				BranchName:     "gitbak-gogit-secrets",
+				CommitPrefix:   "[gitbak] Checkpoint",
+				CreateBranch:   true,
+				NonInteractive: true,
+				Backend:        test.backend,
+			}, logger.NewWithOutput(false, "", false, &out, &out))
+			if err := gb.initialize(context.Background()); err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			if warns := strings.Contains(out.String(), "Secret scanning is disabled"); warns != test.expectWarns {
+				t.Errorf("Expected a secret scanning warning: %v, got:\n%s", test.expectWarns, out.String())
+			}
+		})
+	}
+}
+
+// TestGoGitEmptyRepository tests that the gogit backend starts sessions in repositories without commits
+func TestGoGitEmptyRepository(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	gitOutput(t, repoPath, "init")
+	gitOutput(t, repoPath, "config", "user.email", "test@example.com")
+	gitOutput(t, repoPath, "config", "user.name", "Test User")
+
+	if err := os.WriteFile(filepath.Join(repoPath, "staged.txt"), []byte("staged"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	gitOutput(t, repoPath, "add", "staged.txt")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-gogit",
+		CommitPrefix:   "[gitbak-gogit] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+		Backend:        BackendGoGit,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	var created bool
+	if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil {
+		t.Fatalf("checkAndCommitChanges failed: %v", err)
+	}
+	if !created {
+		t.Fatal("Expected a checkpoint of the staged file")
+	}
+
+	subjects := gitOutput(t, repoPath, "log", "--format=%s")
+	if lines := strings.Split(subjects, "\n"); len(lines) != 2 || lines[1] != initialCommitMessage {
+		t.Errorf("Expected a checkpoint on top of the empty initial commit, got %q", subjects)
+	}
+	if files := gitOutput(t, repoPath, "ls-tree", "--name-only", "HEAD~1"); files != "" {
+		t.Errorf("Expected an empty initial commit, got %q", files)
+	}
+}
@@ -0,0 +1,109 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// coAuthorPattern matches an identity as Co-authored-by trailers take it: "Name <email>"
+var coAuthorPattern = regexp.MustCompile(`^[^<>\n]*[^<>\s] <[^<>\s]+>$`)
+
+// identityEnv returns the environment attributing commits to CommitAuthor as both author
+// and committer, or nil when checkpoints are made as the configured git user
+func (g *Gitbak) identityEnv() []string {
+	if g.config.CommitAuthor == "" {
+		return nil
+	}
+	return append(os.Environ(),
+		"GIT_AUTHOR_NAME="+g.config.CommitAuthor,
+		"GIT_AUTHOR_EMAIL="+g.config.CommitEmail,
+		"GIT_COMMITTER_NAME="+g.config.CommitAuthor,
+		"GIT_COMMITTER_EMAIL="+g.config.CommitEmail,
+	)
+}
+
+// runAsCheckpointIdentity runs a git command creating commit objects in dir, such as
+// commit-tree, attributing them to CommitAuthor if one is configured
+func (g *Gitbak) runAsCheckpointIdentity(ctx context.Context, dir string, args ...string) (string, error) {
+	allArgs := append([]string{"-C", dir}, args...)
+	env := g.identityEnv()
+	if env == nil {
+		return g.executor.ExecuteWithContextAndOutput(ctx, "git", allArgs...)
+	}
+
+	cmd := exec.Command("git", allArgs...)
+	cmd.Env = env
+	return g.executor.ExecuteWithOutput(ctx, cmd)
+}
+
+// commitHooks are the git hooks that can reject a commit
+var commitHooks = []string{"pre-commit", "commit-msg"}
+
+// runCheckpointCommit runs git commit in dir with args, attributed to CommitAuthor if one is
+// configured and bypassing commit hooks with NoVerify
+func (g *Gitbak) runCheckpointCommit(ctx context.Context, dir string, args ...string) error {
+	if g.config.NoVerify {
+		args = append([]string{"--no-verify"}, args...)
+	}
+
+	var err error
+	if g.config.CommitAuthor == "" {
+		err = g.executor.ExecuteWithContext(ctx, "git", append([]string{"-C", dir, "commit"}, args...)...)
+	} else {
+		author := "--author=" + g.config.CommitAuthor + " <" + g.config.CommitEmail + ">"
+		_, err = g.runAsCheckpointIdentity(ctx, dir, append([]string{"commit", author}, args...)...)
+	}
+	if err != nil && !g.config.NoVerify {
+		err = g.classifyHookFailure(ctx, dir, err)
+	}
+	return err
+}
+
+// classifyHookFailure returns the failure of a commit in dir as a HookError if dir has a
+// commit hook, which then most likely rejected it: git says nothing of its own when a hook
+// does. Failures git explains, such as a locked index, and timeouts are left as they are.
+func (g *Gitbak) classifyHookFailure(ctx context.Context, dir string, err error) error {
+	var gitErr *gitbakErrors.GitError
+	if g.config.Backend == BackendGoGit || ctx.Err() != nil || gitbakErrors.Is(err, gitbakErrors.ErrOperationTimeout) ||
+		(gitbakErrors.As(err, &gitErr) && gitErr.Class != nil) {
+		return err
+	}
+
+	for _, hook := range commitHooks {
+		out, hookErr := g.executor.ExecuteWithContextAndOutput(ctx, "git", "-C", dir, "rev-parse", "--git-path", "hooks/"+hook)
+		if hookErr != nil {
+			continue
+		}
+		info, statErr := os.Stat(resolveFrom(dir, strings.TrimSpace(out)))
+		// git runs hooks on Windows whatever their permissions
+		if statErr == nil && info.Mode().IsRegular() && (runtime.GOOS == "windows" || info.Mode()&0o111 != 0) {
+			return gitbakErrors.NewHookError(hook, err)
+		}
+	}
+	return err
+}
+
+// coAuthorTrailers returns a Co-authored-by trailer for each of CoAuthors
+func (g *Gitbak) coAuthorTrailers() []string {
+	trailers := make([]string, 0, len(g.config.CoAuthors))
+	for _, coAuthor := range g.config.CoAuthors {
+		trailers = append(trailers, "Co-authored-by: "+coAuthor)
+	}
+	return trailers
+}
+
+// messageArgs returns the -m arguments for a checkpoint message, with the CoAuthors
+// trailers in a paragraph of their own at the end
+func (g *Gitbak) messageArgs(message string) []string {
+	args := []string{"-m", message}
+	if trailers := g.coAuthorTrailers(); len(trailers) > 0 {
+		args = append(args, "-m", strings.Join(trailers, "\n"))
+	}
+	return args
+}
@@ -0,0 +1,219 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestCheckpointIdentity tests that checkpoints are attributed to CommitAuthor, and to the
+// git user without it, both as commits and as stash snapshots
+func TestCheckpointIdentity(t *testing.T) {
+	t.Parallel()
+
+	const bot = "gitbak bot <bot@example.com>"
+	const user = "Test User <test@example.com>"
+
+	tests := map[string]struct {
+		mode     string
+		author   string
+		email    string
+		expected string
+	}{
+		"Commit":         {mode: ModeBranch, author: "gitbak bot", email: "bot@example.com", expected: bot},
+		"CommitAsUser":   {mode: ModeBranch, expected: user},
+		"Snapshot":       {mode: ModeStash, author: "gitbak bot", email: "bot@example.com", expected: bot},
+		"SnapshotAsUser": {mode: ModeStash, expected: user},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "identity-test-branch",
+				CommitPrefix:   "[identity-test] Checkpoint",
+				CommitAuthor:   test.author,
+				CommitEmail:    test.email,
+				CreateBranch:   true,
+				NonInteractive: true,
+				Mode:           test.mode,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("Failed to initialize gitbak: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify file: %v", err)
+			}
+
+			created := false
+			if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil || !created {
+				t.Fatalf("Expected a checkpoint, got created=%v, err=%v", created, err)
+			}
+
+			rev := "HEAD"
+			if test.mode == ModeStash {
+				rev = "stash@{0}"
+			}
+			for _, format := range []string{"%an <%ae>", "%cn <%ce>"} {
+				if identity := gitOutput(t, repoPath, "log", "-1", "--format="+format, rev); identity != test.expected {
+					t.Errorf("Expected %s to be %q, got %q", format, test.expected, identity)
+				}
+			}
+		})
+	}
+}
+
+// TestCheckpointNoVerify tests that NoVerify lets checkpoints bypass a failing pre-commit hook
+func TestCheckpointNoVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		noVerify bool
+		author   string
+		email    string
+		expected bool
+	}{
+		"HookRejects":         {},
+		"NoVerify":            {noVerify: true, expected: true},
+		"NoVerifyWithAuthor":  {noVerify: true, author: "gitbak bot", email: "bot@example.com", expected: true},
+		"HookRejectsAsAuthor": {author: "gitbak bot", email: "bot@example.com"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			hook := filepath.Join(repoPath, ".git", "hooks", "pre-commit")
+			if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+				t.Fatalf("Failed to create hooks directory: %v", err)
+			}
+			if err := os.WriteFile(hook, []byte("#!/bin/sh\necho 'lint failed' >&2\nexit 1\n"), 0755); err != nil {
+				t.Fatalf("Failed to write hook: %v", err)
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "no-verify-test-branch",
+				CommitPrefix:   "[no-verify-test] Checkpoint",
+				CommitAuthor:   test.author,
+				CommitEmail:    test.email,
+				NoVerify:       test.noVerify,
+				CreateBranch:   true,
+				NonInteractive: true,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("Failed to initialize gitbak: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify file: %v", err)
+			}
+
+			created := false
+			err := gb.checkAndCommitChanges(ctx, 1, &created)
+			if test.expected && err != nil {
+				t.Errorf("Expected the hook to be skipped, got %v", err)
+			}
+			if !test.expected && err == nil {
+				t.Error("Expected the hook to fail the checkpoint")
+			}
+
+			subject := gitOutput(t, repoPath, "log", "-1", "--format=%s")
+			if checkpointed := strings.HasPrefix(subject, "[no-verify-test]"); checkpointed != test.expected {
+				t.Errorf("Expected a checkpoint to be %v, got HEAD %q", test.expected, subject)
+			}
+		})
+	}
+}
+
+// TestCheckpointCoAuthors tests that checkpoints credit CoAuthors with trailers git
+// recognizes, alongside the chain trailer
+func TestCheckpointCoAuthors(t *testing.T) {
+	t.Parallel()
+
+	coAuthors := []string{"Alice Example <alice@example.com>", "Bob Example <bob@example.com>"}
+
+	tests := map[string]struct {
+		mode         string
+		backend      string
+		chainTrailer bool
+		diffSummary  bool
+	}{
+		"Commit":       {mode: ModeBranch},
+		"ChainTrailer": {mode: ModeBranch, chainTrailer: true, diffSummary: true},
+		"Snapshot":     {mode: ModeStash},
+		"GoGit":        {mode: ModeBranch, backend: BackendGoGit},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			cfg := GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "coauthor-test-branch",
+				CommitPrefix:   "[coauthor-test] Checkpoint",
+				CoAuthors:      coAuthors,
+				CreateBranch:   true,
+				NonInteractive: true,
+				Mode:           test.mode,
+				Backend:        test.backend,
+				ChainTrailer:   test.chainTrailer,
+				DiffSummary:    test.diffSummary,
+			}
+			if test.chainTrailer {
+				cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+			}
+			gb, err := NewGitbakWithDeps(cfg, logger.New(false, "", false), NewExecutor(test.backend), NewMockInteractor(true))
+			if err != nil {
+				t.Fatalf("NewGitbakWithDeps failed: %v", err)
+			}
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("Failed to initialize gitbak: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify file: %v", err)
+			}
+
+			created := false
+			if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil || !created {
+				t.Fatalf("Expected a checkpoint, got created=%v, err=%v", created, err)
+			}
+
+			rev := "HEAD"
+			if test.mode == ModeStash {
+				rev = "stash@{0}"
+			}
+			credited := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key=Co-authored-by,valueonly)", rev)
+			if credited != strings.Join(coAuthors, "\n") {
+				t.Errorf("Expected the co-authors to be credited, got %q in:\n%s", credited, gitOutput(t, repoPath, "log", "-1", "--format=%B", rev))
+			}
+			if test.chainTrailer {
+				if chain := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key=Gitbak-Chain,valueonly)", rev); chain == "" {
+					t.Error("Expected the chain trailer to be recognized alongside the co-authors")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// BuiltinIgnoreSource names the rules gitbak applies on top of git's exclude files
+const BuiltinIgnoreSource = "gitbak (built-in)"
+
+// PathsIgnoreSource and UntrackedIgnoreSource name the layers that the -path and
+// -untracked-policy options add on top of the exclude files, and MaxFileSizeIgnoreSource
+// and SecretsIgnoreSource the options that leave out changed files by their contents
+const (
+	PathsIgnoreSource       = "-path"
+	UntrackedIgnoreSource   = "-untracked-policy"
+	MaxFileSizeIgnoreSource = "-max-file-size"
+	SecretsIgnoreSource     = "-secrets"
+)
+
+// IgnoreOptions are the session options that leave changes out of checkpoints on top of
+// the exclude files
+type IgnoreOptions struct {
+	// Paths scopes checkpoints to these files and directories, as GitbakConfig.Paths does
+	Paths []string
+
+	// UntrackedPolicy selects whether new files are taken in, as GitbakConfig.UntrackedPolicy does
+	UntrackedPolicy string
+
+	// MaxFileSizeMB and Secrets leave out changed files that are too large or look like they
+	// hold secrets, as the GitbakConfig fields of the same names do
+	MaxFileSizeMB int
+	Secrets       string
+}
+
+// LeftOutFile is a changed file that the next checkpoint leaves out because of its contents
+type LeftOutFile struct {
+	Path string
+
+	// Source is the option leaving the file out: MaxFileSizeIgnoreSource or SecretsIgnoreSource
+	Source string
+
+	// Reason says why, e.g. "12.0 MB, over the 10 MB limit"
+	Reason string
+}
+
+// builtinIgnoreRules are never checkpointed or watched, regardless of git's rules
+var builtinIgnoreRules = []IgnoreRule{
+	{Source: BuiltinIgnoreSource, Pattern: ".git/"},
+}
+
+// IgnoreRule is a single exclusion pattern and where it is defined
+type IgnoreRule struct {
+	// Source is the file the pattern comes from, relative to the repository
+	// root where possible, or BuiltinIgnoreSource
+	Source string
+
+	// Line is the line number of the pattern in Source, or 0 for built-in rules
+	Line int
+
+	// Pattern is the pattern as written, including a leading "!" for negations
+	Pattern string
+}
+
+// Negated reports whether the rule re-includes paths excluded by an earlier rule
+func (r IgnoreRule) Negated() bool {
+	return strings.HasPrefix(r.Pattern, "!")
+}
+
+// IgnoreSource is an exclude file consulted by git, or a layer added by an option such as
+// PathsIgnoreSource, together with its rules
+type IgnoreSource struct {
+	Path   string
+	Exists bool
+	Rules  []IgnoreRule
+
+	// Description says what an option's layer leaves out, and is empty for exclude files
+	Description string
+}
+
+// IgnoreMatch explains whether gitbak excludes a path from checkpoints
+type IgnoreMatch struct {
+	Path string
+
+	// Tracked is set for files already in the index. Changes to them are always
+	// checkpointed, since exclude rules only apply to untracked files.
+	Tracked bool
+
+	// Rule is the last rule matching the path, if any. A negated rule means the
+	// path was excluded by an earlier rule and re-included by this one.
+	Rule *IgnoreRule
+}
+
+// Excluded reports whether changes to the path are left out of checkpoints
+func (m *IgnoreMatch) Excluded() bool {
+	if m.Rule != nil && (m.Rule.Source == BakignoreFile || m.Rule.Source == PathsIgnoreSource) {
+		return !m.Rule.Negated()
+	}
+	return !m.Tracked && m.Rule != nil && !m.Rule.Negated()
+}
+
+// IgnoreSources returns the exclude files gitbak's checkpoints are subject to,
+// in increasing order of precedence: the built-in rules, the user's global excludes
+// file, the repository's info/exclude, every .gitignore in the working tree, and
+// BakignoreFile, whose rules also apply to tracked files. They are followed by the
+// layers of opts that are set: UntrackedIgnoreSource, then PathsIgnoreSource, which
+// leaves out everything, tracked or not, outside its paths.
+func (r *Repository) IgnoreSources(ctx context.Context, opts IgnoreOptions) ([]IgnoreSource, error) {
+	sources := []IgnoreSource{{Path: BuiltinIgnoreSource, Exists: true, Rules: builtinIgnoreRules}}
+
+	global, err := r.globalExcludesFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infoExclude, err := r.output(ctx, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("rev-parse", []string{"--git-path", "info/exclude"},
+			gitbakErrors.Wrap(err, "failed to locate info/exclude"), "")
+	}
+
+	paths := []string{global, infoExclude}
+
+	gitignores, err := r.gitignoreFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, gitignores...)
+	paths = append(paths, BakignoreFile)
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		source, err := r.readIgnoreSource(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return append(sources, opts.sources()...), nil
+}
+
+// sources returns the layers the options add, leaving out those that are not set
+func (opts IgnoreOptions) sources() []IgnoreSource {
+	var sources []IgnoreSource
+	switch opts.UntrackedPolicy {
+	case UntrackedPolicyNone:
+		sources = append(sources, IgnoreSource{
+			Path:        UntrackedIgnoreSource,
+			Exists:      true,
+			Rules:       []IgnoreRule{{Source: UntrackedIgnoreSource, Pattern: UntrackedPolicyNone}},
+			Description: "new files, until they are added with git add",
+		})
+	case UntrackedPolicyPrompt:
+		sources = append(sources, IgnoreSource{
+			Path:        UntrackedIgnoreSource,
+			Exists:      true,
+			Rules:       []IgnoreRule{{Source: UntrackedIgnoreSource, Pattern: UntrackedPolicyPrompt}},
+			Description: "new files declined when asked, and all of them without a terminal",
+		})
+	}
+
+	if len(opts.Paths) > 0 {
+		source := IgnoreSource{Path: PathsIgnoreSource, Exists: true, Description: "everything outside these paths"}
+		for _, path := range opts.Paths {
+			source.Rules = append(source.Rules, IgnoreRule{Source: PathsIgnoreSource, Pattern: path})
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// inScope reports whether path is one of Paths or inside one of them, as is every path
+// when Paths is not set
+func (opts IgnoreOptions) inScope(path string) bool {
+	if len(opts.Paths) == 0 {
+		return true
+	}
+	for _, scope := range opts.Paths {
+		if scope == "." || path == scope || strings.HasPrefix(path, scope+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// globalExcludesFile returns core.excludesFile, or git's default location when it is unset
+func (r *Repository) globalExcludesFile(ctx context.Context) (string, error) {
+	path, err := r.output(ctx, "config", "--path", "core.excludesFile")
+	if err == nil {
+		return path, nil
+	}
+
+	if exitCode(err) != 1 {
+		return "", gitbakErrors.NewGitError("config", []string{"core.excludesFile"},
+			gitbakErrors.Wrap(err, "failed to read core.excludesFile"), "")
+	}
+
+	// Exit code 1 means the key is unset
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", nil
+	}
+	return filepath.Join(home, ".config", "git", "ignore"), nil
+}
+
+// gitignoreFiles returns the .gitignore files git reads, shallowest first
+func (r *Repository) gitignoreFiles(ctx context.Context) ([]string, error) {
+	out, err := r.output(ctx, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", ":(glob)**/.gitignore")
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{".gitignore"},
+			gitbakErrors.Wrap(err, "failed to list .gitignore files"), "")
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+
+	// Rules in deeper directories take precedence over those above them
+	sort.SliceStable(files, func(i, j int) bool {
+		di, dj := strings.Count(files[i], "/"), strings.Count(files[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return files[i] < files[j]
+	})
+	return files, nil
+}
+
+// readIgnoreSource reads the patterns of an exclude file. A missing file is not an error.
+func (r *Repository) readIgnoreSource(path string) (IgnoreSource, error) {
+	source := IgnoreSource{Path: path}
+
+	fullPath := path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(r.path, path)
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return source, nil
+		}
+		return source, gitbakErrors.Wrapf(err, "failed to read %s", path)
+	}
+	source.Exists = true
+
+	for i, line := range strings.Split(string(data), "\n") {
+		pattern := strings.TrimRight(line, "\r")
+		if strings.TrimSpace(pattern) == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		source.Rules = append(source.Rules, IgnoreRule{Source: path, Line: i + 1, Pattern: pattern})
+	}
+	return source, nil
+}
+
+// ExplainIgnore reports whether path, relative to the repository root, is excluded
+// from checkpoints with the options opts, and which rule decides it.
+func (r *Repository) ExplainIgnore(ctx context.Context, path string, opts IgnoreOptions) (*IgnoreMatch, error) {
+	match := &IgnoreMatch{Path: path}
+
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if clean == ".git" || strings.HasPrefix(clean, ".git/") {
+		match.Rule = &builtinIgnoreRules[0]
+		return match, nil
+	}
+	if !opts.inScope(clean) {
+		match.Rule = &IgnoreRule{Source: PathsIgnoreSource, Pattern: "outside " + strings.Join(opts.Paths, ", ")}
+		return match, nil
+	}
+
+	tracked, err := r.output(ctx, "ls-files", "--cached", "--", path)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{path}, gitbakErrors.Wrap(err, "failed to check whether path is tracked"), "")
+	}
+	match.Tracked = tracked != ""
+
+	// BakignoreFile excludes tracked files too, so it is consulted first
+	info, err := os.Stat(filepath.Join(r.path, path))
+	rule, err := r.bakignoreRule(path, err == nil && info.IsDir())
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && !rule.Negated() {
+		match.Rule = rule
+		return match, nil
+	}
+	if match.Tracked {
+		return match, nil
+	}
+
+	out, err := r.output(ctx, "check-ignore", "--verbose", "--", path)
+	switch {
+	case err == nil:
+		rule, ok := parseCheckIgnore(out)
+		if !ok {
+			return nil, gitbakErrors.Wrapf(gitbakErrors.ErrGitOperationFailed, "unexpected check-ignore output %q", out)
+		}
+		match.Rule = rule
+	case exitCode(err) != 1:
+		// Exit code 1 means no rule matches the path
+		return nil, gitbakErrors.NewGitError("check-ignore", []string{path}, gitbakErrors.Wrap(err, "failed to check ignore rules"), "")
+	}
+
+	// A new file that the exclude files let through is still subject to UntrackedPolicy
+	if match.Rule == nil || match.Rule.Negated() {
+		if sources := opts.sources(); len(sources) > 0 && sources[0].Path == UntrackedIgnoreSource {
+			match.Rule = &sources[0].Rules[0]
+		}
+	}
+	return match, nil
+}
+
+// LeftOutFiles lists the changed files that the next checkpoint leaves out under opts because
+// they are over MaxFileSizeMB or, with SecretsSkip, look like they hold secrets: the files
+// over the limit first, then those with secrets, each by path. Files left out by the exclude
+// files or by the other options are not listed. A session asks about such files when it runs
+// interactively, and may be told to take one in; without a terminal, every one is left out.
+func (r *Repository) LeftOutFiles(ctx context.Context, opts IgnoreOptions) ([]LeftOutFile, error) {
+	if opts.MaxFileSizeMB <= 0 && opts.Secrets != SecretsSkip {
+		return nil, nil
+	}
+
+	g := &Gitbak{
+		config: GitbakConfig{
+			RepoPath:      r.path,
+			Paths:         opts.Paths,
+			MaxFileSizeMB: opts.MaxFileSizeMB,
+			Secrets:       opts.Secrets,
+		},
+		executor: r.executor,
+	}
+	excluded, err := g.bakignoredPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Under UntrackedPolicyPrompt a new file may yet be taken in, so only none leaves them out
+	if opts.UntrackedPolicy == UntrackedPolicyNone {
+		g.config.UntrackedPolicy = UntrackedPolicyNone
+		untracked, err := g.untrackedPaths(ctx, excluded)
+		if err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, untracked...)
+	}
+
+	oversized, err := g.oversizedFiles(ctx, excluded)
+	if err != nil {
+		return nil, err
+	}
+	var files []LeftOutFile
+	for _, file := range oversized {
+		files = append(files, LeftOutFile{
+			Path:   file.path,
+			Source: MaxFileSizeIgnoreSource,
+			Reason: fmt.Sprintf("%.1f MB, over the %d MB limit", float64(file.size)/bytesPerMB, opts.MaxFileSizeMB),
+		})
+		excluded = append(excluded, file.path)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	if opts.Secrets != SecretsSkip {
+		return files, nil
+	}
+	findings, err := g.scanChanges(ctx, excluded)
+	if err != nil {
+		return nil, err
+	}
+	for _, finding := range findings {
+		files = append(files, LeftOutFile{
+			Path:   finding.path,
+			Source: SecretsIgnoreSource,
+			Reason: fmt.Sprintf("looks like it holds a secret (%s)", finding),
+		})
+	}
+	return files, nil
+}
+
+// parseCheckIgnore parses a "<source>:<line>:<pattern>\t<path>" line of git check-ignore --verbose
+func parseCheckIgnore(out string) (*IgnoreRule, bool) {
+	rule, _, ok := strings.Cut(out, "\t")
+	if !ok {
+		return nil, false
+	}
+
+	// The pattern may itself contain colons, so only the first two are separators
+	parts := strings.SplitN(rule, ":", 3)
+	if len(parts) != 3 {
+		return nil, false
+	}
+	line, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	return &IgnoreRule{Source: parts[0], Line: line, Pattern: parts[2]}, true
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// indexLockRetries is how many times a check is retried while another git process holds
+// the index lock, before it is given up until the next check
+const indexLockRetries = 3
+
+// indexLockRetryDelay is the average wait before the first retry of a check that found the
+// index locked; each further retry waits that much longer
+const indexLockRetryDelay = 200 * time.Millisecond
+
+// retryIndexLocked runs operation, running it again after a short randomized delay while
+// it fails because another git process, such as the editor's or a git command in a
+// terminal, holds the repository's index lock. That process usually finishes within
+// moments, so the check goes ahead instead of failing.
+func (g *Gitbak) retryIndexLocked(ctx context.Context, operation func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil || attempt > indexLockRetries || !gitbakErrors.Is(err, gitbakErrors.ErrIndexLocked) {
+			return err
+		}
+
+		random := rand.Float64
+		if g.random != nil {
+			random = g.random
+		}
+		// Randomized, so that gitbak and the other process don't keep colliding
+		delay := time.Duration(float64(indexLockRetryDelay*time.Duration(attempt)) * (0.5 + random()))
+		g.logger.Info("The git index is locked by another git process, retrying in %v", delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
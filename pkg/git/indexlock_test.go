@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestIndexLockRetry tests that checks failing on the index lock are retried, without
+// counting towards MaxRetries
+func TestIndexLockRetry(t *testing.T) {
+	t.Parallel()
+
+	locked := gitbakErrors.NewGitError("commit", nil, gitbakErrors.ErrGitOperationFailed,
+		"fatal: Unable to create '/repo/.git/index.lock': File exists.")
+	diskFull := errors.New("disk full")
+
+	tests := map[string]struct {
+		failures          int
+		err               error
+		expectCalls       int
+		expectErr         error
+		expectConsecutive int
+	}{
+		"LockReleased": {
+			failures:    2,
+			err:         locked,
+			expectCalls: 3,
+		},
+		"StillLocked": {
+			failures:          10,
+			err:               locked,
+			expectCalls:       indexLockRetries + 1,
+			expectErr:         gitbakErrors.ErrIndexLocked,
+			expectConsecutive: 2, // The streak of the earlier error is left alone
+		},
+		"OtherError": {
+			failures:          10,
+			err:               diskFull,
+			expectCalls:       1,
+			expectErr:         diskFull,
+			expectConsecutive: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:     t.TempDir(),
+				Interval:     time.Minute,
+				BranchName:   "gitbak-lock",
+				CommitPrefix: "[gitbak-lock] Commit",
+				MaxRetries:   3,
+			}, logger.New(false, "", false))
+			gb.random = func() float64 { return 0 }
+
+			errorState := struct {
+				consecutiveErrors int
+				lastErrorMsg      string
+			}{consecutiveErrors: 2, lastErrorMsg: "push rejected"}
+
+			calls := 0
+			err := gb.tryOperation(context.Background(), &errorState, func() error {
+				calls++
+				if calls <= test.failures {
+					return test.err
+				}
+				return nil
+			})
+
+			if calls != test.expectCalls {
+				t.Errorf("Expected %d attempts, got %d", test.expectCalls, calls)
+			}
+			if test.expectErr == nil && err != nil {
+				t.Errorf("Expected the check to succeed, got %v", err)
+			}
+			if test.expectErr != nil && !gitbakErrors.Is(err, test.expectErr) {
+				t.Errorf("Expected %v, got %v", test.expectErr, err)
+			}
+			if errorState.consecutiveErrors != test.expectConsecutive {
+				t.Errorf("Expected %d consecutive errors, got %d", test.expectConsecutive, errorState.consecutiveErrors)
+			}
+		})
+	}
+}
+
+// TestIndexLockRetryCheckpoint tests that a checkpoint is made once another git process
+// releases the index lock
+func TestIndexLockRetryCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	writeRepoFile(t, repoPath, "work.txt", "work\n")
+	lockPath := filepath.Join(repoPath, ".git", "index.lock")
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatalf("Failed to create index lock: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:     repoPath,
+		Interval:     time.Minute,
+		BranchName:   "gitbak-lock",
+		CommitPrefix: "[gitbak-lock] Commit",
+		MaxRetries:   1,
+	}, logger.New(false, "", false))
+	gb.random = func() float64 { return 0 }
+
+	// Another git process finishing before the second retry
+	released := time.AfterFunc(150*time.Millisecond, func() { _ = os.Remove(lockPath) })
+	defer released.Stop()
+
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	var commitWasCreated bool
+	err := gb.tryOperation(context.Background(), &errorState, func() error {
+		return gb.checkAndCommitChanges(context.Background(), 1, &commitWasCreated)
+	})
+	if err != nil {
+		t.Fatalf("Expected the check to succeed once the lock was released, got %v", err)
+	}
+	if !commitWasCreated {
+		t.Errorf("Expected a checkpoint once the lock was released")
+	}
+}
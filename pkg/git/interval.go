@@ -0,0 +1,122 @@
+package git
+
+import "time"
+
+// idleChecksBeforeBackoff is how many checks in a row must find nothing to commit
+// before the adaptive interval is doubled
+const idleChecksBeforeBackoff = 3
+
+// busyChecksBeforeSpeedup is how many checks in a row must create a checkpoint
+// before the adaptive interval is halved
+const busyChecksBeforeSpeedup = 3
+
+// intervalSchedule adapts the time between checks to the activity in the repository.
+// Idle repositories are checked less and less often, down to one check per max, so
+// that they cost fewer git processes; busy ones are checked more often, down to min.
+// The first checkpoint after an idle period restores the configured interval at once.
+//
+// With idleAfter set, a repository whose checks find no changes at all that many times in
+// a row goes idle: it is checked only every idleInterval until a check finds changes again.
+type intervalSchedule struct {
+	base, min, max time.Duration
+
+	// current is the interval until the next check
+	current time.Duration
+
+	// idle and busy count the checks in a row without and with a checkpoint
+	idle, busy int
+
+	// idleAfter is how many checks in a row without changes make the schedule go idle,
+	// checking every idleInterval; zero never goes idle
+	idleAfter    int
+	idleInterval time.Duration
+
+	// quiet counts the checks in a row that found no changes, and idling is set while idle
+	quiet  int
+	idling bool
+}
+
+// newIntervalSchedule creates a schedule for the configured interval and bounds.
+// Unset bounds default to the interval itself, so the schedule never adapts in that direction.
+func newIntervalSchedule(config GitbakConfig) *intervalSchedule {
+	s := &intervalSchedule{base: config.Interval}
+	s.current = s.base
+
+	s.min = s.base
+	if config.MinInterval > 0 && config.MinInterval < s.base {
+		s.min = config.MinInterval
+	}
+	s.max = s.base
+	if config.MaxInterval > s.base {
+		s.max = config.MaxInterval
+	}
+
+	if config.IdleAfter > 0 {
+		s.idleAfter = config.IdleAfter
+		s.idleInterval = max(config.IdleInterval, s.base)
+	}
+	return s
+}
+
+// adaptive reports whether the interval can change at all
+func (s *intervalSchedule) adaptive() bool {
+	return s.min < s.base || s.max > s.base
+}
+
+// observe records whether a check created a checkpoint and returns the interval until the next check
+func (s *intervalSchedule) observe(committed bool) time.Duration {
+	if !committed && s.idling {
+		// observeChanges alone ends an idle period
+		return s.current
+	}
+	if !committed {
+		s.busy = 0
+		s.idle++
+		if s.idle >= idleChecksBeforeBackoff {
+			s.idle = 0
+			s.current = min(s.current*2, s.max)
+		}
+		return s.current
+	}
+
+	s.idle = 0
+	s.busy++
+	if s.current > s.base {
+		// Activity resumed after an idle period
+		s.current = s.base
+		return s.current
+	}
+	if s.busy >= busyChecksBeforeSpeedup {
+		s.busy = 0
+		s.current = max(s.current/2, s.min)
+	}
+	return s.current
+}
+
+// observeChanges records whether a check found any changes, committed or not, going idle
+// after idleAfter checks in a row without any and waking at the first check with some.
+// It reports whether the schedule went idle or woke up.
+func (s *intervalSchedule) observeChanges(changed bool) bool {
+	if s.idleAfter == 0 {
+		return false
+	}
+
+	if changed {
+		s.quiet = 0
+		if !s.idling {
+			return false
+		}
+		s.idling = false
+		s.idle = 0
+		s.current = s.base
+		return true
+	}
+
+	s.quiet++
+	if s.idling || s.quiet < s.idleAfter {
+		return false
+	}
+	s.idling = true
+	s.current = max(s.current, s.idleInterval)
+	return true
+}
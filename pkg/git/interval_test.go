@@ -0,0 +1,197 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+func TestIntervalSchedule(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config   GitbakConfig
+		checks   []bool
+		expected []time.Duration
+		adaptive bool
+	}{
+		"Fixed": {
+			config:   GitbakConfig{Interval: 5 * time.Minute},
+			checks:   []bool{false, false, false, true, true, true},
+			expected: []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		"BacksOffWhileIdle": {
+			config:   GitbakConfig{Interval: 5 * time.Minute, MaxInterval: 15 * time.Minute},
+			checks:   []bool{false, false, false, false, false, false, false, false, false},
+			expected: []time.Duration{5 * time.Minute, 5 * time.Minute, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute, 15 * time.Minute, 15 * time.Minute, 15 * time.Minute, 15 * time.Minute},
+			adaptive: true,
+		},
+		"CheckpointRestoresInterval": {
+			config:   GitbakConfig{Interval: 5 * time.Minute, MaxInterval: 30 * time.Minute},
+			checks:   []bool{false, false, false, true},
+			expected: []time.Duration{5 * time.Minute, 5 * time.Minute, 10 * time.Minute, 5 * time.Minute},
+			adaptive: true,
+		},
+		"SpeedsUpWhileBusy": {
+			config:   GitbakConfig{Interval: 4 * time.Minute, MinInterval: 90 * time.Second},
+			checks:   []bool{true, true, true, true, true, true, false},
+			expected: []time.Duration{4 * time.Minute, 4 * time.Minute, 2 * time.Minute, 2 * time.Minute, 2 * time.Minute, 90 * time.Second, 90 * time.Second},
+			adaptive: true,
+		},
+		"IdleCheckResetsBusyStreak": {
+			config:   GitbakConfig{Interval: 4 * time.Minute, MinInterval: time.Minute},
+			checks:   []bool{true, true, false, true, true},
+			expected: []time.Duration{4 * time.Minute, 4 * time.Minute, 4 * time.Minute, 4 * time.Minute, 4 * time.Minute},
+			adaptive: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			schedule := newIntervalSchedule(test.config)
+			if schedule.adaptive() != test.adaptive {
+				t.Errorf("Expected adaptive to be %v", test.adaptive)
+			}
+
+			for i, committed := range test.checks {
+				if got := schedule.observe(committed); got != test.expected[i] {
+					t.Errorf("Check %d (committed: %v): expected interval %v, got %v", i+1, committed, test.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+// TestIntervalScheduleIdle tests going idle after checks without changes, and waking up
+func TestIntervalScheduleIdle(t *testing.T) {
+	t.Parallel()
+
+	// Each check is '.' for no changes, 'h' for changes held back below the threshold
+	// and 'c' for a checkpoint
+	tests := map[string]struct {
+		config      GitbakConfig
+		checks      string
+		expected    []time.Duration
+		transitions []int
+	}{
+		"Disabled": {
+			config:   GitbakConfig{Interval: 5 * time.Minute, IdleInterval: 30 * time.Minute},
+			checks:   "....",
+			expected: []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		"GoesIdleAndWakes": {
+			config:      GitbakConfig{Interval: 5 * time.Minute, IdleAfter: 3, IdleInterval: 30 * time.Minute},
+			checks:      "....c.",
+			expected:    []time.Duration{5 * time.Minute, 5 * time.Minute, 30 * time.Minute, 30 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+			transitions: []int{3, 5},
+		},
+		"HeldBackChangesKeepAwake": {
+			config:      GitbakConfig{Interval: 5 * time.Minute, IdleAfter: 2, IdleInterval: 30 * time.Minute},
+			checks:      ".h.h..h",
+			expected:    []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 30 * time.Minute, 5 * time.Minute},
+			transitions: []int{6, 7},
+		},
+		"IdleHoldsBackoff": {
+			config:      GitbakConfig{Interval: 5 * time.Minute, MaxInterval: time.Hour, IdleAfter: 4, IdleInterval: 20 * time.Minute},
+			checks:      "......",
+			expected:    []time.Duration{5 * time.Minute, 5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 20 * time.Minute, 20 * time.Minute},
+			transitions: []int{4},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			schedule := newIntervalSchedule(test.config)
+			var transitions []int
+			for i, check := range test.checks {
+				schedule.observe(check == 'c')
+				if schedule.observeChanges(check != '.') {
+					transitions = append(transitions, i+1)
+				}
+				if schedule.current != test.expected[i] {
+					t.Errorf("Check %d (%c): expected interval %v, got %v", i+1, check, test.expected[i], schedule.current)
+				}
+			}
+			if !slices.Equal(transitions, test.transitions) {
+				t.Errorf("Expected the idle state to change at checks %v, got %v", test.transitions, transitions)
+			}
+		})
+	}
+}
+
+// TestRunCheckIdle tests that an idle session reports going idle and waking up once,
+// instead of reporting every check without changes
+func TestRunCheckIdle(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	var out bytes.Buffer
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		IdleAfter:      2,
+		IdleInterval:   10 * time.Minute,
+		BranchName:     "gitbak-idle",
+		CommitPrefix:   "[gitbak-idle]",
+		CreateBranch:   true,
+		NonInteractive: true,
+		ShowNoChanges:  true,
+		Verbose:        true,
+		MaxRetries:     3,
+	}, logger.NewWithOutput(false, "", true, &out, &out))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	gb.schedule = newIntervalSchedule(gb.config)
+
+	counter := 1
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	check := func() {
+		t.Helper()
+		if err := gb.runCheck(ctx, &counter, &errorState); err != nil {
+			t.Fatalf("runCheck failed: %v", err)
+		}
+	}
+
+	for range 4 {
+		check()
+	}
+	if got := strings.Count(out.String(), "No changes to commit"); got != 2 {
+		t.Errorf("Expected only the checks before going idle to report no changes, got %d in:\n%s", got, out.String())
+	}
+	if got := strings.Count(out.String(), "💤 No changes for 2 checks"); got != 1 {
+		t.Errorf("Expected going idle to be reported once, got %d in:\n%s", got, out.String())
+	}
+	if gb.schedule.current != 10*time.Minute {
+		t.Errorf("Expected the idle interval while idle, got %v", gb.schedule.current)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("back to work"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	check()
+	if !strings.Contains(out.String(), "⏰ Changes detected") {
+		t.Errorf("Expected waking up to be reported, got:\n%s", out.String())
+	}
+	if gb.schedule.current != time.Minute {
+		t.Errorf("Expected the interval to be restored, got %v", gb.schedule.current)
+	}
+}
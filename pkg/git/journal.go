@@ -0,0 +1,350 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// JournalEntry describes a change ModeObserve detected, as one line of the journal file
+type JournalEntry struct {
+	// Number counts the session's entries, from 1
+	Number int `json:"number"`
+
+	// Time is when the check that detected the change ran
+	Time time.Time `json:"time"`
+
+	// Branch is the branch checked out at the time, or the abbreviated commit HEAD was detached at
+	Branch string `json:"branch"`
+
+	// Head is the commit HEAD pointed to, or empty in a repository without commits
+	Head string `json:"head,omitempty"`
+
+	// Files lists the files that changed since the previous entry, sorted by path
+	Files []JournalFile `json:"files"`
+
+	// Insertions and Deletions total the lines of Files
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// JournalFile describes one file of a JournalEntry and how it now differs from HEAD
+type JournalFile struct {
+	Path string `json:"path"`
+
+	// Status is one of the Journal* file statuses
+	Status string `json:"status"`
+
+	// Insertions and Deletions count the lines the file differs from HEAD by, or
+	// for an untracked file the lines it holds; both are zero for binary files
+	Insertions int  `json:"insertions"`
+	Deletions  int  `json:"deletions"`
+	Binary     bool `json:"binary,omitempty"`
+}
+
+// Statuses of the files in a JournalEntry
+const (
+	JournalModified  = "modified"
+	JournalAdded     = "added"
+	JournalDeleted   = "deleted"
+	JournalRenamed   = "renamed"
+	JournalUntracked = "untracked"
+
+	// JournalClean is a file that no longer differs from HEAD, because its changes were
+	// committed or reverted since the previous entry
+	JournalClean = "clean"
+)
+
+// observeMode reports whether changes are only journaled, without recording checkpoints
+func (g *Gitbak) observeMode() bool {
+	return g.config.Mode == ModeObserve
+}
+
+// setupObserveSession configures gitbak to journal changes, and notes the state of the
+// working tree to compare the first check against
+func (g *Gitbak) setupObserveSession(ctx context.Context) error {
+	g.config.CreateBranch = false
+
+	fingerprints, err := g.changeFingerprints(ctx)
+	if err != nil {
+		return err
+	}
+	g.journaled = fingerprints
+	g.logger.StatusMessage("👀 Observe mode: changes are journaled to %s, nothing is committed", g.config.JournalFile)
+	return nil
+}
+
+// checkAndJournalChanges appends an entry to the journal if any file changed since the
+// previous check. It never writes to the repository: files are fingerprinted by their
+// git status and contents, so that editing an already modified file counts as a change.
+func (g *Gitbak) checkAndJournalChanges(ctx context.Context, number int, journaled *bool) error {
+	*journaled = false
+
+	fingerprints, err := g.changeFingerprints(ctx)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for path, fingerprint := range fingerprints {
+		if g.journaled[path] != fingerprint {
+			changed = append(changed, path)
+		}
+	}
+	for path := range g.journaled {
+		if _, ok := fingerprints[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) == 0 {
+		if g.config.ShowNoChanges && g.config.Verbose && !g.idling() {
+			g.logger.InfoToUser("No changes to journal at %s", time.Now().Format("15:04:05"))
+			g.logger.Info("No changes to journal detected")
+		}
+		return nil
+	}
+	sort.Strings(changed)
+
+	entry, err := g.journalEntry(ctx, number, changed, fingerprints)
+	if err != nil {
+		return err
+	}
+	if err := appendJournal(g.config.JournalFile, entry); err != nil {
+		g.logger.WarningToUser("Failed to write the journal: %v", err)
+		return err
+	}
+
+	*journaled = true
+	g.journaled = fingerprints
+	g.logger.Success("Change #%d journaled at %s: %d file(s), +%d -%d",
+		number, entry.Time.Format("2006-01-02 15:04:05"), len(entry.Files), entry.Insertions, entry.Deletions)
+	g.logger.Info("Successfully journaled change #%d", number)
+
+	g.commitsCount = number
+	g.lastCommitTime = entry.Time
+	g.saveState()
+	return nil
+}
+
+// changeFingerprints returns a fingerprint of every changed file outside the excluded
+// paths, keyed by path: its two-letter git status followed by a hash of its contents
+func (g *Gitbak) changeFingerprints(ctx context.Context) (map[string]string, error) {
+	statuses, err := g.changeStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprints := make(map[string]string, len(statuses))
+	for path, status := range statuses {
+		fingerprints[path] = status + " " + fileHash(filepath.Join(g.config.RepoPath, filepath.FromSlash(path)))
+	}
+	return fingerprints, nil
+}
+
+// changeStatuses returns the git status of every changed file outside the excluded paths,
+// keyed by path. Untracked directories are listed file by file.
+func (g *Gitbak) changeStatuses(ctx context.Context) (map[string]string, error) {
+	untracked := UntrackedAll
+	if g.config.UntrackedFiles == UntrackedNo {
+		untracked = UntrackedNo
+	}
+	args := append(append([]string{}, g.statusConfig...), "status", "--porcelain", "-z", "--untracked-files="+untracked)
+	args = append(args, g.submoduleStatusArgs()...)
+
+	excluded, err := g.excludedPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) > 0 || len(g.config.Paths) > 0 {
+		args = append(append(args, "--"), g.scopePathspec()...)
+		for _, path := range excluded {
+			args = append(args, ":(exclude,literal)"+strings.TrimSuffix(path, "/"))
+		}
+	}
+
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("status", args[len(g.statusConfig)+1:], gitbakErrors.Wrap(err, "failed to check git status"), "")
+	}
+
+	statuses := make(map[string]string)
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		if len(fields[i]) < 4 {
+			continue
+		}
+		status, path := fields[i][:2], fields[i][3:]
+		statuses[path] = status
+		if status[0] == 'R' || status[0] == 'C' {
+			// The original path follows the new one
+			i++
+		}
+	}
+	return statuses, nil
+}
+
+// journalEntry describes the changed paths, given the fingerprints of the current changes
+func (g *Gitbak) journalEntry(ctx context.Context, number int, changed []string, fingerprints map[string]string) (JournalEntry, error) {
+	entry := JournalEntry{Number: number, Time: time.Now(), Branch: g.originalBranch, Files: []JournalFile{}}
+	if branch, err := g.getCurrentBranch(ctx); err == nil && branch != "" {
+		entry.Branch = branch
+	}
+
+	unborn, err := g.isUnbornHead(ctx)
+	if err != nil {
+		return entry, err
+	}
+	if !unborn {
+		head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD")
+		if err != nil {
+			return entry, gitbakErrors.NewGitError("rev-parse", []string{"HEAD"}, gitbakErrors.Wrap(err, "failed to resolve HEAD"), "")
+		}
+		entry.Head = strings.TrimSpace(head)
+	}
+
+	var tracked []string
+	for _, path := range changed {
+		status := journalStatus(fingerprints[path])
+		if status != JournalUntracked && status != JournalClean && !unborn {
+			tracked = append(tracked, path)
+		}
+	}
+	stats, err := g.numstat(ctx, tracked)
+	if err != nil {
+		return entry, err
+	}
+
+	for _, path := range changed {
+		file := JournalFile{Path: path, Status: journalStatus(fingerprints[path])}
+		switch stat, ok := stats[path]; {
+		case ok:
+			file.Insertions, file.Deletions, file.Binary = stat.Insertions, stat.Deletions, stat.Binary
+		case file.Status != JournalClean && file.Status != JournalDeleted:
+			// Untracked files, and any file before the first commit, are all new lines
+			file.Insertions, file.Binary = countLines(filepath.Join(g.config.RepoPath, filepath.FromSlash(path)))
+		}
+		entry.Insertions += file.Insertions
+		entry.Deletions += file.Deletions
+		entry.Files = append(entry.Files, file)
+	}
+	return entry, nil
+}
+
+// numstat returns how each of paths differs from HEAD in the working tree, keyed by path
+func (g *Gitbak) numstat(ctx context.Context, paths []string) (map[string]JournalFile, error) {
+	stats := make(map[string]JournalFile, len(paths))
+	if len(paths) == 0 {
+		return stats, nil
+	}
+
+	args := append([]string{"diff", "HEAD", "--numstat", "-z", "--no-renames", "--"}, paths...)
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("diff", args[1:5], gitbakErrors.Wrap(err, "failed to measure changes"), "")
+	}
+	for _, line := range strings.Split(out, "\x00") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stat := JournalFile{Path: fields[2]}
+		if fields[0] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Insertions, _ = strconv.Atoi(fields[0])
+			stat.Deletions, _ = strconv.Atoi(fields[1])
+		}
+		stats[stat.Path] = stat
+	}
+	return stats, nil
+}
+
+// journalStatus returns the Journal* status described by a change fingerprint,
+// or JournalClean for a file that no longer has one
+func journalStatus(fingerprint string) string {
+	if len(fingerprint) < 2 {
+		return JournalClean
+	}
+	switch x, y := fingerprint[0], fingerprint[1]; {
+	case x == '?':
+		return JournalUntracked
+	case x == 'D' || y == 'D':
+		return JournalDeleted
+	case x == 'R':
+		return JournalRenamed
+	case x == 'A':
+		return JournalAdded
+	default:
+		return JournalModified
+	}
+}
+
+// fileHash returns a hash of the file at path, or of the target of a symlink, and an empty
+// string for a file that cannot be read, such as a deleted one
+func fileHash(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return ""
+	}
+	var data []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return ""
+		}
+		data = []byte(target)
+	} else if data, err = os.ReadFile(path); err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// countLines returns the number of lines in the file at path, counting a last line without
+// a newline, or reports it as binary if it holds a NUL byte, as git does
+func countLines(path string) (lines int, binary bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return 0, true
+	}
+	lines = bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines, false
+}
+
+// appendJournal appends entry to the journal file at path as a line of JSON
+func appendJournal(path string, entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to encode journal entry")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return gitbakErrors.Wrap(err, "failed to create journal directory")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to open journal")
+	}
+	if _, err := fmt.Fprintf(f, "%s\n", data); err != nil {
+		_ = f.Close()
+		return gitbakErrors.Wrap(err, "failed to write journal")
+	}
+	return f.Close()
+}
@@ -0,0 +1,231 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// readJournal returns the entries of the journal file at path
+func readJournal(t *testing.T, path string) []JournalEntry {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to decode journal line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestObserveMode tests that changes are journaled without anything being written to the repository
+func TestObserveMode(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	journalFile := filepath.Join(t.TempDir(), "journal", "session.jsonl")
+	startBranch := gitOutput(t, repoPath, "branch", "--show-current")
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+	// Changes made before the session started are not journaled until they change again
+	if err := os.WriteFile(filepath.Join(repoPath, "before.txt"), []byte("before\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-observe",
+		CommitPrefix:   "[gitbak-observe] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+		Mode:           ModeObserve,
+		JournalFile:    journalFile,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	objectsBefore := gitOutput(t, repoPath, "count-objects")
+
+	check := func(number int) bool {
+		t.Helper()
+		var journaled bool
+		if err := gb.checkAndCommitChanges(ctx, number, &journaled); err != nil {
+			t.Fatalf("checkAndCommitChanges failed: %v", err)
+		}
+		return journaled
+	}
+
+	if check(1) {
+		t.Fatal("Expected nothing to be journaled before anything changed")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoPath, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "docs", "notes.md"), []byte("a\nb\nc"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if !check(1) {
+		t.Fatal("Expected the changes to be journaled")
+	}
+	if check(2) {
+		t.Error("Expected nothing to be journaled when nothing changed since the last entry")
+	}
+
+	// An edit that keeps the line counts is still a change
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("one\nTWO\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if !check(2) {
+		t.Fatal("Expected the further edit to be journaled")
+	}
+
+	if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != startBranch {
+		t.Errorf("Expected to stay on %s, got %s", startBranch, branch)
+	}
+	if head := gitOutput(t, repoPath, "rev-parse", "HEAD"); head != startCommit {
+		t.Errorf("Expected HEAD to stay at %s, got %s", startCommit, head)
+	}
+	if objects := gitOutput(t, repoPath, "count-objects"); objects != objectsBefore {
+		t.Errorf("Expected no objects to be written, got %q instead of %q", objects, objectsBefore)
+	}
+	if staged := gitOutput(t, repoPath, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("Expected the index to be left alone, got %q staged", staged)
+	}
+
+	// Committing the changes outside gitbak makes them clean
+	gitOutput(t, repoPath, "add", "initial.txt")
+	gitOutput(t, repoPath, "commit", "-m", "Commit by hand")
+	if !check(3) {
+		t.Fatal("Expected the commit to be journaled")
+	}
+
+	entries := readJournal(t, journalFile)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 journal entries, got %+v", entries)
+	}
+
+	first := entries[0]
+	if first.Number != 1 || first.Branch != startBranch || first.Head != startCommit {
+		t.Errorf("Expected entry #1 on %s at %s, got %+v", startBranch, startCommit, first)
+	}
+	expectedFiles := []JournalFile{
+		{Path: "docs/notes.md", Status: JournalUntracked, Insertions: 3},
+		{Path: "initial.txt", Status: JournalModified, Insertions: 2, Deletions: 1},
+	}
+	if len(first.Files) != len(expectedFiles) {
+		t.Fatalf("Expected files %+v, got %+v", expectedFiles, first.Files)
+	}
+	for i, file := range first.Files {
+		if file != expectedFiles[i] {
+			t.Errorf("Expected file %+v, got %+v", expectedFiles[i], file)
+		}
+	}
+	if first.Insertions != 5 || first.Deletions != 1 {
+		t.Errorf("Expected +5 -1 in total, got +%d -%d", first.Insertions, first.Deletions)
+	}
+
+	if files := entries[1].Files; len(files) != 1 || files[0].Path != "initial.txt" {
+		t.Errorf("Expected only initial.txt in entry #2, got %+v", files)
+	}
+	if files := entries[2].Files; len(files) != 1 || files[0].Status != JournalClean || entries[2].Head == startCommit {
+		t.Errorf("Expected initial.txt to be clean at the new commit in entry #3, got %+v", entries[2])
+	}
+	if gb.commitsCount != 3 {
+		t.Errorf("Expected 3 changes to be counted, got %d", gb.commitsCount)
+	}
+}
+
+// TestObserveModeEmptyRepository tests that observing needs no initial commit
+func TestObserveModeEmptyRepository(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	gitOutput(t, repoPath, "init", "-q")
+	journalFile := filepath.Join(t.TempDir(), "session.jsonl")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-observe",
+		CommitPrefix:   "[gitbak-observe] Checkpoint",
+		NonInteractive: true,
+		Mode:           ModeObserve,
+		JournalFile:    journalFile,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	var journaled bool
+	if err := gb.checkAndCommitChanges(ctx, 1, &journaled); err != nil || !journaled {
+		t.Fatalf("Expected the new file to be journaled, got journaled=%v, err=%v", journaled, err)
+	}
+
+	if out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "HEAD").Output(); err == nil {
+		t.Errorf("Expected no commit to be made, got HEAD %s", out)
+	}
+	entries := readJournal(t, journalFile)
+	if len(entries) != 1 || entries[0].Head != "" || len(entries[0].Files) != 1 || entries[0].Files[0].Insertions != 1 {
+		t.Errorf("Expected one entry for main.go without a HEAD, got %+v", entries)
+	}
+}
+
+// TestJournalStatus tests how change fingerprints are described
+func TestJournalStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fingerprint string
+		expected    string
+	}{
+		"Untracked":       {fingerprint: "?? abc", expected: JournalUntracked},
+		"Modified":        {fingerprint: " M abc", expected: JournalModified},
+		"StagedModified":  {fingerprint: "MM abc", expected: JournalModified},
+		"Added":           {fingerprint: "A  abc", expected: JournalAdded},
+		"Deleted":         {fingerprint: " D ", expected: JournalDeleted},
+		"AddedThenDelete": {fingerprint: "AD ", expected: JournalDeleted},
+		"Renamed":         {fingerprint: "R  abc", expected: JournalRenamed},
+		"Gone":            {fingerprint: "", expected: JournalClean},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if status := journalStatus(test.fingerprint); status != test.expected {
+				t.Errorf("Expected %q for %q, got %q", test.expected, test.fingerprint, status)
+			}
+		})
+	}
+}
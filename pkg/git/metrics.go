@@ -0,0 +1,32 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// observeCheck records a finished check in the metrics collector, if metrics are enabled
+func (g *Gitbak) observeCheck(duration time.Duration, err error, consecutiveErrors int) {
+	if g.config.Metrics == nil {
+		return
+	}
+	g.config.Metrics.ObserveCheck(duration, err, consecutiveErrors)
+}
+
+// observeCheckpoint records a checkpoint in the metrics collector, if metrics are enabled.
+// diffArgs select the checkpoint for git diff-tree: a commit, or the two trees to compare.
+func (g *Gitbak) observeCheckpoint(ctx context.Context, diffArgs ...string) {
+	if g.config.Metrics == nil {
+		return
+	}
+
+	files := 0
+	out, err := g.runGitCommandWithOutput(ctx, append([]string{"diff-tree", "-r", "--no-commit-id", "--name-only"}, diffArgs...)...)
+	if err != nil {
+		g.logger.Warning("Failed to count files changed by checkpoint: %v", err)
+	} else if out = strings.TrimSpace(out); out != "" {
+		files = strings.Count(out, "\n") + 1
+	}
+	g.config.Metrics.ObserveCommit(g.lastCommitTime, files)
+}
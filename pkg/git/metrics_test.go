@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/metrics"
+)
+
+// TestCheckpointMetrics tests that checks and the files changed by each checkpoint are recorded
+func TestCheckpointMetrics(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mode string
+	}{
+		"Branch": {mode: ModeBranch},
+		"Stash":  {mode: ModeStash},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			for _, file := range []string{"initial.txt", "a.txt", "b.txt"} {
+				if err := os.WriteFile(filepath.Join(repoPath, file), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", file, err)
+				}
+			}
+
+			collector := metrics.NewCollector()
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-metrics",
+				CommitPrefix:   "[gitbak-metrics]",
+				CreateBranch:   false,
+				NonInteractive: true,
+				Mode:           test.mode,
+				MaxRetries:     3,
+				Metrics:        collector,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			counter := 1
+			errorState := struct {
+				consecutiveErrors int
+				lastErrorMsg      string
+			}{}
+			for range 2 {
+				if err := gb.runCheck(ctx, &counter, &errorState); err != nil {
+					t.Fatalf("runCheck failed: %v", err)
+				}
+			}
+
+			var b strings.Builder
+			if _, err := collector.WriteTo(&b); err != nil {
+				t.Fatalf("WriteTo failed: %v", err)
+			}
+			out := b.String()
+
+			for _, want := range []string{
+				"gitbak_checks_total 2\n",
+				"gitbak_commits_total 1\n",
+				"gitbak_errors_total 0\n",
+				"gitbak_files_changed_per_commit_sum 3\n",
+				"gitbak_check_duration_seconds_count 2\n",
+			} {
+				if !strings.Contains(out, want) {
+					t.Errorf("Expected metrics to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NotesRef is the notes ref that checkpoint metadata is recorded under. Notes are attached
+// to commits rather than to a branch, so they survive renaming the branch, and they leave
+// commit messages alone.
+const NotesRef = "refs/notes/gitbak"
+
+// Keys of the lines in a checkpoint note
+const (
+	noteSessionKey = "gitbak-session"
+	noteCounterKey = "gitbak-counter"
+	notePrefixKey  = "gitbak-prefix"
+)
+
+// checkpointNote is the metadata recorded with each checkpoint, so that -continue can pick
+// up a session from its commits alone, whatever the branch is called by now and whatever
+// prefix the checkpoint subjects carry
+type checkpointNote struct {
+	Session string
+	Counter int
+	Prefix  string
+}
+
+// String renders the note as "key: value" lines
+func (n checkpointNote) String() string {
+	return fmt.Sprintf("%s: %s\n%s: %d\n%s: %s\n", noteSessionKey, n.Session, noteCounterKey, n.Counter, notePrefixKey, n.Prefix)
+}
+
+// parseCheckpointNote parses a note written by checkpointNote.String, reporting false for
+// anything else, such as notes added by hand
+func parseCheckpointNote(text string) (checkpointNote, bool) {
+	var note checkpointNote
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case noteSessionKey:
+			note.Session = value
+		case noteCounterKey:
+			note.Counter, _ = strconv.Atoi(value)
+		case notePrefixKey:
+			note.Prefix = value
+		}
+	}
+	return note, note.Session != "" && note.Counter > 0
+}
+
+// notesSupported reports whether checkpoint notes are recorded and read. The gogit backend
+// only runs the git commands gitbak needs to checkpoint.
+func (g *Gitbak) notesSupported() bool {
+	return g.config.Backend != BackendGoGit && g.sessionID != ""
+}
+
+// recordCheckpointNote attaches the metadata of checkpoint counter to HEAD. The note only
+// helps continuing the session later, so failing to add it doesn't fail the checkpoint.
+func (g *Gitbak) recordCheckpointNote(ctx context.Context, counter int) {
+	if !g.notesSupported() {
+		return
+	}
+
+	note := checkpointNote{Session: g.sessionID, Counter: counter, Prefix: g.config.CommitPrefix}
+	// The notes ref gets commits of its own, which are attributed like checkpoints
+	_, err := g.runAsCheckpointIdentity(ctx, g.config.RepoPath, "notes", "--ref="+NotesRef, "add", "-f", "-m", note.String(), "HEAD")
+	if err != nil {
+		g.logger.Warning("Failed to record checkpoint note: %v", err)
+	}
+}
+
+// latestCheckpointNote returns the note of the most recent checkpoint on HEAD that has one,
+// among the latest commitNumberScanLimit commits
+func (g *Gitbak) latestCheckpointNote(ctx context.Context) (checkpointNote, bool, error) {
+	if g.config.Backend == BackendGoGit {
+		return checkpointNote{}, false, nil
+	}
+
+	output, err := g.runGitCommandWithOutput(ctx, "log", "-n", strconv.Itoa(commitNumberScanLimit), "--no-notes", "--notes="+NotesRef, "--format=%x1e%N")
+	if err != nil {
+		return checkpointNote{}, false, err
+	}
+	for _, record := range strings.Split(output, "\x1e") {
+		if note, ok := parseCheckpointNote(record); ok {
+			return note, true, nil
+		}
+	}
+	return checkpointNote{}, false, nil
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestParseCheckpointNote tests reading back the metadata of checkpoint notes
+func TestParseCheckpointNote(t *testing.T) {
+	tests := map[string]struct {
+		text     string
+		expected checkpointNote
+		valid    bool
+	}{
+		"RoundTrip": {
+			text:     checkpointNote{Session: "0123abcd", Counter: 7, Prefix: "[gitbak] Commit"}.String(),
+			expected: checkpointNote{Session: "0123abcd", Counter: 7, Prefix: "[gitbak] Commit"},
+			valid:    true,
+		},
+		"ExtraLines": {
+			text:     "reviewed\ngitbak-session: 0123abcd\ngitbak-counter: 2\n",
+			expected: checkpointNote{Session: "0123abcd", Counter: 2},
+			valid:    true,
+		},
+		"NoSession": {
+			text: "gitbak-counter: 2\n",
+		},
+		"BadCounter": {
+			text: "gitbak-session: 0123abcd\ngitbak-counter: two\n",
+		},
+		"HandWritten": {
+			text: "Fixed the flaky test",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			note, ok := parseCheckpointNote(test.text)
+			if ok != test.valid {
+				t.Fatalf("Expected valid to be %v, got %v", test.valid, ok)
+			}
+			if ok && note != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, note)
+			}
+		})
+	}
+}
+
+// TestContinueAfterRename tests that -continue picks up the counter and integrity chain
+// from checkpoint notes after the branch was renamed and the prefix changed
+func TestContinueAfterRename(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	first := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-notes",
+		CommitPrefix:   "[gitbak-notes] Commit",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      stateFile,
+		MaxRetries:     3,
+	}, logger.NewWithOutput(false, "", true, &out, &out))
+	if err := first.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		if err := os.WriteFile(filepath.Join(repoPath, fmt.Sprintf("notes%d.txt", i)), []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := first.createCommit(ctx, i); err != nil {
+			t.Fatalf("createCommit failed: %v", err)
+		}
+	}
+	first.saveState()
+
+	if note := gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "show", "HEAD"); !strings.Contains(note, "gitbak-counter: 2") {
+		t.Errorf("Expected the latest checkpoint to have a note, got %q", note)
+	}
+	if subject := gitOutput(t, repoPath, "log", "-1", "--format=%B"); strings.Contains(subject, "gitbak-session") {
+		t.Errorf("Expected the commit message to be left alone, got %q", subject)
+	}
+
+	gitOutput(t, repoPath, "branch", "-m", "gitbak-notes", "renamed")
+	out.Reset()
+	second := setupTestGitbak(GitbakConfig{
+		RepoPath:        repoPath,
+		Interval:        time.Minute,
+		BranchName:      "renamed",
+		CommitPrefix:    "[wip] Checkpoint",
+		ContinueSession: true,
+		NonInteractive:  true,
+		StateFile:       stateFile,
+		MaxRetries:      3,
+	}, logger.NewWithOutput(false, "", true, &out, &out))
+	if err := second.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	if second.commitsCount != 2 {
+		t.Errorf("Expected to continue from checkpoint 2, got %d", second.commitsCount)
+	}
+	if second.sessionID != first.sessionID {
+		t.Errorf("Expected the session ID %q to be carried over, got %q", first.sessionID, second.sessionID)
+	}
+	if len(second.chain) != 2 {
+		t.Errorf("Expected the integrity chain of the renamed branch to be restored, got %d links", len(second.chain))
+	}
+	if !strings.Contains(out.String(), "Previous checkpoints used the prefix '[gitbak-notes] Commit'") {
+		t.Errorf("Expected the prefix change to be reported, got:\n%s", out.String())
+	}
+}
+
+// TestBranchVisualizationLeavesOutNotes tests that the summary's branch graph draws the
+// checkpoints but not the commits of the notes ref recording their metadata
+func TestBranchVisualizationLeavesOutNotes(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-graph",
+		CommitPrefix:   "[gitbak-graph] Commit",
+		CreateBranch:   true,
+		NonInteractive: true,
+		MaxRetries:     3,
+	}, logger.NewWithOutput(false, "", true, &out, &out))
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "graph.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := gb.createCommit(ctx, 1); err != nil {
+		t.Fatalf("createCommit failed: %v", err)
+	}
+	if note := gitOutput(t, repoPath, "notes", "--ref="+NotesRef, "list"); note == "" {
+		t.Fatal("Expected the checkpoint to have a note")
+	}
+
+	out.Reset()
+	gb.showBranchVisualization(ctx)
+
+	if !strings.Contains(out.String(), "[gitbak-graph] Commit #1") {
+		t.Errorf("Expected the checkpoint in the graph, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "Notes added by") || strings.Contains(out.String(), NotesRef) {
+		t.Errorf("Expected the notes commits to be left out of the graph, got:\n%s", out.String())
+	}
+}
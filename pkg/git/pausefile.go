@@ -0,0 +1,60 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PauseFile is the name of the sentinel file in the repository's git directory (e.g.
+// .git/gitbak-pause) that pauses checkpointing for as long as it exists. Editors, scripts
+// and git hooks can hold gitbak off, e.g. for the length of a rebase, by creating and
+// removing it, without talking to the running session. In a linked worktree it lives in
+// the worktree's own git directory, so it pauses only sessions in that worktree.
+const PauseFile = "gitbak-pause"
+
+// PauseFilePath returns where the repository's pause file lives, whether or not it exists
+func (r *Repository) PauseFilePath(ctx context.Context) (string, error) {
+	out, err := r.output(ctx, "rev-parse", "--git-path", PauseFile)
+	if err != nil {
+		return "", err
+	}
+	return resolveFrom(r.path, out), nil
+}
+
+// setupPauseFile finds the repository's pause file, telling the user if it already exists.
+// If it cannot be found, checkpointing goes on without one.
+func (g *Gitbak) setupPauseFile(ctx context.Context) {
+	out, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--git-path", PauseFile)
+	if err != nil || strings.TrimSpace(out) == "" {
+		g.logger.Warning("Failed to locate the pause file, so it cannot pause checkpointing: %v", err)
+		return
+	}
+	g.pauseFile = resolveFrom(g.config.RepoPath, strings.TrimSpace(out))
+	g.pauseFilePresent()
+}
+
+// pauseFilePresent reports whether the pause file exists, telling the user when it
+// appears or goes away
+func (g *Gitbak) pauseFilePresent() bool {
+	if g.pauseFile == "" {
+		return false
+	}
+
+	_, err := os.Stat(g.pauseFile)
+	present := err == nil
+	if present != g.pausedByFile {
+		g.pausedByFile = present
+		name := g.pauseFile
+		if rel, err := filepath.Rel(g.config.RepoPath, g.pauseFile); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		if present {
+			g.logger.InfoToUser("Checkpointing paused while %s exists", name)
+		} else {
+			g.logger.InfoToUser("Checkpointing resumed, %s was removed", name)
+		}
+	}
+	return present
+}
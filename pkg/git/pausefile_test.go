@@ -0,0 +1,97 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestPauseFile tests that the pause file pauses checkpointing for as long as it exists,
+// in the git directory of the worktree gitbak runs in
+func TestPauseFile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		worktree bool
+	}{
+		"Repository": {},
+		"Worktree":   {worktree: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gitDir := filepath.Join(repoPath, ".git")
+			if test.worktree {
+				worktree := filepath.Join(t.TempDir(), "feature")
+				gitOutput(t, repoPath, "worktree", "add", "-q", "-b", "feature", worktree)
+				repoPath = worktree
+				gitDir = filepath.Join(gitDir, "worktrees", "feature")
+			}
+
+			var buf bytes.Buffer
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:     repoPath,
+				Interval:     time.Minute,
+				BranchName:   "gitbak-pause-file",
+				CommitPrefix: "[gitbak] Commit",
+			}, logger.NewWithOutput(false, "", true, &buf, &buf))
+			ctx := context.Background()
+			gb.setupPauseFile(ctx)
+
+			pauseFile := filepath.Join(gitDir, PauseFile)
+			if !sameFile(t, gb.pauseFile, pauseFile) {
+				t.Fatalf("Expected the pause file at %s, got %s", pauseFile, gb.pauseFile)
+			}
+			if path, err := NewRepository(repoPath, nil).PauseFilePath(ctx); err != nil || !sameFile(t, path, pauseFile) {
+				t.Errorf("Expected PauseFilePath to return %s, got %s (err %v)", pauseFile, path, err)
+			}
+			if gb.isPaused() {
+				t.Fatal("Expected checkpointing not to be paused without a pause file")
+			}
+
+			if err := os.WriteFile(pauseFile, nil, 0644); err != nil {
+				t.Fatalf("Failed to create pause file: %v", err)
+			}
+			if !gb.isPaused() || !gb.isPaused() {
+				t.Fatal("Expected the pause file to pause checkpointing")
+			}
+			if err := os.Remove(pauseFile); err != nil {
+				t.Fatalf("Failed to remove pause file: %v", err)
+			}
+			if gb.isPaused() {
+				t.Fatal("Expected removing the pause file to resume checkpointing")
+			}
+
+			for _, message := range []string{"Checkpointing paused while", "Checkpointing resumed"} {
+				if count := strings.Count(buf.String(), message); count != 1 {
+					t.Errorf("Expected %q once, got %d times in %q", message, count, buf.String())
+				}
+			}
+		})
+	}
+}
+
+// sameFile reports whether two paths name the same file once symlinks in their
+// directories are resolved, as temporary directories may be reached through one
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	resolve := func(path string) string {
+		dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return path
+		}
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return resolve(a) == resolve(b)
+}
@@ -0,0 +1,17 @@
+package git
+
+import "time"
+
+// lowPowerDeferred reports whether a check should wait because the machine is low on power.
+// While LowPower reports true, checks are spaced at least LowPowerInterval apart,
+// or skipped altogether if it is zero. Scheduled, nudged and watch-triggered checks wait;
+// requested checks do not.
+func (g *Gitbak) lowPowerDeferred() bool {
+	if g.config.LowPower == nil || !g.config.LowPower() {
+		return false
+	}
+	if g.config.LowPowerInterval == 0 {
+		return true
+	}
+	return time.Since(g.lastCheckTime) < g.config.LowPowerInterval
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLowPowerDeferred(t *testing.T) {
+	t.Parallel()
+
+	low := func() bool { return true }
+	plugged := func() bool { return false }
+
+	tests := map[string]struct {
+		config    GitbakConfig
+		lastCheck time.Duration
+		expected  bool
+	}{
+		"NotConfigured": {
+			config:    GitbakConfig{},
+			lastCheck: time.Second,
+			expected:  false,
+		},
+		"PluggedIn": {
+			config:    GitbakConfig{LowPower: plugged, LowPowerInterval: 15 * time.Minute},
+			lastCheck: time.Second,
+			expected:  false,
+		},
+		"LowSinceRecentCheck": {
+			config:    GitbakConfig{LowPower: low, LowPowerInterval: 15 * time.Minute},
+			lastCheck: 5 * time.Minute,
+			expected:  true,
+		},
+		"LowIntervalElapsed": {
+			config:    GitbakConfig{LowPower: low, LowPowerInterval: 15 * time.Minute},
+			lastCheck: 16 * time.Minute,
+			expected:  false,
+		},
+		"LowPaused": {
+			config:    GitbakConfig{LowPower: low},
+			lastCheck: time.Hour,
+			expected:  true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := &Gitbak{config: test.config, lastCheckTime: time.Now().Add(-test.lastCheck)}
+			if deferred := g.lowPowerDeferred(); deferred != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, deferred)
+			}
+		})
+	}
+}
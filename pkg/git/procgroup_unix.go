@@ -0,0 +1,24 @@
+//go:build !windows
+
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel starts cmd in a process group of its own and makes canceling
+// it kill the whole group, so that the hooks, filters and credential helpers git started
+// die with it instead of holding the repository's locks
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+}
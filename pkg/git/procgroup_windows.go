@@ -0,0 +1,9 @@
+//go:build windows
+
+package git
+
+import "os/exec"
+
+// killProcessGroupOnCancel leaves cmd to be killed on its own when canceled. Windows has
+// no process groups that can be killed at once, so processes git started may outlive it.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
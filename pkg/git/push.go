@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// pushAttempts is how many times a push is tried before it is left for the next check
+const pushAttempts = 3
+
+// defaultPushRetryDelay is the wait before the first push retry; it doubles with each attempt
+const defaultPushRetryDelay = 2 * time.Second
+
+// pushDue reports whether checkpoints are waiting to be pushed and the push cadence allows it
+func (g *Gitbak) pushDue(now time.Time) bool {
+	if g.config.Push == "" || !g.pushPending {
+		return false
+	}
+	if g.lastPushTime.IsZero() || g.config.PushInterval <= 0 {
+		return true
+	}
+	return now.Sub(g.lastPushTime) >= g.config.PushInterval
+}
+
+// pushIfDue pushes the session branch to the configured remote when a push is due.
+// A failed push never interrupts checkpointing: the checkpoints stay pending and
+// the push is tried again at the next check.
+func (g *Gitbak) pushIfDue(ctx context.Context) {
+	if !g.pushDue(time.Now()) {
+		return
+	}
+	if err := g.push(ctx); err != nil {
+		g.logger.WarningToUser("Failed to push to %s, will retry at the next check: %v", g.config.Push, err)
+	}
+}
+
+// PushPending pushes the checkpoints not yet pushed, whatever the push cadence, so that
+// stopping a session with -push-interval leaves none of them behind. ctx bounds how long
+// it may take. Nothing is done without -push or once the kill switch is engaged.
+func (g *Gitbak) PushPending(ctx context.Context) error {
+	if g.config.Push == "" || !g.pushPending || (g.config.IsDisabled != nil && g.config.IsDisabled()) {
+		return nil
+	}
+	if err := g.push(ctx); err != nil {
+		return gitbakErrors.Wrapf(err, "checkpoints left unpushed to %s", g.config.Push)
+	}
+	return nil
+}
+
+// push pushes the session branch, recording the outcome in the session's counts
+func (g *Gitbak) push(ctx context.Context) error {
+	branch := g.sessionBranch()
+	if err := g.pushWithRetry(ctx, branch); err != nil {
+		g.pushFailures++
+		g.logger.Warning("Failed to push %s to %s: %v", branch, g.config.Push, err)
+		return err
+	}
+
+	g.pushPending = false
+	g.lastPushTime = time.Now()
+	g.pushesCount++
+	g.logger.Info("Pushed %s to %s", branch, g.config.Push)
+	g.logger.InfoToUser("Pushed %s to %s", branch, g.config.Push)
+	return nil
+}
+
+// pushWithRetry pushes the branch to the configured remote, retrying with
+// exponential backoff. It gives up early if ctx is canceled.
+func (g *Gitbak) pushWithRetry(ctx context.Context, branch string) error {
+	ref := "refs/heads/" + branch
+	delay := g.pushRetryDelay
+
+	var err error
+	for attempt := 1; attempt <= pushAttempts; attempt++ {
+		attemptCtx, cancel := g.withOpTimeout(ctx)
+		_, err = g.runGitCommandWithOutput(attemptCtx, "push", "--quiet", g.config.Push, ref+":"+ref)
+		err = g.reportTimeout(ctx, attemptCtx, err)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		g.logger.Info("Push attempt %d/%d to %s failed: %v", attempt, pushAttempts, g.config.Push, err)
+		if gitbakErrors.Is(err, gitbakErrors.ErrPushRejected) {
+			// Pushing again would be refused the same way
+			return gitbakErrors.Wrapf(err, "push to %s rejected", g.config.Push)
+		}
+
+		if attempt == pushAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return gitbakErrors.Wrap(ctx.Err(), "push canceled")
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return gitbakErrors.Wrapf(err, "push failed after %d attempts", pushAttempts)
+}
@@ -0,0 +1,314 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestPushDue tests when a pending push is allowed by the push cadence
+func TestPushDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := map[string]struct {
+		push         string
+		interval     time.Duration
+		pending      bool
+		lastPushTime time.Time
+		expected     bool
+	}{
+		"PushDisabled": {
+			pending:  true,
+			expected: false,
+		},
+		"NothingPending": {
+			push:     "origin",
+			expected: false,
+		},
+		"FirstPush": {
+			push:     "origin",
+			interval: 30 * time.Minute,
+			pending:  true,
+			expected: true,
+		},
+		"EveryCheckpoint": {
+			push:         "origin",
+			pending:      true,
+			lastPushTime: now,
+			expected:     true,
+		},
+		"WithinInterval": {
+			push:         "origin",
+			interval:     30 * time.Minute,
+			pending:      true,
+			lastPushTime: now.Add(-10 * time.Minute),
+			expected:     false,
+		},
+		"IntervalElapsed": {
+			push:         "origin",
+			interval:     30 * time.Minute,
+			pending:      true,
+			lastPushTime: now.Add(-31 * time.Minute),
+			expected:     true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := &Gitbak{
+				config:       GitbakConfig{Push: test.push, PushInterval: test.interval},
+				pushPending:  test.pending,
+				lastPushTime: test.lastPushTime,
+			}
+
+			if got := gb.pushDue(now); got != test.expected {
+				t.Errorf("Expected pushDue to be %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestPushCheckpoints tests pushing the session branch after a checkpoint
+func TestPushCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		reachable      bool
+		expectPending  bool
+		expectPushes   int
+		expectFailures int
+		expectOnRemote bool
+	}{
+		"ReachableRemote": {
+			reachable:      true,
+			expectPending:  false,
+			expectPushes:   1,
+			expectFailures: 0,
+			expectOnRemote: true,
+		},
+		"UnreachableRemote": {
+			reachable:      false,
+			expectPending:  true,
+			expectPushes:   0,
+			expectFailures: 1,
+			expectOnRemote: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			remotePath := filepath.Join(t.TempDir(), "backup.git")
+			if test.reachable {
+				if err := exec.Command("git", "init", "--bare", remotePath).Run(); err != nil {
+					t.Fatalf("Failed to create bare remote: %v", err)
+				}
+			}
+
+			log := logger.New(false, "", false)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-push-branch",
+				CommitPrefix:   "[gitbak-push] Commit",
+				CreateBranch:   true,
+				NonInteractive: true,
+				Push:           remotePath,
+			}, log)
+			gb.pushRetryDelay = time.Millisecond
+
+			if err := os.WriteFile(filepath.Join(repoPath, "change.txt"), []byte("change"), 0644); err != nil {
+				t.Fatalf("Failed to write change: %v", err)
+			}
+
+			if err := gb.RunSingleIteration(context.Background()); err != nil {
+				t.Fatalf("RunSingleIteration failed: %v", err)
+			}
+
+			if gb.pushPending != test.expectPending {
+				t.Errorf("Expected pushPending to be %v, got %v", test.expectPending, gb.pushPending)
+			}
+			if gb.pushesCount != test.expectPushes {
+				t.Errorf("Expected %d pushes, got %d", test.expectPushes, gb.pushesCount)
+			}
+			if gb.pushFailures != test.expectFailures {
+				t.Errorf("Expected %d push failures, got %d", test.expectFailures, gb.pushFailures)
+			}
+
+			if !test.expectOnRemote {
+				return
+			}
+			local, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+			if err != nil {
+				t.Fatalf("Failed to read local HEAD: %v", err)
+			}
+			remote, err := exec.Command("git", "-C", remotePath, "rev-parse", "refs/heads/gitbak-push-branch").Output()
+			if err != nil {
+				t.Fatalf("Expected branch on remote: %v", err)
+			}
+			if strings.TrimSpace(string(local)) != strings.TrimSpace(string(remote)) {
+				t.Errorf("Expected remote branch at %s, got %s", local, remote)
+			}
+		})
+	}
+}
+
+// TestPushPending tests that the checkpoints held back by a push interval are pushed when
+// the session stops
+func TestPushPending(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		push           bool
+		disabled       bool
+		expectOnRemote bool
+	}{
+		"Pending":    {push: true, expectOnRemote: true},
+		"NoPush":     {},
+		"KillSwitch": {push: true, disabled: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			remotePath := filepath.Join(t.TempDir(), "backup.git")
+			if err := exec.Command("git", "init", "--bare", remotePath).Run(); err != nil {
+				t.Fatalf("Failed to create bare remote: %v", err)
+			}
+
+			cfg := GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-pending-branch",
+				CommitPrefix:   "[gitbak-pending] Commit",
+				CreateBranch:   true,
+				NonInteractive: true,
+				PushInterval:   time.Hour,
+				IsDisabled:     func() bool { return test.disabled },
+			}
+			if test.push {
+				cfg.Push = remotePath
+			}
+			gb := setupTestGitbak(cfg, logger.New(false, "", false))
+			gb.pushRetryDelay = time.Millisecond
+
+			// The first checkpoint is pushed at once, and the push interval holds back the second
+			if err := os.WriteFile(filepath.Join(repoPath, "change.txt"), []byte("first"), 0644); err != nil {
+				t.Fatalf("Failed to write change: %v", err)
+			}
+			if err := gb.RunSingleIteration(context.Background()); err != nil {
+				t.Fatalf("RunSingleIteration failed: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "change.txt"), []byte("second"), 0644); err != nil {
+				t.Fatalf("Failed to write change: %v", err)
+			}
+			created := false
+			if err := gb.checkAndCommitChanges(context.Background(), gb.commitsCount+1, &created); err != nil || !created {
+				t.Fatalf("Expected a second checkpoint, got %v", err)
+			}
+			gb.pushIfDue(context.Background())
+			if test.push && !gb.pushPending {
+				t.Fatal("Expected the push interval to hold back the second checkpoint")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := gb.PushPending(ctx); err != nil {
+				t.Fatalf("PushPending failed: %v", err)
+			}
+
+			remote, _ := exec.Command("git", "-C", remotePath, "rev-parse", "refs/heads/gitbak-pending-branch").Output()
+			local := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			if onRemote := strings.TrimSpace(string(remote)) == local; onRemote != test.expectOnRemote {
+				t.Errorf("Expected the last checkpoint on the remote: %v, got %v", test.expectOnRemote, onRemote)
+			}
+			if test.expectOnRemote && gb.pushPending {
+				t.Error("Expected no checkpoints left pending")
+			}
+		})
+	}
+}
+
+// TestPushWithRetryCanceled tests that a canceled context stops push retries
+func TestPushWithRetryCanceled(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "master",
+		CommitPrefix:   "[gitbak]",
+		NonInteractive: true,
+		Push:           filepath.Join(t.TempDir(), "missing.git"),
+	}, logger.New(false, "", false))
+	gb.pushRetryDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- gb.pushWithRetry(ctx, "master") }()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "canceled") {
+			t.Errorf("Expected a cancellation error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pushWithRetry did not return after cancellation")
+	}
+}
+
+// TestPushTimeoutKeepsIndexLock tests that a push that times out leaves alone an index lock
+// taken while it ran, which can only belong to another git process
+func TestPushTimeoutKeepsIndexLock(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	// The transport takes the lock as the user's own git commit would mid-push, and then hangs
+	lockPath := filepath.Join(repoPath, ".git", "index.lock")
+	if err := exec.Command("git", "-C", repoPath, "config", "core.sshCommand", "sleep 0.05; touch '"+lockPath+"'; sleep 5 #").Run(); err != nil {
+		t.Fatalf("Failed to configure the transport: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "master",
+		CommitPrefix:   "[gitbak]",
+		NonInteractive: true,
+		OpTimeout:      200 * time.Millisecond,
+		Push:           "ssh://gitbak.invalid/backup.git",
+	}, logger.New(false, "", false))
+	gb.pushRetryDelay = time.Millisecond
+
+	err := gb.pushWithRetry(context.Background(), "master")
+	if !gitbakErrors.Is(err, gitbakErrors.ErrOperationTimeout) {
+		t.Fatalf("Expected the push to time out, got %v", err)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Errorf("Expected the index lock taken during the push to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// quietRemaining returns how much longer the changes of the latest check must be left
+// alone before MinQuietSeconds lets them be checkpointed, or zero once the working tree
+// has been stable long enough: once no changed file, nor any file in a new directory, has
+// been modified for that long and, with Changes, no change has been reported for that long.
+func (g *Gitbak) quietRemaining(now time.Time) time.Duration {
+	if g.config.MinQuietSeconds <= 0 || g.finalCheck {
+		return 0
+	}
+
+	latest := g.lastChangeReported
+	for _, path := range statusPaths(g.checkedStatus) {
+		if modified := lastModified(filepath.Join(g.config.RepoPath, filepath.FromSlash(path)), now); modified.After(latest) {
+			latest = modified
+		}
+	}
+
+	quiet := time.Duration(g.config.MinQuietSeconds * float64(time.Second))
+	if remaining := latest.Add(quiet).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// lastModified returns when the file at path, or the newest file under it if it is a
+// directory, was last modified, or the zero time if that cannot be told. Times in the
+// future, from a skewed clock or an archive, are passed over, as no wait would outlast them.
+func lastModified(path string, now time.Time) time.Time {
+	var latest time.Time
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Deleted, or removed while walking; there is nothing left to be written
+			return nil
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			// A nested repository's git directory changes with every git command run in it
+			return filepath.SkipDir
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if modified := info.ModTime(); modified.After(latest) && !modified.After(now) {
+			latest = modified
+		}
+		return nil
+	})
+	return latest
+}
+
+// holdUntilQuiet reports whether the changes of the latest check are still being made,
+// and if so schedules the check that will find them settled
+func (g *Gitbak) holdUntilQuiet() bool {
+	remaining := g.quietRemaining(time.Now())
+	if remaining == 0 {
+		return false
+	}
+
+	// Rounding up keeps the recheck from landing just before the tree counts as quiet
+	remaining = remaining.Truncate(time.Second) + time.Second
+	g.quietWait = remaining
+	if g.config.ShowNoChanges && g.config.Verbose {
+		g.logger.InfoToUser("Changes still being made at %s, checking again in %v", time.Now().Format("15:04:05"), remaining)
+	}
+	g.logger.Info("Holding back changes until the working tree has been quiet for %vs, checking again in %v", g.config.MinQuietSeconds, remaining)
+	return true
+}
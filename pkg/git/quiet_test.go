@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestMinQuiet tests that checkpoints wait for the working tree to be left alone for MinQuietSeconds
+func TestMinQuiet(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		path           string
+		age            time.Duration
+		changeReported time.Duration
+		finalCheck     bool
+		expectHeld     bool
+	}{
+		"RecentlyModified": {
+			path:       "notes.txt",
+			expectHeld: true,
+		},
+		"Settled": {
+			path: "notes.txt",
+			age:  2 * time.Minute,
+		},
+		"RecentlyModifiedInNewDirectory": {
+			path:       "build/out/app.js",
+			expectHeld: true,
+		},
+		"SettledInNewDirectory": {
+			path: "build/out/app.js",
+			age:  2 * time.Minute,
+		},
+		"SettledButChangeReported": {
+			path:           "notes.txt",
+			age:            2 * time.Minute,
+			changeReported: time.Second,
+			expectHeld:     true,
+		},
+		"FinalCheckpoint": {
+			path:       "notes.txt",
+			finalCheck: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			writeRepoFile(t, repoPath, test.path, "generated\n")
+			if test.age > 0 {
+				modified := time.Now().Add(-test.age)
+				for path := filepath.Join(repoPath, filepath.FromSlash(test.path)); path != repoPath; path = filepath.Dir(path) {
+					if err := os.Chtimes(path, modified, modified); err != nil {
+						t.Fatalf("Failed to age %s: %v", path, err)
+					}
+				}
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:        repoPath,
+				Interval:        time.Minute,
+				BranchName:      "gitbak-quiet",
+				CommitPrefix:    "[gitbak-quiet] Commit",
+				MinQuietSeconds: 60,
+			}, logger.New(false, "", false))
+			if test.changeReported > 0 {
+				gb.lastChangeReported = time.Now().Add(-test.changeReported)
+			}
+			gb.finalCheck = test.finalCheck
+
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(context.Background(), 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if commitWasCreated == test.expectHeld {
+				t.Errorf("Expected the changes to be held back: %v, got a checkpoint: %v", test.expectHeld, commitWasCreated)
+			}
+			if held := gb.quietWait > 0; held != test.expectHeld {
+				t.Errorf("Expected a recheck to be scheduled: %v, got %v", test.expectHeld, gb.quietWait)
+			}
+			if test.expectHeld && (gb.quietWait < 55*time.Second || gb.quietWait > 61*time.Second) {
+				t.Errorf("Expected a recheck once the quiet period is up, got one in %v", gb.quietWait)
+			}
+		})
+	}
+}
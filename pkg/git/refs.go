@@ -0,0 +1,201 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// RefsPrefix is the namespace ModeRefs writes checkpoints under, as refs/gitbak/<session>/<n>,
+// where the session is named by GitbakConfig.BranchName. git branch leaves them out,
+// and git gc keeps what they reference. Mirrors receive branches in the
+// same namespace (MirrorRefPrefix), but on the remote.
+const RefsPrefix = "refs/gitbak/"
+
+// refsMode reports whether checkpoints are written to refs rather than committed on a branch
+func (g *Gitbak) refsMode() bool {
+	return g.config.Mode == ModeRefs
+}
+
+// sessionRefs returns the namespace the session's checkpoint refs are written to
+func (g *Gitbak) sessionRefs() string {
+	return RefsPrefix + g.config.BranchName
+}
+
+// stateRefs returns the namespace recorded in the session state as session.State.Refs
+func (g *Gitbak) stateRefs() string {
+	if !g.refsMode() {
+		return ""
+	}
+	return g.sessionRefs()
+}
+
+// setupRefsSession configures gitbak to write checkpoints to refs, without touching any
+// branch. Refs cannot be told apart from those of an earlier session of the same name, so
+// the session is renamed as a branch would be.
+func (g *Gitbak) setupRefsSession(ctx context.Context) error {
+	g.config.CreateBranch = false
+
+	args := []string{"for-each-ref", "--count=1", "--format=%(refname)", g.sessionRefs()}
+	existing, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return gitbakErrors.NewGitError("for-each-ref", args[1:], gitbakErrors.Wrap(err, "failed to look up checkpoint refs"), "")
+	}
+	if strings.TrimSpace(existing) != "" {
+		g.logger.WarningToUser("Checkpoint refs of a session named '%s' already exist.", g.config.BranchName)
+		g.config.BranchName = fmt.Sprintf("%s-%s", g.config.BranchName, time.Now().Format("150405"))
+		g.logger.StatusMessage("🔖 Using new session name: %s", g.config.BranchName)
+	}
+
+	g.logger.StatusMessage("🔖 Refs mode: checkpoints are written to %s/<n>, '%s' is left untouched", g.sessionRefs(), g.originalBranch)
+	return nil
+}
+
+// checkAndRecordRefs writes the working tree as a checkpoint commit to the session's next
+// ref if it differs from both HEAD and the previous checkpoint. The first checkpoint's
+// parent is HEAD and every later one's the checkpoint before it, so the latest ref holds
+// the whole session, ready to be materialized into a branch. As in stash mode, the working
+// tree is never committed to, so the trees are compared to tell whether anything changed.
+func (g *Gitbak) checkAndRecordRefs(ctx context.Context, commitCounter int, commitWasCreated *bool) error {
+	*commitWasCreated = false
+
+	_, tree, err := g.snapshotTrees(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to snapshot working tree: %v", err)
+		return err
+	}
+
+	headTree, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD^{tree}")
+	if err != nil {
+		return gitbakErrors.NewGitError("rev-parse", []string{"HEAD^{tree}"}, gitbakErrors.Wrap(err, "failed to resolve HEAD"), "")
+	}
+
+	if tree == strings.TrimSpace(headTree) || tree == g.lastSnapshotTree {
+		if g.config.ShowNoChanges && g.config.Verbose && !g.idling() {
+			g.logger.InfoToUser("No changes to checkpoint at %s", time.Now().Format("15:04:05"))
+			g.logger.Info("No changes to checkpoint detected")
+		}
+		return nil
+	}
+
+	if held, err := g.secretsHoldCheckpoint(ctx); err != nil || held {
+		return err
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	message := fmt.Sprintf("%s #%d - %s", g.config.CommitPrefix, commitCounter, timestamp)
+
+	parent := g.lastCheckpoint
+	if parent == "" {
+		parent = "HEAD"
+	}
+	commitArgs := []string{"commit-tree", tree, "-p", parent, "-m", message}
+	trailers := g.coAuthorTrailers()
+	if g.sessionID != "" {
+		trailers = append(trailers, sessionTrailer(g.sessionID))
+	}
+	if len(trailers) > 0 {
+		commitArgs = append(commitArgs, "-m", strings.Join(trailers, "\n"))
+	}
+	commit, err := g.runAsCheckpointIdentity(ctx, g.config.RepoPath, commitArgs...)
+	if err != nil {
+		return gitbakErrors.NewGitError("commit-tree", commitArgs[1:], gitbakErrors.Wrap(err, "failed to create checkpoint"), "")
+	}
+	commit = strings.TrimSpace(commit)
+
+	// The empty old value makes update-ref refuse to overwrite a ref that already exists
+	ref := fmt.Sprintf("%s/%d", g.sessionRefs(), commitCounter)
+	updateArgs := []string{"update-ref", ref, commit, ""}
+	if err := g.runGitCommand(ctx, updateArgs...); err != nil {
+		g.logger.WarningToUser("Failed to write checkpoint ref: %v", err)
+		return gitbakErrors.NewGitError("update-ref", updateArgs[1:], gitbakErrors.Wrap(err, "failed to write checkpoint ref"), "")
+	}
+
+	*commitWasCreated = true
+	g.lastSnapshotTree = tree
+	g.logger.Success("Checkpoint #%d written to %s at %s", commitCounter, ref, timestamp)
+	g.logger.Info("Successfully wrote checkpoint #%d to %s", commitCounter, ref)
+
+	g.commitsCount = commitCounter
+	g.lastCommitTime = time.Now()
+	g.lastCheckpoint = commit
+	g.recordCheckpointStorage(ctx)
+	g.observeCheckpoint(ctx, commit)
+	g.saveState()
+
+	if g.config.OnCheckpoint != nil {
+		g.config.OnCheckpoint(Checkpoint{Number: commitCounter, Commit: commit, Time: g.lastCommitTime, Subject: message})
+	}
+	return nil
+}
+
+// checkpointRef is one of the refs a ModeRefs session wrote a checkpoint to
+type checkpointRef struct {
+	Name   string
+	Number int
+	Commit string
+}
+
+// checkpointRefs lists the checkpoint refs in namespace, lowest number first. Refs not
+// named by a checkpoint number were not written by gitbak and are left out.
+func (r *Repository) checkpointRefs(ctx context.Context, namespace string) ([]checkpointRef, error) {
+	args := []string{"for-each-ref", "--format=%(refname)%00%(objectname)", namespace}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("for-each-ref", args[1:], gitbakErrors.Wrap(err, "failed to list checkpoint refs"), "")
+	}
+
+	var refs []checkpointRef
+	for _, line := range strings.Split(out, "\n") {
+		name, commit, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(name, namespace+"/"))
+		if err != nil || n <= 0 {
+			continue
+		}
+		refs = append(refs, checkpointRef{Name: name, Number: n, Commit: commit})
+	}
+	// for-each-ref sorts by name, which puts 10 before 2
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Number < refs[j].Number })
+	return refs, nil
+}
+
+// MaterializeRefs creates branch at the latest checkpoint a ModeRefs session wrote, which
+// carries every earlier checkpoint of the session in its history, and returns the ref it
+// was created from and the number of checkpoints. An existing branch is never overwritten,
+// and the refs are left in place.
+func (r *Repository) MaterializeRefs(ctx context.Context, state *session.State, branch string) (string, int, error) {
+	if state.Refs == "" {
+		return "", 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' did not write its checkpoints to refs (-refs-only), so there is nothing to materialize", state.Branch)
+	}
+
+	refs, err := r.checkpointRefs(ctx, state.Refs)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(refs) == 0 {
+		return "", 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session wrote no checkpoint refs under %s", state.Refs)
+	}
+
+	if _, err := r.output(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return "", 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"branch '%s' already exists; name another branch to materialize the checkpoints into", branch)
+	}
+
+	latest := refs[len(refs)-1]
+	args := []string{"branch", branch, latest.Commit}
+	if err := r.run(ctx, args...); err != nil {
+		return "", 0, gitbakErrors.NewGitError("branch", args[1:], gitbakErrors.Wrap(err, "failed to create branch"), "")
+	}
+	return latest.Name, len(refs), nil
+}
@@ -0,0 +1,200 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestRefsMode tests that checkpoints are written to chained refs without touching
+// branches, the index or the working tree, and can be materialized into a branch
+func TestRefsMode(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	startBranch := gitOutput(t, repoPath, "branch", "--show-current")
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+	// A staged change and an untracked file
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("staged"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	gitOutput(t, repoPath, "add", "initial.txt")
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("untracked"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-refs",
+		CommitPrefix:   "[gitbak-refs] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+		Mode:           ModeRefs,
+		StateFile:      stateFile,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	checkpoint := func(counter int) bool {
+		t.Helper()
+		var created bool
+		if err := gb.checkAndCommitChanges(ctx, counter, &created); err != nil {
+			t.Fatalf("checkAndCommitChanges failed: %v", err)
+		}
+		return created
+	}
+
+	if !checkpoint(1) {
+		t.Fatal("Expected a checkpoint of the changes")
+	}
+	if checkpoint(2) {
+		t.Error("Expected no checkpoint when nothing changed since the last one")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if !checkpoint(2) {
+		t.Fatal("Expected a checkpoint of the further change")
+	}
+
+	if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != startBranch {
+		t.Errorf("Expected to stay on %s, got %s", startBranch, branch)
+	}
+	if head := gitOutput(t, repoPath, "rev-parse", "HEAD"); head != startCommit {
+		t.Errorf("Expected HEAD to stay at %s, got %s", startCommit, head)
+	}
+	if branches := gitOutput(t, repoPath, "branch", "--list", "gitbak-refs"); branches != "" {
+		t.Errorf("Expected no session branch, got %q", branches)
+	}
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status != "M  initial.txt\n?? new.txt" {
+		t.Errorf("Expected the index and working tree to be untouched, got status %q", status)
+	}
+
+	first := gitOutput(t, repoPath, "rev-parse", "refs/gitbak/gitbak-refs/1")
+	second := gitOutput(t, repoPath, "rev-parse", "refs/gitbak/gitbak-refs/2")
+	if parent := gitOutput(t, repoPath, "rev-parse", first+"^"); parent != startCommit {
+		t.Errorf("Expected the first checkpoint's parent to be HEAD, got %s", parent)
+	}
+	if parent := gitOutput(t, repoPath, "rev-parse", second+"^"); parent != first {
+		t.Errorf("Expected the second checkpoint's parent to be the first, got %s", parent)
+	}
+	if content := gitOutput(t, repoPath, "show", second+":new.txt"); content != "changed" {
+		t.Errorf("Expected the checkpoint to include untracked files, got %q", content)
+	}
+	if subject := gitOutput(t, repoPath, "log", "-1", "--format=%s", second); !strings.HasPrefix(subject, "[gitbak-refs] Checkpoint #2 - ") {
+		t.Errorf("Expected a checkpoint subject, got %q", subject)
+	}
+
+	state, err := session.Load(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if state.Refs != "refs/gitbak/gitbak-refs" || state.LastCheckpoint != second {
+		t.Errorf("Expected the state to record the refs and latest checkpoint, got %q and %q", state.Refs, state.LastCheckpoint)
+	}
+
+	repo := NewRepository(repoPath, nil)
+	checkpoints, err := repo.ListCheckpoints(ctx, state)
+	if err != nil {
+		t.Fatalf("ListCheckpoints failed: %v", err)
+	}
+	if len(checkpoints) != 2 || checkpoints[1].Commit != second || checkpoints[1].Number != 2 {
+		t.Errorf("Expected both checkpoints to be listed, got %+v", checkpoints)
+	}
+
+	ref, count, err := repo.MaterializeRefs(ctx, state, "gitbak-refs")
+	if err != nil {
+		t.Fatalf("MaterializeRefs failed: %v", err)
+	}
+	if ref != "refs/gitbak/gitbak-refs/2" || count != 2 {
+		t.Errorf("Expected the branch to be made from the second of 2 refs, got %s of %d", ref, count)
+	}
+	if tip := gitOutput(t, repoPath, "rev-parse", "refs/heads/gitbak-refs"); tip != second {
+		t.Errorf("Expected the branch to point at the latest checkpoint, got %s", tip)
+	}
+	if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != startBranch {
+		t.Errorf("Expected materializing to leave %s checked out, got %s", startBranch, branch)
+	}
+
+	if _, _, err := repo.MaterializeRefs(ctx, state, "gitbak-refs"); !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Errorf("Expected materializing onto an existing branch to fail, got %v", err)
+	}
+}
+
+// TestRefsModeRenamesSession tests that a session does not write over the refs of an
+// earlier session of the same name
+func TestRefsModeRenamesSession(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	gitOutput(t, repoPath, "update-ref", "refs/gitbak/gitbak-refs/1", "HEAD")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-refs",
+		CommitPrefix:   "[gitbak-refs] Checkpoint",
+		NonInteractive: true,
+		Mode:           ModeRefs,
+	}, logger.New(false, "", false))
+
+	if err := gb.initialize(context.Background()); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if !strings.HasPrefix(gb.config.BranchName, "gitbak-refs-") {
+		t.Errorf("Expected the session to be renamed, got %q", gb.config.BranchName)
+	}
+}
+
+// TestMaterializeRefsOrder tests that the latest checkpoint is found by number, not by name
+func TestMaterializeRefsOrder(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	first := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	writeRepoFile(t, repoPath, "next.txt", "next")
+	gitOutput(t, repoPath, "add", "next.txt")
+	gitOutput(t, repoPath, "commit", "-m", "next")
+	latest := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+	for n := 1; n <= 10; n++ {
+		commit := first
+		if n == 10 {
+			commit = latest
+		}
+		gitOutput(t, repoPath, "update-ref", "refs/gitbak/session/"+strconv.Itoa(n), commit)
+	}
+	// Not a checkpoint ref
+	gitOutput(t, repoPath, "update-ref", "refs/gitbak/session/other", first)
+
+	repo := NewRepository(repoPath, nil)
+	ref, count, err := repo.MaterializeRefs(context.Background(), &session.State{Refs: "refs/gitbak/session"}, "materialized")
+	if err != nil {
+		t.Fatalf("MaterializeRefs failed: %v", err)
+	}
+	if ref != "refs/gitbak/session/10" || count != 10 {
+		t.Errorf("Expected refs/gitbak/session/10 of 10 refs, got %s of %d", ref, count)
+	}
+	if tip := gitOutput(t, repoPath, "rev-parse", "materialized"); tip != latest {
+		t.Errorf("Expected the branch to point at checkpoint 10, got %s", tip)
+	}
+
+	if _, _, err := repo.MaterializeRefs(context.Background(), &session.State{Branch: "main"}, "other"); !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Errorf("Expected a session without refs to be refused, got %v", err)
+	}
+}
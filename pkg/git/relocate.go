@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// repoLocation records where the repository was when the session started, so that it
+// can be found again if its directory is moved or renamed while gitbak runs
+type repoLocation struct {
+	// top is the worktree's top-level directory, and sub the path of RepoPath within it
+	top string
+	sub string
+
+	// gitDir is the worktree's own git directory and commonDir the repository's shared
+	// one; they differ only in a linked worktree
+	gitDir    string
+	commonDir string
+
+	// handle is open on the git directory of a main worktree, whose path followDir reads
+	// back after it moves along with the worktree; nil where that is unsupported
+	handle *os.File
+}
+
+// trackLocation records where the repository is, for followRepository. If that fails,
+// the session just cannot follow the repository should it move.
+func (g *Gitbak) trackLocation() {
+	if g.config.SeparateGitDir {
+		g.logger.Info("The git directory is set apart from the work tree, so the repository cannot be followed if it moves")
+		return
+	}
+	repoPath, err := filepath.Abs(g.config.RepoPath)
+	if err != nil {
+		return
+	}
+	top, gitDir, commonDir, err := findGitDirs(repoPath)
+	if err != nil {
+		g.logger.Warning("Failed to locate the git directory, so the repository cannot be followed if it moves: %v", err)
+		return
+	}
+	sub, err := filepath.Rel(top, repoPath)
+	if err != nil {
+		return
+	}
+
+	g.location = &repoLocation{top: top, sub: sub, gitDir: gitDir, commonDir: commonDir}
+	if gitDir == commonDir && filepath.Dir(commonDir) == top {
+		g.location.handle = openFollowable(commonDir)
+	}
+}
+
+// followRepository checks that the repository is still where it was, and re-attaches the
+// session to it if its directory has been moved or renamed. It returns ErrRepositoryMoved
+// when the repository is gone and cannot be found, and monitoring must stop. If its
+// location was never recorded, failing git commands report the problem instead.
+func (g *Gitbak) followRepository(ctx context.Context) error {
+	if g.location == nil {
+		return nil
+	}
+	if _, err := os.Stat(g.config.RepoPath); !os.IsNotExist(err) {
+		return nil
+	}
+
+	moved := g.config.RepoPath
+	path, err := g.location.relocate()
+	if err != nil {
+		g.logger.Info("Repository %s is gone and could not be found: %v", moved, err)
+		hint := "if it was moved, run 'gitbak -continue' in its new location to resume the session"
+		if g.location.gitDir != g.location.commonDir {
+			hint = "if the worktree was moved by hand, run 'git worktree repair' in its new location, then 'gitbak -continue' there to resume the session"
+		}
+		return gitbakErrors.Wrapf(gitbakErrors.ErrRepositoryMoved, "%s no longer exists (%v); %s", moved, err, hint)
+	}
+
+	g.config.RepoPath = path
+	g.logger.WarningToUser("Repository moved from %s to %s, following it", moved, path)
+	g.logger.Info("Re-attached to the repository at %s", path)
+	g.setupPauseFile(ctx)
+	if g.config.OnMoved != nil {
+		g.config.OnMoved(path)
+	}
+	g.saveState()
+	return nil
+}
+
+// relocate returns the new path of the repository's directory, found through its git
+// directories, and records the new location
+func (l *repoLocation) relocate() (string, error) {
+	var top, commonDir string
+	switch {
+	case l.gitDir != l.commonDir:
+		// 'git worktree move' and 'git worktree repair' record where a linked worktree
+		// went in the git directory the repository keeps for it
+		link, err := os.ReadFile(filepath.Join(l.gitDir, "gitdir"))
+		if err != nil {
+			return "", gitbakErrors.Wrap(err, "failed to read the worktree's location")
+		}
+		top = filepath.Dir(resolveFrom(l.gitDir, strings.TrimSpace(string(link))))
+		commonDir = l.commonDir
+	case l.handle != nil:
+		// The git directory moved along with the worktree around it
+		dir, err := followDir(l.handle)
+		if err != nil {
+			return "", err
+		}
+		top, commonDir = filepath.Dir(dir), dir
+	default:
+		return "", gitbakErrors.New("there is no way to tell where it went")
+	}
+
+	path := filepath.Join(top, l.sub)
+	if _, _, found, err := findGitDirs(path); err != nil || found != commonDir {
+		return "", gitbakErrors.Errorf("the repository is not at %s", path)
+	}
+
+	l.top, l.commonDir = top, commonDir
+	if l.handle != nil {
+		l.gitDir = commonDir
+	}
+	return path, nil
+}
@@ -0,0 +1,30 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// openFollowable opens dir so that followDir can tell where it is later, even once it is
+// moved or renamed
+func openFollowable(dir string) *os.File {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// followDir returns the current path of the directory f is open on, from /proc
+func followDir(f *os.File) (string, error) {
+	path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", f.Fd()))
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(path, " (deleted)") {
+		return "", errors.New("it was deleted")
+	}
+	return path, nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package git
+
+import (
+	"errors"
+	"os"
+)
+
+// openFollowable returns nil here, where the path of an open directory cannot be read back.
+// On Windows, an open handle would also keep the directory from being moved at all.
+func openFollowable(string) *os.File {
+	return nil
+}
+
+// followDir is unsupported here; see openFollowable
+func followDir(*os.File) (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestFollowRepository tests that a session follows its repository when the directory is
+// moved, and stops with ErrRepositoryMoved when it cannot be found
+func TestFollowRepository(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		// worktree runs the session in a linked worktree of the repository
+		worktree bool
+
+		// move moves or deletes the repository at repoPath, returning where it went
+		move         func(t *testing.T, repoPath string) string
+		expectFollow bool
+	}{
+		"MovedRepository": {
+			move: func(t *testing.T, repoPath string) string {
+				moved := filepath.Join(t.TempDir(), "moved")
+				if err := os.Rename(repoPath, moved); err != nil {
+					t.Fatalf("Failed to move repository: %v", err)
+				}
+				return moved
+			},
+			// Only Linux can read back the path of the git directory after it moves
+			expectFollow: runtime.GOOS == "linux",
+		},
+		"MovedWorktree": {
+			worktree: true,
+			move: func(t *testing.T, repoPath string) string {
+				moved := filepath.Join(t.TempDir(), "moved")
+				gitOutput(t, repoPath, "worktree", "move", repoPath, moved)
+				return moved
+			},
+			expectFollow: true,
+		},
+		"DeletedRepository": {
+			move: func(t *testing.T, repoPath string) string {
+				if err := os.RemoveAll(repoPath); err != nil {
+					t.Fatalf("Failed to delete repository: %v", err)
+				}
+				return ""
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			if test.worktree {
+				worktree := filepath.Join(t.TempDir(), "worktree")
+				gitOutput(t, repoPath, "worktree", "add", "-q", "-b", "work", worktree)
+				repoPath = worktree
+			}
+
+			moved := ""
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-moved",
+				CommitPrefix:   "[gitbak] Checkpoint",
+				NonInteractive: true,
+				OnMoved:        func(path string) { moved = path },
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			if err := gb.initialize(ctx); err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+			if err := gb.followRepository(ctx); err != nil {
+				t.Fatalf("Expected an unmoved repository to be left alone, got %v", err)
+			}
+
+			newPath := test.move(t, repoPath)
+			err := gb.followRepository(ctx)
+
+			if !test.expectFollow {
+				if !gitbakErrors.Is(err, gitbakErrors.ErrRepositoryMoved) {
+					t.Fatalf("Expected ErrRepositoryMoved, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("followRepository failed: %v", err)
+			}
+			if !samePath(t, gb.config.RepoPath, newPath) || moved != gb.config.RepoPath {
+				t.Fatalf("Expected to follow the repository to %s, got %s (reported %s)", newPath, gb.config.RepoPath, moved)
+			}
+
+			// Checkpoints go on in the new location
+			if err := os.WriteFile(filepath.Join(newPath, "work.txt"), []byte("work"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			created := false
+			if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil || !created {
+				t.Fatalf("Expected a checkpoint after following the repository, got %v", err)
+			}
+		})
+	}
+}
+
+// samePath reports whether a and b name the same directory, whatever symlinks lead to it
+func samePath(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	realA, errA := filepath.EvalSymlinks(a)
+	realB, errB := filepath.EvalSymlinks(b)
+	if errA != nil || errB != nil {
+		t.Fatalf("Failed to resolve %s and %s: %v, %v", a, b, errA, errB)
+	}
+	return realA == realB
+}
+
+// TestSeparateGitDir tests that a session works in a work tree whose git directory is kept
+// elsewhere and named by the global arguments, without trying to follow it
+func TestSeparateGitDir(t *testing.T) {
+	t.Parallel()
+
+	workTree := setupTestRepo(t)
+	gitDir := filepath.Join(t.TempDir(), "repo.git")
+	if err := os.Rename(filepath.Join(workTree, ".git"), gitDir); err != nil {
+		t.Fatalf("Failed to move the git directory: %v", err)
+	}
+
+	config := GitbakConfig{
+		RepoPath:       workTree,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-separate",
+		CommitPrefix:   "[gitbak] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+		GitGlobalArgs:  []string{"--git-dir=" + gitDir, "--work-tree=" + workTree},
+		SeparateGitDir: true,
+	}
+	executor := NewExecExecutorWithOptions(ExecOptions{GlobalArgs: config.GitGlobalArgs})
+	gb, err := NewGitbakWithDeps(config, logger.New(false, "", false), executor, NewNonInteractiveInteractor())
+	if err != nil {
+		t.Fatalf("NewGitbakWithDeps failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if gb.location != nil {
+		t.Error("Expected a separate git directory not to be tracked")
+	}
+
+	if err := os.WriteFile(filepath.Join(workTree, "work.txt"), []byte("work"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	created := false
+	if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil || !created {
+		t.Fatalf("Expected a checkpoint, got %v", err)
+	}
+
+	out, err := exec.Command("git", "--git-dir="+gitDir, "log", "-1", "--format=%s", "gitbak-separate").Output()
+	if err != nil {
+		t.Fatalf("Failed to read the checkpoint: %v", err)
+	}
+	if subject := strings.TrimSpace(string(out)); !strings.HasPrefix(subject, "[gitbak] Checkpoint #1") {
+		t.Errorf("Expected the checkpoint in the separate git directory, got %q", subject)
+	}
+}
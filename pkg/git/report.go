@@ -0,0 +1,278 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// SessionReport is the record of a session that PrintSummary writes to SummaryFile
+type SessionReport struct {
+	// Branch is where the session's checkpoints were made
+	Branch string `json:"branch"`
+
+	// OriginalBranch is the branch that was checked out when the session started
+	OriginalBranch string `json:"original_branch"`
+
+	// Mode is how checkpoints were recorded: ModeBranch, ModeStash, ModeRefs or ModeObserve
+	Mode string `json:"mode"`
+
+	// StartTime and EndTime bound the session
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+
+	// DurationSeconds is how long the session ran
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// Checkpoints is how many checkpoints the session made
+	Checkpoints int `json:"checkpoints"`
+
+	// Storage is how much the session grew the object database, or nil if the backend
+	// cannot measure it
+	Storage *ReportStorage `json:"storage,omitempty"`
+
+	// Commits lists the commits made on Branch during the session, oldest first, or in
+	// refs mode the checkpoints written to refs. In stash mode, snapshots are not listed.
+	Commits []ReportCommit `json:"commits"`
+
+	// NextSteps holds the commands for merging the checkpoints or recovering the snapshots
+	NextSteps []ReportStep `json:"next_steps,omitempty"`
+}
+
+// ReportCommit is a commit made during the session, with the size of its changes
+type ReportCommit struct {
+	Hash       string    `json:"hash"`
+	Subject    string    `json:"subject"`
+	Time       time.Time `json:"time"`
+	Files      int       `json:"files"`
+	Insertions int       `json:"insertions"`
+	Deletions  int       `json:"deletions"`
+}
+
+// ReportStorage is the growth of the object database during the session, in bytes. It can
+// be negative when git's automatic garbage collection packs loose objects.
+type ReportStorage struct {
+	StartBytes         int64 `json:"start_bytes"`
+	EndBytes           int64 `json:"end_bytes"`
+	AddedBytes         int64 `json:"added_bytes"`
+	PerCheckpointBytes int64 `json:"per_checkpoint_bytes"`
+}
+
+// ReportStep is an instruction for following up on the session
+type ReportStep struct {
+	Description string   `json:"description"`
+	Commands    []string `json:"commands"`
+}
+
+// writeReport writes the session report to path, as JSON if it ends in .json and as
+// Markdown otherwise. The git queries it makes are bounded by summaryTimeout.
+func (g *Gitbak) writeReport(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+
+	report := g.report(ctx)
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var err error
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return gitbakErrors.Wrap(err, "failed to encode the session report")
+		}
+		data = append(data, '\n')
+	} else {
+		data = report.Markdown()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return gitbakErrors.Wrapf(err, "failed to create directory for %s", path)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return gitbakErrors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+// printReportJSON writes the session report to SummaryJSON as a single line of JSON.
+// The git queries it makes are bounded by summaryTimeout.
+func (g *Gitbak) printReportJSON(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+
+	if err := json.NewEncoder(g.config.SummaryJSON).Encode(g.report(ctx)); err != nil {
+		g.logger.WarningToUser("Failed to print the session report: %v", err)
+	}
+}
+
+// report gathers the session report. Commits that cannot be listed are left out, so
+// that the rest of the report is still written.
+func (g *Gitbak) report(ctx context.Context) SessionReport {
+	end := time.Now()
+	report := SessionReport{
+		Branch:          g.sessionBranch(),
+		OriginalBranch:  g.originalBranch,
+		Mode:            ModeBranch,
+		StartTime:       g.startTime,
+		EndTime:         end,
+		DurationSeconds: end.Sub(g.startTime).Seconds(),
+		Checkpoints:     g.commitsCount,
+		Commits:         []ReportCommit{},
+		NextSteps:       g.nextSteps(),
+	}
+	if g.storageTracked {
+		report.Storage = &ReportStorage{
+			StartBytes: g.storageStart,
+			EndBytes:   g.storageSize,
+			AddedBytes: g.storageSize - g.storageStart,
+		}
+		if len(g.storageGrowth) > 0 {
+			report.Storage.PerCheckpointBytes = report.Storage.AddedBytes / int64(len(g.storageGrowth))
+		}
+	}
+
+	if g.stashMode() || g.observeMode() {
+		report.Mode = g.config.Mode
+		return report
+	}
+	if g.refsMode() {
+		report.Mode = ModeRefs
+		if g.lastCheckpoint == "" {
+			return report
+		}
+	}
+
+	commits, err := g.sessionCommits(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to list the session's commits for the report: %v", err)
+		return report
+	}
+	report.Commits = commits
+	return report
+}
+
+// sessionCommits lists the commits made on the session branch since the session started,
+// or in refs mode the checkpoints leading up to the latest, oldest first, with the number
+// of files and lines each one changed
+func (g *Gitbak) sessionCommits(ctx context.Context) ([]ReportCommit, error) {
+	tip := g.sessionBranch()
+	if g.refsMode() {
+		tip = g.lastCheckpoint
+	}
+	args := []string{"log", "--reverse", "--no-color", "--format=%x1e%H%x1f%cI%x1f%s", "--numstat"}
+	if g.startCommit != "" {
+		args = append(args, g.startCommit+".."+tip)
+	} else {
+		args = append(args, tip)
+	}
+
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("log", args[1:], gitbakErrors.Wrap(err, "failed to list session commits"), "")
+	}
+	return parseSessionCommits(out), nil
+}
+
+// LastCommit describes the commit at rev with the number of files and lines it changed
+func (r *Repository) LastCommit(ctx context.Context, rev string) (*ReportCommit, error) {
+	args := []string{"log", "-1", "--no-color", "--format=%x1e%H%x1f%cI%x1f%s", "--numstat", rev}
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("log", args[1:], gitbakErrors.Wrap(err, "failed to describe commit"), "")
+	}
+
+	commits := parseSessionCommits(out)
+	if len(commits) == 0 {
+		return nil, gitbakErrors.Errorf("no commit found at %s", rev)
+	}
+	return &commits[0], nil
+}
+
+// parseSessionCommits parses git log output of records separated by \x1e, each a header
+// line of \x1f-separated hash, committer date and subject, followed by --numstat lines
+func parseSessionCommits(out string) []ReportCommit {
+	commits := []ReportCommit{}
+	for _, record := range strings.Split(out, "\x1e") {
+		header, stats, _ := strings.Cut(record, "\n")
+		fields := strings.SplitN(header, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		commit := ReportCommit{Hash: fields[0], Subject: fields[2]}
+		commit.Time, _ = time.Parse(time.RFC3339, fields[1])
+		for _, line := range strings.Split(stats, "\n") {
+			added, rest, ok := strings.Cut(line, "\t")
+			if !ok {
+				continue
+			}
+			removed, _, _ := strings.Cut(rest, "\t")
+
+			// Binary files report "-" for both counts
+			commit.Files++
+			a, _ := strconv.Atoi(added)
+			r, _ := strconv.Atoi(removed)
+			commit.Insertions += a
+			commit.Deletions += r
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// Markdown renders the report as a Markdown document
+func (r SessionReport) Markdown() []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# gitbak session on %s\n\n", r.Branch)
+	fmt.Fprintf(&b, "- **Branch:** `%s`\n", r.Branch)
+	fmt.Fprintf(&b, "- **Original branch:** `%s`\n", r.OriginalBranch)
+	fmt.Fprintf(&b, "- **Mode:** %s\n", r.Mode)
+	fmt.Fprintf(&b, "- **Started:** %s\n", r.StartTime.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- **Ended:** %s\n", r.EndTime.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- **Duration:** %s\n", time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Second))
+	fmt.Fprintf(&b, "- **Checkpoints:** %d\n", r.Checkpoints)
+	if r.Storage != nil {
+		fmt.Fprintf(&b, "- **Object storage:** %s (~%s per checkpoint)\n",
+			FormatSize(r.Storage.AddedBytes), FormatSize(r.Storage.PerCheckpointBytes))
+	}
+
+	if r.Mode == ModeBranch {
+		b.WriteString("\n## Commits\n\n")
+		if len(r.Commits) == 0 {
+			b.WriteString("No commits were made.\n")
+		} else {
+			b.WriteString("| Time | Commit | Subject | Files | + | - |\n")
+			b.WriteString("|------|--------|---------|------:|--:|--:|\n")
+			for _, c := range r.Commits {
+				fmt.Fprintf(&b, "| %s | `%s` | %s | %d | %d | %d |\n",
+					c.Time.Format("15:04:05"), shortHash(c.Hash), strings.ReplaceAll(c.Subject, "|", `\|`),
+					c.Files, c.Insertions, c.Deletions)
+			}
+		}
+	}
+
+	if len(r.NextSteps) > 0 {
+		b.WriteString("\n## Next steps\n")
+		for _, step := range r.NextSteps {
+			fmt.Fprintf(&b, "\n%s:\n\n```sh\n%s\n```\n", step.Description, strings.Join(step.Commands, "\n"))
+		}
+	}
+
+	return b.Bytes()
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestWriteReport tests that the session report lists the session's commits and next steps
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	original := gitOutput(t, repoPath, "branch", "--show-current")
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	gitOutput(t, repoPath, "checkout", "-b", "gitbak-report")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-report",
+		CommitPrefix:   "[gitbak-report] Checkpoint",
+		CreateBranch:   true,
+		NonInteractive: true,
+	}, logger.New(false, "", false))
+	gb.originalBranch = original
+	gb.startCommit = startCommit
+	gb.startTime = time.Now().Add(-time.Hour)
+
+	ctx := context.Background()
+	edits := []map[string]string{
+		{"initial.txt": "changed\n", "new.txt": "one\ntwo\n"},
+		{"new.txt": "one\n"},
+	}
+	for i, edit := range edits {
+		for file, content := range edit {
+			if err := os.WriteFile(filepath.Join(repoPath, file), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", file, err)
+			}
+		}
+		if _, err := gb.createCommit(ctx, i+1); err != nil {
+			t.Fatalf("createCommit failed: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "session.json")
+	if err := gb.writeReport(ctx, jsonPath); err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	var report SessionReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Report is not valid JSON: %v\n%s", err, data)
+	}
+
+	if report.Branch != "gitbak-report" || report.OriginalBranch != original || report.Checkpoints != 2 {
+		t.Errorf("Unexpected session details: %+v", report)
+	}
+	if report.DurationSeconds < time.Hour.Seconds() {
+		t.Errorf("Expected a duration of at least an hour, got %.0fs", report.DurationSeconds)
+	}
+	if len(report.Commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %+v", report.Commits)
+	}
+
+	first, second := report.Commits[0], report.Commits[1]
+	if !strings.HasPrefix(first.Subject, "[gitbak-report] Checkpoint #1") || first.Time.IsZero() {
+		t.Errorf("Expected the first checkpoint to be listed first, got %+v", first)
+	}
+	if first.Files != 2 || first.Insertions != 3 || first.Deletions != 1 {
+		t.Errorf("Expected 2 files, 3 insertions and 1 deletion, got %+v", first)
+	}
+	if second.Files != 1 || second.Insertions != 0 || second.Deletions != 1 {
+		t.Errorf("Expected 1 file and 1 deletion, got %+v", second)
+	}
+	if len(report.NextSteps) == 0 || !strings.Contains(strings.Join(report.NextSteps[0].Commands, "\n"), "git merge gitbak-report") {
+		t.Errorf("Expected merge instructions, got %+v", report.NextSteps)
+	}
+
+	mdPath := filepath.Join(dir, "notes", "session.md")
+	if err := gb.writeReport(ctx, mdPath); err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+	markdown, err := os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	for _, want := range []string{
+		"# gitbak session on gitbak-report",
+		"- **Checkpoints:** 2",
+		"| `" + shortHash(first.Hash) + "` | " + first.Subject + " | 2 | 3 | 1 |",
+		"```sh\ngit checkout " + original + "\ngit merge gitbak-report\n```",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("Expected Markdown report to contain %q, got:\n%s", want, markdown)
+		}
+	}
+}
+
+// TestPrintSummaryJSON tests that the summary is printed as a single line of JSON in place of the text
+func TestPrintSummaryJSON(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	var out, logs bytes.Buffer
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-json",
+		CommitPrefix:   "[gitbak-json] Checkpoint",
+		NonInteractive: true,
+		SummaryJSON:    &out,
+	}, logger.NewWithOutput(false, "", true, &logs, &logs))
+	gb.originalBranch = gitOutput(t, repoPath, "branch", "--show-current")
+	gb.startCommit = gitOutput(t, repoPath, "rev-parse", "HEAD")
+	gb.startTime = time.Now().Add(-time.Minute)
+
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := gb.createCommit(ctx, 1); err != nil {
+		t.Fatalf("createCommit failed: %v", err)
+	}
+	gb.PrintSummary(ctx)
+
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Fatalf("Expected a single line of JSON, got %q", out.String())
+	}
+	var report SessionReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Summary is not valid JSON: %v\n%s", err, out.String())
+	}
+	if report.Branch != gb.originalBranch || report.Checkpoints != 1 || len(report.Commits) != 1 || report.DurationSeconds < 60 {
+		t.Errorf("Unexpected summary: %+v", report)
+	}
+	if strings.Contains(logs.String(), "Session Summary") {
+		t.Errorf("Expected no text summary, got %q", logs.String())
+	}
+}
+
+func TestParseSessionCommits(t *testing.T) {
+	out := "\x1eabc123\x1f2025-04-14T10:32:05+02:00\x1fFirst\n\n1\t2\ta.txt\n-\t-\timage.bin\n" +
+		"\x1edef456\x1f2025-04-14T10:37:05+02:00\x1fEmpty\n"
+
+	commits := parseSessionCommits(out)
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %+v", commits)
+	}
+	if c := commits[0]; c.Hash != "abc123" || c.Subject != "First" || c.Files != 2 || c.Insertions != 1 || c.Deletions != 2 {
+		t.Errorf("Unexpected first commit: %+v", c)
+	}
+	if c := commits[1]; c.Files != 0 || c.Time.Minute() != 37 {
+		t.Errorf("Unexpected second commit: %+v", c)
+	}
+}
+
+// TestLastCommit tests that LastCommit describes the latest commit on a branch
+func TestLastCommit(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\nmore\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	gitOutput(t, repoPath, "commit", "-am", "Second")
+
+	repo := NewRepository(repoPath, nil)
+	commit, err := repo.LastCommit(context.Background(), "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to describe last commit: %v", err)
+	}
+	if commit.Subject != "Second" || commit.Files != 1 || commit.Insertions != 2 || commit.Deletions != 1 {
+		t.Errorf("Unexpected last commit: %+v", commit)
+	}
+
+	if _, err := repo.LastCommit(context.Background(), "missing-branch"); err == nil {
+		t.Error("Expected an error for a missing branch")
+	}
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Repository runs git commands against a repository outside of a monitoring session.
+// It backs the gitbak subcommands (such as abort) that operate on a session after
+// the monitoring process has stopped.
+type Repository struct {
+	path     string
+	executor CommandExecutor
+}
+
+// NewRepository creates a Repository for the given path.
+// If executor is nil, the default ExecExecutor is used.
+func NewRepository(path string, executor CommandExecutor) *Repository {
+	if executor == nil {
+		executor = NewExecExecutor()
+	}
+	return &Repository{
+		path:     path,
+		executor: executor,
+	}
+}
+
+// Path returns the filesystem path of the repository.
+func (r *Repository) Path() string {
+	return r.path
+}
+
+// run executes a git command in the repository directory.
+func (r *Repository) run(ctx context.Context, args ...string) error {
+	allArgs := append([]string{"-C", r.path}, args...)
+	return r.executor.ExecuteWithContext(ctx, "git", allArgs...)
+}
+
+// output executes a git command in the repository directory and returns its trimmed output.
+func (r *Repository) output(ctx context.Context, args ...string) (string, error) {
+	allArgs := append([]string{"-C", r.path}, args...)
+	out, err := r.executor.ExecuteWithContextAndOutput(ctx, "git", allArgs...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runWithInput executes a git command in the repository directory, passing it input on stdin.
+func (r *Repository) runWithInput(ctx context.Context, input string, args ...string) error {
+	allArgs := append([]string{"-C", r.path}, args...)
+	cmd := exec.Command("git", allArgs...)
+	cmd.Stdin = strings.NewReader(input)
+	_, err := r.executor.ExecuteWithOutput(ctx, cmd)
+	return err
+}
+
+// CurrentBranch returns the name of the checked out branch.
+func (r *Repository) CurrentBranch(ctx context.Context) (string, error) {
+	return r.output(ctx, "branch", "--show-current")
+}
+
+// runInteractive executes a git command attached to the terminal, e.g. to open an editor.
+func (r *Repository) runInteractive(ctx context.Context, args ...string) error {
+	allArgs := append([]string{"-C", r.path}, args...)
+	cmd := exec.Command("git", allArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return r.executor.Execute(ctx, cmd)
+}
+
+// MirrorRefPrefix is the namespace that mirrors receive session branches in, so that a
+// mirror never overwrites one of the remote's branches
+const MirrorRefPrefix = "refs/gitbak/"
+
+// PushMirror pushes branch to MirrorRefPrefix+branch on remote and returns the commit pushed.
+// With an empty lease the push must fast-forward the remote ref, or create it; otherwise the
+// remote ref is overwritten only while it still points at lease, the commit pushed last time,
+// so that a rewritten session branch replaces its own mirror but nothing pushed by anyone else.
+// If env is non-nil, it is used as the complete environment of the git process.
+func (r *Repository) PushMirror(ctx context.Context, remote, branch, lease string, env []string) (string, error) {
+	commit, err := r.output(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch+"^{commit}")
+	if err != nil {
+		return "", gitbakErrors.Wrapf(err, "failed to resolve branch %s", branch)
+	}
+
+	ref := MirrorRefPrefix + branch
+	args := []string{"-C", r.path, "push", "--quiet"}
+	if lease != "" {
+		args = append(args, "--force-with-lease="+ref+":"+lease)
+	}
+	cmd := exec.Command("git", append(args, remote, commit+":"+ref)...)
+	cmd.Env = env
+
+	if _, err := r.executor.ExecuteWithOutput(ctx, cmd); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestRepositoryPushMirror tests pushing a branch to its mirror ref on a bare repository
+func TestRepositoryPushMirror(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	mirrorPath := filepath.Join(t.TempDir(), "mirror.git")
+
+	if err := exec.Command("git", "init", "--bare", mirrorPath).Run(); err != nil {
+		t.Fatalf("Failed to create bare mirror: %v", err)
+	}
+	gitOutput(t, repoPath, "checkout", "-b", "gitbak-push-test")
+	// A branch of the same name on the remote must be left alone
+	gitOutput(t, repoPath, "push", "--quiet", mirrorPath, "HEAD:refs/heads/gitbak-push-test")
+	gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "Checkpoint")
+
+	ctx := context.Background()
+	repo := NewRepository(repoPath, nil)
+	pushed, err := repo.PushMirror(ctx, mirrorPath, "gitbak-push-test", "", nil)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	local := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	if pushed != local {
+		t.Errorf("Expected the pushed commit to be %s, got %s", local, pushed)
+	}
+	if remote := gitOutput(t, mirrorPath, "rev-parse", MirrorRefPrefix+"gitbak-push-test"); remote != local {
+		t.Errorf("Expected mirror ref at %s, got %s", local, remote)
+	}
+	if branch := gitOutput(t, mirrorPath, "rev-parse", "refs/heads/gitbak-push-test"); branch == local {
+		t.Error("Expected the remote branch not to be updated")
+	}
+
+	// Rewriting the session branch replaces its mirror, given the commit pushed before
+	gitOutput(t, repoPath, "commit", "--amend", "--allow-empty", "-m", "Rewritten")
+	if _, err := repo.PushMirror(ctx, mirrorPath, "gitbak-push-test", "", nil); err == nil {
+		t.Error("Expected a non-fast-forward push without a lease to be rejected")
+	}
+	rewritten, err := repo.PushMirror(ctx, mirrorPath, "gitbak-push-test", pushed, nil)
+	if err != nil {
+		t.Fatalf("Expected the rewritten branch to replace its mirror: %v", err)
+	}
+
+	// Once someone else moves the mirror ref, it is no longer overwritten
+	gitOutput(t, mirrorPath, "update-ref", MirrorRefPrefix+"gitbak-push-test", pushed)
+	gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "Checkpoint")
+	if _, err := repo.PushMirror(ctx, mirrorPath, "gitbak-push-test", rewritten, nil); err == nil {
+		t.Error("Expected a push with a stale lease to be rejected")
+	}
+
+	if _, err := repo.PushMirror(ctx, filepath.Join(t.TempDir(), "missing.git"), "gitbak-push-test", "", nil); err == nil {
+		t.Error("Expected pushing to a missing remote to fail")
+	}
+}
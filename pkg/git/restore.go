@@ -0,0 +1,228 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// Checkpoint is a checkpoint commit of a session, as listed by ListCheckpoints or
+// passed to GitbakConfig.OnCheckpoint
+type Checkpoint struct {
+	// Number is the checkpoint's number in its commit subject, or 0 if it has none
+	Number  int
+	Commit  string
+	Time    time.Time
+	Subject string
+
+	// Branch is the branch the checkpoint was committed to, or empty for a ModeStash snapshot
+	// or a checkpoint written to a ref in ModeRefs
+	Branch string
+}
+
+// ShortCommit returns the abbreviated hash of the checkpoint's commit
+func (c Checkpoint) ShortCommit() string {
+	return shortCommit(c.Commit)
+}
+
+// ListCheckpoints returns the checkpoint commits a session made on its branch, or wrote to
+// refs, oldest first. Checkpoints are recognized by the session trailer, or by the session's
+// commit prefix for checkpoints made before they carried one, so commits made by hand in
+// between are not listed.
+func (r *Repository) ListCheckpoints(ctx context.Context, state *session.State) ([]Checkpoint, error) {
+	if state.Stash {
+		return nil, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' stored its snapshots in the stash; list them with git stash list and restore one with git stash apply", state.Branch)
+	}
+	if state.SessionID == "" && state.CommitPrefix == "" {
+		return nil, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session state does not record the commit prefix its checkpoints were made with")
+	}
+
+	branch, tip := state.Branch, state.Branch
+	if state.Refs != "" {
+		// The latest ref has every checkpoint of the session in its history
+		refs, err := r.checkpointRefs(ctx, state.Refs)
+		if err != nil || len(refs) == 0 {
+			return nil, err
+		}
+		branch, tip = "", refs[len(refs)-1].Commit
+	}
+
+	revRange := tip
+	if state.StartCommit != "" {
+		revRange = state.StartCommit + ".." + tip
+	}
+
+	var checkpoints []Checkpoint
+	var err error
+	if state.SessionID != "" {
+		checkpoints, err = r.logCheckpoints(ctx, branch, revRange, sessionTrailer(state.SessionID), "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(checkpoints) == 0 && state.CommitPrefix != "" {
+		// The prefix may also appear in the body of other commits, so it must start the subject
+		return r.logCheckpoints(ctx, branch, revRange, state.CommitPrefix, state.CommitPrefix)
+	}
+	return checkpoints, nil
+}
+
+// logCheckpoints returns the commits in revRange whose message contains text and whose
+// subject starts with prefix, oldest first, as checkpoints on branch
+func (r *Repository) logCheckpoints(ctx context.Context, branch, revRange, text, prefix string) ([]Checkpoint, error) {
+	out, err := r.output(ctx, "log", "--reverse", "--format=%H%x00%cI%x00%s", "--fixed-strings", "--grep="+text, revRange)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("log", []string{revRange}, gitbakErrors.Wrap(err, "failed to list checkpoints"), "")
+	}
+
+	var checkpoints []Checkpoint
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], prefix) {
+			continue
+		}
+
+		checkpoint := Checkpoint{Commit: fields[0], Subject: fields[2], Branch: branch, Number: subjectCheckpointNumber(fields[2])}
+		checkpoint.Time, _ = time.Parse(time.RFC3339, fields[1])
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// FindCheckpoint returns the checkpoint ref refers to: a checkpoint number, with or
+// without a leading #, or the start of a checkpoint's commit hash.
+func FindCheckpoint(checkpoints []Checkpoint, ref string) (Checkpoint, error) {
+	ref = strings.TrimSpace(ref)
+	if n, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		// A later checkpoint with the same number belongs to a continued session, so prefer it
+		for i := len(checkpoints) - 1; i >= 0; i-- {
+			if checkpoints[i].Number == n {
+				return checkpoints[i], nil
+			}
+		}
+	}
+
+	if len(ref) >= 4 {
+		var found []Checkpoint
+		for _, checkpoint := range checkpoints {
+			if strings.HasPrefix(checkpoint.Commit, strings.ToLower(ref)) {
+				found = append(found, checkpoint)
+			}
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		}
+		if len(found) > 1 {
+			return Checkpoint{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+				"commit %s is ambiguous: it starts %d checkpoints", ref, len(found))
+		}
+	}
+
+	return Checkpoint{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+		"no checkpoint %s in the session; run gitbak restore without arguments to list them", ref)
+}
+
+// ModifiedPaths lists the tracked paths among paths, or in the whole working tree if
+// none are given, with uncommitted changes that restoring would overwrite
+func (r *Repository) ModifiedPaths(ctx context.Context, paths []string) ([]string, error) {
+	args := append([]string{"diff", "HEAD", "--name-only", "--"}, restorePathspec(paths)...)
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("diff", args[1:], gitbakErrors.Wrap(err, "failed to check for uncommitted changes"), "")
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// OverwrittenUntrackedPaths lists the untracked files among paths, or in the whole working
+// tree if none are given, that restoring checkpoint would overwrite with its content
+func (r *Repository) OverwrittenUntrackedPaths(ctx context.Context, checkpoint Checkpoint, paths []string) ([]string, error) {
+	pathspec := restorePathspec(paths)
+	treeArgs := append([]string{"ls-tree", "-r", "-z", "--name-only", checkpoint.Commit, "--"}, pathspec...)
+	treeOut, err := r.output(ctx, treeArgs...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-tree", treeArgs[1:],
+			gitbakErrors.Wrap(err, fmt.Sprintf("failed to list the files of checkpoint %s", checkpoint.ShortCommit())), "")
+	}
+	indexArgs := append([]string{"ls-files", "-z", "--"}, pathspec...)
+	indexOut, err := r.output(ctx, indexArgs...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", indexArgs[1:], gitbakErrors.Wrap(err, "failed to list tracked files"), "")
+	}
+
+	tracked := make(map[string]bool)
+	for _, path := range strings.Split(indexOut, "\x00") {
+		tracked[path] = true
+	}
+
+	// Ignored files count too, as git restore overwrites them all the same
+	var untracked []string
+	for _, path := range strings.Split(treeOut, "\x00") {
+		if path == "" || tracked[path] {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(r.path, filepath.FromSlash(path))); err == nil {
+			untracked = append(untracked, path)
+		}
+	}
+	return untracked, nil
+}
+
+// RestoreCheckpoint restores paths in the working tree, or the whole working tree if
+// none are given, to their content at the checkpoint. Tracked files the checkpoint
+// did not have are removed and untracked files it has are overwritten, while other
+// untracked files and the index are left alone, so the result shows up as
+// uncommitted changes on the current branch.
+func (r *Repository) RestoreCheckpoint(ctx context.Context, checkpoint Checkpoint, paths []string) error {
+	args := append([]string{"restore", "--source=" + checkpoint.Commit, "--worktree", "--"}, restorePathspec(paths)...)
+	if err := r.run(ctx, args...); err != nil {
+		return gitbakErrors.NewGitError("restore", args[1:],
+			gitbakErrors.Wrap(err, fmt.Sprintf("failed to restore checkpoint %s", checkpoint.ShortCommit())), "")
+	}
+	return nil
+}
+
+// DiffCheckpoints writes the changes from checkpoint from to checkpoint to to w, as git diff
+// shows them, and reports whether there were any. When w is a terminal, git pages and
+// colors the diff as it does its own.
+func (r *Repository) DiffCheckpoints(ctx context.Context, w io.Writer, from, to Checkpoint) (bool, error) {
+	err := r.run(ctx, "diff", "--quiet", from.Commit, to.Commit)
+	if err == nil {
+		return false, nil
+	}
+	if exitCode(err) != 1 {
+		return false, gitbakErrors.NewGitError("diff", []string{"--quiet", from.Commit, to.Commit},
+			gitbakErrors.Wrap(err, "failed to compare checkpoints"), "")
+	}
+
+	cmd := exec.Command("git", "-C", r.path, "diff", from.Commit, to.Commit)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	if err := r.executor.Execute(ctx, cmd); err != nil {
+		return false, gitbakErrors.NewGitError("diff", []string{from.Commit, to.Commit},
+			gitbakErrors.Wrap(err, "failed to show the changes between checkpoints"), "")
+	}
+	return true, nil
+}
+
+// restorePathspec returns the pathspec for paths, which default to the whole working tree
+func restorePathspec(paths []string) []string {
+	if len(paths) == 0 {
+		return []string{"."}
+	}
+	return paths
+}
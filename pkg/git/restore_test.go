@@ -0,0 +1,96 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestListCheckpoints tests listing the checkpoints of a session branch
+func TestListCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	gitOutput(t, repoPath, "checkout", "-b", "gitbak-list")
+	for i, subject := range []string{
+		"[gitbak] Automatic checkpoint #1 - 2026-01-01 10:00:00",
+		"Manual commit mentioning [gitbak] Automatic checkpoint #9",
+		"[gitbak] Automatic checkpoint #2 - 2026-01-01 10:05:00",
+	} {
+		if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte(subject), 0644); err != nil {
+			t.Fatalf("Failed to write file %d: %v", i, err)
+		}
+		gitOutput(t, repoPath, "add", ".")
+		gitOutput(t, repoPath, "commit", "-m", subject)
+	}
+
+	repo := NewRepository(repoPath, nil)
+	state := &session.State{Branch: "gitbak-list", CommitPrefix: "[gitbak] Automatic checkpoint", StartCommit: startCommit}
+	checkpoints, err := repo.ListCheckpoints(context.Background(), state)
+	if err != nil {
+		t.Fatalf("ListCheckpoints failed: %v", err)
+	}
+
+	if len(checkpoints) != 2 || checkpoints[0].Number != 1 || checkpoints[1].Number != 2 {
+		t.Fatalf("Expected checkpoints #1 and #2, got %+v", checkpoints)
+	}
+	if head := gitOutput(t, repoPath, "rev-parse", "HEAD"); checkpoints[1].Commit != head {
+		t.Errorf("Expected the last checkpoint to be HEAD %s, got %s", head, checkpoints[1].Commit)
+	}
+	if checkpoints[0].Time.IsZero() {
+		t.Error("Expected the checkpoint time to be set")
+	}
+
+	state.Stash = true
+	if _, err := repo.ListCheckpoints(context.Background(), state); !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Errorf("Expected stash sessions to be refused, got %v", err)
+	}
+}
+
+// TestFindCheckpoint tests resolving a checkpoint number or commit
+func TestFindCheckpoint(t *testing.T) {
+	checkpoints := []Checkpoint{
+		{Number: 1, Commit: "aaaa1111"},
+		{Number: 2, Commit: "abcd2222"},
+		{Number: 1, Commit: "abcd3333"},
+		{Commit: "ffff4444"},
+	}
+
+	tests := map[string]struct {
+		ref         string
+		expected    string
+		expectError bool
+	}{
+		"Number":          {ref: "2", expected: "abcd2222"},
+		"HashNumber":      {ref: "#2", expected: "abcd2222"},
+		"LatestOfNumber":  {ref: "1", expected: "abcd3333"},
+		"Commit":          {ref: "ffff", expected: "ffff4444"},
+		"CommitUppercase": {ref: "FFFF44", expected: "ffff4444"},
+		"AmbiguousCommit": {ref: "abcd", expectError: true},
+		"ShortCommit":     {ref: "ff", expectError: true},
+		"Unknown":         {ref: "7", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checkpoint, err := FindCheckpoint(checkpoints, test.ref)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %+v", test.ref, checkpoint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindCheckpoint failed: %v", err)
+			}
+			if checkpoint.Commit != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, checkpoint.Commit)
+			}
+		})
+	}
+}
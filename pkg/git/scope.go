@@ -0,0 +1,45 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scopePathspec returns the pathspec of the paths checkpoints are scoped to by Paths,
+// which is the whole working tree when Paths is empty
+func (g *Gitbak) scopePathspec() []string {
+	if len(g.config.Paths) == 0 {
+		return []string{"."}
+	}
+
+	pathspec := make([]string, 0, len(g.config.Paths))
+	for _, path := range g.config.Paths {
+		pathspec = append(pathspec, ":(literal)"+path)
+	}
+	return pathspec
+}
+
+// scopeArgs returns the arguments restricting a git command that lists changes to Paths,
+// or nothing when checkpoints cover the whole working tree
+func (g *Gitbak) scopeArgs() []string {
+	if len(g.config.Paths) == 0 {
+		return nil
+	}
+	return append([]string{"--"}, g.scopePathspec()...)
+}
+
+// setupScope warns about any of Paths that does not exist yet, as a mistyped path would
+// leave every change out of checkpoints
+func (g *Gitbak) setupScope() {
+	if len(g.config.Paths) == 0 {
+		return
+	}
+
+	for _, path := range g.config.Paths {
+		if _, err := os.Stat(filepath.Join(g.config.RepoPath, filepath.FromSlash(path))); os.IsNotExist(err) {
+			g.logger.WarningToUser("%s does not exist in %s; changes under it are checkpointed once it does", path, g.config.RepoPath)
+		}
+	}
+	g.logger.Info("Checkpoints are scoped to %s", strings.Join(g.config.Paths, ", "))
+}
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestScopedPaths tests that checkpoints only detect and stage changes under Paths
+func TestScopedPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		paths []string
+
+		// changed lists the files changed before the check, bakignore any .gitbakignore rules
+		changed   []string
+		bakignore string
+
+		expectCommitted []string
+	}{
+		"OnlyScopedChanges": {
+			paths:           []string{"team-a"},
+			changed:         []string{"team-a/app.txt", "team-a/new.txt", "team-b/app.txt"},
+			expectCommitted: []string{"team-a/app.txt", "team-a/new.txt"},
+		},
+		"ChangesOutsideScope": {
+			paths:   []string{"team-a"},
+			changed: []string{"team-b/app.txt", "shared/config.txt"},
+		},
+		"SeveralPaths": {
+			paths:           []string{"team-a", "shared/config.txt"},
+			changed:         []string{"team-a/app.txt", "team-b/app.txt", "shared/config.txt", "shared/other.txt"},
+			expectCommitted: []string{"shared/config.txt", "team-a/app.txt"},
+		},
+		"ExclusionsWithinScope": {
+			paths:           []string{"team-a"},
+			changed:         []string{"team-a/app.txt", "team-a/tmp/cache.txt", "team-b/app.txt"},
+			bakignore:       "tmp/\n",
+			expectCommitted: []string{"team-a/app.txt"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			for _, path := range []string{"team-a/app.txt", "team-b/app.txt", "shared/config.txt", "shared/other.txt"} {
+				writeRepoFile(t, repoPath, path, "original\n")
+			}
+			if test.bakignore != "" {
+				writeRepoFile(t, repoPath, BakignoreFile, test.bakignore)
+			}
+			gitOutput(t, repoPath, "add", ".")
+			gitOutput(t, repoPath, "commit", "-q", "-m", "Monorepo layout")
+			for _, path := range test.changed {
+				writeRepoFile(t, repoPath, path, "changed\n")
+			}
+			base := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-scoped",
+				CommitPrefix:   "[gitbak] Checkpoint",
+				NonInteractive: true,
+				Paths:          test.paths,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			var created bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &created); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if created != (len(test.expectCommitted) > 0) {
+				t.Fatalf("Expected a checkpoint to be created: %v, got %v", len(test.expectCommitted) > 0, created)
+			}
+
+			committed := strings.Fields(gitOutput(t, repoPath, "diff", "--name-only", base, "HEAD"))
+			if !slices.Equal(committed, test.expectCommitted) {
+				t.Errorf("Expected the checkpoint to take in %v, got %v", test.expectCommitted, committed)
+			}
+
+			// Changes outside the paths stay in the working tree, unstaged
+			status := gitOutput(t, repoPath, "status", "--porcelain", "--untracked-files=all")
+			for _, path := range test.changed {
+				if !slices.Contains(test.expectCommitted, path) && !strings.Contains(status, path) {
+					t.Errorf("Expected %s to be left in the working tree, got status %q", path, status)
+				}
+			}
+			if staged := gitOutput(t, repoPath, "diff", "--cached", "--name-only"); staged != "" {
+				t.Errorf("Expected nothing left staged, got %q", staged)
+			}
+		})
+	}
+}
+
+// writeRepoFile writes content to the file at the slash-separated path in the repository,
+// creating its directory
+func writeRepoFile(t *testing.T, repoPath, path, content string) {
+	t.Helper()
+
+	full := filepath.Join(repoPath, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
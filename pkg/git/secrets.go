@@ -0,0 +1,403 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Secret scanning modes, selecting what happens to changes that look like they hold credentials
+const (
+	// SecretsSkip leaves files that look like they hold secrets out of checkpoints.
+	SecretsSkip = "skip"
+
+	// SecretsAbort holds back checkpoints altogether while any change looks like it holds secrets.
+	SecretsAbort = "abort"
+
+	// SecretsOff commits changes without scanning them.
+	SecretsOff = "off"
+)
+
+// SecretModes lists the accepted values of GitbakConfig.Secrets
+var SecretModes = []string{SecretsSkip, SecretsAbort, SecretsOff}
+
+// secretAllowMarker exempts the line it appears on from scanning, for known false positives
+// such as test fixtures
+const secretAllowMarker = "gitbak:allow"
+
+// maxSecretScanBytes bounds how much of an untracked file is scanned; larger files are
+// left to MaxFileSizeMB
+const maxSecretScanBytes = 1 << 20
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above which a value assigned
+// to a secret-sounding name is taken for a credential rather than a placeholder
+const minSecretEntropy = 3.5
+
+// secretRule recognizes one kind of secret on a line of a changed file
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+
+	// random is set when the pattern's first group must look random to count as a secret
+	random bool
+}
+
+// secretValue is what a value must be made of for an assignment to count: quotes, spaces and
+// the brackets of function calls or template references like ${TOKEN} rule it out
+const secretValue = `([A-Za-z0-9+/=_.~@:-]{16,})`
+
+// secretName matches the names secrets are usually assigned to
+const secretName = `[\w.-]*(?i:secret|token|passw(?:or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credential)[\w.-]*`
+
+var secretRules = []secretRule{
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{name: "AWS access key ID", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "GitHub token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "GitLab token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "Slack token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "Stripe key", pattern: regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{20,}`)},
+	{name: "Google API key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{
+		name:    "secret assignment",
+		pattern: regexp.MustCompile(secretName + `["']?\s*(?::=|=>|[:=])\s*["']` + secretValue + `["']`),
+		random:  true,
+	},
+	{
+		// Unquoted values only count in the files of environment variables, YAML, INI and the like
+		name:    "secret assignment",
+		pattern: regexp.MustCompile(`^\s*(?:export\s+)?` + secretName + `\s*[:=]\s*` + secretValue + `\s*$`),
+		random:  true,
+	},
+}
+
+// secretFileExtensions are the extensions of files holding keys and certificates with their keys
+var secretFileExtensions = []string{".pem", ".key", ".p12", ".pfx", ".jks", ".keystore"}
+
+// secretFileNames are the names of files holding credentials
+var secretFileNames = []string{"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", ".netrc", ".pgpass"}
+
+// envTemplateSuffixes mark .env files that document variables rather than set them
+var envTemplateSuffixes = []string{".example", ".sample", ".template", ".dist", ".defaults"}
+
+// secretFinding describes why a changed file looks like it holds secrets
+type secretFinding struct {
+	path string
+	rule string
+
+	// line is the line the secret was found on, or 0 when the file's name gave it away
+	line int
+}
+
+// String describes the finding without repeating the secret itself
+func (f secretFinding) String() string {
+	if f.line == 0 {
+		return f.rule
+	}
+	return fmt.Sprintf("%s on line %d", f.rule, f.line)
+}
+
+// scanSecrets reports whether changes are scanned for secrets
+func (c *GitbakConfig) scanSecrets() bool {
+	return c.Secrets == SecretsSkip || c.Secrets == SecretsAbort
+}
+
+// secretPaths lists the changed files that look like they hold secrets and so are left
+// out of checkpoints. Paths already covered by excluded are not considered. It returns
+// nothing unless Secrets is SecretsSkip.
+func (g *Gitbak) secretPaths(ctx context.Context, excluded []string) ([]string, error) {
+	if g.config.Secrets != SecretsSkip {
+		return nil, nil
+	}
+
+	findings, err := g.scanChanges(ctx, excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, finding := range findings {
+		if g.skipSecret(finding) {
+			paths = append(paths, finding.path)
+		}
+	}
+	return paths, nil
+}
+
+// skipSecret decides whether the file of finding is left out of checkpoints. Interactive
+// sessions ask the user; others always leave it out. Either way the decision is made once
+// per path, so neither the question nor the warning repeats.
+func (g *Gitbak) skipSecret(finding secretFinding) bool {
+	if skip, decided := g.secrets[finding.path]; decided {
+		return skip
+	}
+
+	skip := true
+	if !g.config.NonInteractive {
+		skip = !g.promptYesNo(fmt.Sprintf("%s looks like it holds a secret (%s). Include it in checkpoints anyway?",
+			finding.path, finding))
+	}
+	if skip {
+		g.logger.WarningToUser("⚠️  Leaving %s out of checkpoints: it looks like it holds a secret (%s). "+
+			"Add it to .gitignore or %s, or mark the line with %q if it is harmless, to silence this.",
+			finding.path, finding, BakignoreFile, secretAllowMarker)
+	}
+
+	if g.secrets == nil {
+		g.secrets = make(map[string]bool)
+	}
+	g.secrets[finding.path] = skip
+	return skip
+}
+
+// secretsHoldCheckpoint reports whether the next checkpoint is held back under SecretsAbort
+// because some change looks like it holds a secret. Each such file is warned about once;
+// checkpoints resume by themselves once the secrets are gone.
+func (g *Gitbak) secretsHoldCheckpoint(ctx context.Context) (bool, error) {
+	if g.config.Secrets != SecretsAbort {
+		return false, nil
+	}
+
+	excluded, err := g.excludedPaths(ctx)
+	if err != nil {
+		return false, err
+	}
+	findings, err := g.scanChanges(ctx, excluded)
+	if err != nil {
+		return false, err
+	}
+	if len(findings) == 0 {
+		return false, nil
+	}
+
+	for _, finding := range findings {
+		if g.secrets[finding.path] {
+			continue
+		}
+		g.logger.Warning("Holding back checkpoints: found %s in %s", finding, finding.path)
+		g.logger.WarningToUser("🚫 Not checkpointing: %s looks like it holds a secret (%s). Remove it, add it to .gitignore or %s, "+
+			"or mark the line with %q if it is harmless; checkpoints resume once it is gone.",
+			finding.path, finding, BakignoreFile, secretAllowMarker)
+		if g.secrets == nil {
+			g.secrets = make(map[string]bool)
+		}
+		g.secrets[finding.path] = true
+	}
+	g.logger.Info("Checkpoint held back: %d changed files look like they hold secrets", len(findings))
+	return true, nil
+}
+
+// scanChanges looks for secrets in the changes a checkpoint would take in, less the paths
+// covered by excluded: the lines added to tracked files since HEAD, and the whole of untracked
+// files that are not ignored. Files are also judged by their names, as .env files and private
+// keys are secret whatever they contain. Each file is reported once, at its first finding.
+func (g *Gitbak) scanChanges(ctx context.Context, excluded []string) ([]secretFinding, error) {
+	unborn, err := g.isUnbornHead(ctx)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("rev-parse", []string{"HEAD"}, gitbakErrors.Wrap(err, "failed to resolve HEAD"), "")
+	}
+
+	seen := make(map[string]bool)
+	var findings []secretFinding
+	record := func(finding *secretFinding) {
+		if finding != nil && !seen[finding.path] {
+			seen[finding.path] = true
+			findings = append(findings, *finding)
+		}
+	}
+
+	// Without commits every file is new, so tracked files are scanned whole like untracked ones
+	listArgs := []string{"ls-files", "-z", "--others", "--exclude-standard"}
+	if unborn {
+		listArgs = []string{"ls-files", "-z", "--cached", "--others", "--exclude-standard"}
+	} else {
+		diffArgs := []string{"-c", "core.quotePath=false", "diff", "HEAD", "-U0", "--no-color", "--no-ext-diff",
+			"--no-textconv", "--diff-filter=d", "--src-prefix=a/", "--dst-prefix=b/"}
+		out, err := g.runGitCommandWithOutput(ctx, append(diffArgs, g.scopeArgs()...)...)
+		if err != nil {
+			return nil, gitbakErrors.NewGitError("diff", diffArgs[2:], gitbakErrors.Wrap(err, "failed to list changes"), "")
+		}
+		for _, finding := range scanDiff(out) {
+			if !isExcluded(finding.path, excluded) {
+				record(&finding)
+			}
+		}
+	}
+
+	out, err := g.runGitCommandWithOutput(ctx, append(listArgs, g.scopeArgs()...)...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", listArgs[1:], gitbakErrors.Wrap(err, "failed to list changed files"), "")
+	}
+	for _, path := range strings.Split(out, "\x00") {
+		if path == "" || seen[path] || isExcluded(path, excluded) {
+			continue
+		}
+		record(g.scanFile(path))
+	}
+
+	slices.SortFunc(findings, func(a, b secretFinding) int { return strings.Compare(a.path, b.path) })
+	return findings, nil
+}
+
+// scanFile looks for secrets in the whole of the file at path, relative to the repository
+func (g *Gitbak) scanFile(path string) *secretFinding {
+	if rule := secretFileRule(path); rule != "" {
+		return &secretFinding{path: path, rule: rule}
+	}
+
+	// Deleted files, symlinks and submodules have no content of their own to scan
+	fullPath := filepath.Join(g.config.RepoPath, path)
+	info, err := os.Lstat(fullPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxSecretScanBytes {
+		return nil
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		// Unreadable or binary
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSecretScanBytes)
+	for n := 1; scanner.Scan(); n++ {
+		if rule := secretLineRule(scanner.Text()); rule != "" {
+			return &secretFinding{path: path, rule: rule, line: n}
+		}
+	}
+	return nil
+}
+
+// scanDiff looks for secrets in the files and added lines of a unified diff made with -U0
+func scanDiff(diff string) []secretFinding {
+	var findings []secretFinding
+	var path string
+	found := false
+	inHunk := false
+	line := 0
+
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			path, found, inHunk = "", false, false
+		case !inHunk && strings.HasPrefix(text, "+++ "):
+			path = diffPath(strings.TrimPrefix(text, "+++ "))
+			if rule := secretFileRule(path); path != "" && rule != "" {
+				findings = append(findings, secretFinding{path: path, rule: rule})
+				found = true
+			}
+		case strings.HasPrefix(text, "@@ "):
+			inHunk = true
+			line = hunkStart(text)
+		case inHunk && strings.HasPrefix(text, "+"):
+			if path != "" && !found {
+				if rule := secretLineRule(text[1:]); rule != "" {
+					findings = append(findings, secretFinding{path: path, rule: rule, line: line})
+					found = true
+				}
+			}
+			line++
+		case inHunk && strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// diffPath returns the path of a file named on a ---/+++ line of a diff made with
+// --dst-prefix=b/, or an empty string for /dev/null
+func diffPath(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		// Names with unusual characters are quoted C-style, which strconv mostly shares
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	path, ok := strings.CutPrefix(name, "b/")
+	if !ok {
+		return ""
+	}
+	return path
+}
+
+// hunkStart returns the first line in the new file of the hunk with header "@@ -a,b +c,d @@"
+func hunkStart(header string) int {
+	_, rest, _ := strings.Cut(header, " +")
+	start, _, _ := strings.Cut(rest, " ")
+	start, _, _ = strings.Cut(start, ",")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// secretFileRule returns what makes the file at path secret by its name alone, if anything
+func secretFileRule(path string) string {
+	name := filepath.Base(path)
+	switch {
+	case name == ".env" || (strings.HasPrefix(name, ".env.") && !hasAnySuffix(name, envTemplateSuffixes)):
+		return "environment file"
+	case slices.Contains(secretFileNames, name):
+		return "credentials file"
+	case slices.Contains(secretFileExtensions, strings.ToLower(filepath.Ext(name))):
+		return "key file"
+	}
+	return ""
+}
+
+// secretLineRule returns the name of the rule a line of text breaks, if any
+func secretLineRule(text string) string {
+	if strings.Contains(text, secretAllowMarker) {
+		return ""
+	}
+	for _, rule := range secretRules {
+		match := rule.pattern.FindStringSubmatch(text)
+		if match == nil || (rule.random && !looksRandom(match[1])) {
+			continue
+		}
+		return rule.name
+	}
+	return ""
+}
+
+// looksRandom reports whether value mixes letters and digits with enough entropy to be a
+// generated credential, rather than a placeholder like "your-api-key-goes-here"
+func looksRandom(value string) bool {
+	hasLetter := strings.ContainsFunc(value, unicode.IsLetter)
+	hasDigit := strings.ContainsFunc(value, unicode.IsDigit)
+	return hasLetter && hasDigit && shannonEntropy(value) >= minSecretEntropy
+}
+
+// shannonEntropy returns the Shannon entropy of s, in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// hasAnySuffix reports whether s ends with any of suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// ulidAlphabet is Crockford's base32, in which ULIDs are written
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// checkpointNumber matches the number in a checkpoint subject, "<prefix> #<n> - <time>",
+// whatever the prefix
+var checkpointNumber = regexp.MustCompile(` #([0-9]+) - `)
+
+// newSessionID returns an identifier for a new session: a ULID, which sorts by the time
+// the session started and is unique without any coordination between sessions
+func newSessionID() string {
+	return newULID(time.Now(), rand.Reader)
+}
+
+// newULID returns the ULID for t with randomness read from entropy: the milliseconds since
+// the Unix epoch in 48 bits followed by 80 random bits, as 26 base32 characters
+func newULID(t time.Time, entropy io.Reader) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(t.UnixMilli())<<16)
+	// Reading crypto/rand never fails on supported platforms
+	_, _ = io.ReadFull(entropy, b[6:])
+
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var id [26]byte
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = ulidAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// sessionTrailer returns the trailer line that marks a checkpoint as one made by session id
+func sessionTrailer(id string) string {
+	return session.SessionTrailer + ": " + id
+}
+
+// subjectCheckpointNumber returns the number in a checkpoint subject, or 0 if it has none
+func subjectCheckpointNumber(subject string) int {
+	matches := checkpointNumber.FindAllStringSubmatch(subject, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(matches[len(matches)-1][1])
+	return n
+}
+
+// latestSessionCheckpoint returns the session of the most recent checkpoint on HEAD that
+// carries a session trailer, among the latest commitNumberScanLimit commits, and the
+// highest checkpoint number among that session's checkpoints
+func (g *Gitbak) latestSessionCheckpoint(ctx context.Context) (id string, counter int, found bool, err error) {
+	if g.config.Backend == BackendGoGit {
+		return "", 0, false, nil
+	}
+
+	output, err := g.runGitCommandWithOutput(ctx, "log", "-n", strconv.Itoa(commitNumberScanLimit),
+		"--format=%x1e%s%x00%(trailers:key="+session.SessionTrailer+",valueonly)")
+	if err != nil {
+		return "", 0, false, err
+	}
+	for _, record := range strings.Split(output, "\x1e") {
+		subject, trailers, ok := strings.Cut(record, "\x00")
+		if !ok {
+			continue
+		}
+		for _, trailer := range strings.Fields(trailers) {
+			if id == "" {
+				// Newest first, so the first session found is the latest
+				id = trailer
+			}
+			if trailer == id {
+				counter = max(counter, subjectCheckpointNumber(subject))
+			}
+		}
+	}
+	return id, counter, id != "" && counter > 0, nil
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestNewULID tests that session IDs are ULIDs, ordered by the time they were made
+func TestNewULID(t *testing.T) {
+	t.Parallel()
+
+	// The timestamp of the example in the ULID specification
+	at := time.UnixMilli(1469918176385)
+	if id := newULID(at, bytes.NewReader(make([]byte, 10))); id != "01ARYZ6S410000000000000000" {
+		t.Errorf("Expected the ULID of the specification's timestamp, got %s", id)
+	}
+	if id := newULID(at, bytes.NewReader(bytes.Repeat([]byte{0xff}, 10))); id != "01ARYZ6S41ZZZZZZZZZZZZZZZZ" {
+		t.Errorf("Expected all the random bits to be set, got %s", id)
+	}
+
+	id := newSessionID()
+	if len(id) != 26 || strings.Trim(id, ulidAlphabet) != "" {
+		t.Errorf("Expected a ULID, got %q", id)
+	}
+	if later := newULID(time.Now().Add(time.Second), bytes.NewReader(make([]byte, 10))); later <= id {
+		t.Errorf("Expected %s, made later, to sort after %s", later, id)
+	}
+}
+
+// TestSessionTrailer tests that checkpoints are recognized by their session trailer once the
+// prefix changed: when listing and counting them, and when continuing without notes
+func TestSessionTrailer(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+	originalBranch := gitOutput(t, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	ctx := context.Background()
+
+	first := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-trailer",
+		CommitPrefix:   "[gitbak-trailer] Commit",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      stateFile,
+		MaxRetries:     3,
+	}, logger.New(false, "", false))
+	if err := first.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		writeRepoFile(t, repoPath, fmt.Sprintf("trailer%d.txt", i), "content")
+		if _, err := first.createCommit(ctx, i); err != nil {
+			t.Fatalf("createCommit failed: %v", err)
+		}
+	}
+	if trailer := gitOutput(t, repoPath, "log", "-1", "--format=%(trailers:key="+session.SessionTrailer+",valueonly)"); trailer != first.sessionID {
+		t.Fatalf("Expected the checkpoint to carry session %s, got %q", first.sessionID, trailer)
+	}
+
+	// Without notes and with a new prefix, only the trailers tell the checkpoints apart
+	gitOutput(t, repoPath, "update-ref", "-d", NotesRef)
+	second := setupTestGitbak(GitbakConfig{
+		RepoPath:        repoPath,
+		Interval:        time.Minute,
+		BranchName:      "gitbak-trailer",
+		CommitPrefix:    "[wip] Checkpoint",
+		ContinueSession: true,
+		NonInteractive:  true,
+		MaxRetries:      3,
+	}, logger.New(false, "", false))
+	if err := second.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if second.commitsCount != 2 || second.sessionID != first.sessionID {
+		t.Fatalf("Expected to continue session %s from checkpoint 2, got session %s at %d", first.sessionID, second.sessionID, second.commitsCount)
+	}
+	writeRepoFile(t, repoPath, "trailer3.txt", "content")
+	if _, err := second.createCommit(ctx, 3); err != nil {
+		t.Fatalf("createCommit failed: %v", err)
+	}
+	gitOutput(t, repoPath, "commit", "--allow-empty", "-m", "Manual commit")
+
+	repo := NewRepository(repoPath, nil)
+	state := &session.State{
+		Branch:         "gitbak-trailer",
+		OriginalBranch: originalBranch,
+		StartCommit:    startCommit,
+		CommitPrefix:   "[wip] Checkpoint",
+		SessionID:      first.sessionID,
+	}
+	checkpoints, err := repo.ListCheckpoints(ctx, state)
+	if err != nil {
+		t.Fatalf("ListCheckpoints failed: %v", err)
+	}
+	if len(checkpoints) != 3 || checkpoints[0].Number != 1 || checkpoints[2].Number != 3 {
+		t.Errorf("Expected checkpoints #1 to #3 under both prefixes, got %+v", checkpoints)
+	}
+
+	summary, err := repo.SummarizeSession(ctx, state)
+	if err != nil {
+		t.Fatalf("SummarizeSession failed: %v", err)
+	}
+	if summary.Commits != 4 || summary.Checkpoints != 3 {
+		t.Errorf("Expected 4 commits of which 3 are checkpoints, got %d and %d", summary.Commits, summary.Checkpoints)
+	}
+
+	// Sessions from before the trailer fall back to their prefix
+	state.SessionID = "0123456789abcdef"
+	if checkpoints, err := repo.ListCheckpoints(ctx, state); err != nil || len(checkpoints) != 1 {
+		t.Errorf("Expected the checkpoint with the prefix, got %+v (%v)", checkpoints, err)
+	}
+}
@@ -0,0 +1,319 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// SquashSummary describes a session branch as it is about to be squashed.
+// It is rendered into the squash commit message so the context of the session
+// survives after the checkpoint commits are gone.
+type SquashSummary struct {
+	Branch      string
+	ForkPoint   string
+	StartTime   time.Time
+	EndTime     time.Time
+	Commits     int
+	Checkpoints int
+	CoAuthors   []string
+
+	// Notes are the notes attached to the commits being squashed, oldest first
+	Notes []SquashNote
+
+	// Subject replaces the generated first line of the message, if set
+	Subject string
+}
+
+// SquashNote is a note carried over from one of the commits being squashed: either where the
+// checkpoints move on to another session or prefix, going by their checkpoint notes, or an
+// annotation, a note added by hand under NotesRef or the default notes ref
+type SquashNote struct {
+	Commit  string
+	Subject string
+	Text    string
+}
+
+// SummarizeSession collects the metadata of a session branch relative to its original branch.
+func (r *Repository) SummarizeSession(ctx context.Context, state *session.State) (*SquashSummary, error) {
+	forkPoint, err := r.output(ctx, "merge-base", state.OriginalBranch, state.Branch)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("merge-base", []string{state.OriginalBranch, state.Branch},
+			gitbakErrors.Wrap(err, "failed to find fork point of session branch"), "")
+	}
+
+	revRange := forkPoint + ".." + state.Branch
+
+	commits, err := r.countCommits(ctx, revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints int
+	if state.SessionID != "" {
+		checkpoints, err = r.countCommits(ctx, "--fixed-strings", "--grep="+sessionTrailer(state.SessionID), revRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if checkpoints == 0 && state.CommitPrefix != "" {
+		// Checkpoints made before they carried the session trailer only have their prefix
+		checkpoints, err = r.countCommits(ctx, "--fixed-strings", "--grep="+state.CommitPrefix, revRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	coAuthors, err := r.coAuthors(ctx, revRange, state.CommitEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := r.squashNotes(ctx, revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	endTime := state.LastCommitTime
+	if endTime.IsZero() {
+		endTime = state.UpdatedAt
+	}
+
+	return &SquashSummary{
+		Branch:      state.Branch,
+		ForkPoint:   forkPoint,
+		StartTime:   state.StartTime,
+		EndTime:     endTime,
+		Commits:     commits,
+		Checkpoints: checkpoints,
+		CoAuthors:   coAuthors,
+		Notes:       notes,
+	}, nil
+}
+
+// squashNotes returns the notes of the commits in the range that are worth keeping in the
+// squash commit: a checkpoint note only where the session or prefix differs from the
+// previous checkpoint's, and any other note in full
+func (r *Repository) squashNotes(ctx context.Context, revRange string) ([]SquashNote, error) {
+	// Each commit is listed by both, in the same order, whether or not it has a note
+	checkpoints, err := r.commitNotes(ctx, revRange, "--notes="+NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := r.commitNotes(ctx, revRange, "--notes")
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []SquashNote
+	var prev *checkpointNote
+	for i, commit := range checkpoints {
+		if commit.Text != "" {
+			note, ok := parseCheckpointNote(commit.Text)
+			switch {
+			case !ok:
+				notes = append(notes, commit)
+			case prev != nil && note.Session != prev.Session:
+				notes = append(notes, SquashNote{Commit: commit.Commit, Subject: commit.Subject,
+					Text: fmt.Sprintf("Checkpoint #%d starts session %s", note.Counter, note.Session)})
+			case prev != nil && note.Prefix != prev.Prefix:
+				notes = append(notes, SquashNote{Commit: commit.Commit, Subject: commit.Subject,
+					Text: fmt.Sprintf("Checkpoint #%d changes the prefix to %q", note.Counter, note.Prefix)})
+			}
+			if ok {
+				prev = &note
+			}
+		}
+		if i < len(annotations) && annotations[i].Text != "" {
+			notes = append(notes, annotations[i])
+		}
+	}
+	return notes, nil
+}
+
+// commitNotes lists the commits in the range, oldest first, with their notes from the notes
+// ref selected by the --notes argument notesArg
+func (r *Repository) commitNotes(ctx context.Context, revRange, notesArg string) ([]SquashNote, error) {
+	out, err := r.output(ctx, "log", "--reverse", "--no-notes", notesArg, "--format=%x1e%h%x1f%s%x1f%N", revRange)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("log", []string{notesArg, revRange}, gitbakErrors.Wrap(err, "failed to read session notes"), "")
+	}
+
+	var commits []SquashNote
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, SquashNote{Commit: fields[0], Subject: fields[1], Text: strings.TrimSpace(fields[2])})
+	}
+	return commits, nil
+}
+
+// countCommits returns the number of commits selected by the rev-list arguments
+func (r *Repository) countCommits(ctx context.Context, args ...string) (int, error) {
+	out, err := r.output(ctx, append([]string{"rev-list", "--count"}, args...)...)
+	if err != nil {
+		return 0, gitbakErrors.NewGitError("rev-list", args, gitbakErrors.Wrap(err, "failed to count session commits"), "")
+	}
+
+	count, err := strconv.Atoi(out)
+	if err != nil {
+		return 0, gitbakErrors.Wrapf(err, "unexpected rev-list output %q", out)
+	}
+	return count, nil
+}
+
+// coAuthors returns everyone other than the current user and the checkpoint identity, if
+// any, who authored or co-authored a commit in the range, in order of first appearance,
+// formatted as "Name <email>".
+func (r *Repository) coAuthors(ctx context.Context, revRange, checkpointEmail string) ([]string, error) {
+	out, err := r.output(ctx, "log", "--reverse", "--format=%an <%ae>%n%(trailers:key=Co-authored-by,valueonly)", revRange)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("log", []string{revRange}, gitbakErrors.Wrap(err, "failed to read session authors"), "")
+	}
+
+	// An unset user.email only means nobody is excluded
+	self, _ := r.output(ctx, "config", "user.email")
+
+	var authors []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		if self != "" && strings.Contains(line, "<"+self+">") {
+			continue
+		}
+		if checkpointEmail != "" && strings.Contains(line, "<"+checkpointEmail+">") {
+			continue
+		}
+		authors = append(authors, line)
+	}
+	return authors, nil
+}
+
+// Message renders the squash commit message for the session.
+func (s *SquashSummary) Message() string {
+	var b strings.Builder
+
+	if s.Subject != "" {
+		_, _ = fmt.Fprintf(&b, "%s\n\n", s.Subject)
+	} else {
+		_, _ = fmt.Fprintf(&b, "Squash gitbak session %s\n\n", s.Branch)
+	}
+
+	if !s.StartTime.IsZero() {
+		_, _ = fmt.Fprintf(&b, "Started:     %s\n", s.StartTime.Format("2006-01-02 15:04:05"))
+		if !s.EndTime.IsZero() && s.EndTime.After(s.StartTime) {
+			duration := s.EndTime.Sub(s.StartTime)
+			hours := int(duration.Hours())
+			minutes := int(duration.Minutes()) % 60
+			seconds := int(duration.Seconds()) % 60
+			_, _ = fmt.Fprintf(&b, "Duration:    %dh %dm %ds\n", hours, minutes, seconds)
+		}
+	}
+	_, _ = fmt.Fprintf(&b, "Checkpoints: %d of %d commits\n", s.Checkpoints, s.Commits)
+	_, _ = fmt.Fprintf(&b, "Fork point:  %s\n", s.ForkPoint)
+
+	if len(s.Notes) > 0 {
+		_, _ = fmt.Fprintf(&b, "\nNotes:\n")
+		for _, note := range s.Notes {
+			_, _ = fmt.Fprintf(&b, "- %s %s\n", note.Commit, note.Subject)
+			for _, line := range strings.Split(note.Text, "\n") {
+				// Indented, so that a line starting with # isn't taken for a comment by the editor
+				_, _ = fmt.Fprintf(&b, "%s\n", strings.TrimRight("  "+line, " "))
+			}
+		}
+	}
+
+	if len(s.CoAuthors) > 0 {
+		_, _ = fmt.Fprintf(&b, "\n")
+		for _, author := range s.CoAuthors {
+			_, _ = fmt.Fprintf(&b, "Co-authored-by: %s\n", author)
+		}
+	}
+
+	return b.String()
+}
+
+// SquashSession folds a session branch into a single commit on the original branch.
+// The commit message is pre-filled with message; if edit is true, the user's editor is
+// opened so they can replace the subject line and adjust the template.
+// The session branch itself is left in place.
+func (r *Repository) SquashSession(ctx context.Context, state *session.State, message string, edit bool) error {
+	if state.Stash {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' stored its snapshots in the stash, so there is nothing to squash; use git stash apply to restore one", state.Branch)
+	}
+	if state.Refs != "" {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' wrote its checkpoints to %s rather than a branch; turn them into one with gitbak materialize", state.Branch, state.Refs)
+	}
+	if !state.CreatedBranch {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' did not create its own branch, so there is nothing to squash it onto; use git rebase -i instead", state.Branch)
+	}
+
+	status, err := r.output(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return gitbakErrors.NewGitError("status", []string{"--porcelain"}, gitbakErrors.Wrap(err, "failed to check working tree"), "")
+	}
+	if status != "" {
+		return gitbakErrors.Wrap(gitbakErrors.ErrGitOperationFailed, "working tree has uncommitted changes; commit or stash them before squashing")
+	}
+
+	if err := r.run(ctx, "checkout", state.OriginalBranch); err != nil {
+		return gitbakErrors.NewGitError("checkout", []string{state.OriginalBranch},
+			gitbakErrors.Wrap(err, "failed to check out original branch"), "")
+	}
+
+	if err := r.run(ctx, "merge", "--squash", state.Branch); err != nil {
+		return gitbakErrors.NewGitError("merge", []string{"--squash", state.Branch},
+			gitbakErrors.Wrap(err, "failed to squash session branch"), "")
+	}
+
+	// A clean index after the squash means the session made no net changes
+	if err := r.run(ctx, "diff", "--cached", "--quiet"); err == nil {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrGitOperationFailed, "session branch '%s' has no changes to squash", state.Branch)
+	}
+
+	msgFile, err := os.CreateTemp("", "gitbak-squash-*.txt")
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to create commit message file")
+	}
+	defer func() {
+		_ = os.Remove(msgFile.Name())
+	}()
+
+	if edit {
+		message += "\n# Replace the first line with a summary of the session's changes.\n"
+	}
+	if _, err := msgFile.WriteString(message); err != nil {
+		_ = msgFile.Close()
+		return gitbakErrors.Wrap(err, "failed to write commit message file")
+	}
+	if err := msgFile.Close(); err != nil {
+		return gitbakErrors.Wrap(err, "failed to write commit message file")
+	}
+
+	if edit {
+		err = r.runInteractive(ctx, "commit", "--edit", "--file", msgFile.Name())
+	} else {
+		err = r.run(ctx, "commit", "--file", msgFile.Name())
+	}
+	if err != nil {
+		return gitbakErrors.NewGitError("commit", []string{"--file", msgFile.Name()},
+			gitbakErrors.Wrap(err, "failed to create squash commit; the squashed changes are still staged"), "")
+	}
+
+	return nil
+}
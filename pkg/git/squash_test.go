@@ -0,0 +1,198 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestSquashSummaryMessage tests rendering of the squash commit message template
+func TestSquashSummaryMessage(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		summary     SquashSummary
+		contains    []string
+		notContains []string
+	}{
+		"FullSession": {
+			summary: SquashSummary{
+				Branch:      "gitbak-1",
+				ForkPoint:   "abc123",
+				StartTime:   start,
+				EndTime:     start.Add(90*time.Minute + 5*time.Second),
+				Commits:     5,
+				Checkpoints: 4,
+				CoAuthors:   []string{"Pat Pair <pat@example.com>"},
+			},
+			contains: []string{
+				"Squash gitbak session gitbak-1\n\n",
+				"Started:     2024-05-01 09:00:00",
+				"Duration:    1h 30m 5s",
+				"Checkpoints: 4 of 5 commits",
+				"Fork point:  abc123",
+				"\nCo-authored-by: Pat Pair <pat@example.com>\n",
+			},
+		},
+		"NoTimesOrCoAuthors": {
+			summary: SquashSummary{
+				Branch:    "gitbak-2",
+				ForkPoint: "def456",
+				Commits:   1,
+			},
+			contains:    []string{"Checkpoints: 0 of 1 commits", "Fork point:  def456"},
+			notContains: []string{"Started:", "Duration:", "Co-authored-by:", "Notes:"},
+		},
+		"Notes": {
+			summary: SquashSummary{
+				Branch:    "gitbak-4",
+				ForkPoint: "abc123",
+				Commits:   3,
+				Notes: []SquashNote{
+					{Commit: "1111111", Subject: "[gitbak] Checkpoint #3", Text: "Checkpoint #3 starts session s2"},
+					{Commit: "2222222", Subject: "Manual milestone", Text: "Reviewed with Pat\n\n# still to do: docs"},
+				},
+			},
+			contains: []string{
+				"Fork point:  abc123\n\nNotes:\n",
+				"- 1111111 [gitbak] Checkpoint #3\n  Checkpoint #3 starts session s2\n",
+				"- 2222222 Manual milestone\n  Reviewed with Pat\n\n  # still to do: docs\n",
+			},
+		},
+		"CustomSubject": {
+			summary: SquashSummary{
+				Branch:    "gitbak-3",
+				ForkPoint: "fed789",
+				Commits:   2,
+				Subject:   "Add CSV export",
+			},
+			contains:    []string{"Add CSV export\n\n", "Checkpoints: 0 of 2 commits"},
+			notContains: []string{"Squash gitbak session"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message := test.summary.Message()
+			for _, want := range test.contains {
+				if !strings.Contains(message, want) {
+					t.Errorf("Expected message to contain %q, got:\n%s", want, message)
+				}
+			}
+			for _, unwanted := range test.notContains {
+				if strings.Contains(message, unwanted) {
+					t.Errorf("Expected message not to contain %q, got:\n%s", unwanted, message)
+				}
+			}
+		})
+	}
+}
+
+// TestSquashSession tests squashing a session branch with checkpoints and a co-authored manual commit
+func TestSquashSession(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	ctx := context.Background()
+
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	originalBranch := gitCmd("branch", "--show-current")
+	forkPoint := gitCmd("rev-parse", "HEAD")
+	gitCmd("checkout", "-b", "gitbak-squash-test")
+
+	for i, content := range []string{"one", "two"} {
+		if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		gitCmd("add", ".")
+		// Checkpoints attributed to a separate identity with -author are not co-authors
+		gitCmd("-c", "user.name=gitbak bot", "-c", "user.email=bot@example.com",
+			"commit", "-m", "[gitbak] Automatic checkpoint #"+string(rune('1'+i)))
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "manual.txt"), []byte("manual"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	gitCmd("add", ".")
+	gitCmd("commit", "-m", "Manual milestone\n\nCo-authored-by: Pat Pair <pat@example.com>")
+
+	// The second checkpoint moved on to another session, and the milestone was annotated
+	for i, session := range []string{"s1", "s2"} {
+		note := checkpointNote{Session: session, Counter: i + 1, Prefix: "[gitbak]"}
+		gitCmd("notes", "--ref="+NotesRef, "add", "-m", note.String(), "HEAD~"+string(rune('2'-i)))
+	}
+	gitCmd("notes", "add", "-m", "Reviewed with Pat", "HEAD")
+
+	state := &session.State{
+		RepoPath:       repoPath,
+		Branch:         "gitbak-squash-test",
+		OriginalBranch: originalBranch,
+		CreatedBranch:  true,
+		CommitPrefix:   "[gitbak]",
+		CommitEmail:    "bot@example.com",
+		StartTime:      time.Now().Add(-time.Hour),
+		LastCommitTime: time.Now(),
+	}
+
+	repo := NewRepository(repoPath, nil)
+	summary, err := repo.SummarizeSession(ctx, state)
+	if err != nil {
+		t.Fatalf("SummarizeSession failed: %v", err)
+	}
+
+	if summary.ForkPoint != forkPoint {
+		t.Errorf("Expected fork point %s, got %s", forkPoint, summary.ForkPoint)
+	}
+	if summary.Commits != 3 || summary.Checkpoints != 2 {
+		t.Errorf("Expected 2 checkpoints of 3 commits, got %d of %d", summary.Checkpoints, summary.Commits)
+	}
+	if len(summary.CoAuthors) != 1 || summary.CoAuthors[0] != "Pat Pair <pat@example.com>" {
+		t.Errorf("Expected the co-author trailer to be collected without the current user or checkpoint identity, got %v", summary.CoAuthors)
+	}
+	if len(summary.Notes) != 2 ||
+		summary.Notes[0].Subject != "[gitbak] Automatic checkpoint #2" || summary.Notes[0].Text != "Checkpoint #2 starts session s2" ||
+		summary.Notes[1].Subject != "Manual milestone" || summary.Notes[1].Text != "Reviewed with Pat" {
+		t.Errorf("Expected the session change and the annotation to be carried over, got %+v", summary.Notes)
+	}
+
+	if err := repo.SquashSession(ctx, state, summary.Message(), false); err != nil {
+		t.Fatalf("SquashSession failed: %v", err)
+	}
+
+	if current := gitCmd("branch", "--show-current"); current != originalBranch {
+		t.Errorf("Expected to be on %s after squashing, got %s", originalBranch, current)
+	}
+	if count := gitCmd("rev-list", "--count", forkPoint+"..HEAD"); count != "1" {
+		t.Errorf("Expected a single squash commit on the original branch, got %s", count)
+	}
+
+	body := gitCmd("log", "-1", "--format=%B")
+	for _, want := range []string{"Squash gitbak session gitbak-squash-test", "Checkpoints: 2 of 3 commits",
+		"Checkpoint #2 starts session s2", "  Reviewed with Pat", "Co-authored-by: Pat Pair <pat@example.com>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected squash commit message to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	if gitCmd("show", "HEAD:work.txt") != "two" {
+		t.Error("Expected the squash commit to contain the final session content")
+	}
+
+	// Squashing again has nothing left to fold in
+	gitCmd("checkout", "gitbak-squash-test")
+	if err := repo.SquashSession(ctx, state, summary.Message(), false); err == nil {
+		t.Error("Expected an error when the session has no changes left to squash")
+	}
+}
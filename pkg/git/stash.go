@@ -0,0 +1,201 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Checkpoint modes, selecting where checkpoints are recorded
+const (
+	// ModeBranch commits checkpoints on the session branch (or the current branch with -no-branch).
+	ModeBranch = "branch"
+
+	// ModeStash records checkpoints as stash entries on top of HEAD, leaving every branch,
+	// the index and the working tree untouched.
+	ModeStash = "stash"
+
+	// ModeRefs writes each checkpoint to a ref of its own under RefsPrefix, chained to the
+	// previous one, so that no branch is created or moved and the index and working tree
+	// are left untouched, yet the session can be materialized into a branch at any time.
+	ModeRefs = "refs"
+
+	// ModeObserve records no checkpoints at all: the changes each check finds are appended
+	// to GitbakConfig.JournalFile instead, leaving the repository untouched.
+	ModeObserve = "observe"
+)
+
+// Modes lists the accepted values of GitbakConfig.Mode
+var Modes = []string{ModeBranch, ModeStash, ModeRefs, ModeObserve}
+
+// stashMode reports whether checkpoints are recorded as stash entries
+func (g *Gitbak) stashMode() bool {
+	return g.config.Mode == ModeStash
+}
+
+// setupStashSession configures gitbak to snapshot into the stash, without touching any branch
+func (g *Gitbak) setupStashSession() {
+	g.config.CreateBranch = false
+	g.logger.StatusMessage("📦 Stash mode: snapshots are stored as stash entries, '%s' is left untouched", g.originalBranch)
+}
+
+// checkAndSnapshotChanges records the working tree as a stash entry if it differs from
+// both HEAD and the previous snapshot. Since snapshots leave the working tree dirty,
+// git status cannot tell whether anything changed in the meantime; the trees are compared instead.
+func (g *Gitbak) checkAndSnapshotChanges(ctx context.Context, commitCounter int, commitWasCreated *bool) error {
+	*commitWasCreated = false
+
+	indexTree, tree, err := g.snapshotTrees(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to snapshot working tree: %v", err)
+		return err
+	}
+
+	headTree, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD^{tree}")
+	if err != nil {
+		return gitbakErrors.NewGitError("rev-parse", []string{"HEAD^{tree}"}, gitbakErrors.Wrap(err, "failed to resolve HEAD"), "")
+	}
+
+	if tree == strings.TrimSpace(headTree) || tree == g.lastSnapshotTree {
+		if g.config.ShowNoChanges && g.config.Verbose && !g.idling() {
+			g.logger.InfoToUser("No changes to snapshot at %s", time.Now().Format("15:04:05"))
+			g.logger.Info("No changes to snapshot detected")
+		}
+		return nil
+	}
+
+	if held, err := g.secretsHoldCheckpoint(ctx); err != nil || held {
+		return err
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	message := fmt.Sprintf("%s #%d - %s", g.config.CommitPrefix, commitCounter, timestamp)
+
+	// Lay the commits out like git stash does, so that git stash show and apply work as usual:
+	// the snapshot's first parent is HEAD and its second records the index.
+	indexArgs := []string{"commit-tree", indexTree, "-p", "HEAD", "-m", "index on " + g.originalBranch}
+	indexCommit, err := g.runAsCheckpointIdentity(ctx, g.config.RepoPath, indexArgs...)
+	if err != nil {
+		return gitbakErrors.NewGitError("commit-tree", indexArgs[1:], gitbakErrors.Wrap(err, "failed to record index"), "")
+	}
+
+	snapshotArgs := append([]string{"commit-tree", tree, "-p", "HEAD", "-p", strings.TrimSpace(indexCommit)}, g.messageArgs(message)...)
+	snapshot, err := g.runAsCheckpointIdentity(ctx, g.config.RepoPath, snapshotArgs...)
+	if err != nil {
+		return gitbakErrors.NewGitError("commit-tree", snapshotArgs[1:], gitbakErrors.Wrap(err, "failed to create snapshot"), "")
+	}
+
+	storeArgs := []string{"stash", "store", "-m", message, strings.TrimSpace(snapshot)}
+	if err := g.runGitCommand(ctx, storeArgs...); err != nil {
+		g.logger.WarningToUser("Failed to store snapshot: %v", err)
+		return gitbakErrors.NewGitError("stash", storeArgs[1:], gitbakErrors.Wrap(err, "failed to store snapshot"), "")
+	}
+
+	*commitWasCreated = true
+	g.lastSnapshotTree = tree
+	g.logger.Success("Snapshot #%d stashed at %s", commitCounter, timestamp)
+	g.logger.Info("Successfully stashed snapshot #%d", commitCounter)
+
+	g.commitsCount = commitCounter
+	g.lastCommitTime = time.Now()
+	g.recordCheckpointStorage(ctx)
+	g.observeCheckpoint(ctx, "HEAD", strings.TrimSpace(snapshot))
+	g.saveState()
+
+	if g.config.OnCheckpoint != nil {
+		g.config.OnCheckpoint(Checkpoint{Number: commitCounter, Commit: strings.TrimSpace(snapshot), Time: g.lastCommitTime, Subject: message})
+	}
+	return nil
+}
+
+// snapshotTrees writes the index and the working tree, including untracked files that
+// are not ignored, as tree objects. It works on a scratch copy of the index, so the
+// repository's own index is never modified and staged changes stay as they are.
+func (g *Gitbak) snapshotTrees(ctx context.Context) (indexTree, tree string, err error) {
+	indexFile, cleanup, err := g.scratchIndex(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer cleanup()
+
+	indexTree, err = g.runGitWithIndex(ctx, indexFile, "write-tree")
+	if err != nil {
+		return "", "", gitbakErrors.NewGitError("write-tree", nil, gitbakErrors.Wrap(err, "failed to record index"), "")
+	}
+
+	addArgs, err := g.stageArgs(ctx, "-A")
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := g.runGitWithIndex(ctx, indexFile, addArgs...); err != nil {
+		return "", "", gitbakErrors.NewGitError("add", addArgs[1:], gitbakErrors.Wrap(err, "failed to stage changes"), "")
+	}
+
+	tree, err = g.runGitWithIndex(ctx, indexFile, "write-tree")
+	if err != nil {
+		return "", "", gitbakErrors.NewGitError("write-tree", nil, gitbakErrors.Wrap(err, "failed to record working tree"), "")
+	}
+	return strings.TrimSpace(indexTree), strings.TrimSpace(tree), nil
+}
+
+// scratchIndex copies the repository's index into a temporary directory and returns its
+// path, along with a function removing it. Copying keeps the cached file stats, so staging
+// into the copy only reads files that changed.
+func (g *Gitbak) scratchIndex(ctx context.Context) (string, func(), error) {
+	path, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", nil, gitbakErrors.NewGitError("rev-parse", []string{"--git-path", "index"},
+			gitbakErrors.Wrap(err, "failed to locate index"), "")
+	}
+	path = strings.TrimSpace(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.config.RepoPath, path)
+	}
+
+	dir, err := os.MkdirTemp("", "gitbak-index-")
+	if err != nil {
+		return "", nil, gitbakErrors.Wrap(err, "failed to create scratch index")
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	scratch := filepath.Join(dir, "index")
+
+	if err := copyFile(path, scratch); err != nil && !os.IsNotExist(err) {
+		cleanup()
+		return "", nil, gitbakErrors.Wrap(err, "failed to copy index")
+	}
+	// A missing index means nothing is staged yet; git then starts from an empty one
+	return scratch, cleanup, nil
+}
+
+// copyFile copies the contents of src to a new file dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// runGitWithIndex runs a git command in the repository against indexFile instead of its index
+func (g *Gitbak) runGitWithIndex(ctx context.Context, indexFile string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", g.config.RepoPath}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+indexFile)
+	return g.executor.ExecuteWithOutput(ctx, cmd)
+}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// gitOutput runs a git command in repoPath and returns its trimmed output
+func gitOutput(t *testing.T, repoPath string, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// TestStashMode tests that snapshots are stashed without touching branches, the index or the working tree
+func TestStashMode(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	startBranch := gitOutput(t, repoPath, "branch", "--show-current")
+	startCommit := gitOutput(t, repoPath, "rev-parse", "HEAD")
+
+	// A staged change and an untracked file
+	if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("staged"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	gitOutput(t, repoPath, "add", "initial.txt")
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("untracked"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-stash",
+		CommitPrefix:   "[gitbak-stash] Snapshot",
+		CreateBranch:   true,
+		NonInteractive: true,
+		Mode:           ModeStash,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	snapshot := func(counter int) bool {
+		t.Helper()
+		var created bool
+		if err := gb.checkAndCommitChanges(ctx, counter, &created); err != nil {
+			t.Fatalf("checkAndCommitChanges failed: %v", err)
+		}
+		return created
+	}
+
+	if !snapshot(1) {
+		t.Fatal("Expected a snapshot of the changes")
+	}
+	if snapshot(2) {
+		t.Error("Expected no snapshot when nothing changed since the last one")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if !snapshot(2) {
+		t.Fatal("Expected a snapshot of the further change")
+	}
+
+	if branch := gitOutput(t, repoPath, "branch", "--show-current"); branch != startBranch {
+		t.Errorf("Expected to stay on %s, got %s", startBranch, branch)
+	}
+	if head := gitOutput(t, repoPath, "rev-parse", "HEAD"); head != startCommit {
+		t.Errorf("Expected HEAD to stay at %s, got %s", startCommit, head)
+	}
+	if branches := gitOutput(t, repoPath, "branch", "--list", "gitbak-stash"); branches != "" {
+		t.Errorf("Expected no session branch, got %q", branches)
+	}
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status != "M  initial.txt\n?? new.txt" {
+		t.Errorf("Expected the index and working tree to be untouched, got status %q", status)
+	}
+
+	stashes := gitOutput(t, repoPath, "stash", "list", "--format=%s")
+	if lines := strings.Split(stashes, "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "[gitbak-stash] Snapshot #2 - ") {
+		t.Fatalf("Expected two snapshots, newest first, got %q", stashes)
+	}
+	if content := gitOutput(t, repoPath, "show", "stash@{0}:new.txt"); content != "changed" {
+		t.Errorf("Expected the latest snapshot to include untracked files, got %q", content)
+	}
+	if content := gitOutput(t, repoPath, "show", "stash@{1}:new.txt"); content != "untracked" {
+		t.Errorf("Expected the first snapshot to keep the earlier content, got %q", content)
+	}
+
+	// Losing the work and applying the snapshot brings it back
+	gitOutput(t, repoPath, "reset", "--hard")
+	if err := os.Remove(filepath.Join(repoPath, "new.txt")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	gitOutput(t, repoPath, "stash", "apply", "stash@{0}")
+	for file, want := range map[string]string{"initial.txt": "staged", "new.txt": "changed"} {
+		content, err := os.ReadFile(filepath.Join(repoPath, file))
+		if err != nil || string(content) != want {
+			t.Errorf("Expected %s to be restored to %q, got %q (%v)", file, want, content, err)
+		}
+	}
+}
+
+// TestStashModeRequiresCommit tests that stash mode refuses a repository without commits
+func TestStashModeRequiresCommit(t *testing.T) {
+	t.Parallel()
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       setupEmptyTestRepo(t),
+		Interval:       time.Minute,
+		BranchName:     "gitbak-stash",
+		CommitPrefix:   "[gitbak-stash] Snapshot",
+		NonInteractive: true,
+		Mode:           ModeStash,
+	}, logger.New(false, "", false))
+
+	if err := gb.initialize(context.Background()); !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Fatalf("Expected ErrInvalidConfiguration, got %v", err)
+	}
+}
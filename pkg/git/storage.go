@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// objectStorageSize returns the bytes used by the repository's object database,
+// loose and packed, as reported by git count-objects
+func (g *Gitbak) objectStorageSize(ctx context.Context) (int64, error) {
+	out, err := g.runGitCommandWithOutput(ctx, "count-objects", "-v")
+	if err != nil {
+		return 0, err
+	}
+
+	var kib int64
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "size" && key != "size-pack") {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, gitbakErrors.Wrapf(err, "unexpected count-objects output %q", line)
+		}
+		kib += n
+		found = true
+	}
+	if !found {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrGitOperationFailed, "unexpected count-objects output %q", out)
+	}
+	return kib * 1024, nil
+}
+
+// startStorageTracking records the size of the object database when the session starts,
+// so that the summary can report how much the session's checkpoints added to .git
+func (g *Gitbak) startStorageTracking(ctx context.Context) {
+	// The gogit backend only estimates the size from whole KiB, which a checkpoint rarely
+	// moves, so storage is left out of the summary and report rather than shown as +0 B
+	if g.config.Backend == BackendGoGit {
+		return
+	}
+
+	size, err := g.objectStorageSize(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to measure object storage, storage growth will not be tracked: %v", err)
+		return
+	}
+
+	g.storageTracked = true
+	g.storageStart = size
+	g.storageSize = size
+}
+
+// recordCheckpointStorage records how much the latest checkpoint grew the object database.
+// Growth can be negative when git's automatic garbage collection packs loose objects.
+func (g *Gitbak) recordCheckpointStorage(ctx context.Context) {
+	if !g.storageTracked {
+		return
+	}
+
+	size, err := g.objectStorageSize(ctx)
+	if err != nil {
+		g.logger.Warning("Failed to measure object storage: %v", err)
+		return
+	}
+
+	g.storageGrowth = append(g.storageGrowth, size-g.storageSize)
+	g.storageSize = size
+}
+
+// storageSummary describes the session's object storage growth for the summary
+func (g *Gitbak) storageSummary() string {
+	added := g.storageSize - g.storageStart
+	if len(g.storageGrowth) == 0 {
+		return FormatSize(added)
+	}
+	return fmt.Sprintf("%s (~%s per checkpoint)", FormatSize(added), FormatSize(added/int64(len(g.storageGrowth))))
+}
+
+// FormatSize renders a signed byte count with a binary unit, e.g. +1.5 MiB
+func FormatSize(bytes int64) string {
+	sign := "+"
+	if bytes < 0 {
+		sign = "-"
+		bytes = -bytes
+	}
+
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%s%.1f GiB", sign, float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%s%.1f MiB", sign, float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%s%.1f KiB", sign, float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%s%d B", sign, bytes)
+	}
+}
@@ -0,0 +1,155 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// TestFormatSize tests rendering byte counts for the summary
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		bytes    int64
+		expected string
+	}{
+		"Zero":      {bytes: 0, expected: "+0 B"},
+		"Bytes":     {bytes: 512, expected: "+512 B"},
+		"KiB":       {bytes: 1536, expected: "+1.5 KiB"},
+		"MiB":       {bytes: 5 << 20, expected: "+5.0 MiB"},
+		"GiB":       {bytes: 3 << 30, expected: "+3.0 GiB"},
+		"Shrinking": {bytes: -2048, expected: "-2.0 KiB"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := FormatSize(test.bytes); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestStorageTracking tests that checkpoint growth of the object database is recorded in the session state
+func TestStorageTracking(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-storage",
+		CommitPrefix:   "[gitbak-storage] Commit",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      stateFile,
+	}, logger.New(false, "", false))
+
+	// The checkpoint stores the new file as a loose object, growing the object database
+	content := strings.Repeat("gitbak storage accounting ", 4096)
+	if err := os.WriteFile(filepath.Join(repoPath, "large.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write change: %v", err)
+	}
+
+	if err := gb.RunSingleIteration(context.Background()); err != nil {
+		t.Fatalf("RunSingleIteration failed: %v", err)
+	}
+
+	if !gb.storageTracked {
+		t.Fatal("Expected object storage to be tracked")
+	}
+	if len(gb.storageGrowth) != 1 || gb.storageGrowth[0] <= 0 {
+		t.Fatalf("Expected one positive growth entry, got %v", gb.storageGrowth)
+	}
+
+	state, err := session.Load(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if state.StorageAdded() != gb.storageGrowth[0] {
+		t.Errorf("Expected state to record %d bytes added, got %d", gb.storageGrowth[0], state.StorageAdded())
+	}
+	if len(state.StorageGrowth) != 1 {
+		t.Errorf("Expected state to record per-checkpoint growth, got %v", state.StorageGrowth)
+	}
+	if summary := gb.storageSummary(); !strings.Contains(summary, "per checkpoint") {
+		t.Errorf("Expected a per-checkpoint average in %q", summary)
+	}
+}
+
+// TestReportStorage tests that the JSON report includes the session's object storage growth,
+// and leaves it out when the backend cannot measure it
+func TestReportStorage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		backend       string
+		expectStorage bool
+	}{
+		"Exec":  {backend: BackendExec, expectStorage: true},
+		"GoGit": {backend: BackendGoGit},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			var out bytes.Buffer
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-report-storage",
+				CommitPrefix:   "[gitbak] Checkpoint",
+				CreateBranch:   true,
+				NonInteractive: true,
+				Backend:        test.backend,
+				SummaryJSON:    &out,
+			}, logger.New(false, "", false))
+
+			content := strings.Repeat("gitbak storage accounting ", 4096)
+			if err := os.WriteFile(filepath.Join(repoPath, "large.txt"), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write change: %v", err)
+			}
+			ctx := context.Background()
+			if err := gb.RunSingleIteration(ctx); err != nil {
+				t.Fatalf("RunSingleIteration failed: %v", err)
+			}
+			gb.PrintSummary(ctx)
+
+			var report map[string]json.RawMessage
+			if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+				t.Fatalf("Summary is not valid JSON: %v\n%s", err, out.String())
+			}
+			raw, ok := report["storage"]
+			if ok != test.expectStorage {
+				t.Fatalf("Expected a storage field: %v, got %s", test.expectStorage, out.String())
+			}
+			if !ok {
+				return
+			}
+			var storage ReportStorage
+			if err := json.Unmarshal(raw, &storage); err != nil {
+				t.Fatalf("Storage is not valid JSON: %v", err)
+			}
+			if storage.AddedBytes <= 0 || storage.AddedBytes != storage.EndBytes-storage.StartBytes || storage.PerCheckpointBytes != storage.AddedBytes {
+				t.Errorf("Unexpected storage: %+v", storage)
+			}
+		})
+	}
+}
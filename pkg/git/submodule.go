@@ -0,0 +1,123 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Submodule modes, selecting how checkpoints treat the repository's submodules
+const (
+	// SubmodulesInclude checkpoints submodules as `git add .` does: a submodule whose HEAD
+	// moved has its new commit recorded, while uncommitted changes inside it are left alone.
+	SubmodulesInclude = "include"
+
+	// SubmodulesIgnore leaves submodules out of checkpoints entirely, so a submodule whose
+	// HEAD moved never causes a checkpoint bumping its recorded commit.
+	SubmodulesIgnore = "ignore"
+
+	// SubmodulesRecursive first checkpoints the uncommitted changes inside each submodule,
+	// as a commit on the submodule's HEAD, so that the checkpoint records them too.
+	SubmodulesRecursive = "recursive"
+)
+
+// SubmoduleModes lists the accepted values of GitbakConfig.Submodules
+var SubmoduleModes = []string{SubmodulesInclude, SubmodulesIgnore, SubmodulesRecursive}
+
+// gitlinkMode is the index mode of a submodule entry
+const gitlinkMode = "160000"
+
+// hasSubmodules reports whether the repository declares any submodules
+func (g *Gitbak) hasSubmodules() bool {
+	if g.config.Backend == BackendGoGit {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(g.config.RepoPath, ".gitmodules"))
+	return err == nil
+}
+
+// submoduleStatusArgs returns the git status option matching the submodule mode.
+// Only a recursive checkpoint can capture changes inside a submodule, so otherwise they
+// must not count as changes: the checkpoint would find nothing to commit.
+func (g *Gitbak) submoduleStatusArgs() []string {
+	if !g.hasSubmodules() {
+		return nil
+	}
+	switch g.config.Submodules {
+	case SubmodulesIgnore:
+		return []string{"--ignore-submodules=all"}
+	case SubmodulesRecursive:
+		return nil
+	default:
+		return []string{"--ignore-submodules=dirty"}
+	}
+}
+
+// ignoredSubmodulePaths lists the submodules left out of checkpoints by SubmodulesIgnore
+func (g *Gitbak) ignoredSubmodulePaths(ctx context.Context) ([]string, error) {
+	if g.config.Submodules != SubmodulesIgnore || !g.hasSubmodules() {
+		return nil, nil
+	}
+	return g.submodulePaths(ctx, g.config.RepoPath)
+}
+
+// submodulePaths lists the submodules recorded in the index of the repository at dir,
+// relative to dir
+func (g *Gitbak) submodulePaths(ctx context.Context, dir string) ([]string, error) {
+	out, err := g.executor.ExecuteWithContextAndOutput(ctx, "git", "-C", dir, "ls-files", "-z", "--stage")
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{"--stage"}, gitbakErrors.Wrap(err, "failed to list submodules"), "")
+	}
+
+	var paths []string
+	for _, entry := range strings.Split(out, "\x00") {
+		// Each entry is "<mode> <object> <stage>\t<path>"
+		info, path, ok := strings.Cut(entry, "\t")
+		if ok && strings.HasPrefix(info, gitlinkMode+" ") {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// checkpointSubmodules commits the uncommitted changes inside each checked out submodule
+// of the repository at dir, nested submodules first, so that the repository at dir sees
+// them as submodules whose HEAD moved.
+func (g *Gitbak) checkpointSubmodules(ctx context.Context, dir, message string) error {
+	paths, err := g.submodulePaths(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		sub := filepath.Join(dir, path)
+		if _, err := os.Stat(filepath.Join(sub, ".git")); err != nil {
+			// Not initialized, so there is nothing to checkpoint
+			continue
+		}
+		if err := g.checkpointSubmodules(ctx, sub, message); err != nil {
+			return err
+		}
+
+		status, err := g.executor.ExecuteWithContextAndOutput(ctx, "git", "-C", sub, "status", "--porcelain")
+		if err != nil {
+			return gitbakErrors.NewGitError("status", []string{"--porcelain"}, gitbakErrors.Wrapf(err, "failed to check submodule %s", path), "")
+		}
+		if strings.TrimSpace(status) == "" {
+			continue
+		}
+
+		if err := g.executor.ExecuteWithContext(ctx, "git", "-C", sub, "add", "-A"); err != nil {
+			return gitbakErrors.NewGitError("add", []string{"-A"}, gitbakErrors.Wrapf(err, "failed to stage changes in submodule %s", path), "")
+		}
+		commitArgs := g.messageArgs(message)
+		if err := g.runCheckpointCommit(ctx, sub, commitArgs...); err != nil {
+			return gitbakErrors.NewGitError("commit", commitArgs, gitbakErrors.Wrapf(err, "failed to checkpoint submodule %s", path), "")
+		}
+		g.logger.Info("Checkpointed submodule %s", sub)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// setupSubmoduleRepo creates a repository with a submodule checked out at lib
+func setupSubmoduleRepo(t *testing.T) string {
+	t.Helper()
+
+	libPath := setupTestRepo(t)
+	repoPath := setupTestRepo(t)
+	gitOutput(t, repoPath, "-c", "protocol.file.allow=always", "submodule", "--quiet", "add", libPath, "lib")
+	gitOutput(t, repoPath, "commit", "-m", "Add lib")
+
+	// The submodule is a fresh clone, without the identity setupTestRepo configures
+	sub := filepath.Join(repoPath, "lib")
+	gitOutput(t, sub, "config", "user.name", "Test User")
+	gitOutput(t, sub, "config", "user.email", "test@example.com")
+	return repoPath
+}
+
+// TestSubmodules tests how each submodule mode treats a submodule with uncommitted changes
+// and one whose HEAD moved
+func TestSubmodules(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mode string
+
+		// expectDirty is whether uncommitted changes inside the submodule count as changes
+		expectDirty bool
+
+		// expectLib is whether the checkpoint records a new commit for the submodule
+		expectLib bool
+	}{
+		"Include":   {mode: SubmodulesInclude, expectLib: true},
+		"Ignore":    {mode: SubmodulesIgnore},
+		"Recursive": {mode: SubmodulesRecursive, expectDirty: true, expectLib: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupSubmoduleRepo(t)
+			libPath := filepath.Join(repoPath, "lib")
+			gitOutput(t, repoPath, "checkout", "-b", "gitbak-submodules")
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-submodules",
+				CommitPrefix:   "[gitbak-submodules] Checkpoint",
+				CreateBranch:   true,
+				NonInteractive: true,
+				Submodules:     test.mode,
+			}, logger.New(false, "", false))
+			ctx := context.Background()
+
+			if err := os.WriteFile(filepath.Join(libPath, "initial.txt"), []byte("edited in lib\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify submodule: %v", err)
+			}
+			hasChanges, err := gb.hasUncommittedChanges(ctx)
+			if err != nil {
+				t.Fatalf("hasUncommittedChanges failed: %v", err)
+			}
+			if hasChanges != test.expectDirty {
+				t.Errorf("Expected changes inside the submodule to count as changes: %v, got %v", test.expectDirty, hasChanges)
+			}
+
+			if test.mode != SubmodulesRecursive {
+				// Move the submodule's HEAD, as pulling inside it would
+				gitOutput(t, libPath, "commit", "-qam", "Edit lib")
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "initial.txt"), []byte("changed\n"), 0644); err != nil {
+				t.Fatalf("Failed to modify file: %v", err)
+			}
+			if _, err := gb.createCommit(ctx, 1); err != nil {
+				t.Fatalf("createCommit failed: %v", err)
+			}
+
+			files := strings.Fields(gitOutput(t, repoPath, "show", "--name-only", "--format=", "HEAD"))
+			recordsLib := len(files) == 2 && files[1] == "lib"
+			if files[0] != "initial.txt" || recordsLib != test.expectLib {
+				t.Errorf("Expected the checkpoint to record lib: %v, got files %q", test.expectLib, files)
+			}
+
+			if test.mode == SubmodulesRecursive {
+				subject := gitOutput(t, libPath, "log", "-1", "--format=%s")
+				if !strings.HasPrefix(subject, "[gitbak-submodules] Checkpoint #1") {
+					t.Errorf("Expected the submodule changes to be checkpointed inside it, got %q", subject)
+				}
+				if status := gitOutput(t, libPath, "status", "--porcelain"); status != "" {
+					t.Errorf("Expected a clean submodule, got %q", status)
+				}
+			}
+		})
+	}
+}
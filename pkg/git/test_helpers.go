@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -64,8 +65,31 @@ func (g *Gitbak) RunSingleIteration(ctx context.Context) error {
 		g.logger.Error("Failed to get current branch: %v", err)
 		return err
 	}
+	g.ignoreCase = g.detectIgnoreCase(ctx)
 
-	if g.config.ContinueSession {
+	if head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD"); err == nil {
+		g.startCommit = strings.TrimSpace(head)
+	}
+
+	if err := g.handleDetachedHead(); err != nil {
+		return err
+	}
+	if err := g.handleEmptyRepository(ctx); err != nil {
+		return err
+	}
+	g.startStorageTracking(ctx)
+
+	if g.stashMode() {
+		g.setupStashSession()
+	} else if g.refsMode() {
+		if err := g.setupRefsSession(ctx); err != nil {
+			return err
+		}
+	} else if g.observeMode() {
+		if err := g.setupObserveSession(ctx); err != nil {
+			return err
+		}
+	} else if g.config.ContinueSession {
 		if err := g.setupContinueSession(ctx); err != nil {
 			return err
 		}
@@ -77,6 +101,7 @@ func (g *Gitbak) RunSingleIteration(ctx context.Context) error {
 		g.setupCurrentBranchSession(ctx)
 	}
 
+	g.saveState()
 	g.displayStartupInfo()
 
 	// Initialize commit counter based on commit count, exactly as in monitoringLoop
@@ -95,13 +120,15 @@ func (g *Gitbak) RunSingleIteration(ctx context.Context) error {
 		return g.checkAndCommitChanges(ctx, commitCounter, &commitWasCreated)
 	})
 
+	g.pushIfDue(ctx)
+
 	return err
 }
 
 // setupTestGitbak creates a Gitbak instance for testing with default mocks
 // In test context, we panic on validation errors since tests should be providing valid configs
 func setupTestGitbak(config GitbakConfig, logger logger.Logger) *Gitbak {
-	executor := NewExecExecutor()
+	executor := NewExecutor(config.Backend)
 
 	var interactor UserInteractor
 	if config.NonInteractive {
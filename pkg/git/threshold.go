@@ -0,0 +1,89 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// thresholdEnabled reports whether small changes are held back until they add up
+func (g *Gitbak) thresholdEnabled() bool {
+	return g.config.MinChangedLines > 0 || g.config.MinChangedFiles > 0
+}
+
+// belowChangeThreshold reports whether the uncommitted changes are too small to checkpoint
+// yet. Changes are held back until they meet either MinChangedLines or MinChangedFiles, or
+// until MaxSkippedChecks checks in a row have held them back.
+func (g *Gitbak) belowChangeThreshold(ctx context.Context) (bool, error) {
+	if !g.thresholdEnabled() {
+		return false, nil
+	}
+	if g.config.MaxSkippedChecks > 0 && g.skippedChecks >= g.config.MaxSkippedChecks {
+		g.logger.Info("Small changes held back for %d checks, checkpointing them anyway", g.skippedChecks)
+		return false, nil
+	}
+
+	files, lines, err := g.changeSize(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if (g.config.MinChangedFiles > 0 && files >= g.config.MinChangedFiles) ||
+		(g.config.MinChangedLines > 0 && lines >= g.config.MinChangedLines) {
+		return false, nil
+	}
+
+	g.skippedChecks++
+	if g.config.ShowNoChanges && g.config.Verbose {
+		g.logger.InfoToUser("Changes too small to commit at %s (%d files, %d lines)", time.Now().Format("15:04:05"), files, lines)
+	}
+	g.logger.Info("Holding back changes below the threshold: %d files, %d lines", files, lines)
+	return true, nil
+}
+
+// changeSize measures the uncommitted changes as the next checkpoint would commit them,
+// including untracked files that are not ignored: how many files changed and how many
+// lines were added or removed. Binary files count as a single line. The changes are staged
+// into a scratch copy of the index, so the repository's own index is left untouched.
+func (g *Gitbak) changeSize(ctx context.Context) (files, lines int, err error) {
+	indexFile, cleanup, err := g.scratchIndex(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cleanup()
+
+	addArgs, err := g.stageArgs(ctx, "-A")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := g.runGitWithIndex(ctx, indexFile, addArgs...); err != nil {
+		return 0, 0, gitbakErrors.NewGitError("add", addArgs[1:], gitbakErrors.Wrap(err, "failed to stage changes"), "")
+	}
+
+	out, err := g.runGitWithIndex(ctx, indexFile, "diff", "--cached", "--numstat")
+	if err != nil {
+		return 0, 0, gitbakErrors.NewGitError("diff", []string{"--cached", "--numstat"}, gitbakErrors.Wrap(err, "failed to measure changes"), "")
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		added, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		removed, _, _ := strings.Cut(rest, "\t")
+
+		files++
+		if added == "-" {
+			// Binary files have no line counts
+			lines++
+			continue
+		}
+		a, _ := strconv.Atoi(added)
+		r, _ := strconv.Atoi(removed)
+		lines += a + r
+	}
+	return files, lines, nil
+}
@@ -0,0 +1,148 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestChangeThreshold tests that small changes accumulate until they meet a threshold
+func TestChangeThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		minLines, minFiles, maxSkipped int
+
+		// edits are applied in order, each followed by a check; expected holds whether
+		// the check should create a checkpoint
+		edits    []map[string]string
+		expected []bool
+	}{
+		"Disabled": {
+			edits:    []map[string]string{{"initial.txt": "a"}},
+			expected: []bool{true},
+		},
+		"LinesAccumulate": {
+			minLines: 4,
+			edits: []map[string]string{
+				{"initial.txt": "one\n"},                          // 1 added, 1 removed
+				{"initial.txt": "one\ntwo\n"},                     // 2 added, 1 removed
+				{"initial.txt": "one\ntwo\nthree\n", "b.txt": ""}, // 3 added, 1 removed
+			},
+			expected: []bool{false, false, true},
+		},
+		"FilesAccumulate": {
+			minFiles: 2,
+			edits:    []map[string]string{{"a.txt": "a\n"}, {"b.txt": "b\n"}},
+			expected: []bool{false, true},
+		},
+		"EitherThresholdSuffices": {
+			minLines: 100,
+			minFiles: 2,
+			edits:    []map[string]string{{"a.txt": "a\n", "b.txt": "b\n"}},
+			expected: []bool{true},
+		},
+		"MaxSkippedChecks": {
+			minLines:   100,
+			maxSkipped: 2,
+			edits:      []map[string]string{{"a.txt": "a\n"}, {}, {}, {"b.txt": "b\n"}},
+			expected:   []bool{false, false, true, false},
+		},
+		"Unlimited": {
+			minLines: 100,
+			edits:    []map[string]string{{"a.txt": "a\n"}, {}, {}, {}, {}, {}, {}},
+			expected: []bool{false, false, false, false, false, false, false},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:         repoPath,
+				Interval:         time.Minute,
+				BranchName:       "gitbak-threshold",
+				CommitPrefix:     "[gitbak-threshold] Checkpoint",
+				CreateBranch:     false,
+				NonInteractive:   true,
+				MinChangedLines:  test.minLines,
+				MinChangedFiles:  test.minFiles,
+				MaxSkippedChecks: test.maxSkipped,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			counter := 1
+			for i, edit := range test.edits {
+				for file, content := range edit {
+					if err := os.WriteFile(filepath.Join(repoPath, file), []byte(content), 0644); err != nil {
+						t.Fatalf("Failed to write %s: %v", file, err)
+					}
+				}
+
+				var created bool
+				if err := gb.checkAndCommitChanges(ctx, counter, &created); err != nil {
+					t.Fatalf("Check %d failed: %v", i+1, err)
+				}
+				if created != test.expected[i] {
+					t.Fatalf("Check %d: expected checkpoint %v, got %v", i+1, test.expected[i], created)
+				}
+				if created {
+					counter++
+				}
+			}
+
+			// Held back changes must never be staged, so the user's index is left alone
+			if staged := gitOutput(t, repoPath, "diff", "--cached", "--name-only"); staged != "" {
+				t.Errorf("Expected nothing staged, got %q", staged)
+			}
+		})
+	}
+}
+
+func TestChangeSize(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	files := map[string][]byte{
+		"initial.txt": []byte("changed\nand more\n"),
+		"new.txt":     []byte("one\ntwo\nthree\n"),
+		"image.bin":   {0, 1, 2, 0, 3},
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(repoPath, file), content, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, ".gitignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "debug.log"), []byte(strings.Repeat("x\n", 100)), 0644); err != nil {
+		t.Fatalf("Failed to write debug.log: %v", err)
+	}
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-threshold",
+		CommitPrefix:   "[gitbak-threshold] Checkpoint",
+		NonInteractive: true,
+	}, logger.New(false, "", false))
+
+	changed, lines, err := gb.changeSize(context.Background())
+	if err != nil {
+		t.Fatalf("changeSize failed: %v", err)
+	}
+
+	// initial.txt: 2 added, 1 removed; new.txt: 3 added; image.bin: binary; .gitignore: 1 added
+	if changed != 4 || lines != 8 {
+		t.Errorf("Expected 4 files and 8 lines, got %d files and %d lines", changed, lines)
+	}
+}
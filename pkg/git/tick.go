@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// overrunSlack is how late a check may be picked up before the delay is logged as an
+// overrun, allowing for the timer firing slightly late on a busy machine
+const overrunSlack = time.Second
+
+// tickScheduler times the monitoring loop's scheduled checks. Each check is due an interval
+// after the previous one was due rather than after it finished, so slow git operations do
+// not push every later check back. With marks, each check is moved on to the first of those
+// offsets into the hour at or after that, e.g. :00 and :30, so that checkpoints line up
+// with the wall clock.
+type tickScheduler struct {
+	interval time.Duration
+	marks    []time.Duration
+
+	// last is when the previous check was due, and due when the next one is
+	last, due time.Time
+	timer     *time.Timer
+}
+
+// newTickScheduler schedules the first check an interval from now
+func newTickScheduler(interval time.Duration, marks []time.Duration) *tickScheduler {
+	s := &tickScheduler{interval: interval, marks: marks, last: time.Now()}
+	s.due = nextTick(s.last, interval, marks)
+	s.timer = time.NewTimer(time.Until(s.due))
+	return s
+}
+
+// C delivers the time of each check as it falls due
+func (s *tickScheduler) C() <-chan time.Time {
+	return s.timer.C
+}
+
+// advance schedules the check after the one that just fell due, and returns how late that
+// one was picked up. A check is late when the loop was still busy with an earlier one that
+// overran its interval; it is made once that ends, but any further checks it ran past are
+// skipped rather than made back to back, and their number returned.
+func (s *tickScheduler) advance() (time.Duration, int) {
+	late := time.Since(s.due)
+	s.last = s.due
+	return late, s.plan()
+}
+
+// setInterval moves the next check to an interval after the previous one was due, passing
+// over the ticks of the new interval that have already gone by
+func (s *tickScheduler) setInterval(interval time.Duration) {
+	s.interval = interval
+	s.due, _ = s.following(interval)
+	s.timer.Reset(time.Until(s.due))
+}
+
+// plan schedules the first tick after last that has not yet passed, and returns the number
+// of ticks that have
+func (s *tickScheduler) plan() int {
+	due, missed := s.following(s.interval)
+	s.due = due
+	s.timer.Reset(time.Until(s.due))
+	return missed
+}
+
+// following returns the first tick after last, an interval apart, that has not yet passed,
+// and the number of ticks that have
+func (s *tickScheduler) following(interval time.Duration) (time.Time, int) {
+	now := time.Now()
+	missed := 0
+	due := nextTick(s.last, interval, s.marks)
+	for !due.After(now) {
+		missed++
+		due = nextTick(due, interval, s.marks)
+	}
+	return due, missed
+}
+
+// stop stops the timer
+func (s *tickScheduler) stop() {
+	s.timer.Stop()
+}
+
+// nextTick returns when the check after one due at from is due: an interval later, moved on
+// to the next of marks, the offsets into the hour, if any are given
+func nextTick(from time.Time, interval time.Duration, marks []time.Duration) time.Time {
+	next := from.Add(interval)
+	if len(marks) == 0 {
+		return next
+	}
+
+	// The hour in local time, which time.Truncate would get wrong in zones offset by
+	// part of an hour
+	hour := time.Date(next.Year(), next.Month(), next.Day(), next.Hour(), 0, 0, 0, next.Location())
+	for {
+		var earliest time.Time
+		for _, mark := range marks {
+			if t := hour.Add(mark); !t.Before(next) && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+			}
+		}
+		if !earliest.IsZero() {
+			return earliest
+		}
+		hour = hour.Add(time.Hour)
+	}
+}
+
+// formatMarks returns marks as the minutes past the hour they stand for, e.g. ":00, :30"
+func formatMarks(marks []time.Duration) string {
+	names := make([]string, len(marks))
+	for i, mark := range marks {
+		names[i] = fmt.Sprintf(":%02d", int(mark/time.Minute))
+		if seconds := int(mark % time.Minute / time.Second); seconds > 0 {
+			names[i] += fmt.Sprintf(":%02d", seconds)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextTick(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2026, 3, 14, hour, minute, second, 0, time.Local)
+	}
+	quarters := []time.Duration{0, 15 * time.Minute, 30 * time.Minute, 45 * time.Minute}
+
+	tests := map[string]struct {
+		from     time.Time
+		interval time.Duration
+		marks    []time.Duration
+		expected time.Time
+	}{
+		"Unaligned": {
+			from:     at(10, 3, 17),
+			interval: 5 * time.Minute,
+			expected: at(10, 8, 17),
+		},
+		"MovedOnToMark": {
+			from:     at(10, 3, 17),
+			interval: 5 * time.Minute,
+			marks:    quarters,
+			expected: at(10, 15, 0),
+		},
+		"OnMark": {
+			from:     at(10, 15, 0),
+			interval: 15 * time.Minute,
+			marks:    quarters,
+			expected: at(10, 30, 0),
+		},
+		"IntervalLongerThanMarks": {
+			from:     at(10, 15, 0),
+			interval: 20 * time.Minute,
+			marks:    quarters,
+			expected: at(10, 45, 0),
+		},
+		"NextHour": {
+			from:     at(10, 50, 0),
+			interval: 5 * time.Minute,
+			marks:    []time.Duration{30 * time.Minute, 10 * time.Minute},
+			expected: at(11, 10, 0),
+		},
+		"Seconds": {
+			from:     at(10, 0, 0),
+			interval: time.Minute,
+			marks:    []time.Duration{30*time.Minute + 30*time.Second},
+			expected: at(10, 30, 30),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if next := nextTick(test.from, test.interval, test.marks); !next.Equal(test.expected) {
+				t.Errorf("Expected the check after %s to be due at %s, got %s", test.from.Format(time.TimeOnly), test.expected.Format(time.TimeOnly), next.Format(time.TimeOnly))
+			}
+		})
+	}
+}
+
+// TestTickSchedulerOverrun tests that checks stay on the schedule they started on when one
+// is picked up late, and that the checks it ran past are skipped and counted
+func TestTickSchedulerOverrun(t *testing.T) {
+	t.Parallel()
+
+	interval := 100 * time.Millisecond
+	ticks := newTickScheduler(interval, nil)
+	defer ticks.stop()
+	start := ticks.due.Add(-interval)
+
+	<-ticks.C()
+	// A check overrunning the next two ticks
+	time.Sleep(2*interval + interval/2)
+
+	late, missed := ticks.advance()
+	if late < 2*interval {
+		t.Errorf("Expected the check to be picked up at least %v late, got %v", 2*interval, late)
+	}
+	if missed < 2 {
+		t.Errorf("Expected at least 2 skipped checks, got %d", missed)
+	}
+	if offset := ticks.due.Sub(start); offset%interval != 0 {
+		t.Errorf("Expected the next check to stay on the schedule, got it %v after the start", offset)
+	}
+	if !ticks.due.After(time.Now()) {
+		t.Errorf("Expected the next check to be due in the future, got %s", ticks.due)
+	}
+}
+
+func TestFormatMarks(t *testing.T) {
+	t.Parallel()
+
+	marks := []time.Duration{0, 5 * time.Minute, 30*time.Minute + 15*time.Second}
+	if formatted := formatMarks(marks); formatted != ":00, :05, :30:15" {
+		t.Errorf("Expected \":00, :05, :30:15\", got %q", formatted)
+	}
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// withOpTimeout derives the context for a single git operation, bounded by OpTimeout if set
+func (g *Gitbak) withOpTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.config.OpTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.config.OpTimeout)
+}
+
+// indexWriters are the git commands gitbak runs that take the index lock
+var indexWriters = map[string]bool{
+	"add":    true,
+	"commit": true,
+	"stash":  true,
+}
+
+// classifyTimeout reports err as ErrOperationTimeout if it was caused by opCtx running
+// out of time, rather than by the session itself being canceled. After a timeout that
+// killed one of the indexWriters, the index lock it left behind is removed so the next
+// check can run; any other command cannot have taken it, so it is left alone.
+// A single command exceeding CommandTimeout already fails with a CommandTimeoutError,
+// which is returned as is.
+func (g *Gitbak) classifyTimeout(ctx, opCtx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	var cmdTimeout *gitbakErrors.CommandTimeoutError
+	if opCtx.Err() == nil && gitbakErrors.As(err, &cmdTimeout) {
+		if indexWriters[gitSubcommand(cmdTimeout.Command, cmdTimeout.Args)] {
+			g.removeStaleIndexLock(ctx, time.Now().Add(-cmdTimeout.Timeout-commandWaitDelay))
+		}
+		return err
+	}
+	if opCtx.Err() == context.DeadlineExceeded && killedIndexWriter(err) {
+		deadline, _ := opCtx.Deadline()
+		g.removeStaleIndexLock(ctx, deadline.Add(-g.config.OpTimeout))
+	}
+	return g.reportTimeout(ctx, opCtx, err)
+}
+
+// reportTimeout is like classifyTimeout, but leaves the index lock alone. It is for
+// operations such as a push, which never take the lock, so that one found after they
+// time out always belongs to another git process, such as the user's own commit.
+func (g *Gitbak) reportTimeout(ctx, opCtx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil || opCtx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return gitbakErrors.Wrapf(gitbakErrors.ErrOperationTimeout,
+		"did not finish within %s, will retry at the next check: %v", g.config.OpTimeout, err)
+}
+
+// killedIndexWriter reports whether err is the failure of one of the indexWriters,
+// naming it in any of the GitErrors it wraps
+func killedIndexWriter(err error) bool {
+	var gitErr *gitbakErrors.GitError
+	for gitbakErrors.As(err, &gitErr) {
+		if indexWriters[gitSubcommand(gitErr.Operation, gitErr.Args)] {
+			return true
+		}
+		err = gitErr.Err
+	}
+	return false
+}
+
+// gitSubcommand returns the git command that operation and args ran. Failures are reported
+// either with the command itself as the operation, such as "commit", or with the git binary
+// and its whole command line, in which the command follows the global options.
+func gitSubcommand(operation string, args []string) string {
+	name := filepath.Base(operation)
+	if name != "git" && name != "git.exe" && !strings.ContainsAny(operation, `/\`) {
+		return operation
+	}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "-c":
+			i++
+		case !strings.HasPrefix(arg, "-"):
+			return arg
+		}
+	}
+	return ""
+}
+
+// removeStaleIndexLock removes the index lock of a git command that was killed mid-operation.
+// Only a lock written since the operation started can be the killed command's; older locks
+// belong to someone else and are left alone.
+func (g *Gitbak) removeStaleIndexLock(ctx context.Context, started time.Time) {
+	out, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--git-path", "index.lock")
+	if err != nil {
+		return
+	}
+
+	lockPath := strings.TrimSpace(out)
+	if lockPath == "" {
+		return
+	}
+	if !filepath.IsAbs(lockPath) {
+		lockPath = filepath.Join(g.config.RepoPath, lockPath)
+	}
+
+	info, err := os.Stat(lockPath)
+	if err != nil || info.ModTime().Before(started) {
+		return
+	}
+	if err := os.Remove(lockPath); err != nil {
+		g.logger.Warning("Failed to remove stale index lock %s: %v", lockPath, err)
+		return
+	}
+	g.logger.Info("Removed index lock left by a timed-out git command: %s", lockPath)
+}
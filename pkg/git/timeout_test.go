@@ -0,0 +1,271 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestClassifyTimeout tests telling operation timeouts apart from other failures
+func TestClassifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	failure := errors.New("signal: killed")
+
+	tests := map[string]struct {
+		err             error
+		expire          bool
+		cancelSession   bool
+		expectTimeout   bool
+		expectUnchanged bool
+	}{
+		"Success": {
+			err:             nil,
+			expire:          true,
+			expectUnchanged: true,
+		},
+		"OrdinaryFailure": {
+			err:             failure,
+			expectUnchanged: true,
+		},
+		"TimedOut": {
+			err:           failure,
+			expire:        true,
+			expectTimeout: true,
+		},
+		"SessionCanceled": {
+			err:             failure,
+			expire:          true,
+			cancelSession:   true,
+			expectUnchanged: true,
+		},
+		"CommandTimedOut": {
+			err:             gitbakErrors.NewGitError("git", nil, gitbakErrors.NewCommandTimeoutError("git", nil, time.Minute), ""),
+			expectTimeout:   true,
+			expectUnchanged: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := &Gitbak{
+				config:   GitbakConfig{RepoPath: t.TempDir(), OpTimeout: time.Millisecond},
+				logger:   logger.New(false, "", false),
+				executor: &MockCommandExecutor{},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			opCtx, opCancel := context.WithTimeout(ctx, time.Hour)
+			if test.expire {
+				opCtx, opCancel = context.WithTimeout(ctx, time.Nanosecond)
+				<-opCtx.Done()
+			}
+			defer opCancel()
+			if test.cancelSession {
+				cancel()
+			}
+
+			got := gb.classifyTimeout(ctx, opCtx, test.err)
+
+			if test.expectUnchanged && got != test.err {
+				t.Errorf("Expected the error to be returned unchanged, got %v", got)
+			}
+			if gitbakErrors.Is(got, gitbakErrors.ErrOperationTimeout) != test.expectTimeout {
+				t.Errorf("Expected timeout classification %v, got %v", test.expectTimeout, got)
+			}
+		})
+	}
+}
+
+// TestRunCheckOpTimeout tests that a hung git command fails the check instead of blocking the loop
+func TestRunCheckOpTimeout(t *testing.T) {
+	t.Parallel()
+
+	executor := &MockCommandExecutor{
+		ExecuteFn: func(ctx context.Context, cmd *exec.Cmd) error {
+			if slices.Contains(cmd.Args, "status") {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	}
+
+	gb, err := NewGitbakWithDeps(GitbakConfig{
+		RepoPath:     t.TempDir(),
+		Interval:     time.Minute,
+		BranchName:   "gitbak-timeout",
+		CommitPrefix: "[gitbak]",
+		MaxRetries:   3,
+		OpTimeout:    50 * time.Millisecond,
+	}, logger.New(false, "", false), executor, NewNonInteractiveInteractor())
+	if err != nil {
+		t.Fatalf("Failed to create gitbak: %v", err)
+	}
+
+	commitCounter := 1
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+
+	done := make(chan error, 1)
+	go func() { done <- gb.runCheck(context.Background(), &commitCounter, &errorState) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected the timed-out check to be retried rather than end the session, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runCheck did not return after the operation timeout")
+	}
+
+	if errorState.consecutiveErrors != 1 || gb.errorsCount != 1 {
+		t.Errorf("Expected the timeout to count as one failed check, got %d consecutive, %d total",
+			errorState.consecutiveErrors, gb.errorsCount)
+	}
+}
+
+// TestRemoveStaleIndexLock tests removing only index locks written during the timed-out operation
+func TestRemoveStaleIndexLock(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		startedOffset time.Duration
+		expectRemoved bool
+	}{
+		"WrittenDuringOperation": {
+			startedOffset: -time.Minute,
+			expectRemoved: true,
+		},
+		"PredatesOperation": {
+			startedOffset: time.Minute,
+			expectRemoved: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			lockPath := filepath.Join(repoPath, ".git", "index.lock")
+			if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+				t.Fatalf("Failed to create index lock: %v", err)
+			}
+
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "master",
+				CommitPrefix:   "[gitbak]",
+				NonInteractive: true,
+				OpTimeout:      time.Minute,
+			}, logger.New(false, "", false))
+
+			gb.removeStaleIndexLock(context.Background(), time.Now().Add(test.startedOffset))
+
+			_, err := os.Stat(lockPath)
+			if removed := os.IsNotExist(err); removed != test.expectRemoved {
+				t.Errorf("Expected lock removed to be %v, got %v", test.expectRemoved, removed)
+			}
+		})
+	}
+}
+
+// TestClassifyTimeoutIndexLock tests that an index lock is only removed after a timeout
+// killed a command that writes the index, and kept after others, such as a push
+func TestClassifyTimeoutIndexLock(t *testing.T) {
+	t.Parallel()
+
+	commandTimeout := func(args ...string) error {
+		args = append([]string{"-C", "/repo", "-c", "core.quotePath=false"}, args...)
+		return gitbakErrors.NewGitError("git", args, gitbakErrors.NewCommandTimeoutError("git", args, time.Minute), "")
+	}
+	killed := func(operation string, args ...string) error {
+		return gitbakErrors.NewGitError(operation, args, errors.New("signal: killed"), "")
+	}
+
+	tests := map[string]struct {
+		err           error
+		expire        bool
+		expectRemoved bool
+	}{
+		"PushCommandTimeout": {
+			err: commandTimeout("push", "--quiet", "origin", "refs/heads/main:refs/heads/main"),
+		},
+		"AddCommandTimeout": {
+			err:           commandTimeout("add", "-A"),
+			expectRemoved: true,
+		},
+		"CustomGitPath": {
+			err:           gitbakErrors.NewGitError("/opt/git/bin/git", []string{"commit", "-m", "x"}, gitbakErrors.NewCommandTimeoutError("/opt/git/bin/git", []string{"commit", "-m", "x"}, time.Minute), ""),
+			expectRemoved: true,
+		},
+		"StatusKilledByOpTimeout": {
+			err:    killed("git", "-C", "/repo", "status", "--porcelain"),
+			expire: true,
+		},
+		"CommitKilledByOpTimeout": {
+			err:           gitbakErrors.NewGitError("commit", []string{"-m", "x"}, killed("git", "-C", "/repo", "commit", "-m", "x"), ""),
+			expire:        true,
+			expectRemoved: true,
+		},
+		"UnknownCommandKilledByOpTimeout": {
+			err:    errors.New("signal: killed"),
+			expire: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "master",
+				CommitPrefix:   "[gitbak]",
+				NonInteractive: true,
+				OpTimeout:      time.Hour,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			opCtx, cancel := context.WithTimeout(ctx, time.Hour)
+			if test.expire {
+				opCtx, cancel = context.WithTimeout(ctx, time.Nanosecond)
+				<-opCtx.Done()
+			}
+			defer cancel()
+
+			// Taken while the command ran, as the user's own git commit might
+			lockPath := filepath.Join(repoPath, ".git", "index.lock")
+			if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+				t.Fatalf("Failed to create index lock: %v", err)
+			}
+
+			_ = gb.classifyTimeout(ctx, opCtx, test.err)
+
+			_, err := os.Stat(lockPath)
+			if removed := os.IsNotExist(err); removed != test.expectRemoved {
+				t.Errorf("Expected lock removed to be %v, got %v", test.expectRemoved, removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/bashhack/gitbak/pkg/tracing"
+)
+
+// tracingExecutor is a CommandExecutor that records a span for every command the
+// executor it wraps runs, as a child of the span in the command's context
+type tracingExecutor struct {
+	executor CommandExecutor
+	tracer   *tracing.Tracer
+}
+
+// newTracingExecutor wraps executor so that its commands are traced with tracer
+func newTracingExecutor(executor CommandExecutor, tracer *tracing.Tracer) *tracingExecutor {
+	return &tracingExecutor{executor: executor, tracer: tracer}
+}
+
+// Execute implements CommandExecutor.Execute
+func (e *tracingExecutor) Execute(ctx context.Context, cmd *exec.Cmd) error {
+	ctx, span := e.start(ctx, cmd.Args)
+	err := e.executor.Execute(ctx, cmd)
+	endCommandSpan(span, err)
+	return err
+}
+
+// ExecuteWithOutput implements CommandExecutor.ExecuteWithOutput
+func (e *tracingExecutor) ExecuteWithOutput(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	ctx, span := e.start(ctx, cmd.Args)
+	out, err := e.executor.ExecuteWithOutput(ctx, cmd)
+	endCommandSpan(span, err)
+	return out, err
+}
+
+// ExecuteWithContext implements CommandExecutor.ExecuteWithContext
+func (e *tracingExecutor) ExecuteWithContext(ctx context.Context, name string, args ...string) error {
+	ctx, span := e.start(ctx, append([]string{name}, args...))
+	err := e.executor.ExecuteWithContext(ctx, name, args...)
+	endCommandSpan(span, err)
+	return err
+}
+
+// ExecuteWithContextAndOutput implements CommandExecutor.ExecuteWithContextAndOutput
+func (e *tracingExecutor) ExecuteWithContextAndOutput(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, span := e.start(ctx, append([]string{name}, args...))
+	out, err := e.executor.ExecuteWithContextAndOutput(ctx, name, args...)
+	endCommandSpan(span, err)
+	return out, err
+}
+
+// start opens the span of a command, given as its name followed by its arguments.
+// The span is named after the git subcommand, so that slow kinds of calls stand out.
+func (e *tracingExecutor) start(ctx context.Context, argv []string) (context.Context, *tracing.Span) {
+	var name string
+	var args []string
+	if len(argv) > 0 {
+		name, args = argv[0], argv[1:]
+	}
+
+	spanName := name
+	if sub := subcommand(args); sub != "" {
+		spanName += " " + sub
+	}
+	return e.tracer.Start(ctx, spanName,
+		tracing.String("process.command", name),
+		tracing.Strings("process.command_args", args),
+	)
+}
+
+// endCommandSpan closes the span of a command with its exit code
+func endCommandSpan(span *tracing.Span, err error) {
+	code := 0
+	if err != nil {
+		code = exitCode(err)
+	}
+	span.SetAttributes(tracing.Int("process.exit_code", code))
+	span.End(err)
+}
+
+// subcommand returns the git subcommand in args, skipping the global options before it
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "-c":
+			i++
+		case len(arg) > 0 && arg[0] == '-':
+		default:
+			return arg
+		}
+	}
+	return ""
+}
+
+// startCheckSpan opens the span of a check, which the spans of its git commands belong to
+func (g *Gitbak) startCheckSpan(ctx context.Context, commitCounter int) (context.Context, *tracing.Span) {
+	return g.config.Tracer.Start(ctx, "gitbak.check",
+		tracing.String("gitbak.branch", g.config.BranchName),
+		tracing.Int("gitbak.checkpoint_number", commitCounter),
+	)
+}
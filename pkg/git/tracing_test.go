@@ -0,0 +1,143 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/tracing"
+)
+
+// exportedSpan is the part of an exported OTLP span the tests look at
+type exportedSpan struct {
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"attributes"`
+}
+
+// TestCheckTracing tests that a check and the git commands it runs are exported as spans
+func TestCheckTracing(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var spans []exportedSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer server.Close()
+
+	repoPath := setupTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repoPath, "traced.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tracer := tracing.New(server.URL, "test", nil)
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-traced",
+		CommitPrefix:   "[gitbak-traced]",
+		CreateBranch:   false,
+		NonInteractive: true,
+		MaxRetries:     3,
+		Tracer:         tracer,
+	}, logger.New(false, "", false))
+
+	ctx := context.Background()
+	if err := gb.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	counter := 1
+	errorState := struct {
+		consecutiveErrors int
+		lastErrorMsg      string
+	}{}
+	if err := gb.runCheck(ctx, &counter, &errorState); err != nil {
+		t.Fatalf("runCheck failed: %v", err)
+	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	var check *exportedSpan
+	for i := range spans {
+		if spans[i].Name == "gitbak.check" {
+			check = &spans[i]
+		}
+	}
+	if check == nil {
+		t.Fatalf("Expected a gitbak.check span, got %+v", spans)
+	}
+
+	commands := map[string]exportedSpan{}
+	for _, span := range spans {
+		if span.ParentSpanID == check.SpanID {
+			commands[span.Name] = span
+		}
+	}
+	commit, ok := commands["git commit"]
+	if !ok {
+		t.Fatalf("Expected a git commit span in the check, got %+v", commands)
+	}
+
+	attributes := map[string]string{}
+	for _, attr := range commit.Attributes {
+		attributes[attr.Key] = string(attr.Value)
+	}
+	if attributes["process.command"] != `{"stringValue":"git"}` || attributes["process.exit_code"] != `{"intValue":"0"}` {
+		t.Errorf("Expected the command and its exit code, got %v", attributes)
+	}
+	if attributes["process.command_args"] == "" {
+		t.Errorf("Expected the command's arguments, got %v", attributes)
+	}
+}
+
+// TestSubcommand tests finding the git subcommand a span is named after
+func TestSubcommand(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"Plain":       {args: []string{"status", "--porcelain"}, expected: "status"},
+		"Directory":   {args: []string{"-C", "/repo", "commit", "-m", "msg"}, expected: "commit"},
+		"ConfigValue": {args: []string{"-c", "core.quotepath=off", "-C", "/repo", "diff"}, expected: "diff"},
+		"OptionsOnly": {args: []string{"--version"}, expected: ""},
+		"Empty":       {expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := subcommand(test.args); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Ways of handling a repository without any commits (an unborn HEAD)
+const (
+	// EmptyRepoInitialCommit creates an empty initial commit on the current branch
+	// before the session starts, so the original branch exists to return to.
+	EmptyRepoInitialCommit = "initial-commit"
+
+	// EmptyRepoRootCheckpoint starts the session as-is, making the first checkpoint the root commit.
+	EmptyRepoRootCheckpoint = "root-checkpoint"
+
+	// EmptyRepoFail refuses to start a session in a repository without commits.
+	EmptyRepoFail = "fail"
+)
+
+// EmptyRepoModes lists the accepted values of GitbakConfig.EmptyRepo
+var EmptyRepoModes = []string{EmptyRepoInitialCommit, EmptyRepoRootCheckpoint, EmptyRepoFail}
+
+// initialCommitMessage is the message of the commit created by EmptyRepoInitialCommit
+const initialCommitMessage = "Initial commit (created by gitbak)"
+
+// isUnbornHead reports whether HEAD points at a branch that has no commits yet,
+// as in a freshly initialized repository.
+func (g *Gitbak) isUnbornHead(ctx context.Context) (bool, error) {
+	_, err := g.runGitCommandWithOutput(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err == nil {
+		return false, nil
+	}
+
+	if exitCode(err) == 1 {
+		// Exit code 1 means HEAD does not resolve to a commit
+		return true, nil
+	}
+	return false, err
+}
+
+// handleEmptyRepository prepares a repository without commits according to the EmptyRepo setting
+func (g *Gitbak) handleEmptyRepository(ctx context.Context) error {
+	unborn, err := g.isUnbornHead(ctx)
+	if err != nil {
+		if gitbakErrors.Is(err, gitbakErrors.ErrGitOperationFailed) {
+			return err
+		}
+		return gitbakErrors.NewGitError("rev-parse", []string{"HEAD"}, gitbakErrors.Wrap(err, "failed to resolve HEAD"), "")
+	}
+	if !unborn || g.observeMode() {
+		// Observing changes needs no commit to build on
+		return nil
+	}
+
+	if g.stashMode() || g.refsMode() {
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"branch '%s' has no commits yet, and %s mode checkpoints need one to build on; make an initial commit first",
+			g.originalBranch, g.config.Mode)
+	}
+
+	switch g.config.EmptyRepo {
+	case EmptyRepoFail:
+		return gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"branch '%s' has no commits yet; make an initial commit or use -empty-repo %s",
+			g.originalBranch, EmptyRepoInitialCommit)
+
+	case EmptyRepoRootCheckpoint:
+		g.logger.InfoToUser("Branch '%s' has no commits yet - the first checkpoint will be the root commit", g.originalBranch)
+		return nil
+
+	default:
+		// --only without paths commits nothing, leaving any staged changes for the first checkpoint
+		args := []string{"--allow-empty", "--only", "-m", initialCommitMessage}
+		if g.config.NoVerify {
+			args = append(args, "--no-verify")
+		}
+		if err := g.runGitCommand(ctx, append([]string{"commit"}, args...)...); err != nil {
+			return gitbakErrors.NewGitError("commit", args, err, "failed to create initial commit")
+		}
+
+		head, err := g.runGitCommandWithOutput(ctx, "rev-parse", "HEAD")
+		if err == nil {
+			g.startCommit = strings.TrimSpace(head)
+		}
+		g.logger.StatusMessage("🌱 Created an empty initial commit on '%s'", g.originalBranch)
+		return nil
+	}
+}
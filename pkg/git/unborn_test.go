@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// setupEmptyTestRepo initializes a test git repository without any commits
+func setupEmptyTestRepo(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--initial-branch=main", tempDir},
+		{"-C", tempDir, "config", "user.email", "test@example.com"},
+		{"-C", tempDir, "config", "user.name", "Test User"},
+	} {
+		if err := exec.Command("git", args...).Run(); err != nil {
+			t.Fatalf("Failed to run git %v: %v", args, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "first.txt"), []byte("first"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	return tempDir
+}
+
+// TestEmptyRepositoryScenarios tests starting a session in a repository without commits
+func TestEmptyRepositoryScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mode         string
+		createBranch bool
+		expectErr    error
+		validateFunc func(t *testing.T, gb *Gitbak, repoPath string)
+	}{
+		"InitialCommit": {
+			mode:         EmptyRepoInitialCommit,
+			createBranch: true,
+			validateFunc: func(t *testing.T, gb *Gitbak, repoPath string) {
+				out, err := exec.Command("git", "-C", repoPath, "log", "--format=%s", "main").Output()
+				if err != nil {
+					t.Fatalf("Expected original branch to exist: %v", err)
+				}
+				if strings.TrimSpace(string(out)) != initialCommitMessage {
+					t.Errorf("Expected only the initial commit on main, got %q", out)
+				}
+				if gb.startCommit == "" {
+					t.Error("Expected the initial commit to be recorded as the session start")
+				}
+			},
+		},
+		"DefaultsToInitialCommit": {
+			mode:         "",
+			createBranch: false,
+			validateFunc: func(t *testing.T, gb *Gitbak, repoPath string) {
+				out, err := exec.Command("git", "-C", repoPath, "rev-list", "--count", "HEAD").Output()
+				if err != nil {
+					t.Fatalf("Failed to count commits: %v", err)
+				}
+				// The initial commit plus the first checkpoint
+				if strings.TrimSpace(string(out)) != "2" {
+					t.Errorf("Expected 2 commits, got %s", out)
+				}
+			},
+		},
+		"RootCheckpoint": {
+			mode:         EmptyRepoRootCheckpoint,
+			createBranch: true,
+			validateFunc: func(t *testing.T, gb *Gitbak, repoPath string) {
+				root, err := exec.Command("git", "-C", repoPath, "rev-list", "--max-parents=0", "HEAD").Output()
+				if err != nil {
+					t.Fatalf("Failed to find root commit: %v", err)
+				}
+				head, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+				if err != nil {
+					t.Fatalf("Failed to read HEAD: %v", err)
+				}
+				if string(root) != string(head) {
+					t.Errorf("Expected the first checkpoint to be the root commit")
+				}
+			},
+		},
+		"Fail": {
+			mode:         EmptyRepoFail,
+			createBranch: true,
+			expectErr:    gitbakErrors.ErrInvalidConfiguration,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupEmptyTestRepo(t)
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:       repoPath,
+				Interval:       time.Minute,
+				BranchName:     "gitbak-empty",
+				CommitPrefix:   "[gitbak-empty] Commit",
+				CreateBranch:   test.createBranch,
+				NonInteractive: true,
+				EmptyRepo:      test.mode,
+			}, logger.New(false, "", false))
+
+			ctx := context.Background()
+			err := gb.initialize(ctx)
+			if test.expectErr != nil {
+				if !gitbakErrors.Is(err, test.expectErr) {
+					t.Fatalf("Expected %v, got %v", test.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("initialize failed: %v", err)
+			}
+
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if !commitWasCreated {
+				t.Fatal("Expected a checkpoint to be created")
+			}
+
+			test.validateFunc(t, gb, repoPath)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// racyWindow is how recently a file may have been modified for its modification time to
+// be trusted to tell its versions apart: an edit within the same tick of a coarse clock
+// would otherwise go unnoticed
+const racyWindow = 2 * time.Second
+
+// changesFingerprint returns a fingerprint of the changes listed in status, the output of
+// git status --porcelain: every path it names, with its size and modification time, so that
+// further edits to a file that is already modified change it too. Whether a change is staged
+// plays no part, as a checkpoint stages everything regardless. It returns an empty string
+// when the changes cannot be told apart that way: when status lists a directory, such as a
+// submodule or an untracked directory git did not look into, or a file modified too recently.
+func (g *Gitbak) changesFingerprint(status string, now time.Time) string {
+	paths := statusPaths(status)
+	if len(paths) == 0 {
+		return ""
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, path := range paths {
+		info, err := os.Lstat(filepath.Join(g.config.RepoPath, filepath.FromSlash(path)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			_, _ = fmt.Fprintf(hash, "%s\x00deleted\x00", path)
+		case err != nil, info.IsDir(), !info.ModTime().Before(now.Add(-racyWindow)):
+			return ""
+		default:
+			_, _ = fmt.Fprintf(hash, "%s\x00%d\x00%d\x00%v\x00", path, info.Size(), info.ModTime().UnixNano(), info.Mode())
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// statusPaths returns every path named in status, the output of git status --porcelain,
+// both the original and the new path of a rename or copy
+func statusPaths(status string) []string {
+	var paths []string
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		entry := line[3:]
+		if line[0] == 'R' || line[0] == 'C' {
+			// The original path comes first
+			if from, to, ok := strings.Cut(entry, " -> "); ok {
+				paths = append(paths, statusPath(from), statusPath(to))
+				continue
+			}
+		}
+		paths = append(paths, statusPath(entry))
+	}
+	return paths
+}
+
+// statusPath returns a path as named by git status, which quotes unusual names C-style
+func statusPath(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	return name
+}
+
+// holdChanges remembers that the changes of the latest check were left uncommitted, so
+// that later checks can skip them while they stay the same; belowThreshold tells whether
+// they were held back for being below the change threshold
+func (g *Gitbak) holdChanges(belowThreshold bool) {
+	g.heldChanges = g.changesFingerprint(g.checkedStatus, time.Now())
+	g.heldBelowThreshold = belowThreshold
+}
+
+// changesStillHeld reports whether the changes of the latest check are the ones an earlier
+// check held back, so that nothing, not even staging them, needs to be done about them.
+// Changes held back below the change threshold still count towards MaxSkippedChecks, and
+// are checked as usual once they are due to be committed regardless.
+func (g *Gitbak) changesStillHeld() bool {
+	if g.heldChanges == "" || g.changesFingerprint(g.checkedStatus, time.Now()) != g.heldChanges {
+		g.heldChanges = ""
+		return false
+	}
+	if g.heldBelowThreshold && g.thresholdEnabled() {
+		if g.config.MaxSkippedChecks > 0 && g.skippedChecks >= g.config.MaxSkippedChecks {
+			return false
+		}
+		g.skippedChecks++
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestChangesStillHeld tests that changes a check left uncommitted are not staged and checked
+// again until they change, while changes held below the threshold still count towards
+// MaxSkippedChecks
+func TestChangesStillHeld(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		hook               bool
+		minLines           int
+		maxSkipped         int
+		recent             bool
+		expectHookRuns     int
+		expectCheckpointAt int
+	}{
+		"HookRejected": {
+			hook:           true,
+			expectHookRuns: 2,
+		},
+		"HookRejectedRecentChanges": {
+			hook:           true,
+			recent:         true,
+			expectHookRuns: 4,
+		},
+		"BelowThreshold": {
+			minLines:           100,
+			maxSkipped:         2,
+			expectCheckpointAt: 3,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if test.hook && runtime.GOOS == "windows" {
+				t.Skip("Hooks are shell scripts")
+			}
+
+			repoPath := setupTestRepo(t)
+			runs := filepath.Join(t.TempDir(), "runs")
+			if test.hook {
+				writeHook(t, repoPath, "pre-commit", "echo run >> '"+runs+"'\nexit 1\n")
+			}
+			gb := setupTestGitbak(GitbakConfig{
+				RepoPath:         repoPath,
+				Interval:         time.Minute,
+				BranchName:       "gitbak-held",
+				CommitPrefix:     "[gitbak-held] Checkpoint",
+				NonInteractive:   true,
+				MinChangedLines:  test.minLines,
+				MaxSkippedChecks: test.maxSkipped,
+			}, logger.New(false, "", false))
+
+			// Three checks of the same change, then one after it is edited again
+			edit := func(content string) {
+				path := filepath.Join(repoPath, "initial.txt")
+				writeTestFile(t, path, content)
+				if !test.recent {
+					old := time.Now().Add(-time.Minute)
+					if err := os.Chtimes(path, old, old); err != nil {
+						t.Fatalf("Failed to date the change: %v", err)
+					}
+				}
+			}
+			edit("changed\n")
+			checkpointAt := 0
+			for check := 1; check <= 4; check++ {
+				if check == 4 {
+					edit("changed again\n")
+				}
+				var created bool
+				err := gb.checkAndCommitChanges(context.Background(), 1, &created)
+				if err != nil && !test.hook {
+					t.Fatalf("Check %d failed: %v", check, err)
+				}
+				if err == nil && created && checkpointAt == 0 {
+					checkpointAt = check
+				}
+			}
+
+			if test.hook {
+				data, _ := os.ReadFile(runs)
+				if got := strings.Count(string(data), "run"); got != test.expectHookRuns {
+					t.Errorf("Expected the hook to run %d times, got %d", test.expectHookRuns, got)
+				}
+			}
+			if checkpointAt != test.expectCheckpointAt {
+				t.Errorf("Expected the checkpoint at check %d, got %d", test.expectCheckpointAt, checkpointAt)
+			}
+		})
+	}
+}
+
+// TestChangesFingerprint tests what tells changes apart
+func TestChangesFingerprint(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupTestRepo(t)
+	gb := setupTestGitbak(GitbakConfig{RepoPath: repoPath, Interval: time.Minute, BranchName: "gitbak-fingerprint", CommitPrefix: "[gitbak] Checkpoint"}, logger.New(false, "", false))
+	old := time.Now().Add(-time.Minute)
+	for _, name := range []string{"a.txt", "b c.txt"} {
+		path := filepath.Join(repoPath, name)
+		writeTestFile(t, path, "content\n")
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("Failed to date %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(repoPath, "dir"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	now := time.Now()
+	unstaged := gb.changesFingerprint(" M a.txt\n?? \"b c.txt\"\n", now)
+	if unstaged == "" {
+		t.Fatal("Expected a fingerprint")
+	}
+
+	tests := map[string]struct {
+		status string
+		now    time.Time
+		same   bool
+		empty  bool
+	}{
+		"Staged": {
+			status: "M  a.txt\nA  \"b c.txt\"\n",
+			now:    now,
+			same:   true,
+		},
+		"Renamed": {
+			status: "R  a.txt -> \"b c.txt\"\n",
+			now:    now,
+			same:   true,
+		},
+		"OtherFiles": {
+			status: " M a.txt\n D gone.txt\n",
+			now:    now,
+		},
+		"Directory": {
+			status: "?? dir/\n",
+			now:    now,
+			empty:  true,
+		},
+		"RecentlyModified": {
+			status: " M a.txt\n",
+			now:    old.Add(time.Second),
+			empty:  true,
+		},
+		"Clean": {
+			now:   now,
+			empty: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fingerprint := gb.changesFingerprint(test.status, test.now)
+			switch {
+			case test.empty && fingerprint != "":
+				t.Errorf("Expected no fingerprint, got %q", fingerprint)
+			case !test.empty && (fingerprint == unstaged) != test.same:
+				t.Errorf("Expected the fingerprint to match the unstaged changes: %v", test.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Untracked file policies, selecting whether new files are taken into checkpoints
+const (
+	// UntrackedPolicyAll takes new files into checkpoints like changes to tracked files.
+	UntrackedPolicyAll = "all"
+
+	// UntrackedPolicyNone leaves new files out, so only changes to tracked files are
+	// checkpointed. A new file is taken in once it is added to the index by hand.
+	UntrackedPolicyNone = "none"
+
+	// UntrackedPolicyPrompt asks about each new file, or new directory, the first time it is
+	// found, and leaves it out without asking when NonInteractive is set.
+	UntrackedPolicyPrompt = "prompt"
+)
+
+// UntrackedPolicies lists the accepted values of GitbakConfig.UntrackedPolicy
+var UntrackedPolicies = []string{UntrackedPolicyAll, UntrackedPolicyNone, UntrackedPolicyPrompt}
+
+// untrackedPaths lists the new files and directories, not ignored, that UntrackedPolicy
+// leaves out of checkpoints; new directories are listed as a whole, with a trailing slash.
+// Paths already covered by excluded are not considered. It returns nothing under
+// UntrackedPolicyAll.
+func (g *Gitbak) untrackedPaths(ctx context.Context, excluded []string) ([]string, error) {
+	if g.config.UntrackedPolicy == "" || g.config.UntrackedPolicy == UntrackedPolicyAll {
+		return nil, nil
+	}
+
+	args := append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--directory"}, g.scopeArgs()...)
+	out, err := g.runGitCommandWithOutput(ctx, args...)
+	if err != nil {
+		return nil, gitbakErrors.NewGitError("ls-files", []string{"--others", "--directory"},
+			gitbakErrors.Wrap(err, "failed to list new files"), "")
+	}
+
+	var paths []string
+	for _, path := range strings.Split(out, "\x00") {
+		if path == "" || isExcluded(path, excluded) {
+			continue
+		}
+		if g.config.UntrackedPolicy == UntrackedPolicyNone || g.skipUntracked(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// skipUntracked decides whether the new file or directory at path is left out of
+// checkpoints under UntrackedPolicyPrompt. Interactive sessions ask the user; others
+// always leave it out. The decision is made once per path, and holds for new files
+// found inside a directory it was made for.
+func (g *Gitbak) skipUntracked(path string) bool {
+	if skip, decided := g.untracked[path]; decided {
+		return skip
+	}
+	for dir, skip := range g.untracked {
+		if strings.HasSuffix(dir, "/") && strings.HasPrefix(path, dir) {
+			return skip
+		}
+	}
+
+	skip := true
+	if !g.config.NonInteractive {
+		skip = !g.promptYesNo(fmt.Sprintf("%s is new. Include it in checkpoints?", path))
+	}
+	if skip {
+		g.logger.InfoToUser("Leaving %s out of checkpoints; add it with 'git add' to include it from then on", path)
+	}
+
+	if g.untracked == nil {
+		g.untracked = make(map[string]bool)
+	}
+	g.untracked[path] = skip
+	return skip
+}
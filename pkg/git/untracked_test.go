@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// TestUntrackedPolicy tests that UntrackedPolicy decides whether new files are checkpointed
+func TestUntrackedPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy         string
+		nonInteractive bool
+		response       bool
+		expectPrompt   bool
+		expectIncluded bool
+	}{
+		"All": {
+			policy:         UntrackedPolicyAll,
+			expectIncluded: true,
+		},
+		"None": {
+			policy: UntrackedPolicyNone,
+		},
+		"PromptAccepted": {
+			policy:         UntrackedPolicyPrompt,
+			response:       true,
+			expectPrompt:   true,
+			expectIncluded: true,
+		},
+		"PromptDeclined": {
+			policy:       UntrackedPolicyPrompt,
+			expectPrompt: true,
+		},
+		"PromptNonInteractive": {
+			policy:         UntrackedPolicyPrompt,
+			nonInteractive: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupTestRepo(t)
+			base := gitOutput(t, repoPath, "rev-parse", "HEAD")
+			writeRepoFile(t, repoPath, "initial.txt", "changed\n")
+			writeRepoFile(t, repoPath, "scratch/one.txt", "one\n")
+
+			interactor := NewMockInteractor(test.response)
+			gb, err := NewGitbakWithDeps(GitbakConfig{
+				RepoPath:        repoPath,
+				Interval:        time.Minute,
+				BranchName:      "gitbak-untracked",
+				CommitPrefix:    "[gitbak-untracked] Commit",
+				NonInteractive:  test.nonInteractive,
+				UntrackedPolicy: test.policy,
+			}, logger.New(false, "", false), NewExecutor(""), interactor)
+			if err != nil {
+				t.Fatalf("NewGitbakWithDeps failed: %v", err)
+			}
+
+			ctx := context.Background()
+			var commitWasCreated bool
+			if err := gb.checkAndCommitChanges(ctx, 1, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if !commitWasCreated {
+				t.Fatal("Expected the change to the tracked file to be checkpointed")
+			}
+			if interactor.PromptYesNoCalled != test.expectPrompt {
+				t.Errorf("Expected a prompt: %v, got %v", test.expectPrompt, interactor.PromptYesNoCalled)
+			}
+
+			// A new file in the same directory is decided with it, without asking again
+			interactor.PromptYesNoCalled = false
+			writeRepoFile(t, repoPath, "initial.txt", "changed again\n")
+			writeRepoFile(t, repoPath, "scratch/two.txt", "two\n")
+			if err := gb.checkAndCommitChanges(ctx, 2, &commitWasCreated); err != nil {
+				t.Fatalf("checkAndCommitChanges failed: %v", err)
+			}
+			if interactor.PromptYesNoCalled {
+				t.Error("Expected no second prompt about the same directory")
+			}
+
+			changed := gitOutput(t, repoPath, "diff", "--name-only", base, "HEAD")
+			for _, file := range []string{"scratch/one.txt", "scratch/two.txt"} {
+				if included := strings.Contains(changed, file); included != test.expectIncluded {
+					t.Errorf("Expected the checkpoints to include %s: %v, got %v (they changed %q)", file, test.expectIncluded, included, changed)
+				}
+			}
+			if status := gitOutput(t, repoPath, "status", "--porcelain"); !test.expectIncluded && !strings.Contains(status, "?? scratch/") {
+				t.Errorf("Expected the new files to stay untracked, got status %q", status)
+			}
+
+			// Once added by hand, a new file is checkpointed like any tracked file
+			if !test.expectIncluded {
+				gitOutput(t, repoPath, "add", "scratch/one.txt")
+				if err := gb.checkAndCommitChanges(ctx, 3, &commitWasCreated); err != nil {
+					t.Fatalf("checkAndCommitChanges failed: %v", err)
+				}
+				if changed := gitOutput(t, repoPath, "show", "--name-only", "--format=", "HEAD"); !strings.Contains(changed, "scratch/one.txt") {
+					t.Errorf("Expected the added file in the last checkpoint, got %q", changed)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"context"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// VerifySession checks the session branch against the integrity chain recorded in state
+// and returns the number of checkpoints verified.
+//
+// Each checkpoint must still exist with its recorded tree, still be part of the session
+// branch, and hash to the recorded link. Checkpoints that carry a Gitbak-Chain trailer
+// must also vouch for the preceding link. Any mismatch is reported as ErrIntegrityViolation.
+func (r *Repository) VerifySession(ctx context.Context, state *session.State) (int, error) {
+	if len(state.Chain) == 0 {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"session on '%s' has no integrity chain to verify", state.Branch)
+	}
+
+	prev := state.StartCommit
+	for i, link := range state.Chain {
+		n := i + 1
+
+		if session.ChainHash(prev, link.Commit, link.Tree) != link.Hash {
+			return i, gitbakErrors.Wrapf(gitbakErrors.ErrIntegrityViolation,
+				"checkpoint %d (%s): recorded hash does not match the chain", n, link.Commit)
+		}
+
+		tree, err := r.output(ctx, "rev-parse", "--verify", "--quiet", link.Commit+"^{tree}")
+		if err != nil {
+			return i, gitbakErrors.Wrapf(gitbakErrors.ErrIntegrityViolation,
+				"checkpoint %d (%s) no longer exists", n, link.Commit)
+		}
+		if tree != link.Tree {
+			return i, gitbakErrors.Wrapf(gitbakErrors.ErrIntegrityViolation,
+				"checkpoint %d (%s): tree %s does not match recorded tree %s", n, link.Commit, tree, link.Tree)
+		}
+
+		if err := r.run(ctx, "merge-base", "--is-ancestor", link.Commit, state.Branch); err != nil {
+			return i, gitbakErrors.Wrapf(gitbakErrors.ErrIntegrityViolation,
+				"checkpoint %d (%s) is no longer part of branch '%s'", n, link.Commit, state.Branch)
+		}
+
+		trailer, err := r.output(ctx, "log", "-1", "--format=%(trailers:key="+session.ChainTrailer+",valueonly)", link.Commit)
+		if err != nil {
+			return i, gitbakErrors.NewGitError("log", []string{link.Commit},
+				gitbakErrors.Wrap(err, "failed to read checkpoint trailers"), "")
+		}
+		if trailer != "" && trailer != prev {
+			return i, gitbakErrors.Wrapf(gitbakErrors.ErrIntegrityViolation,
+				"checkpoint %d (%s): %s trailer does not match the preceding checkpoint", n, link.Commit, session.ChainTrailer)
+		}
+
+		prev = link.Hash
+	}
+
+	return len(state.Chain), nil
+}
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+	"github.com/bashhack/gitbak/pkg/session"
+)
+
+// setupChainedSession runs a two-checkpoint session with the integrity chain and trailers enabled
+func setupChainedSession(t *testing.T) (string, string) {
+	t.Helper()
+
+	repoPath := setupTestRepo(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	ctx := context.Background()
+
+	gb := setupTestGitbak(GitbakConfig{
+		RepoPath:       repoPath,
+		Interval:       time.Minute,
+		BranchName:     "gitbak-verify-test",
+		CommitPrefix:   "[gitbak]",
+		CreateBranch:   true,
+		NonInteractive: true,
+		StateFile:      stateFile,
+		ChainTrailer:   true,
+	}, logger.New(false, "", false))
+
+	if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("one"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := gb.RunSingleIteration(ctx); err != nil {
+		t.Fatalf("RunSingleIteration failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("two"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := gb.createCommit(ctx, 2); err != nil {
+		t.Fatalf("createCommit failed: %v", err)
+	}
+
+	return repoPath, stateFile
+}
+
+// TestVerifySession tests detection of post-hoc rewrites of a session branch
+func TestVerifySession(t *testing.T) {
+	tests := map[string]struct {
+		tamper        func(t *testing.T, repoPath string, state *session.State)
+		expectedCount int
+		expectedErr   error
+		errorContains string
+	}{
+		"Untouched": {
+			tamper:        func(t *testing.T, repoPath string, state *session.State) {},
+			expectedCount: 2,
+		},
+		"AmendedCheckpoint": {
+			tamper: func(t *testing.T, repoPath string, state *session.State) {
+				if err := exec.Command("git", "-C", repoPath, "commit", "--amend", "-m", "rewritten").Run(); err != nil {
+					t.Fatalf("Failed to amend: %v", err)
+				}
+			},
+			expectedCount: 1,
+			expectedErr:   gitbakErrors.ErrIntegrityViolation,
+			errorContains: "checkpoint 2",
+		},
+		"DroppedCheckpoint": {
+			tamper: func(t *testing.T, repoPath string, state *session.State) {
+				if err := exec.Command("git", "-C", repoPath, "reset", "--hard", "HEAD~1").Run(); err != nil {
+					t.Fatalf("Failed to reset: %v", err)
+				}
+			},
+			expectedCount: 1,
+			expectedErr:   gitbakErrors.ErrIntegrityViolation,
+			errorContains: "no longer part of branch",
+		},
+		"EditedState": {
+			tamper: func(t *testing.T, repoPath string, state *session.State) {
+				state.Chain[0].Tree = state.Chain[1].Tree
+			},
+			expectedCount: 0,
+			expectedErr:   gitbakErrors.ErrIntegrityViolation,
+			errorContains: "checkpoint 1",
+		},
+		"NoChain": {
+			tamper: func(t *testing.T, repoPath string, state *session.State) {
+				state.Chain = nil
+			},
+			expectedErr: gitbakErrors.ErrInvalidConfiguration,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoPath, stateFile := setupChainedSession(t)
+
+			state, err := session.Load(stateFile)
+			if err != nil {
+				t.Fatalf("Failed to load state: %v", err)
+			}
+			if len(state.Chain) != 2 {
+				t.Fatalf("Expected 2 chain links, got %d", len(state.Chain))
+			}
+
+			test.tamper(t, repoPath, state)
+
+			count, err := NewRepository(repoPath, nil).VerifySession(context.Background(), state)
+
+			if test.expectedErr != nil {
+				if !gitbakErrors.Is(err, test.expectedErr) {
+					t.Fatalf("Expected %v, got %v", test.expectedErr, err)
+				}
+				if test.errorContains != "" && !strings.Contains(err.Error(), test.errorContains) {
+					t.Errorf("Expected error containing %q, got %q", test.errorContains, err.Error())
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if count != test.expectedCount {
+				t.Errorf("Expected %d verified checkpoints, got %d", test.expectedCount, count)
+			}
+		})
+	}
+}
+
+// TestChainTrailer tests that checkpoint commits vouch for the preceding link
+func TestChainTrailer(t *testing.T) {
+	repoPath, stateFile := setupChainedSession(t)
+
+	state, err := session.Load(stateFile)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repoPath, "log", "-1", "--format=%(trailers:key=Gitbak-Chain,valueonly)").Output()
+	if err != nil {
+		t.Fatalf("Failed to read trailer: %v", err)
+	}
+
+	if trailer := strings.TrimSpace(string(out)); trailer != state.Chain[0].Hash {
+		t.Errorf("Expected latest checkpoint trailer %q, got %q", state.Chain[0].Hash, trailer)
+	}
+}
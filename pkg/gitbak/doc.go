@@ -0,0 +1,25 @@
+// Package gitbak embeds gitbak's automatic checkpointing in other Go programs, such as
+// editor backends or coding agents, without running the gitbak command.
+//
+// A Session checkpoints one repository in the background, with the same defaults as
+// the gitbak command, until it is stopped:
+//
+//	session, err := gitbak.New(gitbak.Options{RepoPath: "/path/to/repo", Interval: time.Minute})
+//	if err != nil {
+//	    return err
+//	}
+//	session.OnCommit(func(checkpoint gitbak.Checkpoint) {
+//	    fmt.Printf("checkpoint #%d: %s\n", checkpoint.Number, checkpoint.Commit)
+//	})
+//	if err := session.Start(ctx); err != nil {
+//	    return err
+//	}
+//	defer session.Stop()
+//
+// Stopping a session, or canceling the context it was started with, makes a last
+// checkpoint of the changes since the previous check, as the gitbak command does when
+// interrupted. Sessions are non-interactive and, unless given a Logger, silent.
+//
+// Options covers what most programs need; Options.Configure reaches every setting of
+// the underlying git.GitbakConfig, such as secret scanning or pushing checkpoints.
+package gitbak
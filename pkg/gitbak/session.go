@@ -0,0 +1,284 @@
+package gitbak
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/config"
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+	"github.com/bashhack/gitbak/pkg/lock"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// ErrStarted is returned by Start for a session that has already been started
+var ErrStarted = gitbakErrors.New("session has already been started")
+
+// Checkpoint describes a checkpoint a session made, as passed to OnCommit handlers
+type Checkpoint = git.Checkpoint
+
+// Options configures a Session. Only RepoPath is required; the rest default to what
+// the gitbak command uses.
+type Options struct {
+	// RepoPath is the repository to checkpoint
+	RepoPath string
+
+	// Interval is how often the repository is checked for changes (default 5 minutes)
+	Interval time.Duration
+
+	// BranchName is the branch created for the session's checkpoints (default gitbak-<timestamp>)
+	BranchName string
+
+	// CommitPrefix starts every checkpoint's commit message (default "[gitbak] Automatic checkpoint")
+	CommitPrefix string
+
+	// NoBranch commits checkpoints onto the current branch instead of creating BranchName
+	NoBranch bool
+
+	// Continue resumes the gitbak session on the current branch, numbering checkpoints on from its last one
+	Continue bool
+
+	// NoFinalCheckpoint stops the session without checkpointing the changes made since the last check
+	NoFinalCheckpoint bool
+
+	// Logger receives the session's messages; if nil, they are discarded
+	Logger logger.Logger
+
+	// Configure, if set, adjusts the configuration built from the options above before it is
+	// validated, to reach settings Options does not cover. An OnCheckpoint it sets is called
+	// after the OnCommit handlers.
+	Configure func(*git.GitbakConfig)
+}
+
+// Stats is a snapshot of a session's progress
+type Stats struct {
+	// Running is set from Start until the session has stopped
+	Running bool
+
+	// StartTime is when the session was started
+	StartTime time.Time
+
+	// Checkpoints is how many checkpoints the session has made
+	Checkpoints int
+
+	// LastCheckpoint is the latest checkpoint made, or the zero Checkpoint if there is none yet
+	LastCheckpoint Checkpoint
+
+	// Err is why the session ended, if it failed rather than being stopped
+	Err error
+}
+
+// Session checkpoints a repository in the background, as a gitbak command would.
+// Its methods are safe for concurrent use.
+type Session struct {
+	opts     Options
+	repoPath string
+	engine   *git.Gitbak
+	executor git.CommandExecutor
+	locker   *lock.Locker
+	logger   logger.Logger
+
+	mu       sync.Mutex
+	handlers []func(Checkpoint)
+	stats    Stats
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New creates a session for the repository opts describe, without starting it.
+// It returns an error if the options are invalid.
+func New(opts Options) (*Session, error) {
+	if opts.RepoPath == "" {
+		return nil, gitbakErrors.NewConfigError("repoPath", "", gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration, "a repository path is required"))
+	}
+	repoPath, err := filepath.Abs(opts.RepoPath)
+	if err != nil {
+		return nil, gitbakErrors.NewConfigError("repoPath", opts.RepoPath, gitbakErrors.Wrap(err, "failed to resolve repository path"))
+	}
+
+	if opts.Interval == 0 {
+		opts.Interval = config.DefaultInterval
+	}
+	if opts.CommitPrefix == "" {
+		opts.CommitPrefix = config.DefaultCommitPrefix
+	}
+	if opts.BranchName == "" {
+		opts.BranchName = fmt.Sprintf("gitbak-%s", time.Now().Format("20060102-150405"))
+	}
+	log := opts.Logger
+	if log == nil {
+		log = logger.NewWithOutput(false, "", false, io.Discard, io.Discard)
+	}
+
+	s := &Session{opts: opts, repoPath: repoPath, logger: log}
+	cfg := git.GitbakConfig{
+		RepoPath:         repoPath,
+		Interval:         opts.Interval,
+		BranchName:       opts.BranchName,
+		CommitPrefix:     opts.CommitPrefix,
+		CreateBranch:     !opts.NoBranch,
+		ContinueSession:  opts.Continue,
+		Verbose:          true,
+		NonInteractive:   true,
+		MaxRetries:       config.DefaultMaxRetries,
+		OpTimeout:        config.DefaultOpTimeout,
+		CommandTimeout:   config.DefaultCommandTimeout,
+		RetryBackoff:     config.DefaultRetryBackoff,
+		RetryBackoffMax:  config.DefaultRetryBackoffMax,
+		MaxSkippedChecks: config.DefaultMaxSkippedChecks,
+		OnDiverge:        git.DivergeWarn,
+		Secrets:          git.SecretsSkip,
+	}
+	if opts.Configure != nil {
+		opts.Configure(&cfg)
+		if cfg.Backend == git.BackendGoGit && cfg.Secrets == git.SecretsSkip {
+			// As with the gitbak command, gogit cannot scan changes, so it does without unless asked
+			cfg.Secrets = git.SecretsOff
+		}
+	}
+	next := cfg.OnCheckpoint
+	cfg.OnCheckpoint = func(checkpoint git.Checkpoint) {
+		s.recordCheckpoint(checkpoint)
+		if next != nil {
+			next(checkpoint)
+		}
+	}
+
+	s.executor = git.NewExecutor(cfg.Backend)
+	if cfg.Backend != git.BackendGoGit {
+		s.executor = git.NewExecExecutorWithOptions(git.ExecOptions{GitPath: cfg.GitPath, GlobalArgs: cfg.GitGlobalArgs})
+	}
+	s.engine, err = git.NewGitbak(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	s.locker, err = lock.New(repoPath)
+	if err != nil {
+		return nil, gitbakErrors.Wrap(err, "failed to initialize lock")
+	}
+	return s, nil
+}
+
+// OnCommit registers fn to be called after every checkpoint the session makes, in the
+// order handlers were registered. Handlers run on the session's goroutine, so a slow
+// one delays the next check, and must not call Stop or Wait.
+func (s *Session) OnCommit(fn func(Checkpoint)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, fn)
+}
+
+// Start starts checkpointing in the background and returns once the session holds the
+// repository's lock, which keeps other gitbak sessions off it. The session runs until
+// Stop is called or ctx is canceled; a session can only be started once.
+func (s *Session) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return ErrStarted
+	}
+
+	isRepo, err := git.IsRepositoryWith(ctx, s.repoPath, s.executor)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to check repository")
+	}
+	if !isRepo {
+		return gitbakErrors.NewGitError("rev-parse", []string{"--is-inside-work-tree"}, gitbakErrors.ErrNotGitRepository, "")
+	}
+	if err := s.locker.Acquire(); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.stats.Running = true
+	s.stats.StartTime = time.Now()
+	go s.run(runCtx)
+	return nil
+}
+
+// Stop stops a started session, making a final checkpoint unless NoFinalCheckpoint is
+// set, and waits for it to finish. It returns what Wait returns.
+func (s *Session) Stop() error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	return s.Wait()
+}
+
+// Wait waits for a started session to stop, and returns the error it failed with,
+// if any. It returns nil at once for a session that was never started.
+func (s *Session) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+	<-done
+	return s.Stats().Err
+}
+
+// Stats returns a snapshot of the session's progress
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// run checkpoints until ctx is canceled or the session fails, then releases the lock
+func (s *Session) run(ctx context.Context) {
+	err := s.engine.Run(ctx)
+
+	// Stopped rather than failed, so keep the work done since the last check, and push
+	// the checkpoints a push interval would have left behind
+	if ctx.Err() != nil && gitbakErrors.Is(err, context.Canceled) {
+		finalCtx, cancel := context.WithTimeout(context.Background(), config.DefaultShutdownTimeout)
+		if !s.opts.NoFinalCheckpoint {
+			if err := s.engine.FinalCheckpoint(finalCtx); err != nil {
+				s.logger.WarningToUser("Failed to make a final checkpoint: %v", err)
+			}
+		}
+		if err := s.engine.PushPending(finalCtx); err != nil {
+			s.logger.WarningToUser("Failed to push at exit: %v", err)
+		}
+		cancel()
+	}
+	s.engine.RecordHistory()
+
+	if err := s.locker.Release(); err != nil {
+		s.logger.Warning("Failed to release lock: %v", err)
+	}
+
+	s.mu.Lock()
+	s.stats.Running = false
+	if err != nil && !gitbakErrors.Is(err, context.Canceled) {
+		s.stats.Err = err
+	}
+	done := s.done
+	s.mu.Unlock()
+	close(done)
+}
+
+// recordCheckpoint counts a checkpoint in the session's stats and passes it to the OnCommit handlers
+func (s *Session) recordCheckpoint(checkpoint Checkpoint) {
+	s.mu.Lock()
+	s.stats.Checkpoints++
+	s.stats.LastCheckpoint = checkpoint
+	handlers := append([]func(Checkpoint){}, s.handlers...)
+	s.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(checkpoint)
+	}
+}
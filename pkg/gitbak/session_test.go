@@ -0,0 +1,207 @@
+package gitbak
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/git"
+)
+
+// setupRepo creates a repository with one commit
+func setupRepo(t *testing.T) string {
+	t.Helper()
+
+	repoPath := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	} {
+		gitOutput(t, repoPath, args...)
+	}
+	return repoPath
+}
+
+// gitOutput runs git in repoPath and returns its trimmed output
+func gitOutput(t *testing.T, repoPath string, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// TestSession tests a session from start to stop
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	repoPath := setupRepo(t)
+	session, err := New(Options{
+		RepoPath:   repoPath,
+		Interval:   50 * time.Millisecond,
+		BranchName: "gitbak-embedded",
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	commits := make(chan Checkpoint, 10)
+	session.OnCommit(func(checkpoint Checkpoint) { commits <- checkpoint })
+
+	if err := session.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := session.Start(context.Background()); !gitbakErrors.Is(err, ErrStarted) {
+		t.Errorf("Expected starting twice to fail with ErrStarted, got %v", err)
+	}
+	if !session.Stats().Running {
+		t.Error("Expected the session to be running")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("first"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	var first Checkpoint
+	select {
+	case first = <-commits:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected a checkpoint")
+	}
+	if first.Number != 1 || first.Branch != "gitbak-embedded" {
+		t.Errorf("Expected checkpoint #1 on gitbak-embedded, got #%d on %q", first.Number, first.Branch)
+	}
+	if head := gitOutput(t, repoPath, "rev-parse", "gitbak-embedded"); first.Commit != head {
+		t.Errorf("Expected the checkpoint's commit to be %s, got %s", head, first.Commit)
+	}
+
+	// Stopping checkpoints what changed since
+	if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("second"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := session.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if status := gitOutput(t, repoPath, "status", "--porcelain"); status != "" {
+		t.Errorf("Expected every change to be checkpointed, got status %q", status)
+	}
+
+	stats := session.Stats()
+	if stats.Running {
+		t.Error("Expected the session to have stopped")
+	}
+	if stats.Checkpoints < 2 || stats.LastCheckpoint.Number != stats.Checkpoints {
+		t.Errorf("Expected the stats to count the checkpoints, got %+v", stats)
+	}
+	if log := gitOutput(t, repoPath, "log", "--format=%s", "gitbak-embedded"); strings.Count(log, "[gitbak] Automatic checkpoint") != stats.Checkpoints {
+		t.Errorf("Expected %d checkpoints on the branch, got log %q", stats.Checkpoints, log)
+	}
+}
+
+// TestSessionFinalCheckpoint tests that stopping a session keeps the changes made since the last check
+func TestSessionFinalCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		noFinalCheckpoint bool
+		expectCheckpoints int
+	}{
+		"Default":           {expectCheckpoints: 1},
+		"NoFinalCheckpoint": {noFinalCheckpoint: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := setupRepo(t)
+			session, err := New(Options{
+				RepoPath:          repoPath,
+				Interval:          time.Hour,
+				NoBranch:          true,
+				NoFinalCheckpoint: test.noFinalCheckpoint,
+			})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if err := session.Start(ctx); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			// Let the session start up before there is anything to commit
+			time.Sleep(200 * time.Millisecond)
+			if err := os.WriteFile(filepath.Join(repoPath, "work.txt"), []byte("work"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			// Canceling the context stops the session like Stop does
+			cancel()
+			if err := session.Wait(); err != nil {
+				t.Fatalf("Wait failed: %v", err)
+			}
+			if checkpoints := session.Stats().Checkpoints; checkpoints != test.expectCheckpoints {
+				t.Errorf("Expected %d checkpoints, got %d", test.expectCheckpoints, checkpoints)
+			}
+		})
+	}
+}
+
+// TestSessionErrors tests that invalid sessions are refused
+func TestSessionErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		opts       Options
+		startError bool
+	}{
+		"MissingRepoPath": {
+			opts: Options{},
+		},
+		"InvalidConfiguration": {
+			opts: Options{
+				RepoPath:  t.TempDir(),
+				Configure: func(c *git.GitbakConfig) { c.MaxRetries = -1 },
+			},
+		},
+		"NotARepository": {
+			opts:       Options{RepoPath: t.TempDir()},
+			startError: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			session, err := New(test.opts)
+			if !test.startError {
+				if err == nil {
+					t.Fatal("Expected New to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			err = session.Start(context.Background())
+			if !gitbakErrors.Is(err, gitbakErrors.ErrNotGitRepository) {
+				t.Errorf("Expected ErrNotGitRepository, got %v", err)
+			}
+			if err := session.Stop(); err != nil {
+				t.Errorf("Expected stopping a session that never started to succeed, got %v", err)
+			}
+		})
+	}
+}
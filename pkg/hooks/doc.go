@@ -0,0 +1,35 @@
+// Package hooks runs user commands when something happens in a gitbak session.
+//
+// # Events
+//
+// A command can be configured for each of these events:
+//
+//   - start: The session has been set up and checks are about to begin (-on-start)
+//   - commit: A checkpoint was made (-on-commit)
+//   - error: A check failed (-on-error)
+//   - stop: The session has ended, whether stopped or failed (-on-stop)
+//
+// # Environment
+//
+// Commands run with the platform's shell (sh -c, or cmd /C on Windows) in the
+// repository, with the event described by these environment variables in addition
+// to gitbak's own environment:
+//
+//   - GITBAK_EVENT: The event's name
+//   - GITBAK_REPO: The repository's path
+//   - GITBAK_BRANCH: The branch checkpoints are committed to, if any
+//   - GITBAK_COMMIT: The checkpoint's commit (commit, and stop after a checkpoint)
+//   - GITBAK_COUNTER: The checkpoint's number (commit, and stop after a checkpoint)
+//   - GITBAK_ERROR: What went wrong (error, and stop when the session failed)
+//
+// # Usage
+//
+// A Runner starts each command without waiting for it, so a slow hook never holds
+// up checkpoints, and reports commands that fail or run longer than Timeout:
+//
+//	runner := hooks.New(map[string]string{hooks.EventCommit: "./notify.sh"}, func(event string, err error) {
+//		log.WarningToUser("The %s hook failed: %v", event, err)
+//	})
+//	runner.Run(hooks.Event{Name: hooks.EventCommit, Repo: repoPath, Counter: 1})
+//	defer runner.Wait(ctx)
+package hooks
@@ -0,0 +1,164 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Events a command can be configured for
+const (
+	// EventStart is run once the session has been set up
+	EventStart = "start"
+
+	// EventCommit is run after every checkpoint
+	EventCommit = "commit"
+
+	// EventError is run after every failed check
+	EventError = "error"
+
+	// EventStop is run once the session has ended
+	EventStop = "stop"
+)
+
+// Timeout is how long a command may run before it is killed
+const Timeout = time.Minute
+
+// maxOutput is how much of a failed command's output is kept for its error
+const maxOutput = 512
+
+// Event describes something that happened in a session
+type Event struct {
+	// Name is which event this is, such as EventCommit
+	Name string
+
+	// Repo is the repository's path; commands run in it
+	Repo string
+
+	// Branch is the branch checkpoints are committed to, if any
+	Branch string
+
+	// Commit is the hash of the checkpoint's commit, if there is one
+	Commit string
+
+	// Counter is the checkpoint's number, or 0 if there is none
+	Counter int
+
+	// Error is what went wrong, if anything
+	Error string
+}
+
+// Env returns the GITBAK_* environment variables describing e, leaving out those it has no value for
+func (e Event) Env() []string {
+	env := []string{"GITBAK_EVENT=" + e.Name, "GITBAK_REPO=" + e.Repo}
+	if e.Branch != "" {
+		env = append(env, "GITBAK_BRANCH="+e.Branch)
+	}
+	if e.Commit != "" {
+		env = append(env, "GITBAK_COMMIT="+e.Commit)
+	}
+	if e.Counter > 0 {
+		env = append(env, "GITBAK_COUNTER="+strconv.Itoa(e.Counter))
+	}
+	if e.Error != "" {
+		env = append(env, "GITBAK_ERROR="+e.Error)
+	}
+	return env
+}
+
+// Runner runs the configured command for each event in the background.
+// Its methods are safe for concurrent use, and a nil Runner runs nothing.
+type Runner struct {
+	commands map[string]string
+	onError  func(event string, err error)
+	timeout  time.Duration
+	wg       sync.WaitGroup
+}
+
+// New creates a Runner for commands, keyed by event name, which calls onError
+// with the event of every command that fails or times out
+func New(commands map[string]string, onError func(event string, err error)) *Runner {
+	return &Runner{commands: commands, onError: onError, timeout: Timeout}
+}
+
+// Run starts the command configured for e's event, if there is one, without waiting for it
+func (r *Runner) Run(e Event) {
+	if r == nil || r.commands[e.Name] == "" {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := r.run(r.commands[e.Name], e); err != nil && r.onError != nil {
+			r.onError(e.Name, err)
+		}
+	}()
+}
+
+// Wait waits for the commands that are still running to finish, or returns
+// ctx's error if it is done first
+func (r *Runner) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run runs command for e and waits for it to finish
+func (r *Runner) run(command string, e Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	name, args := shell(runtime.GOOS, command)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = e.Repo
+	cmd.Env = append(os.Environ(), e.Env()...)
+	// Don't wait on output pipes held open by processes the command left behind
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return gitbakErrors.NewHookError(e.Name, gitbakErrors.Wrapf(ctx.Err(), "%q was killed after running for %v", command, r.timeout))
+	}
+	if err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			if len(out) > maxOutput {
+				out = out[:maxOutput] + "..."
+			}
+			return gitbakErrors.NewHookError(e.Name, gitbakErrors.Wrapf(err, "%q failed: %s", command, out))
+		}
+		return gitbakErrors.NewHookError(e.Name, gitbakErrors.Wrapf(err, "%q failed", command))
+	}
+	return nil
+}
+
+// shell returns the command line that runs command with the shell of goos
+func shell(goos, command string) (string, []string) {
+	if goos == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
@@ -0,0 +1,141 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunner tests that commands run for their events with the event in their environment
+func TestRunner(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are written for sh")
+	}
+
+	tests := map[string]struct {
+		command     string
+		event       Event
+		timeout     time.Duration
+		expectFile  string
+		expectError string
+	}{
+		"Commit": {
+			command:    `echo "$GITBAK_EVENT $GITBAK_BRANCH $GITBAK_COUNTER $GITBAK_COMMIT" > out.txt`,
+			event:      Event{Name: EventCommit, Branch: "gitbak-test", Counter: 3, Commit: "abc123"},
+			expectFile: "commit gitbak-test 3 abc123",
+		},
+		"RunsInRepo": {
+			command:    `basename "$PWD" > out.txt; test "$PWD" = "$GITBAK_REPO"`,
+			event:      Event{Name: EventStart},
+			expectFile: "repo",
+		},
+		"Error": {
+			command:    `printf '%s' "$GITBAK_ERROR" > out.txt`,
+			event:      Event{Name: EventError, Error: "disk full"},
+			expectFile: "disk full",
+		},
+		"NoCommand": {
+			command: "touch out.txt",
+			event:   Event{Name: EventStop},
+		},
+		"Failure": {
+			command:     "echo broken >&2; exit 3",
+			event:       Event{Name: EventCommit},
+			expectError: `"echo broken >&2; exit 3" failed: broken`,
+		},
+		"Timeout": {
+			command:     "sleep 10",
+			event:       Event{Name: EventCommit},
+			timeout:     100 * time.Millisecond,
+			expectError: "was killed after running for 100ms",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repoPath := filepath.Join(t.TempDir(), "repo")
+			if err := os.Mkdir(repoPath, 0755); err != nil {
+				t.Fatalf("Failed to create repository directory: %v", err)
+			}
+			test.event.Repo = repoPath
+
+			// Only the event's own command runs, so NoCommand configures another one
+			commandEvent := test.event.Name
+			if name == "NoCommand" {
+				commandEvent = EventStart
+			}
+
+			var mu sync.Mutex
+			var failures []string
+			runner := New(map[string]string{commandEvent: test.command}, func(event string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failures = append(failures, event+": "+err.Error())
+			})
+			if test.timeout != 0 {
+				runner.timeout = test.timeout
+			}
+
+			runner.Run(test.event)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := runner.Wait(ctx); err != nil {
+				t.Fatalf("Wait failed: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if test.expectError == "" && len(failures) > 0 {
+				t.Errorf("Expected no failures, got %v", failures)
+			}
+			if test.expectError != "" && (len(failures) != 1 || !strings.Contains(failures[0], test.expectError)) {
+				t.Errorf("Expected a failure containing %q, got %v", test.expectError, failures)
+			}
+
+			out, err := os.ReadFile(filepath.Join(repoPath, "out.txt"))
+			if test.expectFile == "" {
+				if err == nil {
+					t.Errorf("Expected no command to run, got output %q", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected the command to write out.txt: %v", err)
+			}
+			if got := strings.TrimSpace(string(out)); got != test.expectFile {
+				t.Errorf("Expected %q, got %q", test.expectFile, got)
+			}
+		})
+	}
+}
+
+// TestEventEnv tests that only the variables an event has values for are set
+func TestEventEnv(t *testing.T) {
+	t.Parallel()
+
+	env := Event{Name: EventStop, Repo: "/repo", Counter: 2}.Env()
+	expected := []string{"GITBAK_EVENT=stop", "GITBAK_REPO=/repo", "GITBAK_COUNTER=2"}
+	if strings.Join(env, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+}
+
+// TestNilRunner tests that a nil Runner does nothing
+func TestNilRunner(t *testing.T) {
+	t.Parallel()
+
+	var runner *Runner
+	runner.Run(Event{Name: EventStart})
+	if err := runner.Wait(context.Background()); err != nil {
+		t.Errorf("Expected Wait to succeed, got %v", err)
+	}
+}
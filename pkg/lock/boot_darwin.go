@@ -0,0 +1,12 @@
+package lock
+
+import "golang.org/x/sys/unix"
+
+// bootID returns the UUID macOS assigns the current boot, or "" if it cannot be read
+func bootID() string {
+	id, err := unix.Sysctl("kern.bootsessionuuid")
+	if err != nil {
+		return ""
+	}
+	return id
+}
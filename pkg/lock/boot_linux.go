@@ -0,0 +1,15 @@
+package lock
+
+import (
+	"os"
+	"strings"
+)
+
+// bootID returns the kernel's random ID for the current boot, or "" if it cannot be read
+func bootID() string {
+	data, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package lock
+
+// bootID returns "" where no boot ID is known, leaving stale locks to be found by PID and age
+func bootID() string {
+	return ""
+}
@@ -13,7 +13,7 @@
 //
 //   - Repository-specific lock files
 //   - Process ID tracking to identify lock ownership
-//   - Stale lock detection and cleanup
+//   - Stale lock detection and cleanup, surviving PID reuse
 //   - Clean error messages for lock conflicts
 //
 // # Usage
@@ -44,11 +44,23 @@
 // from the repository path. Each lock file contains the process ID of the locking
 // process to facilitate ownership verification and cleanup.
 //
+// A lock file holds three lines: the PID, the UTC time the lock was taken or last
+// refreshed, and the ID of the host's current boot where one is known (Linux and macOS).
+// The holder refreshes the time every RefreshInterval while the lock is held. A lock is
+// stale when its process no longer runs or it dates from an earlier boot; a Locker
+// created with NewWithStaleAfter also reclaims one not refreshed for longer than its
+// threshold, for when the PID of a crashed process has been reused. Lock files holding
+// only a PID are read as well.
+//
 // The lock file path follows the pattern:
 //
-//	/tmp/gitbak-<repo-hash>.lock
+//	<temp dir>/gitbak-<repo-hash>.lock
+//
+// Where <temp dir> is os.TempDir(): $TMPDIR or /tmp on Unix-like systems, and
+// %TEMP% on Windows.
 //
-// Where <repo-hash> is a hash of the repository's absolute path.
+// <repo-hash> is a hash of the path passed to New: a worktree's absolute path, or
+// the git directory shared by all of a repository's worktrees to lock them together.
 //
 // # Cleanup
 //
@@ -70,4 +82,8 @@
 //   - Write permissions to the temporary directory
 //   - A filesystem that supports exclusive file creation
 //   - OS-level process ID information
+//
+// The lock itself is an flock(2) lock on Unix-like systems and a LockFileEx
+// lock on Windows; the platform-specific parts live in lock_unix.go and
+// lock_windows.go.
 package lock
@@ -5,53 +5,111 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
+	"time"
 
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 )
 
+// RefreshInterval is how often a held lock's timestamp is renewed, so that the age of a
+// lock tells how long ago its holder was last seen alive, not how long its session has run
+const RefreshInterval = time.Minute
+
+// MinStaleAfter is the shortest age after which a lock may be reclaimed: several refreshes
+// must have been missed, so that a busy or briefly suspended holder keeps its lock
+const MinStaleAfter = 5 * RefreshInterval
+
+// timestampFormat is how the time a lock was last refreshed is recorded. It has a fixed
+// width so that a refresh overwrites the record in place.
+const timestampFormat = "2006-01-02T15:04:05Z"
+
 // Locker prevents concurrent gitbak instances using file locks
 type Locker struct {
 	lockFile string
 	lockFd   *os.File
 	pid      int
 	acquired bool
+
+	// staleAfter is the age after which a lock is reclaimed even though its PID is in use,
+	// 0 trusting the PID alone
+	staleAfter time.Duration
+
+	// refreshEvery is how often the held lock's timestamp is renewed, 0 never
+	refreshEvery time.Duration
+	stopRefresh  chan struct{}
+	refreshDone  chan struct{}
+}
+
+// lockInfo is what a lock file records about the process holding it. Lock files written
+// by earlier versions of gitbak hold only the PID.
+type lockInfo struct {
+	PID int
+
+	// Refreshed is when the lock was taken or last refreshed
+	Refreshed time.Time
+
+	// BootID identifies the boot of the host the lock was taken during, "" if unknown
+	BootID string
 }
 
 // New creates a Locker for the specified repository path
 func New(repoPath string) (*Locker, error) {
-	if runtime.GOOS == "windows" {
-		return nil, gitbakErrors.NewLockError("", 0,
-			gitbakErrors.Wrap(gitbakErrors.ErrLockAcquisitionFailure,
-				"gitbak currently only supports Unix-like operating systems (Linux, macOS, BSD). "+
-					"Windows support is not available at this time."))
-	}
-
-	repoHash := fmt.Sprintf("%x", sha256.Sum256([]byte(repoPath)))[:16]
-	lockFile := filepath.Join(os.TempDir(), fmt.Sprintf("gitbak-%s.lock", repoHash))
+	return NewWithStaleAfter(repoPath, 0)
+}
 
+// NewWithStaleAfter creates a Locker for the specified repository path that reclaims a
+// lock not refreshed for longer than staleAfter, even if its PID has been reused by
+// another process. A staleAfter of 0 trusts the PID alone.
+func NewWithStaleAfter(repoPath string, staleAfter time.Duration) (*Locker, error) {
 	return &Locker{
-		lockFile: lockFile,
-		pid:      os.Getpid(),
-		acquired: false,
+		lockFile:     lockFilePath(repoPath),
+		pid:          os.Getpid(),
+		acquired:     false,
+		staleAfter:   staleAfter,
+		refreshEvery: RefreshInterval,
 	}, nil
 }
 
+// lockFilePath returns the lock file used for the repository at repoPath
+func lockFilePath(repoPath string) string {
+	repoHash := fmt.Sprintf("%x", sha256.Sum256([]byte(repoPath)))[:16]
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gitbak-%s.lock", repoHash))
+}
+
+// Holder returns the PID of the gitbak process holding the lock for repoPath.
+// It reports false if the repository is not locked or the lock is stale.
+func Holder(repoPath string) (int, bool) {
+	return HolderWithStaleAfter(repoPath, 0)
+}
+
+// HolderWithStaleAfter is Holder for locks that are stale once they have not been
+// refreshed for longer than staleAfter, as NewWithStaleAfter reclaims them
+func HolderWithStaleAfter(repoPath string, staleAfter time.Duration) (int, bool) {
+	l := &Locker{lockFile: lockFilePath(repoPath), staleAfter: staleAfter}
+
+	info, err := l.readLockFile()
+	if err != nil || l.isStale(info) {
+		return 0, false
+	}
+	return info.PID, true
+}
+
 // Acquire tries to acquire the lock
 func (l *Locker) Acquire() error {
 	err := l.tryCreateLock()
-	if err == nil {
-		return nil
-	} else if os.IsExist(err) {
+	if os.IsExist(err) {
 		// Only try to acquire an existing lock if the error is specifically about the file already existing
-		return l.tryAcquireExistingLock()
+		err = l.tryAcquireExistingLock()
 	}
 
 	// For other errors, return immediately without trying to acquire an existing lock
-	return err
+	if err != nil {
+		return err
+	}
+
+	l.startRefreshing()
+	return nil
 }
 
 // tryCreateLock attempts to create and lock a new lock file
@@ -100,14 +158,7 @@ func (l *Locker) tryAcquireExistingLock() error {
 	if err = l.acquireFlock(); err != nil {
 		l.closeFileDescriptor()
 
-		// Hedging bets here and checking either EWOULDBLOCK or EAGAIN,
-		// Per GNU docs ...
-		//     Portability Note: In many older Unix systems ...
-		//     [EWOULDBLOCK was] a distinct error code different from EAGAIN.
-		//     To make your program portable, you should check for both codes
-		//     and treat them the same.
-		// Ref: https://www.gnu.org/savannah-checkouts/gnu/libc/manual/html_node/Error-Codes.html
-		if gitbakErrors.Is(err, syscall.EWOULDBLOCK) || gitbakErrors.Is(err, syscall.EAGAIN) {
+		if isLockContended(err) {
 			return l.handleBlockedLock()
 		}
 
@@ -131,22 +182,36 @@ func (l *Locker) tryAcquireExistingLock() error {
 // handleBlockedLock handles locks held by another process
 // and attempts to recover from stale locks
 func (l *Locker) handleBlockedLock() error {
-	otherPid, pidErr := l.readLockFilePid()
-	if pidErr != nil {
+	info, readErr := l.readLockFile()
+	if readErr != nil {
 		return gitbakErrors.NewLockError(l.lockFile, 0,
-			gitbakErrors.Wrap(pidErr, "another gitbak instance is running, but couldn't identify its PID"))
+			gitbakErrors.Wrap(readErr, "another gitbak instance is running, but couldn't identify its PID"))
 	}
 
-	if isProcessRunning(otherPid) {
-		return gitbakErrors.NewLockError(l.lockFile, otherPid, gitbakErrors.ErrAlreadyRunning)
+	if !l.isStale(info) {
+		return gitbakErrors.NewLockError(l.lockFile, info.PID, gitbakErrors.ErrAlreadyRunning)
 	}
 
-	return l.handleStaleLock(otherPid)
+	return l.handleStaleLock(info.PID)
+}
+
+// isStale reports whether the lock recorded by info no longer belongs to a running gitbak
+// process. A live PID is not enough: after a crash the PID may have been reused, by a
+// process started since a reboot or, with staleAfter set, one started since the lock was
+// last refreshed.
+func (l *Locker) isStale(info lockInfo) bool {
+	if current := bootID(); info.BootID != "" && current != "" && info.BootID != current {
+		return true
+	}
+	if l.staleAfter > 0 && !info.Refreshed.IsZero() && time.Since(info.Refreshed) > l.staleAfter {
+		return true
+	}
+	return !isProcessRunning(info.PID)
 }
 
 // acquireFlock gets an exclusive non-blocking lock
 func (l *Locker) acquireFlock() error {
-	return syscall.Flock(int(l.lockFd.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	return lockFile(l.lockFd)
 }
 
 // resetAndWritePid clears the file and writes the current PID
@@ -159,9 +224,10 @@ func (l *Locker) resetAndWritePid() error {
 	return l.writePidToLockFile()
 }
 
-// writePidToLockFile writes PID to the lock file
+// writePidToLockFile writes the PID, the time and the boot ID to the lock file
 func (l *Locker) writePidToLockFile() error {
-	_, err := l.lockFd.WriteAt([]byte(strconv.Itoa(l.pid)), 0)
+	info := lockInfo{PID: l.pid, Refreshed: time.Now(), BootID: bootID()}
+	_, err := l.lockFd.WriteAt(info.marshal(), 0)
 	if err != nil {
 		return gitbakErrors.NewLockError(l.lockFile, l.pid,
 			gitbakErrors.Wrap(err, "failed to write PID to lock file"))
@@ -177,17 +243,6 @@ func (l *Locker) closeFileDescriptor() {
 	}
 }
 
-// isProcessRunning checks if a process exists using signal 0
-func isProcessRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
-}
-
 // handleStaleLock removes and recreates a stale lock
 func (l *Locker) handleStaleLock(otherPid int) error {
 	l.closeFileDescriptor()
@@ -227,24 +282,86 @@ func (l *Locker) handleStaleLock(otherPid int) error {
 	return nil
 }
 
-// readLockFilePid reads and parses the PID from the lock file
-func (l *Locker) readLockFilePid() (int, error) {
-	data, err := os.ReadFile(l.lockFile)
-	if err != nil {
-		return 0, gitbakErrors.Wrap(err, "failed to read lock file")
+// startRefreshing renews the held lock's timestamp every refreshEvery until Release.
+// The record keeps its length, so it is overwritten in place and never seen empty by a
+// process reading it. A failed refresh is not fatal: the lock only ages as if it had not
+// been refreshed.
+func (l *Locker) startRefreshing() {
+	if l.refreshEvery <= 0 || l.lockFd == nil {
+		return
+	}
+
+	fd, info := l.lockFd, lockInfo{PID: l.pid, BootID: bootID()}
+	stop, done := make(chan struct{}), make(chan struct{})
+	l.stopRefresh, l.refreshDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(l.refreshEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				info.Refreshed = time.Now()
+				_, _ = fd.WriteAt(info.marshal(), 0)
+			}
+		}
+	}()
+}
+
+// stopRefreshing stops the refreshes startRefreshing began and waits for them to end
+func (l *Locker) stopRefreshing() {
+	if l.stopRefresh == nil {
+		return
 	}
+	close(l.stopRefresh)
+	<-l.refreshDone
+	l.stopRefresh, l.refreshDone = nil, nil
+}
 
-	pidStr := strings.TrimSpace(string(data))
-	pid, err := strconv.Atoi(pidStr)
+// marshal returns the lock file contents recording info, one field per line
+func (info lockInfo) marshal() []byte {
+	return []byte(fmt.Sprintf("%d\n%s\n%s\n", info.PID, info.Refreshed.UTC().Format(timestampFormat), info.BootID))
+}
+
+// parseLockInfo parses the contents of a lock file. Only the PID is required, so that
+// lock files holding nothing else are still understood.
+func parseLockInfo(data []byte) (lockInfo, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	var info lockInfo
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
 	if err != nil {
-		return 0, gitbakErrors.Wrap(err, "invalid PID in lock file")
+		return lockInfo{}, gitbakErrors.Wrap(err, "invalid PID in lock file")
 	}
+	info.PID = pid
 
-	return pid, nil
+	if len(lines) > 1 {
+		if refreshed, err := time.Parse(timestampFormat, strings.TrimSpace(lines[1])); err == nil {
+			info.Refreshed = refreshed
+		}
+	}
+	if len(lines) > 2 {
+		info.BootID = strings.TrimSpace(lines[2])
+	}
+	return info, nil
+}
+
+// readLockFile reads and parses the lock file
+func (l *Locker) readLockFile() (lockInfo, error) {
+	data, err := os.ReadFile(l.lockFile)
+	if err != nil {
+		return lockInfo{}, gitbakErrors.Wrap(err, "failed to read lock file")
+	}
+	return parseLockInfo(data)
 }
 
 // Release releases the lock if it was acquired
 func (l *Locker) Release() error {
+	l.stopRefreshing()
+
 	if l.lockFd == nil {
 		return nil
 	}
@@ -253,9 +370,8 @@ func (l *Locker) Release() error {
 
 	// First, try to verify if the file descriptor is valid
 	// We do this by getting file stats, a safer operation than unlocking
-	fd := l.lockFd.Fd()
-	var stat syscall.Stat_t
-	if statErr := syscall.Fstat(int(fd), &stat); statErr != nil {
+	held, statErr := l.lockFd.Stat()
+	if statErr != nil {
 		// If we can't even stat the file, it's definitely broken...
 		err = gitbakErrors.NewLockError(l.lockFile, l.pid,
 			gitbakErrors.Wrap(statErr, "failed to stat lock file - file descriptor is invalid"))
@@ -268,7 +384,7 @@ func (l *Locker) Release() error {
 				gitbakErrors.Wrap(writeErr, "failed to write to lock file - file descriptor is invalid"))
 		} else {
 			// Attempt to unlock the file
-			if flockErr := syscall.Flock(int(fd), syscall.LOCK_UN); flockErr != nil {
+			if flockErr := unlockFile(l.lockFd); flockErr != nil {
 				err = gitbakErrors.NewLockError(l.lockFile, l.pid,
 					gitbakErrors.Wrap(flockErr, "failed to release lock"))
 			}
@@ -284,6 +400,11 @@ func (l *Locker) Release() error {
 	l.lockFd = nil
 	l.acquired = false
 
+	// A lock reclaimed as stale was replaced by another process's, which must be kept
+	if current, statErr := os.Stat(l.lockFile); held != nil && statErr == nil && !os.SameFile(held, current) {
+		return err
+	}
+
 	// Always try to remove the lock file, regardless of previous errors
 	// This ensures we clean up as much as possible even if there were errors
 	// Only report the error if there were no previous errors
@@ -0,0 +1,141 @@
+package lock
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestIsStale_AppliesPolicy tests which locks count as stale beyond those of dead processes
+func TestIsStale_AppliesPolicy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := map[string]struct {
+		info       lockInfo
+		staleAfter time.Duration
+		expected   bool
+	}{
+		"LiveAndFresh": {
+			info:       lockInfo{PID: os.Getpid(), Refreshed: now, BootID: bootID()},
+			staleAfter: MinStaleAfter,
+			expected:   false,
+		},
+		"DeadProcess": {
+			info:     lockInfo{PID: 999999, Refreshed: now, BootID: bootID()},
+			expected: true,
+		},
+		"OlderThanStaleAfter": {
+			info:       lockInfo{PID: os.Getpid(), Refreshed: now.Add(-time.Hour), BootID: bootID()},
+			staleAfter: MinStaleAfter,
+			expected:   true,
+		},
+		"OldWithoutStaleAfter": {
+			info:     lockInfo{PID: os.Getpid(), Refreshed: now.Add(-time.Hour), BootID: bootID()},
+			expected: false,
+		},
+		"NoTimestamp": {
+			info:       lockInfo{PID: os.Getpid()},
+			staleAfter: MinStaleAfter,
+			expected:   false,
+		},
+		"EarlierBoot": {
+			info:     lockInfo{PID: os.Getpid(), Refreshed: now, BootID: "an-earlier-boot"},
+			expected: bootID() != "",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			l := &Locker{staleAfter: test.staleAfter}
+			if stale := l.isStale(test.info); stale != test.expected {
+				t.Errorf("Expected isStale(%+v) to be %v, got %v", test.info, test.expected, stale)
+			}
+		})
+	}
+}
+
+// TestAcquire_ReclaimsAgedLock tests that a lock whose PID is live but which has not been
+// refreshed within staleAfter is reclaimed, and that its holder then leaves the new lock alone
+func TestAcquire_ReclaimsAgedLock(t *testing.T) {
+	repoPath := t.TempDir()
+
+	holder, err := New(repoPath)
+	if err != nil {
+		t.Fatalf("Failed to create locker: %v", err)
+	}
+	if err := holder.Acquire(); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	t.Cleanup(func() { _ = holder.Release() })
+
+	// The holder's PID stays live, as a reused PID would
+	aged := lockInfo{PID: os.Getpid(), Refreshed: time.Now().Add(-time.Hour), BootID: bootID()}
+	if _, err := holder.lockFd.WriteAt(aged.marshal(), 0); err != nil {
+		t.Fatalf("Failed to age lock file: %v", err)
+	}
+
+	trusting, err := New(repoPath)
+	if err != nil {
+		t.Fatalf("Failed to create locker: %v", err)
+	}
+	if err := trusting.Acquire(); err == nil {
+		t.Fatal("Expected a lock with a live PID to be kept without -lock-stale-after")
+	}
+	if _, running := Holder(repoPath); !running {
+		t.Error("Expected Holder to report the lock as held")
+	}
+
+	reclaiming, err := NewWithStaleAfter(repoPath, MinStaleAfter)
+	if err != nil {
+		t.Fatalf("Failed to create locker: %v", err)
+	}
+	if _, running := HolderWithStaleAfter(repoPath, MinStaleAfter); running {
+		t.Error("Expected HolderWithStaleAfter to report the aged lock as stale")
+	}
+	if err := reclaiming.Acquire(); err != nil {
+		t.Fatalf("Expected the aged lock to be reclaimed, got %v", err)
+	}
+	t.Cleanup(func() { _ = reclaiming.Release() })
+
+	if err := holder.Release(); err != nil {
+		t.Fatalf("Failed to release the reclaimed lock: %v", err)
+	}
+	info, err := reclaiming.readLockFile()
+	if err != nil {
+		t.Fatalf("Expected the reclaimed lock file to remain, got %v", err)
+	}
+	if time.Since(info.Refreshed) > time.Minute {
+		t.Errorf("Expected the reclaimed lock to be fresh, got %+v", info)
+	}
+}
+
+// TestAcquire_RefreshesHeldLock tests that a held lock's timestamp is renewed
+func TestAcquire_RefreshesHeldLock(t *testing.T) {
+	l, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create locker: %v", err)
+	}
+	l.refreshEvery = 10 * time.Millisecond
+	if err := l.Acquire(); err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Release() })
+
+	aged := lockInfo{PID: os.Getpid(), Refreshed: time.Now().Add(-time.Hour), BootID: bootID()}
+	if _, err := l.lockFd.WriteAt(aged.marshal(), 0); err != nil {
+		t.Fatalf("Failed to age lock file: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := l.readLockFile(); err == nil && time.Since(info.Refreshed) < time.Minute {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected the lock's timestamp to be refreshed")
+}
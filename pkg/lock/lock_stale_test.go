@@ -613,9 +613,8 @@ func TestHandleBlockedLock_HandlesVariousPIDs(t *testing.T) {
 				if readErr != nil {
 					t.Errorf("Failed to read lock file: %v", readErr)
 				} else {
-					expectedPid := strconv.Itoa(os.Getpid())
-					if string(data) != expectedPid {
-						t.Errorf("Expected lock file to contain PID %s, got %s", expectedPid, string(data))
+					if info, err := parseLockInfo(data); err != nil || info.PID != os.Getpid() {
+						t.Errorf("Expected lock file to contain PID %d, got %s", os.Getpid(), string(data))
 					}
 				}
 			}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package lock
+
+import (
+	"os"
+	"syscall"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// lockFile takes an exclusive, non-blocking flock on f
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// unlockFile releases the flock on f
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// isLockContended reports whether err means another process holds the lock
+func isLockContended(err error) bool {
+	// Hedging bets here and checking either EWOULDBLOCK or EAGAIN,
+	// Per GNU docs ...
+	//     Portability Note: In many older Unix systems ...
+	//     [EWOULDBLOCK was] a distinct error code different from EAGAIN.
+	//     To make your program portable, you should check for both codes
+	//     and treat them the same.
+	// Ref: https://www.gnu.org/savannah-checkouts/gnu/libc/manual/html_node/Error-Codes.html
+	return gitbakErrors.Is(err, syscall.EWOULDBLOCK) || gitbakErrors.Is(err, syscall.EAGAIN)
+}
+
+// isProcessRunning checks if a process exists using signal 0
+func isProcessRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil
+}
@@ -45,3 +45,54 @@ func TestIsProcessRunning_DetectsPIDState(t *testing.T) {
 		})
 	}
 }
+
+func TestHolder_ReportsRunningLockOwner(t *testing.T) {
+	tests := map[string]struct {
+		setup       func(t *testing.T, repoPath string)
+		expectFound bool
+	}{
+		"Unlocked": {
+			setup:       func(t *testing.T, repoPath string) {},
+			expectFound: false,
+		},
+		"Locked": {
+			setup: func(t *testing.T, repoPath string) {
+				locker, err := New(repoPath)
+				if err != nil {
+					t.Fatalf("Failed to create locker: %v", err)
+				}
+				if err := locker.Acquire(); err != nil {
+					t.Fatalf("Failed to acquire lock: %v", err)
+				}
+				t.Cleanup(func() { _ = locker.Release() })
+			},
+			expectFound: true,
+		},
+		"StaleLock": {
+			setup: func(t *testing.T, repoPath string) {
+				lockFile := lockFilePath(repoPath)
+				if err := os.WriteFile(lockFile, []byte("999999999"), 0644); err != nil {
+					t.Fatalf("Failed to write stale lock file: %v", err)
+				}
+				t.Cleanup(func() { _ = os.Remove(lockFile) })
+			},
+			expectFound: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			test.setup(t, repoPath)
+
+			pid, found := Holder(repoPath)
+			if found != test.expectFound {
+				t.Fatalf("Expected found to be %v, got %v", test.expectFound, found)
+			}
+			if found && pid != os.Getpid() {
+				t.Errorf("Expected holder PID %d, got %d", os.Getpid(), pid)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+//go:build windows
+
+package lock
+
+import (
+	"os"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a running process
+const stillActive = 259
+
+// lockRegion returns the byte range that is locked. LockFileEx locks are mandatory,
+// so the region lies far beyond the PID written at the start of the file, leaving
+// it readable by other processes checking who holds the lock.
+func lockRegion() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: 1}
+}
+
+// lockFile takes an exclusive, non-blocking LockFileEx lock on f
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, lockRegion())
+}
+
+// unlockFile releases the LockFileEx lock on f
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRegion())
+}
+
+// isLockContended reports whether err means another process holds the lock
+func isLockContended(err error) bool {
+	return gitbakErrors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// isProcessRunning checks if a process exists and has not exited yet
+func isProcessRunning(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process exists but belongs to another user
+		return gitbakErrors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer func() { _ = windows.CloseHandle(handle) }()
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
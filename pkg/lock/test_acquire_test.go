@@ -54,7 +54,8 @@ func TestAcquireRelease_HandlesMultipleLockers(t *testing.T) {
 					t.Fatalf("Failed to read lock file copy: %v", err)
 				}
 
-				lockPid, err := strconv.Atoi(string(data))
+				info, err := parseLockInfo(data)
+				lockPid := info.PID
 				if err != nil {
 					t.Fatalf("Failed to parse PID from lock file copy: %v", err)
 				}
@@ -285,10 +286,11 @@ func TestAcquire_RecoversStaleLock(t *testing.T) {
 							t.Fatalf("Failed to read PID from file: %v", err)
 						}
 
-						pidFromLock, err = strconv.Atoi(string(pidBytes))
+						info, err := parseLockInfo(pidBytes)
 						if err != nil {
 							t.Fatalf("Failed to parse PID from file: %v", err)
 						}
+						pidFromLock = info.PID
 
 						_, err = locker.lockFd.Seek(currentPos, io.SeekStart)
 						if err != nil {
@@ -466,7 +468,7 @@ func TestAcquireFlock_Succeeds(t *testing.T) {
 	file = nil
 }
 
-func TestReadLockFilePid_HandlesFormats(t *testing.T) {
+func TestReadLockFile_HandlesFormats(t *testing.T) {
 	tempDir := t.TempDir()
 	lockPath := filepath.Join(tempDir, "pid_test.lock")
 
@@ -493,6 +495,12 @@ func TestReadLockFilePid_HandlesFormats(t *testing.T) {
 			expectedPID:   12345,
 			expectedError: false,
 		},
+		"FullRecord": {
+			fileContent:   "12345\n2026-01-01T10:00:00Z\nboot\n",
+			createFile:    true,
+			expectedPID:   12345,
+			expectedError: false,
+		},
 		"InvalidFormat": {
 			fileContent:    "not-a-pid",
 			createFile:     true,
@@ -524,7 +532,8 @@ func TestReadLockFilePid_HandlesFormats(t *testing.T) {
 				}
 			}
 
-			pid, err := l.readLockFilePid()
+			info, err := l.readLockFile()
+			pid := info.PID
 
 			if pid != test.expectedPID {
 				t.Errorf("Expected PID %d, got %d", test.expectedPID, pid)
@@ -4,7 +4,6 @@ import (
 	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"testing"
 )
@@ -298,10 +297,13 @@ func TestResetAndWritePid_Succeeds(t *testing.T) {
 		t.Fatalf("Failed to read lock file: %v", err)
 	}
 
-	expectedContent := []byte(strconv.Itoa(os.Getpid()))
-	if string(data) != string(expectedContent) {
+	info, err := parseLockInfo(data)
+	if err != nil || info.PID != os.Getpid() {
 		t.Errorf("Expected lock file to contain PID %d, got '%s'", os.Getpid(), string(data))
 	}
+	if strings.Contains(string(data), "old-content") {
+		t.Errorf("Expected lock file to be truncated, got '%s'", string(data))
+	}
 }
 
 // TestResetAndWritePidErrorWithPipe tests the error path when truncating a file fails using pipe
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Backend selects where and how debug logs are recorded
+type Backend string
+
+const (
+	// BackendText writes logfmt-style records to the log file
+	BackendText Backend = "text"
+	// BackendJSON writes one JSON object per record to the log file
+	BackendJSON Backend = "json"
+	// BackendSyslog sends records to the system logger
+	BackendSyslog Backend = "syslog"
+	// BackendJournald sends records to the systemd journal
+	BackendJournald Backend = "journald"
+)
+
+// Backends lists the backends, in the order they are documented
+var Backends = []Backend{BackendText, BackendJSON, BackendSyslog, BackendJournald}
+
+// SyslogTag identifies gitbak's records to syslog and the journal, e.g. journalctl -t gitbak
+const SyslogTag = "gitbak"
+
+// UsesFile reports whether the backend writes to the log file
+func (b Backend) UsesFile() bool {
+	return b == "" || b == BackendText || b == BackendJSON
+}
+
+// NewHandlerSink creates a sink writing entries as records through handler, so that any
+// slog.Handler can receive gitbak's logs. Trace entries are recorded at slog.LevelDebug,
+// and status messages are left out.
+func NewHandlerSink(handler slog.Handler) Sink {
+	return &handlerSink{handler: handler}
+}
+
+// NewBackendSink creates the sink of backend, writing to logFile rotated as configured by
+// rotation if the backend uses a file. It also returns where the records go, to tell the user.
+func NewBackendSink(backend Backend, logFile string, rotation Rotation) (Sink, string, error) {
+	switch backend {
+	case BackendText, "":
+		sink, err := NewRotatingFileSink(logFile, rotation)
+		return sink, logFile, err
+	case BackendJSON:
+		f, err := openLogFile(logFile, rotation)
+		if err != nil {
+			return nil, "", err
+		}
+		return &handlerSink{handler: slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelInfo}), file: f}, logFile, nil
+	case BackendSyslog:
+		sink, err := NewSyslogSink(SyslogTag)
+		return sink, "syslog (tag " + SyslogTag + ")", err
+	case BackendJournald:
+		sink, err := NewJournaldSink(SyslogTag)
+		return sink, "the systemd journal (journalctl -t " + SyslogTag + ")", err
+	default:
+		return nil, "", gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "unknown log backend %q", backend)
+	}
+}
+
+// lineHandler is the part of a slog.Handler that the syslog and journald handlers share:
+// it renders a record's message and attributes as a single line, as both take a message
+// and a priority rather than structured fields.
+type lineHandler struct {
+	level  slog.Leveler
+	attrs  string
+	groups string
+}
+
+// Enabled implements slog.Handler
+func (h *lineHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// format returns the record's message followed by its attributes and those of the handler
+func (h *lineHandler) format(record slog.Record) string {
+	var b strings.Builder
+	b.WriteString(record.Message)
+	b.WriteString(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&b, h.groups, attr)
+		return true
+	})
+	return b.String()
+}
+
+// withAttrs returns a copy of the handler that adds attrs to every record
+func (h lineHandler) withAttrs(attrs []slog.Attr) lineHandler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, attr := range attrs {
+		appendAttr(&b, h.groups, attr)
+	}
+	h.attrs = b.String()
+	return h
+}
+
+// withGroup returns a copy of the handler that qualifies later attributes with name
+func (h lineHandler) withGroup(name string) lineHandler {
+	if name != "" {
+		h.groups += name + "."
+	}
+	return h
+}
+
+// appendAttr writes attr to b as " key=value", qualified by prefix, flattening groups
+func appendAttr(b *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			appendAttr(b, prefix, member)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if strings.ContainsAny(value, " \"=\n") || value == "" {
+		value = fmt.Sprintf("%q", value)
+	}
+	_, _ = fmt.Fprintf(b, " %s%s=%s", prefix, attr.Key, value)
+}
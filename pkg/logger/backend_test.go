@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// TestNewBackendSink tests that the file backends write records in their format to the log file
+func TestNewBackendSink(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		backend  Backend
+		expected string
+	}{
+		"Text": {
+			backend:  BackendText,
+			expected: `level=WARN msg="disk full"`,
+		},
+		"Default": {
+			expected: `level=WARN msg="disk full"`,
+		},
+		"JSON": {
+			backend:  BackendJSON,
+			expected: `"level":"WARN","msg":"disk full"`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "logs", "gitbak.log")
+			sink, where, err := NewBackendSink(test.backend, path, Rotation{})
+			if err != nil {
+				t.Fatalf("NewBackendSink failed: %v", err)
+			}
+			if where != path {
+				t.Errorf("Expected records to go to %s, got %s", path, where)
+			}
+			if err := sink.Write(Entry{Time: time.Now(), Kind: KindWarning, Message: "disk full"}); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read log file: %v", err)
+			}
+			if !strings.Contains(string(content), test.expected) {
+				t.Errorf("Expected %q in the log file, got %q", test.expected, content)
+			}
+		})
+	}
+
+	if _, _, err := NewBackendSink("carrier-pigeon", "", Rotation{}); !gitbakErrors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Errorf("Expected an unknown backend to be refused, got %v", err)
+	}
+}
+
+// TestHandlerSink tests that entries reach any slog.Handler, with traces as debug records
+func TestHandlerSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := NewPipeline()
+	p.AddSink(NewHandlerSink(slog.NewJSONHandler(&buf, nil).WithAttrs([]slog.Attr{slog.String("repo", "/src/app")})), LevelInfo)
+
+	p.Trace("git status took %dms", 12)
+	p.StatusMessage("banner")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "git status took 12ms" || record["repo"] != "/src/app" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+}
+
+// TestLineHandler tests how records and their attributes are rendered for syslog and journald
+func TestLineHandler(t *testing.T) {
+	t.Parallel()
+
+	base := lineHandler{level: slog.LevelInfo}
+	h := base.withAttrs([]slog.Attr{slog.String("repo", "/src/my app")})
+	h = h.withGroup("git")
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "checkpoint", 0)
+	record.AddAttrs(slog.Int("number", 3), slog.Group("push", slog.String("remote", "origin")), slog.Attr{})
+
+	expected := `checkpoint repo="/src/my app" git.number=3 git.push.remote=origin`
+	if line := h.format(record); line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+	if h.Enabled(t.Context(), slog.LevelDebug) {
+		t.Error("Expected debug records to be below the handler's level")
+	}
+}
@@ -2,13 +2,36 @@
 //
 // This package implements a simple, structured logging system with different
 // log levels, colors for terminal output, and the ability to write logs to
-// both the console and a file simultaneously. It defines both the logging interface
+// several destinations at once. It defines both the logging interface
 // and the standard implementation used throughout the application.
 //
 // # Core Components
 //
 //   - Logger: The main interface for logging used throughout the application
-//   - DefaultLogger: Standard implementation that writes to console and/or file
+//   - Pipeline: Logger implementation that dispatches each message to a set of sinks
+//   - Sink: A destination for log entries, with its own rendering and filtering
+//   - DefaultLogger: Standard Pipeline that writes to the console and, with debug logging, a file
+//
+// # Sinks
+//
+// Each message is formatted once and handed to every sink that accepts it,
+// as an Entry carrying its kind, whether it is addressed to the user, and a timestamp.
+// The following sinks are provided:
+//
+//   - ConsoleSink: Emoji-prefixed terminal output, as described under Console Output
+//   - NewFileSink / NewRotatingFileSink / NewTextSink: Text records, as described under File Logging
+//   - NewJSONSink: One JSON object per entry, for log shippers
+//   - NewSyslogSink / NewJournaldSink: Records sent to syslog or the systemd journal
+//   - NewHandlerSink: Records written through any slog.Handler
+//   - EventSink: Entries published on a channel, for following a session live
+//   - NewNotificationSink: User-facing messages passed to a notification function
+//
+// Sinks are added with a minimum level (LevelInfo, LevelWarning or LevelError),
+// so for example notifications can be limited to warnings and errors:
+//
+//	p := logger.NewPipeline()
+//	p.AddSink(logger.NewConsoleSink(os.Stdout, os.Stderr, logger.VerbosityInfo), logger.LevelInfo)
+//	p.AddSink(logger.NewNotificationSink(notify), logger.LevelWarning)
 //
 // # Features
 //
@@ -27,6 +50,7 @@
 //   - Warning: Warning messages for potential issues
 //   - WarningToUser: Important warnings to display to the user
 //   - Error: Error messages for failures
+//   - Trace: Step-by-step detail, such as each git command run
 //   - Success: Success messages for completed operations
 //   - StatusMessage: Current status updates
 //
@@ -77,14 +101,35 @@
 // Console output is formatted with emoji prefixes to distinguish different
 // message types:
 //
-//   - Info: ℹ️ prefix (for InfoToUser messages)
-//   - Warning: ⚠️ prefix (only shown when verbose, unless WarningToUser)
+//   - Info: ℹ️ prefix (for InfoToUser messages), 🔍 for internal Info messages
+//   - Warning: ⚠️ prefix
 //   - Error: ❌ prefix
 //   - Success: ✅ prefix
+//   - Trace: 🔬 prefix
 //   - Debug Log Enabled: 🔍 prefix
 //
-// Messages directed specifically to users (InfoToUser, WarningToUser) are
-// always displayed regardless of verbosity settings.
+// The console's Verbosity selects which are shown, each level adding to the
+// ones before it:
+//
+//   - VerbosityError: errors
+//   - VerbosityWarn: WarningToUser and Success messages
+//   - VerbosityInfo: InfoToUser messages and internal warnings (the default)
+//   - VerbosityDebug: internal Info messages
+//   - VerbosityTrace: Trace messages
+//
+// Status messages, such as banners and prompts, are shown at every verbosity.
+//
+// A warning or error that repeats one already shown is held back and counted, so that a
+// check failing the same way every interval does not fill the terminal. The repeats are
+// summarized ("Same error repeated 12×, last at 14:05:00: ...") when a different warning or
+// error comes, when a user-facing message of another kind shows the trouble has cleared,
+// or when the sink is closed. Other sinks receive every entry.
+// New and NewWithOutput take a verbose switch, selecting VerbosityInfo or VerbosityWarn.
+//
+// Messages may carry ANSI colors, e.g. when relaying git output. SetColor(false)
+// strips them; gitbak does so when stdout is not a terminal that shows them
+// (see EnableVirtualTerminal, which also turns them on in Windows consoles),
+// with -no-color, or when the NO_COLOR environment variable is set.
 //
 // # File Logging
 //
@@ -92,6 +137,26 @@
 // are written to the file. File logging includes timestamps and does not
 // include ANSI color codes.
 //
+// With a Rotation, the file is rotated once it reaches Rotation.MaxBytes: it is
+// renamed to <file>.1, earlier rotations move up to <file>.2 and so on, and only
+// Rotation.MaxFiles of them are kept. PruneLogs removes log files and rotations
+// that haven't been written to for a given time, such as those of repositories
+// no longer monitored:
+//
+//	log := logger.NewWithRotation(true, path, logger.VerbosityWarn, logger.Rotation{MaxBytes: 10 << 20, MaxFiles: 5}, os.Stdout, os.Stderr)
+//	removed, err := logger.PruneLogs(filepath.Dir(path), 30*24*time.Hour)
+//
+// # Backends
+//
+// All records are written through log/slog handlers, with trace entries at slog.LevelDebug.
+// NewWithBackend chooses where DefaultLogger records them with a Backend: BackendText and
+// BackendJSON write the log file, while BackendSyslog and BackendJournald send each record
+// to the system's logs, tagged SyslogTag, at the syslog priority of its level. journald is
+// reached through its native protocol, so multi-line messages stay in one entry; it is only
+// available on Linux, and syslog everywhere but Windows.
+//
+//	log := logger.NewWithBackend(true, logger.BackendJournald, "", logger.VerbosityInfo, logger.Rotation{}, os.Stdout, os.Stderr)
+//
 // # Resource Management
 //
 // The Logger interface provides a Close method that should be called before
@@ -101,7 +166,7 @@
 //
 // # Thread Safety
 //
-// The DefaultLogger implementation is safe for concurrent use by multiple
-// goroutines. All logging methods can be called from different goroutines
+// Pipeline, and therefore DefaultLogger, is safe for concurrent use by multiple
+// goroutines; entries are delivered to sinks one at a time. All logging methods can be called from different goroutines
 // without additional synchronization.
 package logger
@@ -0,0 +1,87 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// journalSocket is where systemd-journald receives entries in its native protocol
+const journalSocket = "/run/systemd/journal/socket"
+
+// journaldHandler is a slog.Handler sending each record to the systemd journal as an
+// entry with MESSAGE, PRIORITY and SYSLOG_IDENTIFIER fields
+type journaldHandler struct {
+	lineHandler
+	conn *net.UnixConn
+	tag  string
+}
+
+// NewJournaldSink creates a sink sending entries to the systemd journal, identified by tag
+func NewJournaldSink(tag string) (Sink, error) {
+	return dialJournaldSink(journalSocket, tag)
+}
+
+// dialJournaldSink is like NewJournaldSink, sending entries to the journal socket at path
+func dialJournaldSink(path, tag string) (Sink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, gitbakErrors.Wrapf(err, "journald is not accepting entries at %s", path)
+	}
+	handler := &journaldHandler{lineHandler: lineHandler{level: slog.LevelDebug}, conn: conn, tag: tag}
+	return &handlerSink{handler: handler, conn: conn}, nil
+}
+
+// Handle implements slog.Handler
+func (h *journaldHandler) Handle(_ context.Context, record slog.Record) error {
+	var entry bytes.Buffer
+	writeJournalField(&entry, "MESSAGE", h.format(record))
+	writeJournalField(&entry, "PRIORITY", strconv.Itoa(syslogPriority(record.Level)))
+	writeJournalField(&entry, "SYSLOG_IDENTIFIER", h.tag)
+	_, err := h.conn.Write(entry.Bytes())
+	return err
+}
+
+// WithAttrs implements slog.Handler
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &journaldHandler{lineHandler: h.withAttrs(attrs), conn: h.conn, tag: h.tag}
+}
+
+// WithGroup implements slog.Handler
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	return &journaldHandler{lineHandler: h.withGroup(name), conn: h.conn, tag: h.tag}
+}
+
+// writeJournalField appends a field to an entry. Values spanning lines are written with
+// their length in front, as the protocol requires, and others as KEY=value.
+func writeJournalField(entry *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		entry.WriteString(key + "=" + value + "\n")
+		return
+	}
+	entry.WriteString(key + "\n")
+	_ = binary.Write(entry, binary.LittleEndian, uint64(len(value)))
+	entry.WriteString(value + "\n")
+}
+
+// syslogPriority returns the syslog priority of level: err, warning, info or debug
+func syslogPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestJournaldSink tests that entries are sent to the journal socket in the native protocol
+func TestJournaldSink(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "journal")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+	journal, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+
+	sink, err := dialJournaldSink(path, "gitbak")
+	if err != nil {
+		t.Fatalf("dialJournaldSink failed: %v", err)
+	}
+	defer func() { _ = sink.Close() }()
+
+	receive := func() []byte {
+		t.Helper()
+		buf := make([]byte, 4096)
+		_ = journal.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := journal.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to receive an entry: %v", err)
+		}
+		return buf[:n]
+	}
+
+	if err := sink.Write(Entry{Time: time.Now(), Kind: KindWarning, Message: "disk full"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if entry, expected := receive(), "MESSAGE=disk full\nPRIORITY=4\nSYSLOG_IDENTIFIER=gitbak\n"; string(entry) != expected {
+		t.Errorf("Expected entry %q, got %q", expected, entry)
+	}
+
+	if err := sink.Write(Entry{Time: time.Now(), Kind: KindTrace, Message: "line one\nline two"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var expected bytes.Buffer
+	expected.WriteString("MESSAGE\n")
+	_ = binary.Write(&expected, binary.LittleEndian, uint64(len("line one\nline two")))
+	expected.WriteString("line one\nline two\nPRIORITY=7\nSYSLOG_IDENTIFIER=gitbak\n")
+	if entry := receive(); !bytes.Equal(entry, expected.Bytes()) {
+		t.Errorf("Expected a multi-line message to be sent with its length, got %q", entry)
+	}
+
+	if _, err := dialJournaldSink(filepath.Join(dir, "missing"), "gitbak"); err == nil {
+		t.Error("Expected an error when journald is not listening")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package logger
+
+import gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+
+// NewJournaldSink reports that the systemd journal is only available on Linux
+func NewJournaldSink(string) (Sink, error) {
+	return nil, gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration, "the systemd journal is only available on Linux")
+}
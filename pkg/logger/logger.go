@@ -3,10 +3,8 @@ package logger
 import (
 	"fmt"
 	"io"
-	"log/slog"
 	"os"
-	"path/filepath"
-	"sync"
+	"time"
 )
 
 // Logger defines the common logging interface used throughout the application.
@@ -20,7 +18,7 @@ type Logger interface {
 
 	// Info logs an informational message for debugging purposes.
 	// These messages are typically only written to log files and are not shown to users
-	// unless verbose mode is enabled.
+	// unless the verbosity is debug or higher.
 	//
 	// The format string follows fmt.Printf style formatting.
 	Info(format string, args ...interface{})
@@ -28,11 +26,18 @@ type Logger interface {
 	// Warning logs a warning message for debugging purposes.
 	// These messages indicate potential issues that are not critical failures.
 	// They are typically only written to log files and are not shown to users
-	// unless verbose mode is enabled.
+	// unless the verbosity is info or higher.
 	//
 	// The format string follows fmt.Printf style formatting.
 	Warning(format string, args ...interface{})
 
+	// Trace logs a detailed message for following what gitbak does step by step,
+	// such as each git command it runs. These messages are only shown to users at
+	// trace verbosity, and are written to log files as debug records.
+	//
+	// The format string follows fmt.Printf style formatting.
+	Trace(format string, args ...interface{})
+
 	// Error logs an error message for debugging purposes.
 	// These messages indicate operational failures or errors that occurred
 	// during program execution. They are typically written to log files and
@@ -44,7 +49,7 @@ type Logger interface {
 	// User-facing logging methods (typically written to both file and stdout)
 
 	// InfoToUser logs an informational message intended for users.
-	// These messages are always shown to users regardless of verbose settings,
+	// These messages are shown to users unless the verbosity is below info,
 	// and are also written to log files.
 	//
 	// The format string follows fmt.Printf style formatting.
@@ -52,14 +57,15 @@ type Logger interface {
 
 	// WarningToUser logs a warning message intended for users.
 	// These messages highlight important issues that users should be aware of,
-	// and are always shown regardless of verbose settings.
+	// and are shown unless the verbosity is error.
 	//
 	// The format string follows fmt.Printf style formatting.
 	WarningToUser(format string, args ...interface{})
 
 	// Success logs a success message to the user.
 	// These messages indicate successful completion of operations and are
-	// typically styled differently (e.g., green text) to stand out.
+	// typically styled differently (e.g., green text) to stand out. Like warnings,
+	// they are shown unless the verbosity is error.
 	//
 	// The format string follows fmt.Printf style formatting.
 	Success(format string, args ...interface{})
@@ -77,197 +83,73 @@ type Logger interface {
 	Close() error
 }
 
-// DefaultLogger provides structured logging capability and implements the Logger interface
+// DefaultLogger is the standard Logger: a Pipeline feeding a console sink and,
+// when debug logging is enabled, a log file sink.
 type DefaultLogger struct {
-	mu      sync.Mutex
-	logger  *slog.Logger
-	enabled bool
-	logFile string
-	verbose bool
-	stdout  io.Writer
-	stderr  io.Writer
-	file    *os.File // Store file handle for closing
+	*Pipeline
+	console *ConsoleSink
 }
 
-// New creates a new Logger instance
+// New creates a new Logger instance. Verbose selects info verbosity, and
+// otherwise warn verbosity.
 func New(enabled bool, logFile string, verbose bool) Logger {
 	return NewWithOutput(enabled, logFile, verbose, os.Stdout, os.Stderr)
 }
 
-// NewWithOutput creates a DefaultLogger with custom output writers
+// NewWithOutput creates a DefaultLogger with custom output writers.
+// If enabled, entries are also written to logFile; if the file cannot be opened
+// they are written to stderr instead.
 func NewWithOutput(enabled bool, logFile string, verbose bool, stdout, stderr io.Writer) *DefaultLogger {
-	var logger *slog.Logger
+	return NewWithRotation(enabled, logFile, verbosityFor(verbose), Rotation{}, stdout, stderr)
+}
 
-	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}
+// NewWithRotation is like NewWithOutput, showing messages on the console at the given
+// verbosity and rotating logFile as configured by rotation
+func NewWithRotation(enabled bool, logFile string, verbosity Verbosity, rotation Rotation, stdout, stderr io.Writer) *DefaultLogger {
+	return NewWithBackend(enabled, BackendText, logFile, verbosity, rotation, stdout, stderr)
+}
 
-	var file *os.File
+// NewWithBackend is like NewWithRotation, recording entries through backend when enabled.
+// logFile and rotation only apply to the backends that write to a file.
+func NewWithBackend(enabled bool, backend Backend, logFile string, verbosity Verbosity, rotation Rotation, stdout, stderr io.Writer) *DefaultLogger {
+	l := &DefaultLogger{
+		Pipeline: NewPipeline(),
+		console:  NewConsoleSink(stdout, stderr, verbosity),
+	}
+	l.AddSink(l.console, LevelInfo)
 
 	if enabled {
-		logDir := filepath.Dir(logFile)
-		if logDir != "." {
-			err := os.MkdirAll(logDir, 0755)
-			if err != nil {
-				_, _ = fmt.Fprintf(stderr, "⚠️ Failed to create log directory: %v\n", err)
-			}
-		}
-
-		f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		sink, where, err := NewBackendSink(backend, logFile, rotation)
 		if err == nil {
-			file = f
-			fileHandler := slog.NewTextHandler(f, opts)
-			logger = slog.New(fileHandler)
-			_, _ = fmt.Fprintf(stdout, "🔍 Debug logging enabled. Logs will be written to: %s\n", logFile)
-
-			logger.Info("gitbak debug logging started")
+			l.AddSink(sink, LevelInfo)
+			_, _ = fmt.Fprintf(stdout, "🔍 Debug logging enabled. Logs will be written to: %s\n", where)
+			_ = sink.Write(Entry{Time: time.Now(), Kind: KindInfo, Message: "gitbak debug logging started"})
 		} else {
-			// Fallback to standard logger
-			logger = slog.New(slog.NewTextHandler(stderr, opts))
-			_, _ = fmt.Fprintf(stderr, "⚠️ Failed to open log file: %v, using stderr instead\n", err)
+			// Fallback to logging on stderr
+			l.AddSink(NewTextSink(stderr), LevelInfo)
+			_, _ = fmt.Fprintf(stderr, "⚠️ Failed to open %s log: %v, using stderr instead\n", backend, err)
 		}
-	} else {
-		// Setup non-file logger
-		logger = slog.New(slog.NewTextHandler(stderr, opts))
 	}
 
-	return &DefaultLogger{
-		logger:  logger,
-		enabled: enabled,
-		logFile: logFile,
-		verbose: verbose,
-		stdout:  stdout,
-		stderr:  stderr,
-		file:    file,
-	}
-}
-
-// Info logs an informational message (file only)
-func (l *DefaultLogger) Info(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	if !l.enabled {
-		return
-	}
-
-	msg := fmt.Sprintf(format, args...)
-	l.logger.Info(msg)
-}
-
-// InfoToUser logs an informational message to both file and stdout
-func (l *DefaultLogger) InfoToUser(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-
-	if l.enabled {
-		l.logger.Info(msg)
-	}
-
-	_, _ = fmt.Fprintf(l.stdout, "ℹ️  %s\n", msg)
-}
-
-// Success logs a success message to both file and stdout
-func (l *DefaultLogger) Success(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-
-	if l.enabled {
-		l.logger.Info(msg)
-	}
-
-	_, _ = fmt.Fprintf(l.stdout, "✅ %s\n", msg)
-}
-
-// Warning logs a warning message
-func (l *DefaultLogger) Warning(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-
-	if l.enabled {
-		l.logger.Warn(msg)
-	}
-
-	// Always show the message to the user when verbose is on,
-	// regardless of whether file logging is enabled
-	if l.verbose {
-		_, _ = fmt.Fprintf(l.stdout, "⚠️  %s\n", msg)
-	}
-}
-
-// WarningToUser logs a warning message to both file and stdout
-func (l *DefaultLogger) WarningToUser(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-
-	if l.enabled {
-		l.logger.Warn(msg)
-	}
-
-	_, _ = fmt.Fprintf(l.stdout, "⚠️  %s\n", msg)
-}
-
-// Error logs an error message
-func (l *DefaultLogger) Error(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-
-	if l.enabled {
-		l.logger.Error(msg)
-	}
-
-	// Always show errors to the user regardless of debug status
-	_, _ = fmt.Fprintf(l.stderr, "❌ %s\n", msg)
-}
-
-// StatusMessage prints a status message to stdout only (no logging)
-func (l *DefaultLogger) StatusMessage(format string, args ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	msg := fmt.Sprintf(format, args...)
-	_, _ = fmt.Fprintln(l.stdout, msg)
-}
-
-// Close ensures any buffered data is written and closes open log file handles
-func (l *DefaultLogger) Close() error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	if l.file != nil {
-		// Sync ensures any buffered data is flushed to disk before closing
-		if err := l.file.Sync(); err != nil {
-			return err
-		}
-		return l.file.Close()
-	}
-	return nil
+	return l
 }
 
 // SetStdout sets a custom writer for user-facing stdout messages only.
-// NOTE: This does not affect where structured log messages from slog are directed.
+// NOTE: This does not affect where log sinks write.
 // This method is thread-safe and is primarily intended for testing.
 func (l *DefaultLogger) SetStdout(w io.Writer) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.stdout = w
+	l.console.SetStdout(w)
+}
+
+// SetColor enables or disables colored console output.
+// With color disabled, ANSI escape sequences are stripped from messages.
+func (l *DefaultLogger) SetColor(enabled bool) {
+	l.console.SetColor(enabled)
 }
 
 // SetStderr sets a custom writer for user-facing stderr messages only.
-// NOTE: This does not affect where structured log messages from slog are directed.
+// NOTE: This does not affect where log sinks write.
 // This method is thread-safe and is primarily intended for testing.
 func (l *DefaultLogger) SetStderr(w io.Writer) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.stderr = w
+	l.console.SetStderr(w)
 }
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -236,3 +237,22 @@ func TestUserMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestDisabledLoggingAllocations(t *testing.T) {
+	logger := NewWithOutput(false, "", false, io.Discard, io.Discard)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		logger.Info("tick")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected disabled Info to allocate nothing, got %.1f allocs per run", allocs)
+	}
+
+	// User-facing messages format a string per call but must not accumulate state
+	allocs = testing.AllocsPerRun(1000, func() {
+		logger.StatusMessage("status")
+	})
+	if allocs > 2 {
+		t.Errorf("Expected StatusMessage to allocate at most 2 times per call, got %.1f", allocs)
+	}
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Level is the severity of a log entry, used for per-sink filtering
+type Level int
+
+const (
+	// LevelInfo covers informational, success and status messages
+	LevelInfo Level = iota
+	// LevelWarning covers warnings
+	LevelWarning
+	// LevelError covers errors
+	LevelError
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Kind identifies which Logger method produced an entry, so that sinks
+// can render each kind of message the way users expect
+type Kind int
+
+const (
+	// KindInfo is produced by Info and InfoToUser
+	KindInfo Kind = iota
+	// KindWarning is produced by Warning and WarningToUser
+	KindWarning
+	// KindError is produced by Error
+	KindError
+	// KindSuccess is produced by Success
+	KindSuccess
+	// KindStatus is produced by StatusMessage
+	KindStatus
+	// KindTrace is produced by Trace
+	KindTrace
+)
+
+// Level returns the severity of the kind
+func (k Kind) Level() Level {
+	switch k {
+	case KindWarning:
+		return LevelWarning
+	case KindError:
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+// Entry is a single formatted log message as delivered to sinks
+type Entry struct {
+	Time    time.Time
+	Kind    Kind
+	Message string
+
+	// User reports whether the message is addressed to the user
+	// (InfoToUser, WarningToUser, Success, StatusMessage) rather than only to the logs
+	User bool
+}
+
+// Level returns the severity of the entry
+func (e Entry) Level() Level {
+	return e.Kind.Level()
+}
+
+// Sink is a destination for log entries, such as the console or a log file
+type Sink interface {
+	// Accepts reports whether the sink wants entries of this kind.
+	// It is consulted before the message is formatted, so that entries
+	// no sink wants cost nothing.
+	Accepts(kind Kind, user bool) bool
+
+	// Write delivers an entry. Errors are not reported back to the caller of the Logger method.
+	Write(entry Entry) error
+
+	// Close flushes and releases the sink's resources
+	Close() error
+}
+
+// route is a sink together with the minimum level it receives
+type route struct {
+	sink     Sink
+	minLevel Level
+}
+
+// Pipeline is a Logger that dispatches every message to a set of sinks,
+// each with its own minimum level
+type Pipeline struct {
+	mu     sync.Mutex
+	routes []route
+	now    func() time.Time
+}
+
+// NewPipeline creates a Pipeline without any sinks
+func NewPipeline() *Pipeline {
+	return &Pipeline{now: time.Now}
+}
+
+// AddSink adds a sink that receives the entries it accepts at or above minLevel
+func (p *Pipeline) AddSink(sink Sink, minLevel Level) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.routes = append(p.routes, route{sink: sink, minLevel: minLevel})
+}
+
+// dispatch formats the message once and writes it to every sink that wants it
+func (p *Pipeline) dispatch(kind Kind, user bool, format string, args []interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	level := kind.Level()
+	var entry Entry
+	formatted := false
+
+	for _, r := range p.routes {
+		if level < r.minLevel || !r.sink.Accepts(kind, user) {
+			continue
+		}
+		if !formatted {
+			entry = Entry{Time: p.now(), Kind: kind, Message: fmt.Sprintf(format, args...), User: user}
+			formatted = true
+		}
+		_ = r.sink.Write(entry)
+	}
+}
+
+// Info logs an informational message (log sinks only)
+func (p *Pipeline) Info(format string, args ...interface{}) {
+	p.dispatch(KindInfo, false, format, args)
+}
+
+// Warning logs a warning message
+func (p *Pipeline) Warning(format string, args ...interface{}) {
+	p.dispatch(KindWarning, false, format, args)
+}
+
+// Error logs an error message
+func (p *Pipeline) Error(format string, args ...interface{}) {
+	p.dispatch(KindError, false, format, args)
+}
+
+// Trace logs a detailed trace message
+func (p *Pipeline) Trace(format string, args ...interface{}) {
+	p.dispatch(KindTrace, false, format, args)
+}
+
+// InfoToUser logs an informational message intended for users
+func (p *Pipeline) InfoToUser(format string, args ...interface{}) {
+	p.dispatch(KindInfo, true, format, args)
+}
+
+// WarningToUser logs a warning message intended for users
+func (p *Pipeline) WarningToUser(format string, args ...interface{}) {
+	p.dispatch(KindWarning, true, format, args)
+}
+
+// Success logs a success message to the user
+func (p *Pipeline) Success(format string, args ...interface{}) {
+	p.dispatch(KindSuccess, true, format, args)
+}
+
+// StatusMessage logs a status message to the user
+func (p *Pipeline) StatusMessage(format string, args ...interface{}) {
+	p.dispatch(KindStatus, true, format, args)
+}
+
+// Close closes every sink, returning the errors of those that failed
+func (p *Pipeline) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for _, r := range p.routes {
+		if err := r.sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return gitbakErrors.Join(errs...)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink records the entries it receives
+type recordingSink struct {
+	entries []Entry
+	closed  bool
+	err     error
+}
+
+func (s *recordingSink) Accepts(Kind, bool) bool { return true }
+
+func (s *recordingSink) Write(entry Entry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return s.err
+}
+
+// TestPipelineLevelFiltering tests that each sink only receives entries at or above its level
+func TestPipelineLevelFiltering(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		minLevel Level
+		expected []Kind
+	}{
+		"Info": {
+			minLevel: LevelInfo,
+			expected: []Kind{KindInfo, KindWarning, KindError, KindSuccess, KindStatus},
+		},
+		"Warning": {
+			minLevel: LevelWarning,
+			expected: []Kind{KindWarning, KindError},
+		},
+		"Error": {
+			minLevel: LevelError,
+			expected: []Kind{KindError},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sink := &recordingSink{}
+			p := NewPipeline()
+			p.AddSink(sink, test.minLevel)
+
+			p.Info("info")
+			p.Warning("warning")
+			p.Error("error")
+			p.Success("success")
+			p.StatusMessage("status")
+
+			if len(sink.entries) != len(test.expected) {
+				t.Fatalf("Expected %d entries, got %d: %+v", len(test.expected), len(sink.entries), sink.entries)
+			}
+			for i, kind := range test.expected {
+				if sink.entries[i].Kind != kind {
+					t.Errorf("Entry %d: expected kind %d, got %d", i, kind, sink.entries[i].Kind)
+				}
+			}
+		})
+	}
+}
+
+// TestConsoleSinkRendering tests how the console sink renders each kind of message
+func TestConsoleSinkRendering(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		verbosity    Verbosity
+		noColor      bool
+		log          func(p *Pipeline)
+		expectStdout string
+		expectStderr string
+	}{
+		"InternalInfoHidden": {
+			verbosity:    VerbosityInfo,
+			log:          func(p *Pipeline) { p.Info("hidden") },
+			expectStdout: "",
+		},
+		"InternalInfoShownWhenDebug": {
+			verbosity:    VerbosityDebug,
+			log:          func(p *Pipeline) { p.Info("details") },
+			expectStdout: "🔍 details\n",
+		},
+		"InfoToUser": {
+			verbosity:    VerbosityInfo,
+			log:          func(p *Pipeline) { p.InfoToUser("hello %s", "there") },
+			expectStdout: "ℹ️  hello there\n",
+		},
+		"InfoToUserHiddenWhenWarn": {
+			verbosity:    VerbosityWarn,
+			log:          func(p *Pipeline) { p.InfoToUser("hello") },
+			expectStdout: "",
+		},
+		"InternalWarningHiddenWhenWarn": {
+			verbosity:    VerbosityWarn,
+			log:          func(p *Pipeline) { p.Warning("careful") },
+			expectStdout: "",
+		},
+		"InternalWarningShownWhenInfo": {
+			verbosity:    VerbosityInfo,
+			log:          func(p *Pipeline) { p.Warning("careful") },
+			expectStdout: "⚠️  careful\n",
+		},
+		"WarningToUserHiddenWhenError": {
+			verbosity:    VerbosityError,
+			log:          func(p *Pipeline) { p.WarningToUser("careful"); p.Success("done") },
+			expectStdout: "",
+		},
+		"SuccessShownWhenWarn": {
+			verbosity:    VerbosityWarn,
+			log:          func(p *Pipeline) { p.Success("done") },
+			expectStdout: "✅ done\n",
+		},
+		"TraceHiddenWhenDebug": {
+			verbosity:    VerbosityDebug,
+			log:          func(p *Pipeline) { p.Trace("git status") },
+			expectStdout: "",
+		},
+		"TraceShownWhenTrace": {
+			verbosity:    VerbosityTrace,
+			log:          func(p *Pipeline) { p.Trace("git status") },
+			expectStdout: "🔬 git status\n",
+		},
+		"ErrorOnStderr": {
+			verbosity:    VerbosityError,
+			log:          func(p *Pipeline) { p.Error("broken") },
+			expectStderr: "❌ broken\n",
+		},
+		"Status": {
+			verbosity:    VerbosityError,
+			log:          func(p *Pipeline) { p.StatusMessage("plain") },
+			expectStdout: "plain\n",
+		},
+		"ColorKept": {
+			log:          func(p *Pipeline) { p.StatusMessage("\x1b[33mabc1234\x1b[m message") },
+			expectStdout: "\x1b[33mabc1234\x1b[m message\n",
+		},
+		"ColorStripped": {
+			noColor:      true,
+			log:          func(p *Pipeline) { p.StatusMessage("* \x1b[33mabc1234\x1b[m\x1b[1;32m(HEAD)\x1b[m message") },
+			expectStdout: "* abc1234(HEAD) message\n",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			p := NewPipeline()
+			console := NewConsoleSink(&stdout, &stderr, test.verbosity)
+			console.SetColor(!test.noColor)
+			p.AddSink(console, LevelInfo)
+
+			test.log(p)
+
+			if stdout.String() != test.expectStdout {
+				t.Errorf("Expected stdout %q, got %q", test.expectStdout, stdout.String())
+			}
+			if stderr.String() != test.expectStderr {
+				t.Errorf("Expected stderr %q, got %q", test.expectStderr, stderr.String())
+			}
+		})
+	}
+}
+
+// TestConsoleSinkRepeats tests that repeated warnings and errors are shown once, and
+// summarized when they change or clear
+func TestConsoleSinkRepeats(t *testing.T) {
+	t.Parallel()
+
+	at := func(minute int) time.Time { return time.Date(2026, 3, 2, 14, minute, 0, 0, time.UTC) }
+	failedCheck := func(p *Pipeline, minute int, err string) {
+		p.now = func() time.Time { return at(minute) }
+		p.Error("Error in operation: %s", err)
+		p.WarningToUser("Error occurred: %s", err)
+	}
+
+	tests := map[string]struct {
+		log          func(p *Pipeline)
+		expectStdout string
+		expectStderr string
+	}{
+		"Repeated": {
+			log: func(p *Pipeline) {
+				for minute := range 3 {
+					failedCheck(p, minute, "index.lock exists")
+				}
+				_ = p.Close()
+			},
+			expectStdout: "⚠️  Error occurred: index.lock exists\n" +
+				"⚠️  Same warning repeated 2×, last at 14:02:00: Error occurred: index.lock exists\n",
+			expectStderr: "❌ Error in operation: index.lock exists\n" +
+				"❌ Same error repeated 2×, last at 14:02:00: Error in operation: index.lock exists\n",
+		},
+		"Changed": {
+			log: func(p *Pipeline) {
+				failedCheck(p, 0, "A")
+				failedCheck(p, 5, "A")
+				failedCheck(p, 10, "B")
+			},
+			expectStdout: "⚠️  Error occurred: A\n" +
+				"⚠️  Same warning repeated 1×, last at 14:05:00: Error occurred: A\n" +
+				"⚠️  Error occurred: B\n",
+			expectStderr: "❌ Error in operation: A\n" +
+				"❌ Same error repeated 1×, last at 14:05:00: Error in operation: A\n" +
+				"❌ Error in operation: B\n",
+		},
+		"Cleared": {
+			log: func(p *Pipeline) {
+				p.WarningToUser("push failed")
+				p.WarningToUser("push failed")
+				p.Success("Commit #3 created")
+				p.WarningToUser("push failed")
+			},
+			expectStdout: "⚠️  push failed\n" +
+				"⚠️  Same warning repeated 1×, last at 14:00:00: push failed\n" +
+				"✅ Commit #3 created\n" +
+				"⚠️  push failed\n",
+		},
+		"NotRepeated": {
+			log: func(p *Pipeline) {
+				p.WarningToUser("first")
+				p.Info("internal messages do not clear warnings")
+				p.WarningToUser("second")
+				_ = p.Close()
+			},
+			expectStdout: "⚠️  first\n⚠️  second\n",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			p := NewPipeline()
+			p.now = func() time.Time { return at(0) }
+			p.AddSink(NewConsoleSink(&stdout, &stderr, VerbosityInfo), LevelInfo)
+
+			test.log(p)
+
+			if stdout.String() != test.expectStdout {
+				t.Errorf("Expected stdout %q, got %q", test.expectStdout, stdout.String())
+			}
+			if stderr.String() != test.expectStderr {
+				t.Errorf("Expected stderr %q, got %q", test.expectStderr, stderr.String())
+			}
+		})
+	}
+}
+
+// TestJSONSink tests that the JSON sink writes one object per entry and skips status messages
+func TestJSONSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := NewPipeline()
+	p.AddSink(NewJSONSink(&buf), LevelInfo)
+
+	p.WarningToUser("disk %d%% full", 90)
+	p.StatusMessage("banner")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 JSON line, got %d: %q", len(lines), buf.String())
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "disk 90% full" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+}
+
+// TestEventSink tests that events are published and dropped rather than blocking when full
+func TestEventSink(t *testing.T) {
+	t.Parallel()
+
+	events := NewEventSink(1)
+	p := NewPipeline()
+	p.AddSink(events, LevelInfo)
+
+	p.Success("first")
+	p.Success("second") // Dropped: the buffer is full
+
+	entry := <-events.Events()
+	if entry.Message != "first" || entry.Kind != KindSuccess || !entry.User {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, ok := <-events.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+}
+
+// TestNotificationSink tests that only user-facing messages at the sink's level are sent
+func TestNotificationSink(t *testing.T) {
+	t.Parallel()
+
+	var titles, messages []string
+	notify := func(title, message string) error {
+		titles = append(titles, title)
+		messages = append(messages, message)
+		return nil
+	}
+
+	p := NewPipeline()
+	p.AddSink(NewNotificationSink(notify), LevelWarning)
+
+	p.Success("checkpoint")
+	p.Warning("internal")
+	p.WarningToUser("push failed")
+	p.StatusMessage("banner")
+
+	if len(messages) != 1 || messages[0] != "push failed" || titles[0] != "gitbak warning" {
+		t.Errorf("Expected only the user warning to be sent, got titles %v messages %v", titles, messages)
+	}
+}
+
+// TestPipelineClose tests that Close closes every sink and reports failures
+func TestPipelineClose(t *testing.T) {
+	t.Parallel()
+
+	ok := &recordingSink{}
+	failing := &recordingSink{err: errors.New("flush failed")}
+
+	p := NewPipeline()
+	p.AddSink(ok, LevelInfo)
+	p.AddSink(failing, LevelInfo)
+
+	err := p.Close()
+	if err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("Expected the sink error to be returned, got %v", err)
+	}
+	if !ok.closed || !failing.closed {
+		t.Error("Expected every sink to be closed")
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Error("Expected a buffer not to be a terminal")
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if IsTerminal(file) {
+		t.Error("Expected a regular file not to be a terminal")
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxRepeats is how many distinct warnings and errors the console remembers at once. Once
+// it is reached they are summarized and forgotten, so a long run of varied warnings neither
+// grows without bound nor hides one that comes back much later.
+const maxRepeats = 16
+
+// repeatKey identifies a message as repeated: the same kind, addressee and text
+type repeatKey struct {
+	kind    Kind
+	user    bool
+	message string
+}
+
+// repeat counts how often a message was held back, and when it last came
+type repeat struct {
+	count int
+	last  time.Time
+}
+
+// repeats deduplicates the console's warnings and errors. A check that fails the same way
+// every interval would otherwise print the same lines each time: only the first occurrence
+// is shown, and the repeats are summarized, e.g. "Same warning repeated 12×, last at
+// 14:05:00", once a different warning or error comes or the trouble clears, which a
+// user-facing message of another kind, such as a checkpoint's success, is taken to mean.
+type repeats struct {
+	seen  map[repeatKey]*repeat
+	order []repeatKey
+}
+
+// hold reports whether entry repeats a warning or error already shown, counting it if so.
+// A warning or error that is not held back is remembered.
+func (r *repeats) hold(entry Entry) bool {
+	if entry.Kind != KindWarning && entry.Kind != KindError {
+		return false
+	}
+
+	key := repeatKey{kind: entry.Kind, user: entry.User, message: entry.Message}
+	if seen, ok := r.seen[key]; ok {
+		seen.count++
+		seen.last = entry.Time
+		return true
+	}
+
+	if r.seen == nil {
+		r.seen = make(map[repeatKey]*repeat)
+	}
+	r.seen[key] = &repeat{}
+	r.order = append(r.order, key)
+	return false
+}
+
+// changes reports whether entry ends the run of warnings and errors remembered so far, which
+// are then summarized: either a warning or error not seen in the run, as long as any was
+// repeated, or a user-facing message of another kind
+func (r *repeats) changes(entry Entry) bool {
+	if len(r.order) == 0 {
+		return false
+	}
+	if entry.Kind != KindWarning && entry.Kind != KindError {
+		return entry.User
+	}
+	if len(r.order) >= maxRepeats {
+		return true
+	}
+	if _, ok := r.seen[repeatKey{kind: entry.Kind, user: entry.User, message: entry.Message}]; ok {
+		return false
+	}
+	for _, seen := range r.seen {
+		if seen.count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// flush returns the entries summarizing the messages held back, in the order they were
+// first shown, and forgets them all
+func (r *repeats) flush() []Entry {
+	var summaries []Entry
+	for _, key := range r.order {
+		seen := r.seen[key]
+		if seen.count == 0 {
+			continue
+		}
+		what := "warning"
+		if key.kind == KindError {
+			what = "error"
+		}
+		summaries = append(summaries, Entry{
+			Time: seen.last,
+			Kind: key.kind,
+			Message: fmt.Sprintf("Same %s repeated %d×, last at %s: %s",
+				what, seen.count, seen.last.Format("15:04:05"), key.message),
+			User: key.user,
+		})
+	}
+	r.seen, r.order = nil, nil
+	return summaries
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Rotation configures size-based rotation of a log file. When a write would take the file
+// past MaxBytes, it is renamed to <path>.1, older rotations move up one number, and those
+// past MaxFiles are removed. A zero MaxBytes disables rotation.
+type Rotation struct {
+	MaxBytes int64
+	MaxFiles int
+}
+
+// rotatingFile is an append-only log file that rotates itself according to a Rotation
+type rotatingFile struct {
+	path     string
+	rotation Rotation
+	file     *os.File
+	size     int64
+}
+
+// openRotatingFile opens the log file at path for appending, creating it if needed
+func openRotatingFile(path string, rotation Rotation) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, rotation: rotation}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open (re)opens the file at r.path, picking up its current size
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write implements io.Writer, rotating the file first if p would not fit. A record larger
+// than MaxBytes on its own still goes into a fresh file rather than being dropped.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.rotation.MaxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.rotation.MaxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, gitbakErrors.Wrap(err, "failed to rotate log file")
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the current file and its rotations up one number and starts a new file
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	if r.rotation.MaxFiles <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+
+	if err := os.Remove(rotatedName(r.path, r.rotation.MaxFiles)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := r.rotation.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedName(r.path, i), rotatedName(r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, rotatedName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return r.open()
+}
+
+// Sync flushes the current file to disk
+func (r *rotatingFile) Sync() error {
+	return r.file.Sync()
+}
+
+// Close closes the current file
+func (r *rotatingFile) Close() error {
+	return r.file.Close()
+}
+
+// rotatedName returns the name of the nth rotation of the log file at path
+func rotatedName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// PruneLogs removes gitbak log files in dir, and their rotations, that haven't been written
+// to for longer than maxAge, returning the paths it removed. Other files are left alone.
+func PruneLogs(dir string, maxAge time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, gitbakErrors.Wrap(err, "failed to read log directory")
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isLogName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, gitbakErrors.Wrapf(err, "failed to remove %s", path)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// isLogName reports whether name is that of a gitbak log file, gitbak-<repo-hash>.log, or
+// one of its rotations
+func isLogName(name string) bool {
+	if !strings.HasPrefix(name, "gitbak-") {
+		return false
+	}
+	_, rotation, found := strings.Cut(name, ".log")
+	if !found {
+		return false
+	}
+	if rotation == "" {
+		return true
+	}
+	number, ok := strings.CutPrefix(rotation, ".")
+	if !ok || number == "" {
+		return false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotatingFileSink tests that the log file is rotated by size, keeping MaxFiles rotations
+func TestRotatingFileSink(t *testing.T) {
+	tests := map[string]struct {
+		rotation Rotation
+		expected []string
+	}{
+		"KeepsRotations": {
+			rotation: Rotation{MaxBytes: 150, MaxFiles: 2},
+			expected: []string{"gitbak-test.log", "gitbak-test.log.1", "gitbak-test.log.2"},
+		},
+		"NoRotations": {
+			rotation: Rotation{MaxBytes: 150},
+			expected: []string{"gitbak-test.log"},
+		},
+		"Disabled": {
+			expected: []string{"gitbak-test.log"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "gitbak-test.log")
+
+			sink, err := NewRotatingFileSink(path, test.rotation)
+			if err != nil {
+				t.Fatalf("NewRotatingFileSink failed: %v", err)
+			}
+			for i := range 10 {
+				if err := sink.Write(Entry{Time: time.Now(), Kind: KindInfo, Message: strings.Repeat("x", 40) + string(rune('a'+i))}); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("ReadDir failed: %v", err)
+			}
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Fatalf("Expected files %v, got %v", test.expected, names)
+			}
+
+			current, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if !strings.Contains(string(current), "xj") {
+				t.Errorf("Expected the latest entry in the current file, got %q", current)
+			}
+			if test.rotation.MaxBytes > 0 && int64(len(current)) > test.rotation.MaxBytes {
+				t.Errorf("Expected the current file to stay under %d bytes, got %d", test.rotation.MaxBytes, len(current))
+			}
+		})
+	}
+}
+
+// TestPruneLogs tests that only stale gitbak log files are removed
+func TestPruneLogs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-10 * 24 * time.Hour)
+
+	files := map[string]bool{
+		// name: whether it is old
+		"gitbak-aaaa.log":     true,
+		"gitbak-aaaa.log.1":   true,
+		"gitbak-bbbb.log":     false,
+		"gitbak-bbbb.log.1":   true,
+		"gitbak-cccc.log.bak": true,
+		"notes.txt":           true,
+	}
+	for name, stale := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("log"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if stale {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatalf("Chtimes failed: %v", err)
+			}
+		}
+	}
+
+	removed, err := PruneLogs(dir, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneLogs failed: %v", err)
+	}
+
+	var names []string
+	for _, path := range removed {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+	expected := []string{"gitbak-aaaa.log", "gitbak-aaaa.log.1", "gitbak-bbbb.log.1"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v to be removed, got %v", expected, names)
+	}
+	for _, name := range []string{"gitbak-bbbb.log", "gitbak-cccc.log.bak", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", name, err)
+		}
+	}
+
+	if removed, err := PruneLogs(filepath.Join(dir, "missing"), time.Hour); err != nil || len(removed) != 0 {
+		t.Errorf("Expected a missing directory to be ignored, got %v, %v", removed, err)
+	}
+}
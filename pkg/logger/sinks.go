@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// ansiEscape matches ANSI escape sequences such as the colors in git's output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// ConsoleSink renders messages to the terminal with emoji prefixes, showing
+// those its verbosity selects (see Verbosity).
+// With color disabled, ANSI escape sequences are stripped from messages.
+// Warnings and errors repeating ones already shown are summarized rather than printed again.
+type ConsoleSink struct {
+	mu        sync.Mutex
+	stdout    io.Writer
+	stderr    io.Writer
+	verbosity Verbosity
+	noColor   bool
+	repeats   repeats
+}
+
+// NewConsoleSink creates a console sink writing to stdout and stderr, with color enabled
+func NewConsoleSink(stdout, stderr io.Writer, verbosity Verbosity) *ConsoleSink {
+	return &ConsoleSink{stdout: stdout, stderr: stderr, verbosity: verbosity}
+}
+
+// Accepts implements Sink
+func (s *ConsoleSink) Accepts(kind Kind, user bool) bool {
+	switch kind {
+	case KindInfo:
+		if user {
+			return s.verbosity >= VerbosityInfo
+		}
+		return s.verbosity >= VerbosityDebug
+	case KindWarning:
+		if user {
+			return s.verbosity >= VerbosityWarn
+		}
+		return s.verbosity >= VerbosityInfo
+	case KindSuccess:
+		return s.verbosity >= VerbosityWarn
+	case KindTrace:
+		return s.verbosity >= VerbosityTrace
+	default:
+		return true
+	}
+}
+
+// Write implements Sink
+func (s *ConsoleSink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.noColor {
+		entry.Message = ansiEscape.ReplaceAllString(entry.Message, "")
+	}
+
+	if s.repeats.changes(entry) {
+		if err := s.writeRepeats(); err != nil {
+			return err
+		}
+	}
+	if s.repeats.hold(entry) {
+		return nil
+	}
+	return s.write(entry)
+}
+
+// writeRepeats writes the summaries of the warnings and errors held back
+func (s *ConsoleSink) writeRepeats() error {
+	for _, summary := range s.repeats.flush() {
+		if err := s.write(summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write renders entry with the prefix of its kind
+func (s *ConsoleSink) write(entry Entry) error {
+	var err error
+	switch entry.Kind {
+	case KindInfo:
+		prefix := "ℹ️  "
+		if !entry.User {
+			prefix = "🔍 "
+		}
+		_, err = io.WriteString(s.stdout, prefix+entry.Message+"\n")
+	case KindWarning:
+		_, err = io.WriteString(s.stdout, "⚠️  "+entry.Message+"\n")
+	case KindError:
+		_, err = io.WriteString(s.stderr, "❌ "+entry.Message+"\n")
+	case KindSuccess:
+		_, err = io.WriteString(s.stdout, "✅ "+entry.Message+"\n")
+	case KindStatus:
+		_, err = io.WriteString(s.stdout, entry.Message+"\n")
+	case KindTrace:
+		_, err = io.WriteString(s.stdout, "🔬 "+entry.Message+"\n")
+	}
+	return err
+}
+
+// Close implements Sink, summarizing any warnings and errors held back.
+// The console itself is never closed.
+func (s *ConsoleSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeRepeats()
+}
+
+// SetStdout sets the writer for user-facing messages
+func (s *ConsoleSink) SetStdout(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stdout = w
+}
+
+// SetStderr sets the writer for errors
+func (s *ConsoleSink) SetStderr(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stderr = w
+}
+
+// SetColor enables or disables colored output
+func (s *ConsoleSink) SetColor(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noColor = !enabled
+}
+
+// IsTerminal reports whether w is a terminal, as opposed to e.g. a file or pipe
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// EnableVirtualTerminal reports whether w is a terminal that shows ANSI escape sequences,
+// such as colors and the dashboard's redrawing, asking Windows consoles to first.
+// Use it rather than IsTerminal before writing escape sequences.
+func EnableVirtualTerminal(w io.Writer) bool {
+	return IsTerminal(w) && enableVirtualTerminal(w.(*os.File))
+}
+
+// handlerSink writes entries as structured records through a slog.Handler, such as the
+// syslog and journald handlers or one given to NewHandlerSink.
+// Status messages are screen furniture (banners, summaries) and are not recorded.
+type handlerSink struct {
+	handler slog.Handler
+	file    *rotatingFile
+
+	// conn is the connection the handler writes to, if any, such as to syslog
+	conn io.Closer
+}
+
+// NewTextSink creates a sink writing logfmt-style records to w
+func NewTextSink(w io.Writer) Sink {
+	return &handlerSink{handler: slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})}
+}
+
+// NewJSONSink creates a sink writing one JSON object per entry to w
+func NewJSONSink(w io.Writer) Sink {
+	return &handlerSink{handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})}
+}
+
+// NewFileSink creates a text sink appending to the file at path, creating its directory if needed.
+// Closing the sink syncs and closes the file.
+func NewFileSink(path string) (Sink, error) {
+	return NewRotatingFileSink(path, Rotation{})
+}
+
+// NewRotatingFileSink is like NewFileSink, rotating the file as configured by rotation
+func NewRotatingFileSink(path string, rotation Rotation) (Sink, error) {
+	f, err := openLogFile(path, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlerSink{
+		handler: slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		file:    f,
+	}, nil
+}
+
+// openLogFile opens the log file at path for appending, creating its directory if needed
+func openLogFile(path string, rotation Rotation) (*rotatingFile, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return openRotatingFile(path, rotation)
+}
+
+// Accepts implements Sink
+func (s *handlerSink) Accepts(kind Kind, _ bool) bool {
+	return kind != KindStatus
+}
+
+// Write implements Sink
+func (s *handlerSink) Write(entry Entry) error {
+	level := slog.LevelInfo
+	switch entry.Level() {
+	case LevelWarning:
+		level = slog.LevelWarn
+	case LevelError:
+		level = slog.LevelError
+	}
+	if entry.Kind == KindTrace {
+		level = slog.LevelDebug
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	return s.handler.Handle(context.Background(), record)
+}
+
+// Close implements Sink
+func (s *handlerSink) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	if s.file == nil {
+		return nil
+	}
+	// Sync ensures any buffered data is flushed to disk before closing
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+// EventSink publishes entries on a channel for integrations that follow a
+// session live, such as a status endpoint. Entries are dropped rather than
+// blocking the logger when the consumer falls behind.
+type EventSink struct {
+	events chan Entry
+	once   sync.Once
+}
+
+// NewEventSink creates an event sink buffering up to size entries
+func NewEventSink(size int) *EventSink {
+	return &EventSink{events: make(chan Entry, size)}
+}
+
+// Events returns the channel entries are published on. It is closed by Close.
+func (s *EventSink) Events() <-chan Entry {
+	return s.events
+}
+
+// Accepts implements Sink
+func (s *EventSink) Accepts(Kind, bool) bool {
+	return true
+}
+
+// Write implements Sink
+func (s *EventSink) Write(entry Entry) error {
+	select {
+	case s.events <- entry:
+	default:
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *EventSink) Close() error {
+	s.once.Do(func() { close(s.events) })
+	return nil
+}
+
+// NotifyFunc delivers a notification, for example as a desktop notification
+type NotifyFunc func(title, message string) error
+
+// notificationSink forwards user-facing entries to a NotifyFunc
+type notificationSink struct {
+	notify NotifyFunc
+}
+
+// NewNotificationSink creates a sink that sends user-facing messages through notify.
+// Status messages are never sent. Combine it with a minimum level when adding it
+// to a Pipeline (e.g. LevelWarning) to avoid a notification per checkpoint.
+func NewNotificationSink(notify NotifyFunc) Sink {
+	return &notificationSink{notify: notify}
+}
+
+// Accepts implements Sink
+func (s *notificationSink) Accepts(kind Kind, user bool) bool {
+	return user && kind != KindStatus
+}
+
+// Write implements Sink
+func (s *notificationSink) Write(entry Entry) error {
+	title := "gitbak"
+	switch entry.Kind {
+	case KindWarning:
+		title = "gitbak warning"
+	case KindError:
+		title = "gitbak error"
+	}
+	return s.notify(title, entry.Message)
+}
+
+// Close implements Sink
+func (s *notificationSink) Close() error {
+	return nil
+}
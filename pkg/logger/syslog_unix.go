@@ -0,0 +1,60 @@
+//go:build !windows
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"log/syslog"
+)
+
+// syslogHandler is a slog.Handler sending each record to syslog at the priority of its level
+type syslogHandler struct {
+	lineHandler
+	w *syslog.Writer
+}
+
+// NewSyslogSink creates a sink sending entries to the local syslog daemon, tagged with tag
+func NewSyslogSink(tag string) (Sink, error) {
+	return dialSyslogSink("", "", tag)
+}
+
+// dialSyslogSink is like NewSyslogSink, sending entries to the syslog daemon at raddr on
+// network, or the local one if network is empty
+func dialSyslogSink(network, raddr, tag string) (Sink, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &handlerSink{handler: newSyslogHandler(w), conn: w}, nil
+}
+
+// newSyslogHandler creates a handler writing to w
+func newSyslogHandler(w *syslog.Writer) *syslogHandler {
+	return &syslogHandler{lineHandler: lineHandler{level: slog.LevelDebug}, w: w}
+}
+
+// Handle implements slog.Handler
+func (h *syslogHandler) Handle(_ context.Context, record slog.Record) error {
+	message := h.format(record)
+	switch {
+	case record.Level >= slog.LevelError:
+		return h.w.Err(message)
+	case record.Level >= slog.LevelWarn:
+		return h.w.Warning(message)
+	case record.Level >= slog.LevelInfo:
+		return h.w.Info(message)
+	default:
+		return h.w.Debug(message)
+	}
+}
+
+// WithAttrs implements slog.Handler
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{lineHandler: h.withAttrs(attrs), w: h.w}
+}
+
+// WithGroup implements slog.Handler
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{lineHandler: h.withGroup(name), w: h.w}
+}
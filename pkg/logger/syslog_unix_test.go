@@ -0,0 +1,61 @@
+//go:build !windows
+
+package logger
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSyslogSink tests that entries are sent to syslog at the priority of their level
+func TestSyslogSink(t *testing.T) {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "syslog")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+	daemon, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = daemon.Close() }()
+
+	sink, err := dialSyslogSink("unixgram", path, "gitbak")
+	if err != nil {
+		t.Fatalf("dialSyslogSink failed: %v", err)
+	}
+	defer func() { _ = sink.Close() }()
+
+	tests := []struct {
+		kind     Kind
+		priority string
+	}{
+		{kind: KindError, priority: "<11>"},
+		{kind: KindWarning, priority: "<12>"},
+		{kind: KindSuccess, priority: "<14>"},
+		{kind: KindTrace, priority: "<15>"},
+	}
+
+	buf := make([]byte, 4096)
+	for _, test := range tests {
+		if err := sink.Write(Entry{Time: time.Now(), Kind: test.kind, Message: "checkpoint #3"}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		_ = daemon.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := daemon.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to receive a message: %v", err)
+		}
+		message := string(buf[:n])
+		if !strings.HasPrefix(message, test.priority) || !strings.Contains(message, "gitbak[") || !strings.HasSuffix(strings.TrimSpace(message), "checkpoint #3") {
+			t.Errorf("Expected a message with priority %s, got %q", test.priority, message)
+		}
+	}
+}
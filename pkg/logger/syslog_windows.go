@@ -0,0 +1,10 @@
+//go:build windows
+
+package logger
+
+import gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+
+// NewSyslogSink reports that syslog is not available on Windows
+func NewSyslogSink(string) (Sink, error) {
+	return nil, gitbakErrors.Wrap(gitbakErrors.ErrInvalidConfiguration, "syslog is not available on Windows")
+}
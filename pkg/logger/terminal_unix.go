@@ -0,0 +1,11 @@
+//go:build !windows
+
+package logger
+
+import "os"
+
+// enableVirtualTerminal reports whether the terminal f interprets ANSI escape
+// sequences, which terminals here always do
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verbosity selects which messages the console shows. Each verbosity shows
+// everything the ones below it do; errors and status messages are always shown.
+type Verbosity int
+
+const (
+	// VerbosityError shows only errors
+	VerbosityError Verbosity = iota
+	// VerbosityWarn adds warnings and success messages
+	VerbosityWarn
+	// VerbosityInfo adds informational messages and internal warnings
+	VerbosityInfo
+	// VerbosityDebug adds internal informational messages
+	VerbosityDebug
+	// VerbosityTrace adds trace messages, such as every git command run
+	VerbosityTrace
+)
+
+// Verbosities lists the verbosity names, from least to most output
+var Verbosities = []string{"error", "warn", "info", "debug", "trace"}
+
+// String returns the name of the verbosity
+func (v Verbosity) String() string {
+	if v < VerbosityError || int(v) >= len(Verbosities) {
+		return fmt.Sprintf("Verbosity(%d)", int(v))
+	}
+	return Verbosities[v]
+}
+
+// ParseVerbosity returns the verbosity with the given name
+func ParseVerbosity(name string) (Verbosity, error) {
+	for i, v := range Verbosities {
+		if strings.EqualFold(name, v) {
+			return Verbosity(i), nil
+		}
+	}
+	return VerbosityInfo, fmt.Errorf("unknown verbosity %q (expected one of %s)", name, strings.Join(Verbosities, ", "))
+}
+
+// verbosityFor returns the verbosity of the verbose switch the constructors take
+func verbosityFor(verbose bool) Verbosity {
+	if verbose {
+		return VerbosityInfo
+	}
+	return VerbosityWarn
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseVerbosity tests that verbosities are parsed from their names
+func TestParseVerbosity(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		name        string
+		expect      Verbosity
+		expectError bool
+	}{
+		"Error":      {name: "error", expect: VerbosityError},
+		"Warn":       {name: "warn", expect: VerbosityWarn},
+		"Info":       {name: "info", expect: VerbosityInfo},
+		"Debug":      {name: "debug", expect: VerbosityDebug},
+		"Trace":      {name: "trace", expect: VerbosityTrace},
+		"UpperCase":  {name: "DEBUG", expect: VerbosityDebug},
+		"Unknown":    {name: "loud", expect: VerbosityInfo, expectError: true},
+		"Empty":      {name: "", expect: VerbosityInfo, expectError: true},
+		"OldWarning": {name: "warning", expect: VerbosityInfo, expectError: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			verbosity, err := ParseVerbosity(test.name)
+			if (err != nil) != test.expectError {
+				t.Fatalf("Expected error: %v, got %v", test.expectError, err)
+			}
+			if verbosity != test.expect {
+				t.Errorf("Expected %s, got %s", test.expect, verbosity)
+			}
+			if !test.expectError && verbosity.String() != strings.ToLower(test.name) {
+				t.Errorf("Expected the verbosity to be named %q, got %q", strings.ToLower(test.name), verbosity.String())
+			}
+		})
+	}
+}
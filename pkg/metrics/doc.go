@@ -0,0 +1,22 @@
+// Package metrics collects gitbak's session metrics and renders them in the
+// Prometheus text exposition format, for central monitoring of many sessions.
+//
+// A Collector is handed to the monitoring loop, which records every check and
+// checkpoint in it, and its Handler serves the current values on /metrics:
+//
+//	collector := metrics.NewCollector()
+//	mux.Handle("/metrics", collector.Handler())
+//
+// # Metrics
+//
+//   - gitbak_checks_total: Checks for changes, successful or not
+//   - gitbak_commits_total: Checkpoints created
+//   - gitbak_errors_total: Checks that failed
+//   - gitbak_consecutive_errors: Repeats of the current error; 0 after a successful check
+//   - gitbak_last_commit_timestamp_seconds: Unix time of the latest checkpoint; 0 before the first
+//   - gitbak_files_changed_per_commit: Histogram of the number of files each checkpoint changed
+//   - gitbak_check_duration_seconds: Histogram of how long each check took, including the commit
+//
+// The format is written directly rather than through the Prometheus client
+// library, which would be a large dependency for a handful of values.
+package metrics
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// contentType is the media type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// fileBuckets and durationBuckets are the upper bounds of the histogram buckets
+var (
+	fileBuckets     = []float64{1, 2, 5, 10, 25, 50, 100, 250, 1000}
+	durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+)
+
+// Collector records the metrics of a session. It is safe for concurrent use.
+type Collector struct {
+	mu sync.Mutex
+
+	checks            int
+	commits           int
+	errors            int
+	consecutiveErrors int
+	lastCommit        time.Time
+	filesChanged      histogram
+	checkDuration     histogram
+}
+
+// NewCollector creates a Collector with all metrics at zero
+func NewCollector() *Collector {
+	return &Collector{
+		filesChanged:  newHistogram(fileBuckets),
+		checkDuration: newHistogram(durationBuckets),
+	}
+}
+
+// ObserveCheck records a check that took duration. consecutiveErrors is the number
+// of times in a row the check's error has now occurred, or 0 if it succeeded.
+func (c *Collector) ObserveCheck(duration time.Duration, err error, consecutiveErrors int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks++
+	if err != nil {
+		c.errors++
+	}
+	c.consecutiveErrors = consecutiveErrors
+	c.checkDuration.observe(duration.Seconds())
+}
+
+// ObserveCommit records a checkpoint created at the given time, changing files files
+func (c *Collector) ObserveCommit(at time.Time, files int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.commits++
+	c.lastCommit = at
+	c.filesChanged.observe(float64(files))
+}
+
+// WriteTo writes the current values in the Prometheus text exposition format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lastCommit := 0.0
+	if !c.lastCommit.IsZero() {
+		lastCommit = float64(c.lastCommit.UnixNano()) / 1e9
+	}
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	writeMetric(cw, "gitbak_checks_total", "counter", "Checks for changes, successful or not.", float64(c.checks))
+	writeMetric(cw, "gitbak_commits_total", "counter", "Checkpoints created.", float64(c.commits))
+	writeMetric(cw, "gitbak_errors_total", "counter", "Checks that failed.", float64(c.errors))
+	writeMetric(cw, "gitbak_consecutive_errors", "gauge", "Times in a row the current error has occurred.", float64(c.consecutiveErrors))
+	writeMetric(cw, "gitbak_last_commit_timestamp_seconds", "gauge", "Unix time of the latest checkpoint.", lastCommit)
+	c.filesChanged.write(cw, "gitbak_files_changed_per_commit", "Files changed by each checkpoint.")
+	c.checkDuration.write(cw, "gitbak_check_duration_seconds", "Time taken by each check, including the checkpoint.")
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// Handler returns an http.Handler serving the metrics
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = c.WriteTo(w)
+	})
+}
+
+// writeMetric writes a single-valued metric with its HELP and TYPE lines
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, formatValue(value))
+}
+
+// formatValue renders a sample value the way Prometheus expects it
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// histogram counts observations into cumulative buckets
+type histogram struct {
+	bounds []float64
+	counts []int
+	count  int
+	sum    float64
+}
+
+// newHistogram creates a histogram with the given ascending bucket upper bounds
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]int, len(bounds))}
+}
+
+// observe records a value
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// write writes the histogram's buckets, sum and count
+func (h *histogram) write(w io.Writer, name, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range h.bounds {
+		_, _ = fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatValue(bound), h.counts[i])
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	_, _ = fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, formatValue(h.sum), name, h.count)
+}
+
+// countingWriter counts the bytes written and keeps the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// Write implements io.Writer
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
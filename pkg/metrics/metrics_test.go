@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCollectorWriteTo tests the rendered metrics after a few checks and checkpoints
+func TestCollectorWriteTo(t *testing.T) {
+	t.Parallel()
+
+	c := NewCollector()
+	c.ObserveCheck(200*time.Millisecond, nil, 0)
+	c.ObserveCommit(time.Unix(1700000000, 500000000), 3)
+	c.ObserveCheck(2*time.Second, errors.New("index.lock exists"), 1)
+	c.ObserveCheck(40*time.Millisecond, errors.New("index.lock exists"), 2)
+
+	var b strings.Builder
+	if _, err := c.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE gitbak_checks_total counter\ngitbak_checks_total 3\n",
+		"gitbak_commits_total 1\n",
+		"gitbak_errors_total 2\n",
+		"# TYPE gitbak_consecutive_errors gauge\ngitbak_consecutive_errors 2\n",
+		"gitbak_last_commit_timestamp_seconds 1.7000000005e+09\n",
+		"# TYPE gitbak_files_changed_per_commit histogram\n",
+		"gitbak_files_changed_per_commit_bucket{le=\"2\"} 0\n",
+		"gitbak_files_changed_per_commit_bucket{le=\"5\"} 1\n",
+		"gitbak_files_changed_per_commit_bucket{le=\"+Inf\"} 1\n",
+		"gitbak_files_changed_per_commit_sum 3\ngitbak_files_changed_per_commit_count 1\n",
+		"gitbak_check_duration_seconds_bucket{le=\"0.05\"} 1\n",
+		"gitbak_check_duration_seconds_bucket{le=\"0.25\"} 2\n",
+		"gitbak_check_duration_seconds_bucket{le=\"2.5\"} 3\n",
+		"gitbak_check_duration_seconds_count 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestCollectorHandler tests serving the metrics over HTTP
+func TestCollectorHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		method     string
+		wantStatus int
+	}{
+		"Get":  {method: http.MethodGet, wantStatus: http.StatusOK},
+		"Post": {method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			NewCollector().Handler().ServeHTTP(rec, httptest.NewRequest(test.method, "/metrics", nil))
+
+			if rec.Code != test.wantStatus {
+				t.Fatalf("Expected status %d, got %d", test.wantStatus, rec.Code)
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+				t.Errorf("Expected the Prometheus text format, got %q", ct)
+			}
+			if !strings.Contains(rec.Body.String(), "gitbak_commits_total 0\n") {
+				t.Errorf("Expected zeroed metrics, got:\n%s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package mirror pushes gitbak session branches to named remote destinations.
+//
+// Mirrors receive a session branch as refs/gitbak/<branch> (git.MirrorRefPrefix), outside
+// the remote's branches. The first push of a branch must fast-forward that ref; each later
+// push is a --force-with-lease against the commit pushed before it, so that a rewritten
+// session branch replaces its own mirror but never a ref that someone else has updated.
+//
+// A mirror profile names a destination and describes how often it is pushed to
+// and how much upload bandwidth it may use. Several profiles can be active at once,
+// so a session can be mirrored to a NAS on every checkpoint and to a cloud remote
+// only once an hour.
+//
+// # Core Components
+//
+//   - Profile: A named destination with a schedule and an optional bandwidth cap
+//   - Scheduler: Decides which profiles are due after each checkpoint and pushes them
+//   - Pusher: Interface for performing the push (implemented by git.Repository)
+//
+// # Profile Syntax
+//
+// Profiles are written as name=remote followed by optional comma-separated settings.
+// The name may be left out, naming the profile after its remote:
+//
+//	nas=ssh://nas.local/backup/project.git
+//	cloud=origin,every=1h,limit=256k
+//	backup
+//
+// The remote is anything git push accepts: a remote name, a URL or a path.
+// "every" is a Go duration; if omitted, the mirror is pushed after every checkpoint.
+// "limit" caps upload bandwidth in bytes per second, with optional k or m suffixes.
+//
+// # Bandwidth Limits
+//
+// Git has no native upload throttling, so limits are applied by routing the push
+// through gitbak itself acting as GIT_SSH: the gitbak binary runs ssh and feeds it
+// the pack data at the configured rate. Limits therefore only apply to ssh remotes;
+// pushes over HTTP(S) or to local paths are not throttled.
+//
+// # Thread Safety
+//
+// A Scheduler is safe to notify from any goroutine. Pushes are performed one at a
+// time by the goroutine running Scheduler.Run, so a slow mirror never delays checkpoints.
+package mirror
@@ -0,0 +1,140 @@
+package mirror
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Profile is a named mirror destination.
+type Profile struct {
+	// Name identifies the profile in messages.
+	Name string
+
+	// Remote is the git push destination: a remote name, URL or path.
+	Remote string
+
+	// Every is the minimum time between pushes. Zero pushes after every checkpoint.
+	Every time.Duration
+
+	// RateLimit caps upload bandwidth in bytes per second. Zero means unlimited.
+	RateLimit int64
+}
+
+// String returns the profile in the syntax accepted by ParseProfile.
+func (p Profile) String() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s=%s", p.Name, p.Remote)
+	if p.Every > 0 {
+		_, _ = fmt.Fprintf(&b, ",every=%s", p.Every)
+	}
+	if p.RateLimit > 0 {
+		_, _ = fmt.Fprintf(&b, ",limit=%d", p.RateLimit)
+	}
+	return b.String()
+}
+
+// ParseProfile parses a profile written as [name=]remote[,every=<duration>][,limit=<rate>].
+// Without a name, the profile is named after its remote.
+func ParseProfile(spec string) (Profile, error) {
+	fields := strings.Split(spec, ",")
+
+	name, remote, ok := strings.Cut(strings.TrimSpace(fields[0]), "=")
+	if !ok {
+		remote = name
+	}
+	name = strings.TrimSpace(name)
+	remote = strings.TrimSpace(remote)
+	if name == "" || remote == "" {
+		return Profile{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"invalid mirror %q (expected [name=]remote[,every=<duration>][,limit=<rate>])", spec)
+	}
+
+	profile := Profile{Name: name, Remote: remote}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return Profile{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+				"invalid setting %q in mirror %q (expected key=value)", field, name)
+		}
+
+		switch key {
+		case "every":
+			every, err := time.ParseDuration(value)
+			if err != nil || every < 0 {
+				return Profile{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+					"invalid schedule %q in mirror %q (expected a duration like 30m or 1h)", value, name)
+			}
+			profile.Every = every
+		case "limit":
+			limit, err := ParseRate(value)
+			if err != nil {
+				return Profile{}, gitbakErrors.Wrapf(err, "invalid limit in mirror %q", name)
+			}
+			profile.RateLimit = limit
+		default:
+			return Profile{}, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+				"unknown setting %q in mirror %q (expected every or limit)", key, name)
+		}
+	}
+
+	return profile, nil
+}
+
+// ParseProfiles parses a list of profile specs, rejecting duplicate names.
+func ParseProfiles(specs []string) ([]Profile, error) {
+	profiles := make([]Profile, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+
+	for _, spec := range specs {
+		profile, err := ParseProfile(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[profile.Name] {
+			return nil, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration, "duplicate mirror name %q", profile.Name)
+		}
+		seen[profile.Name] = true
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// ParseRate parses a bandwidth in bytes per second, with an optional k (KiB) or m (MiB) suffix.
+func ParseRate(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1024
+		s = strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1024 * 1024
+		s = strings.TrimSuffix(s, "m")
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, gitbakErrors.Wrapf(gitbakErrors.ErrInvalidConfiguration,
+			"invalid rate %q (expected a positive number of bytes per second, e.g. 512k or 2m)", value)
+	}
+
+	return n * multiplier, nil
+}
+
+// throttled reports whether the profile's bandwidth cap can be applied to its remote.
+// Caps are enforced through the ssh transport only.
+func (p Profile) throttled() bool {
+	if p.RateLimit <= 0 {
+		return false
+	}
+	lower := strings.ToLower(p.Remote)
+	return !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") &&
+		!strings.HasPrefix(lower, "file://") && !strings.HasPrefix(p.Remote, "/") && !strings.HasPrefix(p.Remote, ".")
+}
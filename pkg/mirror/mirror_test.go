@@ -0,0 +1,124 @@
+package mirror
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+func TestParseProfile(t *testing.T) {
+	tests := map[string]struct {
+		spec        string
+		expected    Profile
+		expectError bool
+	}{
+		"RemoteOnly": {
+			spec:     "nas=ssh://nas.local/backup.git",
+			expected: Profile{Name: "nas", Remote: "ssh://nas.local/backup.git"},
+		},
+		"ScheduleAndLimit": {
+			spec:     "cloud=origin,every=1h,limit=256k",
+			expected: Profile{Name: "cloud", Remote: "origin", Every: time.Hour, RateLimit: 256 * 1024},
+		},
+		"Whitespace": {
+			spec:     " usb = /mnt/usb/project.git , every=30m ",
+			expected: Profile{Name: "usb", Remote: "/mnt/usb/project.git", Every: 30 * time.Minute},
+		},
+		"MissingRemote": {
+			spec:        "nas=",
+			expectError: true,
+		},
+		"Empty": {
+			spec:        " ",
+			expectError: true,
+		},
+		"MissingName": {
+			spec:        "=ssh://nas.local/backup.git",
+			expectError: true,
+		},
+		"BareRemote": {
+			spec:     "backup,every=1h",
+			expected: Profile{Name: "backup", Remote: "backup", Every: time.Hour},
+		},
+		"BadSchedule": {
+			spec:        "nas=origin,every=hourly",
+			expectError: true,
+		},
+		"BadLimit": {
+			spec:        "nas=origin,limit=fast",
+			expectError: true,
+		},
+		"UnknownSetting": {
+			spec:        "nas=origin,force=true",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			profile, err := ParseProfile(test.spec)
+
+			if test.expectError {
+				if !errors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+					t.Errorf("Expected ErrInvalidConfiguration, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if profile != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, profile)
+			}
+
+			reparsed, err := ParseProfile(profile.String())
+			if err != nil || reparsed != profile {
+				t.Errorf("Expected String() to round-trip, got %+v (%v)", reparsed, err)
+			}
+		})
+	}
+}
+
+func TestParseProfilesRejectsDuplicates(t *testing.T) {
+	_, err := ParseProfiles([]string{"nas=one", "nas=two"})
+	if !errors.Is(err, gitbakErrors.ErrInvalidConfiguration) {
+		t.Errorf("Expected duplicate names to be rejected, got %v", err)
+	}
+
+	profiles, err := ParseProfiles([]string{"nas=one", "cloud=two"})
+	if err != nil || len(profiles) != 2 {
+		t.Errorf("Expected 2 profiles, got %v (%v)", profiles, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := map[string]struct {
+		value       string
+		expected    int64
+		expectError bool
+	}{
+		"Bytes":     {value: "1000", expected: 1000},
+		"Kibibytes": {value: "512k", expected: 512 * 1024},
+		"Mebibytes": {value: "2M", expected: 2 * 1024 * 1024},
+		"Zero":      {value: "0", expectError: true},
+		"Negative":  {value: "-1k", expectError: true},
+		"Garbage":   {value: "fast", expectError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rate, err := ParseRate(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got rate %d", test.value, rate)
+				}
+				return
+			}
+			if err != nil || rate != test.expected {
+				t.Errorf("Expected %d, got %d (%v)", test.expected, rate, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// maxCheckInterval bounds how long a due scheduled push can wait after its
+// interval has elapsed when no new checkpoint arrives.
+const maxCheckInterval = time.Minute
+
+// finalPushTimeout bounds the last push to each profile, made once the session stops
+const finalPushTimeout = 5 * time.Second
+
+// Pusher pushes a branch to its mirror ref on a remote and returns the commit pushed.
+// An empty lease only allows fast-forwarding the mirror ref; otherwise it is only
+// overwritten while it still points at lease.
+// env, if non-nil, is the complete environment for the git process.
+type Pusher interface {
+	PushMirror(ctx context.Context, remote, branch, lease string, env []string) (string, error)
+}
+
+// Scheduler pushes the session branch to each mirror profile when it is due.
+// A profile is due once it has unpushed checkpoints and its interval has elapsed
+// since its last successful push.
+type Scheduler struct {
+	profiles []Profile
+	pusher   Pusher
+	logger   logger.Logger
+
+	// executable is the gitbak binary used as the throttling ssh transport.
+	// If empty, bandwidth limits are not applied.
+	executable string
+
+	notify chan string
+	now    func() time.Time
+
+	// The following fields are only accessed by the goroutine running Run.
+	branch   string
+	pending  map[string]bool
+	lastPush map[string]time.Time
+
+	// pushed is the commit last pushed to each profile, the lease for its next push
+	pushed map[string]string
+}
+
+// NewScheduler creates a Scheduler for the given profiles.
+func NewScheduler(profiles []Profile, pusher Pusher, log logger.Logger) *Scheduler {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = ""
+	}
+
+	return &Scheduler{
+		profiles:   profiles,
+		pusher:     pusher,
+		logger:     log,
+		executable: executable,
+		notify:     make(chan string, 1),
+		now:        time.Now,
+		pending:    make(map[string]bool, len(profiles)),
+		lastPush:   make(map[string]time.Time, len(profiles)),
+		pushed:     make(map[string]string, len(profiles)),
+	}
+}
+
+// Notify records that a checkpoint was committed to branch. It never blocks.
+func (s *Scheduler) Notify(branch string) {
+	select {
+	case s.notify <- branch:
+	default:
+		// A notification is already queued; it covers this checkpoint too
+	}
+}
+
+// Run pushes due mirrors until ctx is canceled. It then pushes every profile with
+// unpushed checkpoints one last time, whatever their schedules, taking at most
+// finalPushTimeout, so that stopping the session leaves no checkpoint unmirrored.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.describe()
+
+	ticker := time.NewTicker(s.checkInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.finish(ctx)
+			return
+		case branch := <-s.notify:
+			s.checkpoint(branch)
+			s.pushDue(ctx)
+		case <-ticker.C:
+			s.pushDue(ctx)
+		}
+	}
+}
+
+// finish pushes every profile with unpushed checkpoints, including a checkpoint
+// notified just before ctx was canceled
+func (s *Scheduler) finish(ctx context.Context) {
+	select {
+	case branch := <-s.notify:
+		s.checkpoint(branch)
+	default:
+	}
+
+	finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalPushTimeout)
+	defer cancel()
+	s.pushProfiles(finalCtx, true)
+}
+
+// checkpoint marks every profile as having unpushed checkpoints on branch
+func (s *Scheduler) checkpoint(branch string) {
+	if branch != s.branch {
+		// Leases are only known for the branch pushed so far
+		clear(s.pushed)
+	}
+	s.branch = branch
+	for _, p := range s.profiles {
+		s.pending[p.Name] = true
+	}
+}
+
+// pushDue pushes every profile that is due. Failed pushes stay pending and are retried later.
+func (s *Scheduler) pushDue(ctx context.Context) {
+	s.pushProfiles(ctx, false)
+}
+
+// pushProfiles pushes every profile that is due or, if final, every profile with unpushed
+// checkpoints, as there is no later push to retry it
+func (s *Scheduler) pushProfiles(ctx context.Context, final bool) {
+	for _, p := range s.profiles {
+		if ctx.Err() != nil {
+			if final {
+				s.logger.WarningToUser("Ran out of time to push the remaining mirrors at exit")
+			}
+			return
+		}
+		if !s.pending[p.Name] {
+			continue
+		}
+		if last, ok := s.lastPush[p.Name]; ok && !final && s.now().Sub(last) < p.Every {
+			continue
+		}
+
+		commit, err := s.pusher.PushMirror(ctx, p.Remote, s.branch, s.pushed[p.Name], s.environment(p))
+		if err != nil {
+			if ctx.Err() != nil && !final {
+				return
+			}
+			s.logger.Warning("Mirror %s: push to %s failed: %v", p.Name, p.Remote, err)
+			if final {
+				s.logger.WarningToUser("Mirror '%s' push failed at exit, its latest checkpoints are not mirrored: %v", p.Name, err)
+			} else {
+				s.logger.WarningToUser("Mirror '%s' push failed, will retry: %v", p.Name, err)
+			}
+			continue
+		}
+
+		s.logger.Info("Mirror %s: pushed %s to %s", p.Name, s.branch, p.Remote)
+		s.pending[p.Name] = false
+		s.lastPush[p.Name] = s.now()
+		s.pushed[p.Name] = commit
+	}
+}
+
+// environment returns the git environment for pushing to a profile,
+// or nil if the push needs no special environment.
+func (s *Scheduler) environment(p Profile) []string {
+	if !p.throttled() || s.executable == "" {
+		return nil
+	}
+
+	env := make([]string, 0, len(os.Environ())+4)
+	for _, kv := range os.Environ() {
+		// GIT_SSH_COMMAND takes precedence over every other ssh setting, so it is
+		// replaced by the wrapper and handed to it to run instead of plain ssh
+		if strings.HasPrefix(kv, "GIT_SSH_COMMAND=") {
+			env = append(env, sshCommandEnvVar+"="+strings.TrimPrefix(kv, "GIT_SSH_COMMAND="))
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	return append(env,
+		"GIT_SSH_COMMAND="+shellQuote(s.executable),
+		"GIT_SSH_VARIANT=ssh",
+		RateEnvVar+"="+strconv.FormatInt(p.RateLimit, 10),
+	)
+}
+
+// checkInterval returns how often to look for profiles whose interval has elapsed
+func (s *Scheduler) checkInterval() time.Duration {
+	interval := maxCheckInterval
+	for _, p := range s.profiles {
+		if p.Every > 0 && p.Every < interval {
+			interval = p.Every
+		}
+	}
+	return interval
+}
+
+// describe tells the user where checkpoints are mirrored
+func (s *Scheduler) describe() {
+	descriptions := make([]string, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		var details []string
+		if p.Every > 0 {
+			details = append(details, "every "+p.Every.String())
+		} else {
+			details = append(details, "every checkpoint")
+		}
+		if p.RateLimit > 0 {
+			details = append(details, fmt.Sprintf("limit %s/s", formatRate(p.RateLimit)))
+			if !p.throttled() {
+				s.logger.WarningToUser("Mirror '%s': bandwidth limits only apply to ssh remotes and will be ignored for %s", p.Name, p.Remote)
+			}
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", p.Name, strings.Join(details, ", ")))
+	}
+	s.logger.InfoToUser("🪞 Mirroring checkpoints to %s", strings.Join(descriptions, ", "))
+}
+
+// formatRate renders a byte rate with a binary unit suffix
+func formatRate(rate int64) string {
+	switch {
+	case rate >= 1024*1024 && rate%(1024*1024) == 0:
+		return fmt.Sprintf("%dMiB", rate/(1024*1024))
+	case rate >= 1024 && rate%1024 == 0:
+		return fmt.Sprintf("%dKiB", rate/1024)
+	default:
+		return fmt.Sprintf("%dB", rate)
+	}
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
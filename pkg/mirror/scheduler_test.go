@@ -0,0 +1,210 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// fakePusher records pushes and their leases, and fails for the configured remotes.
+// Each push reports a new commit, c1, c2 and so on.
+type fakePusher struct {
+	pushes []string
+	leases []string
+	envs   map[string][]string
+	fail   map[string]bool
+}
+
+func (f *fakePusher) PushMirror(ctx context.Context, remote, branch, lease string, env []string) (string, error) {
+	if f.fail[remote] {
+		return "", errors.New("network unreachable")
+	}
+	f.pushes = append(f.pushes, remote+":"+branch)
+	f.leases = append(f.leases, lease)
+	if f.envs == nil {
+		f.envs = make(map[string][]string)
+	}
+	f.envs[remote] = env
+	return fmt.Sprintf("c%d", len(f.pushes)), nil
+}
+
+func newTestScheduler(profiles []Profile, pusher Pusher, now *time.Time) *Scheduler {
+	s := NewScheduler(profiles, pusher, logger.NewWithOutput(false, "", false, io.Discard, io.Discard))
+	s.now = func() time.Time { return *now }
+	s.executable = "/usr/local/bin/gitbak"
+	return s
+}
+
+func TestSchedulerHonorsPerProfileSchedules(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pusher := &fakePusher{}
+	s := newTestScheduler([]Profile{
+		{Name: "nas", Remote: "nas"},
+		{Name: "cloud", Remote: "cloud", Every: time.Hour},
+	}, pusher, &now)
+	ctx := context.Background()
+
+	s.checkpoint("gitbak-1")
+	s.pushDue(ctx)
+	if got := strings.Join(pusher.pushes, ","); got != "nas:gitbak-1,cloud:gitbak-1" {
+		t.Fatalf("Expected the first checkpoint to reach both mirrors, got %s", got)
+	}
+
+	now = now.Add(10 * time.Minute)
+	s.checkpoint("gitbak-1")
+	s.pushDue(ctx)
+	if got := strings.Join(pusher.pushes, ","); got != "nas:gitbak-1,cloud:gitbak-1,nas:gitbak-1" {
+		t.Fatalf("Expected only the per-checkpoint mirror to push within the hour, got %s", got)
+	}
+
+	now = now.Add(time.Hour)
+	s.pushDue(ctx)
+	if got := pusher.pushes[len(pusher.pushes)-1]; got != "cloud:gitbak-1" || len(pusher.pushes) != 4 {
+		t.Fatalf("Expected the hourly mirror to catch up once its interval elapsed, got %v", pusher.pushes)
+	}
+
+	now = now.Add(2 * time.Hour)
+	s.pushDue(ctx)
+	if len(pusher.pushes) != 4 {
+		t.Errorf("Expected no pushes without new checkpoints, got %v", pusher.pushes)
+	}
+}
+
+func TestSchedulerRetriesFailedPushes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pusher := &fakePusher{fail: map[string]bool{"nas": true}}
+	s := newTestScheduler([]Profile{{Name: "nas", Remote: "nas"}}, pusher, &now)
+	ctx := context.Background()
+
+	s.checkpoint("gitbak-1")
+	s.pushDue(ctx)
+	if len(pusher.pushes) != 0 {
+		t.Fatalf("Expected failed push not to be recorded, got %v", pusher.pushes)
+	}
+
+	pusher.fail = nil
+	s.pushDue(ctx)
+	if len(pusher.pushes) != 1 {
+		t.Errorf("Expected the failed push to be retried, got %v", pusher.pushes)
+	}
+}
+
+func TestSchedulerLeases(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pusher := &fakePusher{}
+	s := newTestScheduler([]Profile{{Name: "nas", Remote: "nas"}}, pusher, &now)
+	ctx := context.Background()
+
+	s.checkpoint("gitbak-1")
+	s.pushDue(ctx)
+
+	pusher.fail = map[string]bool{"nas": true}
+	s.checkpoint("gitbak-1")
+	s.pushDue(ctx)
+
+	pusher.fail = nil
+	s.pushDue(ctx)
+
+	s.checkpoint("gitbak-2")
+	s.pushDue(ctx)
+
+	// The first push of a branch only fast-forwards, and each later one expects the commit
+	// pushed before it, even across a failed push
+	if got := strings.Join(pusher.leases, ","); got != ",c1," {
+		t.Errorf("Expected leases \"\", c1 and \"\", got %q", pusher.leases)
+	}
+}
+
+func TestSchedulerEnvironment(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "ssh -i ~/.ssh/backup")
+
+	now := time.Now()
+	pusher := &fakePusher{}
+	s := newTestScheduler([]Profile{
+		{Name: "limited", Remote: "ssh://nas/backup.git", RateLimit: 1024},
+		{Name: "http", Remote: "https://example.com/backup.git", RateLimit: 1024},
+		{Name: "unlimited", Remote: "ssh://nas/other.git"},
+	}, pusher, &now)
+
+	s.checkpoint("gitbak-1")
+	s.pushDue(context.Background())
+
+	env := strings.Join(pusher.envs["ssh://nas/backup.git"], "\n")
+	for _, want := range []string{
+		"GIT_SSH_COMMAND='/usr/local/bin/gitbak'",
+		RateEnvVar + "=1024",
+		sshCommandEnvVar + "=ssh -i ~/.ssh/backup",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Expected throttled push environment to contain %q", want)
+		}
+	}
+
+	if pusher.envs["https://example.com/backup.git"] != nil {
+		t.Error("Expected HTTP remotes to be pushed without the ssh wrapper")
+	}
+	if pusher.envs["ssh://nas/other.git"] != nil {
+		t.Error("Expected unlimited mirrors to be pushed with the default environment")
+	}
+}
+
+func TestSchedulerNotifyNeverBlocks(t *testing.T) {
+	now := time.Now()
+	s := newTestScheduler([]Profile{{Name: "nas", Remote: "nas"}}, &fakePusher{}, &now)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			s.Notify("gitbak-1")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Notify blocked without a running scheduler")
+	}
+}
+
+func TestSchedulerFinalPush(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pusher := &fakePusher{}
+	s := newTestScheduler([]Profile{
+		{Name: "nas", Remote: "nas", Every: time.Hour},
+		{Name: "cloud", Remote: "cloud", Every: time.Hour},
+	}, pusher, &now)
+
+	s.checkpoint("gitbak-1")
+	s.pushDue(context.Background())
+	if len(pusher.pushes) != 2 {
+		t.Fatalf("Expected the first checkpoint pushed to both mirrors, got %v", pusher.pushes)
+	}
+
+	// A checkpoint made as the session stops, well within the mirrors' interval
+	now = now.Add(10 * time.Minute)
+	s.Notify("gitbak-1")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	want := []string{"nas:gitbak-1", "cloud:gitbak-1", "nas:gitbak-1", "cloud:gitbak-1"}
+	if strings.Join(pusher.pushes, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected a last push to each mirror when stopped, got %v", pusher.pushes)
+	}
+	if s.pending["nas"] || s.pending["cloud"] {
+		t.Errorf("Expected no mirror left pending, got %v", s.pending)
+	}
+
+	// Nothing is pushed again once every mirror is up to date
+	s.Run(ctx)
+	if len(pusher.pushes) != len(want) {
+		t.Errorf("Expected no push without new checkpoints, got %v", pusher.pushes)
+	}
+}
@@ -0,0 +1,111 @@
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// RateEnvVar carries the upload limit, in bytes per second, to gitbak running as
+	// the ssh transport for a throttled push. Its presence selects the wrapper mode.
+	RateEnvVar = "GITBAK_MIRROR_RATE"
+
+	// sshCommandEnvVar carries the user's own GIT_SSH_COMMAND, if any, to the wrapper
+	sshCommandEnvVar = "GITBAK_MIRROR_SSH_COMMAND"
+)
+
+// IsSSHWrapper reports whether this process was started by git as the throttling
+// ssh transport of a mirror push, rather than by the user.
+func IsSSHWrapper() bool {
+	return os.Getenv(RateEnvVar) != ""
+}
+
+// RunSSHWrapper runs ssh with the arguments git passed to the transport, feeding it
+// the pack data from stdin at the configured rate. It returns the process exit code.
+func RunSSHWrapper(args []string) int {
+	rate, err := strconv.ParseInt(os.Getenv(RateEnvVar), 10, 64)
+	if err != nil || rate <= 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "gitbak: invalid %s value %q\n", RateEnvVar, os.Getenv(RateEnvVar))
+		return 1
+	}
+
+	var cmd *exec.Cmd
+	if custom := os.Getenv(sshCommandEnvVar); custom != "" {
+		cmd = exec.Command("sh", append([]string{"-c", custom + ` "$@"`, custom}, args...)...)
+	} else {
+		cmd = exec.Command("ssh", args...)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, RateEnvVar+"=") || strings.HasPrefix(kv, sshCommandEnvVar+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	cmd.Env = env
+
+	cmd.Stdin = newRateLimitedReader(os.Stdin, rate)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "gitbak: failed to run ssh: %v\n", err)
+		return 255
+	}
+	return 0
+}
+
+// rateLimitedReader delays reads so that on average no more than rate bytes
+// per second pass through it.
+type rateLimitedReader struct {
+	r     io.Reader
+	rate  int64
+	read  int64
+	start time.Time
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// newRateLimitedReader wraps r so that it yields at most rate bytes per second
+func newRateLimitedReader(r io.Reader, rate int64) *rateLimitedReader {
+	return &rateLimitedReader{
+		r:     r,
+		rate:  rate,
+		start: time.Now(),
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// Read implements io.Reader
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	// Reading at most a tenth of a second's worth keeps the output smooth
+	chunk := l.rate / 10
+	if chunk < 1 {
+		chunk = 1
+	}
+	if int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+
+	expected := time.Duration(float64(l.read) / float64(l.rate) * float64(time.Second))
+	if elapsed := l.now().Sub(l.start); elapsed < expected {
+		l.sleep(expected - elapsed)
+	}
+
+	return n, err
+}
@@ -0,0 +1,31 @@
+package mirror
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+
+	data := bytes.Repeat([]byte("x"), 4096)
+	l := newRateLimitedReader(bytes.NewReader(data), 1024)
+	l.start = start
+	l.now = func() time.Time { return clock }
+	l.sleep = func(d time.Duration) { clock = clock.Add(d) }
+
+	out, err := io.ReadAll(l)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatal("Expected data to pass through unchanged")
+	}
+
+	if elapsed := clock.Sub(start); elapsed != 4*time.Second {
+		t.Errorf("Expected 4KiB at 1KiB/s to take 4s, took %s", elapsed)
+	}
+}
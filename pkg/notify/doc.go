@@ -0,0 +1,29 @@
+// Package notify delivers gitbak messages as desktop notifications.
+//
+// Notifications are shown with the notifier that ships with the platform:
+// osascript on macOS and notify-send (libnotify) on Linux and the BSDs. Other
+// platforms, including Windows, are not supported.
+//
+// # Modes
+//
+// The -notify flag selects which messages become notifications:
+//
+//   - off: No notifications (the default)
+//   - errors: Warnings shown to the user, such as failed checkpoints and pushes,
+//     and gitbak stopping after too many consecutive errors
+//   - all: Additionally every checkpoint and other informational messages
+//
+// # Usage
+//
+// Desktop returns a logger.NotifyFunc, which is attached to the logging pipeline
+// through a notification sink at the level of the selected mode:
+//
+//	send, err := notify.Desktop()
+//	if err != nil {
+//		// No notifier available on this machine
+//	}
+//	pipeline.AddSink(logger.NewNotificationSink(send), notify.MinLevel(notify.ModeErrors))
+//
+// The notifier is started without waiting for it to exit, so a slow notification
+// daemon never holds up logging or checkpoints.
+package notify
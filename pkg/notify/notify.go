@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+// Notification modes accepted by the -notify flag
+const (
+	// ModeOff disables notifications
+	ModeOff = "off"
+
+	// ModeErrors notifies about warnings shown to the user, including failed checkpoints
+	ModeErrors = "errors"
+
+	// ModeAll also notifies about every checkpoint and other informational messages
+	ModeAll = "all"
+)
+
+// Modes lists the accepted notification modes
+var Modes = []string{ModeOff, ModeErrors, ModeAll}
+
+// MinLevel returns the lowest log level that is delivered as a notification in mode
+func MinLevel(mode string) logger.Level {
+	if mode == ModeAll {
+		return logger.LevelInfo
+	}
+	return logger.LevelWarning
+}
+
+// Desktop returns a function that shows desktop notifications using the platform's
+// notifier. It returns an error if the platform is unsupported or the notifier is not installed.
+func Desktop() (logger.NotifyFunc, error) {
+	return desktop(runtime.GOOS, exec.LookPath, startDetached)
+}
+
+// desktop builds the notify function for goos, with lookPath and start injectable for tests
+func desktop(goos string, lookPath func(string) (string, error), start func(*exec.Cmd) error) (logger.NotifyFunc, error) {
+	tool, ok := notifierFor(goos)
+	if !ok {
+		return nil, gitbakErrors.New("desktop notifications are not supported on " + goos)
+	}
+
+	path, err := lookPath(tool)
+	if err != nil {
+		return nil, gitbakErrors.Wrapf(err, "%s not found", tool)
+	}
+
+	return func(title, message string) error {
+		cmd := exec.Command(path, notifierArgs(goos, title, message)...)
+		if err := start(cmd); err != nil {
+			return gitbakErrors.Wrapf(err, "failed to run %s", tool)
+		}
+		return nil
+	}, nil
+}
+
+// notifierFor returns the notification tool used on goos
+func notifierFor(goos string) (string, bool) {
+	switch goos {
+	case "darwin":
+		return "osascript", true
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return "notify-send", true
+	default:
+		return "", false
+	}
+}
+
+// notifierArgs returns the arguments that make the tool for goos show a notification
+func notifierArgs(goos, title, message string) []string {
+	if goos == "darwin" {
+		script := "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)
+		return []string{"-e", script}
+	}
+	// "--" keeps messages starting with a dash from being read as options
+	return []string{"--app-name", "gitbak", "--", title, message}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// startDetached starts cmd and reaps it in the background once it exits
+func startDetached(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/bashhack/gitbak/pkg/logger"
+)
+
+func TestDesktop(t *testing.T) {
+	tests := map[string]struct {
+		goos         string
+		title        string
+		message      string
+		expectedTool string
+		expectedArgs []string
+		expectError  bool
+	}{
+		"MacOS": {
+			goos:         "darwin",
+			title:        "gitbak",
+			message:      "Commit #3 created",
+			expectedTool: "osascript",
+			expectedArgs: []string{"-e", `display notification "Commit #3 created" with title "gitbak"`},
+		},
+		"MacOSQuoting": {
+			goos:         "darwin",
+			title:        "gitbak warning",
+			message:      `Error occurred: pathspec "a\b" did not match`,
+			expectedTool: "osascript",
+			expectedArgs: []string{"-e", `display notification "Error occurred: pathspec \"a\\b\" did not match" with title "gitbak warning"`},
+		},
+		"Linux": {
+			goos:         "linux",
+			title:        "gitbak warning",
+			message:      "-- Too many consecutive errors",
+			expectedTool: "notify-send",
+			expectedArgs: []string{"--app-name", "gitbak", "--", "gitbak warning", "-- Too many consecutive errors"},
+		},
+		"Unsupported": {
+			goos:        "windows",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			lookPath := func(file string) (string, error) {
+				return "/usr/bin/" + file, nil
+			}
+
+			var started *exec.Cmd
+			start := func(cmd *exec.Cmd) error {
+				started = cmd
+				return nil
+			}
+
+			send, err := desktop(test.goos, lookPath, start)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("Expected an error for an unsupported platform")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if err := send(test.title, test.message); err != nil {
+				t.Fatalf("Unexpected error sending notification: %v", err)
+			}
+			if started == nil {
+				t.Fatal("Expected the notifier to be started")
+			}
+			if started.Path != "/usr/bin/"+test.expectedTool {
+				t.Errorf("Expected %s to be run, got %s", test.expectedTool, started.Path)
+			}
+			if !reflect.DeepEqual(started.Args[1:], test.expectedArgs) {
+				t.Errorf("Expected args %q, got %q", test.expectedArgs, started.Args[1:])
+			}
+		})
+	}
+}
+
+func TestDesktopNotifierMissing(t *testing.T) {
+	lookPath := func(string) (string, error) {
+		return "", exec.ErrNotFound
+	}
+	start := func(*exec.Cmd) error {
+		t.Fatal("Notifier should not be started")
+		return nil
+	}
+
+	_, err := desktop("linux", lookPath, start)
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Expected exec.ErrNotFound, got %v", err)
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	tests := map[string]struct {
+		mode     string
+		expected logger.Level
+	}{
+		"Errors": {mode: ModeErrors, expected: logger.LevelWarning},
+		"All":    {mode: ModeAll, expected: logger.LevelInfo},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if level := MinLevel(test.mode); level != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, level)
+			}
+		})
+	}
+}
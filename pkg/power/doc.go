@@ -0,0 +1,21 @@
+// Package power reports whether the machine is running on battery, and how full it is.
+//
+// The power source is read with what the platform provides: the power_supply class
+// in /sys on Linux, and pmset on macOS. Other platforms, including Windows, are not
+// supported.
+//
+// # Usage
+//
+// gitbak polls the power source while a -battery-threshold is set, and spaces out
+// checks while the battery is below it:
+//
+//	status, err := power.Read()
+//	if err != nil {
+//		// Power source unknown on this machine
+//	}
+//	if status.Below(20) {
+//		// On battery with less than 20% left
+//	}
+//
+// Machines without a battery, such as desktops, always report being on AC power.
+package power
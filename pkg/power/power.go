@@ -0,0 +1,135 @@
+package power
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// sysfsRoot is where Linux lists power supplies
+const sysfsRoot = "/sys/class/power_supply"
+
+// pmsetTimeout bounds how long reading the power source on macOS may take
+const pmsetTimeout = 5 * time.Second
+
+// Status is the machine's power source
+type Status struct {
+	// OnBattery is set when the machine draws power from a battery rather than AC power
+	OnBattery bool
+
+	// Percent is the battery's remaining charge, or -1 if the machine has no battery
+	Percent int
+}
+
+// Below reports whether the machine is on battery with less than threshold percent left
+func (s Status) Below(threshold int) bool {
+	return s.OnBattery && s.Percent >= 0 && s.Percent < threshold
+}
+
+// Read returns the machine's current power source. It returns an error if the
+// platform is unsupported or its power source cannot be read.
+func Read() (Status, error) {
+	return read(runtime.GOOS, sysfsRoot, pmset)
+}
+
+// read reads the power source on goos, with the sysfs root and pmset runner injectable for tests
+func read(goos, root string, pmset func() (string, error)) (Status, error) {
+	switch goos {
+	case "linux":
+		return readSysfs(root)
+	case "darwin":
+		out, err := pmset()
+		if err != nil {
+			return Status{}, gitbakErrors.Wrap(err, "failed to run pmset")
+		}
+		return parsePmset(out)
+	default:
+		return Status{}, gitbakErrors.New("reading the power source is not supported on " + goos)
+	}
+}
+
+// readSysfs reads the power supplies listed under root. The machine is considered on
+// battery when it has a battery and no external supply (mains or USB) is online; with
+// several batteries, their average charge is reported.
+func readSysfs(root string) (Status, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return Status{}, gitbakErrors.Wrapf(err, "failed to list power supplies in %s", root)
+	}
+
+	status := Status{Percent: -1}
+	online, batteries, total := false, 0, 0
+
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		switch readAttribute(dir, "type") {
+		case "Battery":
+			capacity, err := strconv.Atoi(readAttribute(dir, "capacity"))
+			if err != nil {
+				// Some devices (e.g. wireless mice) report a battery without a capacity
+				continue
+			}
+			batteries++
+			total += capacity
+		case "Mains", "USB", "USB_C", "USB_PD":
+			if readAttribute(dir, "online") == "1" {
+				online = true
+			}
+		}
+	}
+
+	if batteries > 0 {
+		status.Percent = total / batteries
+		status.OnBattery = !online
+	}
+	return status, nil
+}
+
+// readAttribute returns the trimmed contents of a power supply attribute, or "" if it cannot be read
+func readAttribute(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// pmset runs pmset -g batt
+func pmset() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pmsetTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+	return string(out), err
+}
+
+// pmsetPercent matches the charge in a battery line of pmset -g batt
+var pmsetPercent = regexp.MustCompile(`(\d+)%`)
+
+// parsePmset parses the output of pmset -g batt, such as:
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234567)	15%; discharging; 1:23 remaining present: true
+func parsePmset(out string) (Status, error) {
+	source, _, _ := strings.Cut(out, "\n")
+	if !strings.HasPrefix(source, "Now drawing from") {
+		return Status{}, gitbakErrors.New("unexpected pmset output " + strconv.Quote(out))
+	}
+
+	status := Status{OnBattery: strings.Contains(source, "'Battery Power'"), Percent: -1}
+	if match := pmsetPercent.FindStringSubmatch(out); match != nil {
+		status.Percent, _ = strconv.Atoi(match[1])
+	} else {
+		// Without a battery there is nothing to discharge
+		status.OnBattery = false
+	}
+	return status, nil
+}
@@ -0,0 +1,176 @@
+package power
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSupply creates a power supply directory with the given attributes under root
+func writeSupply(t *testing.T, root, name string, attributes map[string]string) {
+	t.Helper()
+
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	for attribute, value := range attributes {
+		if err := os.WriteFile(filepath.Join(dir, attribute), []byte(value+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", attribute, err)
+		}
+	}
+}
+
+func TestReadSysfs(t *testing.T) {
+	type supply struct {
+		name       string
+		attributes map[string]string
+	}
+
+	tests := map[string]struct {
+		supplies []supply
+		expected Status
+	}{
+		"Desktop": {
+			supplies: []supply{{"AC", map[string]string{"type": "Mains", "online": "1"}}},
+			expected: Status{OnBattery: false, Percent: -1},
+		},
+		"Discharging": {
+			supplies: []supply{
+				{"AC", map[string]string{"type": "Mains", "online": "0"}},
+				{"BAT0", map[string]string{"type": "Battery", "capacity": "15"}},
+			},
+			expected: Status{OnBattery: true, Percent: 15},
+		},
+		"Charging": {
+			supplies: []supply{
+				{"AC", map[string]string{"type": "Mains", "online": "1"}},
+				{"BAT0", map[string]string{"type": "Battery", "capacity": "15"}},
+			},
+			expected: Status{OnBattery: false, Percent: 15},
+		},
+		"ChargingOverUSB": {
+			supplies: []supply{
+				{"ucsi-source-psy-USBC000:001", map[string]string{"type": "USB", "online": "1"}},
+				{"BAT0", map[string]string{"type": "Battery", "capacity": "50"}},
+			},
+			expected: Status{OnBattery: false, Percent: 50},
+		},
+		"TwoBatteries": {
+			supplies: []supply{
+				{"BAT0", map[string]string{"type": "Battery", "capacity": "10"}},
+				{"BAT1", map[string]string{"type": "Battery", "capacity": "30"}},
+			},
+			expected: Status{OnBattery: true, Percent: 20},
+		},
+		"PeripheralBattery": {
+			supplies: []supply{
+				{"AC", map[string]string{"type": "Mains", "online": "1"}},
+				{"hidpp_battery_0", map[string]string{"type": "Battery", "capacity_level": "Normal"}},
+			},
+			expected: Status{OnBattery: false, Percent: -1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, s := range test.supplies {
+				writeSupply(t, root, s.name, s.attributes)
+			}
+
+			status, err := readSysfs(root)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if status != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, status)
+			}
+		})
+	}
+}
+
+func TestParsePmset(t *testing.T) {
+	tests := map[string]struct {
+		output      string
+		expected    Status
+		expectError bool
+	}{
+		"OnBattery": {
+			output:   "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1234567)\t15%; discharging; 1:23 remaining present: true\n",
+			expected: Status{OnBattery: true, Percent: 15},
+		},
+		"OnAC": {
+			output:   "Now drawing from 'AC Power'\n -InternalBattery-0 (id=1234567)\t80%; charging; 0:45 remaining present: true\n",
+			expected: Status{OnBattery: false, Percent: 80},
+		},
+		"NoBattery": {
+			output:   "Now drawing from 'AC Power'\n",
+			expected: Status{OnBattery: false, Percent: -1},
+		},
+		"Unexpected": {
+			output:      "pmset: command failed\n",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, err := parsePmset(test.output)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if status != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, status)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	pmset := func() (string, error) {
+		return "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1)\t42%; discharging\n", nil
+	}
+
+	status, err := read("darwin", "", pmset)
+	if err != nil || status != (Status{OnBattery: true, Percent: 42}) {
+		t.Errorf("Expected to be on battery at 42%%, got %+v (error: %v)", status, err)
+	}
+
+	failing := func() (string, error) { return "", errors.New("not found") }
+	if _, err := read("darwin", "", failing); err == nil {
+		t.Error("Expected an error when pmset fails")
+	}
+
+	if _, err := read("windows", "", pmset); err == nil {
+		t.Error("Expected an error on unsupported platforms")
+	}
+}
+
+func TestBelow(t *testing.T) {
+	tests := map[string]struct {
+		status   Status
+		expected bool
+	}{
+		"OnBatteryBelow":    {status: Status{OnBattery: true, Percent: 15}, expected: true},
+		"OnBatteryAt":       {status: Status{OnBattery: true, Percent: 20}, expected: false},
+		"OnACBelow":         {status: Status{OnBattery: false, Percent: 15}, expected: false},
+		"OnBatteryUnknown":  {status: Status{OnBattery: true, Percent: -1}, expected: false},
+		"OnBatteryAboveMax": {status: Status{OnBattery: true, Percent: 90}, expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.status.Below(20); got != test.expected {
+				t.Errorf("Expected Below(20) to be %v for %+v", test.expected, test.status)
+			}
+		})
+	}
+}
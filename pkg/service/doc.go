@@ -0,0 +1,36 @@
+// Package service generates user-level service definitions that run gitbak at login.
+//
+// A service runs a monitoring session for one repository, started by the
+// platform's service manager when the user logs in and restarted if it fails:
+//
+//   - Linux: a systemd user unit in ~/.config/systemd/user (or $XDG_CONFIG_HOME/systemd/user),
+//     whose output goes to the journal
+//   - macOS: a launchd agent in ~/Library/LaunchAgents, whose output is appended to a log file
+//
+// Other platforms, including Windows, are not supported.
+//
+// # Usage
+//
+// Describe the session with a Spec, then render it for the current platform and
+// run the commands that enable it:
+//
+//	platform, err := service.Current()
+//	if err != nil {
+//		// No supported service manager
+//	}
+//	spec := service.Spec{
+//		Name:       "gitbak-1a2b3c4d",
+//		Executable: "/usr/local/bin/gitbak",
+//		Args:       []string{"-repo", "/home/me/project", "-interval", "2"},
+//		WorkingDir: "/home/me/project",
+//	}
+//	path := platform.Path(spec)
+//	err = os.WriteFile(path, []byte(platform.Render(spec)), 0644)
+//	for _, command := range platform.StartCommands(path, spec) {
+//		// e.g. systemctl --user enable --now gitbak-1a2b3c4d.service
+//	}
+//
+// Stopping the service sends gitbak SIGTERM, on which it makes its final checkpoint
+// as it does for Ctrl+C. A session stopped by the GITBAK_DISABLE kill switch is not
+// restarted by systemd.
+package service
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// labelPrefix namespaces the labels of launchd agents, which are conventionally reverse-DNS
+const labelPrefix = "com.github.bashhack."
+
+// disabledExitCode is the exit code of gitbak refusing to run because of the kill switch.
+// Restarting it would only fail again.
+const disabledExitCode = 3
+
+// Spec describes the gitbak session a service runs
+type Spec struct {
+	// Name identifies the service, e.g. gitbak-<repo-hash>. It is the systemd unit's name,
+	// without .service, and, prefixed, the launchd agent's label.
+	Name string
+
+	// Description is shown by the service manager
+	Description string
+
+	// Executable and Args are the gitbak binary and the arguments it is started with
+	Executable string
+	Args       []string
+
+	// WorkingDir is the directory the session runs in
+	WorkingDir string
+
+	// Env lists KEY=VALUE pairs set for the session
+	Env []string
+
+	// LogFile receives the output of a launchd agent; systemd keeps it in the journal
+	LogFile string
+}
+
+// Platform is the service manager of an operating system: where it looks for user
+// services, how their definitions are written, and how they are started and stopped
+type Platform struct {
+	// Name is the service manager's name, systemd or launchd
+	Name string
+
+	dir    string
+	ext    string
+	render func(spec Spec) string
+	start  func(path string, spec Spec) [][]string
+	stop   func(path string, spec Spec) [][]string
+	reload [][]string
+}
+
+// Current returns the service manager of the running system
+func Current() (*Platform, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, gitbakErrors.Wrap(err, "failed to find the home directory")
+	}
+	return For(runtime.GOOS, home, os.Getenv("XDG_CONFIG_HOME"))
+}
+
+// For returns the service manager of goos for the user with the given home directory.
+// configHome is $XDG_CONFIG_HOME, which systemd honors; if empty, ~/.config is used.
+func For(goos, home, configHome string) (*Platform, error) {
+	switch goos {
+	case "linux":
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return &Platform{
+			Name:   "systemd",
+			dir:    filepath.Join(configHome, "systemd", "user"),
+			ext:    ".service",
+			render: Systemd,
+			start: func(_ string, spec Spec) [][]string {
+				return [][]string{
+					{"systemctl", "--user", "daemon-reload"},
+					{"systemctl", "--user", "enable", "--now", spec.Name + ".service"},
+				}
+			},
+			stop: func(_ string, spec Spec) [][]string {
+				return [][]string{{"systemctl", "--user", "disable", "--now", spec.Name + ".service"}}
+			},
+			reload: [][]string{{"systemctl", "--user", "daemon-reload"}},
+		}, nil
+	case "darwin":
+		return &Platform{
+			Name:   "launchd",
+			dir:    filepath.Join(home, "Library", "LaunchAgents"),
+			ext:    ".plist",
+			render: Launchd,
+			start: func(path string, _ Spec) [][]string {
+				return [][]string{{"launchctl", "load", "-w", path}}
+			},
+			stop: func(path string, _ Spec) [][]string {
+				return [][]string{{"launchctl", "unload", "-w", path}}
+			},
+		}, nil
+	default:
+		return nil, gitbakErrors.Errorf("services are not supported on %s, only with systemd on Linux and launchd on macOS", goos)
+	}
+}
+
+// Path returns where the definition of the service is installed
+func (p *Platform) Path(spec Spec) string {
+	name := spec.Name
+	if p.Name == "launchd" {
+		name = Label(spec)
+	}
+	return filepath.Join(p.dir, name+p.ext)
+}
+
+// Render returns the definition of the service
+func (p *Platform) Render(spec Spec) string {
+	return p.render(spec)
+}
+
+// StartCommands returns the commands that enable the service installed at path, starting
+// it now and at every login
+func (p *Platform) StartCommands(path string, spec Spec) [][]string {
+	return p.start(path, spec)
+}
+
+// StopCommands returns the commands that stop the service installed at path and keep it
+// from starting at login
+func (p *Platform) StopCommands(path string, spec Spec) [][]string {
+	return p.stop(path, spec)
+}
+
+// ReloadCommands returns the commands that make the service manager forget a removed
+// service, if it needs telling
+func (p *Platform) ReloadCommands() [][]string {
+	return p.reload
+}
+
+// Label returns the launchd label of the service
+func Label(spec Spec) string {
+	return labelPrefix + spec.Name
+}
+
+// Systemd renders spec as a systemd user unit. The session is restarted if it fails,
+// except when the kill switch stopped it, and stopping the unit sends SIGTERM, on
+// which gitbak makes its final checkpoint.
+func Systemd(spec Spec) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	_, _ = fmt.Fprintf(&b, "Description=%s\n", spec.Description)
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	_, _ = fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(spec.WorkingDir))
+	for _, env := range spec.Env {
+		_, _ = fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(env))
+	}
+
+	words := make([]string, 0, len(spec.Args)+1)
+	for _, word := range append([]string{spec.Executable}, spec.Args...) {
+		words = append(words, systemdQuote(word))
+	}
+	_, _ = fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(words, " "))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=30\n")
+	_, _ = fmt.Fprintf(&b, "RestartPreventExitStatus=%d\n", disabledExitCode)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes word for a unit file, where whitespace separates words, quotes and
+// backslashes are special, and % and $ introduce specifiers and variables
+func systemdQuote(word string) string {
+	word = strings.ReplaceAll(word, "%", "%%")
+	word = strings.ReplaceAll(word, "$", "$$")
+	if word != "" && !strings.ContainsAny(word, " \t\"'\\;") {
+		return word
+	}
+	word = strings.ReplaceAll(word, `\`, `\\`)
+	word = strings.ReplaceAll(word, `"`, `\"`)
+	return `"` + word + `"`
+}
+
+// Launchd renders spec as a launchd agent property list. The session is started at login
+// and restarted if it fails.
+func Launchd(spec Spec) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+
+	plistString(&b, 1, "Label", Label(spec))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{spec.Executable}, spec.Args...) {
+		_, _ = fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	plistString(&b, 1, "WorkingDirectory", spec.WorkingDir)
+
+	if len(spec.Env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, env := range spec.Env {
+			key, value, _ := strings.Cut(env, "=")
+			plistString(&b, 2, key, value)
+		}
+		b.WriteString("\t</dict>\n")
+	}
+
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>30</integer>\n")
+	if spec.LogFile != "" {
+		plistString(&b, 1, "StandardOutPath", spec.LogFile)
+		plistString(&b, 1, "StandardErrorPath", spec.LogFile)
+	}
+
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// plistString writes a key and its string value at the given indentation
+func plistString(b *strings.Builder, indent int, key, value string) {
+	tabs := strings.Repeat("\t", indent)
+	_, _ = fmt.Fprintf(b, "%s<key>%s</key>\n%s<string>%s</string>\n", tabs, xmlEscape(key), tabs, xmlEscape(value))
+}
+
+// xmlEscape escapes s for use as XML character data
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
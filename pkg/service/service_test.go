@@ -0,0 +1,167 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSpec is a service whose arguments need quoting
+func testSpec() Spec {
+	return Spec{
+		Name:        "gitbak-1a2b3c4d",
+		Description: "gitbak checkpoints for /home/me/my project",
+		Executable:  "/usr/local/bin/gitbak",
+		Args:        []string{"-repo", "/home/me/my project", "-prefix", `[wip] "50%" done`},
+		WorkingDir:  "/home/me/my project",
+		Env:         []string{"NON_INTERACTIVE=true", "PATH=/usr/bin:/bin"},
+		LogFile:     "/home/me/logs/gitbak <1>.log",
+	}
+}
+
+// TestFor tests where each platform installs services and how it starts and stops them
+func TestFor(t *testing.T) {
+	tests := map[string]struct {
+		goos           string
+		configHome     string
+		expectedName   string
+		expectedPath   string
+		expectedStart  [][]string
+		expectedStop   [][]string
+		expectedReload [][]string
+		expectError    bool
+	}{
+		"Linux": {
+			goos:         "linux",
+			expectedName: "systemd",
+			expectedPath: "/home/me/.config/systemd/user/gitbak-1a2b3c4d.service",
+			expectedStart: [][]string{
+				{"systemctl", "--user", "daemon-reload"},
+				{"systemctl", "--user", "enable", "--now", "gitbak-1a2b3c4d.service"},
+			},
+			expectedStop:   [][]string{{"systemctl", "--user", "disable", "--now", "gitbak-1a2b3c4d.service"}},
+			expectedReload: [][]string{{"systemctl", "--user", "daemon-reload"}},
+		},
+		"LinuxConfigHome": {
+			goos:         "linux",
+			configHome:   "/xdg",
+			expectedName: "systemd",
+			expectedPath: "/xdg/systemd/user/gitbak-1a2b3c4d.service",
+			expectedStart: [][]string{
+				{"systemctl", "--user", "daemon-reload"},
+				{"systemctl", "--user", "enable", "--now", "gitbak-1a2b3c4d.service"},
+			},
+			expectedStop:   [][]string{{"systemctl", "--user", "disable", "--now", "gitbak-1a2b3c4d.service"}},
+			expectedReload: [][]string{{"systemctl", "--user", "daemon-reload"}},
+		},
+		"MacOS": {
+			goos:          "darwin",
+			expectedName:  "launchd",
+			expectedPath:  "/home/me/Library/LaunchAgents/com.github.bashhack.gitbak-1a2b3c4d.plist",
+			expectedStart: [][]string{{"launchctl", "load", "-w", "/home/me/Library/LaunchAgents/com.github.bashhack.gitbak-1a2b3c4d.plist"}},
+			expectedStop:  [][]string{{"launchctl", "unload", "-w", "/home/me/Library/LaunchAgents/com.github.bashhack.gitbak-1a2b3c4d.plist"}},
+		},
+		"Unsupported": {
+			goos:        "windows",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			platform, err := For(test.goos, "/home/me", test.configHome)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("Expected an error for an unsupported platform")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("For failed: %v", err)
+			}
+
+			spec := testSpec()
+			path := platform.Path(spec)
+			if platform.Name != test.expectedName {
+				t.Errorf("Expected %s, got %s", test.expectedName, platform.Name)
+			}
+			if path != filepath.FromSlash(test.expectedPath) {
+				t.Errorf("Expected path %s, got %s", test.expectedPath, path)
+			}
+			if got := platform.StartCommands(path, spec); !reflect.DeepEqual(got, test.expectedStart) {
+				t.Errorf("Expected start commands %q, got %q", test.expectedStart, got)
+			}
+			if got := platform.StopCommands(path, spec); !reflect.DeepEqual(got, test.expectedStop) {
+				t.Errorf("Expected stop commands %q, got %q", test.expectedStop, got)
+			}
+			if got := platform.ReloadCommands(); !reflect.DeepEqual(got, test.expectedReload) {
+				t.Errorf("Expected reload commands %q, got %q", test.expectedReload, got)
+			}
+		})
+	}
+}
+
+// TestSystemd tests the rendering of systemd units
+func TestSystemd(t *testing.T) {
+	unit := Systemd(testSpec())
+
+	for _, want := range []string{
+		"Description=gitbak checkpoints for /home/me/my project\n",
+		`WorkingDirectory="/home/me/my project"` + "\n",
+		"Environment=NON_INTERACTIVE=true\n",
+		`ExecStart=/usr/local/bin/gitbak -repo "/home/me/my project" -prefix "[wip] \"50%%\" done"` + "\n",
+		"Restart=on-failure\n",
+		"RestartPreventExitStatus=3\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected the unit to contain %q, got:\n%s", want, unit)
+		}
+	}
+}
+
+// TestSystemdQuote tests quoting words for unit files
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]struct {
+		word     string
+		expected string
+	}{
+		"Plain":       {word: "-interval", expected: "-interval"},
+		"Space":       {word: "my project", expected: `"my project"`},
+		"Quote":       {word: `say "hi"`, expected: `"say \"hi\""`},
+		"Backslash":   {word: `C:\repo`, expected: `"C:\\repo"`},
+		"Specifier":   {word: "100%", expected: "100%%"},
+		"Variable":    {word: "$HOME", expected: "$$HOME"},
+		"Empty":       {word: "", expected: `""`},
+		"Semicolon":   {word: "a;b", expected: `"a;b"`},
+		"SingleQuote": {word: "it's", expected: `"it's"`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := systemdQuote(test.word); got != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+// TestLaunchd tests the rendering of launchd agents
+func TestLaunchd(t *testing.T) {
+	plist := Launchd(testSpec())
+
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>com.github.bashhack.gitbak-1a2b3c4d</string>\n",
+		"\t\t<string>/usr/local/bin/gitbak</string>\n\t\t<string>-repo</string>\n\t\t<string>/home/me/my project</string>\n",
+		"\t\t<string>[wip] &#34;50%&#34; done</string>\n",
+		"\t\t<key>NON_INTERACTIVE</key>\n\t\t<string>true</string>\n",
+		"\t\t<key>PATH</key>\n\t\t<string>/usr/bin:/bin</string>\n",
+		"<key>RunAtLoad</key>\n\t<true/>\n",
+		"<key>StandardOutPath</key>\n\t<string>/home/me/logs/gitbak &lt;1&gt;.log</string>\n",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("Expected the plist to contain %q, got:\n%s", want, plist)
+		}
+	}
+}
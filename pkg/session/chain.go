@@ -0,0 +1,49 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ChainTrailer is the commit trailer that records the chain hash of the preceding checkpoint.
+const ChainTrailer = "Gitbak-Chain"
+
+// ChainLink is one checkpoint in a session's integrity chain.
+type ChainLink struct {
+	// Commit is the SHA of the checkpoint commit.
+	Commit string `json:"commit"`
+
+	// Tree is the SHA of the checkpoint commit's tree.
+	Tree string `json:"tree"`
+
+	// Hash is ChainHash of the previous link's hash, Commit and Tree.
+	Hash string `json:"hash"`
+}
+
+// ChainHash computes the rolling hash of a checkpoint from the previous hash and the
+// checkpoint's commit and tree SHAs. Rewriting any checkpoint, or dropping one,
+// changes every hash that follows it.
+func ChainHash(prev, commit, tree string) string {
+	sum := sha256.Sum256([]byte(prev + commit + tree))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainHead returns the hash the next checkpoint is chained to: the hash of the last
+// link, or the session's start commit if no checkpoint has been made yet.
+func (s *State) ChainHead() string {
+	if len(s.Chain) == 0 {
+		return s.StartCommit
+	}
+	return s.Chain[len(s.Chain)-1].Hash
+}
+
+// AppendChain adds a checkpoint to the integrity chain and returns the new link.
+func (s *State) AppendChain(commit, tree string) ChainLink {
+	link := ChainLink{
+		Commit: commit,
+		Tree:   tree,
+		Hash:   ChainHash(s.ChainHead(), commit, tree),
+	}
+	s.Chain = append(s.Chain, link)
+	return link
+}
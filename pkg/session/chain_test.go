@@ -0,0 +1,29 @@
+package session
+
+import "testing"
+
+func TestChain(t *testing.T) {
+	state := &State{StartCommit: "start"}
+
+	if head := state.ChainHead(); head != "start" {
+		t.Errorf("Expected empty chain to be headed by the start commit, got %q", head)
+	}
+
+	first := state.AppendChain("c1", "t1")
+	if first.Hash != ChainHash("start", "c1", "t1") {
+		t.Errorf("Expected first link to chain from the start commit, got %q", first.Hash)
+	}
+
+	second := state.AppendChain("c2", "t2")
+	if second.Hash != ChainHash(first.Hash, "c2", "t2") {
+		t.Errorf("Expected second link to chain from the first, got %q", second.Hash)
+	}
+
+	if head := state.ChainHead(); head != second.Hash {
+		t.Errorf("Expected chain head %q, got %q", second.Hash, head)
+	}
+
+	if ChainHash("start", "c1", "t1") == ChainHash("start", "c1", "t2") {
+		t.Error("Expected different trees to produce different hashes")
+	}
+}
@@ -0,0 +1,45 @@
+// Package session persists metadata about gitbak sessions.
+//
+// A session's state is written to a small JSON file under the gitbak data
+// directory when monitoring starts and after each checkpoint. The state
+// outlives the gitbak process, which lets follow-up commands (such as
+// `gitbak abort`) act on a session after it has stopped.
+//
+// # Core Components
+//
+//   - State: The persisted description of a single session
+//   - Load / Save / Remove: Helpers for reading and writing state files
+//   - AppendHistory / LoadHistory: The record of finished sessions
+//
+// # Usage
+//
+//	state := &session.State{
+//	    RepoPath:       "/path/to/repo",
+//	    Branch:         "gitbak-20250101-120000",
+//	    OriginalBranch: "main",
+//	    StartTime:      time.Now(),
+//	}
+//	if err := session.Save(path, state); err != nil {
+//	    // Handle error
+//	}
+//
+//	loaded, err := session.Load(path)
+//	if errors.Is(err, session.ErrNoState) {
+//	    // No session has been recorded for this repository
+//	}
+//
+// # File Format
+//
+// State files are JSON documents written atomically (write to a temporary
+// file, then rename) so that a crash never leaves a partially written file.
+//
+// Each repository has a single state file, which the next session replaces
+// and which abort and squash remove. When a session ends, its state is also
+// appended to history.jsonl in the same directory, one JSON object per line,
+// so that `gitbak sessions` can list past sessions as well.
+//
+// # Thread Safety
+//
+// The functions in this package do not coordinate concurrent writers.
+// Each session is expected to be written by a single gitbak process.
+package session
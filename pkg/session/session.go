@@ -0,0 +1,306 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// ErrNoState indicates that no session state has been recorded at the given path
+var ErrNoState = gitbakErrors.New("no gitbak session state found")
+
+// Ways a session can end, as recorded in State.EndState
+const (
+	// EndStopped is a session stopped by a signal, gitbak stop or the kill switch
+	EndStopped = "stopped"
+
+	// EndFailed is a session that gave up after repeated errors
+	EndFailed = "failed"
+)
+
+// HistoryFileName is the name of the file, next to the state files, that keeps
+// a record of every finished session
+const HistoryFileName = "history.jsonl"
+
+// MaxHistory is the number of finished sessions kept in the history file
+const MaxHistory = 200
+
+// SessionTrailer is the commit trailer that records the SessionID of the session that made
+// a checkpoint, which tells its checkpoints apart whatever their commit prefix.
+const SessionTrailer = "Gitbak-Session"
+
+// State describes a gitbak session as persisted on disk.
+type State struct {
+	// RepoPath is the absolute path of the repository being checkpointed.
+	RepoPath string `json:"repo_path"`
+
+	// Branch is the branch checkpoint commits are made on.
+	Branch string `json:"branch"`
+
+	// OriginalBranch is the branch that was checked out when the session started.
+	OriginalBranch string `json:"original_branch"`
+
+	// CreatedBranch records whether the session created Branch itself.
+	// Sessions run with -no-branch or -continue did not.
+	CreatedBranch bool `json:"created_branch"`
+
+	// Stash records whether checkpoints were stored as stash entries rather than commits on Branch.
+	Stash bool `json:"stash,omitempty"`
+
+	// Observe records whether the session only journaled changes, recording no checkpoints.
+	Observe bool `json:"observe,omitempty"`
+
+	// Refs is the namespace checkpoints were written to as refs/gitbak/<session>/<n>,
+	// rather than committed on Branch, or empty if they were committed.
+	Refs string `json:"refs,omitempty"`
+
+	// StartCommit is the HEAD commit when the session started.
+	StartCommit string `json:"start_commit,omitempty"`
+
+	// StartupCommit is the commit gitbak made on OriginalBranch of the changes left
+	// uncommitted when the session started, or empty if it made none.
+	StartupCommit string `json:"startup_commit,omitempty"`
+
+	// CommitPrefix is the prefix used for checkpoint commit messages.
+	CommitPrefix string `json:"commit_prefix"`
+
+	// CommitEmail is the email checkpoints were attributed to with -author, if any.
+	CommitEmail string `json:"commit_email,omitempty"`
+
+	// SessionID identifies the session in the notes and SessionTrailer recorded with its
+	// checkpoints, and stays the same when the session is continued.
+	SessionID string `json:"session_id,omitempty"`
+
+	// PID is the process ID of the gitbak instance that owns the session.
+	PID int `json:"pid"`
+
+	// StartTime is when the session started.
+	StartTime time.Time `json:"start_time"`
+
+	// LastCommitTime is when the most recent checkpoint was created.
+	LastCommitTime time.Time `json:"last_commit_time,omitempty"`
+
+	// CommitsCount is the checkpoint counter after the most recent checkpoint.
+	CommitsCount int `json:"commits_count"`
+
+	// LastCheckpoint is the commit of the checkpoint numbered CommitsCount, which lets the
+	// session be continued without searching the history for its highest number.
+	LastCheckpoint string `json:"last_checkpoint,omitempty"`
+
+	// IntervalMinutes is how often the session checks for changes.
+	IntervalMinutes float64 `json:"interval_minutes,omitempty"`
+
+	// Watch records whether the session checks on file changes rather than on a fixed interval.
+	Watch bool `json:"watch,omitempty"`
+
+	// ControlAddr is the address of the session's control endpoint, if it serves one,
+	// so that 'gitbak control' can find it without being told.
+	ControlAddr string `json:"control_addr,omitempty"`
+
+	// LastCheckTime is when the session last checked for changes.
+	LastCheckTime time.Time `json:"last_check_time,omitempty"`
+
+	// NextCheckTime is when the session's next scheduled check is due, which checks
+	// aligned to the wall clock make more than an interval after the last one.
+	NextCheckTime time.Time `json:"next_check_time,omitempty"`
+
+	// ConsecutiveErrors is how many checks in a row have failed with LastError, or 0 if the last check succeeded.
+	ConsecutiveErrors int    `json:"consecutive_errors,omitempty"`
+	LastError         string `json:"last_error,omitempty"`
+
+	// StorageStartBytes is the size of the repository's object database when the session started.
+	StorageStartBytes int64 `json:"storage_start_bytes,omitempty"`
+
+	// StorageBytes is the size of the object database after the most recent checkpoint.
+	StorageBytes int64 `json:"storage_bytes,omitempty"`
+
+	// StorageGrowth is how many bytes each checkpoint added to the object database, oldest first.
+	StorageGrowth []int64 `json:"storage_growth,omitempty"`
+
+	// Chain is the integrity hash chain of the session's checkpoints, oldest first.
+	Chain []ChainLink `json:"chain,omitempty"`
+
+	// EndTime is when the session ended. It is zero while the session runs,
+	// and stays zero if the gitbak process was killed before it could record it.
+	EndTime time.Time `json:"end_time,omitempty"`
+
+	// EndState records how the session ended: EndStopped or EndFailed.
+	EndState string `json:"end_state,omitempty"`
+
+	// UpdatedAt is when the state was last written.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Outcome describes where the session stands: "running", how it ended,
+// or "interrupted" if its process exited without recording an end state.
+func (s *State) Outcome(running bool) string {
+	switch {
+	case s.EndState != "":
+		return s.EndState
+	case running:
+		return "running"
+	default:
+		return "interrupted"
+	}
+}
+
+// StorageAdded returns how many bytes the session has added to the object database
+func (s *State) StorageAdded() int64 {
+	return s.StorageBytes - s.StorageStartBytes
+}
+
+// NextCheck returns when the session's next interval check is due.
+// Sessions that have not yet checked count from their start time, and
+// states written before NextCheckTime was recorded from the last check.
+func (s *State) NextCheck() time.Time {
+	if !s.NextCheckTime.IsZero() {
+		return s.NextCheckTime
+	}
+	last := s.LastCheckTime
+	if last.IsZero() {
+		last = s.StartTime
+	}
+	return last.Add(time.Duration(s.IntervalMinutes * float64(time.Minute)))
+}
+
+// Load reads the session state stored at path.
+// Returns ErrNoState if no state file exists.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoState
+		}
+		return nil, gitbakErrors.Wrap(err, "failed to read session state")
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, gitbakErrors.Wrapf(err, "failed to parse session state %s", path)
+	}
+	return &state, nil
+}
+
+// Save atomically writes the session state to path, creating parent directories as needed.
+func Save(path string, state *State) error {
+	state.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to encode session state")
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, creating parent directories as needed
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return gitbakErrors.Wrap(err, "failed to create session state directory")
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to create temporary session state file")
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return gitbakErrors.Wrap(err, "failed to write session state")
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return gitbakErrors.Wrap(err, "failed to write session state")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return gitbakErrors.Wrap(err, "failed to replace session state")
+	}
+	return nil
+}
+
+// Remove deletes the session state stored at path.
+// Removing a state file that does not exist is not an error.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return gitbakErrors.Wrap(err, "failed to remove session state")
+	}
+	return nil
+}
+
+// AppendHistory records a finished session in the history file at path, keeping
+// the most recent MaxHistory sessions. The integrity chain and per-checkpoint
+// storage figures are left out to keep the history small.
+func AppendHistory(path string, state *State) error {
+	entries, err := readHistoryLines(path)
+	if err != nil {
+		return err
+	}
+
+	entry := *state
+	entry.Chain = nil
+	entry.StorageGrowth = nil
+	line, err := json.Marshal(&entry)
+	if err != nil {
+		return gitbakErrors.Wrap(err, "failed to encode session history")
+	}
+
+	entries = append(entries, line)
+	if len(entries) > MaxHistory {
+		entries = entries[len(entries)-MaxHistory:]
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		buf.Write(e)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// LoadHistory reads the finished sessions recorded in the history file at path,
+// oldest first. A missing file means no history; unparsable entries are skipped.
+func LoadHistory(path string) ([]*State, error) {
+	lines, err := readHistoryLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]*State, 0, len(lines))
+	for _, line := range lines {
+		var state State
+		if err := json.Unmarshal(line, &state); err != nil {
+			continue
+		}
+		states = append(states, &state)
+	}
+	return states, nil
+}
+
+// readHistoryLines returns the non-empty lines of the history file
+func readHistoryLines(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, gitbakErrors.Wrap(err, "failed to read session history")
+	}
+
+	var lines [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,213 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	state := &State{
+		RepoPath:       "/tmp/repo",
+		Branch:         "gitbak-test",
+		OriginalBranch: "main",
+		CreatedBranch:  true,
+		StartCommit:    "abc123",
+		CommitPrefix:   "[gitbak]",
+		PID:            4242,
+		StartTime:      start,
+		CommitsCount:   3,
+	}
+
+	if err := Save(path, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if state.UpdatedAt.IsZero() {
+		t.Error("Expected Save to stamp UpdatedAt")
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if loaded.Branch != "gitbak-test" || loaded.OriginalBranch != "main" || !loaded.CreatedBranch {
+		t.Errorf("Loaded state does not match saved state: %+v", loaded)
+	}
+	if loaded.CommitsCount != 3 || loaded.PID != 4242 || loaded.StartCommit != "abc123" {
+		t.Errorf("Loaded state does not match saved state: %+v", loaded)
+	}
+	if !loaded.StartTime.Equal(start) {
+		t.Errorf("Expected StartTime %v, got %v", start, loaded.StartTime)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Failed to read state dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file to remain after Save, found %d entries", len(entries))
+	}
+}
+
+func TestLoadScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		setupFunc func(t *testing.T) string
+		expectErr error
+	}{
+		"MissingFile": {
+			setupFunc: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "missing.json")
+			},
+			expectErr: ErrNoState,
+		},
+		"CorruptFile": {
+			setupFunc: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "corrupt.json")
+				if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+					t.Fatalf("Failed to write corrupt state: %v", err)
+				}
+				return path
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(test.setupFunc(t))
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if test.expectErr != nil && !gitbakErrors.Is(err, test.expectErr) {
+				t.Errorf("Expected %v, got %v", test.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := Save(path, &State{RepoPath: "/tmp/repo"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected state file to be removed, stat returned: %v", err)
+	}
+
+	if err := Remove(path); err != nil {
+		t.Errorf("Expected removing a missing state file to succeed, got: %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+
+	history, err := LoadHistory(path)
+	if err != nil || len(history) != 0 {
+		t.Fatalf("Expected no history before the first session, got %v (err %v)", history, err)
+	}
+
+	for i, branch := range []string{"gitbak-1", "gitbak-2"} {
+		state := &State{
+			Branch:        branch,
+			CommitsCount:  i + 1,
+			EndState:      EndStopped,
+			Chain:         []ChainLink{{Commit: "abc", Tree: "def", Hash: "123"}},
+			StorageGrowth: []int64{1024},
+		}
+		if err := AppendHistory(path, state); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+
+	// A damaged entry must not hide the others
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open history: %v", err)
+	}
+	_, _ = f.WriteString("{broken\n")
+	_ = f.Close()
+
+	history, err = LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Branch != "gitbak-1" || history[1].Branch != "gitbak-2" {
+		t.Fatalf("Expected both sessions oldest first, got %+v", history)
+	}
+	if history[1].Chain != nil || history[1].StorageGrowth != nil {
+		t.Errorf("Expected the chain and storage growth to be left out of the history, got %+v", history[1])
+	}
+	if history[1].EndState != EndStopped {
+		t.Errorf("Expected end state %q, got %q", EndStopped, history[1].EndState)
+	}
+}
+
+func TestHistoryTrimmed(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	for i := 0; i < MaxHistory+5; i++ {
+		if err := AppendHistory(path, &State{CommitsCount: i}); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(history) != MaxHistory {
+		t.Fatalf("Expected %d sessions, got %d", MaxHistory, len(history))
+	}
+	if history[0].CommitsCount != 5 {
+		t.Errorf("Expected the oldest sessions to be dropped first, got %d as the oldest", history[0].CommitsCount)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		state    State
+		running  bool
+		expected string
+	}{
+		"Running":     {running: true, expected: "running"},
+		"Stopped":     {state: State{EndState: EndStopped}, expected: "stopped"},
+		"Failed":      {state: State{EndState: EndFailed}, expected: "failed"},
+		"Interrupted": {expected: "interrupted"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.state.Outcome(test.running); got != test.expected {
+				t.Errorf("Expected outcome %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// Package tracing records OpenTelemetry spans for gitbak's checks and git
+// commands and exports them to an OTLP collector, to show which git calls are
+// slow on large repositories.
+//
+// A Tracer is handed to the monitoring loop, which opens a span for every check,
+// and to the command executor, which opens a child span for every git command:
+//
+//	tracer := tracing.New("http://localhost:4318", version, onError)
+//	defer tracer.Shutdown(ctx)
+//
+//	ctx, span := tracer.Start(ctx, "gitbak.check")
+//	err := check(ctx)
+//	span.End(err)
+//
+// Finished spans are exported in batches over OTLP/HTTP, encoded as JSON, in the
+// background and once more on Shutdown. A nil *Tracer records nothing, so callers
+// need not check whether tracing is enabled.
+//
+// The protocol is written directly rather than through the OpenTelemetry SDK,
+// which would be a large dependency for a few kinds of spans.
+package tracing
@@ -0,0 +1,386 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tracesPath is where an OTLP/HTTP collector accepts spans, relative to its base URL
+	tracesPath = "/v1/traces"
+
+	// scopeName identifies gitbak as the instrumentation that recorded the spans
+	scopeName = "github.com/bashhack/gitbak"
+
+	// exportInterval is how often finished spans are sent to the collector
+	exportInterval = 5 * time.Second
+
+	// exportTimeout bounds a single export, so an unresponsive collector cannot hold up Shutdown
+	exportTimeout = 10 * time.Second
+
+	// maxBatch is the number of finished spans that triggers an export before the interval is up
+	maxBatch = 512
+
+	// maxPending bounds the spans kept while the collector is unreachable; later ones are dropped
+	maxPending = 8 * maxBatch
+)
+
+// Span kinds and status codes of the OTLP protocol
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+// Tracer records spans and exports them to an OTLP/HTTP collector.
+// It is safe for concurrent use, and a nil *Tracer records nothing.
+type Tracer struct {
+	url     string
+	version string
+	client  *http.Client
+	onError func(error)
+
+	mu      sync.Mutex
+	pending []*Span
+	dropped int
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a Tracer exporting to the OTLP/HTTP collector at endpoint, either its
+// base URL (such as http://localhost:4318) or the full URL of its traces endpoint.
+// version is reported as the service version. Failed exports are passed to onError,
+// if set; their spans are dropped rather than retried.
+func New(endpoint, version string, onError func(error)) *Tracer {
+	t := &Tracer{
+		url:     exportURL(endpoint),
+		version: version,
+		client:  &http.Client{Timeout: exportTimeout},
+		onError: onError,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// exportURL returns the URL spans are posted to for endpoint
+func exportURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if strings.HasSuffix(endpoint, tracesPath) {
+		return endpoint
+	}
+	return endpoint + tracesPath
+}
+
+// Start opens a span named name, as a child of the span in ctx if there is one,
+// and returns a context carrying the new span
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Shutdown stops the background export and sends the spans that are still pending.
+// It returns the error of that last export, if any.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.export(ctx)
+}
+
+// run exports finished spans every exportInterval, or sooner once a batch is full
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.wake:
+		case <-t.stop:
+			return
+		}
+		if err := t.export(context.Background()); err != nil && t.onError != nil {
+			t.onError(err)
+		}
+	}
+}
+
+// finish queues an ended span for export
+func (t *Tracer) finish(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pending) >= maxPending {
+		t.dropped++
+		return
+	}
+	t.pending = append(t.pending, span)
+	if len(t.pending) >= maxBatch {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// export sends all pending spans to the collector, in batches of at most maxBatch
+func (t *Tracer) export(ctx context.Context) error {
+	t.mu.Lock()
+	spans, dropped := t.pending, t.dropped
+	t.pending, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	var firstErr error
+	if dropped > 0 {
+		firstErr = fmt.Errorf("dropped %d spans while the OTLP collector at %s was unreachable", dropped, t.url)
+	}
+	for len(spans) > 0 {
+		batch := spans[:min(len(spans), maxBatch)]
+		spans = spans[len(batch):]
+		if err := t.post(ctx, batch); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to export %d spans to %s: %w", len(batch), t.url, err)
+		}
+	}
+	return firstErr
+}
+
+// post sends one batch of spans to the collector
+func (t *Tracer) post(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// request builds the OTLP export request for spans
+func (t *Tracer) request(spans []*Span) exportRequest {
+	data := make([]spanData, 0, len(spans))
+	for _, span := range spans {
+		data = append(data, span.data())
+	}
+
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{
+			String("service.name", "gitbak").keyValue(),
+			String("service.version", t.version).keyValue(),
+		}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: scopeName, Version: t.version},
+			Spans: data,
+		}},
+	}}}
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// Span is an operation being traced. A nil *Span ignores all calls, so the
+// spans of a nil Tracer need no checks either.
+type Span struct {
+	tracer   *Tracer
+	name     string
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	err      error
+	ended    bool
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil || s.ended {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End closes the span and queues it for export. A non-nil err marks the span as failed.
+func (s *Span) End(err error) {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.err = err
+	s.tracer.finish(s)
+}
+
+// data encodes the span for the export request
+func (s *Span) data() spanData {
+	data := spanData{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parentID != [8]byte{} {
+		data.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for _, attr := range s.attrs {
+		data.Attributes = append(data.Attributes, attr.keyValue())
+	}
+	if s.err != nil {
+		data.Status = status{Code: statusCodeError, Message: s.err.Error()}
+	}
+	return data
+}
+
+// Attribute is a key-value pair describing a span
+type Attribute struct {
+	Key   string
+	value anyValue
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, value: anyValue{StringValue: &value}}
+}
+
+// Strings returns a string array attribute
+func Strings(key string, values []string) Attribute {
+	array := &arrayValue{Values: make([]anyValue, 0, len(values))}
+	for _, value := range values {
+		array.Values = append(array.Values, String("", value).value)
+	}
+	return Attribute{Key: key, value: anyValue{ArrayValue: array}}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	v := strconv.Itoa(value)
+	return Attribute{Key: key, value: anyValue{IntValue: &v}}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, value: anyValue{BoolValue: &value}}
+}
+
+// keyValue encodes the attribute for the export request
+func (a Attribute) keyValue() keyValue {
+	return keyValue{Key: a.Key, Value: a.value}
+}
+
+// The OTLP/JSON encoding of an export request. Integers are strings, as in the
+// protobuf JSON mapping, and trace and span IDs are hex rather than base64.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanData `json:"spans"`
+	}
+
+	scope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+
+	spanData struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+
+	status struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+
+	anyValue struct {
+		StringValue *string     `json:"stringValue,omitempty"`
+		BoolValue   *bool       `json:"boolValue,omitempty"`
+		IntValue    *string     `json:"intValue,omitempty"`
+		ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+	}
+
+	arrayValue struct {
+		Values []anyValue `json:"values"`
+	}
+)
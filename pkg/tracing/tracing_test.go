@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// collector is a fake OTLP/HTTP collector recording the requests it receives
+type collector struct {
+	mu       sync.Mutex
+	requests []exportRequest
+	paths    []string
+	status   int
+}
+
+// ServeHTTP implements http.Handler
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, req)
+	c.paths = append(c.paths, r.URL.Path)
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+}
+
+// spans returns the spans of all received requests
+func (c *collector) spans() []spanData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var spans []spanData
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+// attribute returns the encoded value of the span's attribute key
+func attribute(span spanData, key string) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			out, _ := json.Marshal(kv.Value)
+			return string(out)
+		}
+	}
+	return ""
+}
+
+// TestTracerExport tests that spans reach the collector with their parents, attributes and status
+func TestTracerExport(t *testing.T) {
+	t.Parallel()
+
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	tracer := New(server.URL, "1.2.3", nil)
+	ctx, check := tracer.Start(context.Background(), "gitbak.check", Int("gitbak.checkpoint_number", 4))
+	_, status := tracer.Start(ctx, "git status", Strings("process.command_args", []string{"status", "--porcelain"}))
+	status.SetAttributes(Int("process.exit_code", 0))
+	status.End(nil)
+	_, commit := tracer.Start(ctx, "git commit")
+	commit.End(errors.New("exit status 1"))
+	check.SetAttributes(Bool("gitbak.checkpoint", false))
+	check.End(nil)
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if len(c.paths) != 1 || c.paths[0] != "/v1/traces" {
+		t.Fatalf("Expected one export to /v1/traces, got %v", c.paths)
+	}
+	resource := c.requests[0].ResourceSpans[0].Resource
+	if len(resource.Attributes) < 2 || *resource.Attributes[0].Value.StringValue != "gitbak" || *resource.Attributes[1].Value.StringValue != "1.2.3" {
+		t.Errorf("Expected the gitbak service and its version as the resource, got %+v", resource)
+	}
+
+	spans := c.spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	statusSpan, commitSpan, checkSpan := spans[0], spans[1], spans[2]
+
+	if checkSpan.ParentSpanID != "" || statusSpan.ParentSpanID != checkSpan.SpanID || commitSpan.ParentSpanID != checkSpan.SpanID {
+		t.Errorf("Expected the git spans to be children of the check span, got %+v", spans)
+	}
+	if statusSpan.TraceID != checkSpan.TraceID || len(checkSpan.TraceID) != 32 || len(checkSpan.SpanID) != 16 {
+		t.Errorf("Expected one trace with hex IDs, got %+v", spans)
+	}
+	if got := attribute(checkSpan, "gitbak.checkpoint_number"); got != `{"intValue":"4"}` {
+		t.Errorf("Expected the checkpoint number attribute, got %s", got)
+	}
+	if got := attribute(checkSpan, "gitbak.checkpoint"); got != `{"boolValue":false}` {
+		t.Errorf("Expected the checkpoint attribute, got %s", got)
+	}
+	if got := attribute(statusSpan, "process.command_args"); got != `{"arrayValue":{"values":[{"stringValue":"status"},{"stringValue":"--porcelain"}]}}` {
+		t.Errorf("Expected the command arguments, got %s", got)
+	}
+	if commitSpan.Status.Code != statusCodeError || commitSpan.Status.Message != "exit status 1" || statusSpan.Status.Code != 0 {
+		t.Errorf("Expected only the failed command to have an error status, got %+v and %+v", commitSpan.Status, statusSpan.Status)
+	}
+	if statusSpan.StartTimeUnixNano == "" || statusSpan.EndTimeUnixNano < statusSpan.StartTimeUnixNano {
+		t.Errorf("Expected the span to have its start and end time, got %+v", statusSpan)
+	}
+}
+
+// TestTracerExportFailure tests that a failing collector is reported without blocking spans
+func TestTracerExportFailure(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&collector{status: http.StatusServiceUnavailable})
+	defer server.Close()
+
+	tracer := New(server.URL+"/v1/traces/", "dev", nil)
+	_, span := tracer.Start(context.Background(), "gitbak.check")
+	span.End(nil)
+
+	err := tracer.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected the collector's status in the error, got %v", err)
+	}
+}
+
+// TestNilTracer tests that a nil Tracer and its spans do nothing
+func TestNilTracer(t *testing.T) {
+	t.Parallel()
+
+	var tracer *Tracer
+	ctx := context.Background()
+	spanCtx, span := tracer.Start(ctx, "gitbak.check")
+	if span != nil || spanCtx != ctx {
+		t.Errorf("Expected no span and the same context, got %v", span)
+	}
+	span.SetAttributes(Bool("gitbak.checkpoint", true))
+	span.End(nil)
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Errorf("Expected Shutdown of a nil Tracer to succeed, got %v", err)
+	}
+}
+
+// TestExportURL tests where spans are posted for an endpoint
+func TestExportURL(t *testing.T) {
+	tests := map[string]struct {
+		endpoint string
+		expected string
+	}{
+		"Base":          {endpoint: "http://localhost:4318", expected: "http://localhost:4318/v1/traces"},
+		"TrailingSlash": {endpoint: "http://localhost:4318/", expected: "http://localhost:4318/v1/traces"},
+		"Traces":        {endpoint: "https://otel.example.com/v1/traces", expected: "https://otel.example.com/v1/traces"},
+		"Prefix":        {endpoint: "https://example.com/otlp", expected: "https://example.com/otlp/v1/traces"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := exportURL(test.endpoint); got != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package watch reports file system changes in a repository's working tree.
+//
+// gitbak normally polls the repository with git status at a fixed interval.
+// In watch mode it instead waits for the operating system to report that files
+// changed, so checkpoints follow edits closely and idle repositories are not
+// polled at all.
+//
+// # Core Components
+//
+//   - Watcher: Watches a working tree recursively and signals when something changed
+//
+// # Behavior
+//
+// Every directory of the working tree except .git is watched; directories created
+// later are added as they appear. Change notifications are coalesced: Changes
+// delivers at most one pending signal, however many files changed, so consumers
+// only learn that a check is worthwhile, not which files to look at.
+//
+// If the watcher loses events (for example because the kernel queue overflowed),
+// it signals a change so that the consumer checks rather than missing an edit.
+//
+// # Fallback
+//
+// New returns an error when watching is unavailable, such as on platforms without
+// a supported notification API or when the system's watch limit is exhausted.
+// Callers are expected to fall back to polling in that case.
+//
+// # Usage
+//
+//	w, err := watch.New("/path/to/repo")
+//	if err != nil {
+//	    // Fall back to polling
+//	}
+//	defer w.Close()
+//
+//	for range w.Changes() {
+//	    // Check the repository for changes
+//	}
+package watch
@@ -0,0 +1,151 @@
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+
+	gitbakErrors "github.com/bashhack/gitbak/pkg/errors"
+)
+
+// Watcher watches a working tree and signals when files in it change
+type Watcher struct {
+	root    string
+	exclude []string
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New starts watching the working tree at root, leaving out its .git directory and the
+// directories in exclude, such as a git directory kept elsewhere in the tree.
+// It returns an error if file system notifications are not available.
+func New(root string, exclude ...string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, gitbakErrors.Wrap(err, "file system notifications are not available")
+	}
+
+	w := &Watcher{
+		root:    root,
+		exclude: exclude,
+		watcher: fw,
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := w.addTree(root); err != nil {
+		_ = fw.Close()
+		return nil, gitbakErrors.Wrapf(err, "failed to watch %s", root)
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns a channel that receives a value when files have changed since
+// the last receive. Signals are coalesced, so at most one is ever pending.
+// The channel is closed when the watcher is closed.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching. It is safe to call more than once.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+// run forwards file system events as coalesced change signals until the watcher is closed
+func (w *Watcher) run() {
+	defer close(w.changes)
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.excluded(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				// Watch new directories (and anything already inside them)
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.addTree(event.Name)
+				}
+			}
+			w.signal()
+
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been lost, so err on the side of checking
+			w.signal()
+		}
+	}
+}
+
+// signal queues a change signal unless one is already pending
+func (w *Watcher) signal() {
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+// addTree watches dir and all directories below it, except the excluded ones
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can disappear while they are being walked
+			if path != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" || w.excluded(path) {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// excluded reports whether path is in the repository's .git directory or one of the
+// excluded directories
+func (w *Watcher) excluded(path string) bool {
+	if isGitDir(w.root, path) {
+		return true
+	}
+	for _, dir := range w.exclude {
+		if rel, err := filepath.Rel(dir, path); dir != "" && err == nil && filepath.IsLocal(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGitDir reports whether path is the repository's .git directory or inside it
+func isGitDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator))
+}
@@ -0,0 +1,167 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForChange reports whether a change signal arrives within the timeout
+func waitForChange(w *Watcher, timeout time.Duration) bool {
+	select {
+	case _, ok := <-w.Changes():
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// TestWatcherScenarios tests which file system activity is reported as a change
+func TestWatcherScenarios(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		change       func(t *testing.T, root string)
+		expectChange bool
+	}{
+		"FileWritten": {
+			change: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, "file.txt"), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			expectChange: true,
+		},
+		"FileInExistingSubdirectory": {
+			change: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, "sub", "nested.txt"), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			expectChange: true,
+		},
+		"FileInNewSubdirectory": {
+			change: func(t *testing.T, root string) {
+				dir := filepath.Join(root, "new")
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				// Let the watcher pick up the new directory before writing into it
+				time.Sleep(100 * time.Millisecond)
+				if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			expectChange: true,
+		},
+		"GitDirectoryIgnored": {
+			change: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, ".git", "index"), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			expectChange: false,
+		},
+		"ExcludedDirectoryIgnored": {
+			change: func(t *testing.T, root string) {
+				if err := os.WriteFile(filepath.Join(root, ".dotfiles", "index"), []byte("changed"), 0644); err != nil {
+					t.Fatalf("Failed to write file: %v", err)
+				}
+			},
+			expectChange: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			for _, dir := range []string{".git", "sub", ".dotfiles"} {
+				if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+					t.Fatalf("Failed to create %s: %v", dir, err)
+				}
+			}
+
+			w, err := New(root, filepath.Join(root, ".dotfiles"))
+			if err != nil {
+				t.Skipf("File watching unavailable: %v", err)
+			}
+			defer func() { _ = w.Close() }()
+
+			test.change(t, root)
+			if test.expectChange {
+				if !waitForChange(w, 5*time.Second) {
+					t.Error("Expected a change to be reported")
+				}
+				return
+			}
+			if waitForChange(w, 300*time.Millisecond) {
+				t.Error("Expected no change to be reported")
+			}
+		})
+	}
+}
+
+// TestWatcherCoalescesChanges tests that many changes leave at most one pending signal
+func TestWatcherCoalescesChanges(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	w, err := New(root)
+	if err != nil {
+		t.Skipf("File watching unavailable: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(root, "file.txt"), []byte{byte(i)}, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if got := len(w.Changes()); got != 1 {
+		t.Errorf("Expected 1 pending change signal, got %d", got)
+	}
+}
+
+// TestWatcherClose tests that closing the watcher closes the Changes channel
+func TestWatcherClose(t *testing.T) {
+	t.Parallel()
+
+	w, err := New(t.TempDir())
+	if err != nil {
+		t.Skipf("File watching unavailable: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Expected a second Close to succeed, got: %v", err)
+	}
+
+	select {
+	case _, ok := <-w.Changes():
+		if ok {
+			// A signal may have been pending; the channel must close after it
+			if _, ok := <-w.Changes(); ok {
+				t.Error("Expected Changes to be closed")
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected Changes to be closed after Close")
+	}
+}
+
+// TestNewMissingDirectory tests that watching a missing directory fails
+func TestNewMissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}